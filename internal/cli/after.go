@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// usesAfter reports whether a command of the config runs after other commands
+func (h *CommandHandler) usesAfter() bool {
+	if h.Config == nil {
+		return false
+	}
+	for _, c := range flattenCommands(h.Config) {
+		if len(c.Command.After) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduledCommands returns the commands a run of cmdName executes: the commands of
+// its plan whose condition is met and the commands their tasks reference
+func (h *CommandHandler) scheduledCommands(cmdName string, cmdVars map[string]string) map[string]bool {
+	scheduled := make(map[string]bool)
+	var add func(name string)
+	add = func(name string) {
+		// Invalid plans, e.g. with cycles, fail when the run executes them
+		steps, err := h.planSteps(name, cmdVars, nil, nil)
+		if err != nil {
+			return
+		}
+		for _, step := range steps {
+			if step.Duplicate || !step.ConditionMet || scheduled[step.Name] {
+				continue
+			}
+			scheduled[step.Name] = true
+			for _, task := range step.Tasks {
+				if task.Ref != "" && task.ConditionMet && !scheduled[task.Ref] {
+					add(task.Ref)
+				}
+			}
+		}
+	}
+	add(cmdName)
+	return scheduled
+}
+
+// executeAfter executes the commands a command runs after that the run executes
+// anyway and that did not run yet. Their failures do not stop the command; they
+// are reported where the run schedules them.
+func (h *CommandHandler) executeAfter(cmdName string, cmd config.Command, cmdVars map[string]string) {
+	run := h.RunContext()
+	for _, name := range cmd.After {
+		if !run.isScheduled(name) {
+			continue
+		}
+		if run.Executed(name) {
+			// Wait for it if another branch of the run is executing it
+			_, _ = run.wait(cmdName, name)
+			continue
+		}
+		run.setEarlyResult(name, h.executeCommand(cmdName, name, cmdVars))
+	}
+}
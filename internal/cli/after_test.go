@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_After(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"build":  {Run: "build"},
+			"deploy": {Run: "deploy", After: []string{"build"}},
+			"ci": {
+				Run:     "ci",
+				Depends: config.NewDependencyList("deploy", "build"),
+			},
+			"ci-tasks": {
+				Tasks: config.TaskList{{Task: "deploy"}, {Task: "build"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		results      map[string]error
+		wantExecuted []string
+		wantErr      string
+	}{
+		{
+			name:         "runs after a scheduled command",
+			command:      "ci",
+			wantExecuted: []string{"build", "deploy", "ci"},
+		},
+		{
+			name:         "does not run commands that are not scheduled",
+			command:      "deploy",
+			wantExecuted: []string{"deploy"},
+		},
+		{
+			name:         "commands referenced by tasks are scheduled",
+			command:      "ci-tasks",
+			wantExecuted: []string{"build", "deploy"},
+		},
+		{
+			name:         "does not require success",
+			command:      "ci",
+			results:      map[string]error{"build": errors.New("build failed")},
+			wantExecuted: []string{"build", "deploy"},
+			wantErr:      "failed to execute dependency 'build'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard, commandResults: tt.results}}
+			handler := NewCommandHandler(cfg, exec)
+
+			err := handler.ExecuteCommand(tt.command, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantExecuted, exec.executed)
+		})
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+
+		require.NoError(t, handler.ExecuteCommand("ci", nil))
+		build := strings.Index(out.String(), "Would execute: build")
+		deploy := strings.Index(out.String(), "Would execute: deploy")
+		require.NotEqual(t, -1, build)
+		assert.Less(t, build, deploy)
+	})
+}
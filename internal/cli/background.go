@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// backgroundDirName is the directory below the state directory in which yxa keeps
+// the pid files and logs of commands started with 'yxa start'
+const backgroundDirName = "run"
+
+// newStartCommand creates the built-in 'start' command, which runs a command in the
+// background
+func (r *RootCommand) newStartCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "start <command> [-- args...]",
+		Short: "Run a command in the background",
+		Long: `Run a command in the background, detached from the terminal, like a dev server
+or a watcher that should not block the shell. The command runs as a new yxa
+process with the global flags of this invocation and its output goes to
+.yxa/run/logs/<command>.log. Use 'yxa logs -f' to follow it, 'yxa status' to see
+whether it is still running and 'yxa stop' to stop it.
+
+Put the parameters of the command after --, so that they are not taken as flags of
+yxa:
+
+  yxa start build-watch
+  yxa start serve -- --port 3000`,
+		Args:              cobra.MinimumNArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: r.completeCommandNames(1, nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.startBackground(cmd, args)
+		},
+	}
+}
+
+// newStopCommand creates the built-in 'stop' command, which stops commands started
+// with 'yxa start'
+func (r *RootCommand) newStopCommand() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "stop [command...]",
+		Short: "Stop commands started with yxa start",
+		Long: `Stop the given commands, or every command started with 'yxa start'. A command
+that does not exit within --timeout after SIGTERM is killed.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.stopBackground(cmd.OutOrStdout(), args, timeout)
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultStopTimeout, "Time to wait for a command to exit before killing it")
+
+	return cmd
+}
+
+// backgroundManager returns the manager of the commands started with 'yxa start'
+func (r *RootCommand) backgroundManager() *services.Manager {
+	base := r.Config.ConfigDir()
+	if base == "" {
+		base = "."
+	}
+	return services.NewManager(filepath.Join(base, stateDirName, backgroundDirName))
+}
+
+// startBackground starts the command named by args[0] in the background with the
+// remaining arguments
+func (r *RootCommand) startBackground(cmd *cobra.Command, args []string) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	name := args[0]
+	if _, err := r.Handler.lookupCommand(name); err != nil {
+		return err
+	}
+
+	argv, err := r.backgroundArgv(cmd, name, args[1:])
+	if err != nil {
+		return err
+	}
+	// The invocation is shown and kept in the state file, which must not reveal secrets
+	shown := append(r.maskArgs("", argv[:len(argv)-len(args)+1]), r.maskArgs(name, args[1:])...)
+	out := cmd.OutOrStdout()
+	if r.DryRun {
+		fmt.Fprintf(out, "[dry-run] Would start '%s' in the background: %s\n", name, strings.Join(shown, " "))
+		return nil
+	}
+	manager := r.backgroundManager()
+	if status, err := manager.Status(name); err != nil {
+		return err
+	} else if status.Running {
+		return fmt.Errorf("'%s' is already running in the background with pid %d, stop it with yxa stop %s", name, status.PID, name)
+	}
+	state, err := manager.StartArgs(name, argv, strings.Join(shown, " "))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Started '%s' in the background (pid %d), logs in %s\n", name, state.PID, state.Log)
+	return nil
+}
+
+// backgroundArgv returns the yxa invocation that runs a command in the background:
+// the global flags of this invocation, which cannot ask questions without a
+// terminal, and the words of the command followed by its arguments
+func (r *RootCommand) backgroundArgv(cmd *cobra.Command, name string, args []string) ([]string, error) {
+	bin, err := selfExecutable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the yxa binary: %w", err)
+	}
+	_, globals := r.invocationArgs(cmd, nil)
+
+	argv := []string{bin}
+	for _, flag := range globals {
+		// Dry runs are handled here and never start a process, which always runs
+		// non-interactive
+		if !strings.HasPrefix(flag, "--dry-run=") && !strings.HasPrefix(flag, "--non-interactive=") {
+			argv = append(argv, flag)
+		}
+	}
+	argv = append(argv, "--non-interactive")
+	argv = append(argv, strings.Split(name, ":")...)
+	return append(argv, args...), nil
+}
+
+// stopBackground stops the given commands, or all commands started with 'yxa start'
+// if none are given
+func (r *RootCommand) stopBackground(out io.Writer, names []string, timeout time.Duration) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	manager := r.backgroundManager()
+
+	if len(names) == 0 {
+		statuses, err := manager.List()
+		if err != nil {
+			return err
+		}
+		if len(statuses) == 0 {
+			_, err := fmt.Fprintln(out, "No commands running in the background")
+			return err
+		}
+		for _, status := range statuses {
+			names = append(names, status.Name)
+		}
+	}
+
+	for _, name := range names {
+		status, err := manager.Status(name)
+		if err != nil {
+			return err
+		}
+		if !status.Known {
+			fmt.Fprintf(out, "'%s' is not running in the background\n", name)
+			continue
+		}
+		if r.DryRun {
+			fmt.Fprintf(out, "[dry-run] Would stop '%s' (pid %d)\n", name, status.PID)
+			continue
+		}
+		if err := manager.Stop(name, timeout); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Stopped '%s'\n", name)
+	}
+	return nil
+}
+
+// backgroundStatus writes a table with the status of every command started with
+// 'yxa start' below that of the services, and nothing if there are none
+func (r *RootCommand) backgroundStatus(out io.Writer) error {
+	statuses, err := r.backgroundManager().List()
+	if err != nil || len(statuses) == 0 {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "\nBACKGROUND\tSTATUS\tPID\tSTARTED"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	for _, status := range statuses {
+		state := "exited"
+		if status.Running {
+			state = "running"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", status.Name, state, status.PID, status.StartedAt.Local().Format(time.DateTime)); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return w.Flush()
+}
+
+// backgroundLogs writes the log of a command started with 'yxa start' to out, and
+// reports whether there is one. With follow, it keeps writing new output until ctx
+// is cancelled.
+func (r *RootCommand) backgroundLogs(ctx context.Context, out io.Writer, name string, follow bool) (bool, error) {
+	status, err := r.backgroundManager().Status(name)
+	if err != nil || !status.Known {
+		return false, err
+	}
+	f, err := os.Open(status.Log) // #nosec G304 -- path comes from the state file yxa wrote
+	if err != nil {
+		return true, fmt.Errorf("failed to open logs of '%s': %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return true, followFile(ctx, out, f, follow)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundCommands(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// The background process is a fake binary that prints its arguments and waits
+	script := filepath.Join(dir, "fake-yxa")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\"\nexec sleep 30\n"), 0o755))
+	origSelf := selfExecutable
+	defer func() { selfExecutable = origSelf }()
+	selfExecutable = func() (string, error) { return script, nil }
+
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"watch": {Run: "watch"},
+			"db":    {Commands: map[string]config.Command{"seed": {Run: "seed"}}},
+		},
+	}
+	out := &bytes.Buffer{}
+	root := NewRootCommand(cfg, executor.NewDefaultExecutor())
+	root.registerCommands()
+	root.RootCmd.SetOut(out)
+	root.RootCmd.SetErr(out)
+	t.Cleanup(func() { _ = root.stopBackground(&bytes.Buffer{}, nil, time.Second) })
+
+	run := func(args ...string) error {
+		out.Reset()
+		root.RootCmd.SetArgs(args)
+		return root.RootCmd.Execute()
+	}
+
+	require.NoError(t, run("start", "--set", "MODE=dev", "watch", "--", "--fast"))
+	assert.Contains(t, out.String(), "Started 'watch' in the background")
+	state, err := os.ReadFile(filepath.Join(".yxa", "run", "services", "watch.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(state), "--set=MODE=*** --non-interactive watch --fast")
+	assert.NotContains(t, string(state), "MODE=dev")
+
+	var logs bytes.Buffer
+	require.Eventually(t, func() bool {
+		logs.Reset()
+		return root.showLogs(context.Background(), &logs, "watch", false, false) == nil && logs.Len() > 0
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "--set=MODE=dev --non-interactive watch --fast\n", logs.String())
+
+	require.NoError(t, run("start", "db:seed"))
+	err = run("start", "watch")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'watch' is already running in the background")
+	err = run("start", "deploy")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deploy")
+
+	require.NoError(t, run("status"))
+	assert.Contains(t, out.String(), "No services defined\n")
+	assert.Regexp(t, `db:seed\s+running\s+\d+`, out.String())
+	assert.Regexp(t, `watch\s+running\s+\d+`, out.String())
+
+	require.NoError(t, run("stop", "watch", "build"))
+	assert.Equal(t, "Stopped 'watch'\n'build' is not running in the background\n", out.String())
+	require.NoError(t, run("stop"))
+	assert.Equal(t, "Stopped 'db:seed'\n", out.String())
+	require.NoError(t, run("stop"))
+	assert.Equal(t, "No commands running in the background\n", out.String())
+
+	require.NoError(t, run("status"))
+	assert.False(t, strings.Contains(out.String(), "BACKGROUND"), out.String())
+}
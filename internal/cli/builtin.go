@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// builtinAnnotation marks commands that are provided by yxa itself rather than by the config
+const builtinAnnotation = "yxa:builtin"
+
+// isBuiltinCommand reports whether a cobra command is provided by yxa itself
+func isBuiltinCommand(cmd *cobra.Command) bool {
+	_, ok := cmd.Annotations[builtinAnnotation]
+	return ok
+}
+
+// registerBuiltinCommands adds the built-in commands that are not already registered
+// and not shadowed by a user-defined command with the same name
+func (r *RootCommand) registerBuiltinCommands() {
+	for _, builtin := range r.builtinCmds {
+		if builtin.Annotations == nil {
+			builtin.Annotations = make(map[string]string)
+		}
+		builtin.Annotations[builtinAnnotation] = "true"
+
+		if findCommandByName(r.RootCmd, builtin.Name()) != nil {
+			continue
+		}
+		r.RootCmd.AddCommand(builtin)
+	}
+}
+
+// removeShadowedBuiltin removes a built-in command that has the same name as a
+// user-defined command, so that the user's command takes precedence
+func (r *RootCommand) removeShadowedBuiltin(name string) {
+	if cmd := findCommandByName(r.RootCmd, name); cmd != nil && isBuiltinCommand(cmd) {
+		r.RootCmd.RemoveCommand(cmd)
+	}
+}
+
+// findCommandByName returns the direct child command with the given name, or nil
+func findCommandByName(parent *cobra.Command, name string) *cobra.Command {
+	for _, cmd := range parent.Commands() {
+		if cmd.Name() == name {
+			return cmd
+		}
+	}
+	return nil
+}
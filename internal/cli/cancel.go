@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
+)
+
+// cancelHookTimeout limits how long an on_cancel hook may run, so that a hanging
+// cleanup cannot keep yxa from exiting after Ctrl-C
+const cancelHookTimeout = 30 * time.Second
+
+// execute runs a shell command as part of the current run, stopping it when the run
+// is cancelled if the executor supports cancellation
+func (h *CommandHandler) execute(cmdStr string, timeout time.Duration) error {
+	return executeContext(h.Executor, h.RunContext().Context, cmdStr, timeout)
+}
+
+// executeContext runs a shell command with exec, stopping it when ctx is cancelled
+// if exec supports cancellation
+func executeContext(exec executor.CommandExecutor, ctx context.Context, cmdStr string, timeout time.Duration) error {
+	if ctxExec, ok := exec.(executor.ContextExecutor); ok {
+		return ctxExec.ExecuteContext(ctx, cmdStr, timeout)
+	}
+	return exec.Execute(cmdStr, timeout)
+}
+
+// executeWithOutput runs a shell command as part of the current run and returns its
+// output, stopping it when the run is cancelled if the executor supports cancellation
+func (h *CommandHandler) executeWithOutput(cmdStr string, timeout time.Duration) (string, error) {
+	return executeWithOutputContext(h.Executor, h.RunContext().Context, cmdStr, timeout)
+}
+
+// executeWithOutputContext runs a shell command with exec and returns its output,
+// stopping it when ctx is cancelled if exec supports cancellation
+func executeWithOutputContext(exec executor.CommandExecutor, ctx context.Context, cmdStr string, timeout time.Duration) (string, error) {
+	if ctxExec, ok := exec.(executor.ContextExecutor); ok {
+		return ctxExec.ExecuteWithOutputContext(ctx, cmdStr, timeout)
+	}
+	return exec.ExecuteWithOutput(cmdStr, timeout)
+}
+
+// runCancelHook executes the on_cancel hook of an interrupted command. The hook runs
+// outside the cancelled run context, so it is not interrupted itself; failures are
+// only reported because the command already failed.
+func (h *CommandHandler) runCancelHook(cmdName string, cmd config.Command, cmdVars map[string]string) {
+	if cmd.OnCancel == "" {
+		return
+	}
+
+	h.printf("Executing on_cancel hook for '%s'...\n", cmdName)
+	hookCmdStr := h.replaceVariablesInString(cmdName, cmd.OnCancel, cmdVars)
+	h.emit(events.Event{Type: events.HookStart, Command: cmdName, Hook: "on_cancel"})
+	span := h.startSpan(cmdName+": on_cancel", cmdName, tracing.String("yxa.command", cmdName), tracing.String("yxa.hook", "on_cancel"))
+
+	var err error
+	if exec, ok := h.Executor.(executor.ContextExecutor); ok {
+		err = exec.ExecuteContext(context.Background(), hookCmdStr, cancelHookTimeout)
+	} else {
+		err = h.Executor.Execute(hookCmdStr, cancelHookTimeout)
+	}
+	endSpan(span, err)
+	if err != nil {
+		h.printf("on_cancel hook for '%s' failed: %v\n", cmdName, err)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cancellingExecutor cancels the run when it executes a given command, like a user
+// pressing Ctrl-C while that command is running
+type cancellingExecutor struct {
+	recordingExecutor
+	cancelOn string
+	cancel   context.CancelFunc
+}
+
+func (e *cancellingExecutor) Execute(command string, timeout time.Duration) error {
+	e.executed = append(e.executed, command)
+	if command == e.cancelOn {
+		e.cancel()
+		return errors.New("signal: interrupt")
+	}
+	return nil
+}
+
+func TestCommandHandler_Cancel(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"build":   {Run: "go build", OnCancel: "echo build cancelled"},
+			"serve":   {Run: "serve", OnCancel: "rm -f $YXA_COMMAND.pid"},
+			"lint":    {Run: "lint"},
+			"release": {Depends: config.NewDependencyList("build", "serve", "lint"), DependsMode: config.DependsModeAll, OnCancel: "echo release cancelled"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exec := &cancellingExecutor{
+		recordingExecutor: recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard}},
+		cancelOn:          "serve",
+		cancel:            cancel,
+	}
+	handler := NewCommandHandler(cfg, exec)
+	handler.SetContext(ctx)
+
+	err := handler.ExecuteCommand("release", nil)
+	require.Error(t, err)
+	assert.True(t, handler.RunContext().Cancelled())
+
+	// Completed commands are not cleaned up, remaining dependencies do not start
+	// and the interrupted commands run their hooks from the inside out
+	assert.Equal(t, []string{
+		"go build",
+		"serve",
+		"rm -f serve.pid",
+		"echo release cancelled",
+	}, exec.executed)
+}
+
+func TestCommandHandler_NoCancelHookOnFailure(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"build": {Run: "go build", OnCancel: "echo build cancelled"},
+		},
+	}
+
+	exec := &recordingExecutor{testExecutor: testExecutor{
+		stdout:         io.Discard,
+		stderr:         io.Discard,
+		commandResults: map[string]error{"go build": errors.New("exit status 1")},
+	}}
+	handler := NewCommandHandler(cfg, exec)
+
+	require.Error(t, handler.ExecuteCommand("build", nil))
+	assert.False(t, handler.RunContext().Cancelled())
+	assert.Equal(t, []string{"go build"}, exec.executed)
+}
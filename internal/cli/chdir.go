@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// chdirFlag is the name of the global flag that changes the working directory
+const chdirFlag = "chdir"
+
+// applyChdir changes to the directory of --chdir/-C among the global flags at the
+// start of args. It runs before cobra parses the arguments, because the config
+// of the new directory defines which commands exist.
+func (r *RootCommand) applyChdir(args []string) error {
+	dir, ok := chdirArg(r.RootCmd.PersistentFlags(), args)
+	if !ok {
+		return nil
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("failed to change to directory '%s': %w", dir, err)
+	}
+	r.chdirApplied = true
+	return nil
+}
+
+// checkChdir fails if --chdir was given after the command, when it is too late to
+// load the config of the directory
+func (r *RootCommand) checkChdir() error {
+	if r.Chdir != "" && !r.chdirApplied {
+		return fmt.Errorf("--chdir must be given before the command, e.g. yxa -C %s <command>", r.Chdir)
+	}
+	return nil
+}
+
+// chdirArg returns the value of the last --chdir/-C among the flags at the start
+// of args
+func chdirArg(flags *pflag.FlagSet, args []string) (string, bool) {
+	dir, found := leadingFlagArg(flags, args, chdirFlag)
+	return dir, found && dir != ""
+}
+
+// leadingFlagArg returns the value of the last occurrence of the flag called name
+// among the flags at the start of args, which end at the first argument that is
+// not a flag or at --. Flags without a value, such as booleans, have their
+// NoOptDefVal. Values of other flags are skipped using their definitions in flags.
+func leadingFlagArg(flags *pflag.FlagSet, args []string, name string) (string, bool) {
+	var result string
+	found := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+
+		// Long flag: --name, --name=value or --name value
+		if strings.HasPrefix(arg, "--") {
+			flagName, value, hasValue := strings.Cut(arg[2:], "=")
+			flag := flags.Lookup(flagName)
+			if flag == nil || hasValue || flag.NoOptDefVal != "" {
+				if flagName == name && (hasValue || flag != nil) {
+					if !hasValue {
+						value = flag.NoOptDefVal
+					}
+					result, found = value, true
+				}
+				continue
+			}
+			if i+1 < len(args) {
+				i++
+				if flagName == name {
+					result, found = args[i], true
+				}
+			}
+			continue
+		}
+
+		// Shorthands, possibly combined: -dk, -Cdir, -C dir, -C=dir
+		for j := 1; j < len(arg); j++ {
+			flag := flags.ShorthandLookup(arg[j : j+1])
+			if flag == nil {
+				continue
+			}
+			if flag.NoOptDefVal != "" {
+				if flag.Name == name {
+					result, found = flag.NoOptDefVal, true
+				}
+				continue
+			}
+			value := strings.TrimPrefix(arg[j+1:], "=")
+			if value == "" && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if flag.Name == name {
+				result, found = value, true
+			}
+			break
+		}
+	}
+	return result, found
+}
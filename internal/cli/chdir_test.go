@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChdirArg(t *testing.T) {
+	flags := NewRootCommand(nil, executor.NewDefaultExecutor()).RootCmd.PersistentFlags()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"short", []string{"-C", "app", "build"}, "app"},
+		{"short attached", []string{"-Capp", "build"}, "app"},
+		{"short with equals", []string{"-C=app", "build"}, "app"},
+		{"long", []string{"--chdir", "app", "build"}, "app"},
+		{"long with equals", []string{"--chdir=app", "build"}, "app"},
+		{"combined shorthands", []string{"-dC", "app", "build"}, "app"},
+		{"after flags with values", []string{"--profile", "ci", "-s", "A=1", "-C", "app"}, "app"},
+		{"last wins", []string{"-C", "a", "-C", "b", "build"}, "b"},
+		{"after the command", []string{"build", "-C", "app"}, ""},
+		{"after --", []string{"--", "-C", "app"}, ""},
+		{"value that looks like a flag", []string{"--profile", "-C", "build"}, ""},
+		{"none", []string{"--dry-run", "build"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, ok := chdirArg(flags, tt.args)
+			assert.Equal(t, tt.want, dir)
+			assert.Equal(t, tt.want != "", ok)
+		})
+	}
+}
+
+func TestInitializeApp_Chdir(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "app"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app", "yxa.yml"), []byte(`
+commands:
+  build:
+    run: echo building
+`), 0o644))
+
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	t.Run("loads the config of the directory", func(t *testing.T) {
+		os.Args = []string{"yxa", "-C", "app", "build"}
+		root, err := InitializeApp()
+		require.NoError(t, err)
+		defer func() { require.NoError(t, os.Chdir(tempDir)) }()
+
+		require.NotNil(t, root.Config)
+		assert.Contains(t, root.Config.Commands, "build")
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		assert.Equal(t, "app", filepath.Base(cwd))
+
+		root.RootCmd.SetArgs(os.Args[1:])
+		assert.NoError(t, root.RootCmd.Execute())
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		os.Args = []string{"yxa", "-C", "missing", "build"}
+		_, err := InitializeApp()
+		assert.ErrorContains(t, err, "failed to change to directory 'missing'")
+	})
+
+	t.Run("after the command", func(t *testing.T) {
+		os.Args = []string{"yxa", "env", "-C", "app"}
+		root, err := InitializeApp()
+		require.NoError(t, err)
+		root.RootCmd.SetArgs(os.Args[1:])
+		assert.ErrorContains(t, root.RootCmd.Execute(), "--chdir must be given before the command")
+	})
+}
@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// maxMenuChoices is how many choices the select menu shows at once, it scrolls
+// through longer lists
+const maxMenuChoices = 10
+
+// errChoiceCancelled is returned when the user cancels a select menu with Ctrl-C
+var errChoiceCancelled = errors.New("cancelled")
+
+// askParamChoices asks for the value of every required parameter with choices
+// that is not given, with a select menu on a terminal and a plain prompt on other
+// input. In non-interactive mode such a parameter is an error. Flags are set to
+// the answer; answers for positional parameters are appended to args, which is
+// returned.
+func (r *RootCommand) askParamChoices(cmd *cobra.Command, args []string, params []config.Param) ([]string, error) {
+	var in io.Reader
+	for _, param := range params {
+		if !param.Required || len(param.Choices) == 0 || paramGiven(cmd, args, param) {
+			continue
+		}
+		positional := !param.Flag && param.Position >= 0
+		if positional && param.Position != len(args) {
+			// Earlier positional parameters are missing, which is reported as usual
+			continue
+		}
+
+		label := paramLabel(param)
+		if in == nil {
+			in = cmd.InOrStdin()
+			if _, ok := in.(*os.File); !ok {
+				// The answers of several prompts are read from the same buffer
+				in = bufio.NewReader(in)
+			}
+		}
+		if !r.interactive(in) {
+			return nil, errNonInteractive("ask for "+label, "pass one of "+strings.Join(param.Choices, ", "))
+		}
+		value, err := askChoice(in, cmd.ErrOrStderr(), label, param.Choices, param.Default)
+		if err != nil {
+			return nil, fmt.Errorf("no value for %s: %w", label, err)
+		}
+
+		if positional {
+			args = append(args, value)
+			continue
+		}
+		name, _ := processParamName(param.Name)
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for %s: %w", value, label, err)
+		}
+	}
+	return args, nil
+}
+
+// askChoice asks to choose one of choices, with def chosen up front if it is one
+// of them. A terminal gets a select menu, other input a numbered list.
+func askChoice(in io.Reader, out io.Writer, label string, choices []string, def string) (string, error) {
+	if f, ok := in.(*os.File); ok && fileIsTerminal(f) {
+		if state, err := term.MakeRaw(int(f.Fd())); err == nil {
+			defer func() { _ = term.Restore(int(f.Fd()), state) }()
+			return selectChoice(f, out, label, choices, def)
+		}
+	}
+	return promptChoice(in, out, label, choices, def)
+}
+
+// promptChoice lists the choices numbered and reads the number or the value of
+// one of them. An empty answer takes def.
+func promptChoice(in io.Reader, out io.Writer, label string, choices []string, def string) (string, error) {
+	fmt.Fprintf(out, "Choose %s:\n", label)
+	for i, choice := range choices {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, choice)
+	}
+	if def != "" {
+		fmt.Fprintf(out, "[%s]: ", def)
+	} else {
+		fmt.Fprint(out, "> ")
+	}
+
+	reader, ok := in.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+	answer, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		answer = def
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], nil
+	}
+	for _, choice := range choices {
+		if answer == choice {
+			return choice, nil
+		}
+	}
+	return "", fmt.Errorf("invalid choice '%s', expected one of %s", answer, strings.Join(choices, ", "))
+}
+
+// choiceMenu is the state of a select menu: the typed filter and the highlighted
+// choice among those that match it
+type choiceMenu struct {
+	choices []string
+	filter  string
+	matches []string
+	cursor  int
+}
+
+// newChoiceMenu creates a menu with def highlighted if it is one of choices
+func newChoiceMenu(choices []string, def string) *choiceMenu {
+	m := &choiceMenu{choices: choices}
+	m.refilter()
+	for i, choice := range m.matches {
+		if choice == def {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+// refilter updates the choices that match the filter, highlighting the first
+func (m *choiceMenu) refilter() {
+	m.matches = m.matches[:0]
+	for _, choice := range m.choices {
+		if fuzzyMatch(m.filter, choice) {
+			m.matches = append(m.matches, choice)
+		}
+	}
+	m.cursor = 0
+}
+
+// selected returns the highlighted choice, false if no choice matches the filter
+func (m *choiceMenu) selected() (string, bool) {
+	if len(m.matches) == 0 {
+		return "", false
+	}
+	return m.matches[m.cursor], true
+}
+
+// move moves the highlight by delta, wrapping around at the ends
+func (m *choiceMenu) move(delta int) {
+	if n := len(m.matches); n > 0 {
+		m.cursor = ((m.cursor+delta)%n + n) % n
+	}
+}
+
+// lines returns the lines the menu is drawn as: the label with the filter,
+// followed by the visible choices with the highlighted one marked
+func (m *choiceMenu) lines(label string) []string {
+	lines := []string{fmt.Sprintf("Choose %s (type to filter): %s", label, m.filter)}
+	if len(m.matches) == 0 {
+		return append(lines, "  no matching choice")
+	}
+	first := 0
+	if m.cursor >= maxMenuChoices {
+		first = m.cursor - maxMenuChoices + 1
+	}
+	for i := first; i < len(m.matches) && i < first+maxMenuChoices; i++ {
+		marker := "  "
+		if i == m.cursor {
+			marker = "> "
+		}
+		lines = append(lines, marker+m.matches[i])
+	}
+	return lines
+}
+
+// Keys of the select menu
+const (
+	keyCtrlC     = 0x03
+	keyBackspace = 0x08
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyCtrlU     = 0x15
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// selectChoice shows a select menu on out, which reads keys from in: the arrow
+// keys or Ctrl-P and Ctrl-N move the highlight, typing filters the choices, Enter
+// chooses and Ctrl-C cancels. in is expected in raw mode, so lines end in \r\n.
+func selectChoice(in io.Reader, out io.Writer, label string, choices []string, def string) (string, error) {
+	m := newChoiceMenu(choices, def)
+	keys := bufio.NewReader(in)
+	drawn := 0
+	draw := func(lines []string) {
+		if drawn > 1 {
+			fmt.Fprintf(out, "\x1b[%dA", drawn-1)
+		}
+		fmt.Fprint(out, "\r\x1b[J"+strings.Join(lines, "\r\n"))
+		drawn = len(lines)
+	}
+
+	for {
+		draw(m.lines(label))
+
+		key, err := keys.ReadByte()
+		if err != nil {
+			draw(nil)
+			return "", err
+		}
+		switch key {
+		case '\r', '\n':
+			if choice, ok := m.selected(); ok {
+				draw([]string{fmt.Sprintf("Choose %s: %s", label, choice)})
+				fmt.Fprint(out, "\r\n")
+				return choice, nil
+			}
+		case keyCtrlC:
+			draw(nil)
+			return "", errChoiceCancelled
+		case keyCtrlP:
+			m.move(-1)
+		case keyCtrlN:
+			m.move(1)
+		case keyCtrlU:
+			m.filter = ""
+			m.refilter()
+		case keyBackspace, keyDelete:
+			if m.filter != "" {
+				m.filter = m.filter[:len(m.filter)-1]
+				m.refilter()
+			}
+		case keyEscape:
+			// Arrow keys are ESC [ A to ESC [ D, or ESC O A to ESC O D
+			if next, err := keys.ReadByte(); err != nil || (next != '[' && next != 'O') {
+				continue
+			}
+			switch arrow, _ := keys.ReadByte(); arrow {
+			case 'A':
+				m.move(-1)
+			case 'B':
+				m.move(1)
+			}
+		default:
+			if key >= ' ' && key < keyDelete {
+				m.filter += string(key)
+				m.refilter()
+			}
+		}
+	}
+}
+
+// fuzzyMatch reports whether the characters of filter appear in s in the same
+// order, ignoring case, e.g. "stg" matches "staging"
+func fuzzyMatch(filter, s string) bool {
+	s = strings.ToLower(s)
+	for _, c := range strings.ToLower(filter) {
+		i := strings.IndexRune(s, c)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(c)):]
+	}
+	return true
+}
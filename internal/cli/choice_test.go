@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectChoice(t *testing.T) {
+	choices := []string{"dev", "staging", "prod"}
+	tests := []struct {
+		name    string
+		keys    string
+		def     string
+		want    string
+		wantErr string
+	}{
+		{name: "enter takes the first choice", keys: "\r", want: "dev"},
+		{name: "default is highlighted", keys: "\r", def: "prod", want: "prod"},
+		{name: "arrow down", keys: "\x1b[B\x1b[B\r", want: "prod"},
+		{name: "arrow up wraps around", keys: "\x1b[A\r", want: "prod"},
+		{name: "ctrl-n and ctrl-p", keys: "\x0e\x0e\x10\r", want: "staging"},
+		{name: "fuzzy filter", keys: "pd\r", want: "prod"},
+		{name: "filter narrows the highlight", keys: "d\x1b[B\r", want: "prod"},
+		{name: "backspace widens the filter", keys: "prx\x7f\x7f\x7f\x1b[B\r", want: "staging"},
+		{name: "enter without a match is ignored", keys: "xyz\x15\r", want: "dev"},
+		{name: "ctrl-c cancels", keys: "\x03", wantErr: "cancelled"},
+		{name: "end of input", keys: "st", wantErr: "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			got, err := selectChoice(strings.NewReader(tt.keys), out, "--env", choices, tt.def)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, strings.HasSuffix(out.String(), "Choose --env: "+tt.want+"\r\n"), "menu ends with the choice: %q", out.String())
+		})
+	}
+}
+
+func TestChoiceMenu_Lines(t *testing.T) {
+	choices := make([]string, 15)
+	for i := range choices {
+		choices[i] = string(rune('a' + i))
+	}
+	m := newChoiceMenu(choices, "m")
+
+	lines := m.lines("--letter")
+	require.Len(t, lines, maxMenuChoices+1)
+	assert.Equal(t, "Choose --letter (type to filter): ", lines[0])
+	assert.Equal(t, "  d", lines[1], "the list scrolls to the highlighted choice")
+	assert.Equal(t, "> m", lines[maxMenuChoices])
+
+	m.filter = "zz"
+	m.refilter()
+	assert.Equal(t, []string{"Choose --letter (type to filter): zz", "  no matching choice"}, m.lines("--letter"))
+}
+
+func TestPromptChoice(t *testing.T) {
+	choices := []string{"dev", "prod"}
+
+	out := &bytes.Buffer{}
+	got, err := promptChoice(strings.NewReader("2\n"), out, "--env", choices, "")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", got)
+	assert.Equal(t, "Choose --env:\n  1) dev\n  2) prod\n> ", out.String())
+
+	got, err = promptChoice(strings.NewReader("dev"), &bytes.Buffer{}, "--env", choices, "")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", got)
+
+	got, err = promptChoice(strings.NewReader("\n"), &bytes.Buffer{}, "--env", choices, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", got)
+
+	_, err = promptChoice(strings.NewReader("3\n"), &bytes.Buffer{}, "--env", choices, "")
+	assert.EqualError(t, err, "invalid choice '3', expected one of dev, prod")
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	assert.True(t, fuzzyMatch("", "prod"))
+	assert.True(t, fuzzyMatch("stg", "staging"))
+	assert.True(t, fuzzyMatch("EU", "eu-north-1"))
+	assert.False(t, fuzzyMatch("gts", "staging"))
+}
+
+func TestAskParamChoices(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"deploy": {
+				Run: "echo deploy $env $region",
+				Params: []config.Param{
+					{Name: "env", Type: "string", Flag: true, Required: true, Choices: []string{"staging", "prod"}},
+					{Name: "region", Type: "string", Position: 0, Required: true, Choices: []string{"eu", "us"}},
+				},
+			},
+		},
+	}
+
+	run := func(t *testing.T, input string, nonInteractive bool, args ...string) (string, int) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.NonInteractive = nonInteractive
+		root.registerCommands()
+		root.RootCmd.SetIn(strings.NewReader(input))
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		require.NoError(t, root.Execute())
+		return strings.Join(exec.executed, "\n"), code
+	}
+
+	t.Run("asks for missing values", func(t *testing.T) {
+		executed, code := run(t, "2\nus\n", false, "deploy")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "echo deploy prod us", executed)
+	})
+
+	t.Run("given values are not asked for", func(t *testing.T) {
+		executed, code := run(t, "", false, "deploy", "--env", "staging", "eu")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "echo deploy staging eu", executed)
+	})
+
+	t.Run("non-interactive mode fails", func(t *testing.T) {
+		_, code := run(t, "2\n", true, "deploy", "eu")
+		assert.Equal(t, 1, code)
+	})
+}
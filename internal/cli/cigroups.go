@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Modes of the --ci-groups flag
+const (
+	CIGroupsAuto = "auto" // Fold the output when running in GitHub Actions or GitLab CI
+	CIGroupsOn   = "on"   // Always fold the output, with the markers of GitHub if not in GitLab CI
+	CIGroupsOff  = "off"  // Never fold the output
+)
+
+// CI systems whose logs fold the output between markers
+const (
+	ciGitHub = "github"
+	ciGitLab = "gitlab"
+)
+
+// detectCI returns the CI system yxa runs in, empty if it is none whose log folds
+// output
+func detectCI() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return ciGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return ciGitLab
+	}
+	return ""
+}
+
+// ciGroupSystem returns the CI system whose markers fold the output in the given
+// --ci-groups mode, empty if the output is not folded
+func ciGroupSystem(mode string) (string, error) {
+	switch mode {
+	case CIGroupsAuto, "":
+		return detectCI(), nil
+	case CIGroupsOn:
+		if system := detectCI(); system != "" {
+			return system, nil
+		}
+		return ciGitHub, nil
+	case CIGroupsOff:
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid --ci-groups '%s': expected '%s', '%s' or '%s'", mode, CIGroupsAuto, CIGroupsOn, CIGroupsOff)
+}
+
+// setupCIGroups validates the --ci-groups flag and folds the output of every
+// command in the log of the CI system. The markers are written before the output
+// is prefixed with timestamps, as CI systems only see them at the start of a line.
+func (r *RootCommand) setupCIGroups() error {
+	system, err := ciGroupSystem(r.CIGroups)
+	if err != nil {
+		return err
+	}
+	r.Handler.SetCIGroups(system, r.Handler.Executor.GetStdout())
+	return nil
+}
+
+// ciGroups writes the markers that fold the output of each command into a
+// collapsible section of the CI log. Only the outermost commands are folded, as
+// GitHub cannot nest groups; the commands they run as tasks are part of theirs.
+type ciGroups struct {
+	system string    // CI system whose markers are written
+	out    io.Writer // Destination of the markers
+	now    func() time.Time
+	mu     sync.Mutex
+	depth  int // Number of commands running in the open group
+}
+
+// gitlabSectionChars matches the characters GitLab does not allow in section names
+var gitlabSectionChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// begin opens a group for the output of a command, unless one is open already, and
+// returns the function that closes it
+func (g *ciGroups) begin(cmdName string) func() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.depth++
+	if g.depth > 1 {
+		return g.leave
+	}
+	section := "yxa_" + gitlabSectionChars.ReplaceAllString(cmdName, "_")
+	switch g.system {
+	case ciGitHub:
+		fmt.Fprintf(g.out, "::group::%s\n", cmdName)
+	case ciGitLab:
+		fmt.Fprintf(g.out, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", g.now().Unix(), section, cmdName)
+	}
+
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		g.depth--
+		switch g.system {
+		case ciGitHub:
+			fmt.Fprintln(g.out, "::endgroup::")
+		case ciGitLab:
+			fmt.Fprintf(g.out, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", g.now().Unix(), section)
+		}
+	}
+}
+
+// leave closes a group of a command that runs inside an open one
+func (g *ciGroups) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.depth--
+}
+
+// SetCIGroups sets the CI system whose markers fold the output of every command,
+// written to out, empty to not fold it
+func (h *CommandHandler) SetCIGroups(system string, out io.Writer) {
+	if system == "" {
+		h.groups = nil
+		return
+	}
+	h.groups = &ciGroups{system: system, out: out, now: time.Now}
+}
+
+// withCIGroup runs fn, which executes a command, with its output folded into a
+// group of the CI log if --ci-groups applies
+func (h *CommandHandler) withCIGroup(cmdName string, fn func() error) error {
+	if h.groups == nil {
+		return fn()
+	}
+	end := h.groups.begin(cmdName)
+	defer end()
+	return fn()
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain hides the CI system the tests run in, so that --ci-groups does not add
+// markers to the output the tests compare and runs do not write to its job summary
+func TestMain(m *testing.M) {
+	_ = os.Unsetenv("GITHUB_ACTIONS")
+	_ = os.Unsetenv("GITHUB_STEP_SUMMARY")
+	_ = os.Unsetenv("GITLAB_CI")
+	os.Exit(m.Run())
+}
+
+func TestCIGroupSystem(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		env     string
+		want    string
+		wantErr string
+	}{
+		{name: "auto outside of CI", mode: CIGroupsAuto},
+		{name: "auto in GitHub Actions", mode: CIGroupsAuto, env: "GITHUB_ACTIONS", want: ciGitHub},
+		{name: "auto in GitLab CI", mode: CIGroupsAuto, env: "GITLAB_CI", want: ciGitLab},
+		{name: "on outside of CI", mode: CIGroupsOn, want: ciGitHub},
+		{name: "on in GitLab CI", mode: CIGroupsOn, env: "GITLAB_CI", want: ciGitLab},
+		{name: "off in GitHub Actions", mode: CIGroupsOff, env: "GITHUB_ACTIONS"},
+		{name: "invalid", mode: "yes", wantErr: "invalid --ci-groups 'yes': expected 'auto', 'on' or 'off'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv(tt.env, "true")
+			}
+			got, err := ciGroupSystem(tt.mode)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandHandler_CIGroups(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "echo generating"},
+			"build":    {Run: "echo building", Depends: config.DependencyList{{Command: "generate"}}},
+			"release":  {Tasks: config.TaskList{{Run: "echo releasing"}, {Task: "tools:gen"}}},
+			"tools": {
+				Commands: map[string]config.Command{"gen": {Run: "echo gen"}},
+			},
+		},
+	}
+	newHandler := func(system string) (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.setProgress(&bytes.Buffer{})
+		handler.SetNoPrefix(true)
+		handler.SetCIGroups(system, out)
+		if handler.groups != nil {
+			handler.groups.now = func() time.Time { return time.Unix(1700000000, 0) }
+		}
+		return handler, out
+	}
+
+	t.Run("github groups every command", func(t *testing.T) {
+		handler, out := newHandler(ciGitHub)
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, "::group::generate\ngenerating\n::endgroup::\n::group::build\nbuilding\n::endgroup::\n", out.String())
+	})
+
+	t.Run("commands run as tasks are part of the group", func(t *testing.T) {
+		handler, out := newHandler(ciGitHub)
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Equal(t, "::group::release\nreleasing\ngen\n::endgroup::\n", out.String())
+	})
+
+	t.Run("gitlab sections", func(t *testing.T) {
+		handler, out := newHandler(ciGitLab)
+		require.NoError(t, handler.ExecuteCommand("tools:gen", nil))
+		assert.Equal(t, "\x1b[0Ksection_start:1700000000:yxa_tools_gen[collapsed=true]\r\x1b[0Ktools:gen\ngen\n"+
+			"\x1b[0Ksection_end:1700000000:yxa_tools_gen\r\x1b[0K\n", out.String())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		handler, out := newHandler("")
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, "generating\nbuilding\n", out.String())
+	})
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newCleanCommand creates the built-in 'clean' command, which removes the artifacts
+// that commands declare
+func (r *RootCommand) newCleanCommand() *cobra.Command {
+	var all, yes bool
+
+	cmd := &cobra.Command{
+		Use:   "clean [command...]",
+		Short: "Remove the artifacts of commands",
+		Long: `Remove the files and directories that commands declare in 'artifacts', of the
+given commands (use parent:sub for subcommands) or of every command. Artifacts
+are paths relative to yxa.yml and may be glob patterns such as dist/*.tar.gz.
+Paths outside the directory of yxa.yml are never removed.
+
+The paths are listed and removed once you confirm. --yes removes them without
+asking, --dry-run only lists them. With --all, the .yxa directory with the run
+history and the state and logs of services is removed as well.`,
+		ValidArgsFunction: r.completeCommandNames(0, nil),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.clean(cmd, args, all, yes)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Also remove the .yxa directory with history and service state")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove without asking for confirmation")
+
+	return cmd
+}
+
+// clean removes the artifacts of the given commands, or of every command
+func (r *RootCommand) clean(cmd *cobra.Command, cmdNames []string, all, yes bool) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	out := cmd.OutOrStdout()
+
+	paths, err := r.artifactPaths(cmdNames)
+	if err != nil {
+		return err
+	}
+	if all {
+		stateDir := filepath.Join(r.projectDir(), stateDirName)
+		if _, err := os.Stat(stateDir); err == nil {
+			if err := r.checkNoServicesRunning(); err != nil {
+				return err
+			}
+			paths = append(paths, stateDir)
+		}
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(out, "Nothing to clean")
+		return nil
+	}
+
+	for _, path := range paths {
+		fmt.Fprintf(out, "  %s\n", r.displayPath(path))
+	}
+	if r.DryRun {
+		fmt.Fprintf(out, "[dry-run] Would remove %d paths\n", len(paths))
+		return nil
+	}
+	if !yes {
+		if !r.interactive(cmd.InOrStdin()) {
+			return errNonInteractive("ask for confirmation", "pass --yes to remove the paths")
+		}
+		ok, err := confirm(cmd.InOrStdin(), out, fmt.Sprintf("Remove %d paths?", len(paths)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted, nothing was removed")
+		}
+	}
+
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", r.displayPath(path), err)
+		}
+	}
+	fmt.Fprintf(out, "Removed %d paths\n", len(paths))
+	return nil
+}
+
+// artifactPaths returns the existing paths that the artifacts of the given
+// commands, or of every command, match, sorted and without duplicates
+func (r *RootCommand) artifactPaths(cmdNames []string) ([]string, error) {
+	if len(cmdNames) == 0 {
+		for _, c := range flattenCommands(r.Config) {
+			cmdNames = append(cmdNames, c.Name)
+		}
+	}
+
+	dir := r.projectDir()
+	seen := make(map[string]bool)
+	for _, name := range cmdNames {
+		cmd, err := r.Handler.lookupCommand(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, artifact := range cmd.Artifacts {
+			pattern := r.Handler.replaceVariablesInString(name, artifact, nil)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(dir, pattern)
+			}
+			pattern = filepath.Clean(pattern)
+			if rel, err := filepath.Rel(dir, pattern); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return nil, fmt.Errorf("artifact '%s' of command '%s' is not inside %s, it is not removed", artifact, name, dir)
+			}
+
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid artifact '%s' of command '%s': %w", artifact, name, err)
+			}
+			for _, match := range matches {
+				seen[match] = true
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// checkNoServicesRunning fails if a service is running, whose state would be lost
+// by removing the .yxa directory
+func (r *RootCommand) checkNoServicesRunning() error {
+	manager := r.serviceManager()
+	for _, name := range r.serviceNames() {
+		status, err := manager.Status(name)
+		if err != nil {
+			return err
+		}
+		if status.Running {
+			return fmt.Errorf("service '%s' is running, stop it with 'yxa down' before removing %s", name, stateDirName)
+		}
+	}
+	return nil
+}
+
+// projectDir returns the directory of the loaded config, the current directory if
+// it is not known
+func (r *RootCommand) projectDir() string {
+	if dir := r.Config.ConfigDir(); dir != "" {
+		return dir
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
+
+// displayPath returns path relative to the current directory if it is below it
+func (r *RootCommand) displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// confirm asks a yes/no question and reports whether it was answered with yes.
+// No answer, e.g. when the input is not a terminal, means no.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if err == io.EOF {
+		fmt.Fprintln(out)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+variables:
+  OUT: dist
+commands:
+  build:
+    run: go build -o $OUT/app
+    artifacts: [$OUT, coverage.*]
+  docs:
+    commands:
+      site:
+        run: hugo
+        artifacts: [public]
+  escape:
+    run: "true"
+    artifacts: [../outside]
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T) {
+		t.Helper()
+		for _, file := range []string{"dist/app", "coverage.out", "coverage.html", "public/index.html", ".yxa/history.jsonl", "main.go"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, file), nil, 0o644))
+		}
+	}
+	exists := func(file string) bool {
+		_, err := os.Stat(filepath.Join(dir, file))
+		return err == nil
+	}
+
+	run := func(t *testing.T, input string, args ...string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetIn(strings.NewReader(input))
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(append([]string{"clean"}, args...))
+		err := root.RootCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("command", func(t *testing.T) {
+		setup(t)
+		out, err := run(t, "", "build", "--yes")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Removed 3 paths\n")
+		assert.False(t, exists("dist"))
+		assert.False(t, exists("coverage.out"))
+		assert.False(t, exists("coverage.html"))
+		assert.True(t, exists("public/index.html"))
+		assert.True(t, exists("main.go"))
+	})
+
+	t.Run("confirmation", func(t *testing.T) {
+		setup(t)
+		out, err := run(t, "n\n", "docs:site")
+		assert.EqualError(t, err, "aborted, nothing was removed")
+		assert.Contains(t, out, "Remove 1 paths? [y/N] ")
+		assert.True(t, exists("public"))
+
+		_, err = run(t, "", "docs:site")
+		assert.Error(t, err, "no answer means no")
+		assert.True(t, exists("public"))
+
+		_, err = run(t, "y\n", "docs:site")
+		require.NoError(t, err)
+		assert.False(t, exists("public"))
+	})
+
+	t.Run("non-interactive", func(t *testing.T) {
+		setup(t)
+		out, err := run(t, "y\n", "docs:site", "--non-interactive")
+		assert.EqualError(t, err, "cannot ask for confirmation in non-interactive mode, pass --yes to remove the paths")
+		assert.NotContains(t, out, "[y/N]")
+		assert.True(t, exists("public"))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		setup(t)
+		out, err := run(t, "", "build", "--dry-run")
+		require.NoError(t, err)
+		assert.Contains(t, out, "coverage.html\n")
+		assert.Contains(t, out, "[dry-run] Would remove 3 paths\n")
+		assert.True(t, exists("dist/app"))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		setup(t)
+		_, err := run(t, "", "build", "docs:site", "--all", "--yes")
+		require.NoError(t, err)
+		assert.False(t, exists("public"))
+		assert.False(t, exists(".yxa"))
+		assert.True(t, exists("main.go"))
+
+		out, err := run(t, "", "build", "--all")
+		require.NoError(t, err)
+		assert.Equal(t, "Nothing to clean\n", out)
+	})
+
+	t.Run("outside the project", func(t *testing.T) {
+		_, err := run(t, "", "escape", "--yes")
+		assert.EqualError(t, err, "artifact '../outside' of command 'escape' is not inside "+dir+", it is not removed")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		_, err := run(t, "", "missing")
+		assert.Error(t, err)
+	})
+}
@@ -1,21 +1,53 @@
 package cli
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/events"
 	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
+	"github.com/floppa/yxa-cli/internal/variables"
 )
 
 // CommandHandler manages command execution with dependencies and variables
 type CommandHandler struct {
-	Config       *config.ProjectConfig
-	Executor     executor.CommandExecutor
-	executedCmds map[string]bool
-	DryRun       bool
+	Config             *config.ProjectConfig
+	Executor           executor.CommandExecutor
+	DryRun             bool
+	KeepGoing          bool                                     // Continue after failing tasks and dependencies, reporting an aggregate error
+	NoDedupe           bool                                     // Execute dependencies again even if they already ran in this run
+	Force              bool                                     // Run commands even if their generates are up to date
+	FailOnSkip         bool                                     // Fail commands that are skipped because their condition is not met
+	NoPrefix           bool                                     // Do not prefix the output of dependencies with their name
+	DebugOnFailure     bool                                     // Open a debug shell when a command fails and stdin is a terminal
+	DebugTimeout       time.Duration                            // Maximum duration of a debug shell
+	Events             *events.Emitter                          // Structured run events, nil if disabled
+	OutputMode         string                                   // Output mode of commands that do not set 'output', empty for stream
+	Timestamps         string                                   // --timestamps mode, empty if disabled
+	Jobs               int                                      // Maximum number of parallel jobs of a command (--jobs), 0 for no limit
+	Tracer             *tracing.Tracer                          // OpenTelemetry spans of runs, nil if disabled
+	DebugVars          io.Writer                                // Destination of the variable substitutions logged by --debug-vars, nil if disabled
+	StrictDeprecations bool                                     // Fail instead of warning when a deprecated command is invoked
+	NonInteractive     bool                                     // Never interact with the user, e.g. in CI
+	Timeout            string                                   // --timeout of commands that do not set their own, empty for the default_timeout of the config
+	Check              bool                                     // Template steps only check that their output is up to date (--check)
+	run                *RunContext                              // State of the current run, replaced by every ExecuteCommand call
+	ctx                context.Context                          // Context of new runs, cancelled on SIGINT/SIGTERM
+	overrides          map[string]string                        // Variables set for the invocation with --set, highest precedence
+	newJobExecutor     func() (executor.CommandExecutor, error) // Creates executors for parallel jobs, nil to run them on the host
+	progress           io.Writer                                // Destination of progress messages while output is captured, nil for stdout
+	progressMu         sync.Mutex                               // Protects progress
+	toolsReady         bool                                     // Whether the tools of the config are installed and on PATH
+	groups             *ciGroups                                // Folds the output of every command in the CI log, nil if disabled
 }
 
 // SetDryRun sets the dry-run mode for the handler
@@ -23,142 +55,559 @@ func (h *CommandHandler) SetDryRun(dryRun bool) {
 	h.DryRun = dryRun
 }
 
+// SetKeepGoing sets whether failing tasks and dependencies stop the execution
+func (h *CommandHandler) SetKeepGoing(keepGoing bool) {
+	h.KeepGoing = keepGoing
+}
+
+// SetNoDedupe sets whether commands that already ran in this run are executed again
+func (h *CommandHandler) SetNoDedupe(noDedupe bool) {
+	h.NoDedupe = noDedupe
+}
+
+// SetForce sets whether commands run even if their generates are up to date
+func (h *CommandHandler) SetForce(force bool) {
+	h.Force = force
+}
+
+// SetFailOnSkip sets whether commands whose condition is not met fail instead of
+// being skipped
+func (h *CommandHandler) SetFailOnSkip(failOnSkip bool) {
+	h.FailOnSkip = failOnSkip
+}
+
+// SetNoPrefix sets whether the output of dependencies is passed on without their
+// name as prefix
+func (h *CommandHandler) SetNoPrefix(noPrefix bool) {
+	h.NoPrefix = noPrefix
+}
+
+// SetDebugOnFailure sets whether a failing command opens a debug shell, and for how long
+func (h *CommandHandler) SetDebugOnFailure(enabled bool, timeout time.Duration) {
+	h.DebugOnFailure = enabled
+	h.DebugTimeout = timeout
+}
+
+// SetEvents sets the emitter for structured run events, nil disables them
+func (h *CommandHandler) SetEvents(emitter *events.Emitter) {
+	h.Events = emitter
+}
+
+// SetOutputMode sets the output mode of commands that do not set 'output'
+func (h *CommandHandler) SetOutputMode(mode string) {
+	h.OutputMode = mode
+}
+
+// SetTimestamps sets the --timestamps mode, empty disables it
+func (h *CommandHandler) SetTimestamps(mode string) {
+	h.Timestamps = mode
+}
+
+// SetJobs sets the maximum number of parallel jobs of a command, 0 for no limit
+func (h *CommandHandler) SetJobs(jobs int) {
+	h.Jobs = jobs
+}
+
+// SetTimeout sets the timeout of commands that do not set their own, empty for
+// the default_timeout of the config
+func (h *CommandHandler) SetTimeout(timeout string) {
+	h.Timeout = timeout
+}
+
+// SetCheck sets whether template steps only check that their output is up to
+// date instead of writing it
+func (h *CommandHandler) SetCheck(check bool) {
+	h.Check = check
+}
+
+// SetContext sets the context of the runs started by ExecuteCommand. Cancelling it
+// stops the running commands and runs their on_cancel hooks.
+func (h *CommandHandler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// SetVariableOverrides sets the variables that take precedence over every other
+// variable source for this invocation
+func (h *CommandHandler) SetVariableOverrides(vars map[string]string) {
+	h.overrides = vars
+}
+
 // NewCommandHandler creates a new command handler
 func NewCommandHandler(cfg *config.ProjectConfig, exec executor.CommandExecutor) *CommandHandler {
 	return &CommandHandler{
-		Config:       cfg,
-		Executor:     exec,
-		executedCmds: make(map[string]bool),
+		Config:   cfg,
+		Executor: exec,
+		run:      NewRunContext(),
 	}
 }
 
-// ExecuteCommand runs a command with its dependencies using the provided variables
+// RunContext returns the state of the current run
+func (h *CommandHandler) RunContext() *RunContext {
+	if h.run == nil {
+		h.run = NewRunContext()
+	}
+	return h.run
+}
+
+// builtinVars returns the runtime built-in variables for the given command
+func (h *CommandHandler) builtinVars(cmdName string) map[string]string {
+	vars := map[string]string{
+		variables.BuiltinCommand: cmdName,
+	}
+	run := h.RunContext()
+	vars[variables.BuiltinRunID] = run.ID
+	vars[variables.BuiltinTimestamp] = run.StartedAt.Format(variables.TimestampFormat)
+	return vars
+}
+
+// ExecuteCommand runs a command with its dependencies using the provided variables.
+// Every call starts a new run, so commands executed by earlier calls run again.
 func (h *CommandHandler) ExecuteCommand(cmdName string, cmdVars map[string]string) error {
-	// Check if command has already been executed
-	if h.executedCmds[cmdName] {
-		return nil
+	h.run = NewRunContext()
+	if h.ctx != nil {
+		h.run.Context = h.ctx
 	}
 
-	// Mark the command as executed
-	h.executedCmds[cmdName] = true
+	if err := h.checkDeprecated(cmdName); err != nil {
+		return err
+	}
 
-	// Check if this is a subcommand reference (format: parent:subcommandname)
-	parts := strings.Split(cmdName, ":")
-	if len(parts) > 1 {
-		parentName := parts[0]
-		subCmdName := parts[1]
+	// In dry-run mode, walk the execution plan and print it instead of executing
+	if h.DryRun {
+		return h.executeDryRun(cmdName, cmdVars)
+	}
 
-		// Get the parent command
-		parentCmd, ok := h.Config.Commands[parentName]
-		if !ok {
-			return fmt.Errorf("parent command '%s' not found", parentName)
-		}
+	if err := h.installTools(); err != nil {
+		return err
+	}
 
-		// Get the subcommand by name
-		subCmd, ok := parentCmd.Commands[subCmdName]
-		if !ok {
-			return fmt.Errorf("subcommand '%s' not found in command '%s'", subCmdName, parentName)
-		}
+	// Commands with after need to know which commands the run executes
+	if h.usesAfter() {
+		h.run.setScheduled(h.scheduledCommands(cmdName, cmdVars))
+	}
+
+	return h.executeCommand("", cmdName, cmdVars)
+}
+
+// executeCommand runs a command executed by parent (empty for the top-level command)
+// with its dependencies as part of the current run
+func (h *CommandHandler) executeCommand(parent, cmdName string, cmdVars map[string]string) error {
+	if h.NoDedupe {
+		return h.runCommand(parent, cmdName, cmdVars)
+	}
+
+	// Look up the command (or parent:subcommand) in the config
+	cmd, err := h.lookupCommand(cmdName)
+	if err != nil {
+		return err
+	}
 
-		// Execute the subcommand
-		return h.executeCommandWithDependencies(cmdName, subCmd, cmdVars)
+	// Wait for commands that another branch of the run is executing and skip
+	// those that already ran in this run, so they run once
+	run := h.RunContext()
+	execution, reused, err := run.start(parent, cmdName)
+	if reused {
+		run.recordCacheHit(cmdName)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return h.runEntered(execution, parent, cmdName, cmd, cmdVars)
+}
+
+// runCommand runs a command executed by parent with its dependencies as part of the
+// current run, even if it already ran earlier in the run
+func (h *CommandHandler) runCommand(parent, cmdName string, cmdVars map[string]string) error {
+	// Look up the command (or parent:subcommand) in the config
+	cmd, err := h.lookupCommand(cmdName)
+	if err != nil {
+		return err
+	}
+
+	return h.runResolvedCommand(parent, cmdName, cmd, cmdVars)
+}
+
+// runResolvedCommand runs cmd under the given name as part of the current run, see
+// runCommand
+func (h *CommandHandler) runResolvedCommand(parent, cmdName string, cmd config.Command, cmdVars map[string]string) error {
+	// Mark the command as executing
+	execution, err := h.RunContext().enter(parent, cmdName)
+	if err != nil {
+		return err
+	}
+	return h.runEntered(execution, parent, cmdName, cmd, cmdVars)
+}
+
+// runEntered runs cmd under the given name as the execution registered for it in
+// the current run, and marks it as finished afterwards
+func (h *CommandHandler) runEntered(execution *commandExecution, parent, cmdName string, cmd config.Command, cmdVars map[string]string) (err error) {
+	run := h.RunContext()
+	defer func() { run.leave(cmdName, execution, err) }()
+
+	h.emit(events.Event{Type: events.CommandStart, Command: cmdName})
+	span := h.startSpan(cmdName, parent, tracing.String("yxa.command", cmdName))
+	run.setSpan(cmdName, span)
+	start := time.Now()
+	failures := run.failureCount()
+
+	// Resolve parameter defaults once, before anything that may reference them:
+	// conditions, hooks, dependencies, the command itself and its failure handling
+	// all see the same variables
+	cmdVars = h.withParamDefaults(cmdName, cmd, cmdVars)
+	h.registerSensitive(cmdName, cmd, cmdVars)
+
+	// Execute the command with proper error handling
+	err = h.executeCommandWithDependencies(cmdName, cmd, cmdVars)
+	skipped := stderrors.Is(err, errUpToDate) || stderrors.Is(err, errConditionNotMet)
+	if skipped && h.FailOnSkip && stderrors.Is(err, errConditionNotMet) {
+		skipped = false
+		err = fmt.Errorf("command '%s' was skipped, its condition is not met: %s (--fail-on-skip)", cmdName, cmd.Condition)
+	} else if skipped {
+		err = nil
+	} else if err != nil {
+		// Give interrupted commands a chance to clean up
+		if run.Cancelled() {
+			h.runCancelHook(cmdName, cmd, cmdVars)
+		} else if h.DebugOnFailure {
+			h.debugFailure(cmdName, cmd, cmdVars, err)
+		}
 	}
+	h.emitCommandEnd(cmdName, start, failures, skipped, err)
+	endSpan(span, err)
+	run.recordExecution(cmdName, time.Since(start), skipped, err)
+
+	return err
+}
+
+// lookupCommand finds a command in the config by name. Subcommands are referenced
+// using the format parent:subcommandname, nested ones as parent:sub:subsub, and
+// inherit the settings of their parents (see config.Command.Inherit).
+func (h *CommandHandler) lookupCommand(cmdName string) (config.Command, error) {
+	parts := strings.Split(cmdName, ":")
 
 	// Get the command from the config
-	cmd, ok := h.Config.Commands[cmdName]
+	cmd, ok := h.Config.Commands[parts[0]]
 	if !ok {
-		return fmt.Errorf("command '%s' not found", cmdName)
+		return config.Command{}, errors.NewCommandNotFoundError(parts[0])
 	}
 
-	// Execute the command with proper error handling
-	if err := h.executeCommandWithDependencies(cmdName, cmd, cmdVars); err != nil {
-		return err
+	// Walk down to the subcommand, each level inheriting from the one above
+	for i, subCmdName := range parts[1:] {
+		subCmd, ok := cmd.Commands[subCmdName]
+		if !ok {
+			return config.Command{}, errors.NewCommandError(strings.Join(parts[:i+1], ":"), fmt.Sprintf("subcommand '%s' not found", subCmdName), nil)
+		}
+		cmd = subCmd.Inherit(cmd)
 	}
 
-	return nil
+	return cmd, nil
+}
+
+// inheritedParams returns the parameters of the parents of a subcommand, outermost
+// first. mergeParams picks the flag parameters the subcommand inherits from them.
+func (h *CommandHandler) inheritedParams(cmdName string) []config.Param {
+	parts := strings.Split(cmdName, ":")
+	if h.Config == nil || len(parts) < 2 {
+		return nil
+	}
+	var params []config.Param
+	commands := h.Config.Commands
+	for _, name := range parts[:len(parts)-1] {
+		parent, ok := commands[name]
+		if !ok {
+			break
+		}
+		params = append(params, parent.Params...)
+		commands = parent.Commands
+	}
+	return params
 }
 
-// executeCommandWithDependencies handles command execution with dependencies
+// executeCommandWithDependencies handles command execution with dependencies. The
+// parameter defaults must already be part of cmdVars, see withParamDefaults.
 func (h *CommandHandler) executeCommandWithDependencies(cmdName string, cmd config.Command, cmdVars map[string]string) error {
-	// Check if the command has a condition
-	if err := h.checkCommandCondition(cmdName, cmd, cmdVars); err != nil {
+	// Skip the command (and its dependencies) if its condition is not met
+	if !h.checkCommandCondition(cmdName, cmd, cmdVars) {
+		return errConditionNotMet
+	}
+
+	// Fail before the dependencies run if the tools the command needs are missing
+	if err := h.checkRequirements(cmdName, cmd); err != nil {
 		return err
 	}
 
 	// Execute dependencies first
-	if err := h.executeDependencies(cmdName, cmd.Depends, cmdVars); err != nil {
+	if err := h.executeDependencies(cmdName, cmd, cmdVars); err != nil {
 		return err
 	}
 
+	// Pass the output of the commands it needs to the command
+	cmdVars, err := h.withNeeds(cmdName, cmd, cmdVars)
+	if err != nil {
+		return err
+	}
+
+	// Let the commands it runs after go first
+	h.executeAfter(cmdName, cmd, cmdVars)
+
 	// If the command has subcommands, it's a command group - just list them
 	if len(cmd.Commands) > 0 {
 		return h.listSubcommands(cmdName, cmd)
 	}
 
+	// Skip the command if the files it generates are newer than its sources
+	if err := h.checkUpToDate(cmdName, cmd, cmdVars); err != nil {
+		return err
+	}
+
 	// Validate the command and determine if it's executable
 	if err := h.validateCommandExecutability(cmdName, cmd); err != nil {
 		return err
 	}
 
 	// Execute the command body (pre-hook, main command, post-hook)
-	return h.executeCommandBody(cmdName, cmd, cmdVars)
+	return h.withCIGroup(cmdName, func() error {
+		return h.withCapturedOutput(cmdName, cmd, func() error {
+			return h.withLogFiles(cmdName, cmd, cmdVars, func() error {
+				return h.withEnvironment(cmdName, cmdVars, func() error {
+					return h.withLimits(cmdName, cmd, func() error {
+						return h.withStall(cmdName, cmd, func() error {
+							return h.withMasking(func() error {
+								return h.executeCommandBody(cmdName, cmd, cmdVars)
+							})
+						})
+					})
+				})
+			})
+		})
+	})
+}
+
+// withParamDefaults returns a copy of cmdVars extended with the default values of the
+// command's parameters (and, for subcommands, the flag parameters inherited from the
+// parent). Values already present in cmdVars take precedence.
+func (h *CommandHandler) withParamDefaults(cmdName string, cmd config.Command, cmdVars map[string]string) map[string]string {
+	vars := h.paramDefaults(cmdName, cmd)
+	for k, v := range cmdVars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// paramDefaults returns the default parameter values of a command, including the
+// flag parameters a subcommand inherits from its parents
+func (h *CommandHandler) paramDefaults(cmdName string, cmd config.Command) map[string]string {
+	defaults := make(map[string]string)
+
+	for _, param := range mergeParams(h.inheritedParams(cmdName), cmd.Params) {
+		defaults[param.Name] = paramDefaultValue(param)
+	}
+
+	return defaults
 }
 
 // validateCommandExecutability checks if a command is executable
-// A command is executable if it has a run command, tasks, or is a dependency aggregator
+// A command is executable if it has a run command, tasks, steps, or is a dependency aggregator
 func (h *CommandHandler) validateCommandExecutability(cmdName string, cmd config.Command) error {
-	// If the command has no run or tasks defined, but has dependencies,
+	if err := h.validateRegister(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateNeeds(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateTasks(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateMatrix(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateForeach(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateService(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateContainer(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateRunner(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateLogFiles(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateOutput(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateLimits(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateMaxParallel(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateNotify(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateStdin(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validatePipe(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateTaskTimeouts(cmdName, cmd); err != nil {
+		return err
+	}
+	if err := h.validateStall(cmdName, cmd); err != nil {
+		return err
+	}
+
+	// If the command has no run, tasks or steps defined, but has dependencies,
 	// it's just a task aggregator, which is fine
-	if cmd.Run == "" && len(cmd.Tasks) == 0 {
+	if cmd.Run == "" && len(cmd.Tasks) == 0 && len(cmd.Steps) == 0 {
 		if len(cmd.Depends) > 0 {
 			// This is a valid dependency aggregator
 			return nil
 		}
 		// Command has no functionality defined
-		return fmt.Errorf("command '%s' has no 'run', 'tasks', or 'commands' defined", cmdName)
+		return errors.NewCommandConfigError(cmdName, "no 'run', 'tasks', 'steps', or 'commands' defined", nil)
 	}
 	
-	// Command has either run or tasks defined, so it's executable
+	// Command has run, tasks or steps defined, so it's executable
 	return nil
 }
 
-// checkCommandCondition evaluates a command's condition if present
-func (h *CommandHandler) checkCommandCondition(cmdName string, cmd config.Command, cmdVars map[string]string) error {
+// errConditionNotMet ends a command whose condition is not met before anything of
+// it runs. runResolvedCommand reports it as skipped, not failed, unless --fail-on-skip
+// is set.
+var errConditionNotMet = stderrors.New("condition not met")
+
+// checkCommandCondition evaluates a command's condition if present and reports
+// whether the command should run
+func (h *CommandHandler) checkCommandCondition(cmdName string, cmd config.Command, cmdVars map[string]string) bool {
 	if cmd.Condition == "" {
-		return nil
+		return true
 	}
 
 	// Evaluate the condition with parameter variables
-	if !h.Config.EvaluateConditionWithParams(cmd.Condition, cmdVars) {
-		fmt.Printf("Skipping command '%s' (condition not met: %s)\n", cmdName, cmd.Condition)
-		return nil
+	if !h.conditionMet(cmdName, cmd.Condition, cmdVars) {
+		h.printf("Skipping command '%s' (condition not met: %s)\n", cmdName, cmd.Condition)
+		return false
 	}
 
-	return nil
+	return true
 }
 
 // executeDependencies executes all dependencies for a command
-// executeDependencies executes all dependencies for a command
-func (h *CommandHandler) executeDependencies(cmdName string, dependencies []string, cmdVars map[string]string) error {
+func (h *CommandHandler) executeDependencies(cmdName string, cmd config.Command, cmdVars map[string]string) error {
 	// If there are no dependencies, return immediately
-	if len(dependencies) == 0 {
+	if len(cmd.Depends) == 0 {
 		return nil
 	}
 
-	// Special handling for check-all command
-	if cmdName == "check-all" {
-		return h.executeCheckAllDependencies(dependencies, cmdVars)
+	mode, err := h.dependsMode(cmdName, cmd)
+	if err != nil {
+		return err
 	}
 
-	// Standard behavior for other commands
+	// Skip the dependencies whose condition is not met
+	var dependencies []string
+	for _, dep := range cmd.Depends {
+		if !h.dependencyConditionMet(cmdName, dep, cmdVars) {
+			h.printf("Skipping dependency '%s' of '%s' (condition not met: %s)\n", dep.Command, cmdName, dep.Condition)
+			continue
+		}
+		dependencies = append(dependencies, dep.Command)
+	}
+
+	// In keep-going mode, and for depends_mode: all, run every dependency
+	// and report the failures together
+	if h.KeepGoing || mode == config.DependsModeAll {
+		return h.executeAllDependencies(cmdName, dependencies, cmdVars)
+	}
+
+	// Standard behavior, stopping at the first failure
 	return h.executeStandardDependencies(cmdName, dependencies, cmdVars)
 }
 
-// executeCheckAllDependencies executes dependencies for the check-all command,
-// continuing execution even if some dependencies fail
-func (h *CommandHandler) executeCheckAllDependencies(dependencies []string, cmdVars map[string]string) error {
+// dependencyConditionMet reports whether a dependency of a command runs: whether it
+// has no condition, or its condition is met with the variables of the command
+func (h *CommandHandler) dependencyConditionMet(cmdName string, dep config.Dependency, cmdVars map[string]string) bool {
+	return h.conditionMet(cmdName, dep.Condition, cmdVars)
+}
+
+// conditionMet evaluates a condition with the variables of a command. The commands
+// of output-of conditions run in the environment of the command; a dry run does not
+// run them and assumes the condition is met.
+func (h *CommandHandler) conditionMet(cmdName, condition string, cmdVars map[string]string) bool {
+	if condition == "" {
+		return true
+	}
+	if h.DryRun {
+		met, _ := h.plannedCondition(cmdName, condition, cmdVars)
+		return met
+	}
+
+	met := false
+	_ = h.withEnvironment(cmdName, cmdVars, func() error {
+		met = config.EvaluateConditionWithResolver(condition, h.resolver(cmdName, cmdVars), h.conditionOutput(cmdName))
+		return nil
+	})
+	return met
+}
+
+// plannedCondition evaluates a condition of a command for plans, which must not run
+// anything: output-of conditions are not evaluated and assumed to be met
+func (h *CommandHandler) plannedCondition(cmdName, condition string, cmdVars map[string]string) (met, evaluated bool) {
+	resolver := h.resolver(cmdName, cmdVars)
+	if config.ConditionRunsCommand(resolver.Resolve(condition)) {
+		return true, false
+	}
+	return config.EvaluateConditionWithResolver(condition, resolver, nil), true
+}
+
+// conditionOutput returns the function that runs the commands of the output-of
+// conditions of a command. They run through the executor as part of the run and
+// with the timeout of the command; their output is the value of the condition and
+// is not shown.
+func (h *CommandHandler) conditionOutput(cmdName string) config.OutputFunc {
+	return func(cmdStr string) (string, error) {
+		var timeout time.Duration
+		if cmd, err := h.lookupCommand(cmdName); err == nil {
+			timeout, _ = h.parseTimeout(cmdName, h.commandTimeout(cmd))
+		}
+
+		stdout := h.Executor.GetStdout()
+		h.Executor.SetStdout(io.Discard)
+		defer h.Executor.SetStdout(stdout)
+		return h.executeWithOutput(cmdStr, timeout)
+	}
+}
+
+// dependsMode returns how the dependencies of a command are executed
+func (h *CommandHandler) dependsMode(cmdName string, cmd config.Command) (string, error) {
+	switch cmd.DependsMode {
+	case "":
+		// Before depends_mode existed, a command named check-all always ran all of
+		// its dependencies. Keep that behavior for existing configs.
+		if cmdName == "check-all" {
+			fmt.Fprintf(os.Stderr, "Warning: running all dependencies of 'check-all' because of its name is deprecated, set 'depends_mode: all' on the command instead\n")
+			return config.DependsModeAll, nil
+		}
+		return config.DependsModeFailFast, nil
+	case config.DependsModeFailFast, config.DependsModeAll:
+		return cmd.DependsMode, nil
+	default:
+		return "", errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid depends_mode '%s': expected '%s' or '%s'",
+			cmd.DependsMode, config.DependsModeAll, config.DependsModeFailFast), nil)
+	}
+}
+
+// executeAllDependencies executes all dependencies, continuing execution even
+// if some dependencies fail
+func (h *CommandHandler) executeAllDependencies(cmdName string, dependencies []string, cmdVars map[string]string) error {
 	// Execute all dependencies and collect errors
-	errors := h.executeAllDependenciesWithErrorCollection(dependencies, cmdVars)
+	errors := h.executeAllDependenciesWithErrorCollection(cmdName, dependencies, cmdVars)
 
 	// Return a combined error if any dependencies failed
 	return h.formatDependencyErrors(errors)
@@ -166,14 +615,20 @@ func (h *CommandHandler) executeCheckAllDependencies(dependencies []string, cmdV
 
 // executeAllDependenciesWithErrorCollection executes all dependencies and collects errors
 // without stopping on the first error
-func (h *CommandHandler) executeAllDependenciesWithErrorCollection(dependencies []string, cmdVars map[string]string) []string {
+func (h *CommandHandler) executeAllDependenciesWithErrorCollection(cmdName string, dependencies []string, cmdVars map[string]string) []string {
 	var errors []string
 
 	for _, dep := range dependencies {
+		// Stop starting new dependencies once the run was interrupted
+		if h.RunContext().Cancelled() {
+			errors = append(errors, fmt.Sprintf("'%s': %v", dep, context.Canceled))
+			break
+		}
+
 		// Don't print the execution message here, it will be printed in runMainCommand
-		if err := h.ExecuteCommand(dep, cmdVars); err != nil {
+		if err := h.executeDependency(cmdName, dep, cmdVars); err != nil {
 			// Log the error but continue with other dependencies
-			fmt.Printf("Error executing command '%s': %v\n", dep, err)
+			h.printf("Error executing command '%s': %s\n", dep, errorMessage(dep, err))
 			errors = append(errors, fmt.Sprintf("'%s': %v", dep, err))
 		}
 	}
@@ -201,26 +656,43 @@ func (h *CommandHandler) executeStandardDependencies(cmdName string, dependencie
 // executeSequentialDependencies executes dependencies in sequence and stops at the first error
 func (h *CommandHandler) executeSequentialDependencies(cmdName string, dependencies []string, cmdVars map[string]string) error {
 	for _, dep := range dependencies {
-		if err := h.ExecuteCommand(dep, cmdVars); err != nil {
-			return fmt.Errorf("failed to execute dependency '%s' for command '%s': %w", dep, cmdName, err)
+		if err := h.executeDependency(cmdName, dep, cmdVars); err != nil {
+			return errors.NewDependencyError(cmdName, dep, err)
 		}
 	}
 
 	return nil
 }
 
+// executeDependency executes the dependency dep of a command, with its output
+// prefixed with its name
+func (h *CommandHandler) executeDependency(cmdName, dep string, cmdVars map[string]string) error {
+	return h.withDependencyPrefix(dep, func() error {
+		return h.executeCommand(cmdName, dep, cmdVars)
+	})
+}
+
 // executeCommandBody executes the main command body including pre/post hooks
 func (h *CommandHandler) executeCommandBody(cmdName string, cmd config.Command, cmdVars map[string]string) error {
 	if err := h.runPreHook(cmdName, cmd, cmdVars); err != nil {
 		return err
 	}
 
-	timeout, err := h.parseTimeout(cmdName, cmd.Timeout)
+	timeout, err := h.parseTimeout(cmdName, h.commandTimeout(cmd))
 	if err != nil {
 		return err
 	}
+	if timeout > 0 {
+		h.printf("Command '%s' will timeout after %s\n", cmdName, timeout)
+	}
 
-	if err := h.runMainCommand(cmdName, cmd, cmdVars, timeout); err != nil {
+	// Hooks run on the host, only the main command runs with the runner
+	start := time.Now()
+	err = h.withRunner(cmdName, cmd, cmdVars, func() error {
+		return h.runMainCommand(cmdName, cmd, cmdVars, timeout)
+	})
+	h.printDuration(cmdName, start, err)
+	if err != nil {
 		return err
 	}
 
@@ -233,96 +705,118 @@ func (h *CommandHandler) executeCommandBody(cmdName string, cmd config.Command,
 
 // runPreHook executes the pre-hook if defined
 func (h *CommandHandler) runPreHook(cmdName string, cmd config.Command, cmdVars map[string]string) error {
-	return h.executeHook(cmdName, "pre", cmd.Pre, cmdVars)
+	timeout, err := h.parseTimeout(cmdName, cmd.PreTimeout)
+	if err != nil {
+		return err
+	}
+	return h.executeHook(cmdName, "pre", cmd.Pre, cmdVars, timeout)
 }
 
 // runMainCommand handles the main command execution logic
 func (h *CommandHandler) runMainCommand(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
-	fmt.Printf("Executing command '%s'...\n", cmdName)
+	h.printf("Executing command '%s'...\n", cmdName)
 
 	// Check for subcommands first
 	if len(cmd.Commands) > 0 {
 		return h.listSubcommands(cmdName, cmd)
 	}
 
-	if cmd.Run != "" {
+	if cmd.Run != "" && len(cmd.Matrix) > 0 {
+		return h.runMatrixCommands(cmdName, cmd, cmdVars, timeout)
+	} else if cmd.Run != "" && cmd.Foreach != "" {
+		return h.runForeachCommands(cmdName, cmd, cmdVars, timeout)
+	} else if cmd.Run != "" {
 		return h.runSingleCommand(cmdName, cmd, cmdVars, timeout)
 	} else if len(cmd.Tasks) > 0 {
 		if cmd.Parallel {
 			return h.runParallelCommands(cmdName, cmd, cmdVars, timeout)
 		}
 		return h.runSequentialCommands(cmdName, cmd, cmdVars, timeout)
+	} else if len(cmd.Steps) > 0 {
+		return h.runScriptSteps(cmdName, cmd, cmdVars, timeout)
 	}
 	return nil
 }
 
 // runSingleCommand executes a single command (Run)
 func (h *CommandHandler) runSingleCommand(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
-	cmdStr := h.replaceVariablesInString(cmd.Run, cmdVars)
-	if h.DryRun {
-		fmt.Printf("[dry-run] Would execute: %s\n", cmdStr)
+	cmdStr := h.replaceVariablesInString(cmdName, cmd.Run, cmdVars)
+	return h.withStdin(cmdName, cmd, cmdVars, func() error {
+		if len(cmd.Register) > 0 {
+			return h.runAndRegister(cmdName, cmd, cmdStr, timeout)
+		}
+		if err := h.execute(cmdStr, timeout); err != nil {
+			return errors.NewExecutionError(cmdName, err)
+		}
 		return nil
-	}
-	if err := h.Executor.Execute(cmdStr, timeout); err != nil {
-		return fmt.Errorf("failed to execute command '%s': %w", cmdName, err)
-	}
-	return nil
+	})
 }
 
 // runParallelCommands executes tasks in parallel
 func (h *CommandHandler) runParallelCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
-	if h.DryRun {
-		for _, subCmd := range cmd.Tasks {
-			cmdStr := h.replaceVariablesInString(subCmd, cmdVars)
-			fmt.Printf("[dry-run] Would execute (parallel): %s\n", cmdStr)
-		}
-		return nil
-	}
-	if err := h.executeParallelCommands(cmdName, cmd, timeout); err != nil {
-		return fmt.Errorf("failed to execute parallel commands for '%s': %w", cmdName, err)
+	if err := h.executeParallelCommands(cmdName, cmd, cmdVars, timeout); err != nil {
+		return errors.NewCommandError(cmdName, "failed to execute parallel commands", err)
 	}
 	return nil
 }
 
 // runSequentialCommands executes tasks sequentially
 func (h *CommandHandler) runSequentialCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
-	if h.DryRun {
-		for _, subCmd := range cmd.Tasks {
-			cmdStr := h.replaceVariablesInString(subCmd, cmdVars)
-			fmt.Printf("[dry-run] Would execute (sequential): %s\n", cmdStr)
+	if cmd.Pipe {
+		if err := h.runPipeline(cmdName, h.taskJobs(cmdName, cmd, cmdVars), timeout); err != nil {
+			return errors.NewCommandError(cmdName, "failed to execute piped commands", err)
 		}
 		return nil
 	}
-	if err := h.executeSequentialCommands(cmdName, cmd, timeout); err != nil {
-		return fmt.Errorf("failed to execute sequential commands for '%s': %w", cmdName, err)
+	if err := h.executeSequentialCommands(cmdName, cmd, cmdVars, timeout); err != nil {
+		return errors.NewCommandError(cmdName, "failed to execute sequential commands", err)
 	}
 	return nil
 }
 
 // runPostHook executes the post-hook if defined
 func (h *CommandHandler) runPostHook(cmdName string, cmd config.Command, cmdVars map[string]string) error {
-	return h.executeHook(cmdName, "post", cmd.Post, cmdVars)
+	timeout, err := h.parseTimeout(cmdName, cmd.PostTimeout)
+	if err != nil {
+		return err
+	}
+	return h.executeHook(cmdName, "post", cmd.Post, cmdVars, timeout)
 }
 
-// executeHook executes a pre or post hook for a command
-func (h *CommandHandler) executeHook(cmdName, hookType, hookCmd string, cmdVars map[string]string) error {
+// executeHook executes a pre or post hook for a command, stopping it after timeout
+// if it is not 0
+func (h *CommandHandler) executeHook(cmdName, hookType, hookCmd string, cmdVars map[string]string, timeout time.Duration) error {
 	if hookCmd == "" {
 		return nil
 	}
 
-	fmt.Printf("Executing %s-hook for '%s'...\n", hookType, cmdName)
-	hookCmdStr := h.replaceVariablesInString(hookCmd, cmdVars)
-	if h.DryRun {
-		fmt.Printf("[dry-run] Would execute (%s-hook): %s\n", hookType, hookCmdStr)
-		return nil
-	}
-	if err := h.Executor.Execute(hookCmdStr, 0); err != nil {
-		return fmt.Errorf("failed to execute %s-hook for command '%s': %w", hookType, cmdName, err)
+	h.printf("Executing %s-hook for '%s'...\n", hookType, cmdName)
+	hookCmdStr := h.replaceVariablesInString(cmdName, hookCmd, cmdVars)
+	h.emit(events.Event{Type: events.HookStart, Command: cmdName, Hook: hookType})
+	span := h.startSpan(cmdName+": "+hookType+"-hook", cmdName, tracing.String("yxa.command", cmdName), tracing.String("yxa.hook", hookType))
+	err := h.execute(hookCmdStr, timeout)
+	endSpan(span, err)
+	if err != nil {
+		return errors.NewHookError(cmdName, hookType, err)
 	}
 
 	return nil
 }
 
+// commandTimeout returns the timeout a command runs with: its own, else that of
+// --timeout, else the default_timeout of the config
+func (h *CommandHandler) commandTimeout(cmd config.Command) string {
+	switch {
+	case cmd.Timeout != "":
+		return cmd.Timeout
+	case h.Timeout != "":
+		return h.Timeout
+	case h.Config != nil:
+		return h.Config.DefaultTimeout
+	}
+	return ""
+}
+
 // parseTimeout parses the timeout string into a time.Duration
 func (h *CommandHandler) parseTimeout(cmdName, timeoutStr string) (time.Duration, error) {
 	if timeoutStr == "" {
@@ -331,16 +825,35 @@ func (h *CommandHandler) parseTimeout(cmdName, timeoutStr string) (time.Duration
 
 	timeout, err := time.ParseDuration(timeoutStr)
 	if err != nil {
-		return 0, fmt.Errorf("invalid timeout '%s' for command '%s': %w", timeoutStr, cmdName, err)
+		return 0, errors.NewTimeoutError(cmdName, timeoutStr, err)
 	}
 
-	fmt.Printf("Command '%s' will timeout after %s\n", cmdName, timeout)
 	return timeout, nil
 }
 
 // replaceVariablesInString replaces variables in a string with their values from the provided map
-func (h *CommandHandler) replaceVariablesInString(input string, vars map[string]string) string {
-	return h.Config.ReplaceVariablesWithParams(input, vars)
+// and the built-in variables of the given command
+func (h *CommandHandler) replaceVariablesInString(cmdName, input string, vars map[string]string) string {
+	return h.resolver(cmdName, vars).Resolve(input)
+}
+
+// resolver creates a variable resolver for the given command with all variable sources,
+// highest precedence first: invocation overrides, registered output, the provided
+// variables (the parameters), the variables of the command, config, encrypted, .env,
+// built-in and system environment variables. Every stage of a command resolves its
+// variables with it. Commands that do not inherit the environment do not see system
+// environment variables.
+func (h *CommandHandler) resolver(cmdName string, vars map[string]string) *variables.Resolver {
+	cmdScopeVars, inheritEnv := h.commandScope(cmdName)
+	resolver := h.Config.NewResolver(vars, h.builtinVars(cmdName)).
+		WithCommandVars(cmdScopeVars).
+		WithSystemEnvVar(inheritEnv).
+		WithOverrideVars(h.overrides).
+		WithRegisterVars(h.RunContext().registeredVars())
+	if h.DebugVars != nil {
+		resolver.WithTrace(func(s variables.Substitution) { h.traceSubstitution(cmdName, s) })
+	}
+	return resolver
 }
 
 // listSubcommands lists all subcommands of a command
@@ -413,8 +926,8 @@ func (h *CommandHandler) printSubcommandList(stdout io.Writer, commands map[stri
 
 // getSubcommandDescription returns the description for a subcommand, with fallbacks
 func (h *CommandHandler) getSubcommandDescription(cmd config.Command) string {
-	if cmd.Description != "" {
-		return cmd.Description
+	if description := listedDescription(cmd); description != "" {
+		return description
 	}
 	
 	if cmd.Run != "" {
@@ -425,19 +938,46 @@ func (h *CommandHandler) getSubcommandDescription(cmd config.Command) string {
 }
 
 // executeSequentialCommands executes multiple tasks sequentially
-func (h *CommandHandler) executeSequentialCommands(cmdName string, cmd config.Command, timeout time.Duration) error {
-	for i, cmdStr := range cmd.Tasks {
-		cmdStr = h.replaceVariablesInString(cmdStr, nil)
-		fmt.Printf("Executing sequential sub-command #%d for '%s'...\n", i+1, cmdName)
+func (h *CommandHandler) executeSequentialCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
+	return h.runSequentialJobs(cmdName, cmd, h.taskJobs(cmdName, cmd, cmdVars), timeout)
+}
 
-		err := h.Executor.Execute(cmdStr, timeout)
+// runSequentialJobs runs the jobs one after another. It stops at the first failing
+// job unless keep-going mode or continue_on_error is enabled, in which case the
+// remaining jobs still run and the failures are reported together
+func (h *CommandHandler) runSequentialJobs(cmdName string, cmd config.Command, jobs []taskJob, timeout time.Duration) error {
+	continueOnError := h.KeepGoing || cmd.ContinueOnError
+	var errors []string
+
+	for _, job := range jobs {
+		h.printf("Executing sequential sub-command %s for '%s'...\n", job.ID, cmdName)
+
+		var err error
+		if job.Ref != "" {
+			// Referenced commands always run, like a shell task would, unless they
+			// already ran early for a command that runs after them
+			var ranEarly bool
+			if ranEarly, err = h.RunContext().takeEarlyResult(job.Ref); !ranEarly {
+				err = h.runCommand(cmdName, job.Ref, job.Vars)
+			}
+		} else {
+			err = h.executeTask(cmdName, job.Task, job.Command, job.timeout(timeout))
+		}
 		if flusher, ok := h.Executor.GetStdout().(interface{ Flush() error }); ok {
 			_ = flusher.Flush()
 		}
 		if err != nil {
-			return fmt.Errorf("sub-command #%d for '%s' failed: %w", i+1, cmdName, err)
+			if !continueOnError || h.RunContext().Cancelled() {
+				return fmt.Errorf("sub-command %s for '%s' failed: %w", job.ID, cmdName, err)
+			}
+			h.printf("Sub-command %s for '%s' failed: %v\n", job.ID, cmdName, err)
+			errors = append(errors, fmt.Sprintf("%s: %v", job.ID, err))
 		}
 	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("one or more sub-commands for '%s' failed: %s", cmdName, strings.Join(errors, "; "))
+	}
 	return nil
 }
 
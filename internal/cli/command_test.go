@@ -2,15 +2,19 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/floppa/yxa-cli/internal/config"
+	yxaerrors "github.com/floppa/yxa-cli/internal/errors"
 	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/variables"
 )
 
 // testExecutor is a simple executor that returns predefined results for commands
@@ -103,7 +107,7 @@ func TestCommandHandler_ExecuteCommand(t *testing.T) {
 			"with-deps": {
 				Run:         "echo 'with dependencies'",
 				Description: "Command with dependencies",
-				Depends:     []string{"test", "dependent"},
+				Depends:     config.NewDependencyList("test", "dependent"),
 			},
 			"with-condition": {
 				Run:         "echo 'conditional command'",
@@ -230,6 +234,50 @@ func TestCommandHandler_ExecuteCommandWithTimeout(t *testing.T) {
 	}
 }
 
+func TestCommandHandler_DefaultTimeout(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DefaultTimeout: "100ms",
+		Commands: map[string]config.Command{
+			"slow": {Run: "sleep 2"},
+			"own":  {Run: "sleep 0.3", Timeout: "5s"},
+		},
+	}
+	newHandler := func() *CommandHandler {
+		buf := &strings.Builder{}
+		realExec := executor.NewDefaultExecutor()
+		realExec.SetStdout(buf)
+		realExec.SetStderr(buf)
+		return NewCommandHandler(cfg, realExec)
+	}
+
+	t.Run("default_timeout applies to commands without timeout", func(t *testing.T) {
+		err := newHandler().ExecuteCommand("slow", nil)
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Expected the command to time out, got: %v", err)
+		}
+	})
+
+	t.Run("the timeout of the command takes precedence", func(t *testing.T) {
+		handler := newHandler()
+		handler.SetTimeout("50ms")
+		if err := handler.ExecuteCommand("own", nil); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("--timeout takes precedence over default_timeout", func(t *testing.T) {
+		handler := newHandler()
+		handler.SetTimeout("soon")
+		err := handler.ExecuteCommand("slow", nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid timeout 'soon'") {
+			t.Errorf("Expected an invalid timeout, got: %v", err)
+		}
+		if got := handler.commandTimeout(cfg.Commands["slow"]); got != "soon" {
+			t.Errorf("commandTimeout() = %q, want soon", got)
+		}
+	})
+}
+
 func TestCommandHandler_ExecuteCommandWithParams(t *testing.T) {
 	buf := &strings.Builder{}
 	realExec := executor.NewDefaultExecutor()
@@ -311,6 +359,118 @@ func TestCommandHandler_ExecuteCommandWithParams(t *testing.T) {
 	}
 }
 
+func TestCommandHandler_BuiltinVariables(t *testing.T) {
+	buf := &strings.Builder{}
+	realExec := executor.NewDefaultExecutor()
+	realExec.SetStdout(buf)
+	realExec.SetStderr(buf)
+
+	cfg := &config.ProjectConfig{
+		Name: "builtin-project",
+		Commands: map[string]config.Command{
+			"dep": {
+				Run: "echo dep=$YXA_COMMAND run=$YXA_RUN_ID",
+			},
+			"main": {
+				Run:     "echo main=$YXA_COMMAND project=$YXA_PROJECT_NAME run=${YXA_RUN_ID} at=$YXA_TIMESTAMP",
+				Pre:     "echo pre=$YXA_COMMAND",
+				Depends: config.NewDependencyList("dep"),
+			},
+			"group": {
+				Commands: map[string]config.Command{
+					"sub": {Run: "echo sub=$YXA_COMMAND"},
+				},
+			},
+		},
+	}
+
+	handler := NewCommandHandler(cfg, realExec)
+	if err := handler.ExecuteCommand("group:sub", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := handler.ExecuteCommand("main", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	runID := handler.RunContext().ID
+	timestamp := handler.RunContext().StartedAt.Format(variables.TimestampFormat)
+	assertOutputContains(t, output,
+		"dep=dep run="+runID,
+		"pre=main",
+		"main=main project=builtin-project run="+runID+" at="+timestamp,
+		"sub=group:sub",
+	)
+	if runID == "" {
+		t.Errorf("Expected a non-empty run ID")
+	}
+}
+
+func TestCommandHandler_ParamsBeforeConditions(t *testing.T) {
+	buf := &strings.Builder{}
+	realExec := executor.NewDefaultExecutor()
+	realExec.SetStdout(buf)
+	realExec.SetStderr(buf)
+
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"migrate": {
+				Run:       "echo migrating $target",
+				Condition: "$target == db",
+				Params: []config.Param{
+					{Name: "target", Type: "string", Default: "db", Flag: true},
+				},
+			},
+			"deploy": {
+				Tasks:     config.NewTaskList("echo deploying to $env"),
+				Pre:       "echo pre for $env",
+				Condition: "$env != none",
+				Depends:   config.NewDependencyList("migrate"),
+				Params: []config.Param{
+					{Name: "env", Type: "string", Default: "staging", Flag: true},
+				},
+			},
+			"skipped": {
+				Run:       "echo should not run",
+				Condition: "$env == prod",
+				Depends:   config.NewDependencyList("migrate"),
+				Params: []config.Param{
+					{Name: "env", Type: "string", Default: "dev", Flag: true},
+				},
+			},
+		},
+	}
+
+	t.Run("defaults are visible to conditions, hooks, tasks and dependencies", func(t *testing.T) {
+		buf.Reset()
+		handler := NewCommandHandler(cfg, realExec)
+		if err := handler.ExecuteCommand("deploy", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertOutputContains(t, buf.String(), "migrating db", "pre for staging", "deploying to staging")
+	})
+
+	t.Run("provided values override defaults", func(t *testing.T) {
+		buf.Reset()
+		handler := NewCommandHandler(cfg, realExec)
+		if err := handler.ExecuteCommand("deploy", map[string]string{"env": "prod"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertOutputContains(t, buf.String(), "pre for prod", "deploying to prod")
+	})
+
+	t.Run("unmet condition skips the command and its dependencies", func(t *testing.T) {
+		buf.Reset()
+		handler := NewCommandHandler(cfg, realExec)
+		if err := handler.ExecuteCommand("skipped", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Contains(buf.String(), "should not run") || strings.Contains(buf.String(), "migrating") {
+			t.Errorf("Expected command and dependencies to be skipped, got '%s'", buf.String())
+		}
+	})
+}
+
 func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 	t.Run("Parallel commands: one fails, should return error", func(t *testing.T) {
 		buf := &strings.Builder{}
@@ -331,7 +491,7 @@ func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 				},
 				"parallel-parent": {
 					Parallel: true,
-					Tasks: []string{"fail", "ok"},
+					Tasks: config.NewTaskList("fail", "ok"),
 				},
 			},
 		}
@@ -352,13 +512,13 @@ func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 			Commands: map[string]config.Command{
 				"parallel-empty": {
 					Parallel: true,
-					Tasks: []string{},
+					Tasks: config.NewTaskList(),
 				},
 			},
 		}
 		handler := NewCommandHandler(cfg, realExec)
 		err := handler.ExecuteCommand("parallel-empty", nil)
-		if err == nil || !strings.Contains(err.Error(), "has no 'run', 'tasks', or 'commands' defined") {
+		if err == nil || !strings.Contains(err.Error(), "no 'run', 'tasks', 'steps', or 'commands' defined") {
 			t.Errorf("Expected error for empty parallel tasks, got: %v", err)
 		}
 	})
@@ -374,7 +534,7 @@ func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 				"fail": {Run: "sh -c 'exit 1'", Description: "Fails intentionally"},
 				"ok":   {Run: "echo 'ok'", Description: "Succeeds"},
 				"sequential-parent": {
-					Tasks: []string{"fail", "ok"},
+					Tasks: config.NewTaskList("fail", "ok"),
 				},
 			},
 		}
@@ -394,13 +554,13 @@ func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 			Name: "test-project",
 			Commands: map[string]config.Command{
 				"sequential-empty": {
-					Tasks: []string{},
+					Tasks: config.NewTaskList(),
 				},
 			},
 		}
 		handler := NewCommandHandler(cfg, realExec)
 		err := handler.ExecuteCommand("sequential-empty", nil)
-		if err == nil || !strings.Contains(err.Error(), "has no 'run', 'tasks', or 'commands' defined") {
+		if err == nil || !strings.Contains(err.Error(), "no 'run', 'tasks', 'steps', or 'commands' defined") {
 			t.Errorf("Expected error for empty sequential tasks, got: %v", err)
 		}
 	})
@@ -418,7 +578,7 @@ func TestCommandHandler_ParallelAndSequentialEdgeCases(t *testing.T) {
 		Commands: map[string]config.Command{
 			"parallel-parent": {
 				Parallel: true,
-				Tasks: []string{"echo 'parallel1'", "echo 'parallel2'"},
+				Tasks: config.NewTaskList("echo 'parallel1'", "echo 'parallel2'"),
 			},
 			"parallel1": {
 				Run:         "echo 'parallel1'",
@@ -453,7 +613,7 @@ func TestCommandHandler_SequentialCommands_Success(t *testing.T) {
 		Name: "test-project",
 		Commands: map[string]config.Command{
 			"sequential-parent": {
-				Tasks: []string{"echo 'seq1'", "echo 'seq2'"},
+				Tasks: config.NewTaskList("echo 'seq1'", "echo 'seq2'"),
 			},
 			"seq1": {
 				Run:         "echo 'seq1'",
@@ -584,7 +744,7 @@ func testInvalidSubcommandName(t *testing.T) {
 	handler, _ := setupSubcommandTest(t)
 	
 	err := handler.ExecuteCommand("parent:99", nil)
-	assertErrorContains(t, err, "not found in command")
+	assertErrorContains(t, err, "command 'parent': subcommand")
 }
 
 // testInvalidSubcommandFormat tests executing an invalid subcommand format
@@ -592,7 +752,7 @@ func testInvalidSubcommandFormat(t *testing.T) {
 	handler, _ := setupSubcommandTest(t)
 	
 	err := handler.ExecuteCommand("parent:invalid", nil)
-	assertErrorContains(t, err, "not found in command")
+	assertErrorContains(t, err, "command 'parent': subcommand")
 }
 
 func TestCommandHandler_ExecuteCommand_ErrorCases(t *testing.T) {
@@ -602,7 +762,7 @@ func TestCommandHandler_ExecuteCommand_ErrorCases(t *testing.T) {
 			"missing-run": {}, // No Run or Commands
 			"parent": {
 				Parallel: true,
-				Tasks: []string{"echo $PARAM1", "echo $PARAM2"},
+				Tasks: config.NewTaskList("echo $PARAM1", "echo $PARAM2"),
 				Params: []config.Param{
 					{Name: "PARAM1", Type: "string", Default: "default1"},
 					{Name: "PARAM2", Type: "string", Default: "default2"},
@@ -659,19 +819,19 @@ func TestCommandHandler_HookCases(t *testing.T) {
 	handler := NewCommandHandler(cfg, exec)
 
 	// Pre-hook with variable substitution
-	err := handler.executeHook("hook-cmd", "pre", "echo $PARAM", map[string]string{"PARAM": "test"})
+	err := handler.executeHook("hook-cmd", "pre", "echo $PARAM", map[string]string{"PARAM": "test"}, 0)
 	if err != nil {
 		t.Errorf("Unexpected error for hook with variable: %v", err)
 	}
 
 	// Empty post-hook should not error
-	err = handler.executeHook("hook-cmd", "post", "", nil)
+	err = handler.executeHook("hook-cmd", "post", "", nil, 0)
 	if err != nil {
 		t.Errorf("Unexpected error for empty post-hook: %v", err)
 	}
 
 	// Failing hook should error
-	err = handler.executeHook("hook-fail", "pre", "false", nil)
+	err = handler.executeHook("hook-fail", "pre", "false", nil, 0)
 	if err == nil {
 		t.Errorf("Expected error for failing hook, got nil")
 	}
@@ -730,9 +890,9 @@ func TestCommandHandler_SequentialCommands_Timeout(t *testing.T) {
 			"sequential-timeout": {
 				Parallel: false,
 				Timeout:  "100ms",
-				Tasks: []string{
+				Tasks: config.NewTaskList(
 					"sleep 1",
-				},
+				),
 			},
 		},
 	}
@@ -756,7 +916,7 @@ func TestCommandHandler_SequentialCommands_Failure(t *testing.T) {
 				Run:         "",
 				Description: "Parent with failing sequential command",
 				Parallel:    false,
-				Tasks:       []string{"echo 'seq1'", "echo 'fail'; exit 1"},
+				Tasks:       config.NewTaskList("echo 'seq1'", "echo 'fail'; exit 1"),
 			},
 		},
 	}
@@ -799,8 +959,8 @@ func TestCommandHandler_ExecuteCommandWithInvalidCommand(t *testing.T) {
 }
 
 func TestCommandHandler_ExecuteCommandWithCircularDependencies(t *testing.T) {
-	// This test should not be needed since we validate circular dependencies
-	// at config load time, but we'll include it for completeness
+	// Circular dependencies are validated at config load time, but configs built
+	// in code and task references reach the handler without that check
 	buf := &strings.Builder{}
 	realExec := executor.NewDefaultExecutor()
 	realExec.SetStdout(buf)
@@ -812,26 +972,44 @@ func TestCommandHandler_ExecuteCommandWithCircularDependencies(t *testing.T) {
 			"circular1": {
 				Run:         "echo 'circular1'",
 				Description: "First circular command",
-				Depends:     []string{"circular2"},
+				Depends:     config.NewDependencyList("circular2"),
 			},
 			"circular2": {
 				Run:         "echo 'circular2'",
 				Description: "Second circular command",
-				Depends:     []string{"circular1"},
+				Depends:     config.NewDependencyList("circular1"),
 			},
 		},
 	}
 
 	handler := NewCommandHandler(cfg, realExec)
 
+	// The cycle is reported with its full path instead of being cut short by the
+	// deduplication of executed commands
 	err := handler.ExecuteCommand("circular1", nil)
-	if err != nil {
-		// We expect this to succeed because we track executed commands
-		t.Errorf("ExecuteCommand() with circular deps error = %v", err)
+	want := "circular dependency detected: circular1 -> circular2 -> circular1"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("ExecuteCommand() with circular deps error = %v, want %q", err, want)
 	}
-	output := buf.String()
-	if output == "" {
-		t.Errorf("Expected at least one command to execute, got empty output")
+	var cmdErr *yxaerrors.CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Errorf("Expected a CommandError, got %T", err)
+	}
+	if output := buf.String(); output != "" {
+		t.Errorf("Expected no command to execute, got %q", output)
+	}
+
+	// Cycles through the tasks of subcommands are found as well
+	cfg.Commands["group"] = config.Command{
+		Commands: map[string]config.Command{
+			"a": {Tasks: config.TaskList{{Task: "group:b"}}},
+			"b": {Tasks: config.TaskList{{Task: "group:a"}}},
+		},
+	}
+	err = handler.ExecuteCommand("group:a", nil)
+	want = "circular dependency detected: group:a -> group:b -> group:a"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("ExecuteCommand() with circular tasks error = %v, want %q", err, want)
 	}
 }
 
@@ -952,11 +1130,11 @@ func TestRunParallelCommands(t *testing.T) {
 		// Create a command with parallel tasks
 		cmd := config.Command{
 			Run:   "",
-			Tasks: []string{
+			Tasks: config.NewTaskList(
 				"echo 'Task 1'",
 				"echo 'Task 2'",
 				"echo 'Task 3'",
-			},
+			),
 		}
 
 		// Run the parallel commands
@@ -996,11 +1174,11 @@ func TestRunSequentialCommands(t *testing.T) {
 		// Create a command with sequential tasks
 		cmd := config.Command{
 			Run:   "",
-			Tasks: []string{
+			Tasks: config.NewTaskList(
 				"echo 'Task 1'",
 				"echo 'Task 2'",
 				"echo 'Task 3'",
-			},
+			),
 		}
 
 		// Run the sequential commands
@@ -1013,3 +1191,333 @@ func TestRunSequentialCommands(t *testing.T) {
 		handler.SetDryRun(false)
 	})
 }
+
+func TestCommandHandler_KeepGoing(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"lint": {Run: "lint"},
+			"vet":  {Run: "vet"},
+			"test": {Run: "test"},
+			"ci": {
+				Depends: config.NewDependencyList("lint", "vet", "test"),
+			},
+			"steps": {
+				Tasks: config.NewTaskList("step1", "step2", "step3"),
+			},
+			"tolerant": {
+				Tasks:           config.NewTaskList("step1", "step2", "step3"),
+				ContinueOnError: true,
+			},
+		},
+	}
+	results := map[string]error{
+		"vet":   errors.New("vet failed"),
+		"step2": errors.New("step2 failed"),
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		keepGoing    bool
+		wantExecuted []string
+		wantErr      []string
+	}{
+		{
+			name:         "dependencies stop at first failure",
+			command:      "ci",
+			wantExecuted: []string{"lint", "vet"},
+			wantErr:      []string{"failed to execute dependency 'vet'"},
+		},
+		{
+			name:         "dependencies keep going",
+			command:      "ci",
+			keepGoing:    true,
+			wantExecuted: []string{"lint", "vet", "test"},
+			wantErr:      []string{"one or more dependencies failed", "'vet': command 'vet': execution failed: vet failed"},
+		},
+		{
+			name:         "tasks stop at first failure",
+			command:      "steps",
+			wantExecuted: []string{"step1", "step2"},
+			wantErr:      []string{"sub-command #2 for 'steps' failed"},
+		},
+		{
+			name:         "tasks keep going",
+			command:      "steps",
+			keepGoing:    true,
+			wantExecuted: []string{"step1", "step2", "step3"},
+			wantErr:      []string{"one or more sub-commands for 'steps' failed", "#2: step2 failed"},
+		},
+		{
+			name:         "tasks with continue_on_error",
+			command:      "tolerant",
+			wantExecuted: []string{"step1", "step2", "step3"},
+			wantErr:      []string{"one or more sub-commands for 'tolerant' failed", "#2: step2 failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard, commandResults: results}}
+			handler := NewCommandHandler(cfg, exec)
+			handler.SetKeepGoing(tt.keepGoing)
+
+			err := handler.ExecuteCommand(tt.command, nil)
+			if err == nil {
+				t.Fatalf("Expected an error, got nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got %q", want, err.Error())
+				}
+			}
+			if !reflect.DeepEqual(exec.executed, tt.wantExecuted) {
+				t.Errorf("Expected executed commands %v, got %v", tt.wantExecuted, exec.executed)
+			}
+		})
+	}
+}
+
+func TestCommandHandler_DependsMode(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"lint": {Run: "lint"},
+			"vet":  {Run: "vet"},
+			"test": {Run: "test"},
+			"all-checks": {
+				Depends:     config.NewDependencyList("lint", "vet", "test"),
+				DependsMode: config.DependsModeAll,
+			},
+			"fail-fast": {
+				Depends:     config.NewDependencyList("lint", "vet", "test"),
+				DependsMode: config.DependsModeFailFast,
+			},
+			"check-all": {
+				Depends: config.NewDependencyList("lint", "vet", "test"),
+			},
+			"invalid": {
+				Depends:     config.NewDependencyList("lint"),
+				DependsMode: "sometimes",
+			},
+		},
+	}
+	results := map[string]error{"vet": errors.New("vet failed")}
+
+	tests := []struct {
+		name         string
+		command      string
+		wantExecuted []string
+		wantErr      string
+	}{
+		{
+			name:         "all runs every dependency",
+			command:      "all-checks",
+			wantExecuted: []string{"lint", "vet", "test"},
+			wantErr:      "one or more dependencies failed: 'vet': command 'vet': execution failed: vet failed",
+		},
+		{
+			name:         "fail-fast stops at first failure",
+			command:      "fail-fast",
+			wantExecuted: []string{"lint", "vet"},
+			wantErr:      "command 'fail-fast': failed to execute dependency 'vet'",
+		},
+		{
+			name:         "check-all without depends_mode keeps the legacy behavior",
+			command:      "check-all",
+			wantExecuted: []string{"lint", "vet", "test"},
+			wantErr:      "one or more dependencies failed",
+		},
+		{
+			name:    "invalid mode",
+			command: "invalid",
+			wantErr: "config error in command 'invalid': invalid depends_mode 'sometimes'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard, commandResults: results}}
+			handler := NewCommandHandler(cfg, exec)
+
+			err := handler.ExecuteCommand(tt.command, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(exec.executed, tt.wantExecuted) {
+				t.Errorf("Expected executed commands %v, got %v", tt.wantExecuted, exec.executed)
+			}
+		})
+	}
+}
+
+func TestCommandHandler_ConditionalDependencies(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name:      "test-project",
+		Variables: map[string]string{"SKIP_DOCKER": "true"},
+		Commands: map[string]config.Command{
+			"compile":      {Run: "compile"},
+			"docker-build": {Run: "docker-build"},
+			"push":         {Run: "push"},
+			"release": {
+				Run: "release",
+				Depends: config.DependencyList{
+					{Command: "compile"},
+					{Command: "docker-build", Condition: "$SKIP_DOCKER != true"},
+					{Command: "push", Condition: "$target == prod"},
+				},
+				Params: []config.Param{{Name: "target", Type: "string", Flag: true, Default: "dev"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		vars         map[string]string
+		wantExecuted []string
+	}{
+		{"conditions not met", nil, []string{"compile", "release"}},
+		{"condition on a parameter", map[string]string{"target": "prod"}, []string{"compile", "push", "release"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard}}
+			handler := NewCommandHandler(cfg, exec)
+
+			if err := handler.ExecuteCommand("release", tt.vars); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(exec.executed, tt.wantExecuted) {
+				t.Errorf("Expected executed commands %v, got %v", tt.wantExecuted, exec.executed)
+			}
+		})
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+
+		if err := handler.ExecuteCommand("release", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Contains(out.String(), "Would execute: docker-build") {
+			t.Errorf("Expected docker-build to be skipped, got:\n%s", out.String())
+		}
+		want := "[dry-run] Would skip dependency 'docker-build' of 'release' (condition not met: $SKIP_DOCKER != true)"
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	})
+}
+
+func TestCommandHandler_RunContext(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"clean": {Run: "clean"},
+			"lint":  {Run: "lint", Depends: config.NewDependencyList("clean")},
+			"test":  {Run: "test", Depends: config.NewDependencyList("clean")},
+			"ci":    {Depends: config.NewDependencyList("lint", "test")},
+			"a":     {Run: "a", Depends: config.NewDependencyList("b")},
+			"b":     {Run: "b", Depends: config.NewDependencyList("a")},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *recordingExecutor) {
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard}}
+		return NewCommandHandler(cfg, exec), exec
+	}
+
+	t.Run("dependencies run once per run", func(t *testing.T) {
+		handler, exec := newHandler()
+		if err := handler.ExecuteCommand("ci", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"clean", "lint", "test"}
+		if !reflect.DeepEqual(exec.executed, want) {
+			t.Errorf("Expected executed commands %v, got %v", want, exec.executed)
+		}
+	})
+
+	t.Run("every call starts a new run", func(t *testing.T) {
+		handler, exec := newHandler()
+		if err := handler.ExecuteCommand("lint", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		firstRun := handler.RunContext()
+		if err := handler.ExecuteCommand("lint", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"clean", "lint", "clean", "lint"}
+		if !reflect.DeepEqual(exec.executed, want) {
+			t.Errorf("Expected executed commands %v, got %v", want, exec.executed)
+		}
+		if handler.RunContext().ID == firstRun.ID {
+			t.Errorf("Expected a new run ID for the second call")
+		}
+	})
+
+	t.Run("no-dedupe executes shared dependencies every time", func(t *testing.T) {
+		handler, exec := newHandler()
+		handler.SetNoDedupe(true)
+		if err := handler.ExecuteCommand("ci", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"clean", "lint", "clean", "test"}
+		if !reflect.DeepEqual(exec.executed, want) {
+			t.Errorf("Expected executed commands %v, got %v", want, exec.executed)
+		}
+	})
+
+	t.Run("no-dedupe detects circular dependencies", func(t *testing.T) {
+		handler, exec := newHandler()
+		handler.SetNoDedupe(true)
+		err := handler.ExecuteCommand("a", nil)
+		if err == nil || !strings.Contains(err.Error(), "circular dependency detected: a -> b -> a") {
+			t.Errorf("Expected circular dependency error, got %v", err)
+		}
+		if len(exec.executed) != 0 {
+			t.Errorf("Expected nothing to be executed, got %v", exec.executed)
+		}
+	})
+}
+
+func TestCommandHandler_TypedErrors(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"slow":  {Run: "sleep 60"},
+			"build": {Run: "go build", Depends: config.NewDependencyList("slow")},
+			"empty": {},
+		},
+	}
+	exec := &testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, commandResults: map[string]error{
+		"sleep 60": yxaerrors.NewExecutionTimeoutError(time.Second, "was terminated", nil),
+	}}
+	handler := NewCommandHandler(cfg, exec)
+
+	err := handler.ExecuteCommand("build", nil)
+	var cmdErr *yxaerrors.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.CommandName != "build" {
+		t.Fatalf("Expected a CommandError of 'build', got %v", err)
+	}
+	var timeoutErr *yxaerrors.ExecutionTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Timeout != time.Second {
+		t.Errorf("Expected the timeout of the dependency, got %v", err)
+	}
+
+	err = handler.ExecuteCommand("missing", nil)
+	if !errors.As(err, &cmdErr) || cmdErr.Message != "command not found" {
+		t.Errorf("Expected a command not found error, got %v", err)
+	}
+
+	err = handler.ExecuteCommand("empty", nil)
+	var cfgErr *yxaerrors.ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Name != "empty" {
+		t.Errorf("Expected a ConfigError of 'empty', got %v", err)
+	}
+}
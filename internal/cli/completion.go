@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells yxa generates completion scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCmdTimeout limits how long the completion_cmd of a parameter may run,
+// so that a slow command does not hang the shell
+const completionCmdTimeout = 5 * time.Second
+
+// addParamCompletions completes the values of the parameters of a command that
+// declare choices or a completion_cmd, both for flags and positional arguments.
+// cmdName is the full name of the command, whose variables completion_cmd can use.
+func (r *RootCommand) addParamCompletions(cmd *cobra.Command, cmdName string, params []config.Param) {
+	for _, param := range params {
+		complete := r.paramCompletion(cmdName, param)
+		if complete == nil {
+			continue
+		}
+		name, _ := processParamName(param.Name)
+		if cmd.Flag(name) == nil {
+			continue
+		}
+		// Registering twice only fails for flags that already complete
+		_ = cmd.RegisterFlagCompletionFunc(name, complete)
+	}
+
+	posParams := collectPositionalParams(params)
+	if len(posParams) == 0 {
+		return
+	}
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		param, ok := posParams[len(args)]
+		if !ok {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		complete := r.paramCompletion(cmdName, param)
+		if complete == nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// paramCompletion returns the completion function of a parameter: its choices, or
+// the lines its completion_cmd prints. It returns nil if the parameter has neither.
+func (r *RootCommand) paramCompletion(cmdName string, param config.Param) cobra.CompletionFunc {
+	if len(param.Choices) > 0 {
+		return cobra.FixedCompletions(param.Choices, cobra.ShellCompDirectiveNoFileComp)
+	}
+	if param.CompletionCmd == "" {
+		return nil
+	}
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		values, err := r.completionCmdValues(cmd.Context(), cmdName, param.CompletionCmd)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("completion_cmd of parameter '%s' failed: %v", param.Name, err), false)
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionCmdValues runs the completion_cmd of a parameter of the given command
+// with its variables resolved, in the directory of the config, and returns the
+// non-empty lines of its output
+func (r *RootCommand) completionCmdValues(ctx context.Context, cmdName, cmdStr string) ([]cobra.Completion, error) {
+	if r.Handler != nil {
+		cmdStr = r.Handler.replaceVariablesInString(cmdName, cmdStr, nil)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionCmdTimeout)
+	defer cancel()
+
+	shell := exec.CommandContext(ctx, "sh", "-c", cmdStr) // #nosec G204 -- running the completion_cmd of the config is intended
+	if runtime.GOOS == "windows" {
+		shell = exec.CommandContext(ctx, "cmd", "/C", cmdStr) // #nosec G204 -- running the completion_cmd of the config is intended
+	}
+	if r.Config != nil {
+		shell.Dir = r.Config.ConfigDir()
+	}
+	output, err := shell.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []cobra.Completion
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values, nil
+}
+
+// completeCommandNames returns a completion function for built-ins that take
+// command names. It offers the commands for which include returns true, with
+// subcommands as parent:sub, and stops after maxArgs names (0 for no limit).
+func (r *RootCommand) completeCommandNames(maxArgs int, include func(config.Command) bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if r.Config == nil || (maxArgs > 0 && len(args) >= maxArgs) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		given := make(map[string]bool)
+		for _, arg := range args {
+			given[arg] = true
+		}
+
+		var names []cobra.Completion
+		for _, c := range flattenCommands(r.Config) {
+			if given[c.Name] || isHiddenDeprecated(c.Command) || (include != nil && !include(c.Command)) {
+				continue
+			}
+			names = append(names, cobra.CompletionWithDesc(c.Name, c.Command.Description))
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// isService reports whether a command is a service
+func isService(cmd config.Command) bool {
+	return cmd.Service
+}
+
+// newCompletionInstallCommand creates 'yxa completion install', which writes the
+// completion script to where the shell loads it from
+func (r *RootCommand) newCompletionInstallCommand() *cobra.Command {
+	var path string
+
+	cmd := &cobra.Command{
+		Use:       "install [bash|zsh|fish|powershell]",
+		Short:     "Install the completion script for your shell",
+		Long:      "Install the completion script for the given shell, or for $SHELL if none is given.",
+		ValidArgs: completionShells,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell := ""
+			if len(args) > 0 {
+				shell = args[0]
+			} else if env := os.Getenv("SHELL"); env != "" {
+				shell = filepath.Base(env)
+				if shell == "pwsh" {
+					shell = "powershell"
+				}
+			} else {
+				return fmt.Errorf("cannot detect your shell from $SHELL, pass one of %s", strings.Join(completionShells, ", "))
+			}
+
+			target := path
+			if target == "" {
+				var err error
+				if target, err = completionPath(shell); err != nil {
+					return err
+				}
+			}
+			if err := r.installCompletion(shell, target); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Installed %s completion to %s\n", shell, target)
+			fmt.Fprintln(out, completionHint(shell, target))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Write the script to this file instead of the default location")
+
+	return cmd
+}
+
+// completionPath returns the file the completion script of a shell is installed to
+func completionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions", "yxa"), nil
+	case "zsh":
+		zdot := os.Getenv("ZDOTDIR")
+		if zdot == "" {
+			zdot = home
+		}
+		return filepath.Join(zdot, ".zfunc", "_yxa"), nil
+	case "fish":
+		return filepath.Join(configHome, "fish", "completions", "yxa.fish"), nil
+	case "powershell":
+		return filepath.Join(configHome, "powershell", "yxa-completion.ps1"), nil
+	}
+	return "", fmt.Errorf("unsupported shell '%s', expected one of %s", shell, strings.Join(completionShells, ", "))
+}
+
+// completionHint tells what else is needed for the shell to load an installed script
+func completionHint(shell, target string) string {
+	switch shell {
+	case "zsh":
+		return fmt.Sprintf("Make sure ~/.zshrc contains, before compinit:\n  fpath=(%s $fpath)", filepath.Dir(target))
+	case "powershell":
+		return fmt.Sprintf("Add this line to your PowerShell profile:\n  . %s", target)
+	}
+	return "Start a new shell to use it."
+}
+
+// installCompletion writes the completion script of a shell to target
+func (r *RootCommand) installCompletion(shell, target string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+	f, err := os.Create(target) // #nosec G304 -- path chosen by the user
+	if err != nil {
+		return fmt.Errorf("failed to write completion script: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	switch shell {
+	case "bash":
+		err = r.RootCmd.GenBashCompletionV2(f, true)
+	case "zsh":
+		err = r.RootCmd.GenZshCompletion(f)
+	case "fish":
+		err = r.RootCmd.GenFishCompletion(f, true)
+	case "powershell":
+		err = r.RootCmd.GenPowerShellCompletionWithDesc(f)
+	default:
+		err = fmt.Errorf("unsupported shell '%s', expected one of %s", shell, strings.Join(completionShells, ", "))
+	}
+	return err
+}
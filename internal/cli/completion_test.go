@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletion(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"PREFIX": "feature"},
+		Commands: map[string]config.Command{
+			"checkout": {
+				Run: "git checkout $branch",
+				Params: []config.Param{
+					{Name: "branch", Type: "string", Flag: true, CompletionCmd: "printf '$PREFIX/a\\n\\n  $PREFIX/b\\n'"},
+					{Name: "remote", Type: "string", Flag: true, CompletionCmd: "exit 1"},
+					{Name: "ref", Type: "string", Position: 1, CompletionCmd: "echo main; echo $YXA_COMMAND"},
+				},
+			},
+			"deploy": {
+				Run:         "echo deploying $region to $env",
+				Description: "Deploy the app",
+				Params: []config.Param{
+					{Name: "env", Type: "string", Flag: true, Choices: []string{"staging", "prod"}},
+					{Name: "region", Type: "string", Position: 1, Choices: []string{"eu", "us"}},
+				},
+			},
+			"db": {Run: "postgres", Service: true},
+			"tools": {
+				Commands: map[string]config.Command{
+					"gen": {Run: "go generate ./..."},
+				},
+			},
+		},
+	}
+
+	complete := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		root := NewRootCommand(nil, executor.NewDefaultExecutor())
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, root.Executor)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetArgs(append([]string{"__complete"}, args...))
+		require.NoError(t, root.Execute())
+
+		// The last line is the directive
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		var names []string
+		for _, line := range lines[:len(lines)-1] {
+			names = append(names, strings.SplitN(line, "\t", 2)[0])
+		}
+		return names
+	}
+
+	t.Run("flag choices", func(t *testing.T) {
+		assert.Equal(t, []string{"staging", "prod"}, complete(t, "deploy", "--env", ""))
+	})
+
+	t.Run("declared flags", func(t *testing.T) {
+		assert.Contains(t, complete(t, "deploy", "--"), "--env")
+	})
+
+	t.Run("positional choices", func(t *testing.T) {
+		assert.Equal(t, []string{"eu", "us"}, complete(t, "deploy", "first", ""))
+	})
+
+	t.Run("flag completion_cmd", func(t *testing.T) {
+		assert.Equal(t, []string{"feature/a", "feature/b"}, complete(t, "checkout", "--branch", ""))
+		assert.Empty(t, complete(t, "checkout", "--remote", ""), "a failing completion_cmd offers nothing")
+	})
+
+	t.Run("positional completion_cmd", func(t *testing.T) {
+		assert.Equal(t, []string{"main", "checkout"}, complete(t, "checkout", "first", ""))
+	})
+
+	t.Run("subcommands", func(t *testing.T) {
+		assert.Contains(t, complete(t, "tools", ""), "gen")
+	})
+
+	t.Run("command names with parent:sub", func(t *testing.T) {
+		names := complete(t, "explain", "")
+		assert.Contains(t, names, "deploy")
+		assert.Contains(t, names, "tools:gen")
+		assert.Empty(t, complete(t, "explain", "deploy", ""))
+	})
+
+	t.Run("service names", func(t *testing.T) {
+		assert.Equal(t, []string{"db"}, complete(t, "up", ""))
+		assert.Empty(t, complete(t, "up", "db", ""))
+	})
+}
+
+func TestCompletionInstall(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		root := NewRootCommand(&config.ProjectConfig{}, executor.NewDefaultExecutor())
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(append([]string{"completion", "install"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	t.Run("default location", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		t.Setenv("SHELL", "/usr/bin/fish")
+
+		out, err := run(t)
+		require.NoError(t, err)
+		path := filepath.Join(dir, "fish", "completions", "yxa.fish")
+		assert.Contains(t, out, "Installed fish completion to "+path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "complete -c yxa")
+	})
+
+	t.Run("explicit shell and path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "completions", "_yxa")
+		out, err := run(t, "zsh", "--path", path)
+		require.NoError(t, err)
+		assert.Contains(t, out, "fpath=("+filepath.Dir(path))
+		assert.FileExists(t, path)
+	})
+
+	t.Run("unsupported shell", func(t *testing.T) {
+		t.Setenv("SHELL", "/bin/tcsh")
+		_, err := run(t)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported shell 'tcsh'")
+	})
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_ConditionSkipped(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"GOOS": "linux"},
+		Commands: map[string]config.Command{
+			"setup": {Run: "echo setup"},
+			"mac": {
+				Depends:   config.DependencyList{{Command: "setup"}},
+				Pre:       "echo pre",
+				Run:       "echo mac",
+				Condition: "$GOOS == darwin",
+			},
+			"all": {
+				Depends: config.DependencyList{{Command: "mac"}},
+				Run:     "echo all",
+			},
+		},
+	}
+
+	run := func(t *testing.T, name string, failOnSkip bool) (*CommandHandler, string, []events.Event, error) {
+		t.Helper()
+		var out, eventsOut bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		exec.SetStderr(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.setProgress(&out)
+		handler.SetFailOnSkip(failOnSkip)
+		emitter, err := events.NewEmitter(events.FormatJSON, &eventsOut)
+		require.NoError(t, err)
+		handler.SetEvents(emitter)
+		err = handler.ExecuteCommand(name, nil)
+		return handler, out.String(), decodeEvents(t, eventsOut.String()), err
+	}
+	status := func(evs []events.Event, name string) string {
+		for _, event := range evs {
+			if event.Type == events.CommandEnd && event.Command == name {
+				return event.Status
+			}
+		}
+		return ""
+	}
+
+	t.Run("skips the body, hooks and dependencies", func(t *testing.T) {
+		handler, out, evs, err := run(t, "all", false)
+		require.NoError(t, err)
+		assert.Contains(t, out, "Skipping command 'mac' (condition not met: $GOOS == darwin)")
+		assert.NotContains(t, out, "setup\n")
+		assert.NotContains(t, out, "pre\n")
+		assert.NotContains(t, out, "mac\n")
+		assert.Contains(t, out, "all\n")
+		assert.Equal(t, events.StatusSkipped, status(evs, "mac"))
+		assert.Equal(t, events.StatusOK, status(evs, "all"))
+		assert.True(t, handler.RunContext().skippedCommand("mac"))
+		assert.False(t, handler.RunContext().skippedCommand("all"))
+	})
+
+	t.Run("fail-on-skip", func(t *testing.T) {
+		_, out, evs, err := run(t, "all", true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'mac' was skipped, its condition is not met: $GOOS == darwin (--fail-on-skip)")
+		assert.NotContains(t, out, "all\n")
+		assert.Equal(t, events.StatusFailed, status(evs, "mac"))
+	})
+}
+
+func TestCommandHandler_OutputOfCondition(t *testing.T) {
+	exportEnv := true
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"release": {
+				Run:       "echo releasing",
+				Condition: `output-of "printenv STAGE" == clean`,
+				Variables: map[string]string{"STAGE": "clean"},
+				ExportEnv: &exportEnv,
+			},
+			"dirty": {Run: "echo dirty", Condition: `output-of "echo modified" == ""`},
+			"all": {
+				Depends: config.DependencyList{{Command: "dirty", Condition: `output-of "echo modified" == modified`}},
+				Tasks:   config.TaskList{{Run: "echo task", Condition: `output-of "echo modified" contains mod`}},
+			},
+		},
+	}
+
+	t.Run("runs the command through the executor in the environment of the command", func(t *testing.T) {
+		var out, progress bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		exec.SetStderr(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.setProgress(&progress)
+
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Equal(t, "releasing\n", out.String(), "the output of the condition is not shown")
+
+		out.Reset()
+		require.NoError(t, handler.ExecuteCommand("dirty", nil))
+		assert.Empty(t, out.String())
+		assert.Contains(t, progress.String(), `Skipping command 'dirty' (condition not met: output-of "echo modified" == "")`)
+
+		out.Reset()
+		require.NoError(t, NewCommandHandler(cfg, exec).ExecuteCommand("all", nil))
+		assert.Equal(t, "task\n", out.String(), "conditions of dependencies and tasks run their commands too")
+	})
+
+	t.Run("dry run and explain run nothing", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+
+		root.RootCmd.SetArgs([]string{"explain", "release"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), `condition:   output-of "printenv STAGE" == clean => output-of "printenv STAGE" == clean (not evaluated, runs a command)`)
+
+		out.Reset()
+		root.RootCmd.SetArgs([]string{"explain", "all"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), `depends:     dirty (if output-of "echo modified" == modified, not evaluated)`)
+		assert.Contains(t, out.String(), `#1 echo task (condition: output-of "echo modified" contains mod, not evaluated)`)
+
+		out.Reset()
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("all", nil))
+		assert.Contains(t, out.String(), `[dry-run] Would evaluate the condition of 'dirty' when it runs: output-of "echo modified" == ""`)
+		assert.Contains(t, out.String(), `[dry-run] Would execute (sequential, if output-of "echo modified" contains mod, not evaluated): echo task`)
+
+		assert.Empty(t, exec.executed)
+	})
+}
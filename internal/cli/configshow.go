@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of 'yxa config show'
+const (
+	ConfigFormatYAML = "yaml"
+	ConfigFormatJSON = "json"
+)
+
+// keyOrigin is where a command or variable of the merged config comes from
+type keyOrigin struct {
+	From    string   `json:"from"`              // Config file that defines it
+	Shadows []string `json:"shadows,omitempty"` // Config files whose definitions it shadows, nearest first
+	Profile string   `json:"profile,omitempty"` // Applied profile that changes it
+}
+
+// newConfigCommand creates the built-in 'config' command, which shows the loaded
+// configuration
+func (r *RootCommand) newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the loaded configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	var format string
+	var origin, showSecrets bool
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Show the configuration merged from all config files",
+		Long: `Show the configuration yxa uses, merged from the global config, the yxa.yml files
+of parent directories, yxa.yml and yxa.override.yml, with the profile selected with
+--profile applied and the values of variables resolved. Values of encrypted and
+sensitive variables and of variables whose names look like secrets are masked
+unless --show-secrets is set.
+
+With --origin, every command and variable is annotated with the file that defines
+it, the files whose definitions it shadows and the profile that changes it. In
+JSON, the origins are listed under "origins".`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != ConfigFormatYAML && format != ConfigFormatJSON {
+				return fmt.Errorf("invalid --format '%s': expected %s or %s", format, ConfigFormatYAML, ConfigFormatJSON)
+			}
+			return r.showConfig(cmd.OutOrStdout(), format, origin, showSecrets)
+		},
+	}
+	show.Flags().StringVar(&format, "format", ConfigFormatYAML, "Format of the configuration: yaml or json")
+	show.Flags().BoolVar(&origin, "origin", false, "Annotate commands and variables with the config file they come from")
+	show.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show the values of secret variables")
+	_ = show.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{ConfigFormatYAML, ConfigFormatJSON}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.AddCommand(show)
+
+	return cmd
+}
+
+// showConfig writes the effective configuration to out in the given format, with
+// the origins of its commands and variables if origin is set
+func (r *RootCommand) showConfig(out io.Writer, format string, origin, showSecrets bool) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	effective := *r.Config
+	effective.Variables = r.resolvedVariables(showSecrets)
+
+	var doc yaml.Node
+	if err := doc.Encode(&effective); err != nil {
+		return fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+
+	if format == ConfigFormatJSON {
+		var value map[string]interface{}
+		if err := doc.Decode(&value); err != nil {
+			return fmt.Errorf("failed to encode the configuration: %w", err)
+		}
+		if origin {
+			value["origins"] = r.configOrigins()
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+
+	if origin {
+		r.annotateOrigins(&doc)
+	}
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to write the configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+// resolvedVariables returns the variables of the config with their effective
+// values resolved, masked unless showSecrets is set
+func (r *RootCommand) resolvedVariables(showSecrets bool) map[string]string {
+	if len(r.Config.Variables) == 0 {
+		return nil
+	}
+	resolver := r.Handler.resolver("", nil)
+	r.Handler.registerSensitive("", config.Command{}, nil)
+
+	resolved := make(map[string]string, len(r.Config.Variables))
+	for name := range r.Config.Variables {
+		value, _, _ := resolver.Lookup(name)
+		value = resolver.Resolve(value)
+		if !showSecrets {
+			value = variables.MaskValue(name, r.Handler.maskSecrets(value))
+		}
+		resolved[name] = value
+	}
+	return resolved
+}
+
+// configOrigins returns the origins of the commands and variables of the config
+func (r *RootCommand) configOrigins() map[string]map[string]keyOrigin {
+	origins := map[string]map[string]keyOrigin{
+		"commands":  make(map[string]keyOrigin, len(r.Config.Commands)),
+		"variables": make(map[string]keyOrigin, len(r.Config.Variables)),
+	}
+	for name := range r.Config.Commands {
+		origins["commands"][name] = r.keyOrigin("commands", name)
+	}
+	for name := range r.Config.Variables {
+		origins["variables"][name] = r.keyOrigin("variables", name)
+	}
+	return origins
+}
+
+// keyOrigin returns where a top-level command or variable comes from, with the
+// config files shown relative to the current directory
+func (r *RootCommand) keyOrigin(section, name string) keyOrigin {
+	var o keyOrigin
+	var shadowed []string
+	if section == "commands" {
+		o.From, shadowed, o.Profile = r.Config.CommandOrigin(name), r.Config.ShadowedCommand(name), r.Config.CommandProfile(name)
+	} else {
+		o.From, shadowed, o.Profile = r.Config.VariableOrigin(name), r.Config.ShadowedVariable(name), r.Config.VariableProfile(name)
+	}
+	if o.From != "" {
+		o.From = r.displayPath(o.From)
+	}
+	for _, path := range shadowed {
+		o.Shadows = append(o.Shadows, r.displayPath(path))
+	}
+	return o
+}
+
+// annotateOrigins adds the origin of every top-level command and variable to the
+// encoded configuration as a comment
+func (r *RootCommand) annotateOrigins(doc *yaml.Node) {
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		section := doc.Content[i].Value
+		if section != "commands" && section != "variables" {
+			continue
+		}
+		entries := doc.Content[i+1].Content
+		for j := 0; j+1 < len(entries); j += 2 {
+			entries[j].LineComment = r.keyOrigin(section, entries[j].Value).comment()
+		}
+	}
+}
+
+// comment describes an origin as a comment of the config
+func (o keyOrigin) comment() string {
+	var parts []string
+	if o.From != "" {
+		parts = append(parts, "from "+o.From)
+	}
+	if len(o.Shadows) > 0 {
+		parts = append(parts, "shadows "+strings.Join(o.Shadows, ", "))
+	}
+	if o.Profile != "" {
+		parts = append(parts, "changed by profile "+o.Profile)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// warnMergeConflicts warns about project commands that shadow a global command of
+// the same name with a different definition, which is easy to miss
+func (r *RootCommand) warnMergeConflicts() {
+	if r.Config == nil {
+		return
+	}
+	for _, conflict := range r.Config.MergeConflicts() {
+		fmt.Fprintf(os.Stderr, "Warning: command '%s' of %s shadows a different command '%s' of the global config %s, see yxa config show --origin\n",
+			conflict.Command, r.displayPath(conflict.Origin), conflict.Command, r.displayPath(conflict.Shadowed))
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigShowCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("YXA_NO_OVERRIDE", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".yxa.yml"), []byte("variables:\n  REGISTRY: ghcr.io\ncommands:\n  deploy:\n    run: ./deploy.sh\n  fmt:\n    run: gofmt -w .\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "project"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "project", "yxa.yml"), []byte("root: true\nvariables:\n  REGISTRY: registry.internal\n  IMAGE: $REGISTRY/app\n  API_TOKEN: abc123\ncommands:\n  deploy:\n    run: kubectl apply -f k8s\nprofiles:\n  ci:\n    variables:\n      REGISTRY: ghcr.io/ci\n    commands:\n      deploy:\n        run: echo skipped\n"), 0600))
+
+	cfg, err := config.LoadConfigFrom(filepath.Join(dir, "project", "yxa.yml"))
+	require.NoError(t, err)
+
+	t.Run("shows the merged config", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"config", "show"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "  deploy:\n    run: kubectl apply -f k8s\n")
+		assert.Contains(t, out.String(), "  fmt:\n    run: gofmt -w .\n")
+		assert.NotContains(t, out.String(), "# from")
+	})
+
+	t.Run("origin annotates commands and variables", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"config", "show", "--origin"})
+		require.NoError(t, root.Execute())
+		assert.Regexp(t, `deploy: # from \S*yxa\.yml, shadows \S*\.yxa\.yml\n`, out.String())
+		assert.Regexp(t, `fmt: # from \S*\.yxa\.yml\n`, out.String())
+		assert.Regexp(t, `REGISTRY: registry\.internal # from \S*yxa\.yml, shadows \S*\.yxa\.yml\n`, out.String())
+	})
+
+	t.Run("resolves variables and masks secrets", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"config", "show"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "IMAGE: registry.internal/app\n")
+		assert.NotContains(t, out.String(), "abc123")
+
+		root, out = setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"config", "show", "--show-secrets"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "API_TOKEN: abc123\n")
+	})
+
+	t.Run("json with the profile applied", func(t *testing.T) {
+		ci, err := cfg.WithProfile("ci")
+		require.NoError(t, err)
+		root, out := setupEnvTestRoot(ci)
+		root.RootCmd.SetArgs([]string{"config", "show", "--format", "json", "--origin"})
+		require.NoError(t, root.Execute())
+
+		var shown struct {
+			Variables map[string]string
+			Commands  map[string]config.Command
+			Origins   map[string]map[string]keyOrigin
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &shown))
+		assert.Equal(t, "ghcr.io/ci/app", shown.Variables["IMAGE"])
+		assert.Equal(t, "echo skipped", shown.Commands["deploy"].Run)
+		assert.Equal(t, "ci", shown.Origins["commands"]["deploy"].Profile)
+		assert.Equal(t, "ci", shown.Origins["variables"]["REGISTRY"].Profile)
+		assert.Empty(t, shown.Origins["variables"]["IMAGE"].Profile)
+		assert.Len(t, shown.Origins["commands"]["deploy"].Shadows, 1)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		root, _ := setupEnvTestRoot(cfg)
+		root.RootCmd.SilenceErrors = true
+		root.RootCmd.SetArgs([]string{"config", "show", "--format", "toml"})
+		assert.ErrorContains(t, root.Execute(), "invalid --format 'toml': expected yaml or json")
+	})
+}
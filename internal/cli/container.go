@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// validateContainer checks the container block of a command
+func (h *CommandHandler) validateContainer(cmdName string, cmd config.Command) error {
+	if cmd.Container == nil {
+		return nil
+	}
+	if cmd.Container.Image == "" {
+		return errors.NewCommandConfigError(cmdName, "uses 'container', which requires 'image'", nil)
+	}
+	switch cmd.Container.Pull {
+	case "", executor.PullMissing, executor.PullAlways, executor.PullNever:
+	default:
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid container pull policy '%s', must be %s, %s or %s",
+			cmd.Container.Pull, executor.PullMissing, executor.PullAlways, executor.PullNever), nil)
+	}
+	if len(cmd.Steps) > 0 {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'container' and 'steps'", nil)
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use 'container'", nil)
+	}
+	return nil
+}
+
+// describeContainer returns a short description of a container for dry-run and
+// explain output
+func describeContainer(spec *executor.ContainerSpec) string {
+	var opts []string
+	for _, volume := range spec.Volumes {
+		opts = append(opts, "-v "+volume)
+	}
+	if spec.Workdir != "" {
+		opts = append(opts, "-w "+spec.Workdir)
+	}
+	if len(opts) == 0 {
+		return spec.Image
+	}
+	return fmt.Sprintf("%s (%s)", spec.Image, strings.Join(opts, ", "))
+}
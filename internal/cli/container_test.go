@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDockerScript stands in for the Docker CLI on PATH. It logs its arguments and
+// runs the command of 'docker run' on the host.
+const fakeDockerScript = `#!/bin/sh
+echo "$@" >> "$(dirname "$0")/calls.log"
+if [ "$1" = run ]; then
+	for cmd; do :; done
+	exec sh -c "$cmd"
+fi
+`
+
+func TestCommandHandler_Container(t *testing.T) {
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker"), []byte(fakeDockerScript), 0700)) // #nosec G306
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"GO_VERSION": "1.24"},
+		Commands: map[string]config.Command{
+			"build": {
+				Run: "echo building",
+				Pre: "echo pre",
+				Container: &config.Container{
+					Image:   "golang:$GO_VERSION",
+					Volumes: []string{"/src:/src"},
+					Env:     map[string]string{"GOFLAGS": "-mod=$MODE"},
+					Workdir: "/src",
+					Pull:    executor.PullNever,
+				},
+				Params: []config.Param{{Name: "MODE", Type: "string", Default: "vendor", Flag: true}},
+			},
+			"test": {
+				Tasks:     config.NewTaskList("echo one", "echo two"),
+				Parallel:  true,
+				Container: &config.Container{Image: "golang:1.24"},
+			},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec), out
+	}
+
+	t.Run("run executes in the container, hooks on the host", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, "pre\nbuilding\n", out.String())
+		_, isDefault := handler.Executor.(*executor.DefaultExecutor)
+		assert.True(t, isDefault, "the host executor must be restored")
+
+		calls, err := os.ReadFile(filepath.Join(bin, "calls.log")) // #nosec G304
+		require.NoError(t, err)
+		assert.NotContains(t, string(calls), "pre")
+		assert.Contains(t, string(calls), "-v /src:/src -e GOFLAGS=-mod=vendor -w /src golang:1.24 sh -c echo building")
+	})
+
+	t.Run("parallel tasks get their own container", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("test", nil))
+		assert.Contains(t, out.String(), "[#1] one")
+		assert.Contains(t, out.String(), "[#2] two")
+
+		calls, err := os.ReadFile(filepath.Join(bin, "calls.log")) // #nosec G304
+		require.NoError(t, err)
+		assert.Contains(t, string(calls), "image inspect golang:1.24")
+		assert.Contains(t, string(calls), "golang:1.24 sh -c echo two")
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		handler, out := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would run with runner: docker golang:1.24 (-v /src:/src, -w /src)")
+	})
+}
+
+func TestValidateContainer(t *testing.T) {
+	h := NewCommandHandler(&config.ProjectConfig{}, executor.NewDefaultExecutor())
+
+	assert.NoError(t, h.validateContainer("build", config.Command{Run: "make"}))
+	assert.NoError(t, h.validateContainer("build", config.Command{Run: "make", Container: &config.Container{Image: "alpine"}}))
+	assert.EqualError(t, h.validateContainer("build", config.Command{Run: "make", Container: &config.Container{}}),
+		"config error in command 'build': uses 'container', which requires 'image'")
+	assert.EqualError(t, h.validateContainer("build", config.Command{Run: "make", Container: &config.Container{Image: "alpine", Pull: "daily"}}),
+		"config error in command 'build': invalid container pull policy 'daily', must be missing, always or never")
+	assert.EqualError(t, h.validateContainer("build", config.Command{Steps: []config.Step{{}}, Container: &config.Container{Image: "alpine"}}),
+		"config error in command 'build': cannot combine 'container' and 'steps'")
+	assert.EqualError(t, h.validateContainer("web", config.Command{Run: "serve", Service: true, Container: &config.Container{Image: "alpine"}}),
+		"config error in command 'web': a service cannot use 'container'")
+}
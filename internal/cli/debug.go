@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// DefaultDebugTimeout is how long a --debug-on-failure shell may stay open by default
+const DefaultDebugTimeout = 15 * time.Minute
+
+// stdinIsTerminal reports whether stdin is an interactive terminal. It is a variable
+// so tests can pretend to run in a terminal.
+var stdinIsTerminal = func() bool {
+	return fileIsTerminal(os.Stdin)
+}
+
+// debugFailure opens an interactive shell after a command failed, in the command's
+// working directory and with its resolved variables in the environment. Only the
+// first failure of a run is debugged, which is the innermost failing command.
+func (h *CommandHandler) debugFailure(cmdName string, cmd config.Command, cmdVars map[string]string, cmdErr error) {
+	run := h.RunContext()
+	if h.DryRun || h.NonInteractive || !stdinIsTerminal() || !run.claimDebug() {
+		return
+	}
+
+	dir := h.debugWorkingDir(cmdName, cmd, cmdVars)
+	env := os.Environ()
+	for _, v := range h.resolver(cmdName, cmdVars).Variables(true) {
+		env = append(env, v.Name+"="+v.Value)
+	}
+
+	timeout := h.DebugTimeout
+	if timeout <= 0 {
+		timeout = DefaultDebugTimeout
+	}
+
+	fmt.Printf("[debug] Command '%s' failed: %v\n", cmdName, cmdErr)
+	fmt.Printf("[debug] Starting debug shell in %s for up to %s, exit the shell to continue\n", displayDir(dir), timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shell := debugShell()
+	// #nosec G204 -- The shell is chosen by the user through $SHELL
+	shellCmd := exec.CommandContext(ctx, shell)
+	shellCmd.Dir = dir
+	shellCmd.Env = env
+	shellCmd.Stdin = os.Stdin
+	shellCmd.Stdout = os.Stdout
+	shellCmd.Stderr = os.Stderr
+
+	start := time.Now()
+	err := shellCmd.Run()
+	elapsed := time.Since(start).Round(time.Second)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		fmt.Printf("[debug] Debug session for '%s' closed after the %s limit\n", cmdName, timeout)
+	case err != nil:
+		fmt.Printf("[debug] Debug session for '%s' ended after %s: %v\n", cmdName, elapsed, err)
+	default:
+		fmt.Printf("[debug] Debug session for '%s' ended after %s\n", cmdName, elapsed)
+	}
+}
+
+// debugWorkingDir returns the working directory of a command with variables
+// resolved, or an empty string for the current directory
+func (h *CommandHandler) debugWorkingDir(cmdName string, cmd config.Command, cmdVars map[string]string) string {
+	dir := cmd.WorkingDir
+	if dir == "" {
+		dir = h.Config.WorkingDir
+	}
+	return h.replaceVariablesInString(cmdName, dir, cmdVars)
+}
+
+// displayDir returns a directory for messages, naming the current directory if empty
+func displayDir(dir string) string {
+	if dir == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			return cwd
+		}
+		return "the current directory"
+	}
+	return dir
+}
+
+// debugShell returns the shell to open for debugging
+func debugShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if comspec := os.Getenv("ComSpec"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	return "sh"
+}
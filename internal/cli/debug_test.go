@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_DebugOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "debug.log")
+
+	// A fake shell that records where and with which environment it was started
+	shell := filepath.Join(dir, "shell.sh")
+	script := "#!/bin/sh\necho \"$(pwd) $APP $version\" >> " + record + "\n"
+	require.NoError(t, os.WriteFile(shell, []byte(script), 0700)) // #nosec G306 -- test script must be executable
+	t.Setenv("SHELL", shell)
+
+	restore := stdinIsTerminal
+	defer func() { stdinIsTerminal = restore }()
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"APP": "yxa"},
+		Commands: map[string]config.Command{
+			"test": {
+				Run:        "go test",
+				WorkingDir: dir,
+				Params:     []config.Param{{Name: "version", Type: "string", Default: "1.0", Flag: true}},
+			},
+			"release": {Run: "release", Depends: config.NewDependencyList("test")},
+		},
+	}
+	newHandler := func() *CommandHandler {
+		exec := &recordingExecutor{testExecutor: testExecutor{
+			stdout:         io.Discard,
+			stderr:         io.Discard,
+			commandResults: map[string]error{"go test": errors.New("exit status 1")},
+		}}
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDebugOnFailure(true, 0)
+		return handler
+	}
+
+	t.Run("opens one shell for the failing command", func(t *testing.T) {
+		stdinIsTerminal = func() bool { return true }
+		require.NoError(t, os.RemoveAll(record))
+
+		require.Error(t, newHandler().ExecuteCommand("release", nil))
+
+		data, err := os.ReadFile(record)
+		require.NoError(t, err)
+		resolvedDir, err := filepath.EvalSymlinks(dir)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 1)
+		assert.True(t, lines[0] == dir+" yxa 1.0" || lines[0] == resolvedDir+" yxa 1.0", "unexpected shell record %q", lines[0])
+	})
+
+	t.Run("no shell in non-interactive mode", func(t *testing.T) {
+		stdinIsTerminal = func() bool { return true }
+		require.NoError(t, os.RemoveAll(record))
+
+		handler := newHandler()
+		handler.SetNonInteractive(true)
+		require.Error(t, handler.ExecuteCommand("release", nil))
+		assert.NoFileExists(t, record)
+	})
+
+	t.Run("no shell without a terminal", func(t *testing.T) {
+		stdinIsTerminal = func() bool { return false }
+		require.NoError(t, os.RemoveAll(record))
+
+		require.Error(t, newHandler().ExecuteCommand("release", nil))
+		assert.NoFileExists(t, record)
+	})
+}
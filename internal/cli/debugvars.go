@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/variables"
+)
+
+// SetDebugVars sets where variable substitutions are logged, nil to disable it
+func (h *CommandHandler) SetDebugVars(w io.Writer) {
+	h.DebugVars = w
+}
+
+// traceSubstitution logs a variable substitution in a string of cmdName for
+// --debug-vars. Every substitution is logged once per run, since the same strings
+// are resolved several times, e.g. for the plan and the execution. Values of
+// secrets and encrypted variables are masked.
+func (h *CommandHandler) traceSubstitution(cmdName string, s variables.Substitution) {
+	// Positional parameters such as $1 are meant for the shell
+	if s.Name[0] >= '0' && s.Name[0] <= '9' {
+		return
+	}
+	value := h.maskSecrets(variables.MaskValue(s.Name, s.Value))
+	key := strings.Join([]string{cmdName, s.Reference, s.Source, value}, "\x00")
+	if !h.RunContext().firstTrace(key) {
+		return
+	}
+
+	scope := cmdName
+	if scope == "" {
+		scope = "(config)"
+	}
+	var line string
+	switch {
+	case s.Source == "":
+		line = fmt.Sprintf("%s not set, left as is", s.Reference)
+	case !s.Replaced:
+		line = fmt.Sprintf("%s unknown modifier, left as is (%s from %s)", s.Reference, s.Name, s.Source)
+	default:
+		line = fmt.Sprintf("%s = %q (from %s)", s.Reference, value, s.Source)
+		// Values are substituted in a single pass, so references in them are not
+		// resolved again; that also means references can never form a cycle
+		if refs := variables.References(s.Value); len(refs) > 0 {
+			line += fmt.Sprintf(", not expanded further: $%s", strings.Join(refs, ", $"))
+		}
+	}
+	fmt.Fprintf(h.DebugVars, "[vars] %s: %s\n", scope, line)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_DebugVars(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"OUT": "dist", "ROOT": "$HOME/app", "API_TOKEN": "hunter2"},
+		Commands: map[string]config.Command{
+			"build": {
+				Run:       "echo $OUT $TARGET $ROOT $API_TOKEN $MISSING $1",
+				Variables: map[string]string{"TARGET": "linux"},
+			},
+		},
+	}
+	var out, trace bytes.Buffer
+	exec := executor.NewDefaultExecutor()
+	exec.SetStdout(&out)
+	exec.SetStderr(&out)
+	handler := NewCommandHandler(cfg, exec)
+	handler.SetDebugVars(&trace)
+
+	require.NoError(t, handler.ExecuteCommand("build", nil))
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	assert.Equal(t, []string{
+		`[vars] build: $OUT = "dist" (from config)`,
+		`[vars] build: $TARGET = "linux" (from command)`,
+		`[vars] build: $ROOT = "$HOME/app" (from config), not expanded further: $HOME`,
+		`[vars] build: $API_TOKEN = "***" (from config)`,
+		`[vars] build: $MISSING not set, left as is`,
+	}, lines, "every substitution is logged once, secrets are masked")
+	assert.Contains(t, out.String(), "hunter2", "only the log is masked")
+}
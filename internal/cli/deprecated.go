@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+// deprecatedMarker flags deprecated commands in command lists
+const deprecatedMarker = "[deprecated]"
+
+// SetStrictDeprecations sets whether invoking a deprecated command fails instead of
+// printing a warning
+func (h *CommandHandler) SetStrictDeprecations(strict bool) {
+	h.StrictDeprecations = strict
+}
+
+// checkDeprecated warns that an invoked command is deprecated, or fails with
+// --strict-deprecations. Commands that do not exist are reported by the execution.
+func (h *CommandHandler) checkDeprecated(cmdName string) error {
+	cmd, err := h.lookupCommand(cmdName)
+	if err != nil || cmd.Deprecated == "" {
+		return nil
+	}
+	if h.StrictDeprecations {
+		return &errors.CommandError{
+			CommandName: cmdName,
+			Stage:       errors.StageValidate,
+			Message:     "deprecated: " + cmd.Deprecated,
+		}
+	}
+	fmt.Fprintf(h.Executor.GetStderr(), "Warning: command '%s' is deprecated: %s\n", cmdName, cmd.Deprecated)
+	return nil
+}
+
+// listedDescription returns the description of a command in command lists,
+// flagged if the command is deprecated
+func listedDescription(cmd config.Command) string {
+	if cmd.Deprecated == "" {
+		return cmd.Description
+	}
+	if cmd.Description == "" {
+		return deprecatedMarker + " " + cmd.Deprecated
+	}
+	return deprecatedMarker + " " + cmd.Description
+}
+
+// isHiddenDeprecated reports whether a command is deprecated and hidden from help
+// and completion
+func isHiddenDeprecated(cmd config.Command) bool {
+	return cmd.Deprecated != "" && cmd.HiddenDeprecated
+}
+
+// applyDeprecation flags the cobra command of a deprecated command in help, and
+// hides it if it sets hidden_deprecated
+func applyDeprecation(cobraCmd *cobra.Command, cmd config.Command) {
+	cobraCmd.Short = listedDescription(cmd)
+	cobraCmd.Hidden = isHiddenDeprecated(cmd)
+}
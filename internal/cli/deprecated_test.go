@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedCommands(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"build":    {Run: "echo old build", Description: "Build the app", Deprecated: "use build-v2 instead"},
+			"build-v2": {Run: "echo new build", Description: "Build the app faster"},
+			"legacy":   {Run: "echo legacy", Deprecated: "no longer needed", HiddenDeprecated: true},
+			"tools": {
+				Commands: map[string]config.Command{
+					"gen": {Run: "echo gen", Deprecated: "use go generate"},
+				},
+			},
+		},
+	}
+
+	setup := func() (*RootCommand, *bytes.Buffer, *bytes.Buffer) {
+		out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(errOut)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		return root, out, errOut
+	}
+
+	t.Run("warning", func(t *testing.T) {
+		root, out, errOut := setup()
+		root.RootCmd.SetArgs([]string{"build"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "old build")
+		assert.Equal(t, "Warning: command 'build' is deprecated: use build-v2 instead\n", errOut.String())
+	})
+
+	t.Run("subcommand warning", func(t *testing.T) {
+		root, _, errOut := setup()
+		root.RootCmd.SetArgs([]string{"tools", "gen"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, errOut.String(), "Warning: command 'tools:gen' is deprecated: use go generate")
+	})
+
+	t.Run("no warning", func(t *testing.T) {
+		root, _, errOut := setup()
+		root.RootCmd.SetArgs([]string{"build-v2"})
+		require.NoError(t, root.Execute())
+		assert.Empty(t, errOut.String())
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		h := NewCommandHandler(cfg, &testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}})
+		h.SetStrictDeprecations(true)
+		err := h.ExecuteCommand("build", nil)
+		require.Error(t, err)
+		assert.Equal(t, "command 'build': deprecated: use build-v2 instead", err.Error())
+		assert.NoError(t, h.ExecuteCommand("build-v2", nil))
+	})
+
+	t.Run("flagged in help", func(t *testing.T) {
+		root, out, _ := setup()
+		root.RootCmd.SetArgs([]string{"help"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "[deprecated] Build the app")
+		assert.NotContains(t, out.String(), "legacy")
+	})
+
+	t.Run("flagged in subcommand list", func(t *testing.T) {
+		h := NewCommandHandler(cfg, &testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}})
+		assert.Equal(t, "[deprecated] use go generate", h.getSubcommandDescription(cfg.Commands["tools"].Commands["gen"]))
+	})
+
+	t.Run("hidden from completion", func(t *testing.T) {
+		root, out, _ := setup()
+		root.RootCmd.SetArgs([]string{"__complete", ""})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "build")
+		assert.NotContains(t, out.String(), "legacy")
+
+		out.Reset()
+		root.RootCmd.SetArgs([]string{"__complete", "explain", ""})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "tools:gen")
+		assert.NotContains(t, out.String(), "legacy")
+	})
+}
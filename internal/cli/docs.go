@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+)
+
+// newDocsCommand creates the built-in 'docs' command, which renders the commands
+// of the config as Markdown
+func (r *RootCommand) newDocsCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate Markdown documentation of the commands",
+		Long: `Write a Markdown reference of the commands of the config: their descriptions
+and help texts, usage, parameters, dependencies, conditions, examples and
+subcommands. Commit the output, e.g. with --output docs/COMMANDS.md, to keep the
+documentation of a project in sync with its yxa.yml.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if r.Config == nil {
+				return fmt.Errorf("no configuration loaded")
+			}
+			return writeExport(cmd, output, func(out io.Writer) error {
+				return r.writeDocs(out)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the documentation to this file instead of stdout")
+
+	return cmd
+}
+
+// writeDocs writes the Markdown reference of the commands of the config to out
+func (r *RootCommand) writeDocs(out io.Writer) error {
+	var b strings.Builder
+	title := "Commands"
+	if r.Config.Name != "" {
+		title = r.Config.Name + " commands"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("<!-- Generated by yxa docs from yxa.yml, do not edit. -->\n\n")
+
+	commands := flattenCommands(r.Config)
+	for _, c := range commands {
+		indent := strings.Repeat("  ", strings.Count(c.Name, ":"))
+		fmt.Fprintf(&b, "%s- [%s](#%s)", indent, c.Name, markdownAnchor(c.Name))
+		if c.Command.Description != "" {
+			fmt.Fprintf(&b, ": %s", c.Command.Description)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, c := range commands {
+		params := mergeParams(r.Handler.inheritedParams(c.Name), c.Command.Params)
+		b.WriteString("\n")
+		writeCommandDocs(&b, c.Name, c.Command, params)
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// writeCommandDocs writes the section of a command with the given parameters,
+// its own and those it inherits
+func writeCommandDocs(b *strings.Builder, name string, cmd config.Command, params []config.Param) {
+	fmt.Fprintf(b, "## %s\n\n", name)
+	// Positional arguments are listed in the table of parameters, not below the help
+	help := strings.TrimSpace(cmd.Help)
+	if help == "" {
+		help = cmd.Description
+	}
+	if help != "" {
+		b.WriteString(help + "\n\n")
+	}
+
+	fmt.Fprintf(b, "```sh\n%s\n```\n\n", commandUsage(name, cmd, params))
+
+	if len(cmd.Depends) > 0 {
+		fmt.Fprintf(b, "**Depends on:** %s\n\n", markdownCommandLinks(cmd.Depends.Commands()))
+	}
+	if cmd.Condition != "" {
+		fmt.Fprintf(b, "**Runs only if:** `%s`\n\n", cmd.Condition)
+	}
+
+	if len(params) > 0 {
+		b.WriteString("| Parameter | Type | Default | Description |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, param := range params {
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n",
+				paramDocsName(param), param.Type, paramDocsDefault(param), markdownCell(paramDocsDescription(param)))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(cmd.Examples) > 0 {
+		b.WriteString("Examples:\n\n```sh\n")
+		for _, example := range cmd.Examples {
+			b.WriteString(strings.TrimSpace(example) + "\n")
+		}
+		b.WriteString("```\n\n")
+	}
+
+	if len(cmd.Commands) > 0 {
+		names := make([]string, 0, len(cmd.Commands))
+		for sub := range cmd.Commands {
+			names = append(names, name+":"+sub)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(b, "**Subcommands:** %s\n\n", markdownCommandLinks(names))
+	}
+}
+
+// commandUsage returns how a command is invoked: its path, [flags] if it has flag
+// parameters and its positional arguments, <required> or [optional]
+func commandUsage(name string, cmd config.Command, params []config.Param) string {
+	usage := "yxa " + strings.ReplaceAll(name, ":", " ")
+	if len(cmd.Commands) > 0 && cmd.Run == "" && len(cmd.Tasks) == 0 && len(cmd.Steps) == 0 {
+		usage += " <command>"
+	}
+	var args []config.Param
+	hasFlags := false
+	for _, param := range params {
+		if isPositionalParam(param) {
+			args = append(args, param)
+		} else {
+			hasFlags = true
+		}
+	}
+	if hasFlags {
+		usage += " [flags]"
+	}
+	sort.SliceStable(args, func(i, j int) bool { return args[i].Position < args[j].Position })
+	for _, param := range args {
+		if param.Required {
+			usage += " <" + param.Name + ">"
+		} else {
+			usage += " [" + param.Name + "]"
+		}
+	}
+	return usage
+}
+
+// paramDocsName returns the name of a parameter as it is given on the command
+// line: its flag and shorthand, or its name and position for arguments
+func paramDocsName(param config.Param) string {
+	if isPositionalParam(param) {
+		return fmt.Sprintf("`%s` (argument %d)", param.Name, param.Position)
+	}
+	name, shorthand := processParamName(param.Name)
+	if shorthand != "" {
+		return fmt.Sprintf("`--%s`, `-%s`", name, shorthand)
+	}
+	return fmt.Sprintf("`--%s`", name)
+}
+
+// paramDocsDefault returns the default column of a parameter
+func paramDocsDefault(param config.Param) string {
+	switch {
+	case param.Required:
+		return "required"
+	case param.Sensitive && param.Default != "":
+		return "`" + variables.MaskedValue + "`"
+	case param.Default != "":
+		return "`" + markdownCell(param.Default) + "`"
+	}
+	return ""
+}
+
+// paramDocsDescription returns the description of a parameter with its choices
+func paramDocsDescription(param config.Param) string {
+	description := param.Description
+	if len(param.Choices) > 0 {
+		choices := "One of `" + strings.Join(param.Choices, "`, `") + "`."
+		if description != "" {
+			description = strings.TrimSuffix(description, ".") + ". " + choices
+		} else {
+			description = choices
+		}
+	}
+	return description
+}
+
+// markdownCommandLinks returns links to the sections of commands
+func markdownCommandLinks(names []string) string {
+	links := make([]string, 0, len(names))
+	for _, name := range names {
+		links = append(links, fmt.Sprintf("[%s](#%s)", name, markdownAnchor(name)))
+	}
+	return strings.Join(links, ", ")
+}
+
+// markdownAnchor returns the anchor GitHub generates for a heading: lower case,
+// spaces replaced with dashes and punctuation other than - and _ removed
+func markdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(heading) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+			b.WriteRune(c)
+		case c == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// markdownCell makes text fit in a table cell, escaping pipes and joining lines
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
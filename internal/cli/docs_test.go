@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "shop",
+		Commands: map[string]config.Command{
+			"build": {Run: "go build", Description: "Build the app"},
+			"deploy": {
+				Description: "Deploy the app",
+				Condition:   "$CI == true",
+				Params: []config.Param{
+					{Name: "env|e", Type: "string", Flag: true, Default: "staging", Choices: []string{"staging", "prod"}, Description: "Target | environment"},
+				},
+				Commands: map[string]config.Command{
+					"app": {
+						Run:      "./deploy.sh $env $version",
+						Depends:  config.NewDependencyList("build"),
+						Help:     "Deploy the app container.",
+						Examples: []string{"yxa deploy app 1.2.0"},
+						Params: []config.Param{
+							{Name: "version", Type: "string", Position: 1, Required: true, Description: "Version to deploy"},
+							{Name: "token", Type: "string", Flag: true, Default: "t0k3n", Sensitive: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	root, out := setupEnvTestRoot(cfg)
+	root.RootCmd.SetArgs([]string{"docs"})
+	require.NoError(t, root.Execute())
+	docs := out.String()
+
+	assert.Contains(t, docs, "# shop commands\n")
+	assert.Contains(t, docs, "- [build](#build): Build the app\n- [deploy](#deploy): Deploy the app\n  - [deploy:app](#deployapp)\n")
+	assert.Contains(t, docs, "## deploy\n\nDeploy the app\n\n```sh\nyxa deploy <command> [flags]\n```\n\n**Runs only if:** `$CI == true`\n")
+	assert.Contains(t, docs, "| `--env`, `-e` | string | `staging` | Target \\| environment. One of `staging`, `prod`. |\n")
+	assert.Contains(t, docs, "**Subcommands:** [deploy:app](#deployapp)")
+
+	assert.Contains(t, docs, "## deploy:app\n\nDeploy the app container.\n\n```sh\nyxa deploy app [flags] <version>\n```\n\n**Depends on:** [build](#build)\n")
+	assert.Contains(t, docs, "| `--env`, `-e` | string | `staging` |", "inherited flags are listed")
+	assert.Contains(t, docs, "| `version` (argument 1) | string | required | Version to deploy |\n")
+	assert.Contains(t, docs, "| `--token` | string | `***` |  |\n")
+	assert.NotContains(t, docs, "t0k3n")
+	assert.Contains(t, docs, "Examples:\n\n```sh\nyxa deploy app 1.2.0\n```\n")
+
+	t.Run("output file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "COMMANDS.md")
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"docs", "--output", path})
+		require.NoError(t, root.Execute())
+		assert.Empty(t, out.String())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, docs, string(data))
+	})
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// executeDryRun prints every command that would be executed for cmdName, in
+// execution order, without running anything. It walks the same plan as a real
+// run: dependencies, conditions, pre/post hooks, sequential or parallel tasks and
+// script steps.
+func (h *CommandHandler) executeDryRun(cmdName string, cmdVars map[string]string) error {
+	run := h.RunContext()
+	steps, err := h.buildPlanFrom(cmdName, cmdVars, run.executedCommands())
+	if err != nil {
+		return err
+	}
+
+	out := newMaskingWriter(h.Executor.GetStdout(), h.maskedValues())
+	defer func() { _ = out.Flush() }()
+	for _, step := range steps {
+		// Commands that already ran are skipped silently, just like a real run
+		if step.Duplicate {
+			continue
+		}
+		run.markExecuted(step.Name)
+
+		if !step.ConditionMet {
+			fmt.Fprintf(out, "[dry-run] Would skip '%s' (condition not met: %s)\n", step.Name, step.Command.Condition)
+			continue
+		}
+
+		if step.NotEvaluated {
+			fmt.Fprintf(out, "[dry-run] Would evaluate the condition of '%s' when it runs: %s\n", step.Name, step.Command.Condition)
+		}
+
+		if step.HasSubcommands {
+			if err := h.listSubcommands(step.Name, step.Command); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if step.UpToDateErr != nil {
+			return step.UpToDateErr
+		}
+		if step.UpToDate {
+			fmt.Fprintf(out, "[dry-run] Would skip '%s' (up to date)\n", step.Name)
+			continue
+		}
+
+		for _, dep := range step.SkippedDepends {
+			fmt.Fprintf(out, "[dry-run] Would skip dependency '%s' of '%s' (condition not met: %s)\n", dep.Command, step.Name, dep.Condition)
+		}
+
+		if err := h.validateCommandExecutability(step.Name, step.Command); err != nil {
+			return err
+		}
+
+		// Dependency aggregators have nothing of their own to run
+		if step.Run == "" && len(step.Tasks) == 0 && len(step.Command.Steps) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "[dry-run] Command '%s':\n", step.Name)
+		for _, name := range step.Command.NeededVars() {
+			fmt.Fprintf(out, "[dry-run] Would set %s\n", describeNeed(step.Command, name))
+		}
+		if step.Pre != "" {
+			fmt.Fprintf(out, "[dry-run] Would execute (pre-hook): %s\n", step.Pre)
+		}
+
+		if step.TimeoutErr != nil {
+			return fmt.Errorf("invalid timeout '%s' for command '%s': %w", step.Command.Timeout, step.Name, step.TimeoutErr)
+		}
+		if step.Timeout > 0 {
+			fmt.Fprintf(out, "[dry-run] Would time out after %s\n", step.Timeout)
+		}
+		if step.Runner != "" {
+			fmt.Fprintf(out, "[dry-run] Would run with runner: %s\n", step.Runner)
+		}
+		if step.LogFile != "" {
+			fmt.Fprintf(out, "[dry-run] Would write output to: %s\n", step.LogFile)
+		}
+		if step.StderrFile != "" {
+			fmt.Fprintf(out, "[dry-run] Would write stderr to: %s\n", step.StderrFile)
+		}
+
+		switch {
+		case step.ForeachErr != nil:
+			return step.ForeachErr
+		case len(step.Foreach) > 0:
+			for _, job := range step.Foreach {
+				fmt.Fprintf(out, "[dry-run] Would execute (foreach %s): %s\n", job.ID, job.Command)
+			}
+		case len(step.Matrix) > 0:
+			for _, job := range step.Matrix {
+				fmt.Fprintf(out, "[dry-run] Would execute (matrix %s): %s\n", job.ID, job.Command)
+			}
+		case step.Run != "":
+			fmt.Fprintf(out, "[dry-run] Would execute: %s\n", step.Run)
+			for _, reg := range step.Command.Register {
+				fmt.Fprintf(out, "[dry-run] Would register %s\n", describeRegister(reg))
+			}
+		case len(step.Tasks) == 0:
+			if step.StepsErr != nil {
+				return step.StepsErr
+			}
+			for i, s := range step.Steps {
+				fmt.Fprintf(out, "[dry-run] Would run step #%d: %s\n", i+1, s)
+			}
+		case step.Command.Parallel:
+			for _, task := range step.Tasks {
+				fmt.Fprintln(out, describeDryRunTask("parallel", task))
+			}
+		default:
+			mode := "sequential"
+			if step.Command.Pipe {
+				mode = "piped"
+			}
+			for _, task := range step.Tasks {
+				fmt.Fprintln(out, describeDryRunTask(mode, task))
+			}
+		}
+
+		if step.Post != "" {
+			fmt.Fprintf(out, "[dry-run] Would execute (post-hook): %s\n", step.Post)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandHandler_DryRun(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"OUT": "./bin"},
+		Commands: map[string]config.Command{
+			"clean": {Run: "rm -rf $OUT"},
+			"lint": {
+				Tasks:    config.NewTaskList("go vet ./...", "staticcheck ./..."),
+				Parallel: true,
+				Depends:  config.NewDependencyList("clean"),
+			},
+			"test": {
+				Tasks:   config.NewTaskList("go test ./...", "go test -race ./..."),
+				Depends: config.NewDependencyList("clean"),
+			},
+			"build": {
+				Run:     "go build -o $OUT/$name",
+				Pre:     "echo before $name",
+				Post:    "echo after",
+				Timeout: "1m",
+				Depends: config.NewDependencyList("lint", "test", "tools:gen"),
+				Params: []config.Param{
+					{Name: "name", Type: "string", Default: "app", Flag: true},
+				},
+			},
+			"tools": {
+				Commands: map[string]config.Command{
+					"gen": {Run: "go generate ./..."},
+				},
+			},
+			"windows-only": {
+				Run:       "echo windows",
+				Condition: "$OS == windows",
+				Depends:   config.NewDependencyList("clean"),
+			},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *recordingExecutor, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		return handler, exec, out
+	}
+
+	t.Run("full plan in order", func(t *testing.T) {
+		handler, exec, out := newHandler()
+		if err := handler.ExecuteCommand("build", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		want := []string{
+			"[dry-run] Would execute: rm -rf ./bin",
+			"[dry-run] Would execute (parallel): go vet ./...",
+			"[dry-run] Would execute (parallel): staticcheck ./...",
+			"[dry-run] Would execute (sequential): go test ./...",
+			"[dry-run] Would execute (sequential): go test -race ./...",
+			"[dry-run] Would execute: go generate ./...",
+			"[dry-run] Would execute (pre-hook): echo before app",
+			"[dry-run] Would time out after 1m0s",
+			"[dry-run] Would execute: go build -o ./bin/app",
+			"[dry-run] Would execute (post-hook): echo after",
+		}
+		output := out.String()
+		last := -1
+		for _, line := range want {
+			idx := strings.Index(output, line)
+			if idx < 0 {
+				t.Fatalf("Expected output to contain %q, got:\n%s", line, output)
+			}
+			if idx < last {
+				t.Errorf("Expected %q to appear in execution order, got:\n%s", line, output)
+			}
+			last = idx
+		}
+		assert.Equal(t, 1, strings.Count(output, "rm -rf ./bin"), "shared dependency should be planned once")
+		assert.Empty(t, exec.executed, "dry-run must not execute anything")
+	})
+
+	t.Run("condition not met", func(t *testing.T) {
+		handler, exec, out := newHandler()
+		if err := handler.ExecuteCommand("windows-only", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assert.Contains(t, out.String(), "[dry-run] Would skip 'windows-only'")
+		assert.NotContains(t, out.String(), "rm -rf")
+		assert.Empty(t, exec.executed)
+	})
+
+	t.Run("command group lists subcommands", func(t *testing.T) {
+		handler, exec, out := newHandler()
+		if err := handler.ExecuteCommand("tools", nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assert.Contains(t, out.String(), "Available subcommands for 'tools'")
+		assert.Empty(t, exec.executed)
+	})
+
+	t.Run("every invocation is a run of its own", func(t *testing.T) {
+		handler, exec, out := newHandler()
+		assert.NoError(t, handler.ExecuteCommand("lint", nil))
+		assert.NoError(t, handler.ExecuteCommand("test", nil))
+		assert.Equal(t, 2, strings.Count(out.String(), "rm -rf ./bin"))
+		assert.Empty(t, exec.executed)
+	})
+
+	t.Run("no-dedupe plans shared dependencies every time", func(t *testing.T) {
+		handler, _, out := newHandler()
+		handler.SetNoDedupe(true)
+		assert.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, 2, strings.Count(out.String(), "rm -rf ./bin"))
+	})
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+)
+
+// newEnvCommand creates the built-in 'env' command, which prints the resolved
+// variables together with the source of each value
+func (r *RootCommand) newEnvCommand() *cobra.Command {
+	var showSecrets, includeSystem bool
+
+	cmd := &cobra.Command{
+		Use:   "env [command]",
+		Short: "Show the resolved variables and where they come from",
+		Long: `Show every variable yxa knows about, the value it resolves to and its source
+(param, config, yxa.override.yml, encrypted, .env, builtin or system).
+
+When a command is given (use parent:sub for subcommands), the default values of
+its parameters are included as well. Values of encrypted and sensitive variables and of
+variables whose names look like secrets are masked unless --show-secrets is set.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: r.completeCommandNames(1, nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmdName := ""
+			if len(args) > 0 {
+				cmdName = args[0]
+			}
+			return r.printEnv(cmd.OutOrStdout(), cmdName, showSecrets, includeSystem)
+		},
+	}
+
+	cmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show the values of secret variables")
+	cmd.Flags().BoolVar(&includeSystem, "system", false, "Include system environment variables")
+
+	return cmd
+}
+
+// printEnv writes the resolved variables for the given command (or for no command
+// when cmdName is empty) to out
+func (r *RootCommand) printEnv(out io.Writer, cmdName string, showSecrets, includeSystem bool) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	paramVars, err := r.commandParamDefaults(cmdName)
+	if err != nil {
+		return err
+	}
+
+	resolver := r.Handler.resolver(cmdName, paramVars)
+	cmd, _ := r.Handler.lookupCommand(cmdName)
+	r.Handler.registerSensitive(cmdName, cmd, paramVars)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "NAME\tVALUE\tSOURCE"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	for _, v := range resolver.Variables(includeSystem) {
+		value := v.Value
+		if !showSecrets {
+			value = variables.MaskValue(v.Name, r.Handler.maskSecrets(value))
+		}
+		source := v.Source
+		if source == variables.SourceConfig && r.Config.FromOverride(v.Name) {
+			source = config.OverrideFileName
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, value, source); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return w.Flush()
+}
+
+// commandParamDefaults returns the default parameter values of a command, including
+// the flag parameters a subcommand inherits from its parent
+func (r *RootCommand) commandParamDefaults(cmdName string) (map[string]string, error) {
+	if cmdName == "" {
+		return make(map[string]string), nil
+	}
+
+	cmd, err := r.Handler.lookupCommand(cmdName)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Handler.paramDefaults(cmdName, cmd), nil
+}
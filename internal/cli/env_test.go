@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupEnvTestRoot(cfg *config.ProjectConfig) (*RootCommand, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	exec := executor.NewDefaultExecutor()
+	exec.SetStdout(out)
+	root := NewRootCommand(nil, exec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, exec)
+	root.registerCommands()
+	root.RootCmd.SetOut(out)
+	return root, out
+}
+
+func TestEnvCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "env-project",
+		Variables: map[string]string{
+			"BUILD_DIR": "./build",
+			"API_TOKEN": "s3cr3t",
+		},
+		Commands: map[string]config.Command{
+			"build": {
+				Run: "go build -o $BUILD_DIR",
+				Params: []config.Param{
+					{Name: "BUILD_DIR", Type: "string", Default: "./out", Flag: true},
+					{Name: "verbose", Type: "bool", Flag: true},
+				},
+			},
+		},
+	}
+
+	t.Run("without command", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env"})
+		assert.NoError(t, root.Execute())
+
+		output := out.String()
+		assert.Regexp(t, `BUILD_DIR\s+\./build\s+config`, output)
+		assert.Regexp(t, `API_TOKEN\s+\*+\s+config`, output)
+		assert.Regexp(t, `YXA_PROJECT_NAME\s+env-project\s+builtin`, output)
+		assert.NotContains(t, output, "s3cr3t")
+	})
+
+	t.Run("with command params", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env", "build"})
+		assert.NoError(t, root.Execute())
+
+		output := out.String()
+		assert.Regexp(t, `BUILD_DIR\s+\./out\s+param`, output)
+		assert.Regexp(t, `verbose\s+false\s+param`, output)
+		assert.Regexp(t, `YXA_COMMAND\s+build\s+builtin`, output)
+	})
+
+	t.Run("show secrets", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env", "--show-secrets"})
+		assert.NoError(t, root.Execute())
+		assert.Regexp(t, `API_TOKEN\s+s3cr3t\s+config`, out.String())
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		root, _ := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env", "missing"})
+		root.RootCmd.SilenceUsage = true
+		err := root.Execute()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'missing': command not found")
+	})
+}
+
+func TestEnvCommand_ShadowedByUserCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"env": {Run: "echo user-env"},
+		},
+	}
+	root, out := setupEnvTestRoot(cfg)
+	root.RootCmd.SetArgs([]string{"env"})
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, out.String(), "user-env")
+
+	// The built-in comes back once the user command is gone
+	root.Config = &config.ProjectConfig{Commands: map[string]config.Command{}}
+	root.clearUserCommands()
+	root.registerCommands()
+	cmd := findCommandByName(root.RootCmd, "env")
+	assert.NotNil(t, cmd)
+	assert.True(t, isBuiltinCommand(cmd))
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// Formats of the --error-format flag
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// stderrTailLines is the number of stderr lines included in a JSON error report
+const stderrTailLines = 20
+
+// errorReport is the JSON object written for a failed command with --error-format json
+type errorReport struct {
+	Command       string `json:"command"`        // Command that was invoked
+	FailedCommand string `json:"failed_command"` // Innermost command that failed, e.g. a dependency
+	Stage         string `json:"stage"`          // Stage of the failed command, e.g. run or pre-hook
+	ExitCode      int    `json:"exit_code"`      // Exit code of the failed process, or of yxa
+	DurationMS    int64  `json:"duration_ms"`    // Duration of the run until the failure
+	RunID         string `json:"run_id"`         // Identifier of the run (YXA_RUN_ID)
+	Error         string `json:"error"`          // Full error message
+	Stderr        string `json:"stderr"`         // Last lines written to stderr by the commands
+}
+
+// tailWriter keeps the last lines written to it
+type tailWriter struct {
+	mu    sync.Mutex
+	lines []string
+	max   int
+}
+
+// Write implements io.Writer
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	text := string(p)
+	if len(w.lines) > 0 && !strings.HasSuffix(w.lines[len(w.lines)-1], "\n") {
+		// Continue the last line if it was not terminated yet
+		text = w.lines[len(w.lines)-1] + text
+		w.lines = w.lines[:len(w.lines)-1]
+	}
+	w.lines = append(w.lines, strings.SplitAfter(text, "\n")...)
+	if last := len(w.lines) - 1; w.lines[last] == "" {
+		w.lines = w.lines[:last]
+	}
+	if len(w.lines) > w.max {
+		w.lines = append([]string(nil), w.lines[len(w.lines)-w.max:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the kept lines
+func (w *tailWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.Join(w.lines, "")
+}
+
+// Reset drops the kept lines
+func (w *tailWriter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = nil
+}
+
+// setupErrorFormat validates the --error-format flag. For JSON error reports the
+// stderr of the commands is tailed as well.
+func (r *RootCommand) setupErrorFormat() error {
+	switch r.ErrorFormat {
+	case "", ErrorFormatText:
+		return nil
+	case ErrorFormatJSON:
+	default:
+		return fmt.Errorf("invalid --error-format '%s': expected '%s' or '%s'", r.ErrorFormat, ErrorFormatText, ErrorFormatJSON)
+	}
+
+	if r.stderrTail != nil {
+		r.stderrTail.Reset()
+		return nil
+	}
+	r.stderrTail = &tailWriter{max: stderrTailLines}
+	cmdExec := r.Handler.Executor
+	cmdExec.SetStderr(io.MultiWriter(cmdExec.GetStderr(), r.stderrTail))
+	return nil
+}
+
+// reportCommandError reports a failed command in the format of --error-format and
+// exits with the exit code of the run. kind is "command" or "subcommand".
+func (r *RootCommand) reportCommandError(kind, cmdName string, err error) {
+	code := r.exitCode()
+	if r.ErrorFormat != ErrorFormatJSON {
+		fmt.Printf("Error executing %s '%s': %s\n", kind, cmdName, r.Handler.maskSecrets(errorMessage(cmdName, err)))
+		exitFunc(code)
+		return
+	}
+
+	report := r.newErrorReport(cmdName, err, code)
+	var b bytes.Buffer
+	if encErr := json.NewEncoder(&b).Encode(report); encErr == nil {
+		_, _ = r.RootCmd.ErrOrStderr().Write(b.Bytes())
+	}
+	exitFunc(code)
+}
+
+// newErrorReport describes a failed command for --error-format json
+func (r *RootCommand) newErrorReport(cmdName string, err error, code int) errorReport {
+	run := r.Handler.RunContext()
+	report := errorReport{
+		Command:       cmdName,
+		FailedCommand: cmdName,
+		Stage:         errors.StageRun,
+		ExitCode:      code,
+		DurationMS:    time.Since(run.StartedAt).Milliseconds(),
+		RunID:         run.ID,
+		Error:         r.Handler.maskSecrets(err.Error()),
+	}
+	if r.stderrTail != nil {
+		report.Stderr = r.stderrTail.String()
+	}
+
+	// The innermost command error tells which command failed, and where
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		switch typed := e.(type) {
+		case *errors.CommandError:
+			report.FailedCommand = typed.CommandName
+			report.Stage = typed.Stage
+			if report.Stage == "" {
+				report.Stage = errors.StageRun
+			}
+		case *errors.ConfigError:
+			if typed.Section == "command" {
+				report.FailedCommand = typed.Name
+			}
+			report.Stage = errors.StageValidate
+		case *exec.ExitError:
+			if typed.ExitCode() >= 0 {
+				report.ExitCode = typed.ExitCode()
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{max: 2}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree\nfour"))
+	assert.Equal(t, "three\nfour", w.String())
+
+	w.Reset()
+	assert.Empty(t, w.String())
+}
+
+func TestErrorFormatJSON(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"vet":   {Run: "echo checking; echo 'vet: bad code' >&2; exit 3"},
+			"build": {Run: "echo building", Depends: config.NewDependencyList("vet")},
+			"hooks": {Run: "echo run", Pre: "exit 1"},
+			"empty": {},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) (*bytes.Buffer, *bytes.Buffer, int) {
+		t.Helper()
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(stdout)
+		exec.SetStderr(stderr)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(stdout)
+		root.RootCmd.SetErr(stderr)
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		require.NoError(t, root.Execute())
+		return stdout, stderr, code
+	}
+
+	report := func(t *testing.T, stderr *bytes.Buffer) errorReport {
+		t.Helper()
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		var r errorReport
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &r), stderr.String())
+		return r
+	}
+
+	t.Run("failing dependency", func(t *testing.T) {
+		_, stderr, code := run(t, "build", "--error-format", "json")
+		assert.Equal(t, 1, code)
+
+		r := report(t, stderr)
+		assert.Equal(t, "build", r.Command)
+		assert.Equal(t, "vet", r.FailedCommand)
+		assert.Equal(t, "run", r.Stage)
+		assert.Equal(t, 3, r.ExitCode)
+		assert.Equal(t, "[vet] vet: bad code\n", r.Stderr)
+		assert.NotEmpty(t, r.RunID)
+		assert.Contains(t, r.Error, "command 'build': failed to execute dependency 'vet'")
+	})
+
+	t.Run("failing hook", func(t *testing.T) {
+		_, stderr, _ := run(t, "hooks", "--error-format", "json")
+		r := report(t, stderr)
+		assert.Equal(t, "hooks", r.FailedCommand)
+		assert.Equal(t, "pre-hook", r.Stage)
+	})
+
+	t.Run("invalid command", func(t *testing.T) {
+		_, stderr, _ := run(t, "empty", "--error-format", "json")
+		r := report(t, stderr)
+		assert.Equal(t, "empty", r.FailedCommand)
+		assert.Equal(t, "validate", r.Stage)
+		assert.Equal(t, 1, r.ExitCode)
+	})
+
+	t.Run("text by default", func(t *testing.T) {
+		_, stderr, code := run(t, "build")
+		assert.Equal(t, 1, code)
+		assert.NotContains(t, stderr.String(), "{")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		root := NewRootCommand(cfg, executor.NewDefaultExecutor())
+		root.RootCmd.SetOut(stdout)
+		root.RootCmd.SetErr(stdout)
+		root.RootCmd.SetArgs([]string{"env", "--error-format", "xml"})
+		err := root.Execute()
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("invalid --error-format 'xml': expected '%s' or '%s'", ErrorFormatText, ErrorFormatJSON), err.Error())
+	})
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/tracing"
+)
+
+// setupEvents creates the emitter for the --events and --events-fd flags
+func (r *RootCommand) setupEvents() error {
+	r.events = nil
+	if r.EventsFormat == "" {
+		return nil
+	}
+
+	var w io.Writer
+	switch r.EventsFD {
+	case 1:
+		w = r.RootCmd.OutOrStdout()
+	case 2:
+		w = r.RootCmd.ErrOrStderr()
+	default:
+		f := os.NewFile(uintptr(r.EventsFD), fmt.Sprintf("fd%d", r.EventsFD))
+		if f == nil {
+			return fmt.Errorf("invalid --events-fd %d", r.EventsFD)
+		}
+		if _, err := f.Stat(); err != nil {
+			return fmt.Errorf("invalid --events-fd %d: %w", r.EventsFD, err)
+		}
+		w = f
+	}
+
+	emitter, err := events.NewEmitter(r.EventsFormat, w)
+	if err != nil {
+		return err
+	}
+	r.events = emitter
+	return nil
+}
+
+// emit writes a structured event for the current run
+func (h *CommandHandler) emit(event events.Event) {
+	if !h.Events.Enabled() {
+		return
+	}
+	event.RunID = h.RunContext().ID
+	event.Error = h.maskSecrets(event.Error)
+	event.Output = h.maskSecrets(event.Output)
+	h.Events.Emit(event)
+}
+
+// emitCommandEnd writes the command_end event of a command. A failure is also
+// reported as an error event, unless it was caused by a failing dependency or
+// subcommand that already reported its own error. A command that did not run
+// because it was up to date or its condition was not met ends as skipped.
+func (h *CommandHandler) emitCommandEnd(cmdName string, start time.Time, failuresBefore int, skipped bool, err error) {
+	end := events.Event{
+		Type:       events.CommandEnd,
+		Command:    cmdName,
+		Status:     events.StatusOK,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+
+	if skipped {
+		end.Status = events.StatusSkipped
+	}
+	if err != nil {
+		if h.RunContext().recordFailure(cmdName, failuresBefore) {
+			h.emit(events.Event{Type: events.Error, Command: cmdName, Error: err.Error()})
+		}
+		end.Status = events.StatusFailed
+		end.Error = err.Error()
+	}
+
+	h.emit(end)
+}
+
+// executeTask runs a sequential task. When events are enabled its output is
+// captured as well and reported as a task_output event.
+func (h *CommandHandler) executeTask(cmdName string, task int, cmdStr string, timeout time.Duration) (err error) {
+	span := h.startSpan(taskSpanName(cmdName, task), cmdName, tracing.String("yxa.command", cmdName), tracing.Int("yxa.task", int64(task)))
+	defer func() { endSpan(span, err) }()
+
+	if !h.Events.Enabled() {
+		return h.execute(cmdStr, timeout)
+	}
+
+	output, err := h.executeWithOutput(cmdStr, timeout)
+	if output != "" {
+		h.emit(events.Event{Type: events.TaskOutput, Command: cmdName, Task: task, Output: output})
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeEvents parses newline-delimited JSON events
+func decodeEvents(t *testing.T, data string) []events.Event {
+	t.Helper()
+	var result []events.Event
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var event events.Event
+		require.NoError(t, json.Unmarshal([]byte(line), &event), "invalid event %q", line)
+		result = append(result, event)
+	}
+	return result
+}
+
+func TestCommandHandler_Events(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"lint": {Run: "golangci-lint run", Pre: "echo linting"},
+			"test": {Run: "go test ./..."},
+			"ci":   {Depends: config.NewDependencyList("lint", "test")},
+		},
+	}
+
+	exec := &recordingExecutor{testExecutor: testExecutor{
+		stdout:         io.Discard,
+		stderr:         io.Discard,
+		commandResults: map[string]error{"go test ./...": errors.New("exit status 1")},
+	}}
+	out := &bytes.Buffer{}
+	emitter, err := events.NewEmitter(events.FormatJSON, out)
+	require.NoError(t, err)
+
+	handler := NewCommandHandler(cfg, exec)
+	handler.SetEvents(emitter)
+	require.Error(t, handler.ExecuteCommand("ci", nil))
+
+	var types []string
+	for _, event := range decodeEvents(t, out.String()) {
+		assert.Equal(t, handler.RunContext().ID, event.RunID)
+		types = append(types, event.Type+" "+event.Command+" "+event.Hook+event.Status)
+	}
+	assert.Equal(t, []string{
+		"command_start ci ",
+		"command_start lint ",
+		"hook_start lint pre",
+		"command_end lint ok",
+		"command_start test ",
+		"error test ",
+		"command_end test failed",
+		"command_end ci failed",
+	}, types)
+}
+
+func TestRootCommand_Events(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"checks": {Tasks: config.NewTaskList("echo one", "echo two")},
+		},
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	exec := &recordingExecutor{testExecutor: testExecutor{stdout: stdout, stderr: stdout}}
+	root := NewRootCommand(nil, exec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, exec)
+	root.registerCommands()
+	root.RootCmd.SetOut(stdout)
+	root.RootCmd.SetErr(stderr)
+
+	t.Run("json on stderr", func(t *testing.T) {
+		root.RootCmd.SetArgs([]string{"checks", "--events", "json"})
+		require.NoError(t, root.Execute())
+
+		var types []string
+		for _, event := range decodeEvents(t, stderr.String()) {
+			types = append(types, event.Type)
+		}
+		assert.Equal(t, []string{"command_start", "command_end"}, types)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		root.RootCmd.SetArgs([]string{"checks", "--events", "xml"})
+		err := root.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported event format 'xml'")
+	})
+}
+
+func TestCommandHandler_TaskOutputEvents(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"sequential": {Tasks: config.NewTaskList("echo one", "echo two")},
+			"parallel":   {Tasks: config.NewTaskList("echo three"), Parallel: true},
+		},
+	}
+
+	for name, want := range map[string][]string{
+		"sequential": {"1: one\n", "2: two\n"},
+		"parallel":   {"1: three\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			exec := executor.NewDefaultExecutor()
+			exec.SetStdout(io.Discard)
+			exec.SetStderr(io.Discard)
+			out := &bytes.Buffer{}
+			emitter, err := events.NewEmitter(events.FormatJSON, out)
+			require.NoError(t, err)
+
+			handler := NewCommandHandler(cfg, exec)
+			handler.SetEvents(emitter)
+			require.NoError(t, handler.ExecuteCommand(name, nil))
+
+			var outputs []string
+			for _, event := range decodeEvents(t, out.String()) {
+				if event.Type == events.TaskOutput {
+					outputs = append(outputs, fmt.Sprintf("%d: %s", event.Task, event.Output))
+				}
+			}
+			assert.Equal(t, want, outputs)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// inlineCommandName is the name an ad-hoc command of 'yxa exec' runs as, e.g. in
+// $YXA_COMMAND, run events and error messages
+const inlineCommandName = "exec"
+
+// ExecuteInline runs a shell line that is not defined in the config as a new run.
+// It runs like a configured command with only run and timeout set, so variables,
+// .env files, --set overrides, events and the other global flags apply to it.
+func (h *CommandHandler) ExecuteInline(cmdStr, timeout string) error {
+	h.run = NewRunContext()
+	if h.ctx != nil {
+		h.run.Context = h.ctx
+	}
+
+	cmd := config.Command{Run: cmdStr, Timeout: timeout}
+	if h.DryRun {
+		fmt.Fprintf(h.Executor.GetStdout(), "[dry-run] Would execute: %s\n", h.replaceVariablesInString(inlineCommandName, cmdStr, nil))
+		return nil
+	}
+	if err := h.installTools(); err != nil {
+		return err
+	}
+	return h.runResolvedCommand("", inlineCommandName, cmd, make(map[string]string))
+}
+
+// newExecCommand creates the built-in 'exec' command, which runs an ad-hoc shell
+// line with the variables and environment handling of yxa
+func (r *RootCommand) newExecCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec -- <command line>",
+		Short: "Run an ad-hoc shell command with the variables of yxa",
+		Long: `Run a shell command that is not defined in the config as if it were one: its
+variables ($VAR, ${VAR}) are resolved from the config, the .env file, --set and
+the other variable sources, and --timeout, --dry-run and --events apply to it.
+
+Put the command line after --, so that its flags are not taken as flags of yxa:
+
+  yxa exec -- go test ./...
+  yxa exec --timeout 5m --set TARGET=prod -- './deploy.sh $TARGET'`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if r.Config == nil {
+				return fmt.Errorf("no configuration loaded")
+			}
+
+			// Apply the global execution flags to the handler
+			r.configureHandler()
+
+			// The line has no timeout of its own, --timeout applies to it
+			err := r.Handler.ExecuteInline(strings.Join(args, " "), "")
+			r.finishRun(cmd, args, inlineCommandName, err)
+			if err != nil {
+				r.reportCommandError("command", inlineCommandName, err)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
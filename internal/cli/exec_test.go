@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // the .env file is read from the current directory
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+variables:
+  TARGET: local
+commands:
+  build:
+    run: echo build
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("TOKEN=from-env-file\n"), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	run := func(t *testing.T, args ...string) (string, int) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		require.NoError(t, root.Execute())
+		return out.String(), code
+	}
+
+	t.Run("resolves variables like a configured command", func(t *testing.T) {
+		out, code := run(t, "exec", "--", "echo $TARGET $TOKEN $YXA_COMMAND")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "local from-env-file exec\n", out)
+	})
+
+	t.Run("global flags apply", func(t *testing.T) {
+		out, _ := run(t, "exec", "--set", "TARGET=prod", "--", "echo", "$TARGET")
+		assert.Equal(t, "prod\n", out)
+
+		out, _ = run(t, "exec", "--dry-run", "--", "echo $TARGET")
+		assert.Equal(t, "[dry-run] Would execute: echo local\n", out)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, code := run(t, "exec", "--timeout", "100ms", "--", "sleep 2")
+		assert.Equal(t, 1, code)
+
+		_, code = run(t, "exec", "--timeout", "soon", "--", "true")
+		assert.Equal(t, 1, code)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, code := run(t, "exec", "--", "exit 3")
+		assert.Equal(t, 1, code)
+	})
+
+	t.Run("history keeps the arguments after --", func(t *testing.T) {
+		entries, err := history.NewStore(filepath.Join(dir, stateDirName)).Load()
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		assert.Equal(t, "exec", entries[0].Command)
+		assert.Equal(t, []string{"exec", "--", "echo $TARGET $TOKEN $YXA_COMMAND"}, entries[0].Args)
+		last := entries[len(entries)-1]
+		assert.Equal(t, []string{"exec", "--", "exit 3"}, last.Args)
+		assert.Equal(t, history.OutcomeFailure, last.Outcome)
+	})
+
+	t.Run("no configuration", func(t *testing.T) {
+		root := NewRootCommand(nil, executor.NewDefaultExecutor())
+		root.RootCmd.SetArgs([]string{"exec", "--", "true"})
+		root.RootCmd.PersistentPreRunE = nil
+		root.RootCmd.SetOut(&bytes.Buffer{})
+		root.RootCmd.SetErr(&bytes.Buffer{})
+		assert.ErrorContains(t, root.RootCmd.Execute(), "no configuration loaded")
+	})
+}
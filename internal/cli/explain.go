@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newExplainCommand creates the built-in 'explain' command, which prints the full
+// execution plan of a command without running anything
+func (r *RootCommand) newExplainCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <command>",
+		Short: "Show everything yxa would do for a command without executing it",
+		Long: `Show the execution plan of a command without running anything: the dependency
+order, condition results, hooks, resolved run strings, tasks and steps, timeouts and
+working directories. Commands that are skipped because they already ran earlier
+in the same invocation are listed as well.
+
+Use parent:sub to explain a subcommand. Parameters use their default values.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: r.completeCommandNames(1, nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.explainCommand(cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+// explainCommand writes the execution plan of the given command to out
+func (r *RootCommand) explainCommand(out io.Writer, cmdName string) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	r.configureHandler()
+	steps, err := r.Handler.buildPlan(cmdName, r.createCommandVariables())
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Execution plan for '%s':\n", cmdName)
+	for i, step := range steps {
+		writePlanStep(&b, i+1, step)
+	}
+
+	if _, err := io.WriteString(out, r.Handler.maskSecrets(b.String())); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	return nil
+}
+
+// writePlanStep writes a human readable description of a plan step
+func writePlanStep(b *strings.Builder, index int, step planStep) {
+	indent := strings.Repeat("  ", step.Depth)
+	fmt.Fprintf(b, "\n%s%d. %s\n", indent, index, step.Name)
+	indent += "   "
+
+	if step.Duplicate {
+		fmt.Fprintf(b, "%sskipped: already executed earlier in this run\n", indent)
+		return
+	}
+
+	if step.Command.Description != "" {
+		fmt.Fprintf(b, "%sdescription: %s\n", indent, step.Command.Description)
+	}
+	if step.Condition != "" {
+		if !step.ConditionMet {
+			fmt.Fprintf(b, "%scondition:   %s => %s (not met)\n", indent, step.Command.Condition, step.Condition)
+			fmt.Fprintf(b, "%sskipped: condition not met, dependencies and hooks do not run\n", indent)
+			return
+		}
+		if step.NotEvaluated {
+			fmt.Fprintf(b, "%scondition:   %s => %s (not evaluated, runs a command)\n", indent, step.Command.Condition, step.Condition)
+		} else {
+			fmt.Fprintf(b, "%scondition:   %s => %s (met)\n", indent, step.Command.Condition, step.Condition)
+		}
+	}
+	if len(step.Command.Depends) > 0 {
+		skipped := make(map[string]bool, len(step.SkippedDepends))
+		for _, dep := range step.SkippedDepends {
+			skipped[dep.Command] = true
+		}
+		deps := make([]string, len(step.Command.Depends))
+		for i, dep := range step.Command.Depends {
+			switch {
+			case dep.Condition == "":
+				deps[i] = dep.Command
+			case skipped[dep.Command]:
+				deps[i] = fmt.Sprintf("%s (if %s, not met, skipped)", dep.Command, dep.Condition)
+			case config.ConditionRunsCommand(dep.Condition):
+				deps[i] = fmt.Sprintf("%s (if %s, not evaluated)", dep.Command, dep.Condition)
+			default:
+				deps[i] = fmt.Sprintf("%s (if %s, met)", dep.Command, dep.Condition)
+			}
+		}
+		fmt.Fprintf(b, "%sdepends:     %s\n", indent, strings.Join(deps, ", "))
+		if step.Command.DependsMode != "" {
+			fmt.Fprintf(b, "%sdepends_mode: %s\n", indent, step.Command.DependsMode)
+		}
+	}
+	if len(step.Command.After) > 0 {
+		fmt.Fprintf(b, "%safter:       %s\n", indent, strings.Join(step.Command.After, ", "))
+	}
+	for _, name := range step.Command.NeededVars() {
+		fmt.Fprintf(b, "%sneeds:       %s\n", indent, describeNeed(step.Command, name))
+	}
+	if len(step.Command.Requires) > 0 {
+		reqs := make([]string, len(step.Command.Requires))
+		for i, req := range step.Command.Requires {
+			reqs[i] = req.String()
+		}
+		fmt.Fprintf(b, "%srequires:    %s\n", indent, strings.Join(reqs, ", "))
+	}
+	if step.WorkingDir != "" {
+		fmt.Fprintf(b, "%sworkingdir:  %s\n", indent, step.WorkingDir)
+	}
+	if step.Runner != "" {
+		fmt.Fprintf(b, "%srunner:      %s\n", indent, step.Runner)
+	}
+	if step.LogFile != "" {
+		fmt.Fprintf(b, "%slog_file:    %s\n", indent, step.LogFile)
+	}
+	if step.StderrFile != "" {
+		fmt.Fprintf(b, "%sstderr_file: %s\n", indent, step.StderrFile)
+	}
+	if limits := describeLimits(step.Command); limits != "" {
+		fmt.Fprintf(b, "%slimits:      %s\n", indent, limits)
+	}
+	if step.Command.Output != "" {
+		fmt.Fprintf(b, "%soutput:      %s\n", indent, step.Command.Output)
+	}
+	if len(step.Command.Artifacts) > 0 {
+		fmt.Fprintf(b, "%sartifacts:   %s\n", indent, strings.Join(step.Command.Artifacts, ", "))
+	}
+	if len(step.Command.Sources) > 0 {
+		fmt.Fprintf(b, "%ssources:     %s\n", indent, strings.Join(step.Command.Sources, ", "))
+	}
+	if len(step.Command.Generates) > 0 {
+		fmt.Fprintf(b, "%sgenerates:   %s\n", indent, strings.Join(step.Command.Generates, ", "))
+	}
+	switch {
+	case step.UpToDateErr != nil:
+		fmt.Fprintf(b, "%sup to date:  unknown: %v\n", indent, step.UpToDateErr)
+	case step.UpToDate:
+		fmt.Fprintf(b, "%sskipped: up to date, generates are newer than sources (--force runs it)\n", indent)
+	}
+	if step.Command.Stdin != "" {
+		fmt.Fprintf(b, "%sstdin:       %s\n", indent, describeStdin(step.Command.Stdin))
+	}
+	if len(step.Command.Variables) > 0 {
+		names := make([]string, 0, len(step.Command.Variables))
+		for name := range step.Command.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(b, "%svariables:   %s\n", indent, strings.Join(names, ", "))
+	}
+	if !step.Command.InheritsEnv() {
+		fmt.Fprintf(b, "%sinherit_env: false\n", indent)
+	} else if step.Command.ExportsEnv() {
+		fmt.Fprintf(b, "%sexport_env:  true\n", indent)
+	}
+	if step.TimeoutErr != nil {
+		fmt.Fprintf(b, "%stimeout:     invalid '%s': %v\n", indent, step.Command.Timeout, step.TimeoutErr)
+	} else if step.Timeout > 0 {
+		fmt.Fprintf(b, "%stimeout:     %s\n", indent, step.Timeout)
+	}
+	if stall := describeStall(step.Command); stall != "" {
+		fmt.Fprintf(b, "%sstall:       %s\n", indent, stall)
+	}
+	if step.Pre != "" {
+		fmt.Fprintf(b, "%spre-hook:    %s%s\n", indent, step.Pre, describeHookTimeout(step.Command.PreTimeout))
+	}
+
+	switch {
+	case step.HasSubcommands:
+		fmt.Fprintf(b, "%srun:         (command group, lists its subcommands)\n", indent)
+	case step.ForeachErr != nil:
+		fmt.Fprintf(b, "%sforeach:     invalid: %v\n", indent, step.ForeachErr)
+	case len(step.Foreach) > 0:
+		mode := "sequential"
+		if step.Command.Parallel {
+			mode = parallelMode(step.Command)
+		}
+		fmt.Fprintf(b, "%sforeach (%s, %s):\n", indent, mode, step.Command.Foreach)
+		for _, job := range step.Foreach {
+			fmt.Fprintf(b, "%s  [%s] %s\n", indent, job.ID, job.Command)
+		}
+	case len(step.Matrix) > 0:
+		fmt.Fprintf(b, "%smatrix (%s, %d combinations):\n", indent, parallelMode(step.Command), len(step.Matrix))
+		for _, job := range step.Matrix {
+			fmt.Fprintf(b, "%s  [%s] %s\n", indent, job.ID, job.Command)
+		}
+	case step.Run != "":
+		fmt.Fprintf(b, "%srun:         %s\n", indent, step.Run)
+		for _, reg := range step.Command.Register {
+			fmt.Fprintf(b, "%sregister:    %s\n", indent, describeRegister(reg))
+		}
+	case len(step.Tasks) > 0:
+		mode := "sequential"
+		if step.Command.Parallel {
+			mode = parallelMode(step.Command)
+		} else if step.Command.Pipe {
+			mode = "piped"
+		}
+		fmt.Fprintf(b, "%stasks (%s):\n", indent, mode)
+		for i, task := range step.Tasks {
+			switch {
+			case task.Condition == "":
+				fmt.Fprintf(b, "%s  #%d %s\n", indent, i+1, task)
+			case task.NotEvaluated:
+				fmt.Fprintf(b, "%s  #%d %s (condition: %s, not evaluated)\n", indent, i+1, task, task.Condition)
+			case task.ConditionMet:
+				fmt.Fprintf(b, "%s  #%d %s (condition: %s, met)\n", indent, i+1, task, task.Condition)
+			default:
+				fmt.Fprintf(b, "%s  #%d %s (condition: %s, not met, skipped)\n", indent, i+1, task, task.Condition)
+			}
+		}
+	case step.StepsErr != nil:
+		fmt.Fprintf(b, "%ssteps:       invalid: %v\n", indent, step.StepsErr)
+	case len(step.Steps) > 0:
+		fmt.Fprintf(b, "%ssteps:\n", indent)
+		for i, s := range step.Steps {
+			fmt.Fprintf(b, "%s  #%d %s\n", indent, i+1, s)
+		}
+	case len(step.Command.Depends) > 0:
+		fmt.Fprintf(b, "%srun:         (dependency aggregator, nothing to run)\n", indent)
+	default:
+		fmt.Fprintf(b, "%srun:         (nothing defined, execution would fail)\n", indent)
+	}
+
+	if step.Post != "" {
+		fmt.Fprintf(b, "%spost-hook:   %s%s\n", indent, step.Post, describeHookTimeout(step.Command.PostTimeout))
+	}
+	if step.OnCancel != "" {
+		fmt.Fprintf(b, "%son_cancel:   %s\n", indent, step.OnCancel)
+	}
+}
+
+// describeHookTimeout returns the timeout of a hook for explain output, or "" if
+// it has none
+func describeHookTimeout(timeout string) string {
+	if timeout == "" {
+		return ""
+	}
+	return " (timeout " + timeout + ")"
+}
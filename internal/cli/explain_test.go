@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingExecutor records every command it is asked to run and returns the
+// configured result for it
+type recordingExecutor struct {
+	testExecutor
+	executed []string
+}
+
+func (e *recordingExecutor) Execute(command string, timeout time.Duration) error {
+	e.executed = append(e.executed, command)
+	return e.commandResults[command]
+}
+
+func (e *recordingExecutor) ExecuteWithOutput(command string, timeout time.Duration) (string, error) {
+	e.executed = append(e.executed, command)
+	return "", nil
+}
+
+func TestExplainCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name:       "explain-project",
+		WorkingDir: "./src",
+		Variables:  map[string]string{"OUT": "./bin", "MODE": "release"},
+		Commands: map[string]config.Command{
+			"clean": {Run: "rm -rf $OUT"},
+			"generate": {
+				Run:     "go generate ./...",
+				Depends: config.NewDependencyList("clean"),
+			},
+			"build": {
+				Description: "Build it",
+				Run:         "go build -o $OUT/$name",
+				Pre:         "echo building $YXA_COMMAND",
+				Post:        "echo done",
+				OnCancel:    "rm -f $OUT/$name",
+				Timeout:     "30s",
+				Condition:   "$name == app",
+				Depends:     config.NewDependencyList("clean", "generate"),
+				Params: []config.Param{
+					{Name: "name", Type: "string", Default: "app", Flag: true},
+				},
+			},
+			"debug-only": {
+				Run:       "echo debug",
+				Condition: "$MODE == debug",
+				Depends:   config.NewDependencyList("clean"),
+			},
+			"checks": {
+				Tasks:    config.NewTaskList("go vet ./...", "go test $OUT"),
+				Parallel: true,
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+	root := NewRootCommand(nil, exec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, exec)
+	root.registerCommands()
+	root.RootCmd.SetOut(out)
+
+	t.Run("dependencies, hooks and condition", func(t *testing.T) {
+		out.Reset()
+		root.RootCmd.SetArgs([]string{"explain", "build"})
+		assert.NoError(t, root.Execute())
+
+		output := out.String()
+		assert.Contains(t, output, "Execution plan for 'build'")
+		assert.Regexp(t, `(?s)1\. clean.*run:\s+rm -rf \./bin.*2\. clean\s+skipped: already executed.*3\. generate.*4\. build`, output)
+		assert.Contains(t, output, "condition:   $name == app => app == app (met)")
+		assert.Contains(t, output, "pre-hook:    echo building build")
+		assert.Contains(t, output, "run:         go build -o ./bin/app")
+		assert.Contains(t, output, "post-hook:   echo done")
+		assert.Contains(t, output, "on_cancel:   rm -f ./bin/app")
+		assert.Contains(t, output, "timeout:     30s")
+		assert.Contains(t, output, "workingdir:  ./src")
+	})
+
+	t.Run("condition not met", func(t *testing.T) {
+		out.Reset()
+		root.RootCmd.SetArgs([]string{"explain", "debug-only"})
+		assert.NoError(t, root.Execute())
+
+		output := out.String()
+		assert.Contains(t, output, "condition:   $MODE == debug => release == debug (not met)")
+		assert.Contains(t, output, "skipped: condition not met")
+		assert.NotContains(t, output, "clean")
+	})
+
+	t.Run("tasks", func(t *testing.T) {
+		out.Reset()
+		root.RootCmd.SetArgs([]string{"explain", "checks"})
+		assert.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "tasks (parallel):")
+		assert.Contains(t, out.String(), "#2 go test ./bin")
+	})
+
+	assert.Empty(t, exec.executed, "explain must not execute anything")
+}
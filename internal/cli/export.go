@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+)
+
+// Formats of 'yxa export'
+const (
+	ExportMakefile = "makefile"
+	ExportNPM      = "npm"
+	ExportJust     = "just"
+)
+
+// exportFormats are the formats 'yxa export' can write
+var exportFormats = []string{ExportMakefile, ExportNPM, ExportJust}
+
+// exportLine is a line of an exported command: a shell script, or a reference to
+// another command for tasks that run one
+type exportLine struct {
+	Script  string
+	Command string
+}
+
+// exportedCommand is a command in the form the exporters write it
+type exportedCommand struct {
+	Name        string
+	Description string
+	Depends     []string
+	Lines       []exportLine
+}
+
+// newExportCommand creates the built-in 'export' command, which writes the
+// commands of the config as a Makefile, npm scripts or a justfile
+func (r *RootCommand) newExportCommand() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the commands as a Makefile, npm scripts or a justfile",
+		Long: `Write an equivalent of the commands of the config in another format, to adopt
+yxa step by step or to work with tools that expect one of them:
+
+  makefile  a Makefile with a phony target per command
+  npm       the "scripts" block of a package.json
+  just      a justfile with a recipe per command
+
+Run lines, hooks, sequential tasks, dependencies and variables are exported.
+The export is best effort: features the format has no equivalent for, such as
+parameters, conditions, timeouts or matrices, are left out with a warning.
+Subcommands are exported as parent-sub (parent:sub for npm).
+
+'yxa export gha' writes a GitHub Actions workflow that runs yxa commands instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeExport(cmd, output, func(out io.Writer) error {
+				return r.exportConfig(out, cmd.ErrOrStderr(), format)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", ExportMakefile, "Format to export to: "+strings.Join(exportFormats, ", "))
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", "", "Write the export to this file instead of stdout")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(exportFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	cmd.AddCommand(r.newExportGHACommand(&output))
+
+	return cmd
+}
+
+// writeExport runs write with the file at output, or stdout if output is empty
+func writeExport(cmd *cobra.Command, output string, write func(io.Writer) error) error {
+	if output == "" {
+		return write(cmd.OutOrStdout())
+	}
+	// #nosec G304 -- Writing the export to a user specified file is intended
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportConfig writes the commands of the config in the given format to out and
+// the features that could not be exported to warnings
+func (r *RootCommand) exportConfig(out, warnings io.Writer, format string) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	var write func(io.Writer, []exportedCommand, map[string]string) error
+	switch format {
+	case ExportMakefile:
+		write = writeMakefile
+	case ExportNPM:
+		write = writeNPMScripts
+	case ExportJust:
+		write = writeJustfile
+	default:
+		return fmt.Errorf("invalid --format '%s': expected one of %s", format, strings.Join(exportFormats, ", "))
+	}
+
+	// npm scripts have no variables, the values are written into the scripts
+	vars := r.exportVariables()
+	inline := map[string]string(nil)
+	if format == ExportNPM {
+		inline = vars
+	}
+
+	var commands []exportedCommand
+	for _, c := range flattenCommands(r.Config) {
+		cmd, err := r.Handler.lookupCommand(c.Name)
+		if err != nil {
+			return err
+		}
+		// Command groups that only list subcommands have nothing to export
+		if len(cmd.Commands) > 0 && cmd.Run == "" && len(cmd.Tasks) == 0 && len(cmd.Steps) == 0 {
+			continue
+		}
+		exported, problems := exportCommand(c.Name, cmd, inline)
+		if format == ExportNPM && strings.Contains(cmd.Pre+cmd.Run+cmd.Post, "\n") {
+			problems = append(problems, "the lines of multi-line scripts are chained with &&")
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(warnings, "Warning: command '%s': %s\n", c.Name, problem)
+		}
+		if exported != nil {
+			commands = append(commands, *exported)
+		}
+	}
+
+	return write(out, commands, vars)
+}
+
+// exportVariables returns the config variables with references between them resolved
+func (r *RootCommand) exportVariables() map[string]string {
+	resolver := variables.NewResolver().WithConfigVars(r.Config.Variables).WithSystemEnvVar(false)
+	vars := make(map[string]string, len(r.Config.Variables))
+	for name, value := range r.Config.Variables {
+		vars[name] = resolver.Resolve(value)
+	}
+	return vars
+}
+
+// exportCommand converts a command for the exporters. The command variables, and
+// the config variables in inline, are written into the scripts. It returns the
+// features of the command that cannot be exported; a nil command means it was left
+// out entirely.
+func exportCommand(name string, cmd config.Command, inline map[string]string) (*exportedCommand, []string) {
+	var problems []string
+	if len(cmd.Steps) > 0 {
+		return nil, []string{"steps cannot be exported, the command is left out"}
+	}
+
+	unsupported := []struct {
+		set     bool
+		feature string
+	}{
+		{len(cmd.Params) > 0, "parameters"},
+		{cmd.Condition != "", "condition"},
+		{cmd.Timeout != "", "timeout"},
+		{len(cmd.Matrix) > 0, "matrix"},
+		{cmd.Foreach != "", "foreach"},
+		{len(cmd.Register) > 0, "register"},
+		{len(cmd.Needs) > 0, "needs"},
+		{len(cmd.After) > 0, "after"},
+		{cmd.Service, "service"},
+		{cmd.Runner != nil || cmd.Container != nil, "runner and container"},
+		{cmd.LogFile != nil || cmd.StderrFile != nil, "log_file and stderr_file"},
+		{len(cmd.Requires) > 0, "requires"},
+		{cmd.OnCancel != "", "on_cancel"},
+		{cmd.Nice != 0 || cmd.CPULimit != "" || cmd.MemoryLimit != "", "resource limits"},
+		{cmd.Output == config.OutputCaptured, "captured output"},
+		{!cmd.InheritsEnv(), "inherit_env: false"},
+		{cmd.InheritsEnv() && cmd.ExportsEnv(), "export_env"},
+		{cmd.DependsMode == config.DependsModeAll, "depends_mode: all"},
+		{cmd.ContinueOnError, "continue_on_error"},
+		{cmd.Notify != nil, "notify"},
+		{cmd.Stdin != "", "stdin"},
+		{len(cmd.Sources) > 0 || len(cmd.Generates) > 0, "sources and generates"},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			problems = append(problems, u.feature+" is not supported and left out")
+		}
+	}
+	if cmd.Parallel && len(cmd.Tasks) > 1 {
+		problems = append(problems, "parallel tasks run one after another")
+	}
+	if cmd.Pipe && len(cmd.Tasks) > 1 {
+		problems = append(problems, "piped tasks run one after another without a pipe")
+	}
+	for _, dep := range cmd.Depends {
+		if dep.Condition != "" {
+			problems = append(problems, fmt.Sprintf("condition of dependency '%s' is not supported, it always runs", dep.Command))
+		}
+	}
+
+	resolver := variables.NewResolver().WithConfigVars(inline).WithCommandVars(cmd.Variables).WithSystemEnvVar(false)
+	exported := &exportedCommand{Name: name, Description: cmd.Description, Depends: cmd.Depends.Commands()}
+	addScript := func(script string) {
+		if script == "" {
+			return
+		}
+		for _, ref := range variables.References(script) {
+			if strings.HasPrefix(ref, "YXA_") {
+				problems = append(problems, fmt.Sprintf("built-in variable %s is not set outside yxa", ref))
+			}
+		}
+		exported.Lines = append(exported.Lines, exportLine{Script: resolver.Resolve(script)})
+	}
+
+	addScript(cmd.Pre)
+	addScript(cmd.Run)
+	for i, task := range cmd.Tasks {
+		if task.Condition != "" {
+			problems = append(problems, fmt.Sprintf("the condition of task #%d is not supported, the task always runs", i+1))
+		}
+		if task.Task == "" {
+			addScript(task.Run)
+			continue
+		}
+		if len(task.Params) > 0 {
+			problems = append(problems, fmt.Sprintf("the params of task #%d are not supported and left out", i+1))
+		}
+		exported.Lines = append(exported.Lines, exportLine{Command: task.Task})
+	}
+	addScript(cmd.Post)
+	return exported, problems
+}
+
+// exportTargetName returns the name of a subcommand in formats where ':' cannot
+// be part of a name
+func exportTargetName(name string) string {
+	return strings.ReplaceAll(name, ":", "-")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeMakefile writes the commands as phony targets. The recipe of a target runs
+// in a single shell that stops at the first failing line.
+func writeMakefile(out io.Writer, commands []exportedCommand, vars map[string]string) error {
+	var b strings.Builder
+	b.WriteString("# Generated by yxa export from yxa.yml\n\n")
+	b.WriteString("SHELL := /bin/sh\n.SHELLFLAGS := -ec\n.ONESHELL:\n")
+	if len(vars) > 0 {
+		b.WriteString("\n")
+		for _, name := range sortedKeys(vars) {
+			fmt.Fprintf(&b, "export %s ?= %s\n", name, strings.ReplaceAll(vars[name], "$", "$$"))
+		}
+	}
+
+	targets := make([]string, len(commands))
+	for i, c := range commands {
+		targets[i] = exportTargetName(c.Name)
+	}
+	fmt.Fprintf(&b, "\n.PHONY: %s\n", strings.Join(targets, " "))
+
+	for i, c := range commands {
+		b.WriteString("\n")
+		if c.Description != "" {
+			fmt.Fprintf(&b, "# %s\n", c.Description)
+		}
+		deps := make([]string, len(c.Depends))
+		for j, dep := range c.Depends {
+			deps[j] = exportTargetName(dep)
+		}
+		fmt.Fprintf(&b, "%s:", targets[i])
+		if len(deps) > 0 {
+			b.WriteString(" " + strings.Join(deps, " "))
+		}
+		b.WriteString("\n")
+		for _, line := range c.Lines {
+			script := "$(MAKE) " + exportTargetName(line.Command)
+			if line.Command == "" {
+				script = strings.ReplaceAll(line.Script, "$", "$$")
+			}
+			for _, l := range strings.Split(script, "\n") {
+				b.WriteString("\t" + l + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// writeJustfile writes the commands as recipes. Recipes with scripts over several
+// lines run them as one shell script that stops at the first failing line.
+func writeJustfile(out io.Writer, commands []exportedCommand, vars map[string]string) error {
+	var b strings.Builder
+	b.WriteString("# Generated by yxa export from yxa.yml\n")
+	if len(vars) > 0 {
+		b.WriteString("\n")
+		for _, name := range sortedKeys(vars) {
+			fmt.Fprintf(&b, "export %s := %s\n", name, justString(vars[name]))
+		}
+	}
+
+	for _, c := range commands {
+		b.WriteString("\n")
+		if c.Description != "" {
+			fmt.Fprintf(&b, "# %s\n", c.Description)
+		}
+		deps := make([]string, len(c.Depends))
+		for j, dep := range c.Depends {
+			deps[j] = exportTargetName(dep)
+		}
+		fmt.Fprintf(&b, "%s:", exportTargetName(c.Name))
+		if len(deps) > 0 {
+			b.WriteString(" " + strings.Join(deps, " "))
+		}
+		b.WriteString("\n")
+
+		multiline := false
+		for _, line := range c.Lines {
+			multiline = multiline || strings.Contains(line.Script, "\n")
+		}
+		if multiline {
+			b.WriteString("    #!/bin/sh\n    set -e\n")
+		}
+		for _, line := range c.Lines {
+			script := "just " + exportTargetName(line.Command)
+			if line.Command == "" {
+				// {{ starts an interpolation in just
+				script = strings.ReplaceAll(line.Script, "{{", "{{{{")
+			}
+			for _, l := range strings.Split(script, "\n") {
+				b.WriteString("    " + l + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// justString quotes a value as a just string
+func justString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
+
+// writeNPMScripts writes the commands as the scripts block of a package.json.
+// Dependencies and the lines of a command are chained with &&.
+func writeNPMScripts(out io.Writer, commands []exportedCommand, _ map[string]string) error {
+	// Keep the order of the commands, which json.Marshal of a map would not
+	var b strings.Builder
+	b.WriteString("{\n  \"scripts\": {")
+	for i, c := range commands {
+		var parts []string
+		for _, dep := range c.Depends {
+			parts = append(parts, "npm run "+dep)
+		}
+		for _, line := range c.Lines {
+			if line.Command != "" {
+				parts = append(parts, "npm run "+line.Command)
+				continue
+			}
+			for _, l := range strings.Split(line.Script, "\n") {
+				if strings.TrimSpace(l) != "" {
+					parts = append(parts, strings.TrimSpace(l))
+				}
+			}
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n    %s: %s", jsonString(c.Name), jsonString(strings.Join(parts, " && ")))
+	}
+	if len(commands) > 0 {
+		b.WriteString("\n  ")
+	}
+	b.WriteString("}\n}\n")
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// jsonString quotes a value as a JSON string without escaping &, < and >
+func jsonString(value string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(value)
+	return strings.TrimSuffix(b.String(), "\n")
+}
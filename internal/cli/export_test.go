@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"OUT": "./bin", "APP": "$OUT/app"},
+		Commands: map[string]config.Command{
+			"generate": {Run: "go generate ./..."},
+			"build": {
+				Description: "Build the app",
+				Run:         "go build -o $APP",
+				Depends:     config.NewDependencyList("generate"),
+				Post:        "echo built",
+			},
+			"ci": {
+				Tasks:    config.TaskList{{Task: "build"}, {Run: "go test ./..."}},
+				Parallel: true,
+				Timeout:  "5m",
+			},
+			"db": {
+				Variables: map[string]string{"DSN": "postgres://localhost"},
+				Commands: map[string]config.Command{
+					"migrate": {Run: "migrate -database $DSN up\necho ${YXA_COMMAND} done"},
+				},
+			},
+			"bundle": {Steps: []config.Step{{Copy: &config.CopyStep{From: "a", To: "b"}}}},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) (string, string, error) {
+		t.Helper()
+		out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(errOut)
+		root.RootCmd.SetArgs(append([]string{"export"}, args...))
+		err := root.RootCmd.Execute()
+		return out.String(), errOut.String(), err
+	}
+
+	t.Run("makefile", func(t *testing.T) {
+		out, warnings, err := run(t)
+		require.NoError(t, err)
+		assert.Equal(t, `# Generated by yxa export from yxa.yml
+
+SHELL := /bin/sh
+.SHELLFLAGS := -ec
+.ONESHELL:
+
+export APP ?= ./bin/app
+export OUT ?= ./bin
+
+.PHONY: build ci db-migrate generate
+
+# Build the app
+build: generate
+	go build -o $$APP
+	echo built
+
+ci:
+	$(MAKE) build
+	go test ./...
+
+db-migrate:
+	migrate -database postgres://localhost up
+	echo $${YXA_COMMAND} done
+
+generate:
+	go generate ./...
+`, out)
+		assert.Equal(t, `Warning: command 'bundle': steps cannot be exported, the command is left out
+Warning: command 'ci': timeout is not supported and left out
+Warning: command 'ci': parallel tasks run one after another
+Warning: command 'db:migrate': built-in variable YXA_COMMAND is not set outside yxa
+`, warnings)
+	})
+
+	t.Run("just", func(t *testing.T) {
+		out, _, err := run(t, "--format", "just")
+		require.NoError(t, err)
+		assert.Equal(t, `# Generated by yxa export from yxa.yml
+
+export APP := "./bin/app"
+export OUT := "./bin"
+
+# Build the app
+build: generate
+    go build -o $APP
+    echo built
+
+ci:
+    just build
+    go test ./...
+
+db-migrate:
+    #!/bin/sh
+    set -e
+    migrate -database postgres://localhost up
+    echo ${YXA_COMMAND} done
+
+generate:
+    go generate ./...
+`, out)
+	})
+
+	t.Run("npm", func(t *testing.T) {
+		out, warnings, err := run(t, "--format", "npm")
+		require.NoError(t, err)
+		assert.Equal(t, `{
+  "scripts": {
+    "build": "npm run generate && go build -o ./bin/app && echo built",
+    "ci": "npm run build && go test ./...",
+    "db:migrate": "migrate -database postgres://localhost up && echo ${YXA_COMMAND} done",
+    "generate": "go generate ./..."
+  }
+}
+`, out)
+		assert.Contains(t, warnings, "Warning: command 'db:migrate': the lines of multi-line scripts are chained with &&\n")
+
+		var pkg map[string]map[string]string
+		require.NoError(t, json.Unmarshal([]byte(out), &pkg))
+		assert.Len(t, pkg["scripts"], 4)
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "Makefile")
+		out, _, err := run(t, "-o", path)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "build: generate\n")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, _, err := run(t, "--format", "gradle")
+		assert.EqualError(t, err, "invalid --format 'gradle': expected one of makefile, npm, just")
+	})
+}
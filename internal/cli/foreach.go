@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// ForeachItemVariable is the variable that holds the current path of a foreach command
+const ForeachItemVariable = "ITEM"
+
+// validateForeach checks the foreach pattern of a command
+func (h *CommandHandler) validateForeach(cmdName string, cmd config.Command) error {
+	if cmd.Foreach == "" {
+		return nil
+	}
+	if cmd.Run == "" {
+		return errors.NewCommandConfigError(cmdName, "uses 'foreach', which requires 'run'", nil)
+	}
+	if len(cmd.Matrix) > 0 {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'foreach' and 'matrix'", nil)
+	}
+	if len(cmd.Register) > 0 {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'foreach' and 'register'", nil)
+	}
+	return nil
+}
+
+// foreachJobs expands the run string of a command into one job per path matched
+// by its foreach pattern, with the path bound to $ITEM
+func (h *CommandHandler) foreachJobs(cmdName string, cmd config.Command, cmdVars map[string]string) ([]taskJob, error) {
+	pattern := h.replaceVariablesInString(cmdName, cmd.Foreach, cmdVars)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid foreach pattern '%s' for command '%s': %w", pattern, cmdName, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("foreach pattern '%s' for command '%s' matched no paths", pattern, cmdName)
+	}
+
+	jobs := make([]taskJob, len(paths))
+	for i, path := range paths {
+		vars := make(map[string]string, len(cmdVars)+1)
+		for k, v := range cmdVars {
+			vars[k] = v
+		}
+		vars[ForeachItemVariable] = path
+		jobs[i] = taskJob{
+			ID:      path,
+			Task:    i + 1,
+			Command: h.replaceVariablesInString(cmdName, cmd.Run, vars),
+		}
+	}
+	return jobs, nil
+}
+
+// runForeachCommands executes the run string of a command for every path matched by
+// its foreach pattern, in parallel or one after another
+func (h *CommandHandler) runForeachCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
+	jobs, err := h.foreachJobs(cmdName, cmd, cmdVars)
+	if err != nil {
+		return err
+	}
+	if cmd.Parallel {
+		err = h.runParallelJobs(cmdName, cmd, jobs, timeout)
+	} else {
+		err = h.runSequentialJobs(cmdName, cmd, jobs, timeout)
+	}
+	if err != nil {
+		return errors.NewCommandError(cmdName, "failed to execute foreach", err)
+	}
+	return nil
+}
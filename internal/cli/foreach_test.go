@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Foreach(t *testing.T) {
+	dir := t.TempDir()
+	for _, pkg := range []string{"api", "cli", "web"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "packages", pkg), 0750))
+	}
+	pkgs := filepath.Join(dir, "packages")
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"PKGS": pkgs},
+		Commands: map[string]config.Command{
+			"lint":   {Run: "echo linting $ITEM", Foreach: "$PKGS/*"},
+			"build":  {Run: "echo building $ITEM", Foreach: "$PKGS/*", Parallel: true},
+			"test":   {Run: "test $ITEM != $PKGS/cli", Foreach: "$PKGS/*", ContinueOnError: true},
+			"none":   {Run: "echo $ITEM", Foreach: "$PKGS/*.go"},
+			"no-run": {Tasks: config.NewTaskList("echo $ITEM"), Foreach: "$PKGS/*"},
+		},
+	}
+
+	newHandler := func(out io.Writer) *CommandHandler {
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(io.Discard)
+		return NewCommandHandler(cfg, exec)
+	}
+
+	t.Run("sequential", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, newHandler(out).ExecuteCommand("lint", nil))
+		assert.Equal(t, "linting "+pkgs+"/api\nlinting "+pkgs+"/cli\nlinting "+pkgs+"/web\n", out.String())
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, newHandler(out).ExecuteCommand("build", nil))
+		assert.Contains(t, out.String(), "["+pkgs+"/web] building "+pkgs+"/web")
+	})
+
+	t.Run("continue_on_error reports every failing path", func(t *testing.T) {
+		err := newHandler(io.Discard).ExecuteCommand("test", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'test': failed to execute foreach: one or more sub-commands for 'test' failed: "+pkgs+"/cli: exit status 1")
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		err := newHandler(io.Discard).ExecuteCommand("none", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "foreach pattern '"+pkgs+"/*.go' for command 'none' matched no paths")
+	})
+
+	t.Run("requires run", func(t *testing.T) {
+		err := newHandler(io.Discard).ExecuteCommand("no-run", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config error in command 'no-run': uses 'foreach', which requires 'run'")
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		handler := NewCommandHandler(cfg, &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}})
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("lint", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would execute (foreach "+pkgs+"/api): echo linting "+pkgs+"/api")
+		assert.Empty(t, handler.Executor.(*recordingExecutor).executed)
+	})
+}
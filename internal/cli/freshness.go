@@ -0,0 +1,52 @@
+package cli
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/freshness"
+)
+
+// errUpToDate ends a command that did not run because the files it generates are
+// newer than its sources. runResolvedCommand reports it as skipped, not failed.
+var errUpToDate = stderrors.New("up to date")
+
+// upToDate reports whether the files a command generates are all newer than its
+// sources, in which case it does not need to run. It is always false with --force
+// or for commands without sources and generates.
+func (h *CommandHandler) upToDate(cmdName string, cmd config.Command, cmdVars map[string]string) (bool, error) {
+	if h.Force || len(cmd.Sources) == 0 || len(cmd.Generates) == 0 {
+		return false, nil
+	}
+	resolve := func(patterns []string) []string {
+		resolved := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			resolved[i] = h.replaceVariablesInString(cmdName, pattern, cmdVars)
+		}
+		return resolved
+	}
+
+	base := "."
+	if h.Config != nil && h.Config.ConfigDir() != "" {
+		base = h.Config.ConfigDir()
+	}
+	ok, err := freshness.UpToDate(base, resolve(cmd.Sources), resolve(cmd.Generates))
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether command '%s' is up to date: %w", cmdName, err)
+	}
+	return ok, nil
+}
+
+// checkUpToDate returns errUpToDate if the command does not need to run
+func (h *CommandHandler) checkUpToDate(cmdName string, cmd config.Command, cmdVars map[string]string) error {
+	ok, err := h.upToDate(cmdName, cmd, cmdVars)
+	if err != nil {
+		return err
+	}
+	if ok {
+		h.printf("Skipping command '%s' (up to date)\n", cmdName)
+		return errUpToDate
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_UpToDate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: app
+variables:
+  OUT: dist
+commands:
+  build:
+    run: echo building
+    sources: ["src/**/*.go"]
+    generates: [$OUT/app]
+  release:
+    depends: [build]
+    run: echo releasing
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	old := time.Now().Add(-time.Hour)
+	touch := func(t *testing.T, file string, mtime time.Time) {
+		t.Helper()
+		file = filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, nil, 0o644))
+		require.NoError(t, os.Chtimes(file, mtime, mtime))
+	}
+	run := func(t *testing.T, name string, force bool) (string, string, error) {
+		t.Helper()
+		var out, eventsOut bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		exec.SetStderr(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetForce(force)
+		emitter, err := events.NewEmitter(events.FormatJSON, &eventsOut)
+		require.NoError(t, err)
+		handler.SetEvents(emitter)
+		err = handler.ExecuteCommand(name, nil)
+		return out.String(), eventsOut.String(), err
+	}
+	buildStatus := func(t *testing.T, data string) string {
+		t.Helper()
+		for _, event := range decodeEvents(t, data) {
+			if event.Type == events.CommandEnd && event.Command == "build" {
+				return event.Status
+			}
+		}
+		return ""
+	}
+
+	touch(t, "src/cmd/main.go", old)
+
+	t.Run("missing generates", func(t *testing.T) {
+		out, _, err := run(t, "build", false)
+		require.NoError(t, err)
+		assert.Contains(t, out, "building")
+	})
+
+	touch(t, "dist/app", time.Now())
+
+	t.Run("up to date", func(t *testing.T) {
+		out, eventsOut, err := run(t, "release", false)
+		require.NoError(t, err)
+		assert.NotContains(t, out, "building")
+		assert.Contains(t, out, "releasing", "commands that depend on it still run")
+		assert.Equal(t, events.StatusSkipped, buildStatus(t, eventsOut))
+	})
+
+	t.Run("force", func(t *testing.T) {
+		out, eventsOut, err := run(t, "build", true)
+		require.NoError(t, err)
+		assert.Contains(t, out, "building")
+		assert.Equal(t, events.StatusOK, buildStatus(t, eventsOut))
+	})
+
+	t.Run("changed source", func(t *testing.T) {
+		touch(t, "src/cmd/main.go", time.Now().Add(time.Minute))
+		out, _, err := run(t, "build", false)
+		require.NoError(t, err)
+		assert.Contains(t, out, "building")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		touch(t, "src/cmd/main.go", old)
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would skip 'build' (up to date)")
+		assert.Contains(t, out.String(), "[dry-run] Would execute: echo releasing")
+	})
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// commandHelp returns the long help text of a command: its help, or its
+// description if it sets none, followed by its positional arguments, which
+// unlike flags are not listed by cobra
+func commandHelp(cmd config.Command) string {
+	text := strings.TrimSpace(cmd.Help)
+	if text == "" {
+		text = cmd.Description
+	}
+
+	var args []config.Param
+	for _, param := range cmd.Params {
+		if isPositionalParam(param) {
+			args = append(args, param)
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].Position < args[j].Position })
+
+	var b strings.Builder
+	if text != "" {
+		b.WriteString(text + "\n\n")
+	}
+	b.WriteString("Arguments:\n")
+	for _, param := range args {
+		line := fmt.Sprintf("  %d. %s", param.Position, param.Name)
+		if param.Description != "" {
+			line += " - " + param.Description
+		}
+		if param.Required {
+			line += " (required)"
+		} else if param.Default != "" {
+			line += fmt.Sprintf(" (default %q)", param.Default)
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// commandExamples returns the examples of a command in the format of cobra's
+// Example field, indented, with examples spanning several lines kept together
+func commandExamples(cmd config.Command) string {
+	examples := make([]string, 0, len(cmd.Examples))
+	for _, example := range cmd.Examples {
+		lines := strings.Split(strings.TrimSpace(example), "\n")
+		for i, line := range lines {
+			lines[i] = "  " + line
+		}
+		examples = append(examples, strings.Join(lines, "\n"))
+	}
+	return strings.Join(examples, "\n")
+}
+
+// isPositionalParam reports whether a parameter is a positional argument rather
+// than a flag
+func isPositionalParam(param config.Param) bool {
+	return !param.Flag && param.Position > 0
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHelp(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"deploy": {
+				Run:         "./deploy.sh $env $version",
+				Description: "Deploy the app",
+				Help:        "Deploy the app to an environment.\n\nThe version defaults to the latest tag.\n",
+				Examples:    []string{"yxa deploy staging", "# a specific version\nyxa deploy prod 1.2.0"},
+				Params: []config.Param{
+					{Name: "version", Type: "string", Position: 2, Default: "latest"},
+					{Name: "env", Type: "string", Position: 1, Description: "Environment to deploy to", Required: true},
+					{Name: "dry", Type: "bool", Flag: true, Description: "Only print what would be deployed"},
+				},
+				Commands: map[string]config.Command{
+					"rollback": {Run: "./rollback.sh", Description: "Roll back the last deploy", Examples: []string{"yxa deploy rollback"}},
+				},
+			},
+			"build": {Run: "go build", Description: "Build the app"},
+		},
+	}
+
+	t.Run("help and examples", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"help", "deploy"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "Deploy the app to an environment.\n\nThe version defaults to the latest tag.\n\nArguments:\n"+
+			"  1. env - Environment to deploy to (required)\n"+
+			"  2. version (default \"latest\")\n")
+		assert.Contains(t, out.String(), "Examples:\n  yxa deploy staging\n  # a specific version\n  yxa deploy prod 1.2.0\n")
+		assert.Contains(t, out.String(), "Only print what would be deployed")
+	})
+
+	t.Run("subcommand", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"help", "deploy", "rollback"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "Roll back the last deploy")
+		assert.Contains(t, out.String(), "Examples:\n  yxa deploy rollback\n")
+	})
+
+	t.Run("description as fallback", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"help", "build"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "Build the app")
+		assert.NotContains(t, out.String(), "Examples:")
+	})
+}
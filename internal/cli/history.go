@@ -0,0 +1,324 @@
+package cli
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/history"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultHistoryLimit is how many entries 'yxa history' shows by default
+const defaultHistoryLimit = 20
+
+// selfExecutable returns the path of the yxa binary that 'yxa rerun' starts
+var selfExecutable = os.Executable
+
+// historyStore returns the store of the run history, kept in the state directory
+// next to the config file. It returns nil if the config was not loaded from a file.
+func (r *RootCommand) historyStore() *history.Store {
+	if r.Config == nil || r.Config.ConfigDir() == "" {
+		return nil
+	}
+	return history.NewStore(filepath.Join(r.Config.ConfigDir(), stateDirName))
+}
+
+// recordHistory adds the invocation of a command to the run history. Failing to
+// write the history does not fail the command.
+func (r *RootCommand) recordHistory(cmd *cobra.Command, args []string, cmdName string, err error) {
+	store := r.historyStore()
+	if store == nil {
+		return
+	}
+
+	run := r.Handler.RunContext()
+	entry := history.Entry{
+		Command:    cmdName,
+		Time:       run.StartedAt,
+		Outcome:    history.OutcomeSuccess,
+		DurationMS: time.Since(run.StartedAt).Milliseconds(),
+	}
+	// The history is kept on disk and shown by yxa history, so secrets stay out of it
+	entry.Args, entry.Flags = r.invocationArgs(cmd, args)
+	words := len(strings.Fields(cmd.CommandPath())) - 1
+	entry.Args = append(entry.Args[:words:words], r.maskArgs(cmdName, entry.Args[words:])...)
+	entry.Flags = r.maskArgs("", entry.Flags)
+	switch {
+	case err != nil && run.Cancelled():
+		entry.Outcome = history.OutcomeCancelled
+	case err != nil:
+		entry.Outcome = history.OutcomeFailure
+		entry.Failed = run.failedCommands()
+	case run.skippedCommand(cmdName):
+		entry.Outcome = history.OutcomeSkipped
+	}
+
+	if err := store.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the run history: %v\n", err)
+	}
+}
+
+// invocationArgs rebuilds the arguments of an invocation from the parsed command:
+// the command path, the flags that were set and the positional arguments, with --
+// where it was given. The global flags among them are returned separately.
+func (r *RootCommand) invocationArgs(cmd *cobra.Command, args []string) (all, globals []string) {
+	all = append(all, strings.Fields(cmd.CommandPath())[1:]...)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		// The history is kept in the directory --chdir changed to, where reruns start
+		if f.Name == chdirFlag {
+			return
+		}
+		var values []string
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			values = slice.GetSlice()
+		} else {
+			values = []string{f.Value.String()}
+		}
+		for _, value := range values {
+			flag := "--" + f.Name + "=" + value
+			all = append(all, flag)
+			if r.RootCmd.PersistentFlags().Lookup(f.Name) != nil {
+				globals = append(globals, flag)
+			}
+		}
+	})
+	// Arguments after -- stay there, so that flags among them are not parsed on rerun
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 && dash <= len(args) {
+		all = append(append(all, args[:dash]...), "--")
+		args = args[dash:]
+	}
+	return append(all, args...), globals
+}
+
+// maskedFlags are the global flags whose values are masked in recorded arguments,
+// always given by their long name: the variables they set may be secrets
+var maskedFlags = map[string]bool{"set": true, "set-file": true}
+
+// maskArgs returns a copy of the arguments of a command, given without its name,
+// with the values of its sensitive parameters and of --set and --set-file replaced
+// with variables.MaskedValue, for arguments that are recorded or shown rather than run
+func (r *RootCommand) maskArgs(cmdName string, args []string) []string {
+	sensitive := make(map[string]bool)
+	var positions map[int]config.Param
+	if cmd, err := r.Handler.lookupCommand(cmdName); err == nil {
+		params := mergeParams(r.Handler.inheritedParams(cmdName), cmd.Params)
+		for _, param := range params {
+			if param.Sensitive {
+				name, shorthand := processParamName(param.Name)
+				sensitive[name], sensitive[shorthand] = true, shorthand != ""
+			}
+		}
+		positions = collectPositionalParams(params)
+	}
+
+	masked := make([]string, len(args))
+	copy(masked, args)
+	position, afterDash := 0, false
+	for i := 0; i < len(masked); i++ {
+		arg := masked[i]
+		switch {
+		case arg == "--" && !afterDash:
+			afterDash = true
+		case strings.HasPrefix(arg, "-") && len(arg) > 1 && !afterDash:
+			flag, value, hasValue := strings.Cut(arg, "=")
+			name := strings.TrimLeft(flag, "-")
+			if !sensitive[name] && !maskedFlags[name] {
+				continue
+			}
+			if !hasValue {
+				// The value is the next argument
+				if i++; i == len(masked) {
+					continue
+				}
+				value = masked[i]
+			}
+			if maskedFlags[name] {
+				key, _, _ := strings.Cut(value, "=")
+				value = key + "=" + variables.MaskedValue
+			} else {
+				value = variables.MaskedValue
+			}
+			if hasValue {
+				value = flag + "=" + value
+			}
+			masked[i] = value
+		default:
+			if positions[position].Sensitive {
+				masked[i] = variables.MaskedValue
+			}
+			position++
+		}
+	}
+	return masked
+}
+
+// hasMaskedValue reports whether maskArgs replaced a value among args
+func hasMaskedValue(args []string) bool {
+	for _, arg := range args {
+		if arg == variables.MaskedValue || strings.HasSuffix(arg, "="+variables.MaskedValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// newHistoryCommand creates the built-in 'history' command, which lists recent runs
+func (r *RootCommand) newHistoryCommand() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the recent runs of commands",
+		Long: `Show the most recent runs of commands with their outcome and duration, oldest
+first. The history is kept in .yxa/history.jsonl next to the config file.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.showHistory(cmd.OutOrStdout(), limit)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", defaultHistoryLimit, "Number of runs to show, 0 for all")
+
+	return cmd
+}
+
+// showHistory writes the last limit entries of the run history to out
+func (r *RootCommand) showHistory(out io.Writer, limit int) error {
+	store := r.historyStore()
+	if store == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	entries, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(out, "No runs recorded yet")
+		return err
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "TIME\tOUTCOME\tDURATION\tINVOCATION"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	for _, e := range entries {
+		outcome := e.Outcome
+		if len(e.Failed) > 0 {
+			outcome += " (" + strings.Join(e.Failed, ", ") + ")"
+		}
+		duration := (time.Duration(e.DurationMS) * time.Millisecond).String()
+		invocation := strings.Join(append([]string{"yxa"}, e.Args...), " ")
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"), outcome, duration, invocation); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return w.Flush()
+}
+
+// newRerunCommand creates the built-in 'rerun' command, which repeats the previous
+// invocation of a command
+func (r *RootCommand) newRerunCommand() *cobra.Command {
+	var lastFailed bool
+
+	cmd := &cobra.Command{
+		Use:   "rerun",
+		Short: "Run the previous command again",
+		Long: `Run the previous invocation of a command again, with the same arguments and flags.
+
+With --last-failed, only the commands that failed in the last failed run are run
+again, with the global flags of that run such as --timeout. Runs with sensitive
+values or --set cannot be run again, as the history does not keep their values.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.rerun(cmd, lastFailed); err != nil {
+				var exitErr *exec.ExitError
+				if stderrors.As(err, &exitErr) {
+					exitFunc(exitErr.ExitCode())
+					return
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+				exitFunc(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&lastFailed, "last-failed", false, "Run only the commands that failed in the last failed run")
+
+	return cmd
+}
+
+// rerun runs the invocations of the last run again as new yxa processes, which
+// record their own history entries. It stops at the first invocation that fails.
+func (r *RootCommand) rerun(cmd *cobra.Command, lastFailed bool) error {
+	store := r.historyStore()
+	if store == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	entry, ok, err := store.Last(lastFailed)
+	if err != nil {
+		return err
+	}
+	if !ok && lastFailed {
+		return fmt.Errorf("no failed run in the history")
+	}
+	if !ok {
+		return fmt.Errorf("no run in the history")
+	}
+
+	bin, err := selfExecutable()
+	if err != nil {
+		return fmt.Errorf("failed to find the yxa binary: %w", err)
+	}
+	invocations := rerunInvocations(entry, lastFailed)
+	for _, args := range invocations {
+		if hasMaskedValue(args) {
+			return fmt.Errorf("cannot rerun 'yxa %s': the history does not keep sensitive values and those of --set, run it with them again",
+				strings.Join(args, " "))
+		}
+	}
+	for _, args := range invocations {
+		fmt.Fprintf(cmd.OutOrStdout(), "Rerunning: yxa %s\n", strings.Join(args, " "))
+		rerunCmd := exec.Command(bin, args...) // #nosec G204
+		rerunCmd.Stdin = cmd.InOrStdin()
+		rerunCmd.Stdout = cmd.OutOrStdout()
+		rerunCmd.Stderr = cmd.ErrOrStderr()
+		if err := rerunCmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rerunInvocations returns the arguments to run an entry of the history again
+// with. With lastFailed, every failed command runs on its own: the command of the
+// entry with its arguments, dependencies and tasks with the global flags.
+func rerunInvocations(entry history.Entry, lastFailed bool) [][]string {
+	if !lastFailed || len(entry.Failed) == 0 {
+		return [][]string{entry.Args}
+	}
+	invocations := make([][]string, 0, len(entry.Failed))
+	for _, name := range entry.Failed {
+		if name == entry.Command {
+			invocations = append(invocations, entry.Args)
+			continue
+		}
+		args := append(strings.Split(name, ":"), entry.Flags...)
+		invocations = append(invocations, args)
+	}
+	return invocations
+}
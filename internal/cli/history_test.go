@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+commands:
+  build:
+    run: echo building $target
+    params:
+      - name: target
+        type: string
+        flag: true
+  lint:
+    run: exit 1
+  test:
+    run: exit 1
+  ci:
+    depends: [lint, test]
+    run: echo ci
+  tools:
+    commands:
+      gen:
+        run: echo gen
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	run := func(t *testing.T, args ...string) (string, int) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		require.NoError(t, root.Execute())
+		return out.String(), code
+	}
+
+	run(t, "build", "--target", "linux")
+	run(t, "tools", "gen")
+	_, code := run(t, "ci", "--keep-going", "--timeout", "5m")
+	assert.Equal(t, 1, code)
+
+	entries, err := history.NewStore(filepath.Join(dir, stateDirName)).Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "build", entries[0].Command)
+	assert.Equal(t, []string{"build", "--target=linux"}, entries[0].Args)
+	assert.Equal(t, history.OutcomeSuccess, entries[0].Outcome)
+	assert.Equal(t, "tools:gen", entries[1].Command)
+	assert.Equal(t, []string{"tools", "gen"}, entries[1].Args)
+	assert.Equal(t, history.OutcomeFailure, entries[2].Outcome)
+	assert.Equal(t, []string{"lint", "test"}, entries[2].Failed)
+	assert.Equal(t, []string{"--keep-going=true", "--timeout=5m"}, entries[2].Flags)
+
+	t.Run("history", func(t *testing.T) {
+		out, _ := run(t, "history", "-n", "2")
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], "OUTCOME")
+		assert.Contains(t, lines[1], "success")
+		assert.True(t, strings.HasSuffix(lines[1], "yxa tools gen"), lines[1])
+		assert.Contains(t, lines[2], "failure (lint, test)")
+		assert.True(t, strings.HasSuffix(lines[2], "yxa ci --keep-going=true --timeout=5m"), lines[2])
+	})
+
+	// The rerun invocations are recorded by a fake binary instead of a new yxa
+	recorded := filepath.Join(dir, "reruns.txt")
+	script := filepath.Join(dir, "fake-yxa")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+recorded+"\n"), 0o755))
+	origSelf := selfExecutable
+	defer func() { selfExecutable = origSelf }()
+	selfExecutable = func() (string, error) { return script, nil }
+
+	t.Run("rerun", func(t *testing.T) {
+		out, code := run(t, "rerun")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, out, "Rerunning: yxa ci --keep-going=true --timeout=5m\n")
+	})
+
+	t.Run("rerun --last-failed", func(t *testing.T) {
+		out, code := run(t, "rerun", "--last-failed")
+		assert.Equal(t, 0, code)
+		assert.Contains(t, out, "Rerunning: yxa lint --keep-going=true --timeout=5m\n")
+		assert.Contains(t, out, "Rerunning: yxa test --keep-going=true --timeout=5m\n")
+	})
+
+	data, err := os.ReadFile(recorded)
+	require.NoError(t, err)
+	assert.Equal(t, "ci --keep-going=true --timeout=5m\n"+
+		"lint --keep-going=true --timeout=5m\n"+
+		"test --keep-going=true --timeout=5m\n", string(data))
+}
+
+func TestRerun_EmptyHistory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte("commands:\n  build:\n    run: echo build\n"), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	root := NewRootCommand(nil, executor.NewDefaultExecutor())
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, root.Executor)
+	root.registerCommands()
+	root.RootCmd.SetOut(out)
+	root.RootCmd.SetErr(out)
+	root.RootCmd.SetArgs([]string{"rerun", "--last-failed"})
+
+	origExit := exitFunc
+	defer func() { exitFunc = origExit }()
+	code := 0
+	exitFunc = func(c int) { code = c }
+
+	require.NoError(t, root.Execute())
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out.String(), "Error: no failed run in the history")
+}
+
+func TestHistory_MasksSensitiveValues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+commands:
+  deploy:
+    run: echo deploying $region
+    params:
+      - name: password
+        type: string
+        flag: true
+        sensitive: true
+      - name: token
+        type: string
+        position: 1
+        sensitive: true
+      - name: region
+        type: string
+        position: 0
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	exec := executor.NewDefaultExecutor()
+	exec.SetStdout(out)
+	exec.SetStderr(out)
+	root := NewRootCommand(nil, exec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, exec)
+	root.Handler.setProgress(&bytes.Buffer{})
+	root.registerCommands()
+	root.RootCmd.SetOut(out)
+	root.RootCmd.SetErr(out)
+
+	origExit := exitFunc
+	defer func() { exitFunc = origExit }()
+	code := 0
+	exitFunc = func(c int) { code = c }
+
+	root.RootCmd.SetArgs([]string{"deploy", "eu", "abc123", "--password", "hunter2", "--set", "API_KEY=s3cr3t"})
+	require.NoError(t, root.Execute())
+	assert.Equal(t, 0, code)
+
+	entries, err := history.NewStore(filepath.Join(dir, stateDirName)).Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []string{"deploy", "--password=***", "--set=API_KEY=***", "eu", "***"}, entries[0].Args)
+	assert.Equal(t, []string{"--set=API_KEY=***"}, entries[0].Flags)
+
+	out.Reset()
+	require.NoError(t, root.showHistory(out, 10))
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "abc123")
+	assert.NotContains(t, out.String(), "s3cr3t")
+
+	err = root.rerun(root.RootCmd, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the history does not keep sensitive values")
+}
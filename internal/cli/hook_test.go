@@ -3,6 +3,7 @@ package cli
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/floppa/yxa-cli/internal/config"
 	"github.com/floppa/yxa-cli/internal/executor"
@@ -37,7 +38,7 @@ func TestCommandHandler_ExecuteHook(t *testing.T) {
 	handler := NewCommandHandler(cfg, realExec)
 
 	// Test executing a pre-hook
-	err := handler.executeHook("with-hooks", "pre", "echo 'pre-hook'", nil)
+	err := handler.executeHook("with-hooks", "pre", "echo 'pre-hook'", nil, 0)
 	if err != nil {
 		t.Errorf("executeHook() pre-hook error = %v", err)
 	}
@@ -52,7 +53,7 @@ func TestCommandHandler_ExecuteHook(t *testing.T) {
 	buf.Reset()
 
 	// Test executing a post-hook
-	err = handler.executeHook("with-hooks", "post", "echo 'post-hook'", nil)
+	err = handler.executeHook("with-hooks", "post", "echo 'post-hook'", nil, 0)
 	if err != nil {
 		t.Errorf("executeHook() post-hook error = %v", err)
 	}
@@ -66,14 +67,14 @@ func TestCommandHandler_ExecuteHook(t *testing.T) {
 
 	// Test executing a hook with variables
 	vars := map[string]string{"PARAM": "param-value"}
-	err = handler.executeHook("with-hooks", "pre", "echo 'pre-hook'", vars)
+	err = handler.executeHook("with-hooks", "pre", "echo 'pre-hook'", vars, 0)
 	if err != nil {
 		t.Errorf("executeHook() with vars error = %v", err)
 	}
 	buf.Reset()
 
 	// Test executing a failing hook (simulate error with 'false')
-	err = handler.executeHook("with-hooks", "pre", "false", nil)
+	err = handler.executeHook("with-hooks", "pre", "false", nil, 0)
 	if err == nil {
 		t.Errorf("Expected error for failing hook, got nil")
 	}
@@ -83,3 +84,34 @@ func TestCommandHandler_ExecuteHook(t *testing.T) {
 		t.Errorf("Expected error to contain 'pre-hook', got '%v'", err)
 	}
 }
+
+func TestCommandHandler_HookTimeouts(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"slow-pre":  {Run: "echo main", Pre: "sleep 5", PreTimeout: "100ms"},
+			"slow-post": {Run: "echo main", Post: "sleep 5", PostTimeout: "100ms"},
+			"invalid":   {Run: "echo main", Pre: "echo pre", PreTimeout: "soon"},
+		},
+	}
+	buf := &strings.Builder{}
+	exec := executor.NewDefaultExecutor()
+	exec.SetStdout(buf)
+	exec.SetStderr(buf)
+	handler := NewCommandHandler(cfg, exec)
+
+	for _, name := range []string{"slow-pre", "slow-post"} {
+		start := time.Now()
+		err := handler.ExecuteCommand(name, nil)
+		if err == nil {
+			t.Fatalf("ExecuteCommand(%s) expected the hook to time out", name)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("ExecuteCommand(%s) took %s, the hook was not stopped", name, elapsed)
+		}
+	}
+
+	err := handler.ExecuteCommand("invalid", nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid timeout 'soon'") {
+		t.Errorf("ExecuteCommand(invalid) error = %v, want an invalid timeout", err)
+	}
+}
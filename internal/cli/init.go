@@ -10,17 +10,27 @@ import (
 
 // InitializeApp sets up the basic root command structure and loads configuration.
 var InitializeApp = func() (*RootCommand, error) {
-	// Create a default executor
-	exec := executor.NewDefaultExecutor()
+	// Create the executor of the local runner, commands with another runner
+	// get their executor when they run
+	exec, err := executor.New(executor.LocalRunner, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create the root command with nil config initially
 	root := NewRootCommand(nil, exec)
 
+	// Change the directory first, it decides which config is loaded
+	if err := root.applyChdir(os.Args[1:]); err != nil {
+		return root, err
+	}
+	root.applyNoOverride(os.Args[1:])
+
 	// Load configuration and register commands
 	localPath := "./yxa.yml"
 	if _, statErr := os.Stat(localPath); statErr == nil {
 		// Local config file exists, load it
-		cfg, err := config.LoadConfigFrom(localPath)
+		cfg, err := config.LoadConfigWith(localPath, root.loadOptions())
 		if err != nil {
 			return root, fmt.Errorf("failed to load local configuration: %w", err)
 		}
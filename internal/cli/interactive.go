@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// fileIsTerminal reports whether f is an interactive terminal
+func fileIsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// interactive reports whether yxa may ask questions on in. It may not with
+// --non-interactive, nor when in is a file that is no terminal, as in CI. Input
+// that is no file, such as answers given by a test, can be asked.
+func (r *RootCommand) interactive(in io.Reader) bool {
+	if r.NonInteractive {
+		return false
+	}
+	if f, ok := in.(*os.File); ok {
+		if f == os.Stdin {
+			return stdinIsTerminal()
+		}
+		return fileIsTerminal(f)
+	}
+	return true
+}
+
+// errNonInteractive returns the error of a built-in that would have to ask what
+// to do in non-interactive mode, with how to tell it up front
+func errNonInteractive(what, hint string) error {
+	return fmt.Errorf("cannot %s in non-interactive mode, %s", what, hint)
+}
+
+// SetNonInteractive sets whether commands may interact with the user, such as
+// by opening a --debug-on-failure shell
+func (h *CommandHandler) SetNonInteractive(nonInteractive bool) {
+	h.NonInteractive = nonInteractive
+}
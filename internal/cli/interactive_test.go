@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractive(t *testing.T) {
+	restore := stdinIsTerminal
+	defer func() { stdinIsTerminal = restore }()
+
+	root := NewRootCommand(nil, executor.NewDefaultExecutor())
+	answers := strings.NewReader("y\n")
+
+	stdinIsTerminal = func() bool { return true }
+	assert.True(t, root.interactive(os.Stdin))
+	assert.True(t, root.interactive(answers), "input that is no file can be asked")
+
+	stdinIsTerminal = func() bool { return false }
+	assert.False(t, root.interactive(os.Stdin), "stdin is no terminal, e.g. in CI")
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "answers"))
+	require.NoError(t, err)
+	defer file.Close()
+	assert.False(t, root.interactive(file))
+
+	root.NonInteractive = true
+	stdinIsTerminal = func() bool { return true }
+	assert.False(t, root.interactive(os.Stdin))
+	assert.False(t, root.interactive(answers))
+}
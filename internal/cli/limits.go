@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// commandLimits parses the resource limits of a command
+func commandLimits(cmdName string, cmd config.Command) (executor.Limits, error) {
+	limits := executor.Limits{Nice: cmd.Nice}
+	if cmd.Nice < -20 || cmd.Nice > 19 {
+		return limits, errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid nice %d: must be between -20 and 19", cmd.Nice), nil)
+	}
+	if cmd.CPULimit != "" {
+		cpus, err := strconv.ParseFloat(strings.TrimSpace(cmd.CPULimit), 64)
+		if err != nil || cpus <= 0 {
+			return limits, errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid cpu_limit '%s': expected a number of CPUs such as 0.5 or 2", cmd.CPULimit), nil)
+		}
+		limits.CPUs = cpus
+	}
+	if cmd.MemoryLimit != "" {
+		memory, err := parseSize(cmd.MemoryLimit)
+		if err != nil {
+			return limits, errors.NewCommandConfigError(cmdName, "invalid memory_limit", err)
+		}
+		limits.Memory = memory
+	}
+	if cmd.Umask != "" {
+		mask, err := strconv.ParseUint(strings.TrimSpace(cmd.Umask), 8, 32)
+		if err != nil || mask > 0o777 {
+			return limits, errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid umask '%s': expected an octal mask such as 022 or 0077", cmd.Umask), nil)
+		}
+		limits.Umask = fmt.Sprintf("%04o", mask)
+	}
+	if err := validateUser(cmdName, cmd); err != nil {
+		return limits, err
+	}
+	limits.User = sudoUser(cmd)
+	return limits, nil
+}
+
+// describeLimits returns the resource limits of a command for explain output, or
+// "" if it has none
+func describeLimits(cmd config.Command) string {
+	var limits []string
+	if cmd.Nice != 0 {
+		limits = append(limits, fmt.Sprintf("nice %d", cmd.Nice))
+	}
+	if cmd.CPULimit != "" {
+		limits = append(limits, "cpu_limit "+cmd.CPULimit)
+	}
+	if cmd.MemoryLimit != "" {
+		limits = append(limits, "memory_limit "+cmd.MemoryLimit)
+	}
+	if cmd.Umask != "" {
+		limits = append(limits, "umask "+cmd.Umask)
+	}
+	if cmd.User != "" {
+		limits = append(limits, "user "+cmd.User)
+	} else if cmd.Sudo {
+		limits = append(limits, "sudo")
+	}
+	return strings.Join(limits, ", ")
+}
+
+// validateLimits checks the nice, cpu_limit, memory_limit, umask, sudo and user of
+// a command
+func (h *CommandHandler) validateLimits(cmdName string, cmd config.Command) error {
+	limits, err := commandLimits(cmdName, cmd)
+	if err != nil || limits.IsZero() {
+		return err
+	}
+	if limits.User != "" && runtime.GOOS == "windows" {
+		return errors.NewCommandConfigError(cmdName, "cannot use sudo or user on Windows", nil)
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use resource limits", nil)
+	}
+	if runner := cmd.RunnerConfig(); runner != nil && runner.Name != executor.LocalRunner {
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("cannot combine resource limits with runner '%s', limits only apply on the host", runner.Name), nil)
+	}
+	return nil
+}
+
+// withLimits runs fn with the executor set to run commands within the resource
+// limits of cmd. Limits the platform cannot enforce are reported as warnings and
+// the command runs without them.
+func (h *CommandHandler) withLimits(cmdName string, cmd config.Command, fn func() error) error {
+	limits, err := commandLimits(cmdName, cmd)
+	if err != nil {
+		return err
+	}
+	if limits.IsZero() || h.DryRun {
+		return fn()
+	}
+
+	limitExec, ok := h.Executor.(executor.LimitExecutor)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: resource limits of '%s' are not enforced by this executor\n", cmdName)
+		return fn()
+	}
+	unenforced := executor.Unenforced(limits)
+	names := make([]string, 0, len(unenforced))
+	for name := range unenforced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Warning: %s of '%s' is not enforced: %s\n", name, cmdName, unenforced[name])
+	}
+
+	if limits.User != "" {
+		if err := h.authorizeSudo(cmdName); err != nil {
+			return err
+		}
+	}
+
+	previous := limitExec.GetLimits()
+	limitExec.SetLimits(limits)
+	defer limitExec.SetLimits(previous)
+
+	return fn()
+}
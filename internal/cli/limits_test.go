@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	osexec "os/exec"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitRecordingExecutor records the resource limits every command runs with
+type limitRecordingExecutor struct {
+	testExecutor
+	limits executor.Limits
+	seen   []executor.Limits
+}
+
+func (e *limitRecordingExecutor) Execute(command string, timeout time.Duration) error {
+	e.seen = append(e.seen, e.limits)
+	return nil
+}
+
+func (e *limitRecordingExecutor) GetLimits() executor.Limits       { return e.limits }
+func (e *limitRecordingExecutor) SetLimits(limits executor.Limits) { e.limits = limits }
+
+func TestCommandLimits(t *testing.T) {
+	limits, err := commandLimits("build", config.Command{Nice: 10, CPULimit: "1.5", MemoryLimit: "512MB", Umask: "22"})
+	require.NoError(t, err)
+	assert.Equal(t, executor.Limits{Nice: 10, CPUs: 1.5, Memory: 512 << 20, Umask: "0022"}, limits)
+
+	tests := map[string]struct {
+		cmd  config.Command
+		want string
+	}{
+		"nice": {
+			cmd:  config.Command{Run: "make", Nice: 25},
+			want: "config error in command 'build': invalid nice 25: must be between -20 and 19",
+		},
+		"cpu_limit": {
+			cmd:  config.Command{Run: "make", CPULimit: "half"},
+			want: "config error in command 'build': invalid cpu_limit 'half': expected a number of CPUs such as 0.5 or 2",
+		},
+		"memory_limit": {
+			cmd:  config.Command{Run: "make", MemoryLimit: "lots"},
+			want: "config error in command 'build': invalid memory_limit: invalid size 'lots', expected e.g. 512KB, 10MB or 1GB",
+		},
+		"umask": {
+			cmd:  config.Command{Run: "make", Umask: "0999"},
+			want: "config error in command 'build': invalid umask '0999': expected an octal mask such as 022 or 0077",
+		},
+		"umask out of range": {
+			cmd:  config.Command{Run: "make", Umask: "1777"},
+			want: "config error in command 'build': invalid umask '1777': expected an octal mask such as 022 or 0077",
+		},
+		"service": {
+			cmd:  config.Command{Run: "serve", Service: true, MemoryLimit: "1GB"},
+			want: "config error in command 'build': a service cannot use resource limits",
+		},
+		"runner": {
+			cmd:  config.Command{Run: "make", CPULimit: "2", Runner: &config.Runner{Name: executor.DockerRunner}},
+			want: "config error in command 'build': cannot combine resource limits with runner 'docker', limits only apply on the host",
+		},
+	}
+	h := NewCommandHandler(&config.ProjectConfig{}, &testExecutor{})
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := h.validateLimits("build", tt.cmd)
+			require.Error(t, err)
+			assert.Equal(t, tt.want, err.Error())
+		})
+	}
+}
+
+func TestCommandHandler_Limits(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"prepare": {Run: "prepare"},
+			"build": {
+				Run:         "make",
+				Pre:         "echo pre",
+				Depends:     config.NewDependencyList("prepare"),
+				Nice:        10,
+				MemoryLimit: "1GB",
+			},
+		},
+	}
+	exec := &limitRecordingExecutor{testExecutor: testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}}
+	h := NewCommandHandler(cfg, exec)
+	h.setProgress(&bytes.Buffer{})
+
+	require.NoError(t, h.ExecuteCommand("build", nil))
+	limited := executor.Limits{Nice: 10, Memory: 1 << 30}
+	assert.Equal(t, []executor.Limits{{}, limited, limited}, exec.seen, "the dependency runs without limits, the hook and command with them")
+	assert.Equal(t, executor.Limits{}, exec.limits, "limits are restored")
+}
+
+func TestCommandLimits_Sudo(t *testing.T) {
+	origUser := currentUsername
+	defer func() { currentUsername = origUser }()
+	currentUsername = func() string { return "dev" }
+
+	limits, err := commandLimits("install", config.Command{Sudo: true})
+	require.NoError(t, err)
+	assert.Equal(t, executor.Limits{User: "root"}, limits)
+
+	limits, err = commandLimits("install", config.Command{User: "deploy"})
+	require.NoError(t, err)
+	assert.Equal(t, executor.Limits{User: "deploy"}, limits)
+
+	// Commands of the user yxa runs as need no sudo
+	limits, err = commandLimits("install", config.Command{Sudo: true, User: "dev"})
+	require.NoError(t, err)
+	assert.True(t, limits.IsZero())
+
+	_, err = commandLimits("install", config.Command{User: "-s"})
+	assert.EqualError(t, err, "invalid user '-s' for command 'install': expected a user name")
+
+	assert.Equal(t, "sudo", describeLimits(config.Command{Sudo: true}))
+	assert.Equal(t, "nice 5, user deploy", describeLimits(config.Command{Nice: 5, User: "deploy"}))
+}
+
+func TestCommandHandler_Sudo(t *testing.T) {
+	origUser, origRefresh, origPrompt, origTerminal := currentUsername, refreshSudo, promptSudo, stdinIsTerminal
+	defer func() {
+		currentUsername, refreshSudo, promptSudo, stdinIsTerminal = origUser, origRefresh, origPrompt, origTerminal
+	}()
+	currentUsername = func() string { return "dev" }
+	cached := false
+	prompts := 0
+	refreshSudo = func() error {
+		if !cached {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	promptSudo = func() error {
+		prompts++
+		cached = true
+		return nil
+	}
+	stdinIsTerminal = func() bool { return true }
+
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"install": {Run: "cp yxa /usr/local/bin", Sudo: true},
+			"restart": {Run: "systemctl restart app", User: "app", Depends: config.NewDependencyList("install")},
+		},
+	}
+	exec := &limitRecordingExecutor{testExecutor: testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}}
+	h := NewCommandHandler(cfg, exec)
+	h.setProgress(&bytes.Buffer{})
+
+	require.NoError(t, h.ExecuteCommand("restart", nil))
+	assert.Equal(t, []executor.Limits{{User: "root"}, {User: "app"}}, exec.seen)
+	assert.Equal(t, 1, prompts, "the password is asked once")
+
+	// Without cached credentials, non-interactive runs fail before the command runs
+	cached = false
+	exec.seen = nil
+	h.SetNonInteractive(true)
+	err := h.ExecuteCommand("install", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sudo needs a password, which cannot be asked in non-interactive mode")
+	assert.Empty(t, exec.seen)
+
+	refreshSudo = func() error { return &osexec.Error{Name: "sudo", Err: osexec.ErrNotFound} }
+	err = h.ExecuteCommand("install", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sudo is not on PATH")
+}
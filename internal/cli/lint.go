@@ -0,0 +1,588 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules of lint findings, the kinds of problems lint reports
+const (
+	lintRuleInvalidCommand    = "invalid-command"
+	lintRuleUnknownField      = "unknown-field"
+	lintRuleUnknownCommand    = "unknown-command"
+	lintRuleUndefinedVariable = "undefined-variable"
+	lintRuleUnusedParameter   = "unused-parameter"
+)
+
+// Formats of 'yxa lint'
+const (
+	LintFormatText  = "text"
+	LintFormatSARIF = "sarif"
+)
+
+// lintFinding is a problem found in the configuration
+type lintFinding struct {
+	Scope   string // Command the problem was found in, "variables" or "config"
+	Message string
+	Rule    string // Kind of problem, one of the lintRule constants
+	Field   string // Key within the command or the variables the problem is about, if any
+	Line    int    // Position in the config file, 0 if not known
+	Column  int
+}
+
+// namedCommand is a command of the configuration with its full name (parent:sub for subcommands)
+type namedCommand struct {
+	Name    string
+	Command config.Command
+}
+
+// newLintCommand creates the built-in 'lint' command, which checks the configuration
+// for problems that would otherwise only show up when a command runs
+func (r *RootCommand) newLintCommand() *cobra.Command {
+	var refs bool
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check the configuration for problems",
+		Long: `Check every command of the configuration for problems that would otherwise only
+show up at runtime: invalid timeouts, depends_mode values, register entries and
+steps, commands that have nothing to run and keys of yxa.yml that are not part of
+the config format, which yxa ignores.
+
+With --refs, references across the configuration are checked as well: depends
+entries and 'yxa <command>' tasks that point at unknown commands, variables that
+are not defined anywhere in the resolution chain and parameters that are never used.
+
+With --format sarif the problems are written as a SARIF log with the lines of
+yxa.yml they are found at, for editors and code scanning, e.g. GitHub's
+upload-sarif action.
+
+Exits with an error if any problem is found.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != LintFormatText && format != LintFormatSARIF {
+				return fmt.Errorf("invalid --format '%s': expected %s or %s", format, LintFormatText, LintFormatSARIF)
+			}
+			return writeExport(cmd, output, func(out io.Writer) error {
+				return r.lintConfig(out, refs, format)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&refs, "refs", false, "Also report unknown commands, undefined variables and unused parameters")
+	cmd.Flags().StringVar(&format, "format", LintFormatText, "Format of the problems: text or sarif")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the problems to this file instead of stdout")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{LintFormatText, LintFormatSARIF}, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// lintConfig checks the configuration and writes the problems found to out in
+// the given format
+func (r *RootCommand) lintConfig(out io.Writer, refs bool, format string) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	commands := flattenCommands(r.Config)
+	findings := r.Handler.lintCommands(commands)
+	if timeout := r.Config.DefaultTimeout; timeout != "" {
+		if _, err := time.ParseDuration(timeout); err != nil {
+			findings = append(findings, lintFinding{Scope: "config", Message: fmt.Sprintf("invalid default_timeout '%s': %v", timeout, err), Rule: lintRuleInvalidCommand, Field: "default_timeout"})
+		}
+	}
+	if refs {
+		findings = append(findings, r.lintReferences(commands)...)
+	}
+	// Positions are only known for a config loaded from a file
+	if doc := readConfigDocument(r.Config.ConfigFile()); doc != nil {
+		findings = append(findings, lintUnknownFields(doc)...)
+		locateFindings(doc, findings)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Scope < findings[j].Scope
+	})
+	if format == LintFormatSARIF {
+		if err := writeSARIF(out, r.Config.ConfigFile(), findings); err != nil {
+			return err
+		}
+		if len(findings) > 0 {
+			return fmt.Errorf("found %d problem(s) in the configuration", len(findings))
+		}
+		return nil
+	}
+
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(out, "No problems found")
+		return err
+	}
+
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(out, "%s: %s\n", f.Scope, f.Message); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return fmt.Errorf("found %d problem(s) in the configuration", len(findings))
+}
+
+// flattenCommands returns every command and subcommand of the configuration sorted by name
+func flattenCommands(cfg *config.ProjectConfig) []namedCommand {
+	var commands []namedCommand
+	var add func(prefix string, cmds map[string]config.Command)
+	add = func(prefix string, cmds map[string]config.Command) {
+		for name, cmd := range cmds {
+			commands = append(commands, namedCommand{Name: prefix + name, Command: cmd})
+			add(prefix+name+":", cmd.Commands)
+		}
+	}
+	add("", cfg.Commands)
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// lintCommands runs the checks that are otherwise only done when a command executes
+func (h *CommandHandler) lintCommands(commands []namedCommand) []lintFinding {
+	var findings []lintFinding
+	add := func(scope, field string, err error) {
+		if err != nil {
+			findings = append(findings, lintFinding{Scope: scope, Message: errorMessage(scope, err), Rule: lintRuleInvalidCommand, Field: field})
+		}
+	}
+
+	for _, c := range commands {
+		// Command groups only list their subcommands
+		if len(c.Command.Commands) > 0 {
+			continue
+		}
+
+		_, err := h.parseTimeout(c.Name, c.Command.Timeout)
+		add(c.Name, "timeout", err)
+		_, err = h.parseTimeout(c.Name, c.Command.PreTimeout)
+		add(c.Name, "pre_timeout", err)
+		_, err = h.parseTimeout(c.Name, c.Command.PostTimeout)
+		add(c.Name, "post_timeout", err)
+		_, err = h.dependsMode(c.Name, c.Command)
+		add(c.Name, "depends_mode", err)
+		add(c.Name, "", h.validateCommandExecutability(c.Name, c.Command))
+		add(c.Name, "params", validateParamReferences(mergeParams(h.inheritedParams(c.Name), c.Command.Params)))
+		if len(c.Command.Steps) > 0 {
+			_, err = h.newScriptSteps(c.Name, c.Command, h.paramDefaults(c.Name, c.Command))
+			add(c.Name, "steps", err)
+		}
+	}
+	return findings
+}
+
+// lintReferences reports depends entries and tasks pointing at unknown commands,
+// references to variables that are never defined and parameters that are never used
+func (r *RootCommand) lintReferences(commands []namedCommand) []lintFinding {
+	var findings []lintFinding
+	h := r.Handler
+
+	// Variables registered from command output are defined for the rest of the run
+	registered := make(map[string]bool)
+	for _, c := range commands {
+		for _, reg := range c.Command.Register {
+			registered[reg.Var] = true
+		}
+	}
+
+	dependents := reverseDependencies(commands)
+	for _, c := range commands {
+		for _, dep := range c.Command.Depends.Commands() {
+			if _, err := h.lookupCommand(dep); err != nil {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("depends on unknown command '%s'", dep), Rule: lintRuleUnknownCommand, Field: "depends"})
+			}
+		}
+		for _, after := range c.Command.After {
+			if _, err := h.lookupCommand(after); err != nil {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("runs after unknown command '%s'", after), Rule: lintRuleUnknownCommand, Field: "after"})
+			}
+		}
+		for _, name := range c.Command.NeededVars() {
+			if _, err := h.lookupCommand(c.Command.Needs[name]); err != nil {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("needs unknown command '%s' for '%s'", c.Command.Needs[name], name), Rule: lintRuleUnknownCommand, Field: "needs"})
+			}
+		}
+		for i, task := range c.Command.Tasks {
+			if name, ok := yxaInvocation(task.Run); ok && !r.isKnownCommand(name) {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("task #%d runs unknown command 'yxa %s'", i+1, name), Rule: lintRuleUnknownCommand, Field: "tasks"})
+			}
+		}
+
+		// Parameters of the command and of every command depending on it are passed down
+		params := h.paramDefaults(c.Name, c.Command)
+		for _, axis := range c.Command.Matrix {
+			params[axis.Name] = ""
+		}
+		if c.Command.Foreach != "" {
+			params[ForeachItemVariable] = ""
+		}
+		for name := range c.Command.Needs {
+			params[name] = ""
+		}
+		for _, dependent := range transitiveClosure(c.Name, dependents) {
+			if cmd, err := h.lookupCommand(dependent); err == nil {
+				for name, value := range h.paramDefaults(dependent, cmd) {
+					params[name] = value
+				}
+				for _, task := range cmd.Tasks {
+					for name, value := range task.Params {
+						params[name] = value
+					}
+				}
+			}
+		}
+
+		resolver := h.resolver(c.Name, params)
+		assigned := shellAssignments(c.Command)
+		for _, name := range commandReferences(c.Command) {
+			if _, ok := resolver.GetVariableValue(name); !ok && !registered[name] && !assigned[name] {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("references undefined variable '%s'", name), Rule: lintRuleUndefinedVariable})
+			}
+		}
+
+		for _, param := range c.Command.Params {
+			if !r.paramUsed(param.Name, c, commands) {
+				findings = append(findings, lintFinding{Scope: c.Name, Message: fmt.Sprintf("parameter '%s' is never used", param.Name), Rule: lintRuleUnusedParameter, Field: "params"})
+			}
+		}
+	}
+
+	// Config variables may reference other variables as well
+	resolver := h.resolver("", nil)
+	names := make([]string, 0, len(r.Config.Variables))
+	for name := range r.Config.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ref := range variables.References(r.Config.Variables[name]) {
+			if _, ok := resolver.GetVariableValue(ref); !ok && !registered[ref] {
+				findings = append(findings, lintFinding{Scope: "variables", Message: fmt.Sprintf("'%s' references undefined variable '%s'", name, ref), Rule: lintRuleUndefinedVariable, Field: name})
+			}
+		}
+	}
+
+	return findings
+}
+
+// paramUsed reports whether a parameter of a command is referenced by the command
+// itself, by one of its dependencies or, for flag parameters, by its subcommands
+func (r *RootCommand) paramUsed(name string, c namedCommand, commands []namedCommand) bool {
+	using := []string{c.Name}
+	using = append(using, transitiveClosure(c.Name, dependencyGraph(commands))...)
+	for _, sub := range commands {
+		if strings.HasPrefix(sub.Name, c.Name+":") {
+			using = append(using, sub.Name)
+		}
+	}
+
+	for _, cmdName := range using {
+		cmd, err := r.Handler.lookupCommand(cmdName)
+		if err != nil {
+			continue
+		}
+		for _, ref := range commandReferences(cmd) {
+			if ref == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isKnownCommand reports whether name is a command of the configuration or a built-in command
+func (r *RootCommand) isKnownCommand(name string) bool {
+	if _, ok := r.Config.Commands[name]; ok {
+		return true
+	}
+	for _, builtin := range r.builtinCmds {
+		if builtin.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// yxaInvocation returns the command a task runs if the task invokes yxa itself,
+// e.g. "yxa build --release"
+func yxaInvocation(task string) (string, bool) {
+	fields := strings.Fields(task)
+	if len(fields) < 2 || fields[0] != "yxa" {
+		return "", false
+	}
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, "-") {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// dependencyGraph returns the direct dependencies and needed commands of every command
+func dependencyGraph(commands []namedCommand) map[string][]string {
+	graph := make(map[string][]string)
+	for _, c := range commands {
+		graph[c.Name] = prerequisites(c.Command)
+	}
+	return graph
+}
+
+// reverseDependencies returns the commands that directly depend on, need, or
+// reference in their tasks, every command
+func reverseDependencies(commands []namedCommand) map[string][]string {
+	graph := make(map[string][]string)
+	for _, c := range commands {
+		for _, dep := range prerequisites(c.Command) {
+			graph[dep] = append(graph[dep], c.Name)
+		}
+		for _, task := range c.Command.Tasks {
+			if task.Task != "" {
+				graph[task.Task] = append(graph[task.Task], c.Name)
+			}
+		}
+	}
+	return graph
+}
+
+// transitiveClosure returns every command reachable from name in the graph, not
+// including name itself
+func transitiveClosure(name string, graph map[string][]string) []string {
+	var result []string
+	seen := map[string]bool{name: true}
+	queue := append([]string{}, graph[name]...)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		result = append(result, next)
+		queue = append(queue, graph[next]...)
+	}
+	return result
+}
+
+// Patterns for variables that the shell scripts of a command define themselves
+var (
+	shellAssignmentPattern = regexp.MustCompile(`(?:^|[\s;&|(])(?:export\s+|local\s+)?([A-Za-z_]\w*)=`)
+	shellForPattern        = regexp.MustCompile(`\bfor\s+([A-Za-z_]\w*)\s+in\b`)
+	shellReadPattern       = regexp.MustCompile(`\bread\b([^;&|\n]*)`)
+	shellQuotedPattern     = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	identifierPattern      = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+// shellAssignments returns the variables that the shell scripts of a command assign
+// themselves, with an assignment, a for loop or read. References to them are not
+// resolved by yxa but by the shell.
+func shellAssignments(cmd config.Command) map[string]bool {
+	assigned := make(map[string]bool)
+	for _, input := range shellInputs(cmd) {
+		for _, match := range shellAssignmentPattern.FindAllStringSubmatch(input, -1) {
+			assigned[match[1]] = true
+		}
+		for _, match := range shellForPattern.FindAllStringSubmatch(input, -1) {
+			assigned[match[1]] = true
+		}
+		for _, match := range shellReadPattern.FindAllStringSubmatch(input, -1) {
+			for _, field := range strings.Fields(shellQuotedPattern.ReplaceAllString(match[1], " ")) {
+				if identifierPattern.MatchString(field) {
+					assigned[field] = true
+				}
+			}
+		}
+	}
+	return assigned
+}
+
+// shellInputs returns the strings of a command that are run by a shell
+func shellInputs(cmd config.Command) []string {
+	inputs := []string{cmd.Run, cmd.Pre, cmd.Post, cmd.OnCancel}
+	return append(inputs, cmd.Tasks.Runs()...)
+}
+
+// commandReferences returns the variables referenced anywhere in a command
+func commandReferences(cmd config.Command) []string {
+	inputs := append(shellInputs(cmd), cmd.Condition, cmd.WorkingDir, cmd.Foreach)
+	for _, step := range cmd.Steps {
+		inputs = append(inputs, stepInputs(step)...)
+	}
+	for _, task := range cmd.Tasks {
+		inputs = append(inputs, task.Condition)
+		for _, value := range task.Params {
+			inputs = append(inputs, value)
+		}
+	}
+	if runner := cmd.RunnerConfig(); runner != nil {
+		runner.Expand(func(value string) string {
+			inputs = append(inputs, value)
+			return value
+		})
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		for _, name := range variables.References(input) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// stepInputs returns the strings of a step in which variables are resolved
+func stepInputs(step config.Step) []string {
+	var inputs []string
+	if s := step.HTTP; s != nil {
+		inputs = append(inputs, s.URL, s.Method, s.Body, s.Output)
+		for _, value := range s.Headers {
+			inputs = append(inputs, value)
+		}
+	}
+	if s := step.Copy; s != nil {
+		inputs = append(inputs, s.From, s.To)
+	}
+	if s := step.Move; s != nil {
+		inputs = append(inputs, s.From, s.To)
+	}
+	if s := step.Mkdir; s != nil {
+		inputs = append(inputs, s.Path)
+	}
+	if s := step.Rm; s != nil {
+		inputs = append(inputs, s.Path)
+	}
+	if s := step.Download; s != nil {
+		inputs = append(inputs, s.URL, s.Dest, s.SHA256)
+	}
+	if s := step.WaitFor; s != nil {
+		inputs = append(inputs, s.URL, s.TCP, s.File, s.Timeout, s.Interval)
+	}
+	if s := step.Template; s != nil {
+		inputs = append(inputs, s.Src, s.Dest)
+	}
+	if s := step.Archive; s != nil {
+		inputs = append(inputs, s.Src, s.Dest, s.Format)
+	}
+	if s := step.Assert; s != nil {
+		inputs = append(inputs, s.Condition, s.Message)
+	}
+	return inputs
+}
+
+// readConfigDocument parses the config file at path into a YAML node, for the
+// positions of findings; nil if there is no file or it cannot be read
+func readConfigDocument(path string) *yaml.Node {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- the config file that was loaded
+	if err != nil {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// lintUnknownFields reports the keys of the config file that are not part of the
+// config format, in the scope of the command they are found in
+func lintUnknownFields(doc *yaml.Node) []lintFinding {
+	var findings []lintFinding
+	for _, f := range config.UnknownFields(doc) {
+		var names []string
+		path := f.Path
+		for len(path) >= 2 && path[0] == "commands" {
+			names = append(names, path[1])
+			path = path[2:]
+		}
+		scope := "config"
+		if len(names) > 0 {
+			scope = strings.Join(names, ":")
+		}
+		message := fmt.Sprintf("unknown field '%s'", f.Key)
+		if len(path) > 0 {
+			message += fmt.Sprintf(" in '%s'", strings.Join(path, "."))
+		}
+		findings = append(findings, lintFinding{Scope: scope, Message: message, Rule: lintRuleUnknownField, Line: f.Line, Column: f.Column})
+	}
+	return findings
+}
+
+// locateFindings sets the position of the findings that have none: the key of
+// their field, or of their command or variable if the field is not in the file
+func locateFindings(doc *yaml.Node, findings []lintFinding) {
+	for i := range findings {
+		f := &findings[i]
+		if f.Line > 0 {
+			continue
+		}
+		var path []string
+		switch f.Scope {
+		case "config":
+		case "variables":
+			path = []string{"variables"}
+		default:
+			for _, name := range strings.Split(f.Scope, ":") {
+				path = append(path, "commands", name)
+			}
+		}
+		if f.Field != "" {
+			path = append(path, f.Field)
+		}
+
+		node := doc
+		for _, key := range path {
+			next := mappingKey(node, key)
+			if next == nil {
+				break
+			}
+			f.Line, f.Column = next.Line, next.Column
+			node = mappingValue(node, key)
+		}
+	}
+}
+
+// mappingKey returns the key node of a key of a mapping node, nil if it has none
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of a key of a mapping node, nil if it has none
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommand(t *testing.T) {
+	newRoot := func(cfg *config.ProjectConfig) (*RootCommand, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}}
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		return root, out
+	}
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"OUT": "./bin", "DIST": "$OUT/$MISSING_ROOT"},
+		Commands: map[string]config.Command{
+			"generate": {Run: "go generate -tags $tags ./..."},
+			"build": {
+				Run:     "go build -o $OUT/$name $LDFLAGS",
+				Depends: config.NewDependencyList("generate"),
+				Params: []config.Param{
+					{Name: "name", Type: "string", Default: "app", Flag: true},
+					{Name: "tags", Type: "string", Flag: true},
+					{Name: "verbose", Type: "bool", Flag: true},
+				},
+			},
+			"release": {
+				Run:     "for f in $OUT/*; do sha=$(sha256sum $f); echo $sha; done; read -p 'ok? ' answer; echo $answer",
+				Depends: config.NewDependencyList("build", "publish"),
+			},
+			"checks": {
+				Tasks: config.NewTaskList("yxa build --verbose", "yxa vet", "yxa env", "go test ./..."),
+			},
+			"deploy": {
+				Params: []config.Param{{Name: "env", Type: "string", Default: "dev", Flag: true}},
+				Commands: map[string]config.Command{
+					"app": {Run: "kubectl apply -n $env", Depends: config.NewDependencyList("deploy:db", "deploy:cache")},
+					"db":  {Run: "migrate"},
+				},
+			},
+		},
+	}
+
+	t.Run("without --refs", func(t *testing.T) {
+		root, out := newRoot(cfg)
+		root.RootCmd.SetArgs([]string{"lint"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "No problems found")
+	})
+
+	t.Run("with --refs", func(t *testing.T) {
+		root, out := newRoot(cfg)
+		root.RootCmd.SetArgs([]string{"lint", "--refs"})
+		err := root.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 6 problem(s)")
+
+		output := out.String()
+		assert.Contains(t, output, "build: references undefined variable 'LDFLAGS'")
+		assert.Contains(t, output, "build: parameter 'verbose' is never used")
+		assert.Contains(t, output, "checks: task #2 runs unknown command 'yxa vet'")
+		assert.Contains(t, output, "deploy:app: depends on unknown command 'deploy:cache'")
+		assert.Contains(t, output, "release: depends on unknown command 'publish'")
+		assert.Contains(t, output, "variables: 'DIST' references undefined variable 'MISSING_ROOT'")
+
+		// Parameters passed down to dependencies and shell variables are fine
+		assert.NotContains(t, output, "'tags'")
+		assert.NotContains(t, output, "'env'")
+		assert.NotContains(t, output, "'sha'")
+		assert.NotContains(t, output, "'answer'")
+	})
+
+	t.Run("invalid commands", func(t *testing.T) {
+		root, out := newRoot(&config.ProjectConfig{
+			Commands: map[string]config.Command{
+				"slow":  {Run: "sleep 1", Timeout: "soon"},
+				"empty": {Description: "nothing here"},
+				"deploy": {Run: "./deploy.sh", Params: []config.Param{
+					{Name: "prod", Type: "bool", Flag: true, ConflictsWith: []string{"staging"}},
+				}},
+			},
+		})
+		root.RootCmd.SetArgs([]string{"lint"})
+		require.Error(t, root.Execute())
+		assert.Contains(t, out.String(), "slow: invalid timeout 'soon'")
+		assert.Contains(t, out.String(), "empty: no 'run', 'tasks', 'steps', or 'commands' defined")
+		assert.Contains(t, out.String(), "deploy: parameter 'prod' refers to unknown parameter 'staging' in conflicts_with or requires")
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "yxa.yml")
+		require.NoError(t, os.WriteFile(path, []byte(`name: shop
+commands:
+  build:
+    run: go build $FLAGS
+    timout: 5m
+  test:
+    run: go test
+    timeout: soon
+`), 0o644))
+		cfg, err := config.LoadConfigFrom(path)
+		require.NoError(t, err)
+		t.Chdir(dir)
+
+		root, out := newRoot(cfg)
+		sarif := filepath.Join(dir, "lint.sarif")
+		root.RootCmd.SetArgs([]string{"lint", "--refs", "--format", "sarif", "--output", sarif})
+		assert.ErrorContains(t, root.Execute(), "found 3 problem(s)")
+		assert.NotContains(t, out.String(), "build:", "the log is written to the file")
+
+		data, err := os.ReadFile(sarif)
+		require.NoError(t, err)
+		var log sarifLog
+		require.NoError(t, json.Unmarshal(data, &log))
+		require.Len(t, log.Runs, 1)
+		var results []string
+		for _, r := range log.Runs[0].Results {
+			loc := r.Locations[0].PhysicalLocation
+			results = append(results, fmt.Sprintf("%s:%d:%d %s %s", loc.ArtifactLocation.URI, loc.Region.StartLine, loc.Region.StartColumn, r.RuleID, r.Message.Text))
+		}
+		assert.Equal(t, []string{
+			"yxa.yml:3:3 undefined-variable build: references undefined variable 'FLAGS'",
+			"yxa.yml:5:5 unknown-field build: unknown field 'timout'",
+			"yxa.yml:8:5 invalid-command test: invalid timeout 'soon': time: invalid duration \"soon\"",
+		}, results)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		root, _ := newRoot(cfg)
+		root.RootCmd.SetArgs([]string{"lint", "--format", "xml"})
+		assert.ErrorContains(t, root.Execute(), "invalid --format 'xml'")
+	})
+}
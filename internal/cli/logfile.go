@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// sizeUnits are the suffixes accepted by max_size, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size such as 512KB, 10MB or 1GB. A plain number is in bytes.
+func parseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected e.g. 512KB, 10MB or 1GB", s)
+	}
+	return n * multiplier, nil
+}
+
+// validateLogFiles checks the log_file and stderr_file of a command
+func (h *CommandHandler) validateLogFiles(cmdName string, cmd config.Command) error {
+	if cmd.LogFile == nil && cmd.StderrFile == nil {
+		return nil
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use 'log_file' or 'stderr_file', its output is kept by yxa up", nil)
+	}
+	for _, f := range []struct {
+		field string
+		lf    *config.LogFile
+	}{{"log_file", cmd.LogFile}, {"stderr_file", cmd.StderrFile}} {
+		field, lf := f.field, f.lf
+		if lf == nil {
+			continue
+		}
+		switch lf.Mode {
+		case "", config.LogModeTruncate, config.LogModeAppend:
+		default:
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid %s mode '%s': expected '%s' or '%s'",
+				field, lf.Mode, config.LogModeTruncate, config.LogModeAppend), nil)
+		}
+		if lf.MaxSize != "" {
+			if _, err := parseSize(lf.MaxSize); err != nil {
+				return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid %s max_size", field), err)
+			}
+		}
+		if lf.Keep < 0 {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid %s keep: must not be negative", field), nil)
+		}
+	}
+	return nil
+}
+
+// logFilePath returns the path of a log file with variables resolved. Relative paths
+// are relative to the directory of the config file.
+func (h *CommandHandler) logFilePath(cmdName string, lf *config.LogFile, cmdVars map[string]string) string {
+	path := h.replaceVariablesInString(cmdName, lf.Path, cmdVars)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	base := "."
+	if h.Config != nil && h.Config.ConfigDir() != "" {
+		base = h.Config.ConfigDir()
+	}
+	return filepath.Join(base, path)
+}
+
+// openLogFile opens a log file for writing, rotating it first when it has grown
+// beyond its max_size
+func openLogFile(path string, lf *config.LogFile) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	if lf.MaxSize != "" {
+		maxSize, err := parseSize(lf.MaxSize)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && info.Size() >= maxSize {
+			keep := lf.Keep
+			if keep == 0 {
+				keep = config.DefaultLogKeep
+			}
+			if err := rotateLogFile(path, keep); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if lf.Mode == config.LogModeAppend {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	return os.OpenFile(path, flags, 0o644) // #nosec G304 -- path comes from the config
+}
+
+// rotateLogFile moves path to path.1, path.1 to path.2 and so on, dropping the
+// files beyond keep
+func rotateLogFile(path string, keep int) error {
+	if err := os.Remove(fmt.Sprintf("%s.%d", path, keep)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := keep - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
+
+// withLogFiles runs fn with the output of the executor also written to the
+// log_file and stderr_file of the command
+func (h *CommandHandler) withLogFiles(cmdName string, cmd config.Command, cmdVars map[string]string, fn func() error) error {
+	if (cmd.LogFile == nil && cmd.StderrFile == nil) || h.DryRun {
+		return fn()
+	}
+
+	stdout, stderr := h.Executor.GetStdout(), h.Executor.GetStderr()
+	var files []*os.File
+	defer func() {
+		h.Executor.SetStdout(stdout)
+		h.Executor.SetStderr(stderr)
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	open := func(lf *config.LogFile) (io.Writer, error) {
+		path := h.logFilePath(cmdName, lf, cmdVars)
+		f, err := openLogFile(path, lf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file '%s' of command '%s': %w", path, cmdName, err)
+		}
+		files = append(files, f)
+		return f, nil
+	}
+
+	if cmd.LogFile != nil {
+		f, err := open(cmd.LogFile)
+		if err != nil {
+			return err
+		}
+		h.Executor.SetStdout(io.MultiWriter(stdout, f))
+		if cmd.StderrFile == nil {
+			h.Executor.SetStderr(io.MultiWriter(stderr, f))
+		}
+	}
+	if cmd.StderrFile != nil {
+		f, err := open(cmd.StderrFile)
+		if err != nil {
+			return err
+		}
+		h.Executor.SetStderr(io.MultiWriter(stderr, f))
+	}
+
+	return fn()
+}
+
+// showLogs prints the output of a service, or the log file of any other command
+func (r *RootCommand) showLogs(ctx context.Context, out io.Writer, name string, follow, stderr bool) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	cmd, err := r.Handler.lookupCommand(name)
+	if err != nil {
+		return err
+	}
+	if !stderr {
+		// Commands started with yxa start write their output to a log of their own
+		if found, err := r.backgroundLogs(ctx, out, name, follow); found || err != nil {
+			return err
+		}
+	}
+	if cmd.Service {
+		if stderr {
+			return fmt.Errorf("service '%s' has no separate stderr log", name)
+		}
+		return r.serviceLogs(ctx, out, name, follow)
+	}
+
+	field, lf := "log_file", cmd.LogFile
+	if stderr {
+		field, lf = "stderr_file", cmd.StderrFile
+	}
+	if lf == nil {
+		return fmt.Errorf("command '%s' has no %s, set '%s' to keep its output", name, field, field)
+	}
+
+	cmdVars := r.Handler.withParamDefaults(name, cmd, r.createCommandVariables())
+	path := r.Handler.logFilePath(name, lf, cmdVars)
+	f, err := os.Open(path) // #nosec G304 -- path comes from the config
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no logs for command '%s' at %s, run it with yxa %s", name, path, strings.ReplaceAll(name, ":", " "))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open logs of command '%s': %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return followFile(ctx, out, f, follow)
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "100", want: 100},
+		{input: "10B", want: 10},
+		{input: "512KB", want: 512 << 10},
+		{input: "10mb", want: 10 << 20},
+		{input: "1 GB", want: 1 << 30},
+		{input: "", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "ten MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseSize(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandHandler_LogFiles(t *testing.T) {
+	dir := t.TempDir()
+	logPath := func(name string) string { return filepath.Join(dir, name) }
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"LOG_DIR": dir},
+		Commands: map[string]config.Command{
+			"build":  {Run: "echo out; echo err >&2", LogFile: &config.LogFile{Path: "$LOG_DIR/logs/build.log"}},
+			"split":  {Run: "echo out; echo err >&2", LogFile: &config.LogFile{Path: logPath("split.log")}, StderrFile: &config.LogFile{Path: logPath("split.err")}},
+			"append": {Run: "echo again", LogFile: &config.LogFile{Path: logPath("append.log"), Mode: config.LogModeAppend}},
+			"rotate": {Run: "echo fresh", LogFile: &config.LogFile{Path: logPath("rotate.log"), MaxSize: "4", Keep: 2}},
+			"bad":    {Run: "echo bad", LogFile: &config.LogFile{Path: logPath("bad.log"), Mode: "sometimes"}},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec), out
+	}
+	readFile := func(t *testing.T, name string) string {
+		data, err := os.ReadFile(logPath(name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	// stdout and stderr are copied by separate goroutines, so the order of their
+	// lines in a shared destination is not guaranteed
+	lines := func(s string) []string {
+		return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	}
+
+	t.Run("tees stdout and stderr to log_file", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.ElementsMatch(t, []string{"out", "err"}, lines(out.String()))
+		assert.ElementsMatch(t, []string{"out", "err"}, lines(readFile(t, "logs/build.log")))
+		assert.Same(t, out, handler.Executor.GetStdout())
+	})
+
+	t.Run("stderr_file takes stderr", func(t *testing.T) {
+		handler, _ := newHandler()
+		require.NoError(t, handler.ExecuteCommand("split", nil))
+		assert.Equal(t, "out\n", readFile(t, "split.log"))
+		assert.Equal(t, "err\n", readFile(t, "split.err"))
+	})
+
+	t.Run("truncate and append modes", func(t *testing.T) {
+		handler, _ := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.ElementsMatch(t, []string{"out", "err"}, lines(readFile(t, "logs/build.log")))
+
+		require.NoError(t, os.WriteFile(logPath("append.log"), []byte("first\n"), 0o600))
+		require.NoError(t, handler.ExecuteCommand("append", nil))
+		assert.Equal(t, "first\nagain\n", readFile(t, "append.log"))
+	})
+
+	t.Run("rotates once max_size is reached", func(t *testing.T) {
+		handler, _ := newHandler()
+		for _, content := range []string{"one\n", "two\n"} {
+			require.NoError(t, os.WriteFile(logPath("rotate.log"), []byte(content), 0o600))
+			require.NoError(t, handler.ExecuteCommand("rotate", nil))
+			assert.Equal(t, "fresh\n", readFile(t, "rotate.log"))
+		}
+		assert.Equal(t, "two\n", readFile(t, "rotate.log.1"))
+		assert.Equal(t, "one\n", readFile(t, "rotate.log.2"))
+
+		require.NoError(t, handler.ExecuteCommand("rotate", nil))
+		assert.Equal(t, "fresh\n", readFile(t, "rotate.log.1"))
+		assert.Equal(t, "two\n", readFile(t, "rotate.log.2"))
+		assert.NoFileExists(t, logPath("rotate.log.3"))
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		handler, _ := newHandler()
+		err := handler.ExecuteCommand("bad", nil)
+		require.Error(t, err)
+		assert.EqualError(t, err, "config error in command 'bad': invalid log_file mode 'sometimes': expected 'truncate' or 'append'")
+		assert.NoFileExists(t, logPath("bad.log"))
+	})
+
+	t.Run("dry-run shows the log file", func(t *testing.T) {
+		require.NoError(t, os.Remove(logPath("split.log")))
+		handler, out := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("split", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would write output to: "+logPath("split.log"))
+		assert.Contains(t, out.String(), "[dry-run] Would write stderr to: "+logPath("split.err"))
+		assert.NoFileExists(t, logPath("split.log"))
+	})
+}
+
+func TestShowLogs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"build": {
+				Run:        "make",
+				LogFile:    &config.LogFile{Path: filepath.Join(dir, "build.log")},
+				StderrFile: &config.LogFile{Path: filepath.Join(dir, "build.err")},
+			},
+			"test": {Run: "go test ./..."},
+		},
+	}
+	root := setupTestRoot(cfg)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build.log"), []byte("compiled\n"), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, root.showLogs(context.Background(), &out, "build", false, false))
+	assert.Equal(t, "compiled\n", out.String())
+
+	err := root.showLogs(context.Background(), &out, "build", false, true)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "no logs for command 'build'"), err.Error())
+
+	err = root.showLogs(context.Background(), &out, "test", false, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command 'test' has no log_file")
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/variables"
+)
+
+// registerSensitive remembers the values of the sensitive parameters of a command
+// and of the variables marked as sensitive as they resolve for it, so they are
+// masked in the output of the rest of the run
+func (h *CommandHandler) registerSensitive(cmdName string, cmd config.Command, cmdVars map[string]string) {
+	run := h.RunContext()
+	for _, param := range append(h.inheritedParams(cmdName), cmd.Params...) {
+		if param.Sensitive {
+			run.addSensitive(cmdVars[param.Name])
+		}
+	}
+	if h.Config == nil {
+		return
+	}
+	names := h.Config.SensitiveVariables()
+	if len(names) == 0 {
+		return
+	}
+	resolver := h.resolver(cmdName, cmdVars)
+	for _, name := range names {
+		if value, _, ok := resolver.Lookup(name); ok {
+			run.addSensitive(value)
+		}
+	}
+}
+
+// maskedValues returns the values that are masked in the output of yxa: those of
+// the encrypted variables and the sensitive values seen in this run, longest
+// first, so that a value containing another one is masked entirely
+func (h *CommandHandler) maskedValues() []string {
+	values := h.RunContext().sensitiveValues()
+	if h.Config != nil {
+		for _, value := range h.Config.SecretVars() {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// maskSecrets replaces the masked values in s, see maskedValues
+func (h *CommandHandler) maskSecrets(s string) string {
+	values := h.maskedValues()
+	if len(values) == 0 {
+		return s
+	}
+	return newMaskReplacer(values).Replace(s)
+}
+
+// newMaskReplacer returns a replacer of values with variables.MaskedValue
+func newMaskReplacer(values []string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, variables.MaskedValue)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// withMasking runs fn with the stdout and stderr of the executor filtered, so that
+// the masked values do not appear in the output of the commands, their log files
+// or captured output. Without values to mask the writers stay as they are, so
+// commands keep writing to the terminal directly.
+func (h *CommandHandler) withMasking(fn func() error) error {
+	values := h.maskedValues()
+	if len(values) == 0 || h.DryRun {
+		return fn()
+	}
+
+	stdout, stderr := h.Executor.GetStdout(), h.Executor.GetStderr()
+	maskedStdout, maskedStderr := newMaskingWriter(stdout, values), newMaskingWriter(stderr, values)
+	// The stdout of a needed command is a value, which must reach the command that
+	// needs it unchanged
+	if !isNeededOutput(stdout) {
+		h.Executor.SetStdout(maskedStdout)
+	}
+	h.Executor.SetStderr(maskedStderr)
+	defer func() {
+		_ = maskedStdout.Flush()
+		_ = maskedStderr.Flush()
+		h.Executor.SetStdout(stdout)
+		h.Executor.SetStderr(stderr)
+	}()
+	return fn()
+}
+
+// maskingWriter replaces values in the output written through it. A value may be
+// split across writes, so the end of the output that could start a value is held
+// back until the next write or Flush.
+type maskingWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	values   []string
+	replacer *strings.Replacer
+	pending  string
+}
+
+// newMaskingWriter creates a maskingWriter that masks values in the output written
+// to w
+func newMaskingWriter(w io.Writer, values []string) *maskingWriter {
+	return &maskingWriter{w: w, values: values, replacer: newMaskReplacer(values)}
+}
+
+// Write implements io.Writer
+func (m *maskingWriter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	text := m.pending + string(p)
+	keep := m.partialSuffix(text)
+	m.pending = text[len(text)-keep:]
+	if _, err := io.WriteString(m.w, m.replacer.Replace(text[:len(text)-keep])); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the output that was held back
+func (m *maskingWriter) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	text := m.pending
+	m.pending = ""
+	if text == "" {
+		return nil
+	}
+	_, err := io.WriteString(m.w, m.replacer.Replace(text))
+	return err
+}
+
+// partialSuffix returns the length of the longest end of text that is the start,
+// but not the whole, of a value
+func (m *maskingWriter) partialSuffix(text string) int {
+	longest := 0
+	for _, value := range m.values {
+		for n := min(len(value)-1, len(text)); n > longest; n-- {
+			if strings.HasSuffix(text, value[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
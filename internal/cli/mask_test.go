@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/events"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskingWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newMaskingWriter(&out, []string{"hunter2", "abc"})
+
+	for _, chunk := range []string{"pass=hun", "ter2 key=a", "bc end hun"} {
+		_, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "pass=*** key=*** end ", out.String(), "the start of a value is held back")
+
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "pass=*** key=*** end hun", out.String())
+}
+
+func TestSensitiveValues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+variables:
+  TOKEN: {value: t0k3n, sensitive: true}
+commands:
+  deploy:
+    run: echo deploy $TOKEN $password
+    params:
+      - name: password
+        type: string
+        sensitive: true
+  fail:
+    run: echo $TOKEN >&2; exit 1
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	t.Run("command output", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		require.NoError(t, handler.ExecuteCommand("deploy", map[string]string{"password": "s3cret"}))
+		assert.Contains(t, out.String(), "deploy *** ***")
+		assert.Equal(t, &out, exec.GetStdout(), "the writer is restored afterwards")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("deploy", map[string]string{"password": "s3cret"}))
+		assert.Contains(t, out.String(), "echo deploy *** ***")
+		assert.NotContains(t, out.String(), "s3cret")
+	})
+
+	t.Run("events and stderr", func(t *testing.T) {
+		var stderr, out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(io.Discard)
+		exec.SetStderr(&stderr)
+		emitter, err := events.NewEmitter(events.FormatJSON, &out)
+		require.NoError(t, err)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetEvents(emitter)
+		handler.SetOutputMode(config.OutputCaptured)
+		require.Error(t, handler.ExecuteCommand("fail", nil))
+		assert.Contains(t, stderr.String()+out.String(), "***")
+		assert.NotContains(t, stderr.String(), "t0k3n")
+		assert.NotContains(t, out.String(), "t0k3n")
+	})
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// validateMatrix checks the matrix of a command
+func (h *CommandHandler) validateMatrix(cmdName string, cmd config.Command) error {
+	if len(cmd.Matrix) == 0 {
+		return nil
+	}
+	if cmd.Run == "" {
+		return errors.NewCommandConfigError(cmdName, "uses 'matrix', which requires 'run'", nil)
+	}
+	if len(cmd.Register) > 0 {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'matrix' and 'register'", nil)
+	}
+
+	seen := make(map[string]bool)
+	for _, axis := range cmd.Matrix {
+		if !variableNamePattern.MatchString(axis.Name) {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid matrix: '%s' is not a valid variable name", axis.Name), nil)
+		}
+		if seen[axis.Name] {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid matrix: variable '%s' is defined more than once", axis.Name), nil)
+		}
+		seen[axis.Name] = true
+		if len(axis.Values) == 0 {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid matrix: variable '%s' has no values", axis.Name), nil)
+		}
+	}
+	return nil
+}
+
+// matrixJobs expands the run string of a command into one job per matrix combination.
+// The matrix variables take precedence over parameters with the same name.
+func (h *CommandHandler) matrixJobs(cmdName string, cmd config.Command, cmdVars map[string]string) []taskJob {
+	var jobs []taskJob
+	for _, combination := range cmd.Matrix.Combinations() {
+		vars := make(map[string]string, len(cmdVars)+len(combination))
+		for k, v := range cmdVars {
+			vars[k] = v
+		}
+		for k, v := range combination.Vars() {
+			vars[k] = v
+		}
+		jobs = append(jobs, taskJob{
+			ID:      combination.String(),
+			Task:    len(jobs) + 1,
+			Command: h.replaceVariablesInString(cmdName, cmd.Run, vars),
+		})
+	}
+	return jobs
+}
+
+// runMatrixCommands executes the run string of a command for every matrix combination in parallel
+func (h *CommandHandler) runMatrixCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
+	jobs := h.matrixJobs(cmdName, cmd, cmdVars)
+	if err := h.runParallelJobs(cmdName, cmd, jobs, timeout); err != nil {
+		return errors.NewCommandError(cmdName, "failed to execute matrix", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Matrix(t *testing.T) {
+	platforms := config.Matrix{
+		{Name: "GOOS", Values: []string{"linux", "darwin"}},
+		{Name: "GOARCH", Values: []string{"amd64", "arm64"}},
+	}
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"OUT": "dist"},
+		Commands: map[string]config.Command{
+			"build": {
+				Run:    "echo $OUT/$name-$GOOS-$GOARCH",
+				Matrix: platforms,
+				Params: []config.Param{{Name: "name", Type: "string", Default: "app", Flag: true}},
+			},
+			"test": {
+				Run:    "test $GOOS != darwin",
+				Matrix: config.Matrix{{Name: "GOOS", Values: []string{"linux", "darwin", "windows"}}},
+			},
+			"no-run": {
+				Tasks:  config.NewTaskList("echo $GOOS"),
+				Matrix: config.Matrix{{Name: "GOOS", Values: []string{"linux"}}},
+			},
+			"empty-axis": {
+				Run:    "echo $GOOS",
+				Matrix: config.Matrix{{Name: "GOOS"}},
+			},
+		},
+	}
+
+	t.Run("runs every combination with prefixed output", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(io.Discard)
+
+		require.NoError(t, NewCommandHandler(cfg, exec).ExecuteCommand("build", nil))
+		output := out.String()
+		assert.Contains(t, output, "[GOOS=linux GOARCH=amd64] dist/app-linux-amd64")
+		assert.Contains(t, output, "[GOOS=linux GOARCH=arm64] dist/app-linux-arm64")
+		assert.Contains(t, output, "[GOOS=darwin GOARCH=amd64] dist/app-darwin-amd64")
+		assert.Contains(t, output, "[GOOS=darwin GOARCH=arm64] dist/app-darwin-arm64")
+	})
+
+	t.Run("reports the failing combinations", func(t *testing.T) {
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(io.Discard)
+		exec.SetStderr(io.Discard)
+
+		err := NewCommandHandler(cfg, exec).ExecuteCommand("test", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'test': failed to execute matrix")
+		assert.Contains(t, err.Error(), "sub-command GOOS=darwin for 'test' failed")
+		assert.NotContains(t, err.Error(), "GOOS=linux")
+		assert.NotContains(t, err.Error(), "GOOS=windows")
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		handler := NewCommandHandler(cfg, &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}})
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("build", map[string]string{"name": "yxa"}))
+		assert.Contains(t, out.String(), "[dry-run] Would execute (matrix GOOS=darwin GOARCH=arm64): echo dist/yxa-darwin-arm64")
+	})
+
+	t.Run("invalid matrix", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, &recordingExecutor{testExecutor: testExecutor{stdout: os.Stdout, stderr: os.Stderr}})
+		err := handler.ExecuteCommand("no-run", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config error in command 'no-run': uses 'matrix', which requires 'run'")
+
+		err = handler.ExecuteCommand("empty-axis", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "variable 'GOOS' has no values")
+	})
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metricsFileMode lets the node_exporter, which usually runs as another user,
+// read the metrics file
+const metricsFileMode = 0o644
+
+// writeMetricsFile writes the metrics of the finished run to --metrics-file.
+// Failing to write them is reported as a warning, it does not fail the command.
+func (r *RootCommand) writeMetricsFile(cmdName string, err error) {
+	if r.MetricsFile == "" || r.DryRun {
+		return
+	}
+	project := ""
+	if r.Config != nil {
+		project = r.Config.Name
+	}
+	var b bytes.Buffer
+	writeMetrics(&b, project, cmdName, r.Handler.RunContext(), time.Now(), err)
+	if err := writeFileAtomic(r.MetricsFile, b.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the metrics file: %v\n", err)
+	}
+}
+
+// writeMetrics writes the metrics of a run of cmdName in the Prometheus text
+// format. The values describe the last run only, so every metric is a gauge.
+func writeMetrics(out io.Writer, project, cmdName string, run *RunContext, now time.Time, err error) {
+	success := 1
+	if err != nil {
+		success = 0
+	}
+	runLabels := fmt.Sprintf(`project="%s",command="%s"`, escapeLabel(project), escapeLabel(cmdName))
+
+	gauge := func(name, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	gauge("yxa_run_duration_seconds", "Duration of the last run of the invoked command.")
+	fmt.Fprintf(out, "yxa_run_duration_seconds{%s} %s\n", runLabels, formatSeconds(now.Sub(run.StartedAt)))
+	gauge("yxa_run_success", "Whether the last run of the invoked command succeeded.")
+	fmt.Fprintf(out, "yxa_run_success{%s} %d\n", runLabels, success)
+	gauge("yxa_run_timestamp_seconds", "Time the last run of the invoked command finished.")
+	fmt.Fprintf(out, "yxa_run_timestamp_seconds{%s} %d\n", runLabels, now.Unix())
+
+	stats := run.statsSnapshot()
+	names := sortedKeys(stats)
+	commandMetric := func(name, help string, value func(commandStats) string) {
+		gauge(name, help)
+		for _, cmd := range names {
+			fmt.Fprintf(out, "%s{project=\"%s\",command=\"%s\"} %s\n", name, escapeLabel(project), escapeLabel(cmd), value(stats[cmd]))
+		}
+	}
+	commandMetric("yxa_command_duration_seconds", "Total duration of the executions of a command in the last run.", func(s commandStats) string {
+		return formatSeconds(s.Duration)
+	})
+	commandMetric("yxa_command_executions", "Executions of a command in the last run.", func(s commandStats) string {
+		return fmt.Sprint(s.Executions)
+	})
+	commandMetric("yxa_command_failures", "Failed executions of a command in the last run.", func(s commandStats) string {
+		return fmt.Sprint(s.Failures)
+	})
+	commandMetric("yxa_command_skipped", "Executions of a command in the last run that were skipped because it was up to date or its condition was not met.", func(s commandStats) string {
+		return fmt.Sprint(s.Skipped)
+	})
+	commandMetric("yxa_command_cache_hits", "Times a command was not executed in the last run because it already ran.", func(s commandStats) string {
+		return fmt.Sprint(s.CacheHits)
+	})
+}
+
+// formatSeconds formats a duration in seconds, with millisecond precision
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// escapeLabel escapes a label value of the Prometheus text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeFileAtomic replaces the file at path with data, so that a collector that
+// reads it at the same time never sees a partial file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), metricsFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsFile(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "app",
+		Commands: map[string]config.Command{
+			"generate": {Run: "true"},
+			"lint":     {Run: "true", Depends: config.NewDependencyList("generate")},
+			"test":     {Run: "false", Depends: config.NewDependencyList("generate")},
+			"ci":       {Depends: config.NewDependencyList("lint", "test"), DependsMode: config.DependsModeAll},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&bytes.Buffer{})
+		exec.SetStderr(&bytes.Buffer{})
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(&bytes.Buffer{})
+		root.RootCmd.SetErr(&bytes.Buffer{})
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		exitFunc = func(int) {}
+		require.NoError(t, root.Execute())
+	}
+
+	t.Run("snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metrics", "yxa.prom")
+		run(t, "--metrics-file", path, "ci")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		metrics := string(data)
+		assert.Contains(t, metrics, "# TYPE yxa_run_duration_seconds gauge\n")
+		assert.Contains(t, metrics, `yxa_run_success{project="app",command="ci"} 0`+"\n")
+		assert.Regexp(t, `yxa_run_timestamp_seconds\{project="app",command="ci"\} \d+\n`, metrics)
+		assert.Regexp(t, `yxa_command_duration_seconds\{project="app",command="lint"\} \d+\.\d{3}\n`, metrics)
+		assert.Contains(t, metrics, `yxa_command_executions{project="app",command="generate"} 1`+"\n")
+		assert.Contains(t, metrics, `yxa_command_failures{project="app",command="test"} 1`+"\n")
+		assert.Contains(t, metrics, `yxa_command_failures{project="app",command="ci"} 1`+"\n")
+		assert.Contains(t, metrics, `yxa_command_failures{project="app",command="lint"} 0`+"\n")
+		assert.Contains(t, metrics, `yxa_command_cache_hits{project="app",command="generate"} 1`+"\n")
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(metricsFileMode), info.Mode().Perm())
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary files are left behind")
+
+		// The next run replaces the snapshot
+		run(t, "--metrics-file", path, "lint")
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `yxa_run_success{project="app",command="lint"} 1`+"\n")
+		assert.NotContains(t, string(data), `command="test"`)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "yxa.prom")
+		run(t, "--metrics-file", path, "--dry-run", "ci")
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabel("a\\b\"c\nd"))
+}
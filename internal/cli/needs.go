@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// validateNeeds checks the needs of a command
+func (h *CommandHandler) validateNeeds(cmdName string, cmd config.Command) error {
+	for _, name := range cmd.NeededVars() {
+		if !variableNamePattern.MatchString(name) {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid needs: '%s' is not a valid variable name", name), nil)
+		}
+		if _, err := h.lookupCommand(cmd.Needs[name]); err != nil {
+			return errors.NewCommandConfigError(cmdName, "invalid needs", err)
+		}
+	}
+	return nil
+}
+
+// withNeeds runs the commands a command needs and returns a copy of cmdVars with
+// their stdout as the variables of needs
+func (h *CommandHandler) withNeeds(cmdName string, cmd config.Command, cmdVars map[string]string) (map[string]string, error) {
+	if len(cmd.Needs) == 0 {
+		return cmdVars, nil
+	}
+	if err := h.validateNeeds(cmdName, cmd); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(cmdVars)+len(cmd.Needs))
+	for k, v := range cmdVars {
+		vars[k] = v
+	}
+	for _, name := range cmd.NeededVars() {
+		output, err := h.commandOutput(cmdName, cmd.Needs[name], cmdVars)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = output
+	}
+	return vars, nil
+}
+
+// commandOutput returns the stdout of a command needed by parent without the
+// trailing newline. The output is captured once per run: the command runs the
+// first time it is needed, even if it already ran without capturing it, and every
+// later command that needs it reuses the output.
+func (h *CommandHandler) commandOutput(parent, cmdName string, cmdVars map[string]string) (string, error) {
+	run := h.RunContext()
+	if output, ok := run.output(cmdName); ok {
+		run.recordCacheHit(cmdName)
+		return output, nil
+	}
+
+	capture := &neededOutput{}
+	stdout := h.Executor.GetStdout()
+	h.Executor.SetStdout(capture)
+	err := h.runCommand(parent, cmdName, cmdVars)
+	h.Executor.SetStdout(stdout)
+	if err != nil {
+		return "", err
+	}
+
+	output := strings.TrimRight(capture.String(), "\r\n")
+	run.setOutput(cmdName, output)
+	return output, nil
+}
+
+// neededOutput captures the stdout of a command another command needs. It is a
+// value rather than output to show, so captured output and masking leave it alone.
+type neededOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer
+func (o *neededOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+// String returns the output written so far
+func (o *neededOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// isNeededOutput reports whether w captures the stdout of a needed command
+func isNeededOutput(w io.Writer) bool {
+	_, ok := w.(*neededOutput)
+	return ok
+}
+
+// describeNeed returns a short description of a variable of needs for dry-run and
+// explain
+func describeNeed(cmd config.Command, name string) string {
+	return fmt.Sprintf("%s from the output of '%s'", name, cmd.Needs[name])
+}
+
+// prerequisites returns the commands that run before a command: its dependencies
+// and the commands it needs
+func prerequisites(cmd config.Command) []string {
+	commands := cmd.Depends.Commands()
+	for _, name := range cmd.NeededVars() {
+		commands = append(commands, cmd.Needs[name])
+	}
+	return commands
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Needs(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"get-version": {
+				Run:  "echo v1.2.3",
+				Post: "echo hook >&2",
+			},
+			"get-commit": {
+				Run:    "echo abc123",
+				Output: config.OutputCaptured,
+			},
+			"build": {
+				Run:     "echo building $VERSION at $COMMIT",
+				Depends: config.NewDependencyList("get-version"),
+				Needs:   map[string]string{"VERSION": "get-version", "COMMIT": "get-commit"},
+			},
+			"publish": {
+				Run:   "echo publishing $VERSION",
+				Needs: map[string]string{"VERSION": "get-version"},
+			},
+			"release": {
+				Run:     "echo released",
+				Depends: config.NewDependencyList("build", "publish"),
+			},
+			"bad-name": {
+				Run:   "echo hi",
+				Needs: map[string]string{"NOT-VALID": "get-version"},
+			},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		realExec := executor.NewDefaultExecutor()
+		realExec.SetStdout(buf)
+		realExec.SetStderr(buf)
+		return NewCommandHandler(cfg, realExec), buf
+	}
+
+	t.Run("stdout of the needed commands without trailing newline", func(t *testing.T) {
+		handler, buf := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Contains(t, buf.String(), "building v1.2.3 at abc123\n")
+		assert.Equal(t, buf, handler.Executor.GetStdout(), "the writer is restored afterwards")
+	})
+
+	t.Run("output is captured once per run", func(t *testing.T) {
+		handler, buf := newHandler()
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Contains(t, buf.String(), "publishing v1.2.3\n")
+		// Once as a dependency of build, once to capture its output
+		assert.Equal(t, 2, strings.Count(buf.String(), "hook"))
+		assert.Equal(t, 1, handler.RunContext().statsSnapshot()["get-version"].CacheHits)
+	})
+
+	t.Run("invalid variable name", func(t *testing.T) {
+		handler, _ := newHandler()
+		err := handler.ExecuteCommand("bad-name", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config error in command 'bad-name': invalid needs: 'NOT-VALID' is not a valid variable name")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		handler, buf := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		out := buf.String()
+		assert.Contains(t, out, "[dry-run] Would set COMMIT from the output of 'get-commit'")
+		assert.Contains(t, out, "[dry-run] Would set VERSION from the output of 'get-version'")
+		assert.Equal(t, 2, strings.Count(out, "[dry-run] Command 'get-version'"), "get-version runs again to capture its output")
+		assert.Less(t, strings.Index(out, "[dry-run] Command 'get-commit'"), strings.Index(out, "[dry-run] Command 'build'"))
+	})
+}
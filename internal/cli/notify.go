@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/notify"
+)
+
+// notifyTimeout is how long sending one notification may take
+const notifyTimeout = 10 * time.Second
+
+// desktopNotify shows a desktop notification, replaced in tests
+var desktopNotify = notify.Desktop
+
+// commandNotify returns the notification settings of a command: its own or a
+// parent's, otherwise the top-level ones of the config. It is nil if there are none.
+func (h *CommandHandler) commandNotify(cmdName string) *config.Notify {
+	if cmd, err := h.lookupCommand(cmdName); err == nil && cmd.Notify != nil {
+		return cmd.Notify
+	}
+	if h.Config == nil {
+		return nil
+	}
+	return h.Config.Notify
+}
+
+// validateNotify checks the on and min_duration of the notifications of a command
+func (h *CommandHandler) validateNotify(cmdName string, cmd config.Command) error {
+	n := cmd.Notify
+	if n == nil && h.Config != nil {
+		n = h.Config.Notify
+	}
+	if n == nil {
+		return nil
+	}
+	switch n.On {
+	case "", config.NotifyAlways, config.NotifyFailure, config.NotifySuccess:
+	default:
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid notify on '%s': expected '%s', '%s' or '%s'",
+			n.On, config.NotifyAlways, config.NotifyFailure, config.NotifySuccess), nil)
+	}
+	if n.MinDuration != "" {
+		if d, err := time.ParseDuration(n.MinDuration); err != nil || d < 0 {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid notify min_duration '%s': expected a duration such as 30s or 5m", n.MinDuration), nil)
+		}
+	}
+	return nil
+}
+
+// shouldNotify reports whether a result is to be sent with the settings n
+func shouldNotify(n config.Notify, result notify.Result) bool {
+	switch n.On {
+	case config.NotifyFailure:
+		if result.Status != notify.StatusFailure {
+			return false
+		}
+	case config.NotifySuccess:
+		if result.Status != notify.StatusSuccess {
+			return false
+		}
+	}
+	minDuration, _ := time.ParseDuration(n.MinDuration)
+	return result.Duration >= minDuration
+}
+
+// sendNotifications reports the outcome of the command that was invoked. With
+// --notify a notification is sent even if the command does not configure one,
+// on the desktop if it has no other channel, regardless of on and min_duration.
+// Notifications that cannot be sent are reported as warnings.
+func (r *RootCommand) sendNotifications(cmdName string, err error) {
+	if r.DryRun || r.Config == nil {
+		return
+	}
+	n := r.Handler.commandNotify(cmdName)
+	if n == nil {
+		if !r.Notify {
+			return
+		}
+		n = &config.Notify{}
+	}
+
+	run := r.Handler.RunContext()
+	result := notify.Result{
+		Command:  cmdName,
+		Project:  r.Config.Name,
+		Status:   notify.StatusSuccess,
+		Duration: time.Since(run.StartedAt),
+	}
+	switch {
+	case err != nil && run.Cancelled():
+		result.Status = notify.StatusCancelled
+	case err != nil:
+		result.Status = notify.StatusFailure
+		result.Failed = run.failedCommands()
+		result.Error = errorMessage(cmdName, err)
+	}
+	if !r.Notify && !shouldNotify(*n, result) {
+		return
+	}
+
+	// The run may have been cancelled, the notifications get their own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	warn := func(channel string, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to send the %s notification of '%s': %v\n", channel, cmdName, err)
+		}
+	}
+	if n.Desktop || (n.Slack == "" && n.Webhook == "") {
+		warn("desktop", desktopNotify(ctx, result))
+	}
+	if n.Slack != "" {
+		warn("Slack", notify.Slack(ctx, r.Handler.replaceVariablesInString(cmdName, n.Slack, nil), result))
+	}
+	if n.Webhook != "" {
+		warn("webhook", notify.Webhook(ctx, r.Handler.replaceVariablesInString(cmdName, n.Webhook, nil), result))
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendNotifications(t *testing.T) {
+	var mu sync.Mutex
+	var received []notify.Result
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result notify.Result
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&result))
+		mu.Lock()
+		received = append(received, result)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	var desktop []string
+	origDesktop := desktopNotify
+	defer func() { desktopNotify = origDesktop }()
+	desktopNotify = func(_ context.Context, r notify.Result) error {
+		desktop = append(desktop, r.Message())
+		return nil
+	}
+
+	cfg := &config.ProjectConfig{
+		Name:      "app",
+		Variables: map[string]string{"HOOK": server.URL},
+		Commands: map[string]config.Command{
+			"build":  {Run: "true", Notify: &config.Notify{Webhook: "$HOOK"}},
+			"test":   {Run: "false", Notify: &config.Notify{Webhook: "$HOOK", On: config.NotifyFailure}},
+			"lint":   {Run: "true", Notify: &config.Notify{Webhook: "$HOOK", On: config.NotifyFailure}},
+			"deploy": {Run: "true", Notify: &config.Notify{Webhook: "$HOOK", MinDuration: "1h"}},
+			"fmt":    {Run: "true"},
+			"db": {
+				Notify:   &config.Notify{Desktop: true},
+				Commands: map[string]config.Command{"migrate": {Run: "true"}},
+			},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		received, desktop = nil, nil
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&bytes.Buffer{})
+		exec.SetStderr(&bytes.Buffer{})
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(&bytes.Buffer{})
+		root.RootCmd.SetErr(&bytes.Buffer{})
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		exitFunc = func(int) {}
+		require.NoError(t, root.Execute())
+	}
+
+	t.Run("webhook", func(t *testing.T) {
+		run(t, "build")
+		require.Len(t, received, 1)
+		assert.Equal(t, "build", received[0].Command)
+		assert.Equal(t, "app", received[0].Project)
+		assert.Equal(t, notify.StatusSuccess, received[0].Status)
+		assert.Empty(t, desktop)
+	})
+
+	t.Run("on failure", func(t *testing.T) {
+		run(t, "lint")
+		assert.Empty(t, received)
+
+		run(t, "test")
+		require.Len(t, received, 1)
+		assert.Equal(t, notify.StatusFailure, received[0].Status)
+		assert.Equal(t, []string{"test"}, received[0].Failed)
+	})
+
+	t.Run("min_duration", func(t *testing.T) {
+		run(t, "deploy")
+		assert.Empty(t, received)
+
+		// --notify sends it anyway
+		run(t, "deploy", "--notify")
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("notify flag without configuration", func(t *testing.T) {
+		run(t, "fmt")
+		assert.Empty(t, desktop)
+
+		run(t, "fmt", "--notify")
+		require.Len(t, desktop, 1)
+		assert.Contains(t, desktop[0], "fmt succeeded in")
+	})
+
+	t.Run("inherited by subcommands", func(t *testing.T) {
+		run(t, "db", "migrate")
+		require.Len(t, desktop, 1)
+		assert.Contains(t, desktop[0], "db:migrate succeeded in")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		run(t, "build", "--dry-run")
+		assert.Empty(t, received)
+	})
+}
+
+func TestValidateNotify(t *testing.T) {
+	h := NewCommandHandler(&config.ProjectConfig{}, executor.NewDefaultExecutor())
+	assert.NoError(t, h.validateNotify("build", config.Command{}))
+	assert.NoError(t, h.validateNotify("build", config.Command{Notify: &config.Notify{On: config.NotifySuccess, MinDuration: "30s"}}))
+	assert.EqualError(t, h.validateNotify("build", config.Command{Notify: &config.Notify{On: "error"}}),
+		"config error in command 'build': invalid notify on 'error': expected 'always', 'failure' or 'success'")
+	assert.EqualError(t, h.validateNotify("build", config.Command{Notify: &config.Notify{MinDuration: "soon"}}),
+		"config error in command 'build': invalid notify min_duration 'soon': expected a duration such as 30s or 5m")
+
+	// The top-level notify applies to commands without their own
+	h = NewCommandHandler(&config.ProjectConfig{Notify: &config.Notify{On: "sometimes"}}, executor.NewDefaultExecutor())
+	assert.Error(t, h.validateNotify("build", config.Command{}))
+}
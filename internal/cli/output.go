@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// validOutputMode reports whether mode is a known output mode, empty meaning the default
+func validOutputMode(mode string) bool {
+	switch mode {
+	case "", config.OutputStream, config.OutputCaptured:
+		return true
+	}
+	return false
+}
+
+// validateOutput checks the output mode of a command
+func (h *CommandHandler) validateOutput(cmdName string, cmd config.Command) error {
+	if !validOutputMode(cmd.Output) {
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid output '%s': expected '%s' or '%s'",
+			cmd.Output, config.OutputStream, config.OutputCaptured), nil)
+	}
+	if cmd.Service && cmd.Output == config.OutputCaptured {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use 'output: captured'", nil)
+	}
+	return nil
+}
+
+// outputMode returns the output mode of a command, falling back to --output-mode
+func (h *CommandHandler) outputMode(cmd config.Command) string {
+	if cmd.Output != "" {
+		return cmd.Output
+	}
+	if h.OutputMode != "" && !cmd.Service {
+		return h.OutputMode
+	}
+	return config.OutputStream
+}
+
+// printf prints a progress message of the running command. While the output of a
+// command is captured, its progress messages are captured with it.
+func (h *CommandHandler) printf(format string, args ...interface{}) {
+	h.progressMu.Lock()
+	out := h.progress
+	h.progressMu.Unlock()
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, args...)
+}
+
+// setProgress sets the destination of progress messages and returns the previous one
+func (h *CommandHandler) setProgress(w io.Writer) io.Writer {
+	h.progressMu.Lock()
+	defer h.progressMu.Unlock()
+	prev := h.progress
+	h.progress = w
+	return prev
+}
+
+// withCapturedOutput runs fn with the output of a command in captured mode held
+// back. A successful command prints a single status line, a failing one prints
+// the captured output as well.
+func (h *CommandHandler) withCapturedOutput(cmdName string, cmd config.Command, fn func() error) error {
+	if h.outputMode(cmd) != config.OutputCaptured || h.DryRun {
+		return fn()
+	}
+
+	buf := executor.NewCaptureBuffer(executor.DefaultCaptureLimit)
+	defer func() { _ = buf.Close() }()
+
+	stdout, stderr := h.Executor.GetStdout(), h.Executor.GetStderr()
+	progress := h.setProgress(buf)
+	// The stdout of a needed command is passed on, not output to show
+	if !isNeededOutput(stdout) {
+		h.Executor.SetStdout(buf)
+	}
+	h.Executor.SetStderr(buf)
+
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	h.Executor.SetStdout(stdout)
+	h.Executor.SetStderr(stderr)
+	h.setProgress(progress)
+
+	if err == nil {
+		h.printf("ok   %s (%s)\n", cmdName, elapsed)
+		return nil
+	}
+	h.printf("FAIL %s (%s), output:\n", cmdName, elapsed)
+	_, _ = buf.WriteTo(stderr)
+	return err
+}
+
+// withDependencyPrefix runs fn, which executes the dependency dep, with the output
+// lines of the dependency prefixed with [dep], so they can be told apart from those
+// of the command that depends on it. With --no-prefix the output is passed on as
+// is, and so is the stdout of a needed command.
+func (h *CommandHandler) withDependencyPrefix(dep string, fn func() error) error {
+	if h.NoPrefix {
+		return fn()
+	}
+
+	stdout, stderr := h.Executor.GetStdout(), h.Executor.GetStderr()
+	prefix := "[" + dep + "] "
+	prefixedStderr := &linePrefixer{writer: stderr, prefix: prefix}
+	h.Executor.SetStderr(prefixedStderr)
+	var prefixedStdout *linePrefixer
+	if !isNeededOutput(stdout) {
+		prefixedStdout = &linePrefixer{writer: stdout, prefix: prefix}
+		h.Executor.SetStdout(prefixedStdout)
+	}
+
+	err := fn()
+
+	h.Executor.SetStdout(stdout)
+	h.Executor.SetStderr(stderr)
+	if prefixedStdout != nil {
+		prefixedStdout.Flush()
+	}
+	prefixedStderr.Flush()
+	return err
+}
+
+// linePrefixer writes the lines written to it to writer with the prefix, each once
+// its newline arrived
+type linePrefixer struct {
+	writer  io.Writer
+	prefix  string
+	pending []byte // Output after the last newline, not written yet
+	mutex   sync.Mutex
+}
+
+// Write writes the lines p completes
+func (w *linePrefixer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.pending = append(w.pending, p...)
+	if i := bytes.LastIndexByte(w.pending, '\n'); i >= 0 {
+		err := w.writeLocked(w.pending[:i+1])
+		w.pending = append([]byte(nil), w.pending[i+1:]...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the last line if it has no trailing newline
+func (w *linePrefixer) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.pending) > 0 {
+		if err := w.writeLocked(w.pending); err != nil {
+			// Log the error but don't fail the command
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+		w.pending = nil
+	}
+}
+
+// writeLocked writes lines with the prefix. It does not take the outputMutex, as
+// the lines of parallel tasks are written to it while they hold that.
+func (w *linePrefixer) writeLocked(lines []byte) error {
+	prefixed := NewSafeWriter(w.writer, w.prefix)
+	_, _ = prefixed.Write(lines)
+	return prefixed.Flush()
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_CapturedOutput(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"gen":    {Run: "echo generating", Output: config.OutputCaptured},
+			"build":  {Run: "echo building; echo warning >&2", Pre: "echo pre", Depends: config.NewDependencyList("gen"), Output: config.OutputCaptured},
+			"broken": {Run: "echo compiling; echo boom >&2; exit 3", Output: config.OutputCaptured},
+			"stream": {Run: "echo streaming"},
+			"bad":    {Run: "echo bad", Output: "silent"},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer, *bytes.Buffer, *bytes.Buffer) {
+		stdout, stderr, progress := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(stdout)
+		exec.SetStderr(stderr)
+		handler := NewCommandHandler(cfg, exec)
+		handler.setProgress(progress)
+		return handler, stdout, stderr, progress
+	}
+
+	t.Run("success prints a status line", func(t *testing.T) {
+		handler, stdout, stderr, progress := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Empty(t, stdout.String())
+		assert.Empty(t, stderr.String())
+		assert.Regexp(t, regexp.MustCompile(`^ok   gen \(.+\)\nok   build \(.+\)\n$`), progress.String())
+		assert.Same(t, stdout, handler.Executor.GetStdout())
+		assert.Same(t, stderr, handler.Executor.GetStderr())
+	})
+
+	t.Run("failure replays the output", func(t *testing.T) {
+		handler, stdout, stderr, progress := newHandler()
+		require.Error(t, handler.ExecuteCommand("broken", nil))
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, progress.String(), "FAIL broken (")
+		assert.Equal(t, "Executing command 'broken'...\ncompiling\nboom\n", stderr.String())
+	})
+
+	t.Run("output mode flag applies to commands without output", func(t *testing.T) {
+		handler, stdout, _, progress := newHandler()
+		handler.SetOutputMode(config.OutputCaptured)
+		require.NoError(t, handler.ExecuteCommand("stream", nil))
+		assert.Empty(t, stdout.String())
+		assert.Contains(t, progress.String(), "ok   stream (")
+	})
+
+	t.Run("stream mode", func(t *testing.T) {
+		handler, stdout, _, progress := newHandler()
+		require.NoError(t, handler.ExecuteCommand("stream", nil))
+		assert.Equal(t, "streaming\n", stdout.String())
+		assert.Equal(t, "Executing command 'stream'...\n", progress.String())
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		handler, _, _, _ := newHandler()
+		err := handler.ExecuteCommand("bad", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid output 'silent'")
+	})
+
+	t.Run("captured service", func(t *testing.T) {
+		handler, _, _, _ := newHandler()
+		err := handler.validateOutput("web", config.Command{Run: "serve", Service: true, Output: config.OutputCaptured})
+		assert.EqualError(t, err, "config error in command 'web': a service cannot use 'output: captured'")
+	})
+}
+
+func TestCommandHandler_DependencyPrefix(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "echo generating; printf 'no newline'"},
+			"lint":     {Run: "echo linting >&2", Depends: config.DependencyList{{Command: "generate"}}},
+			"build":    {Run: "echo building", Depends: config.DependencyList{{Command: "lint"}}},
+		},
+	}
+	newHandler := func() (*CommandHandler, *bytes.Buffer, *bytes.Buffer) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(stdout)
+		exec.SetStderr(stderr)
+		handler := NewCommandHandler(cfg, exec)
+		handler.setProgress(&bytes.Buffer{})
+		return handler, stdout, stderr
+	}
+
+	t.Run("dependency output is prefixed", func(t *testing.T) {
+		handler, stdout, stderr := newHandler()
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, "[lint] [generate] generating\n[lint] [generate] no newline\nbuilding\n", stdout.String())
+		assert.Equal(t, "[lint] linting\n", stderr.String())
+	})
+
+	t.Run("no prefix", func(t *testing.T) {
+		handler, stdout, stderr := newHandler()
+		handler.SetNoPrefix(true)
+		require.NoError(t, handler.ExecuteCommand("build", nil))
+		assert.Equal(t, "generating\nno newlinebuilding\n", stdout.String())
+		assert.Equal(t, "linting\n", stderr.String())
+	})
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// noOverrideFlag is the name of the global flag that disables yxa.override.yml
+const noOverrideFlag = "no-override"
+
+// applyNoOverride sets NoOverride from --no-override among the global flags at the
+// start of args. Like --chdir, it is read before cobra parses the arguments,
+// because it decides which config is loaded.
+func (r *RootCommand) applyNoOverride(args []string) {
+	if value, ok := leadingFlagArg(r.RootCmd.PersistentFlags(), args, noOverrideFlag); ok {
+		r.NoOverride, _ = strconv.ParseBool(value)
+		r.noOverrideApplied = true
+	}
+}
+
+// loadOptions returns how the config is loaded: without yxa.override.yml if
+// --no-override or YXA_NO_OVERRIDE is set
+func (r *RootCommand) loadOptions() config.LoadOptions {
+	noOverride := r.NoOverride
+	if env, err := strconv.ParseBool(os.Getenv("YXA_NO_OVERRIDE")); err == nil && env {
+		noOverride = true
+	}
+	return config.LoadOptions{NoOverride: noOverride}
+}
+
+// checkNoOverride fails if --no-override was given after the command, when the
+// override file has already been merged
+func (r *RootCommand) checkNoOverride() error {
+	if r.NoOverride && !r.noOverrideApplied && r.Config != nil && r.Config.OverrideFile() != "" {
+		return fmt.Errorf("--%s must be given before the command, e.g. yxa --%s <command>", noOverrideFlag, noOverrideFlag)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoOverride(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want bool
+	}{
+		{name: "flag", args: []string{"--no-override", "build"}, want: true},
+		{name: "flag with value", args: []string{"-d", "--no-override=false", "build"}, want: false},
+		{name: "environment", args: []string{"build"}, env: "1", want: true},
+		{name: "none", args: []string{"build"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("YXA_NO_OVERRIDE", tt.env)
+			root := NewRootCommand(nil, executor.NewDefaultExecutor())
+			root.applyNoOverride(tt.args)
+			assert.Equal(t, tt.want, root.loadOptions().NoOverride)
+		})
+	}
+}
+
+func TestOverrideFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("YXA_NO_OVERRIDE", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yxa.yml"), []byte("root: true\nvariables:\n  DB_HOST: db.internal\n  PORT: \"8080\"\ncommands:\n  serve:\n    run: serve\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.OverrideFileName), []byte("variables:\n  DB_HOST: localhost\n"), 0600))
+
+	cfg, err := config.LoadConfigFrom(filepath.Join(dir, "yxa.yml"))
+	require.NoError(t, err)
+
+	t.Run("env shows values of the override file", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env"})
+		require.NoError(t, root.Execute())
+		assert.Regexp(t, `DB_HOST\s+localhost\s+yxa\.override\.yml`, out.String())
+		assert.Regexp(t, `PORT\s+8080\s+config`, out.String())
+	})
+
+	t.Run("no-override after the command", func(t *testing.T) {
+		root, _ := setupEnvTestRoot(cfg)
+		root.RootCmd.SilenceUsage = true
+		root.RootCmd.SilenceErrors = true
+		root.RootCmd.SetArgs([]string{"env", "--no-override"})
+		err := root.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--no-override must be given before the command, e.g. yxa --no-override <command>")
+	})
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variableNamePattern matches names that can be referenced as $NAME or ${NAME}
+var variableNamePattern = regexp.MustCompile(`^\w+$`)
+
+// parseVariableOverrides parses KEY=VALUE pairs from --set and KEY=path pairs from
+// --set-file into a single map. For --set-file the variable gets the contents of the
+// file, without a trailing newline. When a key is given more than once, the last one wins,
+// with --set-file applied after --set.
+func parseVariableOverrides(sets, setFiles []string) (map[string]string, error) {
+	overrides := make(map[string]string)
+
+	for _, set := range sets {
+		key, value, err := splitVariableAssignment(set, "--set")
+		if err != nil {
+			return nil, err
+		}
+		overrides[key] = value
+	}
+
+	for _, setFile := range setFiles {
+		key, path, err := splitVariableAssignment(setFile, "--set-file")
+		if err != nil {
+			return nil, err
+		}
+		// #nosec G304 -- Reading a user specified file is the purpose of --set-file
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file for variable '%s': %w", key, err)
+		}
+		overrides[key] = strings.TrimRight(string(data), "\r\n")
+	}
+
+	return overrides, nil
+}
+
+// splitVariableAssignment splits a KEY=VALUE assignment and validates the key
+func splitVariableAssignment(assignment, flagName string) (string, string, error) {
+	key, value, ok := strings.Cut(assignment, "=")
+	if !ok {
+		return "", "", fmt.Errorf("invalid %s value '%s': expected KEY=VALUE", flagName, assignment)
+	}
+	if !variableNamePattern.MatchString(key) {
+		return "", "", fmt.Errorf("invalid %s value '%s': '%s' is not a valid variable name", flagName, assignment, key)
+	}
+	return key, value, nil
+}
+
+// loadVariablesFrom reads variables from JSON or YAML maps in the given files. The
+// source "-" reads from stdin, which can only be used once. Later sources take
+// precedence over earlier ones.
+func loadVariablesFrom(sources []string, stdin io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	stdinUsed := false
+
+	for _, source := range sources {
+		var data []byte
+		var err error
+		if source == "-" {
+			if stdinUsed {
+				return nil, fmt.Errorf("--vars-from - can only be used once")
+			}
+			stdinUsed = true
+			data, err = io.ReadAll(stdin)
+		} else {
+			// #nosec G304 -- Reading a user specified file is the purpose of --vars-from
+			data, err = os.ReadFile(source)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read variables from '%s': %w", source, err)
+		}
+
+		if err := parseVariableMap(data, vars); err != nil {
+			return nil, fmt.Errorf("failed to parse variables from '%s': %w", source, err)
+		}
+	}
+
+	return vars, nil
+}
+
+// parseVariableMap parses a JSON or YAML map of scalar values into vars. Scalars are
+// kept as written, so 1.10 stays "1.10" and null becomes an empty string.
+func parseVariableMap(data []byte, vars map[string]string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	// An empty document defines no variables
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected a map of variable names to values")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+		if !variableNamePattern.MatchString(key) {
+			return fmt.Errorf("'%s' is not a valid variable name", key)
+		}
+		if value.Kind != yaml.ScalarNode {
+			return fmt.Errorf("value of '%s' must be a string, number or boolean", key)
+		}
+		if value.Tag == "!!null" {
+			vars[key] = ""
+			continue
+		}
+		vars[key] = value.Value
+	}
+
+	return nil
+}
+
+// applyVariableOverrides reads the --vars-from sources, parses the --set and
+// --set-file flags and passes the result to the command handler. Values given with
+// --set and --set-file take precedence over values read with --vars-from.
+func (r *RootCommand) applyVariableOverrides() error {
+	overrides, err := loadVariablesFrom(r.VarsFrom, r.RootCmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	flagOverrides, err := parseVariableOverrides(r.SetVars, r.SetFiles)
+	if err != nil {
+		return err
+	}
+	for key, value := range flagOverrides {
+		overrides[key] = value
+	}
+	if r.Handler != nil {
+		r.Handler.SetVariableOverrides(overrides)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVariableOverrides(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sets     []string
+		setFiles []string
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name: "simple values",
+			sets: []string{"A=1", "B=two words", "C="},
+			want: map[string]string{"A": "1", "B": "two words", "C": ""},
+		},
+		{
+			name: "value containing equals sign",
+			sets: []string{"FLAGS=-X main.v=1"},
+			want: map[string]string{"FLAGS": "-X main.v=1"},
+		},
+		{
+			name:     "file value without trailing newline",
+			sets:     []string{"KEY=inline"},
+			setFiles: []string{"KEY=" + keyFile},
+			want:     map[string]string{"KEY": "from-file"},
+		},
+		{
+			name:    "missing equals sign",
+			sets:    []string{"NOPE"},
+			wantErr: "expected KEY=VALUE",
+		},
+		{
+			name:    "invalid name",
+			sets:    []string{"BAD-NAME=1"},
+			wantErr: "not a valid variable name",
+		},
+		{
+			name:     "missing file",
+			setFiles: []string{"KEY=" + filepath.Join(dir, "missing.txt")},
+			wantErr:  "failed to read file for variable 'KEY'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVariableOverrides(tt.sets, tt.setFiles)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRootCommand_SetOverridesVariables(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"VERSION": "dev"},
+		Commands: map[string]config.Command{
+			"release": {
+				Run:       "echo releasing $VERSION to $target",
+				Condition: "$target != none",
+				Params: []config.Param{
+					{Name: "target", Type: "string", Default: "staging", Flag: true},
+				},
+			},
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	realExec := executor.NewDefaultExecutor()
+	realExec.SetStdout(stdout)
+	realExec.SetStderr(stdout)
+
+	root := NewRootCommand(nil, realExec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, realExec)
+	root.registerCommands()
+	root.RootCmd.SetOut(stdout)
+	root.RootCmd.SetArgs([]string{"release", "--target", "prod", "--set", "VERSION=1.2.3", "-s", "target=canary"})
+
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stdout.String(), "releasing 1.2.3 to canary")
+}
+
+func TestLoadVariablesFrom(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "vars.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"VERSION": "1.2.3", "REPLICAS": 3, "DEBUG": false, "EMPTY": null}`), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	yamlFile := filepath.Join(dir, "vars.yml")
+	if err := os.WriteFile(yamlFile, []byte("VERSION: \"2.0\"\nGO_VERSION: 1.10\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sources []string
+		stdin   string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "json file",
+			sources: []string{jsonFile},
+			want:    map[string]string{"VERSION": "1.2.3", "REPLICAS": "3", "DEBUG": "false", "EMPTY": ""},
+		},
+		{
+			name:    "later yaml file wins and scalars are kept as written",
+			sources: []string{jsonFile, yamlFile},
+			want:    map[string]string{"VERSION": "2.0", "REPLICAS": "3", "DEBUG": "false", "EMPTY": "", "GO_VERSION": "1.10"},
+		},
+		{
+			name:    "stdin",
+			sources: []string{"-"},
+			stdin:   `{"TOKEN": "s3cr3t"}`,
+			want:    map[string]string{"TOKEN": "s3cr3t"},
+		},
+		{
+			name:    "empty stdin",
+			sources: []string{"-"},
+			want:    map[string]string{},
+		},
+		{
+			name:    "stdin used twice",
+			sources: []string{"-", "-"},
+			stdin:   `{}`,
+			wantErr: "can only be used once",
+		},
+		{
+			name:    "not a map",
+			sources: []string{"-"},
+			stdin:   `["a", "b"]`,
+			wantErr: "expected a map",
+		},
+		{
+			name:    "nested value",
+			sources: []string{"-"},
+			stdin:   `{"DB": {"host": "localhost"}}`,
+			wantErr: "value of 'DB' must be a string, number or boolean",
+		},
+		{
+			name:    "invalid name",
+			sources: []string{"-"},
+			stdin:   `{"BAD-NAME": "x"}`,
+			wantErr: "not a valid variable name",
+		},
+		{
+			name:    "missing file",
+			sources: []string{filepath.Join(dir, "missing.json")},
+			wantErr: "failed to read variables from",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadVariablesFrom(tt.sources, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRootCommand_VarsFromStdin(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"VERSION": "dev", "TARGET": "local"},
+		Commands: map[string]config.Command{
+			"release": {Run: "echo releasing $VERSION to $TARGET"},
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	realExec := executor.NewDefaultExecutor()
+	realExec.SetStdout(stdout)
+	realExec.SetStderr(stdout)
+
+	root := NewRootCommand(nil, realExec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, realExec)
+	root.registerCommands()
+	root.RootCmd.SetOut(stdout)
+	root.RootCmd.SetIn(strings.NewReader(`{"VERSION": "1.2.3", "TARGET": "staging"}`))
+	root.RootCmd.SetArgs([]string{"release", "--vars-from", "-", "--set", "TARGET=prod"})
+
+	assert.NoError(t, root.Execute())
+	assert.Contains(t, stdout.String(), "releasing 1.2.3 to prod")
+}
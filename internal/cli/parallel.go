@@ -11,7 +11,10 @@ import (
 	"time"
 
 	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/events"
 	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
 )
 
 // SafeWriter is a thread-safe writer implementation
@@ -93,89 +96,187 @@ func syncWrite(writer io.Writer, format string, args ...interface{}) {
 	}
 }
 
+// syncWritePrefixed writes output to the writer with every line prefixed, in a
+// thread-safe manner
+func syncWritePrefixed(writer io.Writer, prefix, output string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	prefixed := NewSafeWriter(writer, prefix)
+	_, _ = prefixed.Write([]byte(output))
+	if err := prefixed.Flush(); err != nil {
+		// Log the error but don't fail the command
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+	}
+}
+
 // outputMutex protects access to the shared output writer
 var outputMutex sync.Mutex
 
+// taskJob is a shell command that runs as one of several tasks of a command
+type taskJob struct {
+	ID      string            // Identifier used in messages and errors, e.g. #1
+	Task    int               // 1-based position of the job, used in events
+	Command string            // Command with variables resolved
+	Ref     string            // Command referenced by a task, executed instead of Command
+	Vars    map[string]string // Variables passed to the referenced command
+	Timeout time.Duration     // Timeout of the job instead of that of the command, 0 for that of the command
+}
+
+// timeout returns the timeout the job runs with, given the timeout of its command
+func (j taskJob) timeout(cmdTimeout time.Duration) time.Duration {
+	if j.Timeout > 0 {
+		return j.Timeout
+	}
+	return cmdTimeout
+}
+
 // executeParallelCommands executes multiple tasks in parallel
-func (h *CommandHandler) executeParallelCommands(cmdName string, cmd config.Command, timeout time.Duration) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(cmd.Tasks))
+func (h *CommandHandler) executeParallelCommands(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
+	return h.runParallelJobs(cmdName, cmd, h.taskJobs(cmdName, cmd, cmdVars), timeout)
+}
+
+// jobExecutor returns a new executor for a parallel job. Jobs of a command with a
+// runner get their own executor from the runner, others the environment and the
+// resource limits of the executor of the handler.
+func (h *CommandHandler) jobExecutor() (executor.CommandExecutor, error) {
+	if h.newJobExecutor != nil {
+		return h.newJobExecutor()
+	}
+	jobExec := executor.NewDefaultExecutor()
+	if envExec, ok := h.Executor.(executor.EnvExecutor); ok {
+		jobExec.SetEnv(envExec.GetEnv())
+	}
+	if limitExec, ok := h.Executor.(executor.LimitExecutor); ok {
+		jobExec.SetLimits(limitExec.GetLimits())
+	}
+	return jobExec, nil
+}
+
+// parallelism returns how many jobs of cmd may run at the same time: the lower of
+// its max_parallel and the --jobs flag, 0 for no limit
+func (h *CommandHandler) parallelism(cmd config.Command) int {
+	limit := cmd.MaxParallel
+	if h.Jobs > 0 && (limit <= 0 || h.Jobs < limit) {
+		limit = h.Jobs
+	}
+	return limit
+}
+
+// parallelMode describes how the parallel tasks of cmd run, with its max_parallel
+// and ordered_output
+func parallelMode(cmd config.Command) string {
+	mode := "parallel"
+	if cmd.MaxParallel > 0 {
+		mode += fmt.Sprintf(", at most %d at a time", cmd.MaxParallel)
+	}
+	if cmd.OrderedOutput {
+		mode += ", ordered output"
+	}
+	return mode
+}
+
+// validateMaxParallel checks the max_parallel of a command
+func (h *CommandHandler) validateMaxParallel(cmdName string, cmd config.Command) error {
+	if cmd.MaxParallel < 0 {
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid max_parallel %d: must be at least 1", cmd.MaxParallel), nil)
+	}
+	return nil
+}
+
+// jobOutput collects the output of a parallel job. When streaming, complete lines
+// are also written to writer with the prefix as they arrive, so the lines of
+// several jobs interleave but are never mixed up.
+type jobOutput struct {
+	writer  io.Writer // Destination of streamed lines, nil to only collect the output
+	prefix  string
+	output  strings.Builder // All output of the job
+	pending []byte          // Output after the last newline, not streamed yet
+	mutex   sync.Mutex
+}
 
-	// We'll use a mutex to protect access to the shared output writer
+// Write collects p and streams the lines it completes
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.output.Write(p)
+	if o.writer == nil {
+		return len(p), nil
+	}
+	o.pending = append(o.pending, p...)
+	if i := bytes.LastIndexByte(o.pending, '\n'); i >= 0 {
+		syncWritePrefixed(o.writer, o.prefix, string(o.pending[:i+1]))
+		o.pending = append([]byte(nil), o.pending[i+1:]...)
+	}
+	return len(p), nil
+}
+
+// Flush streams the last line if it has no trailing newline, and returns the
+// complete output of the job
+func (o *jobOutput) Flush() string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.writer != nil && len(o.pending) > 0 {
+		syncWritePrefixed(o.writer, o.prefix, string(o.pending))
+		o.pending = nil
+	}
+	return o.output.String()
+}
+
+// runParallelJobs runs the jobs of cmd in parallel with a pool of at most
+// parallelism(cmd) workers, and reports the failures of all jobs together. The
+// output lines of each job are prefixed with its ID and streamed as they arrive,
+// or with ordered_output printed per job in declaration order once all finished.
+func (h *CommandHandler) runParallelJobs(cmdName string, cmd config.Command, jobs []taskJob, timeout time.Duration) error {
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(jobs))
 
 	// Create a context with timeout if specified
 	var ctx context.Context
 	var cancel context.CancelFunc
+	runCtx := h.RunContext().Context
 	if timeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+		ctx, cancel = context.WithTimeout(runCtx, timeout)
 		defer cancel()
 	} else {
-		ctx, cancel = context.WithCancel(context.Background())
+		ctx, cancel = context.WithCancel(runCtx)
 		defer cancel()
 	}
 
-	// Start all tasks in parallel
-	for i, cmdStr := range cmd.Tasks {
+	// Start the workers, each of them runs queued jobs until the queue is empty
+	workers := len(jobs)
+	if limit := h.parallelism(cmd); limit > 0 && limit < workers {
+		workers = limit
+	}
+	outputs := make([]string, len(jobs))
+	queue := make(chan int)
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(index int, cmdStr string) {
+		go func() {
 			defer wg.Done()
-			
-			// Create a command ID for logging
-			cmdID := fmt.Sprintf("#%d", index+1)
-
-			// Replace variables in the command
-			cmdStr = h.replaceVariablesInString(cmdStr, nil)
-			// Log the command execution to stdout so it's visible in the main output
-			syncWrite(h.Executor.GetStdout(), "Executing parallel sub-command %s for '%s'...\n", cmdID, cmdName)
-
-			// Create a dedicated buffer for each command
-			cmdOutputBuffer := &bytes.Buffer{}
-
-			// Create a local executor with prefixed output
-			localExecutor := executor.NewDefaultExecutor()
-			localExecutor.SetStdout(cmdOutputBuffer)
-			localExecutor.SetStderr(cmdOutputBuffer)
-
-			// Use the syncWrite helper for thread-safe output
-			syncWrite(h.Executor.GetStdout(), "[%s] Starting execution...\n", cmdID)
-
-			// Create a channel for command completion
-			done := make(chan error, 1)
-			go func() {
-				// Execute the command and capture its output
-				_, err := localExecutor.ExecuteWithOutput(cmdStr, timeout)
-
-				// Get the buffered output
-				output := cmdOutputBuffer.String()
-
-				// Use the syncWrite helper for thread-safe output
-				if output != "" {
-					syncWrite(h.Executor.GetStdout(), "[%s] %s\n", cmdID, output)
-				}
-
-				// Send the error (if any) to the done channel
-				done <- err
-			}()
-
-			// Wait for command completion or timeout
-			select {
-			case err := <-done:
-				if err != nil {
-					errChan <- fmt.Errorf("sub-command %s for '%s' failed: %v", cmdID, cmdName, err)
-				}
-			case <-ctx.Done():
-
-				// Command timed out or context was canceled
-				errChan <- fmt.Errorf("sub-command %s for '%s' timed out after %s", cmdID, cmdName, timeout)
+			for index := range queue {
+				outputs[index] = h.runParallelJob(ctx, cmdName, jobs[index], !cmd.OrderedOutput, timeout, errChan)
 			}
-		}(i, cmdStr)
+		}()
 	}
+	for index := range jobs {
+		queue <- index
+	}
+	close(queue)
 
 	// Wait for all commands to finish
 	wg.Wait()
 	close(errChan)
 
-	// No need for any final output handling
+	if cmd.OrderedOutput {
+		for index, output := range outputs {
+			if output != "" {
+				syncWritePrefixed(h.Executor.GetStdout(), "["+jobs[index].ID+"] ", output)
+			}
+		}
+	}
 
 	// Collect errors
 	var errors []string
@@ -190,3 +291,77 @@ func (h *CommandHandler) executeParallelCommands(cmdName string, cmd config.Comm
 
 	return nil
 }
+
+// runParallelJob runs one job of runParallelJobs, sends its error, if any, to
+// errChan and returns its output. The output is also streamed if stream is set.
+// ctx is cancelled when the run is interrupted or the command times out.
+func (h *CommandHandler) runParallelJob(ctx context.Context, cmdName string, job taskJob, stream bool, timeout time.Duration, errChan chan<- error) string {
+	task, cmdID, cmdStr := job.Task, job.ID, job.Command
+	runCtx := h.RunContext().Context
+
+	// Jobs still queued when the run is interrupted or times out are not started
+	if ctx.Err() != nil {
+		if runCtx.Err() != nil {
+			errChan <- fmt.Errorf("sub-command %s for '%s' cancelled: %w", cmdID, cmdName, runCtx.Err())
+		} else {
+			errChan <- fmt.Errorf("sub-command %s for '%s' timed out after %s", cmdID, cmdName, timeout)
+		}
+		return ""
+	}
+
+	// Log the command execution to stdout so it's visible in the main output
+	syncWrite(h.Executor.GetStdout(), "Executing parallel sub-command %s for '%s'...\n", cmdID, cmdName)
+
+	// Collect the output of each command, streaming it unless it is printed later
+	cmdOutput := &jobOutput{prefix: "[" + cmdID + "] "}
+	if stream {
+		cmdOutput.writer = h.Executor.GetStdout()
+	}
+
+	// Create a local executor with prefixed output
+	localExecutor, err := h.jobExecutor()
+	if err != nil {
+		errChan <- fmt.Errorf("sub-command %s for '%s' failed: %v", cmdID, cmdName, err)
+		return ""
+	}
+	localExecutor.SetStdout(cmdOutput)
+	localExecutor.SetStderr(cmdOutput)
+
+	// Use the syncWrite helper for thread-safe output
+	syncWrite(h.Executor.GetStdout(), "[%s] Starting execution...\n", cmdID)
+
+	// Create a channel for command completion
+	done := make(chan error, 1)
+	go func() {
+		// Execute the command and capture its output
+		span := h.startSpan(taskSpanName(cmdName, task), cmdName, tracing.String("yxa.command", cmdName), tracing.Int("yxa.task", int64(task)))
+		_, err := executeWithOutputContext(localExecutor, runCtx, cmdStr, job.timeout(timeout))
+		endSpan(span, err)
+
+		if output := cmdOutput.Flush(); output != "" {
+			h.emit(events.Event{Type: events.TaskOutput, Command: cmdName, Task: task, Output: output})
+		}
+
+		// Send the error (if any) to the done channel
+		done <- err
+	}()
+
+	// Wait for command completion or timeout
+	select {
+	case err := <-done:
+		if err != nil {
+			errChan <- fmt.Errorf("sub-command %s for '%s' failed: %v", cmdID, cmdName, err)
+		}
+	case <-ctx.Done():
+		if runCtx.Err() != nil {
+			// The run was interrupted, wait for the command to be stopped
+			<-done
+			errChan <- fmt.Errorf("sub-command %s for '%s' cancelled: %w", cmdID, cmdName, runCtx.Err())
+			return cmdOutput.Flush()
+		}
+
+		// Command timed out
+		errChan <- fmt.Errorf("sub-command %s for '%s' timed out after %s", cmdID, cmdName, timeout)
+	}
+	return cmdOutput.Flush()
+}
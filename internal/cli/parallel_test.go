@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -111,10 +112,10 @@ func TestExecuteParallelCommands_Timeout(t *testing.T) {
 			"parallel-timeout": {
 				Parallel: true,
 				Timeout:  "100ms",
-				Tasks: []string{
+				Tasks: config.NewTaskList(
 					"sleep 1",
 					"sleep 1",
-				},
+				),
 			},
 		},
 	}
@@ -144,22 +145,21 @@ func TestExecuteParallelCommands(t *testing.T) {
 					"VAR2": "value2",
 				},
 			},
-			Executor:     realExec,
-			executedCmds: make(map[string]bool),
+			Executor: realExec,
 		}
 
 		// Create a command with parallel sub-commands
 		cmd := config.Command{
-			Tasks: []string{
+			Tasks: config.NewTaskList(
 				"echo $VAR1",
 				"echo $VAR2",
 				"echo test",
-			},
+			),
 			Parallel: true,
 		}
 
 		// Execute the parallel commands
-		err := handler.executeParallelCommands("test-parallel", cmd, 0)
+		err := handler.executeParallelCommands("test-parallel", cmd, nil, 0)
 		assert.NoError(t, err)
 
 		// Check the output contains the expected content
@@ -184,22 +184,21 @@ func TestExecuteParallelCommands(t *testing.T) {
 			Config: &config.ProjectConfig{
 				Variables: map[string]string{},
 			},
-			Executor:     realExec,
-			executedCmds: make(map[string]bool),
+			Executor: realExec,
 		}
 
 		// Create a command with parallel sub-commands, one of which will fail
 		cmd := config.Command{
-			Tasks: []string{
+			Tasks: config.NewTaskList(
 				"echo success1",
 				"false",
 				"echo success2",
-			},
+			),
 			Parallel: true,
 		}
 
 		// Execute the parallel commands
-		err := handler.executeParallelCommands("test-parallel-errors", cmd, 0)
+		err := handler.executeParallelCommands("test-parallel-errors", cmd, nil, 0)
 
 		// Should return an error
 		assert.Error(t, err)
@@ -232,22 +231,21 @@ func TestExecuteParallelCommands(t *testing.T) {
 			Config: &config.ProjectConfig{
 				Variables: map[string]string{},
 			},
-			Executor:     realExec,
-			executedCmds: make(map[string]bool),
+			Executor: realExec,
 		}
 
 		// Create a command with parallel sub-commands, one of which is slow
 		cmd := config.Command{
-			Tasks: []string{
+			Tasks: config.NewTaskList(
 				"echo quick1",
 				"sleep 2",
 				"echo quick2",
-			},
+			),
 			Parallel: true,
 		}
 
 		// Execute the parallel commands with a short timeout
-		err := handler.executeParallelCommands("test-parallel-timeout", cmd, 50*time.Millisecond)
+		err := handler.executeParallelCommands("test-parallel-timeout", cmd, nil, 50*time.Millisecond)
 
 		// Log the error for debugging
 		if err != nil {
@@ -295,3 +293,145 @@ func TestSafeWriterIntegration(t *testing.T) {
 		assert.Equal(t, expected, buf.String())
 	})
 }
+
+func TestCommandHandler_Parallelism(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxParallel int
+		jobs        int
+		want        int
+	}{
+		{"no limit", 0, 0, 0},
+		{"max_parallel only", 3, 0, 3},
+		{"jobs only", 0, 2, 2},
+		{"jobs is lower", 3, 2, 2},
+		{"max_parallel is lower", 2, 4, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &CommandHandler{Jobs: tt.jobs}
+			assert.Equal(t, tt.want, h.parallelism(config.Command{MaxParallel: tt.maxParallel}))
+		})
+	}
+}
+
+// TestExecuteParallelCommands_MaxParallel checks that no more tasks than allowed
+// run at the same time, by having every task log when it starts and ends
+func TestExecuteParallelCommands_MaxParallel(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxParallel int
+		jobs        int
+		want        int
+	}{
+		{"max_parallel", 2, 0, 2},
+		{"jobs", 3, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := t.TempDir() + "/log"
+			task := fmt.Sprintf("echo start >> %s; sleep 0.1; echo end >> %s", logPath, logPath)
+
+			buf := &bytes.Buffer{}
+			exec := executor.NewDefaultExecutor()
+			exec.SetStdout(buf)
+			exec.SetStderr(buf)
+			cfg := &config.ProjectConfig{
+				Name: "test-project",
+				Commands: map[string]config.Command{
+					"limited": {
+						Parallel:    true,
+						MaxParallel: tt.maxParallel,
+						Tasks:       config.NewTaskList(task, task, task, task, task),
+					},
+				},
+			}
+			handler := NewCommandHandler(cfg, exec)
+			handler.SetJobs(tt.jobs)
+			require.NoError(t, handler.ExecuteCommand("limited", nil))
+
+			log, err := os.ReadFile(logPath)
+			require.NoError(t, err)
+			running, peak := 0, 0
+			for _, line := range strings.Fields(string(log)) {
+				if line == "start" {
+					running++
+					peak = max(peak, running)
+				} else {
+					running--
+				}
+			}
+			assert.Equal(t, tt.want, peak)
+			assert.Equal(t, 5, strings.Count(buf.String(), "Starting execution"))
+		})
+	}
+
+	t.Run("invalid max_parallel", func(t *testing.T) {
+		cfg := &config.ProjectConfig{
+			Commands: map[string]config.Command{
+				"invalid": {Parallel: true, MaxParallel: -1, Tasks: config.NewTaskList("true")},
+			},
+		}
+		err := NewCommandHandler(cfg, executor.NewDefaultExecutor()).ExecuteCommand("invalid", nil)
+		assert.ErrorContains(t, err, "config error in command 'invalid': invalid max_parallel -1: must be at least 1")
+	})
+}
+
+func TestJobOutput(t *testing.T) {
+	t.Run("streams complete lines", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		o := &jobOutput{writer: buf, prefix: "[#1] "}
+		_, _ = o.Write([]byte("one\ntw"))
+		assert.Equal(t, "[#1] one\n", buf.String())
+		_, _ = o.Write([]byte("o\nthree"))
+		assert.Equal(t, "[#1] one\n[#1] two\n", buf.String())
+		assert.Equal(t, "one\ntwo\nthree", o.Flush())
+		assert.Equal(t, "[#1] one\n[#1] two\n[#1] three\n", buf.String())
+	})
+
+	t.Run("only collects without writer", func(t *testing.T) {
+		o := &jobOutput{prefix: "[#1] "}
+		_, _ = o.Write([]byte("one\ntwo\n"))
+		assert.Equal(t, "one\ntwo\n", o.Flush())
+	})
+}
+
+func TestExecuteParallelCommands_OutputOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		want    []string
+	}{
+		// The second task prints while the first one is still running
+		{"streamed", false, []string{"[#1] a1", "[#2] b1", "[#1] a2"}},
+		// The first task is printed first although the second one finishes earlier
+		{"ordered", true, []string{"[#1] a1", "[#1] a2", "[#2] b1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			exec := executor.NewDefaultExecutor()
+			exec.SetStdout(buf)
+			exec.SetStderr(buf)
+			cfg := &config.ProjectConfig{
+				Name: "test-project",
+				Commands: map[string]config.Command{
+					"both": {
+						Parallel:      true,
+						OrderedOutput: tt.ordered,
+						Tasks:         config.NewTaskList("echo a1; sleep 0.4; echo a2", "sleep 0.2; echo b1"),
+					},
+				},
+			}
+			require.NoError(t, NewCommandHandler(cfg, exec).ExecuteCommand("both", nil))
+
+			var lines []string
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if strings.HasPrefix(line, "[#") && !strings.Contains(line, "Starting execution") {
+					lines = append(lines, line)
+				}
+			}
+			assert.Equal(t, tt.want, lines)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/floppa/yxa-cli/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // addParametersToCommand adds parameters as flags to a cobra command
@@ -41,33 +42,63 @@ func addParametersToCommand(cmd *cobra.Command, params []config.Param) {
 		}
 		
 		// Register all other parameters as flags
-		registerFlagForParam(cmd, param)
+		registerFlagForParam(cmd.Flags(), param)
+		markRequiredFlag(cmd, param.Name, requiredUnasked(param))
 	}
 }
 
-// registerFlagForParam handles flag registration and required marking for a parameter
-func registerFlagForParam(cmd *cobra.Command, param config.Param) {
+// addPersistentParametersToCommand adds flag parameters as persistent flags so that
+// they are inherited by the command's subcommands. Positional parameters only apply
+// to the command itself and are registered as usual.
+func addPersistentParametersToCommand(cmd *cobra.Command, params []config.Param) {
+	for _, param := range inheritableParams(params) {
+		registerFlagForParam(cmd.PersistentFlags(), param)
+		markRequiredPersistentFlag(cmd, param.Name, requiredUnasked(param))
+	}
+}
+
+// requiredUnasked reports whether cobra must reject a command without the flag of
+// a parameter. A required parameter with choices is asked for instead, see
+// askParamChoices.
+func requiredUnasked(param config.Param) bool {
+	return param.Required && len(param.Choices) == 0
+}
+
+// inheritableParams returns the parameters that are registered as flags and can
+// therefore be inherited by subcommands
+func inheritableParams(params []config.Param) []config.Param {
+	var inheritable []config.Param
+	for _, param := range params {
+		if !param.Flag && param.Position > 0 {
+			continue
+		}
+		inheritable = append(inheritable, param)
+	}
+	return inheritable
+}
+
+// registerFlagForParam registers a parameter as a typed flag in the given flag set
+func registerFlagForParam(flags *pflag.FlagSet, param config.Param) {
 	name, shorthand := processParamName(param.Name)
 	switch strings.ToLower(param.Type) {
 	case "string":
-		addStringFlag(cmd, name, shorthand, param.Default, param.Description)
+		addStringFlag(flags, name, shorthand, param.Default, param.Description)
 	case "int":
-		addIntFlag(cmd, name, shorthand, param.Default, param.Description, param.Name)
+		addIntFlag(flags, name, shorthand, param.Default, param.Description, param.Name)
 	case "float":
-		addFloatFlag(cmd, name, shorthand, param.Default, param.Description, param.Name)
+		addFloatFlag(flags, name, shorthand, param.Default, param.Description, param.Name)
 	case "bool":
-		addBoolFlag(cmd, name, shorthand, param.Default, param.Description, param.Name)
+		addBoolFlag(flags, name, shorthand, param.Default, param.Description, param.Name)
 	default:
-		addStringFlag(cmd, name, shorthand, param.Default, param.Description)
+		addStringFlag(flags, name, shorthand, param.Default, param.Description)
 	}
-	markRequiredFlag(cmd, name, param.Required)
 }
 
-func addStringFlag(cmd *cobra.Command, name, shorthand, def, desc string) {
-	cmd.Flags().StringP(name, shorthand, def, desc)
+func addStringFlag(flags *pflag.FlagSet, name, shorthand, def, desc string) {
+	flags.StringP(name, shorthand, def, desc)
 }
 
-func addIntFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramName string) {
+func addIntFlag(flags *pflag.FlagSet, name, shorthand, def, desc string, paramName string) {
 	defaultVal := 0
 	if def != "" {
 		var err error
@@ -76,10 +107,10 @@ func addIntFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramName
 			fmt.Printf("Warning: Invalid default value '%s' for int parameter '%s', using 0\n", def, paramName)
 		}
 	}
-	cmd.Flags().IntP(name, shorthand, defaultVal, desc)
+	flags.IntP(name, shorthand, defaultVal, desc)
 }
 
-func addFloatFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramName string) {
+func addFloatFlag(flags *pflag.FlagSet, name, shorthand, def, desc string, paramName string) {
 	defaultVal := 0.0
 	if def != "" {
 		var err error
@@ -88,10 +119,10 @@ func addFloatFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramNa
 			fmt.Printf("Warning: Invalid default value '%s' for float parameter '%s', using 0.0\n", def, paramName)
 		}
 	}
-	cmd.Flags().Float64P(name, shorthand, defaultVal, desc)
+	flags.Float64P(name, shorthand, defaultVal, desc)
 }
 
-func addBoolFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramName string) {
+func addBoolFlag(flags *pflag.FlagSet, name, shorthand, def, desc string, paramName string) {
 	defaultVal := false
 	if def != "" {
 		var err error
@@ -100,10 +131,21 @@ func addBoolFlag(cmd *cobra.Command, name, shorthand, def, desc string, paramNam
 			fmt.Printf("Warning: Invalid default value '%s' for bool parameter '%s', using false\n", def, paramName)
 		}
 	}
-	cmd.Flags().BoolP(name, shorthand, defaultVal, desc)
+	flags.BoolP(name, shorthand, defaultVal, desc)
+
+	// --no-<name> sets the parameter to false, e.g. to turn off one that defaults to true
+	negated := negatedFlagPrefix + name
+	if flags.Lookup(negated) == nil {
+		flags.Bool(negated, false, "Set --"+name+" to false")
+		_ = flags.MarkHidden(negated)
+	}
 }
 
-func markRequiredFlag(cmd *cobra.Command, name string, required bool) {
+// negatedFlagPrefix prefixes the name of the flag that sets a bool parameter to false
+const negatedFlagPrefix = "no-"
+
+func markRequiredFlag(cmd *cobra.Command, paramName string, required bool) {
+	name, _ := processParamName(paramName)
 	if required {
 		if err := cmd.MarkFlagRequired(name); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to mark flag '%s' as required: %v\n", name, err)
@@ -111,6 +153,15 @@ func markRequiredFlag(cmd *cobra.Command, name string, required bool) {
 	}
 }
 
+func markRequiredPersistentFlag(cmd *cobra.Command, paramName string, required bool) {
+	name, _ := processParamName(paramName)
+	if required {
+		if err := cmd.MarkPersistentFlagRequired(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to mark flag '%s' as required: %v\n", name, err)
+		}
+	}
+}
+
 // processParameters processes command parameters and returns a map of parameter values
 func processParameters(cmd *cobra.Command, args []string, params []config.Param) (map[string]string, error) {
 	paramVars := make(map[string]string)
@@ -129,6 +180,10 @@ func processParameters(cmd *cobra.Command, args []string, params []config.Param)
 		return nil, err
 	}
 
+	if err := validateParamGroups(cmd, args, params); err != nil {
+		return nil, err
+	}
+
 	return paramVars, nil
 }
 
@@ -143,6 +198,33 @@ func collectPositionalParams(params []config.Param) map[int]config.Param {
 	return posParams
 }
 
+// mergeParams returns the parameters of a subcommand: the flag parameters it
+// inherits from its parents, outermost first, followed by its own. A parameter
+// replaces an earlier one with the same flag name, so the subcommand overrides its
+// parents and closer parents override outer ones. Inherited parameters are
+// registered as flags on the subcommand and are returned as flag parameters.
+func mergeParams(inherited, own []config.Param) []config.Param {
+	var merged []config.Param
+	index := make(map[string]int)
+	add := func(param config.Param) {
+		name, _ := processParamName(param.Name)
+		if i, ok := index[name]; ok {
+			merged[i] = param
+			return
+		}
+		index[name] = len(merged)
+		merged = append(merged, param)
+	}
+	for _, param := range inheritableParams(inherited) {
+		param.Flag = true
+		add(param)
+	}
+	for _, param := range own {
+		add(param)
+	}
+	return merged
+}
+
 // extractFlagParameters extracts flag parameters and fills paramVars
 func extractFlagParameters(cmd *cobra.Command, params []config.Param, paramVars map[string]string) error {
 	for _, param := range params {
@@ -153,6 +235,9 @@ func extractFlagParameters(cmd *cobra.Command, params []config.Param, paramVars
 		if err != nil {
 			return err
 		}
+		if err := validateParamChoice(param, value); err != nil {
+			return err
+		}
 		paramVars[param.Name] = value
 	}
 	return nil
@@ -181,11 +266,7 @@ func processFlagParameter(cmd *cobra.Command, param config.Param) (string, error
 			return "", fmt.Errorf("error getting float parameter '%s': %w", name, err)
 		}
 	case "bool":
-		if val, err := cmd.Flags().GetBool(name); err == nil {
-			return strconv.FormatBool(val), nil
-		} else {
-			return "", fmt.Errorf("error getting bool parameter '%s': %w", name, err)
-		}
+		return processBoolParameter(cmd, name)
 	default:
 		if val, err := cmd.Flags().GetString(name); err == nil {
 			return val, nil
@@ -195,11 +276,32 @@ func processFlagParameter(cmd *cobra.Command, param config.Param) (string, error
 	}
 }
 
+// processBoolParameter returns the value of a bool parameter, which --<name> sets to
+// true and --no-<name> to false
+func processBoolParameter(cmd *cobra.Command, name string) (string, error) {
+	val, err := cmd.Flags().GetBool(name)
+	if err != nil {
+		return "", fmt.Errorf("error getting bool parameter '%s': %w", name, err)
+	}
+	negated := cmd.Flags().Lookup(negatedFlagPrefix + name)
+	if negated == nil || !negated.Changed || negated.Value.String() != "true" {
+		return strconv.FormatBool(val), nil
+	}
+	if cmd.Flags().Changed(name) && val {
+		return "", fmt.Errorf("--%s and --%s%s cannot be used together", name, negatedFlagPrefix, name)
+	}
+	return "false", nil
+}
+
 // extractPositionalParameters extracts positional parameters from args and fills paramVars
 func extractPositionalParameters(args []string, posParams map[int]config.Param, paramVars map[string]string) error {
 	for i, arg := range args {
 		if param, ok := posParams[i]; ok {
-			paramVars[param.Name] = processPositionalParameter(arg, param)
+			value := processPositionalParameter(arg, param)
+			if err := validateParamChoice(param, value); err != nil {
+				return err
+			}
+			paramVars[param.Name] = value
 		}
 	}
 	return nil
@@ -210,6 +312,21 @@ func processPositionalParameter(arg string, param config.Param) string {
 	return arg
 }
 
+// validateParamChoice ensures the value of a parameter with choices is one of them.
+// An empty value means the parameter was not provided.
+func validateParamChoice(param config.Param, value string) error {
+	if len(param.Choices) == 0 || value == "" {
+		return nil
+	}
+	for _, choice := range param.Choices {
+		if value == choice {
+			return nil
+		}
+	}
+	name, _ := processParamName(param.Name)
+	return fmt.Errorf("invalid value '%s' for parameter '%s': expected one of %s", value, name, strings.Join(param.Choices, ", "))
+}
+
 // validateRequiredPositionalParameters ensures all required positional parameters are provided
 func validateRequiredPositionalParameters(posParams map[int]config.Param, args []string) error {
 	for pos, param := range posParams {
@@ -220,6 +337,89 @@ func validateRequiredPositionalParameters(posParams map[int]config.Param, args [
 	return nil
 }
 
+// validateParamGroups rejects combinations of parameters that their conflicts_with
+// and requires forbid, before they turn into a nonsense invocation
+func validateParamGroups(cmd *cobra.Command, args []string, params []config.Param) error {
+	byName := make(map[string]config.Param, len(params))
+	for _, param := range params {
+		name, _ := processParamName(param.Name)
+		byName[name] = param
+	}
+	given := func(name string) bool {
+		param, ok := byName[name]
+		return ok && paramGiven(cmd, args, param)
+	}
+
+	for _, param := range params {
+		if !paramGiven(cmd, args, param) {
+			continue
+		}
+		for _, other := range param.ConflictsWith {
+			if given(other) {
+				return fmt.Errorf("%s cannot be used together with %s", paramLabel(param), paramLabelOf(byName, other))
+			}
+		}
+		for _, other := range param.Requires {
+			if !given(other) {
+				return fmt.Errorf("%s requires %s", paramLabel(param), paramLabelOf(byName, other))
+			}
+		}
+	}
+	return nil
+}
+
+// validateParamReferences checks that conflicts_with and requires only name
+// parameters of the command
+func validateParamReferences(params []config.Param) error {
+	names := make(map[string]bool, len(params))
+	for _, param := range params {
+		name, _ := processParamName(param.Name)
+		names[name] = true
+	}
+	for _, param := range params {
+		name, _ := processParamName(param.Name)
+		for _, other := range append(append([]string(nil), param.ConflictsWith...), param.Requires...) {
+			if !names[other] {
+				return fmt.Errorf("parameter '%s' refers to unknown parameter '%s' in conflicts_with or requires", name, other)
+			}
+		}
+	}
+	return nil
+}
+
+// paramGiven reports whether a parameter was given on the command line. A bool
+// parameter counts only when it is true, so that --no-<name> does not.
+func paramGiven(cmd *cobra.Command, args []string, param config.Param) bool {
+	if !param.Flag && param.Position >= 0 {
+		return param.Position < len(args)
+	}
+	name, _ := processParamName(param.Name)
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil || !flag.Changed {
+		return false
+	}
+	return strings.ToLower(param.Type) != "bool" || flag.Value.String() == "true"
+}
+
+// paramLabel returns how a parameter is written on the command line: --name for
+// flags and <name> for positional parameters
+func paramLabel(param config.Param) string {
+	name, _ := processParamName(param.Name)
+	if !param.Flag && param.Position >= 0 {
+		return "<" + name + ">"
+	}
+	return "--" + name
+}
+
+// paramLabelOf returns the label of the parameter of the given name, or the name
+// itself if the command has no such parameter
+func paramLabelOf(params map[string]config.Param, name string) string {
+	if param, ok := params[name]; ok {
+		return paramLabel(param)
+	}
+	return "parameter '" + name + "'"
+}
+
 // processParamName extracts name and shorthand from the parameter name
 func processParamName(paramName string) (name, shorthand string) {
 	parts := []string{paramName}
@@ -237,3 +437,19 @@ func processParamName(paramName string) (name, shorthand string) {
 
 	return name, shorthand
 }
+
+// paramDefaultValue returns the value a parameter has when it is not provided,
+// formatted the same way as a provided value
+func paramDefaultValue(param config.Param) string {
+	if param.Default != "" {
+		return param.Default
+	}
+	switch strings.ToLower(param.Type) {
+	case "int", "float":
+		return "0"
+	case "bool":
+		return "false"
+	default:
+		return ""
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/floppa/yxa-cli/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcessParamName(t *testing.T) {
@@ -454,3 +455,120 @@ func TestProcessParameters(t *testing.T) {
 	assert.NoError(t, err) // Should not error, just use the value as-is
 	assert.Equal(t, "not-an-int", paramVars["second-pos"])
 }
+
+func TestProcessParameters_Choices(t *testing.T) {
+	cmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+	params := []config.Param{
+		{Name: "env", Type: "string", Flag: true, Choices: []string{"staging", "prod"}},
+		{Name: "region", Type: "string", Position: 1, Choices: []string{"eu", "us"}},
+	}
+	addParametersToCommand(cmd, params)
+
+	// Parameters that are not provided are not checked
+	_, err := processParameters(cmd, nil, params)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cmd.Flags().Set("env", "prod"))
+	paramVars, err := processParameters(cmd, []string{"first", "eu"}, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", paramVars["env"])
+	assert.Equal(t, "eu", paramVars["region"])
+
+	_, err = processParameters(cmd, []string{"first", "asia"}, params)
+	assert.EqualError(t, err, "invalid value 'asia' for parameter 'region': expected one of eu, us")
+
+	assert.NoError(t, cmd.Flags().Set("env", "dev"))
+	_, err = processParameters(cmd, nil, params)
+	assert.EqualError(t, err, "invalid value 'dev' for parameter 'env': expected one of staging, prod")
+}
+
+func TestMergeParams(t *testing.T) {
+	inherited := []config.Param{
+		{Name: "env|e", Type: "string", Default: "dev", Flag: true},
+		{Name: "verbose", Type: "bool", Flag: true},
+		{Name: "target", Type: "string", Position: 1},
+		{Name: "region", Type: "string", Default: "eu", Flag: true},
+	}
+	own := []config.Param{
+		{Name: "file", Type: "string", Position: 0},
+		{Name: "env", Type: "int", Default: "3", Flag: true},
+	}
+
+	merged := mergeParams(inherited, own)
+	names := make([]string, len(merged))
+	for i, param := range merged {
+		names[i] = param.Name
+	}
+	// Positional parameters of parents are not inherited, and the own env replaces
+	// the inherited one in place
+	assert.Equal(t, []string{"env", "verbose", "region", "file"}, names)
+	assert.Equal(t, "int", merged[0].Type)
+	assert.Equal(t, "3", merged[0].Default)
+	assert.True(t, merged[1].Flag)
+	assert.False(t, merged[3].Flag)
+
+	assert.Empty(t, mergeParams(nil, nil))
+	assert.Equal(t, own, mergeParams(nil, own))
+}
+
+func TestProcessParameters_BoolNegation(t *testing.T) {
+	params := []config.Param{
+		{Name: "verbose", Type: "bool", Flag: true},
+		{Name: "cache", Type: "bool", Flag: true, Default: "true"},
+	}
+	parse := func(t *testing.T, args ...string) (map[string]string, error) {
+		t.Helper()
+		cmd := &cobra.Command{Use: "build", Run: func(cmd *cobra.Command, args []string) {}}
+		addParametersToCommand(cmd, params)
+		require.NoError(t, cmd.ParseFlags(args))
+		return processParameters(cmd, cmd.Flags().Args(), params)
+	}
+
+	paramVars, err := parse(t)
+	require.NoError(t, err)
+	assert.Equal(t, "false", paramVars["verbose"])
+	assert.Equal(t, "true", paramVars["cache"])
+
+	paramVars, err = parse(t, "--verbose", "--no-cache")
+	require.NoError(t, err)
+	assert.Equal(t, "true", paramVars["verbose"])
+	assert.Equal(t, "false", paramVars["cache"])
+
+	paramVars, err = parse(t, "--no-verbose")
+	require.NoError(t, err)
+	assert.Equal(t, "false", paramVars["verbose"])
+
+	_, err = parse(t, "--cache", "--no-cache")
+	assert.EqualError(t, err, "--cache and --no-cache cannot be used together")
+
+	cmd := &cobra.Command{Use: "build"}
+	addParametersToCommand(cmd, params)
+	assert.True(t, cmd.Flags().Lookup("no-cache").Hidden, "negated flags are not listed in the help")
+}
+
+func TestProcessParameters_Groups(t *testing.T) {
+	params := []config.Param{
+		{Name: "staging", Type: "bool", Flag: true, ConflictsWith: []string{"prod"}},
+		{Name: "prod", Type: "bool", Flag: true, ConflictsWith: []string{"staging"}},
+		{Name: "tag|t", Type: "string", Flag: true, Requires: []string{"push"}},
+		{Name: "push", Type: "bool", Flag: true},
+		{Name: "target", Type: "string", Position: 0, ConflictsWith: []string{"prod"}},
+	}
+	parse := func(t *testing.T, args ...string) error {
+		t.Helper()
+		cmd := &cobra.Command{Use: "deploy", Run: func(cmd *cobra.Command, args []string) {}}
+		addParametersToCommand(cmd, params)
+		require.NoError(t, cmd.ParseFlags(args))
+		_, err := processParameters(cmd, cmd.Flags().Args(), params)
+		return err
+	}
+
+	assert.NoError(t, parse(t, "--staging"))
+	assert.NoError(t, parse(t, "--prod", "--no-staging"))
+	assert.NoError(t, parse(t, "-t", "v1", "--push"))
+	assert.EqualError(t, parse(t, "--staging", "--prod"), "--staging cannot be used together with --prod")
+	assert.EqualError(t, parse(t, "--tag", "v1"), "--tag requires --push")
+	assert.EqualError(t, parse(t, "--prod", "web"), "<target> cannot be used together with --prod")
+	assert.Error(t, parse(t, "--staging=true", "--prod"))
+	assert.NoError(t, parse(t, "--staging=false", "--prod"))
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
+)
+
+// validatePipe checks that a command with pipe has sequential tasks that run
+// shell commands
+func (h *CommandHandler) validatePipe(cmdName string, cmd config.Command) error {
+	if !cmd.Pipe {
+		return nil
+	}
+	if len(cmd.Tasks) == 0 {
+		return errors.NewCommandConfigError(cmdName, "uses pipe, which requires 'tasks'", nil)
+	}
+	if cmd.Parallel {
+		return errors.NewCommandConfigError(cmdName, "cannot combine pipe and parallel", nil)
+	}
+	if cmd.ContinueOnError {
+		return errors.NewCommandConfigError(cmdName, "cannot combine pipe and continue_on_error", nil)
+	}
+	for i, task := range cmd.Tasks {
+		if task.Task != "" {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d references a command, which cannot be piped", i+1), nil)
+		}
+	}
+	return nil
+}
+
+// runPipeline runs the jobs at the same time, with the stdout of each job streamed
+// into the stdin of the next, like a shell pipeline. The first job reads the stdin
+// of yxa and the output of the last job is shown. The pipeline fails if any job
+// fails, as with 'set -o pipefail'.
+func (h *CommandHandler) runPipeline(cmdName string, jobs []taskJob, timeout time.Duration) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	executors := make([]executor.CommandExecutor, len(jobs))
+	for i := range jobs {
+		jobExec, err := h.jobExecutor()
+		if err != nil {
+			return err
+		}
+		if _, ok := jobExec.(executor.StdinExecutor); !ok {
+			return fmt.Errorf("command '%s' cannot pipe tasks with this executor", cmdName)
+		}
+		jobExec.SetStderr(lockWriter(h.Executor.GetStderr()))
+		executors[i] = jobExec
+	}
+
+	// Connect every job to the next one
+	readers := make([]*io.PipeReader, len(jobs)-1)
+	writers := make([]*io.PipeWriter, len(jobs)-1)
+	for i := range writers {
+		readers[i], writers[i] = io.Pipe()
+		executors[i].SetStdout(writers[i])
+		executors[i+1].(executor.StdinExecutor).SetStdin(readers[i])
+	}
+	executors[len(jobs)-1].SetStdout(lockWriter(h.Executor.GetStdout()))
+
+	runCtx := h.RunContext().Context
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		h.printf("Executing piped sub-command %s for '%s'...\n", job.ID, cmdName)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			span := h.startSpan(taskSpanName(cmdName, job.Task), cmdName, tracing.String("yxa.command", cmdName), tracing.Int("yxa.task", int64(job.Task)))
+			errs[i] = executeContext(executors[i], runCtx, job.Command, job.timeout(timeout))
+			endSpan(span, errs[i])
+
+			// The next job reads to the end of its input, and the previous one
+			// stops writing to a job that no longer reads
+			if i < len(writers) {
+				_ = writers[i].Close()
+			}
+			if i > 0 {
+				_ = readers[i-1].Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", jobs[i].ID, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("pipeline of '%s' failed: %s", cmdName, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// lockedWriter writes to a writer that the jobs of a pipeline share, one write at
+// a time
+type lockedWriter struct {
+	writer io.Writer
+}
+
+// Write writes p while holding outputMutex
+func (w lockedWriter) Write(p []byte) (int, error) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return w.writer.Write(p)
+}
+
+// lockWriter returns a writer that can be shared by jobs. Files are safe for
+// concurrent writes, so they are returned as they are.
+func lockWriter(w io.Writer) io.Writer {
+	if _, ok := w.(*os.File); ok {
+		return w
+	}
+	return lockedWriter{writer: w}
+}
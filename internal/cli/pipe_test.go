@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Pipe(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"WORD": "banana"},
+		Commands: map[string]config.Command{
+			"count": {
+				Pipe:  true,
+				Tasks: config.TaskList{{Run: "printf 'cherry\\n$WORD\\napple\\n$WORD\\n'"}, {Run: "sort"}, {Run: "uniq -c"}, {Run: "sed 's/^ *//'"}},
+			},
+			"head": {
+				Pipe:  true,
+				Tasks: config.TaskList{{Run: "printf 'a\\nb\\nc\\n'"}, {Run: "head -n 1"}},
+			},
+			"fail": {
+				Pipe:  true,
+				Tasks: config.TaskList{{Run: "echo data; exit 3"}, {Run: "cat"}},
+			},
+			"slow": {
+				Pipe:  true,
+				Tasks: config.TaskList{{Run: "sleep 0.2; echo done"}, {Run: "cat"}},
+			},
+			"ref":      {Pipe: true, Tasks: config.TaskList{{Run: "echo"}, {Task: "head"}}},
+			"parallel": {Pipe: true, Parallel: true, Tasks: config.TaskList{{Run: "echo"}}},
+			"no-tasks": {Pipe: true, Run: "echo"},
+		},
+	}
+
+	run := func(t *testing.T, name string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		exec.SetStderr(&out)
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand(name, nil)
+		return out.String(), err
+	}
+
+	t.Run("pipeline", func(t *testing.T) {
+		out, err := run(t, "count")
+		require.NoError(t, err)
+		assert.Contains(t, out, "1 apple\n2 banana\n1 cherry\n")
+	})
+
+	t.Run("reader that stops early", func(t *testing.T) {
+		out, err := run(t, "head")
+		require.NoError(t, err)
+		assert.Equal(t, "a\n", out)
+	})
+
+	t.Run("tasks run at the same time", func(t *testing.T) {
+		start := time.Now()
+		out, err := run(t, "slow")
+		require.NoError(t, err)
+		assert.Contains(t, out, "done\n")
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("failing task", func(t *testing.T) {
+		out, err := run(t, "fail")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pipeline of 'fail' failed: #1: exit status 3")
+		assert.Contains(t, out, "data\n", "the output reaches the next task")
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("head", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would execute (piped): printf 'a\\nb\\nc\\n'\n[dry-run] Would execute (piped): head -n 1\n")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := run(t, "ref")
+		assert.EqualError(t, err, "config error in command 'ref': task #2 references a command, which cannot be piped")
+		_, err = run(t, "parallel")
+		assert.EqualError(t, err, "config error in command 'parallel': cannot combine pipe and parallel")
+		_, err = run(t, "no-tasks")
+		assert.EqualError(t, err, "config error in command 'no-tasks': uses pipe, which requires 'tasks'")
+	})
+}
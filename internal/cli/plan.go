@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/steps"
+)
+
+// planStep describes what the handler would do for a single command
+type planStep struct {
+	Name           string              // Command name (parent:sub for subcommands)
+	Command        config.Command      // Command configuration
+	Depth          int                 // Dependency depth, 0 for the requested command
+	Duplicate      bool                // Already planned earlier in this run, so it will not run again
+	Condition      string              // Condition with variables resolved
+	SkippedDepends []config.Dependency // Dependencies that do not run because their condition is not met
+	ConditionMet   bool                // Result of the condition (true if there is none or it is not evaluated)
+	NotEvaluated   bool                // Condition runs a command, so it is only evaluated when the command runs
+	Pre            string              // Pre-hook with variables resolved
+	Run            string              // Run string with variables resolved
+	Tasks          []planTask          // Tasks with variables resolved and conditions evaluated
+	Matrix         []taskJob           // Run string expanded for every matrix combination
+	Foreach        []taskJob           // Run string expanded for every path matched by foreach
+	ForeachErr     error               // Error expanding the foreach pattern, if any
+	Steps          []string            // Descriptions of the script steps with variables resolved
+	StepsErr       error               // Error creating the script steps, if any
+	Post           string              // Post-hook with variables resolved
+	OnCancel       string              // on_cancel hook with variables resolved
+	Timeout        time.Duration       // Parsed timeout, 0 if none
+	TimeoutErr     error               // Error parsing the timeout, if any
+	WorkingDir     string              // Configured working directory, if any
+	Runner         string              // Description of the runner the main command runs with, if any
+	LogFile        string              // Path of the log_file with variables resolved, if any
+	StderrFile     string              // Path of the stderr_file with variables resolved, if any
+	UpToDate       bool                // Files the command generates are newer than its sources, so it is skipped
+	UpToDateErr    error               // Error checking whether the command is up to date, if any
+	HasSubcommands bool                // Command is a group that lists its subcommands
+}
+
+// buildPlan walks a command and its dependencies in execution order without running
+// anything and returns one step per visited command
+func (h *CommandHandler) buildPlan(cmdName string, cmdVars map[string]string) ([]planStep, error) {
+	return h.buildPlanFrom(cmdName, cmdVars, nil)
+}
+
+// buildPlanFrom is like buildPlan, but treats the commands in alreadyExecuted as
+// executed earlier in this run. With NoDedupe set, commands are planned again every
+// time they are reached.
+func (h *CommandHandler) buildPlanFrom(cmdName string, cmdVars map[string]string, alreadyExecuted map[string]bool) ([]planStep, error) {
+	// The commands that after waits for are only known once the run is planned
+	var scheduled map[string]bool
+	if h.usesAfter() {
+		scheduled = h.scheduledCommands(cmdName, cmdVars)
+	}
+	return h.planSteps(cmdName, cmdVars, alreadyExecuted, scheduled)
+}
+
+// planSteps is buildPlanFrom, with the commands that are planned before the
+// commands that run after them if they are in scheduled
+func (h *CommandHandler) planSteps(cmdName string, cmdVars map[string]string, alreadyExecuted, scheduled map[string]bool) ([]planStep, error) {
+	var steps []planStep
+	planned := make(map[string]bool)
+	// Commands whose output is captured for needs, which only run once per run
+	captured := make(map[string]bool)
+	for name, executed := range alreadyExecuted {
+		planned[name] = executed
+	}
+
+	// Commands currently being visited, to detect cycles
+	var active []string
+
+	var visit func(name string, depth int, cmdVars map[string]string) error
+	visit = func(name string, depth int, cmdVars map[string]string) error {
+		cmd, err := h.lookupCommand(name)
+		if err != nil {
+			return err
+		}
+
+		for i, activeName := range active {
+			if activeName == name {
+				path := append(append([]string{}, active[i:]...), name)
+				return fmt.Errorf("circular dependency detected: %s", strings.Join(path, " -> "))
+			}
+		}
+		if planned[name] && !h.NoDedupe {
+			steps = append(steps, planStep{Name: name, Command: cmd, Depth: depth, Duplicate: true})
+			return nil
+		}
+		planned[name] = true
+		active = append(active, name)
+		defer func() {
+			active = active[:len(active)-1]
+		}()
+
+		vars := h.withParamDefaults(name, cmd, cmdVars)
+		step := h.planCommand(name, cmd, vars, depth)
+
+		// A command whose condition is not met is skipped together with its dependencies
+		if !step.ConditionMet {
+			steps = append(steps, step)
+			return nil
+		}
+
+		// Dependencies run before the command itself
+		for _, dep := range cmd.Depends {
+			if met, _ := h.plannedCondition(name, dep.Condition, vars); !met {
+				continue
+			}
+			if err := visit(dep.Command, depth+1, vars); err != nil {
+				return err
+			}
+		}
+
+		// The commands it needs run next, even if they ran before, to capture their output
+		for _, varName := range cmd.NeededVars() {
+			need := cmd.Needs[varName]
+			if captured[need] {
+				neededCmd, err := h.lookupCommand(need)
+				if err != nil {
+					return err
+				}
+				steps = append(steps, planStep{Name: need, Command: neededCmd, Depth: depth + 1, Duplicate: true})
+				continue
+			}
+			captured[need] = true
+			planned[need] = false
+			if err := visit(need, depth+1, vars); err != nil {
+				return err
+			}
+		}
+
+		// The commands it runs after go first if the run executes them anyway
+		for _, after := range cmd.After {
+			if scheduled[after] && !planned[after] {
+				if err := visit(after, depth+1, vars); err != nil {
+					return err
+				}
+			}
+		}
+
+		steps = append(steps, step)
+		return nil
+	}
+
+	if err := visit(cmdName, 0, cmdVars); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// planCommand resolves everything the handler would use to execute a single command
+func (h *CommandHandler) planCommand(cmdName string, cmd config.Command, cmdVars map[string]string, depth int) planStep {
+	step := planStep{
+		Name:           cmdName,
+		Command:        cmd,
+		Depth:          depth,
+		ConditionMet:   true,
+		HasSubcommands: len(cmd.Commands) > 0,
+		WorkingDir:     cmd.WorkingDir,
+	}
+
+	if step.WorkingDir == "" {
+		step.WorkingDir = h.Config.WorkingDir
+	}
+
+	// Plans show resolved values, which must not reveal sensitive ones
+	h.registerSensitive(cmdName, cmd, cmdVars)
+
+	if cmd.Condition != "" {
+		step.Condition = h.replaceVariablesInString(cmdName, cmd.Condition, cmdVars)
+		met, evaluated := h.plannedCondition(cmdName, cmd.Condition, cmdVars)
+		step.ConditionMet, step.NotEvaluated = met, !evaluated
+	}
+	for _, dep := range cmd.Depends {
+		if met, _ := h.plannedCondition(cmdName, dep.Condition, cmdVars); !met {
+			step.SkippedDepends = append(step.SkippedDepends, dep)
+		}
+	}
+
+	step.Pre = h.replaceVariablesInString(cmdName, cmd.Pre, cmdVars)
+	step.Run = h.replaceVariablesInString(cmdName, cmd.Run, cmdVars)
+	step.Runner = h.describeRunner(cmdName, cmd, cmdVars)
+	if cmd.LogFile != nil {
+		step.LogFile = h.logFilePath(cmdName, cmd.LogFile, cmdVars)
+	}
+	if cmd.StderrFile != nil {
+		step.StderrFile = h.logFilePath(cmdName, cmd.StderrFile, cmdVars)
+	}
+	step.Post = h.replaceVariablesInString(cmdName, cmd.Post, cmdVars)
+	step.OnCancel = h.replaceVariablesInString(cmdName, cmd.OnCancel, cmdVars)
+	step.Tasks = h.planTasks(cmdName, cmd, cmdVars)
+	if cmd.Run != "" {
+		step.Matrix = h.matrixJobs(cmdName, cmd, cmdVars)
+	}
+	if cmd.Run != "" && cmd.Foreach != "" {
+		step.Foreach, step.ForeachErr = h.foreachJobs(cmdName, cmd, cmdVars)
+	}
+
+	if len(cmd.Steps) > 0 {
+		if scriptSteps, err := h.newScriptSteps(cmdName, cmd, cmdVars); err != nil {
+			step.StepsErr = err
+		} else {
+			for i, s := range scriptSteps {
+				step.Steps = append(step.Steps, fmt.Sprintf("%s: %s", steps.Label(cmd.Steps[i], s), s.Describe()))
+			}
+		}
+	}
+
+	// The plan shows the timeout the command runs with, which may be the default
+	step.Command.Timeout = h.commandTimeout(cmd)
+	if step.Command.Timeout != "" {
+		step.Timeout, step.TimeoutErr = time.ParseDuration(step.Command.Timeout)
+	}
+	if !step.HasSubcommands {
+		step.UpToDate, step.UpToDateErr = h.upToDate(cmdName, cmd, cmdVars)
+	}
+
+	return step
+}
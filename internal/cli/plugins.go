@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/variables"
+	"github.com/spf13/cobra"
+)
+
+// PluginPrefix is the prefix of executables on PATH that yxa exposes as commands,
+// e.g. yxa-deploy becomes 'yxa deploy'
+const PluginPrefix = "yxa-"
+
+// PluginBinVariable is the environment variable that tells a plugin where the yxa
+// binary that started it is, so it can call back into yxa
+const PluginBinVariable = "YXA_BIN"
+
+// pluginAnnotation holds the path of the executable of a plugin command
+const pluginAnnotation = "yxa:plugin"
+
+// findPlugins returns the plugin executables on the given PATH by command name.
+// Like the shell, the first match on PATH wins.
+func findPlugins(pathList string) map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if _, exists := plugins[name]; exists {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if isExecutable(path) {
+				plugins[name] = path
+			}
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the command name of a plugin executable file name
+func pluginName(fileName string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(fileName)
+		if !strings.EqualFold(ext, ".exe") {
+			return "", false
+		}
+		fileName = strings.TrimSuffix(fileName, ext)
+	}
+	name := strings.TrimPrefix(fileName, PluginPrefix)
+	if name == fileName || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// isExecutable reports whether path is a regular file that can be executed
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// newPluginCommands creates a command for every plugin on PATH that does not have
+// the name of one of the existing commands
+func (r *RootCommand) newPluginCommands(existing []*cobra.Command) []*cobra.Command {
+	taken := make(map[string]bool)
+	for _, cmd := range existing {
+		taken[cmd.Name()] = true
+	}
+	taken["help"], taken["completion"] = true, true
+
+	plugins := findPlugins(os.Getenv("PATH"))
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		if !taken[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	cmds := make([]*cobra.Command, 0, len(names))
+	for _, name := range names {
+		cmds = append(cmds, r.newPluginCommand(name, plugins[name]))
+	}
+	return cmds
+}
+
+// newPluginCommand creates the command that runs a plugin executable. All arguments
+// are passed to the plugin as they are.
+func (r *RootCommand) newPluginCommand(name, path string) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              fmt.Sprintf("Plugin command (%s)", path),
+		DisableFlagParsing: true,
+		Annotations:        map[string]string{pluginAnnotation: path},
+		// Plugins also work without a config; when there is one, its context is passed on
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			_ = r.loadConfigAndRegisterCommands(ConfigFlag)
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := r.runPlugin(cmd, name, path, args); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					exitFunc(exitErr.ExitCode())
+					return
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error executing plugin '%s': %v\n", name, err)
+				exitFunc(1)
+			}
+		},
+	}
+}
+
+// runPlugin executes a plugin with the config context in its environment
+func (r *RootCommand) runPlugin(cmd *cobra.Command, name, path string, args []string) error {
+	pluginCmd := exec.Command(path, args...) // #nosec G204
+	pluginCmd.Stdin = cmd.InOrStdin()
+	pluginCmd.Stdout = cmd.OutOrStdout()
+	pluginCmd.Stderr = cmd.ErrOrStderr()
+	pluginCmd.Env = append(os.Environ(), r.pluginEnv(name)...)
+	return pluginCmd.Run()
+}
+
+// pluginEnv returns the variables that pass the config context to a plugin
+func (r *RootCommand) pluginEnv(name string) []string {
+	env := []string{variables.BuiltinCommand + "=" + name}
+	if bin, err := os.Executable(); err == nil {
+		env = append(env, PluginBinVariable+"="+bin)
+	}
+	if r.Config != nil {
+		for key, value := range r.Config.BuiltinVars() {
+			env = append(env, key+"="+value)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlugin writes an executable plugin script to dir
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, PluginPrefix+name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return path
+}
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	hello := writePlugin(t, first, "hello", "echo first")
+	writePlugin(t, second, "hello", "echo second")
+	deploy := writePlugin(t, second, "deploy", "echo deploy")
+	require.NoError(t, os.WriteFile(filepath.Join(second, PluginPrefix+"notes"), []byte("not executable"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(second, PluginPrefix+"dir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(second, "yxa"), []byte("#!/bin/sh\n"), 0755))
+
+	plugins := findPlugins(first + string(os.PathListSeparator) + second)
+	assert.Equal(t, map[string]string{"hello": hello, "deploy": deploy}, plugins)
+}
+
+func TestPluginCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "hello", `echo "hello $* from $YXA_COMMAND in $YXA_PROJECT_NAME"; test -n "$YXA_BIN"`)
+	writePlugin(t, dir, "fail", "exit 3")
+	writePlugin(t, dir, "env", "echo plugin env")
+	writePlugin(t, dir, "build", "echo plugin build")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &config.ProjectConfig{
+		Name: "plugin-project",
+		Commands: map[string]config.Command{
+			"build": {Run: "echo config build"},
+		},
+	}
+	setup := func() (*RootCommand, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		return root, out
+	}
+
+	t.Run("passes arguments and config context", func(t *testing.T) {
+		root, out := setup()
+		root.RootCmd.SetArgs([]string{"hello", "--name", "world"})
+		require.NoError(t, root.Execute())
+		assert.Equal(t, "hello --name world from hello in plugin-project\n", out.String())
+	})
+
+	t.Run("propagates the exit code", func(t *testing.T) {
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		root, _ := setup()
+		root.RootCmd.SetArgs([]string{"fail"})
+		require.NoError(t, root.Execute())
+		assert.Equal(t, 3, code)
+	})
+
+	t.Run("built-ins take precedence", func(t *testing.T) {
+		root, out := setup()
+		root.RootCmd.SetArgs([]string{"env"})
+		require.NoError(t, root.Execute())
+		assert.NotContains(t, out.String(), "plugin env")
+		assert.Contains(t, out.String(), "YXA_PROJECT_NAME")
+	})
+
+	t.Run("config commands shadow plugins", func(t *testing.T) {
+		root, out := setup()
+		root.RootCmd.SetArgs([]string{"build"})
+		require.NoError(t, root.Execute())
+		assert.Equal(t, "config build\n", out.String())
+	})
+
+	t.Run("works without a config", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		out := &bytes.Buffer{}
+		root := NewRootCommand(nil, executor.NewDefaultExecutor())
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetArgs([]string{"hello"})
+		require.NoError(t, root.Execute())
+		assert.Equal(t, "hello  from hello in \n", out.String())
+	})
+}
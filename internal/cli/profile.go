@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// applyProfile applies the profile selected with --profile, or YXA_PROFILE, to the
+// loaded config
+func (r *RootCommand) applyProfile() error {
+	name := r.Profile
+	if name == "" {
+		name = os.Getenv(config.ProfileEnvVariable)
+	}
+	if name == "" || r.Config == nil {
+		return nil
+	}
+
+	cfg, err := r.Config.WithProfile(name)
+	if err != nil {
+		return err
+	}
+	r.Config = cfg
+	if r.Handler != nil {
+		r.Handler.Config = cfg
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestProfiles(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+variables:
+  TARGET: local
+commands:
+  deploy:
+    run: echo deploying to $TARGET as $YXA_PROFILE
+profiles:
+  ci:
+    variables:
+      TARGET: staging
+  prod:
+    variables:
+      TARGET: production
+    commands:
+      deploy:
+        run: echo shipping to $TARGET
+`), &cfg))
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = &cfg
+		root.Handler = NewCommandHandler(&cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	t.Run("no profile", func(t *testing.T) {
+		t.Setenv(config.ProfileEnvVariable, "")
+		out, err := run(t, "deploy")
+		require.NoError(t, err)
+		assert.Equal(t, "deploying to local as\n", out)
+	})
+
+	t.Run("profile flag", func(t *testing.T) {
+		out, err := run(t, "deploy", "--profile", "prod")
+		require.NoError(t, err)
+		assert.Equal(t, "shipping to production\n", out)
+	})
+
+	t.Run("profile from the environment", func(t *testing.T) {
+		t.Setenv(config.ProfileEnvVariable, "ci")
+		out, err := run(t, "deploy")
+		require.NoError(t, err)
+		assert.Equal(t, "deploying to staging as ci\n", out)
+	})
+
+	t.Run("set overrides the profile", func(t *testing.T) {
+		out, err := run(t, "deploy", "--profile", "ci", "--set", "TARGET=qa")
+		require.NoError(t, err)
+		assert.Equal(t, "deploying to qa as ci\n", out)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := run(t, "deploy", "--profile", "dev")
+		require.Error(t, err)
+		assert.Equal(t, "config error in profile 'dev': not defined, available profiles: ci, prod", err.Error())
+	})
+}
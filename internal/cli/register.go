@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/variables"
+)
+
+// validateRegister checks the register entries of a command
+func (h *CommandHandler) validateRegister(cmdName string, cmd config.Command) error {
+	if len(cmd.Register) == 0 {
+		return nil
+	}
+	if cmd.Run == "" {
+		return errors.NewCommandConfigError(cmdName, "uses 'register', which requires 'run'", nil)
+	}
+
+	for _, reg := range cmd.Register {
+		if !variableNamePattern.MatchString(reg.Var) {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid register: '%s' is not a valid variable name", reg.Var), nil)
+		}
+		if reg.From != "" && reg.From != config.RegisterFromStdout {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid register: unsupported source '%s', expected '%s'",
+				reg.From, config.RegisterFromStdout), nil)
+		}
+	}
+	return nil
+}
+
+// runAndRegister executes the run string of a command while capturing its output,
+// then stores the registered variables in the current run
+func (h *CommandHandler) runAndRegister(cmdName string, cmd config.Command, cmdStr string, timeout time.Duration) error {
+	output, err := h.executeWithOutput(cmdStr, timeout)
+	if err != nil {
+		return errors.NewExecutionError(cmdName, err)
+	}
+
+	run := h.RunContext()
+	for _, reg := range cmd.Register {
+		value := strings.TrimRight(output, "\r\n")
+		if reg.JSONPath != "" {
+			if value, err = variables.Extract(output, reg.JSONPath); err != nil {
+				return fmt.Errorf("failed to register '%s' for command '%s': %w", reg.Var, cmdName, err)
+			}
+		}
+		run.register(reg.Var, value)
+	}
+	return nil
+}
+
+// describeRegister returns a short description of a register entry for dry-run and explain
+func describeRegister(reg config.Register) string {
+	from := reg.From
+	if from == "" {
+		from = config.RegisterFromStdout
+	}
+	if reg.JSONPath == "" {
+		return fmt.Sprintf("%s from %s", reg.Var, from)
+	}
+	return fmt.Sprintf("%s from %s at %s", reg.Var, from, reg.JSONPath)
+}
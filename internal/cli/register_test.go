@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Register(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Name: "test-project",
+		Commands: map[string]config.Command{
+			"get-pods": {
+				Run: `printf '{"items": [{"metadata": {"name": "web-1"}, "replicas": 3}]}'`,
+				Register: config.RegisterList{
+					{Var: "POD_NAME", From: "stdout", JSONPath: "$.items[0].metadata.name"},
+					{Var: "REPLICAS", JSONPath: "$.items[0].replicas"},
+				},
+				Post: "echo post=$POD_NAME",
+			},
+			"logs": {
+				Run:     "echo logs for $POD_NAME x$REPLICAS",
+				Depends: config.NewDependencyList("get-pods"),
+			},
+			"version": {
+				Run:      "echo v1.2.3",
+				Register: config.RegisterList{{Var: "VERSION"}},
+			},
+			"release": {
+				Run:     "echo releasing $VERSION",
+				Depends: config.NewDependencyList("version"),
+			},
+			"show": {
+				Run: "echo show=$VERSION.",
+			},
+			"bad-path": {
+				Run:      "echo '{}'",
+				Register: config.RegisterList{{Var: "X", JSONPath: "$.missing"}},
+			},
+			"bad-source": {
+				Run:      "echo hi",
+				Register: config.RegisterList{{Var: "X", From: "stderr"}},
+			},
+			"no-run": {
+				Tasks:    config.NewTaskList("echo hi"),
+				Register: config.RegisterList{{Var: "X"}},
+			},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		realExec := executor.NewDefaultExecutor()
+		realExec.SetStdout(buf)
+		realExec.SetStderr(buf)
+		return NewCommandHandler(cfg, realExec), buf
+	}
+
+	t.Run("json path values are available to later commands and hooks", func(t *testing.T) {
+		handler, buf := newHandler()
+		require.NoError(t, handler.ExecuteCommand("logs", nil))
+		assert.Contains(t, buf.String(), "post=web-1")
+		assert.Contains(t, buf.String(), "logs for web-1 x3")
+	})
+
+	t.Run("whole output without trailing newline", func(t *testing.T) {
+		handler, buf := newHandler()
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Contains(t, buf.String(), "releasing v1.2.3\n")
+	})
+
+	t.Run("registered values do not leak into the next run", func(t *testing.T) {
+		handler, buf := newHandler()
+		require.NoError(t, handler.ExecuteCommand("version", nil))
+		require.NoError(t, handler.ExecuteCommand("show", nil))
+		assert.Contains(t, buf.String(), "show=.\n")
+	})
+
+	tests := []struct {
+		command string
+		wantErr string
+	}{
+		{"bad-path", "failed to register 'X' for command 'bad-path': $.missing: key not found"},
+		{"bad-source", "unsupported source 'stderr'"},
+		{"no-run", "config error in command 'no-run': uses 'register', which requires 'run'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			handler, _ := newHandler()
+			err := handler.ExecuteCommand(tt.command, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+// toolVersionTimeout limits how long asking a tool for its version may take
+const toolVersionTimeout = 10 * time.Second
+
+// Results of checking a requirement
+const (
+	toolOK       = "ok"       // The tool is on PATH in a matching version
+	toolMissing  = "missing"  // The tool is not on PATH
+	toolOutdated = "outdated" // The version of the tool does not match
+	toolUnknown  = "unknown"  // The version of the tool could not be determined
+)
+
+// versionPattern matches the first dotted version number in the output of a tool,
+// e.g. 24.0.7 in "Docker version 24.0.7, build afdd53b" or 1.21.3 in "go1.21.3"
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// toolCheck is the result of checking a requirement
+type toolCheck struct {
+	Requirement config.Requirement
+	Path        string // Path of the tool, empty if it is not on PATH
+	Version     string // Version of the tool, empty if not probed or unknown
+	Status      string // toolOK, toolMissing, toolOutdated or toolUnknown
+}
+
+// checkRequirement looks for the tool of a requirement on PATH and compares its
+// version. Tools are only asked for their version if the requirement has a version
+// constraint or probeVersion is set.
+func checkRequirement(req config.Requirement, probeVersion bool) toolCheck {
+	check := toolCheck{Requirement: req}
+	path, err := exec.LookPath(req.Tool)
+	if err != nil {
+		check.Status = toolMissing
+		return check
+	}
+	check.Path = path
+	if req.Op != "" || probeVersion {
+		check.Version = toolVersion(path)
+	}
+
+	switch {
+	case req.Op == "":
+		check.Status = toolOK
+	case check.Version == "":
+		check.Status = toolUnknown
+	case !versionSatisfies(check.Version, req.Op, req.Version):
+		check.Status = toolOutdated
+	default:
+		check.Status = toolOK
+	}
+	return check
+}
+
+// problem describes why the requirement is not met, or returns "" if it is
+func (c toolCheck) problem() string {
+	switch c.Status {
+	case toolMissing:
+		return fmt.Sprintf("%s (not found in PATH)", c.Requirement)
+	case toolOutdated:
+		return fmt.Sprintf("%s (found %s)", c.Requirement, c.Version)
+	case toolUnknown:
+		return fmt.Sprintf("%s (cannot determine the version of %s)", c.Requirement, c.Path)
+	}
+	return ""
+}
+
+// toolVersion returns the version a tool reports with --version, or with version
+// for tools such as go that do not support the flag. It returns "" if neither
+// succeeds with a version number.
+func toolVersion(path string) string {
+	for _, arg := range []string{"--version", "version"} {
+		ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+		out, err := exec.CommandContext(ctx, path, arg).CombinedOutput() // #nosec G204
+		cancel()
+		if err != nil {
+			continue
+		}
+		if version := versionPattern.FindString(string(out)); version != "" {
+			return version
+		}
+	}
+	return ""
+}
+
+// versionSatisfies reports whether version matches the constraint op want
+func versionSatisfies(version, op, want string) bool {
+	cmp := compareVersions(version, want)
+	switch op {
+	case config.VersionAtLeast:
+		return cmp >= 0
+	case config.VersionAbove:
+		return cmp > 0
+	case config.VersionAtMost:
+		return cmp <= 0
+	case config.VersionBelow:
+		return cmp < 0
+	case config.VersionExactly:
+		// A constraint of 1.21 matches every 1.21.x
+		return compareVersions(truncateVersion(version, want), want) == 0
+	}
+	return false
+}
+
+// compareVersions compares two dotted versions component by component, missing
+// components counting as 0. It returns -1, 0 or 1 like strings.Compare.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// truncateVersion shortens version to as many components as like has
+func truncateVersion(version, like string) string {
+	parts := strings.Split(version, ".")
+	if n := len(strings.Split(like, ".")); n < len(parts) {
+		parts = parts[:n]
+	}
+	return strings.Join(parts, ".")
+}
+
+// checkRequirements checks the tools a command requires before it runs, and
+// reports all that are missing or too old together
+func (h *CommandHandler) checkRequirements(cmdName string, cmd config.Command) error {
+	var problems []string
+	for _, req := range cmd.Requires {
+		if check := checkRequirement(req, false); check.Status != toolOK {
+			problems = append(problems, check.problem())
+		}
+	}
+	if len(problems) > 0 {
+		return errors.NewRequirementsError(cmdName, problems)
+	}
+	return nil
+}
+
+// newDoctorCommand creates the built-in 'doctor' command, which checks the tools
+// required by the commands of the configuration
+func (r *RootCommand) newDoctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the tools required by the commands",
+		Long: `Check that the tools listed in the 'requires' of every command are on PATH in a
+matching version, and show the version found.
+
+Exits with an error if any requirement is not met.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.runDoctor(cmd.OutOrStdout())
+		},
+	}
+}
+
+// runDoctor checks the requirements of all commands and writes a report to out.
+// Requirements shared by several commands are checked once.
+func (r *RootCommand) runDoctor(out io.Writer) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+
+	var reqs []config.Requirement
+	users := make(map[string][]string)
+	for _, nc := range flattenCommands(r.Config) {
+		for _, req := range nc.Command.Requires {
+			key := req.String()
+			if _, ok := users[key]; !ok {
+				reqs = append(reqs, req)
+			}
+			users[key] = append(users[key], nc.Name)
+		}
+	}
+	if len(reqs) == 0 {
+		_, err := fmt.Fprintln(out, "No prerequisites declared")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "STATUS\tREQUIREMENT\tFOUND\tCOMMANDS"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	failed := 0
+	for _, req := range reqs {
+		check := checkRequirement(req, true)
+		found := check.Version
+		switch {
+		case check.Status == toolMissing:
+			found = "-"
+		case found == "":
+			found = check.Path
+		}
+		if check.Status != toolOK {
+			failed++
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Status, req, found, strings.Join(users[req.String()], ", ")); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d prerequisites are not met", failed, len(reqs))
+	}
+	_, err := fmt.Fprintf(out, "All %d prerequisites are met\n", len(reqs))
+	return err
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTools puts scripts on PATH that print the given output for --version, and
+// for version when the output starts with "version:"
+func fakeTools(t *testing.T, tools map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, output := range tools {
+		script := "#!/bin/sh\n[ \"$1\" = --version ] && echo '" + output + "'\n"
+		if rest, ok := strings.CutPrefix(output, "version:"); ok {
+			script = "#!/bin/sh\n[ \"$1\" = version ] && echo '" + rest + "' && exit 0\nexit 2\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func mustRequirements(t *testing.T, specs ...string) []config.Requirement {
+	t.Helper()
+	reqs := make([]config.Requirement, len(specs))
+	for i, spec := range specs {
+		req, err := config.ParseRequirement(spec)
+		require.NoError(t, err)
+		reqs[i] = req
+	}
+	return reqs
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		version, op, want string
+		ok                bool
+	}{
+		{"24.0.7", ">=", "24", true},
+		{"20.10.2", ">=", "24", false},
+		{"1.21.0", ">=", "1.21", true},
+		{"1.9", ">=", "1.21", false},
+		{"4.3", "<", "4", false},
+		{"3.81", "<", "4", true},
+		{"1.21.3", "=", "1.21", true},
+		{"1.22.0", "=", "1.21", false},
+		{"2.0", ">", "2", false},
+		{"2.0.1", "<=", "2", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ok, versionSatisfies(tt.version, tt.op, tt.want), "%s %s %s", tt.version, tt.op, tt.want)
+	}
+}
+
+func TestCheckRequirement(t *testing.T) {
+	fakeTools(t, map[string]string{
+		"yxa-fake-docker": "Docker version 20.10.2, build 2291f61",
+		"yxa-fake-go":     "version:go version go1.22.1 linux/amd64",
+		"yxa-fake-blank":  "no version here",
+	})
+
+	tests := []struct {
+		spec    string
+		status  string
+		version string
+		problem string
+	}{
+		{spec: "yxa-fake-docker>=24", status: toolOutdated, version: "20.10.2", problem: "yxa-fake-docker>=24 (found 20.10.2)"},
+		{spec: "yxa-fake-go>=1.21", status: toolOK, version: "1.22.1"},
+		{spec: "yxa-fake-missing", status: toolMissing, problem: "yxa-fake-missing (not found in PATH)"},
+		{spec: "yxa-fake-blank>=1", status: toolUnknown},
+		{spec: "yxa-fake-blank", status: toolOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			check := checkRequirement(mustRequirements(t, tt.spec)[0], false)
+			assert.Equal(t, tt.status, check.Status)
+			assert.Equal(t, tt.version, check.Version)
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, check.problem())
+			}
+		})
+	}
+}
+
+func TestCommandHandler_Requires(t *testing.T) {
+	fakeTools(t, map[string]string{"yxa-fake-node": "v18.19.0"})
+
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"prepare": {Run: "echo prepare"},
+			"build": {
+				Run:      "echo build",
+				Depends:  config.NewDependencyList("prepare"),
+				Requires: mustRequirements(t, "yxa-fake-node>=20", "yxa-fake-missing"),
+			},
+			"lint": {
+				Run:      "echo lint",
+				Requires: mustRequirements(t, "yxa-fake-node"),
+			},
+		},
+	}
+	exec := &recordingExecutor{testExecutor: testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}}
+	h := NewCommandHandler(cfg, exec)
+	h.setProgress(&bytes.Buffer{})
+
+	err := h.ExecuteCommand("build", nil)
+	require.Error(t, err)
+	assert.Equal(t, "command 'build': missing required tools: yxa-fake-node>=20 (found 18.19.0), yxa-fake-missing (not found in PATH); install them, or run 'yxa doctor' to check all prerequisites", err.Error())
+	assert.Empty(t, exec.executed, "dependencies should not run")
+
+	require.NoError(t, h.ExecuteCommand("lint", nil))
+	assert.Equal(t, []string{"echo lint"}, exec.executed)
+}
+
+func TestDoctorCommand(t *testing.T) {
+	fakeTools(t, map[string]string{
+		"yxa-fake-node": "v20.11.1",
+		"yxa-fake-make": "GNU Make 4.3",
+	})
+
+	run := func(cfg *config.ProjectConfig) (string, error) {
+		out := &bytes.Buffer{}
+		root := NewRootCommand(nil, &testExecutor{stdout: out, stderr: out})
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, root.Executor)
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs([]string{"doctor"})
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	t.Run("all met", func(t *testing.T) {
+		out, err := run(&config.ProjectConfig{
+			Commands: map[string]config.Command{
+				"build": {Run: "make", Requires: mustRequirements(t, "yxa-fake-make", "yxa-fake-node>=20")},
+				"web":   {Run: "npm start", Requires: mustRequirements(t, "yxa-fake-node>=20")},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "STATUS  REQUIREMENT        FOUND    COMMANDS\n"+
+			"ok      yxa-fake-make      4.3      build\n"+
+			"ok      yxa-fake-node>=20  20.11.1  build, web\n"+
+			"All 2 prerequisites are met\n", out)
+	})
+
+	t.Run("not met", func(t *testing.T) {
+		out, err := run(&config.ProjectConfig{
+			Commands: map[string]config.Command{
+				"deploy": {Run: "deploy", Requires: mustRequirements(t, "yxa-fake-node>=22", "yxa-fake-missing")},
+			},
+		})
+		require.Error(t, err)
+		assert.Equal(t, "2 of 2 prerequisites are not met", err.Error())
+		assert.Contains(t, out, "outdated  yxa-fake-node>=22  20.11.1  deploy\n")
+		assert.Contains(t, out, "missing   yxa-fake-missing   -        deploy\n")
+	})
+
+	t.Run("nothing declared", func(t *testing.T) {
+		out, err := run(&config.ProjectConfig{Commands: map[string]config.Command{"build": {Run: "make"}}})
+		require.NoError(t, err)
+		assert.Equal(t, "No prerequisites declared\n", out)
+	})
+}
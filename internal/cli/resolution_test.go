@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolutionOrder verifies that every stage of a command resolves variables in
+// the same order: parameters, then config variables, then the environment
+func TestResolutionOrder(t *testing.T) {
+	t.Setenv("TARGET", "env")
+	t.Setenv("LEVEL", "env")
+	t.Setenv("REGION", "env")
+
+	stages := config.Command{
+		Params: []config.Param{
+			{Name: "TARGET", Type: "string", Position: 0, Default: "default"},
+			{Name: "LEVEL", Type: "string", Flag: true, Default: "default"},
+		},
+		Condition: "$LEVEL != config",
+		Pre:       "pre $TARGET $LEVEL $REGION",
+		Tasks: config.TaskList{
+			{Run: "task $TARGET $LEVEL $REGION", Condition: "$REGION == env"},
+			{Run: "skipped", Condition: "$TARGET == config"},
+		},
+		Post: "post $TARGET $LEVEL $REGION",
+	}
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"TARGET": "config", "LEVEL": "config"},
+		Commands: map[string]config.Command{
+			"deploy": stages,
+			"release": {
+				Depends: config.DependencyList{{Command: "deploy"}},
+				Run:     "release",
+			},
+			"ship": {
+				Tasks: config.TaskList{{Task: "deploy", Params: map[string]string{"TARGET": "task"}}},
+			},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard}}
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.registerCommands()
+		root.RootCmd.SetArgs(args)
+		require.NoError(t, root.Execute())
+		return exec.executed
+	}
+	stagesWith := func(values string) []string {
+		return []string{"pre " + values, "task " + values, "post " + values}
+	}
+
+	t.Run("invocation", func(t *testing.T) {
+		assert.Equal(t, stagesWith("prod debug env"), run(t, "deploy", "prod", "--LEVEL", "debug"))
+	})
+
+	t.Run("parameter defaults", func(t *testing.T) {
+		assert.Equal(t, stagesWith("default default env"), run(t, "deploy"))
+	})
+
+	t.Run("dependency", func(t *testing.T) {
+		assert.Equal(t, append(stagesWith("default default env"), "release"), run(t, "release"))
+	})
+
+	t.Run("task reference", func(t *testing.T) {
+		assert.Equal(t, stagesWith("task default env"), run(t, "ship"))
+	})
+
+	t.Run("config variables without parameters", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, nil)
+		resolver := handler.resolver("release", nil)
+		assert.Equal(t, "config config env", resolver.Resolve("$TARGET $LEVEL $REGION"))
+	})
+}
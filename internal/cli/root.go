@@ -1,24 +1,71 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/events"
 	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
 	"github.com/spf13/cobra"
 )
 
 var exitFunc = os.Exit
 
+// ExitCodeInterrupted is the exit code used when a command is interrupted by
+// SIGINT or SIGTERM, following the shell convention of 128 + SIGINT
+const ExitCodeInterrupted = 130
+
 // RootCommand manages the root command and its subcommands
 type RootCommand struct {
-	Config   *config.ProjectConfig
-	Executor executor.CommandExecutor
-	Handler  *CommandHandler
-	RootCmd  *cobra.Command
-	DryRun   bool // global dry-run flag
+	Config             *config.ProjectConfig
+	Executor           executor.CommandExecutor
+	Handler            *CommandHandler
+	RootCmd            *cobra.Command
+	DryRun             bool          // global dry-run flag
+	Chdir              string        // global --chdir directory, changed to before the config is loaded
+	KeepGoing          bool          // global keep-going flag
+	NoDedupe           bool          // global no-dedupe flag
+	Force              bool          // global force flag
+	FailOnSkip         bool          // global fail-on-skip flag
+	NoPrefix           bool          // global no-prefix flag to not prefix the output of dependencies
+	CIGroups           string        // global --ci-groups mode (auto, on or off)
+	DebugOnFailure     bool          // global debug-on-failure flag
+	DebugTimeout       time.Duration // global debug-timeout flag
+	EventsFormat       string        // global --events format, empty if disabled
+	EventsFD           int           // global --events-fd file descriptor
+	ErrorFormat        string        // global --error-format for failed commands (text or json)
+	OutputMode         string        // global --output-mode of commands without 'output' (stream or captured)
+	Timestamps         string        // global --timestamps mode (relative or absolute), empty if disabled
+	SetVars            []string      // global --set KEY=VALUE overrides
+	SetFiles           []string      // global --set-file KEY=path overrides
+	VarsFrom           []string      // global --vars-from files (or - for stdin) with variable maps
+	Profile            string        // global --profile to apply, YXA_PROFILE if not set
+	Jobs               int           // global --jobs limit of parallel jobs per command, 0 for no limit
+	Notify             bool          // global --notify flag to send a notification when the command finishes
+	MetricsFile        string        // global --metrics-file written with Prometheus metrics of the run, empty if disabled
+	DebugVars          bool          // global --debug-vars flag to log variable substitutions
+	StrictDeprecations bool          // global --strict-deprecations flag to fail when a deprecated command is invoked
+	NonInteractive     bool          // global --non-interactive flag to never ask questions, also when stdin is no terminal
+	Timeout            string        // global --timeout of commands that do not set their own
+	Check              bool          // global --check flag to fail on outdated template output instead of writing it
+	NoOverride         bool          // global --no-override flag to not merge yxa.override.yml
+
+	builtinCmds  []*cobra.Command // commands provided by yxa itself (e.g. env)
+	events       *events.Emitter  // emitter for --events, nil if disabled
+	tracer       *tracing.Tracer  // spans exported to YXA_OTEL_ENDPOINT, nil if disabled
+	stderrTail   *tailWriter      // last stderr lines for --error-format json, nil if disabled
+	timestamped  bool             // output is already prefixed for --timestamps
+	chdirApplied bool             // --chdir was changed to before the arguments were parsed
+
+	noOverrideApplied bool // --no-override was read before the config was loaded
 }
 
 // NewRootCommand creates a new root command
@@ -46,8 +93,44 @@ func NewRootCommand(cfg *config.ProjectConfig, exec executor.CommandExecutor) *R
 		},
 		// PersistentPreRunE now delegates to the dedicated loading method.
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := r.checkChdir(); err != nil {
+				return err
+			}
+			if err := r.checkNoOverride(); err != nil {
+				return err
+			}
 			// ConfigFlag is populated by Cobra before this hook runs.
-			return r.loadConfigAndRegisterCommands(ConfigFlag)
+			if err := r.loadConfigAndRegisterCommands(ConfigFlag); err != nil {
+				return err
+			}
+			r.warnSecretVars()
+			r.warnGlobalConfig()
+			r.warnMergeConflicts()
+			if err := r.applyProfile(); err != nil {
+				return err
+			}
+			if err := r.applyVariableOverrides(); err != nil {
+				return err
+			}
+			if err := r.setupEvents(); err != nil {
+				return err
+			}
+			if err := r.setupErrorFormat(); err != nil {
+				return err
+			}
+			if err := r.setupTracing(); err != nil {
+				return err
+			}
+			if !validOutputMode(r.OutputMode) {
+				return fmt.Errorf("invalid --output-mode '%s': expected '%s' or '%s'", r.OutputMode, config.OutputStream, config.OutputCaptured)
+			}
+			if r.Jobs < 0 {
+				return fmt.Errorf("invalid --jobs %d: must be at least 1", r.Jobs)
+			}
+			if err := r.setupCIGroups(); err != nil {
+				return err
+			}
+			return r.setupTimestamps()
 		},
 		// Add RunE to ensure configuration is loaded even when no command is specified
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -58,12 +141,85 @@ func NewRootCommand(cfg *config.ProjectConfig, exec executor.CommandExecutor) *R
 
 	// Add persistent config flag
 	r.RootCmd.PersistentFlags().StringVar(&ConfigFlag, "config", "", "config file (default: yxa.yml in current directory, or global config)")
+	// Add persistent chdir flag, applied by InitializeApp before the arguments are parsed
+	r.RootCmd.PersistentFlags().StringVarP(&r.Chdir, chdirFlag, "C", "", "Change to this directory before loading the config and running commands")
+	r.RootCmd.PersistentFlags().BoolVar(&r.NoOverride, noOverrideFlag, false, "Do not merge yxa.override.yml over the config (default: $YXA_NO_OVERRIDE)")
 	// Add persistent dry-run flag
 	r.RootCmd.PersistentFlags().BoolVarP(&r.DryRun, "dry-run", "d", false, "Show commands to be executed without running them")
+	// Add persistent keep-going flag
+	r.RootCmd.PersistentFlags().BoolVarP(&r.KeepGoing, "keep-going", "k", false, "Keep running remaining tasks and dependencies after a failure and report all errors")
+	// Add persistent no-dedupe flag
+	r.RootCmd.PersistentFlags().BoolVar(&r.NoDedupe, "no-dedupe", false, "Execute dependencies every time they are reached, even if they already ran")
+	r.RootCmd.PersistentFlags().BoolVar(&r.Force, "force", false, "Run commands even if the files they generate are newer than their sources")
+	r.RootCmd.PersistentFlags().BoolVar(&r.FailOnSkip, "fail-on-skip", false, "Fail commands that are skipped because their condition is not met")
+	r.RootCmd.PersistentFlags().BoolVar(&r.NoPrefix, "no-prefix", false, "Do not prefix the output lines of dependencies with [name]")
+	r.RootCmd.PersistentFlags().StringVar(&r.CIGroups, "ci-groups", CIGroupsAuto, "Fold the output of every command in the CI log: auto (in GitHub Actions and GitLab CI), on or off")
+	// Add persistent debug-on-failure flags
+	r.RootCmd.PersistentFlags().BoolVar(&r.DebugOnFailure, "debug-on-failure", false, "Open a shell with the command's environment when a command fails and stdin is a terminal")
+	r.RootCmd.PersistentFlags().DurationVar(&r.DebugTimeout, "debug-timeout", DefaultDebugTimeout, "Maximum duration of a --debug-on-failure shell")
+	r.RootCmd.PersistentFlags().BoolVar(&r.DebugVars, "debug-vars", false, "Log every variable substitution with the source of its value to stderr")
+	// Add persistent strict deprecations flag
+	r.RootCmd.PersistentFlags().BoolVar(&r.StrictDeprecations, "strict-deprecations", false, "Fail instead of warning when a deprecated command is invoked")
+	// Add persistent timeout flag
+	r.RootCmd.PersistentFlags().StringVar(&r.Timeout, "timeout", "", "Maximum duration of every command that sets no timeout, e.g. 30s or 5m (default: default_timeout of the config)")
+	// Add persistent check flag
+	r.RootCmd.PersistentFlags().BoolVar(&r.Check, "check", false, "Fail if a template step would change its output instead of writing it, e.g. in CI")
+	// Add persistent non-interactive flag
+	r.RootCmd.PersistentFlags().BoolVar(&r.NonInteractive, "non-interactive", false, "Never ask questions or open shells, fail on missing answers instead (default when stdin is no terminal)")
+	// Add persistent structured events flags
+	r.RootCmd.PersistentFlags().StringVar(&r.EventsFormat, "events", "", "Emit structured run events in the given format (json)")
+	r.RootCmd.PersistentFlags().IntVar(&r.EventsFD, "events-fd", 2, "File descriptor to write --events to (default stderr)")
+	// Add persistent error format flag
+	r.RootCmd.PersistentFlags().StringVar(&r.ErrorFormat, "error-format", ErrorFormatText, "Format of the error report of a failed command (text or json)")
+	// Add persistent output mode flag
+	r.RootCmd.PersistentFlags().StringVar(&r.OutputMode, "output-mode", config.OutputStream, "Output of commands without 'output': stream, or captured to show it only on failure")
+	// Add persistent timestamps flag, --timestamps alone means relative
+	r.RootCmd.PersistentFlags().StringVar(&r.Timestamps, "timestamps", "", "Prefix every output line with a timestamp (relative or absolute)")
+	r.RootCmd.PersistentFlags().Lookup("timestamps").NoOptDefVal = TimestampsRelative
+	// Add persistent profile flag
+	r.RootCmd.PersistentFlags().StringVar(&r.Profile, "profile", "", "Apply a profile of the config (default: $YXA_PROFILE)")
+	// Add persistent jobs flag
+	r.RootCmd.PersistentFlags().IntVar(&r.Jobs, "jobs", 0, "Maximum number of parallel tasks a command runs at the same time (default: no limit)")
+	// Add persistent notify flag
+	r.RootCmd.PersistentFlags().BoolVar(&r.Notify, "notify", false, "Send a notification when the command finishes, on the desktop unless the command configures notify")
+	// Add persistent metrics file flag
+	r.RootCmd.PersistentFlags().StringVar(&r.MetricsFile, "metrics-file", "", "Write Prometheus metrics of the run to this file, e.g. for the node_exporter textfile collector")
+	// Add persistent variable override flags
+	r.RootCmd.PersistentFlags().StringArrayVarP(&r.SetVars, "set", "s", nil, "Set a variable for this invocation (KEY=VALUE), can be repeated")
+	r.RootCmd.PersistentFlags().StringArrayVar(&r.SetFiles, "set-file", nil, "Set a variable to the contents of a file (KEY=path), can be repeated")
+	r.RootCmd.PersistentFlags().StringArrayVar(&r.VarsFrom, "vars-from", nil, "Read variables from a JSON or YAML map in a file, or - for stdin, can be repeated")
 
 	// Setup command completion
 	r.setupCompletion()
 
+	// Add the built-in commands
+	r.builtinCmds = []*cobra.Command{
+		r.newEnvCommand(),
+		r.newExecCommand(),
+		r.newExportCommand(),
+		r.newExplainCommand(),
+		r.newLintCommand(),
+		r.newDoctorCommand(),
+		r.newToolsCommand(),
+		r.newHistoryCommand(),
+		r.newRerunCommand(),
+		r.newUpCommand(),
+		r.newDownCommand(),
+		r.newStatusCommand(),
+		r.newLogsCommand(),
+		r.newStartCommand(),
+		r.newStopCommand(),
+		r.newScaffoldCommand(),
+		r.newCleanCommand(),
+		r.newSecretCommand(),
+		r.newDocsCommand(),
+		r.newSchemaCommand(),
+		r.newConfigCommand(),
+	}
+	// Plugins on PATH are registered like built-ins, so config commands shadow them
+	r.builtinCmds = append(r.builtinCmds, r.newPluginCommands(r.builtinCmds)...)
+	r.registerBuiltinCommands()
+
 	return r
 }
 
@@ -126,7 +282,7 @@ func (r *RootCommand) loadConfiguration(configFlagValue string) (*config.Project
 
 // loadConfigFromPath loads configuration from a specific path
 func (r *RootCommand) loadConfigFromPath(path string) (*config.ProjectConfig, error) {
-	loadedConfig, err := config.LoadConfigFrom(path)
+	loadedConfig, err := config.LoadConfigWith(path, r.loadOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load configuration from '%s': %w", path, err)
 	}
@@ -155,9 +311,18 @@ func (r *RootCommand) setupWithConfig(loadedConfig *config.ProjectConfig) error
 	return nil
 }
 
-// Execute executes the root command
+// Execute executes the root command. SIGINT and SIGTERM cancel the running
+// commands; a second signal terminates yxa immediately.
 func (r *RootCommand) Execute() error {
-	return r.RootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default signal behavior once the first signal was received
+		<-ctx.Done()
+		stop()
+	}()
+
+	return r.RootCmd.ExecuteContext(ctx)
 }
 
 // registerCommands registers all commands from the configuration
@@ -170,25 +335,38 @@ func (r *RootCommand) registerCommands() {
 
 	// Register each command from the configuration
 	for name, cmd := range r.Config.Commands {
+		// User-defined commands take precedence over built-in commands
+		r.removeShadowedBuiltin(name)
+
 		// Create a cobra command for this command
 		cobraCmd := r.createCobraCommand(name, cmd)
 
-		// Add parameters and subcommands
-		addParametersToCommand(cobraCmd, cmd.Params)
-		r.addSubcommandsToCommand(cobraCmd, name, cmd.Commands)
+		// Add parameters and subcommands. Commands with subcommands register their
+		// flag parameters as persistent flags so the subcommands inherit them.
+		if len(cmd.Commands) > 0 {
+			addPersistentParametersToCommand(cobraCmd, cmd.Params)
+		} else {
+			addParametersToCommand(cobraCmd, cmd.Params)
+		}
+		r.addParamCompletions(cobraCmd, name, cmd.Params)
+		r.addSubcommandsToCommand(cobraCmd, name, cmd, nil)
 
 		// Add the command to the root command
 		r.RootCmd.AddCommand(cobraCmd)
 	}
+
+	// Restore built-in commands that are no longer shadowed
+	r.registerBuiltinCommands()
 }
 
 // createCobraCommand creates a new cobra.Command for a config.Command
 func (r *RootCommand) createCobraCommand(cmdName string, cmdConfig config.Command) *cobra.Command {
 	// Create a new cobra command
-	return &cobra.Command{
-		Use:   cmdName,
-		Short: cmdConfig.Description,
-		Long:  cmdConfig.Description,
+	cobraCmd := &cobra.Command{
+		Use:     cmdName,
+		Short:   cmdConfig.Description,
+		Long:    commandHelp(cmdConfig),
+		Example: commandExamples(cmdConfig),
 		Run: func(cmd *cobra.Command, args []string) {
 			// Create command variables and execute the command
 			cmdVars := r.createCommandVariables()
@@ -199,29 +377,35 @@ func (r *RootCommand) createCobraCommand(cmdName string, cmdConfig config.Comman
 			}
 
 			// Check if this command has a subcommand specified
-			if r.tryExecuteSubcommand(cmdName, cmdConfig, args, cmdVars) {
+			if r.tryExecuteSubcommand(cmd, cmdName, cmdConfig, args, cmdVars) {
 				return
 			}
 
 			// Execute the main command
-			r.executeMainCommand(cmdName, cmdVars)
+			r.executeMainCommand(cmd, args, cmdName, cmdVars)
 		},
 	}
+	applyDeprecation(cobraCmd, cmdConfig)
+	return cobraCmd
 }
 
-// createCommandVariables creates a map of variables from the global config
+// createCommandVariables creates the map the parameters of a command are added to.
+// Config variables are not copied into it: the resolver looks them up with a lower
+// precedence than the variables of the command.
 func (r *RootCommand) createCommandVariables() map[string]string {
-	cmdVars := make(map[string]string)
-	if r.Config != nil {
-		for k, v := range r.Config.Variables {
-			cmdVars[k] = v
-		}
-	}
-	return cmdVars
+	return make(map[string]string)
 }
 
 // processCommandParameters processes command parameters and adds them to the variables map
 func (r *RootCommand) processCommandParameters(cmd *cobra.Command, args []string, params []config.Param, cmdVars map[string]string) {
+	// Ask for the required parameters with choices that are not given
+	args, err := r.askParamChoices(cmd, args, params)
+	if err != nil {
+		fmt.Printf("Error processing parameters: %v\n", err)
+		exitFunc(1)
+		return
+	}
+
 	// Process parameters and update cmdVars
 	paramVars, err := processParameters(cmd, args, params)
 	if err != nil {
@@ -235,81 +419,165 @@ func (r *RootCommand) processCommandParameters(cmd *cobra.Command, args []string
 	}
 }
 
-// tryExecuteSubcommand checks if a subcommand is specified and executes it if found
+// tryExecuteSubcommand checks if a subcommand is specified and executes it if found.
+// Leading arguments naming nested subcommands select the deepest one, e.g.
+// 'migrate up' of db runs db:migrate:up.
 // Returns true if a subcommand was executed, false otherwise
-func (r *RootCommand) tryExecuteSubcommand(cmdName string, cmdConfig config.Command, args []string, cmdVars map[string]string) bool {
-	if len(args) > 0 && len(cmdConfig.Commands) > 0 {
-		subCmdName := args[0]
-		_, ok := cmdConfig.Commands[subCmdName]
-		if ok {
-			// Execute the subcommand
-			fullCmdName := fmt.Sprintf("%s:%s", cmdName, subCmdName)
-
-			// Set dry-run flag on the handler
-			r.Handler.SetDryRun(r.DryRun)
-
-			// Use ExecuteCommand which will internally call executeCommandWithDependencies
-			if err := r.Handler.ExecuteCommand(fullCmdName, cmdVars); err != nil {
-				fmt.Printf("Error executing subcommand '%s': %v\n", fullCmdName, err)
-				exitFunc(1)
-			}
-			return true
+func (r *RootCommand) tryExecuteSubcommand(cmd *cobra.Command, cmdName string, cmdConfig config.Command, args []string, cmdVars map[string]string) bool {
+	fullCmdName := cmdName
+	for _, arg := range args {
+		subCmd, ok := cmdConfig.Commands[arg]
+		if !ok {
+			break
 		}
+		fullCmdName += ":" + arg
+		cmdConfig = subCmd
 	}
-	return false
+	if fullCmdName == cmdName {
+		return false
+	}
+
+	// Apply the global execution flags to the handler
+	r.configureHandler()
+
+	// Use ExecuteCommand which will internally call executeCommandWithDependencies
+	err := r.Handler.ExecuteCommand(fullCmdName, cmdVars)
+	r.finishRun(cmd, args, fullCmdName, err)
+	if err != nil {
+		r.reportCommandError("subcommand", fullCmdName, err)
+	}
+	return true
 }
 
-// executeMainCommand executes the main command with the given variables
-func (r *RootCommand) executeMainCommand(cmdName string, cmdVars map[string]string) {
-	// Set dry-run flag on the handler
+// configureHandler applies the global execution flags to the command handler
+func (r *RootCommand) configureHandler() {
 	r.Handler.SetDryRun(r.DryRun)
+	r.Handler.SetKeepGoing(r.KeepGoing)
+	r.Handler.SetNoDedupe(r.NoDedupe)
+	r.Handler.SetForce(r.Force)
+	r.Handler.SetFailOnSkip(r.FailOnSkip)
+	r.Handler.SetNoPrefix(r.NoPrefix)
+	r.Handler.SetDebugOnFailure(r.DebugOnFailure, r.DebugTimeout)
+	r.Handler.SetEvents(r.events)
+	r.Handler.SetOutputMode(r.OutputMode)
+	r.Handler.SetJobs(r.Jobs)
+	r.Handler.SetTracer(r.tracer)
+	r.Handler.SetStrictDeprecations(r.StrictDeprecations)
+	r.Handler.SetNonInteractive(r.NonInteractive)
+	r.Handler.SetTimeout(r.Timeout)
+	r.Handler.SetCheck(r.Check)
+	if r.DebugVars {
+		r.Handler.SetDebugVars(os.Stderr)
+	} else {
+		r.Handler.SetDebugVars(nil)
+	}
+	if ctx := r.RootCmd.Context(); ctx != nil {
+		r.Handler.SetContext(ctx)
+	}
+}
+
+// exitCode returns the exit code for a failed command execution
+func (r *RootCommand) exitCode() int {
+	if r.Handler.RunContext().Cancelled() {
+		return ExitCodeInterrupted
+	}
+	return 1
+}
+
+// finishRun records the outcome of an invoked command: in the run history, in
+// notifications, in the exported trace, in the metrics file and in the job summary
+// of GitHub Actions
+func (r *RootCommand) finishRun(cmd *cobra.Command, args []string, cmdName string, err error) {
+	r.recordHistory(cmd, args, cmdName, err)
+	r.sendNotifications(cmdName, err)
+	r.exportTrace()
+	r.writeMetricsFile(cmdName, err)
+	r.writeStepSummary(cmdName, err)
+}
+
+// executeMainCommand executes the main command with the given variables
+func (r *RootCommand) executeMainCommand(cmd *cobra.Command, args []string, cmdName string, cmdVars map[string]string) {
+	// Apply the global execution flags to the handler
+	r.configureHandler()
 
 	// Execute the command with variables
-	if err := r.Handler.ExecuteCommand(cmdName, cmdVars); err != nil {
-		fmt.Printf("Error executing command '%s': %v\n", cmdName, err)
-		exitFunc(1)
+	err := r.Handler.ExecuteCommand(cmdName, cmdVars)
+	r.finishRun(cmd, args, cmdName, err)
+	if err != nil {
+		r.reportCommandError("command", cmdName, err)
 	}
 }
 
-// addSubcommandsToCommand adds subcommands to a parent cobra.Command
-func (r *RootCommand) addSubcommandsToCommand(parentCmd *cobra.Command, parentName string, subCommands map[string]config.Command) {
+// errorMessage returns the user-facing message of an error of the given command.
+// Errors of the command itself leave out its name, which the caller already prints.
+func errorMessage(cmdName string, err error) string {
+	switch e := err.(type) {
+	case *errors.CommandError:
+		if e.CommandName == cmdName {
+			return e.Detail()
+		}
+	case *errors.ConfigError:
+		if e.Section == "command" && e.Name == cmdName {
+			return e.Detail()
+		}
+	}
+	return err.Error()
+}
+
+// addSubcommandsToCommand adds subcommands to a parent cobra.Command, and their
+// own subcommands recursively. inherited holds the parameters of the parents
+// of parentConfig, which its subcommands inherit along with its own.
+func (r *RootCommand) addSubcommandsToCommand(parentCmd *cobra.Command, parentName string, parentConfig config.Command, inherited []config.Param) {
 	// Skip if no subcommands are defined
-	if len(subCommands) == 0 {
+	if len(parentConfig.Commands) == 0 {
 		return
 	}
+	inherited = append(append([]config.Param(nil), inherited...), parentConfig.Params...)
 
-	for subName, subCmd := range subCommands {
+	for subName, subCmd := range parentConfig.Commands {
 		// Create a local copy for the closure
 		subCmdName := subName
 		subCmdConfig := subCmd
+		fullCmdName := fmt.Sprintf("%s:%s", parentName, subCmdName)
 
 		// Create the subcommand
 		subCobraCmd := &cobra.Command{
-			Use:   subCmdName,
-			Short: subCmdConfig.Description,
-			Long:  subCmdConfig.Description,
+			Use:     subCmdName,
+			Short:   subCmdConfig.Description,
+			Long:    commandHelp(subCmdConfig),
+			Example: commandExamples(subCmdConfig),
 			Run: func(cmd *cobra.Command, args []string) {
 				// Create command variables
 				cmdVars := r.createCommandVariables()
 
-				// Execute the subcommand
-				fullCmdName := fmt.Sprintf("%s:%s", parentName, subCmdName)
+				// Process the flag parameters inherited from the parents together with
+				// the subcommand's own, which take precedence
+				if params := mergeParams(inherited, subCmdConfig.Params); len(params) > 0 {
+					r.processCommandParameters(cmd, args, params, cmdVars)
+				}
 
-				// Set dry-run flag on the handler
-				r.Handler.SetDryRun(r.DryRun)
+				// Apply the global execution flags to the handler
+				r.configureHandler()
 
 				// Execute the command
-				if err := r.Handler.ExecuteCommand(fullCmdName, cmdVars); err != nil {
-					fmt.Printf("Error executing subcommand '%s': %v\n", fullCmdName, err)
-					exitFunc(1)
+				err := r.Handler.ExecuteCommand(fullCmdName, cmdVars)
+				r.finishRun(cmd, args, fullCmdName, err)
+				if err != nil {
+					r.reportCommandError("subcommand", fullCmdName, err)
 				}
 			},
 		}
+		applyDeprecation(subCobraCmd, subCmdConfig)
 
-		// Add parameters to the subcommand if defined
-		if len(subCmdConfig.Params) > 0 {
+		// Add parameters to the subcommand if defined. Nested groups register their
+		// flag parameters as persistent flags, like top-level groups.
+		if len(subCmdConfig.Commands) > 0 {
+			addPersistentParametersToCommand(subCobraCmd, subCmdConfig.Params)
+		} else {
 			addParametersToCommand(subCobraCmd, subCmdConfig.Params)
 		}
+		r.addParamCompletions(subCobraCmd, fullCmdName, subCmdConfig.Params)
+		r.addSubcommandsToCommand(subCobraCmd, fullCmdName, subCmdConfig, inherited)
 
 		// Add the subcommand to the parent command
 		parentCmd.AddCommand(subCobraCmd)
@@ -328,7 +596,7 @@ func (r *RootCommand) clearUserCommands() {
 	// Remove user-defined commands
 	for _, cmd := range commandsCopy {
 		// Skip built-in commands
-		if cmd.Name() == "help" || cmd.Name() == "completion" {
+		if cmd.Name() == "help" || cmd.Name() == "completion" || isBuiltinCommand(cmd) {
 			continue
 		}
 
@@ -381,13 +649,13 @@ PowerShell:
   # and source this file from your PowerShell profile.
 `,
 		DisableFlagsInUseLine: true,
-		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		ValidArgs:             completionShells,
 		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		Run: func(cmd *cobra.Command, args []string) {
 			var err error
 			switch args[0] {
 			case "bash":
-				err = r.RootCmd.GenBashCompletion(os.Stdout)
+				err = r.RootCmd.GenBashCompletionV2(os.Stdout, true)
 			case "zsh":
 				err = r.RootCmd.GenZshCompletion(os.Stdout)
 			case "fish":
@@ -402,6 +670,8 @@ PowerShell:
 		},
 	}
 
+	completionCmd.AddCommand(r.newCompletionInstallCommand())
+
 	r.RootCmd.AddCommand(completionCmd)
 }
 
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
 	"github.com/floppa/yxa-cli/internal/executor"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -17,7 +18,7 @@ import (
 func countUserCommands(cmd *cobra.Command) int {
 	count := 0
 	for _, c := range cmd.Commands() {
-		if c.Name() != "help" && c.Name() != "completion" {
+		if c.Name() != "help" && c.Name() != "completion" && !isBuiltinCommand(c) {
 			count++
 		}
 	}
@@ -89,7 +90,7 @@ func createTestConfig() *config.ProjectConfig {
 				Post:        "echo post-hook",
 			},
 			"cmd-with-tasks": {
-				Tasks:       []string{"echo task1", "echo task2"},
+				Tasks:       config.NewTaskList("echo task1", "echo task2"),
 				Description: "Command with tasks",
 				Parallel:    true,
 			},
@@ -792,6 +793,121 @@ func TestRootCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestRootCommand_SubcommandInheritsParentParams(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"deploy": {
+				Description: "Deploy commands",
+				Params: []config.Param{
+					{Name: "env", Type: "string", Default: "dev", Description: "Target environment", Flag: true},
+				},
+				Commands: map[string]config.Command{
+					"app": {
+						Run: "echo deploying app to $env in $region",
+						Params: []config.Param{
+							{Name: "region", Type: "string", Default: "eu", Description: "Target region", Flag: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "inherited default",
+			args: []string{"deploy", "app"},
+			want: "deploying app to dev in eu",
+		},
+		{
+			name: "inherited flag after subcommand",
+			args: []string{"deploy", "app", "--env", "prod", "--region", "us"},
+			want: "deploying app to prod in us",
+		},
+		{
+			name: "inherited flag before subcommand",
+			args: []string{"deploy", "--env", "staging", "app"},
+			want: "deploying app to staging in eu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			realExec := executor.NewDefaultExecutor()
+			realExec.SetStdout(stdout)
+			realExec.SetStderr(stdout)
+
+			root := NewRootCommand(nil, realExec)
+			root.clearUserCommands()
+			root.Config = cfg
+			root.Handler = NewCommandHandler(cfg, realExec)
+			root.registerCommands()
+
+			root.RootCmd.SetOut(stdout)
+			root.RootCmd.SetArgs(tt.args)
+
+			assert.NoError(t, root.Execute())
+			assert.Contains(t, stdout.String(), tt.want)
+		})
+	}
+}
+
+func TestRootCommand_SubcommandOverridesParentParams(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"deploy": {
+				Params: []config.Param{
+					{Name: "env", Type: "string", Default: "dev", Flag: true, Choices: []string{"dev", "prod"}},
+					{Name: "replicas", Type: "int", Default: "1", Flag: true},
+				},
+				Commands: map[string]config.Command{
+					"app": {Run: "echo app $env $replicas"},
+					"canary": {
+						Run: "echo canary $env $replicas",
+						Params: []config.Param{
+							{Name: "replicas", Type: "string", Default: "10%", Flag: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		stdout := &bytes.Buffer{}
+		realExec := executor.NewDefaultExecutor()
+		realExec.SetStdout(stdout)
+		realExec.SetStderr(stdout)
+		root := NewRootCommand(cfg, realExec)
+		root.Handler.setProgress(io.Discard)
+		root.registerCommands()
+		root.RootCmd.SetArgs(args)
+		assert.NoError(t, root.Execute())
+		return stdout.String()
+	}
+
+	assert.Equal(t, "app dev 1\n", run(t, "deploy", "app"))
+	// The parameter of the subcommand replaces the one of the parent, type included
+	assert.Equal(t, "canary dev 10%\n", run(t, "deploy", "canary"))
+	assert.Equal(t, "canary prod 25%\n", run(t, "deploy", "canary", "--env", "prod", "--replicas", "25%"))
+
+	t.Run("choices of inherited parameters are checked", func(t *testing.T) {
+		oldExit := exitFunc
+		defer func() { exitFunc = oldExit }()
+		code := 0
+		exitFunc = func(c int) { code = c }
+
+		run(t, "deploy", "app", "--env", "staging")
+		assert.Equal(t, 1, code)
+	})
+}
+
 func TestGetWriterMutex(t *testing.T) {
 	// Test getting a mutex for a writer
 	writer1 := &bytes.Buffer{}
@@ -1040,3 +1156,83 @@ commands:
 		assert.Contains(t, err.Error(), "circular dependency detected")
 	})
 }
+
+func TestErrorMessage(t *testing.T) {
+	execErr := errors.NewExecutionError("build", fmt.Errorf("exit status 1"))
+	assert.Equal(t, "execution failed: exit status 1", errorMessage("build", execErr))
+	assert.Equal(t, "command 'build': execution failed: exit status 1", errorMessage("all", execErr))
+
+	cfgErr := errors.NewCommandConfigError("empty", "no 'run' defined", nil)
+	assert.Equal(t, "no 'run' defined", errorMessage("empty", cfgErr))
+
+	assert.Equal(t, "plain", errorMessage("build", fmt.Errorf("plain")))
+}
+
+func TestRegisterCommands_NestedSubcommands(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"db": {
+				Description: "Database tasks",
+				Commands: map[string]config.Command{
+					"migrate": {
+						Description: "Migrations",
+						Commands: map[string]config.Command{
+							"up":   {Run: "echo up", Description: "Apply migrations"},
+							"down": {Run: "echo down"},
+						},
+					},
+				},
+			},
+			"release": {Run: "echo release", Depends: config.NewDependencyList("db:migrate:up")},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(cfg, exec)
+		root.Handler.setProgress(io.Discard)
+		root.registerCommands()
+		root.RootCmd.SetArgs(args)
+		assert.NoError(t, root.Execute())
+		return out.String()
+	}
+
+	t.Run("every level is registered", func(t *testing.T) {
+		root := NewRootCommand(cfg, executor.NewDefaultExecutor())
+		root.registerCommands()
+		up, _, err := root.RootCmd.Find([]string{"db", "migrate", "up"})
+		assert.NoError(t, err)
+		assert.Equal(t, "up", up.Name())
+		assert.Equal(t, "Apply migrations", up.Short)
+	})
+
+	t.Run("third level runs", func(t *testing.T) {
+		assert.Equal(t, "up\n", run(t, "db", "migrate", "up"))
+	})
+
+	t.Run("nested group lists its subcommands", func(t *testing.T) {
+		out := run(t, "db", "migrate")
+		assert.Contains(t, out, "Available subcommands for 'db:migrate'")
+		assert.Contains(t, out, "up")
+		assert.Contains(t, out, "down")
+	})
+
+	t.Run("dependencies reference nested subcommands", func(t *testing.T) {
+		assert.Equal(t, "[db:migrate:up] up\nrelease\n", run(t, "release"))
+	})
+
+	t.Run("fallback resolves nested subcommands from arguments", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		root := NewRootCommand(cfg, exec)
+		root.Handler.setProgress(io.Discard)
+		assert.True(t, root.tryExecuteSubcommand(root.RootCmd, "db", cfg.Commands["db"], []string{"migrate", "down"}, map[string]string{}))
+		assert.Equal(t, "down\n", out.String())
+		assert.False(t, root.tryExecuteSubcommand(root.RootCmd, "db", cfg.Commands["db"], []string{"seed"}, map[string]string{}))
+	})
+}
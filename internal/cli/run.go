@@ -0,0 +1,448 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/tracing"
+)
+
+// RunContext holds the state of a single top-level command execution. Every call
+// to CommandHandler.ExecuteCommand starts a new run; the dependencies it executes
+// share that run. The state is safe for concurrent use, so dependencies can be
+// executed from several goroutines.
+type RunContext struct {
+	ID         string                       // Unique identifier of the run (YXA_RUN_ID)
+	StartedAt  time.Time                    // Start time of the run (YXA_TIMESTAMP)
+	Context    context.Context              // Cancelled when the run is interrupted, e.g. by Ctrl-C
+	mu         sync.Mutex                   // Guards the fields below
+	executed   map[string]bool              // Commands already executed in this run
+	results    map[string]error             // Results of the commands that finished in this run
+	inFlight   map[string]*commandExecution // Commands currently executing by name
+	registered map[string]string            // Variables registered from command output in this run
+	outputs    map[string]string            // Stdout of the commands other commands need, by command name
+	scheduled  map[string]bool              // Commands the run executes, which commands with after wait for
+	early      map[string]error             // Results of commands run early for after, until they are reached
+	debugged   bool                         // A debug shell was already opened in this run
+	failures   int                          // Number of commands that failed in this run
+	failed     []string                     // Commands that caused a failure, in order
+	spans      map[string]*tracing.Span     // Span of each command, the parent of its hooks, tasks and dependencies
+	stats      map[string]*commandStats     // Executions of each command in this run, for --metrics-file
+	traced     map[string]bool              // Substitutions already logged by --debug-vars
+	sensitive  map[string]bool              // Values of sensitive variables and parameters seen in this run
+}
+
+// commandStats counts the executions of a command in a run
+type commandStats struct {
+	Executions int           // Times the command ran
+	Failures   int           // Times the command failed
+	CacheHits  int           // Times the result of an earlier execution was reused
+	Skipped    int           // Executions skipped because the command was up to date or its condition was not met
+	Duration   time.Duration // Total duration of the executions
+}
+
+// commandExecution tracks a command that is currently executing, so that other
+// branches of the run that depend on it can wait for its result
+type commandExecution struct {
+	parent string        // Command that executes this one, empty for the top-level command
+	done   chan struct{} // Closed when the command finished
+	err    error         // Result of the command, set before done is closed
+}
+
+// NewRunContext creates the state for a new run
+func NewRunContext() *RunContext {
+	return &RunContext{
+		ID:         newRunID(),
+		StartedAt:  time.Now().UTC(),
+		Context:    context.Background(),
+		executed:   make(map[string]bool),
+		results:    make(map[string]error),
+		inFlight:   make(map[string]*commandExecution),
+		registered: make(map[string]string),
+		outputs:    make(map[string]string),
+		early:      make(map[string]error),
+		spans:      make(map[string]*tracing.Span),
+		stats:      make(map[string]*commandStats),
+		traced:     make(map[string]bool),
+		sensitive:  make(map[string]bool),
+	}
+}
+
+// newRunID generates a short random identifier for a run
+func newRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to a time based identifier if the random source fails
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Executed reports whether the command was already executed in this run
+func (rc *RunContext) Executed(cmdName string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.executed[cmdName]
+}
+
+// Cancelled reports whether the run was interrupted
+func (rc *RunContext) Cancelled() bool {
+	return rc.Context.Err() == context.Canceled
+}
+
+// wait blocks until the command finished if another branch of the run is executing
+// it, and returns its result. It reports false if the command is not executing, or
+// is executing in the branch of parent, where waiting would never finish.
+func (rc *RunContext) wait(parent, cmdName string) (bool, error) {
+	rc.mu.Lock()
+	execution, ok := rc.inFlight[cmdName]
+	if !ok || rc.chain(parent)[cmdName] {
+		rc.mu.Unlock()
+		return false, nil
+	}
+	rc.mu.Unlock()
+
+	<-execution.done
+	return true, execution.err
+}
+
+// start registers the execution of a command by parent, unless the run executes it
+// already. The checks and the registration happen under one lock, so branches
+// running in parallel never execute the same command twice. If another branch is
+// executing the command, start waits for it; if it finished earlier in the run,
+// its result is reused. Either way reused is true with the result of the earlier
+// execution. It returns an error if the command is already executing in the
+// branch of parent, which means its dependencies form a cycle.
+func (rc *RunContext) start(parent, cmdName string) (execution *commandExecution, reused bool, err error) {
+	rc.mu.Lock()
+	if rc.chain(parent)[cmdName] {
+		defer rc.mu.Unlock()
+		return nil, false, rc.circularDependencyError(parent, cmdName)
+	}
+	if running, ok := rc.inFlight[cmdName]; ok {
+		rc.mu.Unlock()
+		<-running.done
+		return nil, true, running.err
+	}
+	defer rc.mu.Unlock()
+
+	if rc.executed[cmdName] {
+		// Commands that ran early for after report their result here, where they
+		// are scheduled
+		if err, ok := rc.early[cmdName]; ok {
+			delete(rc.early, cmdName)
+			return nil, true, err
+		}
+		return nil, true, rc.results[cmdName]
+	}
+	return rc.begin(parent, cmdName), false, nil
+}
+
+// enter registers the execution of a command by parent even if it already ran in
+// the run. If another branch is executing the command, enter waits for it to
+// finish first, so that a command never executes twice at the same time. It
+// returns an error if the command is already executing in the branch of parent,
+// which means its dependencies form a cycle.
+func (rc *RunContext) enter(parent, cmdName string) (*commandExecution, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for {
+		if rc.chain(parent)[cmdName] {
+			return nil, rc.circularDependencyError(parent, cmdName)
+		}
+		running, ok := rc.inFlight[cmdName]
+		if !ok {
+			return rc.begin(parent, cmdName), nil
+		}
+		rc.mu.Unlock()
+		<-running.done
+		rc.mu.Lock()
+	}
+}
+
+// begin marks a command executed by parent as executed and currently executing.
+// The caller must hold mu and make sure the command is not executing.
+func (rc *RunContext) begin(parent, cmdName string) *commandExecution {
+	execution := &commandExecution{parent: parent, done: make(chan struct{})}
+	rc.executed[cmdName] = true
+	rc.inFlight[cmdName] = execution
+	return execution
+}
+
+// circularDependencyError returns the error of a cycle closed by executing cmdName
+// from parent, with the full path of the cycle. The caller must hold mu.
+func (rc *RunContext) circularDependencyError(parent, cmdName string) error {
+	path := []string{}
+	for name := parent; name != cmdName; name = rc.inFlight[name].parent {
+		path = append([]string{name}, path...)
+	}
+	path = append([]string{cmdName}, path...)
+	return errors.NewCircularDependencyError(path, cmdName)
+}
+
+// leave marks a command as finished with the given result and wakes up the
+// branches waiting for it
+func (rc *RunContext) leave(cmdName string, execution *commandExecution, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	execution.err = err
+	rc.results[cmdName] = err
+	close(execution.done)
+	if rc.inFlight[cmdName] == execution {
+		delete(rc.inFlight, cmdName)
+	}
+}
+
+// chain returns the commands executing in the branch of cmdName: the command
+// itself and the commands that execute it. The caller must hold mu.
+func (rc *RunContext) chain(cmdName string) map[string]bool {
+	chain := make(map[string]bool)
+	for name := cmdName; name != "" && !chain[name]; {
+		execution, ok := rc.inFlight[name]
+		if !ok {
+			break
+		}
+		chain[name] = true
+		name = execution.parent
+	}
+	return chain
+}
+
+// executedCommands returns a copy of the commands already executed in this run
+func (rc *RunContext) executedCommands() map[string]bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	executed := make(map[string]bool, len(rc.executed))
+	for name := range rc.executed {
+		executed[name] = true
+	}
+	return executed
+}
+
+// markExecuted marks a command as executed without running it, e.g. in dry-run mode
+func (rc *RunContext) markExecuted(cmdName string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.executed[cmdName] = true
+}
+
+// registeredVars returns a copy of the variables registered in this run
+func (rc *RunContext) registeredVars() map[string]string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	vars := make(map[string]string, len(rc.registered))
+	for k, v := range rc.registered {
+		vars[k] = v
+	}
+	return vars
+}
+
+// register stores a variable registered from command output
+func (rc *RunContext) register(name, value string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.registered[name] = value
+}
+
+// output returns the stdout of a command captured earlier in this run for needs
+func (rc *RunContext) output(cmdName string) (string, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	output, ok := rc.outputs[cmdName]
+	return output, ok
+}
+
+// setOutput stores the captured stdout of a command, which commands that need it
+// later in this run reuse
+func (rc *RunContext) setOutput(cmdName, output string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.outputs[cmdName] = output
+}
+
+// setScheduled sets the commands the run executes
+func (rc *RunContext) setScheduled(scheduled map[string]bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.scheduled = scheduled
+}
+
+// isScheduled reports whether the run executes a command
+func (rc *RunContext) isScheduled(cmdName string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.scheduled[cmdName]
+}
+
+// setEarlyResult stores the result of a command that ran early because another
+// command runs after it
+func (rc *RunContext) setEarlyResult(cmdName string, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.early[cmdName] = err
+}
+
+// takeEarlyResult returns the result of a command that ran early, once, so that it
+// is reported where the command is scheduled. It reports false if the command did
+// not run early.
+func (rc *RunContext) takeEarlyResult(cmdName string) (bool, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	err, ok := rc.early[cmdName]
+	delete(rc.early, cmdName)
+	return ok, err
+}
+
+// claimDebug reports whether a debug shell may be opened, which is only the case
+// for the first failure of a run
+func (rc *RunContext) claimDebug() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.debugged {
+		return false
+	}
+	rc.debugged = true
+	return true
+}
+
+// failureCount returns the number of commands that failed in this run
+func (rc *RunContext) failureCount() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.failures
+}
+
+// recordFailure counts a failed command. It reports whether no other command
+// failed since failuresBefore, i.e. whether this command caused the failure.
+func (rc *RunContext) recordFailure(cmdName string, failuresBefore int) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	first := rc.failures == failuresBefore
+	rc.failures++
+	if first {
+		rc.failed = append(rc.failed, cmdName)
+	}
+	return first
+}
+
+// failedCommands returns the commands that caused a failure in this run, in the
+// order they failed
+func (rc *RunContext) failedCommands() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]string(nil), rc.failed...)
+}
+
+// skippedCommand reports whether a command was skipped in this run because it was
+// up to date or its condition was not met
+func (rc *RunContext) skippedCommand(cmdName string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	stats, ok := rc.stats[cmdName]
+	return ok && stats.Skipped > 0
+}
+
+// setSpan stores the span of a command, which is nil if tracing is disabled
+func (rc *RunContext) setSpan(cmdName string, span *tracing.Span) {
+	if span == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.spans[cmdName] = span
+}
+
+// span returns the span of a command, nil if it has none
+func (rc *RunContext) span(cmdName string) *tracing.Span {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.spans[cmdName]
+}
+
+// firstTrace reports whether a substitution is logged by --debug-vars for the
+// first time in this run, and marks it as logged
+func (rc *RunContext) firstTrace(key string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.traced[key] {
+		return false
+	}
+	rc.traced[key] = true
+	return true
+}
+
+// addSensitive remembers values that are masked in the output of the rest of the
+// run
+func (rc *RunContext) addSensitive(values ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, value := range values {
+		if value != "" {
+			rc.sensitive[value] = true
+		}
+	}
+}
+
+// sensitiveValues returns the values to mask in the output of the run
+func (rc *RunContext) sensitiveValues() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	values := make([]string, 0, len(rc.sensitive))
+	for value := range rc.sensitive {
+		values = append(values, value)
+	}
+	return values
+}
+
+// recordExecution counts a finished execution of a command, skipped if it did not
+// run because it was up to date or its condition was not met
+func (rc *RunContext) recordExecution(cmdName string, duration time.Duration, skipped bool, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	stats := rc.commandStats(cmdName)
+	stats.Executions++
+	if skipped {
+		stats.Skipped++
+	}
+	stats.Duration += duration
+	if err != nil {
+		stats.Failures++
+	}
+}
+
+// recordCacheHit counts a command that was not executed because it already ran
+// in this run
+func (rc *RunContext) recordCacheHit(cmdName string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.commandStats(cmdName).CacheHits++
+}
+
+// commandStats returns the stats of a command, creating them on first use. The
+// caller must hold mu.
+func (rc *RunContext) commandStats(cmdName string) *commandStats {
+	stats, ok := rc.stats[cmdName]
+	if !ok {
+		stats = &commandStats{}
+		rc.stats[cmdName] = stats
+	}
+	return stats
+}
+
+// statsSnapshot returns a copy of the stats of the commands of this run
+func (rc *RunContext) statsSnapshot() map[string]commandStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	snapshot := make(map[string]commandStats, len(rc.stats))
+	for name, stats := range rc.stats {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
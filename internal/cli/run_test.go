@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingExecutor records the commands it runs from several goroutines, and holds
+// the command block until release is closed, signalling started when it begins
+type blockingExecutor struct {
+	testExecutor
+	block    string
+	started  chan struct{}
+	release  chan struct{}
+	mu       sync.Mutex
+	executed []string
+}
+
+func newBlockingExecutor(block string) *blockingExecutor {
+	return &blockingExecutor{block: block, started: make(chan struct{}, 2), release: make(chan struct{})}
+}
+
+func (e *blockingExecutor) Execute(command string, timeout time.Duration) error {
+	e.mu.Lock()
+	e.executed = append(e.executed, command)
+	e.mu.Unlock()
+	if command == e.block {
+		e.started <- struct{}{}
+		<-e.release
+	}
+	return e.commandResults[command]
+}
+
+// count returns how often the executor ran command
+func (e *blockingExecutor) count(command string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, executed := range e.executed {
+		if executed == command {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRunContext_Wait(t *testing.T) {
+	run := NewRunContext()
+
+	waited, err := run.wait("", "lint")
+	assert.False(t, waited, "commands that are not executing are not waited for")
+	assert.NoError(t, err)
+
+	execution, err := run.enter("", "lint")
+	require.NoError(t, err)
+
+	waited, _ = run.wait("lint", "lint")
+	assert.False(t, waited, "a branch must not wait for itself")
+
+	result := make(chan error)
+	go func() {
+		_, err := run.wait("build", "lint")
+		result <- err
+	}()
+
+	select {
+	case <-result:
+		t.Fatal("wait returned before the command finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	failure := errors.New("lint failed")
+	run.leave("lint", execution, failure)
+	assert.Equal(t, failure, <-result)
+	assert.True(t, run.Executed("lint"))
+}
+
+func TestRunContext_EnterDetectsCyclesPerBranch(t *testing.T) {
+	run := NewRunContext()
+	_, err := run.enter("", "all")
+	require.NoError(t, err)
+	_, err = run.enter("all", "a")
+	require.NoError(t, err)
+	_, err = run.enter("all", "b")
+	require.NoError(t, err)
+
+	_, err = run.enter("a", "all")
+	require.Error(t, err)
+	assert.Equal(t, "command 'all': circular dependency detected: all -> a -> all", err.Error())
+
+	_, _, err = run.start("a", "all")
+	require.Error(t, err)
+	assert.Equal(t, "command 'all': circular dependency detected: all -> a -> all", err.Error())
+}
+
+func TestRunContext_StartRegistersOnce(t *testing.T) {
+	run := NewRunContext()
+	execution, reused, err := run.start("build", "generate")
+	require.NoError(t, err)
+	require.False(t, reused)
+	require.NotNil(t, execution)
+
+	// 'generate' is executing in the branch of 'build', which is no cycle for
+	// 'test': it waits for the execution instead of starting another one
+	type result struct {
+		execution *commandExecution
+		reused    bool
+		err       error
+	}
+	results := make(chan result)
+	go func() {
+		execution, reused, err := run.start("test", "generate")
+		results <- result{execution, reused, err}
+	}()
+
+	failure := errors.New("generate failed")
+	run.leave("generate", execution, failure)
+	waited := <-results
+	assert.Nil(t, waited.execution)
+	assert.True(t, waited.reused)
+	assert.Equal(t, failure, waited.err)
+
+	// Once it finished, the command is not started again
+	execution, reused, _ = run.start("lint", "generate")
+	assert.Nil(t, execution)
+	assert.True(t, reused)
+}
+
+func TestRunContext_EnterWaitsForRunningExecution(t *testing.T) {
+	run := NewRunContext()
+	first, err := run.enter("", "generate")
+	require.NoError(t, err)
+
+	entered := make(chan *commandExecution)
+	go func() {
+		second, _ := run.enter("", "generate")
+		entered <- second
+	}()
+
+	run.leave("generate", first, nil)
+	second := <-entered
+	require.NotNil(t, second)
+	assert.NotSame(t, first, second, "the command runs again once the first execution finished")
+	run.leave("generate", second, nil)
+}
+
+func TestCommandHandler_ConcurrentBranchesShareDependency(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "go generate ./..."},
+			"build":    {Run: "go build", Depends: config.NewDependencyList("generate")},
+			"test":     {Run: "go test", Depends: config.NewDependencyList("generate")},
+			"all":      {Depends: config.NewDependencyList("build", "test")},
+		},
+	}
+	exec := newBlockingExecutor("go generate ./...")
+	handler := NewCommandHandler(cfg, exec)
+
+	// Run both branches of 'all' at the same time, like parallel dependencies would
+	entered, err := handler.RunContext().enter("", "all")
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, branch := range []string{"build", "test"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = handler.executeCommand("all", branch, nil)
+		}()
+	}
+	// Hold the shared dependency until the first branch started it
+	<-exec.started
+	close(exec.release)
+	wg.Wait()
+	handler.RunContext().leave("all", entered, nil)
+
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, 1, exec.count("go generate ./..."), "the shared dependency runs exactly once")
+	assert.ElementsMatch(t, []string{"go generate ./...", "go build", "go test"}, exec.executed)
+	assert.Equal(t, "go generate ./...", exec.executed[0], "dependents run after the shared dependency")
+}
+
+func TestCommandHandler_WaitingBranchGetsDependencyError(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "go generate ./..."},
+			"build":    {Run: "go build", Depends: config.NewDependencyList("generate")},
+			"test":     {Run: "go test", Depends: config.NewDependencyList("generate")},
+		},
+	}
+	exec := newBlockingExecutor("go generate ./...")
+	exec.commandResults = map[string]error{"go generate ./...": errors.New("exit status 1")}
+	handler := NewCommandHandler(cfg, exec)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, branch := range []string{"build", "test"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = handler.executeCommand("", branch, nil)
+		}()
+	}
+	<-exec.started
+	close(exec.release)
+	wg.Wait()
+
+	for _, err := range errs {
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to execute dependency 'generate'")
+	}
+	assert.Equal(t, []string{"go generate ./..."}, exec.executed)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// validateRunner checks the runner of a command
+func (h *CommandHandler) validateRunner(cmdName string, cmd config.Command) error {
+	if cmd.Runner == nil {
+		return nil
+	}
+	if cmd.Container != nil {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'runner' and 'container'", nil)
+	}
+	if !executor.IsRegistered(cmd.Runner.Name) {
+		return errors.NewCommandConfigError(cmdName, fmt.Sprintf("unknown runner '%s', available runners: %s",
+			cmd.Runner.Name, strings.Join(executor.Runners(), ", ")), nil)
+	}
+	if cmd.Runner.Name == executor.LocalRunner {
+		return nil
+	}
+	if len(cmd.Steps) > 0 {
+		return errors.NewCommandConfigError(cmdName, "cannot combine 'runner' and 'steps'", nil)
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use 'runner'", nil)
+	}
+	return nil
+}
+
+// resolvedRunner returns the runner of a command with variables resolved in its
+// options, or nil if the command runs on the host
+func (h *CommandHandler) resolvedRunner(cmdName string, cmd config.Command, cmdVars map[string]string) *config.Runner {
+	runner := cmd.RunnerConfig()
+	if runner == nil || runner.Name == executor.LocalRunner {
+		return nil
+	}
+	return runner.Expand(func(value string) string {
+		return h.replaceVariablesInString(cmdName, value, cmdVars)
+	})
+}
+
+// describeRunner returns a short description of the runner of a command for
+// dry-run and explain output, or "" if the command runs on the host
+func (h *CommandHandler) describeRunner(cmdName string, cmd config.Command, cmdVars map[string]string) string {
+	runner := h.resolvedRunner(cmdName, cmd, cmdVars)
+	if runner == nil {
+		return ""
+	}
+	if runner.Name == executor.DockerRunner {
+		var spec executor.ContainerSpec
+		if err := runner.Decode(&spec); err == nil {
+			return runner.Name + " " + describeContainer(&spec)
+		}
+	}
+	return runner.Name
+}
+
+// withRunner runs fn with the executor of the handler replaced by one created by
+// the runner of cmd. Parallel jobs of the command get their own executor from the
+// same runner. Commands that run on the host run fn as is.
+func (h *CommandHandler) withRunner(cmdName string, cmd config.Command, cmdVars map[string]string, fn func() error) error {
+	runner := h.resolvedRunner(cmdName, cmd, cmdVars)
+	if runner == nil || h.DryRun {
+		return fn()
+	}
+
+	newExecutor := func() (executor.CommandExecutor, error) {
+		exec, err := executor.New(runner.Name, runner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create runner '%s' for '%s': %w", runner.Name, cmdName, err)
+		}
+		return exec, nil
+	}
+
+	runnerExec, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	runnerExec.SetStdout(h.Executor.GetStdout())
+	runnerExec.SetStderr(h.Executor.GetStderr())
+
+	h.printf("Running '%s' with runner %s\n", cmdName, runner.Name)
+	if preparer, ok := runnerExec.(executor.Preparer); ok {
+		if err := preparer.Prepare(h.RunContext().Context); err != nil {
+			return fmt.Errorf("failed to prepare runner '%s' for '%s': %w", runner.Name, cmdName, err)
+		}
+	}
+
+	hostExec, hostJobExecutor := h.Executor, h.newJobExecutor
+	h.Executor, h.newJobExecutor = runnerExec, newExecutor
+	defer func() { h.Executor, h.newJobExecutor = hostExec, hostJobExecutor }()
+
+	return fn()
+}
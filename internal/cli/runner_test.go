@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixExecutor runs commands on the host behind a configurable prefix, like a
+// runner that wraps commands in ssh or kubectl exec would
+type prefixExecutor struct {
+	*executor.DefaultExecutor
+	prefix string
+}
+
+func (e *prefixExecutor) ExecuteContext(ctx context.Context, cmdStr string, timeout time.Duration) error {
+	return e.DefaultExecutor.ExecuteContext(ctx, e.prefix+cmdStr, timeout)
+}
+
+func (e *prefixExecutor) ExecuteWithOutputContext(ctx context.Context, cmdStr string, timeout time.Duration) (string, error) {
+	return e.DefaultExecutor.ExecuteWithOutputContext(ctx, e.prefix+cmdStr, timeout)
+}
+
+func init() {
+	executor.Register("test-prefix", func(opts executor.Options) (executor.CommandExecutor, error) {
+		var options struct {
+			Prefix string `yaml:"prefix"`
+		}
+		if err := opts.Decode(&options); err != nil {
+			return nil, err
+		}
+		return &prefixExecutor{DefaultExecutor: executor.NewDefaultExecutor(), prefix: options.Prefix}, nil
+	})
+}
+
+func TestCommandHandler_Runner(t *testing.T) {
+	runner, err := config.NewRunner("test-prefix", map[string]string{"prefix": "echo $TARGET: "})
+	require.NoError(t, err)
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"TARGET": "remote"},
+		Commands: map[string]config.Command{
+			"deploy": {Run: "deploying", Pre: "echo pre", Runner: runner},
+			"check":  {Tasks: config.NewTaskList("one", "two"), Parallel: true, Runner: runner},
+			"local":  {Run: "echo local", Runner: &config.Runner{Name: executor.LocalRunner}},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec), out
+	}
+
+	t.Run("run uses the runner, hooks run on the host", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("deploy", nil))
+		assert.Equal(t, "pre\nremote: deploying\n", out.String())
+		assert.IsType(t, &executor.DefaultExecutor{}, handler.Executor)
+	})
+
+	t.Run("parallel jobs use the runner", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("check", nil))
+		assert.Contains(t, out.String(), "[#1] remote: one")
+		assert.Contains(t, out.String(), "[#2] remote: two")
+	})
+
+	t.Run("local runner", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("local", nil))
+		assert.Equal(t, "local\n", out.String())
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		handler, out := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("deploy", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would run with runner: test-prefix")
+	})
+}
+
+func TestValidateRunner(t *testing.T) {
+	h := NewCommandHandler(&config.ProjectConfig{}, executor.NewDefaultExecutor())
+	local := &config.Runner{Name: executor.LocalRunner}
+	prefix := &config.Runner{Name: "test-prefix"}
+
+	assert.NoError(t, h.validateRunner("build", config.Command{Run: "make"}))
+	assert.NoError(t, h.validateRunner("build", config.Command{Run: "make", Runner: prefix}))
+	assert.NoError(t, h.validateRunner("web", config.Command{Run: "serve", Service: true, Runner: local}))
+	assert.ErrorContains(t, h.validateRunner("build", config.Command{Run: "make", Runner: &config.Runner{Name: "wsl"}}),
+		"config error in command 'build': unknown runner 'wsl', available runners: docker, local")
+	assert.EqualError(t, h.validateRunner("build", config.Command{Run: "make", Runner: prefix, Container: &config.Container{Image: "alpine"}}),
+		"config error in command 'build': cannot combine 'runner' and 'container'")
+	assert.EqualError(t, h.validateRunner("build", config.Command{Steps: []config.Step{{}}, Runner: prefix}),
+		"config error in command 'build': cannot combine 'runner' and 'steps'")
+	assert.EqualError(t, h.validateRunner("web", config.Command{Run: "serve", Service: true, Runner: prefix}),
+		"config error in command 'web': a service cannot use 'runner'")
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SARIF 2.1.0, the format of 'yxa lint --format sarif'
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// lintRules describes the rules of lint findings for the SARIF log
+var lintRules = []sarifRule{
+	{ID: lintRuleInvalidCommand, ShortDescription: sarifMessage{"Command that fails to run because of its configuration"}},
+	{ID: lintRuleUnknownField, ShortDescription: sarifMessage{"Key that is not part of the config format and is ignored"}},
+	{ID: lintRuleUnknownCommand, ShortDescription: sarifMessage{"Dependency or task that refers to a command that does not exist"}},
+	{ID: lintRuleUndefinedVariable, ShortDescription: sarifMessage{"Reference to a variable that is not defined"}},
+	{ID: lintRuleUnusedParameter, ShortDescription: sarifMessage{"Parameter that is never used"}},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeSARIF writes lint findings in the config file at path as a SARIF log
+func writeSARIF(out io.Writer, path string, findings []lintFinding) error {
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.Rule,
+			Level:   "error",
+			Message: sarifMessage{f.Scope + ": " + f.Message},
+		}
+		if path != "" {
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: sarifURI(path)}}
+			if f.Line > 0 {
+				location.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "yxa",
+				InformationURI: "https://github.com/floppa/yxa-cli",
+				Rules:          lintRules,
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// sarifURI returns the URI of a file in a SARIF log: relative to the working
+// directory, usually the root of the repository, if the file is below it
+func sarifURI(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return "file://" + filepath.ToSlash(path)
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+// newScaffoldCommand creates the built-in 'new' command, which creates a project
+// from a local or git-hosted template
+func (r *RootCommand) newScaffoldCommand() *cobra.Command {
+	var useDefaults bool
+
+	cmd := &cobra.Command{
+		Use:   "new <template> <name>",
+		Short: "Create a project from a template",
+		Long: `Create the directory <name> from a template, or add the template to it if it
+exists. A template is a directory with files to copy, a yxa.yml snippet whose
+commands and variables are added to the yxa.yml of the project, and an optional
+template.yml that declares the variables the template asks for:
+
+  description: Go service
+  variables:
+    - name: MODULE
+      prompt: Go module path
+      default: example.com/{{ .NAME }}
+
+Files ending in .tmpl and the yxa.yml snippet are rendered as Go templates with
+the variables, e.g. {{ .MODULE }}, and .tmpl is removed from their names. NAME
+holds the base name of the project directory.
+
+<template> is a directory, the name of a template in ~/.yxa/templates or
+$XDG_CONFIG_HOME/yxa/templates, or a git repository such as
+https://github.com/acme/templates.git#v1, where #v1 selects a branch or tag.
+Values passed with --set are not asked for.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		// Templates create projects that have no config yet
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.scaffold(cmd, args[0], args[1], useDefaults)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&useDefaults, "yes", "y", false, "Use the default of every variable instead of asking for it")
+
+	return cmd
+}
+
+// scaffold renders the template source into the directory dest
+func (r *RootCommand) scaffold(cmd *cobra.Command, source, dest string, useDefaults bool) error {
+	dir := ""
+	if scaffold.IsGitSource(source) {
+		tmp, err := os.MkdirTemp("", "yxa-template-")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+		dir = filepath.Join(tmp, "template")
+		if err := scaffold.Clone(cmd.Context(), source, dir); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if dir, err = scaffold.Lookup(source, scaffold.Dirs()); err != nil {
+			return err
+		}
+	}
+
+	tmpl, err := scaffold.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := parseVariableOverrides(r.SetVars, r.SetFiles)
+	if err != nil {
+		return err
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	vars := map[string]string{scaffold.NameVariable: filepath.Base(absDest)}
+	for key, value := range overrides {
+		vars[key] = value
+	}
+
+	out := cmd.OutOrStdout()
+	useDefaults = useDefaults || !r.interactive(cmd.InOrStdin())
+	if err := askVariables(cmd.InOrStdin(), out, tmpl.Manifest.Variables, vars, useDefaults); err != nil {
+		return err
+	}
+
+	created, err := tmpl.Render(dest, vars)
+	for _, path := range created {
+		fmt.Fprintf(out, "created %s\n", filepath.Join(dest, path))
+	}
+	return err
+}
+
+// askVariables asks for the value of every template variable that is not in vars
+// yet. An empty answer takes the default, which can reference earlier variables.
+// With useDefaults nothing is asked and variables without a default are an error.
+func askVariables(in io.Reader, out io.Writer, vars []scaffold.Variable, values map[string]string, useDefaults bool) error {
+	reader := bufio.NewReader(in)
+	for _, v := range vars {
+		if _, ok := values[v.Name]; ok {
+			continue
+		}
+		def, err := renderDefault(v, values)
+		if err != nil {
+			return err
+		}
+		if useDefaults {
+			if v.Default == "" {
+				return fmt.Errorf("variable '%s' has no default, pass it with --set %s=VALUE", v.Name, v.Name)
+			}
+			values[v.Name] = def
+			continue
+		}
+
+		prompt := v.Prompt
+		if prompt == "" {
+			prompt = v.Name
+		}
+		if def != "" {
+			prompt += " [" + def + "]"
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			// The input ended without a newline, end the prompt line
+			fmt.Fprintln(out)
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			if def == "" {
+				return fmt.Errorf("no value for variable '%s', pass it with --set %s=VALUE", v.Name, v.Name)
+			}
+			answer = def
+		}
+		values[v.Name] = answer
+	}
+	return nil
+}
+
+// renderDefault returns the default of a variable with the values given so far
+func renderDefault(v scaffold.Variable, values map[string]string) (string, error) {
+	if !strings.Contains(v.Default, "{{") {
+		return v.Default, nil
+	}
+	rendered, err := scaffold.RenderString(v.Name, v.Default, values)
+	if err != nil {
+		return "", fmt.Errorf("invalid default of variable '%s': %w", v.Name, err)
+	}
+	return rendered, nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/scaffold"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffoldCommand(t *testing.T) {
+	tmplDir := t.TempDir()
+	files := map[string]string{
+		scaffold.ManifestFile: `variables:
+  - name: MODULE
+    prompt: Go module path
+    default: example.com/{{ .NAME }}
+  - name: OWNER
+`,
+		scaffold.ConfigFile: "commands:\n  build:\n    run: go build # {{ .OWNER }}\n",
+		"go.mod.tmpl":       "module {{ .MODULE }}\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(tmplDir, name), []byte(content), 0o644))
+	}
+	t.Chdir(t.TempDir()) // there is no yxa.yml to load
+
+	run := func(t *testing.T, stdin string, args ...string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		root := NewRootCommand(nil, executor.NewDefaultExecutor())
+		root.RootCmd.SetIn(strings.NewReader(stdin))
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(append([]string{"new", tmplDir}, args...))
+		err := root.RootCmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("asks for variables", func(t *testing.T) {
+		out, err := run(t, "\nteam-a\n", "svc")
+		require.NoError(t, err)
+		assert.Equal(t, "Go module path [example.com/svc]: OWNER: created svc/go.mod\ncreated svc/yxa.yml\n", filepath.ToSlash(out))
+
+		data, err := os.ReadFile(filepath.Join("svc", "go.mod"))
+		require.NoError(t, err)
+		assert.Equal(t, "module example.com/svc\n", string(data))
+		data, err = os.ReadFile(filepath.Join("svc", "yxa.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "commands:\n  build:\n    run: go build # team-a\n", string(data))
+	})
+
+	t.Run("set values are not asked for", func(t *testing.T) {
+		out, err := run(t, "", "api", "--set", "OWNER=team-b", "--set", "MODULE=example.com/x/api")
+		require.NoError(t, err)
+		assert.NotContains(t, out, "Go module path")
+
+		data, err := os.ReadFile(filepath.Join("api", "go.mod"))
+		require.NoError(t, err)
+		assert.Equal(t, "module example.com/x/api\n", string(data))
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		_, err := run(t, "", "web", "--yes")
+		assert.EqualError(t, err, "variable 'OWNER' has no default, pass it with --set OWNER=VALUE")
+
+		_, err = run(t, "", "web", "-y", "--set", "OWNER=team-c")
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join("web", "go.mod"))
+	})
+
+	t.Run("non-interactive", func(t *testing.T) {
+		out, err := run(t, "team-d\n", "job", "--non-interactive")
+		assert.EqualError(t, err, "variable 'OWNER' has no default, pass it with --set OWNER=VALUE")
+		assert.NotContains(t, out, "Go module path")
+
+		_, err = run(t, "", "job", "--non-interactive", "--set", "OWNER=team-d")
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join("job", "go.mod"))
+		require.NoError(t, err)
+		assert.Equal(t, "module example.com/job\n", string(data))
+	})
+
+	t.Run("no answer", func(t *testing.T) {
+		_, err := run(t, "", "cli")
+		assert.EqualError(t, err, "no value for variable 'OWNER', pass it with --set OWNER=VALUE")
+		assert.NoDirExists(t, "cli")
+	})
+
+	t.Run("adds to an existing project", func(t *testing.T) {
+		require.NoError(t, os.Mkdir("lib", 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join("lib", "yxa.yml"), []byte("name: lib\ncommands:\n  test:\n    run: go test\n"), 0o644))
+		_, err := run(t, "", "lib", "-y", "--set", "OWNER=team-d")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join("lib", "yxa.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "name: lib\ncommands:\n  test:\n    run: go test\n  build:\n    run: go build # team-d\n", string(data))
+
+		_, err = run(t, "", "svc", "--set", "OWNER=team-a")
+		assert.ErrorContains(t, err, "already exists")
+	})
+}
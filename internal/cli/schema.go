@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newSchemaCommand creates the built-in 'schema' command, which prints the JSON
+// Schema of yxa.yml
+func (r *RootCommand) newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of yxa.yml",
+		Long: `Print the JSON Schema of the config format, for editors to complete and validate
+yxa.yml. With the YAML language server, save it and point to it from the first
+line of yxa.yml:
+
+  yxa schema > yxa.schema.json
+  # yaml-language-server: $schema=yxa.schema.json`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		// The schema is the same for every config, and helps to write the first one
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := json.MarshalIndent(config.Schema(), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	root, out := setupEnvTestRoot(&config.ProjectConfig{Commands: map[string]config.Command{}})
+	root.RootCmd.SetArgs([]string{"schema"})
+	require.NoError(t, root.Execute())
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &schema))
+	assert.Equal(t, config.SchemaDraft, schema["$schema"])
+	assert.Contains(t, schema["properties"], "commands")
+}
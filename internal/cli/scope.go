@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// cleanEnvKeys are the variables of the environment of yxa that commands running
+// with inherit_env: false still get, so that programs can be found
+var cleanEnvKeys = []string{"PATH", "HOME"}
+
+// commandScope returns the variables a command declares and whether it inherits
+// the environment of yxa. Subcommands see the variables of their parents, shadowed
+// by their own, and inherit their inherit_env unless they set it themselves.
+func (h *CommandHandler) commandScope(cmdName string) (map[string]string, bool) {
+	if cmdName == "" || h.Config == nil {
+		return nil, true
+	}
+	cmd, err := h.lookupCommand(cmdName)
+	if err != nil {
+		return nil, true
+	}
+	return cmd.Variables, cmd.InheritsEnv()
+}
+
+// exportsEnv reports whether a command exports its variables to the environment
+// of its processes
+func (h *CommandHandler) exportsEnv(cmdName string) bool {
+	if cmdName == "" || h.Config == nil {
+		return false
+	}
+	cmd, err := h.lookupCommand(cmdName)
+	return err == nil && cmd.ExportsEnv()
+}
+
+// cleanEnv returns the environment of a command that does not inherit the one of
+// yxa: PATH and HOME, and the variables known to yxa for the command
+func (h *CommandHandler) cleanEnv(cmdName string, cmdVars map[string]string) []string {
+	var env []string
+	for _, key := range cleanEnvKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	for _, v := range h.resolver(cmdName, cmdVars).Variables(false) {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
+
+// exportedEnv returns the environment of a command with export_env: env, the one
+// of yxa if nil, extended with the variables known to yxa for the command, which
+// take precedence
+func (h *CommandHandler) exportedEnv(cmdName string, cmdVars map[string]string, env []string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	exported := append([]string(nil), env...)
+	for _, v := range h.resolver(cmdName, cmdVars).Variables(false) {
+		exported = append(exported, v.Name+"="+v.Value)
+	}
+	return exported
+}
+
+// withEnvironment runs fn with the executor set to run commands in a clean
+// environment if cmd does not inherit the one of yxa, or with its variables
+// exported if it sets export_env. Executors that cannot set the environment, such
+// as containers, are isolated already and run fn as is.
+func (h *CommandHandler) withEnvironment(cmdName string, cmdVars map[string]string, fn func() error) error {
+	_, inherit := h.commandScope(cmdName)
+	if (inherit && !h.exportsEnv(cmdName)) || h.DryRun {
+		return fn()
+	}
+	envExec, ok := h.Executor.(executor.EnvExecutor)
+	if !ok {
+		return fn()
+	}
+
+	env := envExec.GetEnv()
+	if inherit {
+		envExec.SetEnv(h.exportedEnv(cmdName, cmdVars, env))
+	} else {
+		envExec.SetEnv(h.cleanEnv(cmdName, cmdVars))
+	}
+	defer envExec.SetEnv(env)
+
+	return fn()
+}
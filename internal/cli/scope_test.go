@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCommandScope(t *testing.T) {
+	t.Setenv("YXA_TEST_HOST_VAR", "host")
+
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+variables:
+  TARGET: local
+  REGION: eu
+commands:
+  deploy:
+    variables:
+      TARGET: staging
+    pre: echo pre $TARGET
+    run: echo deploy $TARGET $REGION
+  status:
+    run: echo status $TARGET
+  isolated:
+    inherit_env: false
+    variables:
+      GREETING: hello
+    run: echo "[$YXA_TEST_HOST_VAR] [${GREETING}] $(printenv GREETING) $(printenv REGION)"
+  exported:
+    export_env: true
+    variables:
+      TARGET: staging
+    run: sh -c 'echo "$YXA_TEST_HOST_VAR $TARGET $REGION"'
+  scripts:
+    export_env: true
+    variables:
+      TARGET: staging
+    commands:
+      sub:
+        run: printenv TARGET
+      opted-out:
+        export_env: false
+        run: printenv TARGET || echo unset
+  tools:
+    variables:
+      TARGET: tools
+    commands:
+      gen:
+        run: echo gen $TARGET
+      lint:
+        variables:
+          TARGET: lint
+        run: echo lint $TARGET
+`), &cfg))
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = &cfg
+		root.Handler = NewCommandHandler(&cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.registerCommands()
+		root.RootCmd.SetArgs(args)
+		require.NoError(t, root.Execute())
+		return out.String()
+	}
+
+	t.Run("command variables shadow project variables", func(t *testing.T) {
+		assert.Equal(t, "pre staging\ndeploy staging eu\n", run(t, "deploy"))
+		assert.Equal(t, "status local\n", run(t, "status"))
+	})
+
+	t.Run("set overrides command variables", func(t *testing.T) {
+		assert.Equal(t, "pre prod\ndeploy prod eu\n", run(t, "deploy", "--set", "TARGET=prod"))
+	})
+
+	t.Run("subcommands see the variables of their parent", func(t *testing.T) {
+		assert.Equal(t, "gen tools\n", run(t, "tools", "gen"))
+		assert.Equal(t, "lint lint\n", run(t, "tools", "lint"))
+	})
+
+	t.Run("clean environment", func(t *testing.T) {
+		assert.Equal(t, "[] [hello] hello eu\n", run(t, "isolated"))
+	})
+
+	t.Run("exported variables", func(t *testing.T) {
+		assert.Equal(t, "host staging eu\n", run(t, "exported"))
+		assert.Equal(t, "prod\n", run(t, "scripts", "sub", "--set", "TARGET=prod"))
+		assert.Equal(t, "unset\n", run(t, "scripts", "opted-out"))
+	})
+}
+
+func TestCommandInheritance(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+variables:
+  REGION: eu
+commands:
+  platform:
+    timeout: 100ms
+    workingdir: /srv/platform
+    inherit_env: false
+    variables:
+      TIER: platform
+      OWNER: infra
+    params:
+      - name: env
+        type: string
+        flag: true
+        default: dev
+    commands:
+      services:
+        workingdir: /srv/services
+        variables:
+          TIER: services
+        commands:
+          api:
+            variables:
+              NAME: api
+            run: echo "$NAME $TIER $OWNER $REGION $env [$(printenv OWNER)]"
+          slow:
+            run: sleep 1
+          patient:
+            timeout: 5s
+            inherit_env: true
+            run: echo patient
+`), &cfg))
+
+	t.Run("settings are inherited through every level", func(t *testing.T) {
+		h := NewCommandHandler(&cfg, executor.NewDefaultExecutor())
+		cmd, err := h.lookupCommand("platform:services:api")
+		require.NoError(t, err)
+		assert.Equal(t, "100ms", cmd.Timeout)
+		assert.Equal(t, "/srv/services", cmd.WorkingDir)
+		assert.False(t, cmd.InheritsEnv())
+		assert.Equal(t, map[string]string{"TIER": "services", "OWNER": "infra", "NAME": "api"}, cmd.Variables)
+
+		cmd, err = h.lookupCommand("platform:services:patient")
+		require.NoError(t, err)
+		assert.Equal(t, "5s", cmd.Timeout)
+		assert.True(t, cmd.InheritsEnv())
+
+		_, err = h.lookupCommand("platform:services:missing")
+		assert.ErrorContains(t, err, "subcommand 'missing' not found")
+	})
+
+	t.Run("third level runs with inherited variables, flags and environment", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		root := NewRootCommand(nil, exec)
+		root.Config = &cfg
+		root.Handler = NewCommandHandler(&cfg, exec)
+		root.Handler.setProgress(&bytes.Buffer{})
+		root.registerCommands()
+		root.RootCmd.SetArgs([]string{"platform", "services", "api", "--env", "prod"})
+		require.NoError(t, root.Execute())
+		assert.Equal(t, "api services infra eu prod [infra]\n", out.String())
+	})
+
+	t.Run("third level inherits the timeout", func(t *testing.T) {
+		h := NewCommandHandler(&cfg, executor.NewDefaultExecutor())
+		h.setProgress(&bytes.Buffer{})
+		assert.ErrorContains(t, h.ExecuteCommand("platform:services:slow", nil), "timed out")
+		assert.NoError(t, h.ExecuteCommand("platform:services:patient", nil))
+	})
+}
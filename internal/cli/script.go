@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/steps"
+)
+
+// downloadsDirName is the directory below the state directory in which download
+// steps cache files
+const downloadsDirName = "downloads"
+
+// runScriptSteps executes the built-in steps of a script command in order. Like
+// sequential tasks, it stops at the first failing step unless keep-going mode or
+// continue_on_error is enabled.
+func (h *CommandHandler) runScriptSteps(cmdName string, cmd config.Command, cmdVars map[string]string, timeout time.Duration) error {
+	resolver := h.resolver(cmdName, cmdVars)
+
+	scriptSteps, err := h.newScriptSteps(cmdName, cmd, cmdVars)
+	if err != nil {
+		return err
+	}
+
+	ctx := h.RunContext().Context
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	stepCtx := &steps.Context{
+		Context:  ctx,
+		Vars:     make(map[string]string),
+		Check:    h.Check,
+		CacheDir: filepath.Join(h.stateDir(), downloadsDirName),
+		Output:   h.conditionOutput(cmdName),
+	}
+	for _, v := range resolver.Variables(true) {
+		stepCtx.Vars[v.Name] = v.Value
+	}
+
+	continueOnError := h.KeepGoing || cmd.ContinueOnError
+	var failures []string
+
+	for i, step := range scriptSteps {
+		label := steps.Label(cmd.Steps[i], step)
+		h.printf("Executing step #%d (%s) for '%s': %s\n", i+1, label, cmdName, step.Describe())
+
+		if err := step.Run(stepCtx); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = errors.NewExecutionTimeoutError(timeout, "", err)
+			}
+			if !continueOnError || h.RunContext().Cancelled() {
+				return fmt.Errorf("step #%d (%s) for '%s' failed: %w", i+1, label, cmdName, err)
+			}
+			h.printf("Step #%d (%s) for '%s' failed: %v\n", i+1, label, cmdName, err)
+			failures = append(failures, fmt.Sprintf("#%d (%s): %v", i+1, label, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("one or more steps for '%s' failed: %s", cmdName, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// newScriptSteps creates the steps of a script command with variables resolved
+func (h *CommandHandler) newScriptSteps(cmdName string, cmd config.Command, cmdVars map[string]string) ([]steps.Step, error) {
+	resolve := h.resolver(cmdName, cmdVars).Resolve
+
+	result := make([]steps.Step, 0, len(cmd.Steps))
+	for i, cfg := range cmd.Steps {
+		step, err := steps.New(cfg, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("step #%d (%s) for '%s' is invalid: %w", i+1, steps.Label(cfg, nil), cmdName, err)
+		}
+		result = append(result, step)
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_ScriptSteps(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "version.tmpl"), []byte("{{ .APP }} {{ .version }}"), 0600); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"APP": "yxa", "DIR": dir},
+		Commands: map[string]config.Command{
+			"package": {
+				Params: []config.Param{
+					{Name: "version", Type: "string", Default: "1.0.0", Flag: true},
+				},
+				Steps: []config.Step{
+					{Name: "render", Template: &config.TemplateStep{Src: "$DIR/version.tmpl", Dest: "$DIR/dist/VERSION"}},
+					{Copy: &config.CopyStep{From: "$DIR/dist/VERSION", To: "$DIR/dist/VERSION-$version"}},
+					{Archive: &config.ArchiveStep{Src: "$DIR/dist", Dest: "$DIR/$APP-$version.zip"}},
+				},
+			},
+			"broken": {
+				Steps: []config.Step{
+					{Copy: &config.CopyStep{From: "$DIR/missing", To: "$DIR/out"}},
+					{Copy: &config.CopyStep{From: "$DIR/version.tmpl", To: "$DIR/copied.tmpl"}},
+				},
+			},
+			"tolerant": {
+				ContinueOnError: true,
+				Steps: []config.Step{
+					{Copy: &config.CopyStep{From: "$DIR/missing", To: "$DIR/out"}},
+					{Copy: &config.CopyStep{From: "$DIR/version.tmpl", To: "$DIR/tolerant.tmpl"}},
+				},
+			},
+			"verify": {
+				Steps: []config.Step{
+					{Assert: &config.AssertStep{Condition: "$APP == yxa"}},
+					{Name: "healthy", Assert: &config.AssertStep{Condition: "$APP == other", Message: "unexpected app $APP"}},
+					{Copy: &config.CopyStep{From: "$DIR/version.tmpl", To: "$DIR/verified.tmpl"}},
+				},
+			},
+			"invalid": {
+				Steps: []config.Step{{Name: "nothing"}},
+			},
+		},
+	}
+
+	exec := &recordingExecutor{testExecutor: testExecutor{stdout: io.Discard, stderr: io.Discard}}
+
+	t.Run("runs steps without a shell", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		require.NoError(t, handler.ExecuteCommand("package", nil))
+
+		data, err := os.ReadFile(filepath.Join(dir, "dist", "VERSION-1.0.0"))
+		require.NoError(t, err)
+		assert.Equal(t, "yxa 1.0.0", string(data))
+		assert.FileExists(t, filepath.Join(dir, "yxa-1.0.0.zip"))
+		assert.Empty(t, exec.executed)
+	})
+
+	t.Run("stops at the first failing step", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand("broken", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "step #1 (copy) for 'broken' failed")
+		assert.NoFileExists(t, filepath.Join(dir, "copied.tmpl"))
+	})
+
+	t.Run("continue_on_error runs the remaining steps", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand("tolerant", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "one or more steps for 'tolerant' failed: #1 (copy)")
+		assert.FileExists(t, filepath.Join(dir, "tolerant.tmpl"))
+	})
+
+	t.Run("failed assertion stops the command", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand("verify", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "step #2 (healthy) for 'verify' failed: unexpected app yxa")
+		assert.NoFileExists(t, filepath.Join(dir, "verified.tmpl"))
+	})
+
+	t.Run("invalid step", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand("invalid", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "step #1 (nothing) for 'invalid' is invalid: step has no type")
+	})
+
+	t.Run("check fails on outdated template output", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetCheck(true)
+		require.NoError(t, handler.ExecuteCommand("package", nil))
+
+		err := handler.ExecuteCommand("package", map[string]string{"version": "2.0.0"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "step #1 (render) for 'package' failed: "+filepath.Join(dir, "dist", "VERSION")+" is out of date")
+		data, err := os.ReadFile(filepath.Join(dir, "dist", "VERSION"))
+		require.NoError(t, err)
+		assert.Equal(t, "yxa 1.0.0", string(data))
+	})
+
+	t.Run("dry-run describes the steps", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		handler := NewCommandHandler(cfg, &recordingExecutor{testExecutor: testExecutor{stdout: out, stderr: out}})
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("package", map[string]string{"version": "2.0.0"}))
+
+		output := out.String()
+		assert.Contains(t, output, "[dry-run] Would run step #1: render: render "+dir+"/version.tmpl to "+dir+"/dist/VERSION")
+		assert.Contains(t, output, "[dry-run] Would run step #3: archive: archive "+dir+"/dist to "+dir+"/yxa-2.0.0.zip (zip)")
+		assert.NoFileExists(t, filepath.Join(dir, "yxa-2.0.0.zip"))
+	})
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/secrets"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newSecretCommand creates the built-in 'secret' command, which manages the
+// encrypted variables file of the config
+func (r *RootCommand) newSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage the encrypted variables of the config",
+		Long: `Manage the file that encrypted_variables in yxa.yml points to, a YAML map of
+variables encrypted with sops, or with age if its name ends in .age.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	var global bool
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the encrypted variables and encrypt them again on save",
+		Long: `Open the decrypted encrypted_variables file in your editor ($VISUAL or $EDITOR)
+and encrypt it again when the editor exits. sops files are edited with
+'sops edit'. age files are decrypted with your age identity ($YXA_AGE_KEY_FILE,
+$SOPS_AGE_KEY_FILE or the default keys.txt of sops) to a private temporary file
+and encrypted for its recipient; a missing file is created.
+
+With --global, edit the encrypted global config ~/.yxa.yml.enc instead. It is
+encrypted with a key kept in the keychain of the OS, or in $YXA_CONFIG_KEY; a
+missing config is created, along with a key if there is none.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if global {
+				if !r.interactive(cmd.InOrStdin()) {
+					return errNonInteractive("open an editor", "edit the file in a terminal")
+				}
+				path, err := config.EncryptedGlobalConfigPath()
+				if err != nil {
+					return err
+				}
+				return secrets.EditConfig(path, validateConfigData, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+			}
+			if r.Config == nil {
+				return fmt.Errorf("no configuration loaded")
+			}
+			path := r.Config.EncryptedVariablesPath()
+			if path == "" {
+				return fmt.Errorf("yxa.yml sets no encrypted_variables file")
+			}
+			if !r.interactive(cmd.InOrStdin()) {
+				return errNonInteractive("open an editor", "edit the file in a terminal")
+			}
+			return secrets.Edit(path, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+	editCmd.Flags().BoolVar(&global, "global", false, "Edit the encrypted global config ~/.yxa.yml.enc")
+	cmd.AddCommand(editCmd)
+
+	return cmd
+}
+
+// warnSecretVars warns that the encrypted variables could not be decrypted, e.g.
+// because the key is not available. Commands that need them see them as unset.
+func (r *RootCommand) warnSecretVars() {
+	if r.Config == nil || r.Config.SecretVarsError() == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: encrypted variables of %s not loaded: %v\n", r.Config.EncryptedVariables, r.Config.SecretVarsError())
+}
+
+// validateConfigData checks that an edited config is valid YAML for yxa.yml
+func validateConfigData(data []byte) error {
+	var cfg config.ProjectConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// warnGlobalConfig warns that the encrypted global config could not be loaded
+// because its key is not available. Its commands and variables are missing.
+func (r *RootCommand) warnGlobalConfig() {
+	if r.Config == nil || r.Config.GlobalConfigError() == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: encrypted global config not loaded: %v\n", r.Config.GlobalConfigError())
+}
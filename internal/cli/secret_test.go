@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedVariables(t *testing.T) {
+	// A fake sops that "decrypts" by printing the file
+	tools := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tools, "sops"), []byte("#!/bin/sh\n[ \"$1\" = \"--decrypt\" ] && cat \"$4\"\n"), 0o755))
+	t.Setenv("PATH", tools+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.enc.yml"), []byte("DB_PASS: hunter2\nREGION: shadowed\n"), 0o600))
+	require.NoError(t, os.WriteFile(path, []byte(`
+encrypted_variables: secrets.enc.yml
+variables:
+  REGION: eu
+commands:
+  migrate:
+    run: echo migrate $REGION $DB_PASS
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+	require.NoError(t, cfg.SecretVarsError())
+
+	t.Run("resolved", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		require.NoError(t, handler.ExecuteCommand("migrate", nil))
+		assert.Contains(t, out.String(), "migrate eu ***", "config variables shadow encrypted ones, which are masked")
+	})
+
+	t.Run("masked in env", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"env"})
+		require.NoError(t, root.Execute())
+		assert.Regexp(t, `DB_PASS\s+\*+\s+encrypted`, out.String())
+		assert.NotContains(t, out.String(), "hunter2")
+	})
+
+	t.Run("masked in dry run", func(t *testing.T) {
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		handler := NewCommandHandler(cfg, exec)
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("migrate", nil))
+		assert.Contains(t, out.String(), "echo migrate eu ***")
+	})
+
+	t.Run("masked in explain", func(t *testing.T) {
+		root, out := setupEnvTestRoot(cfg)
+		root.RootCmd.SetArgs([]string{"explain", "migrate"})
+		require.NoError(t, root.Execute())
+		assert.Contains(t, out.String(), "echo migrate eu ***")
+		assert.NotContains(t, out.String(), "hunter2")
+	})
+
+	t.Run("key not available", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		cfg, err := config.LoadConfigFrom(path)
+		require.NoError(t, err, "the config still loads")
+		assert.ErrorContains(t, cfg.SecretVarsError(), "sops is not installed")
+		assert.Empty(t, cfg.SecretVars())
+	})
+
+	t.Run("edit without file", func(t *testing.T) {
+		root, _ := setupEnvTestRoot(&config.ProjectConfig{Commands: map[string]config.Command{}})
+		root.RootCmd.SetArgs([]string{"secret", "edit"})
+		assert.ErrorContains(t, root.Execute(), "sets no encrypted_variables file")
+	})
+}
+
+func TestEncryptedGlobalConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(secrets.ConfigKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+
+	editor := filepath.Join(t.TempDir(), "editor")
+	editWith := func(t *testing.T, content string) error {
+		t.Helper()
+		require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\ncat >> \"$1\" <<'EOF'\n"+content+"EOF\n"), 0o755))
+		t.Setenv("VISUAL", editor)
+		root, _ := setupEnvTestRoot(&config.ProjectConfig{Commands: map[string]config.Command{}})
+		root.RootCmd.SetIn(strings.NewReader(""))
+		root.RootCmd.SetArgs([]string{"secret", "edit", "--global"})
+		return root.Execute()
+	}
+
+	require.NoError(t, editWith(t, "variables:\n  TOKEN: abc\ncommands:\n  login:\n    run: echo login $TOKEN\n"))
+	data, err := os.ReadFile(filepath.Join(home, ".yxa.yml.enc"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "TOKEN")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte("root: true\ncommands:\n  build:\n    run: go build\n"), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+	assert.Equal(t, "echo login $TOKEN", cfg.Commands["login"].Run)
+	assert.Equal(t, "abc", cfg.Variables["TOKEN"])
+
+	t.Run("invalid config is not written", func(t *testing.T) {
+		err := editWith(t, "commands: [\n")
+		assert.ErrorContains(t, err, "invalid config")
+		assert.ErrorContains(t, err, "is unchanged")
+		unchanged, err := os.ReadFile(filepath.Join(home, ".yxa.yml.enc"))
+		require.NoError(t, err)
+		assert.Equal(t, data, unchanged)
+	})
+}
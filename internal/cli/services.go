@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// stateDirName is the directory next to the config file in which yxa keeps the
+// state and logs of services
+const stateDirName = ".yxa"
+
+// defaultStopTimeout is how long 'yxa down' waits for a service to exit before killing it
+const defaultStopTimeout = 10 * time.Second
+
+// followInterval is how often 'yxa logs --follow' checks for new output
+const followInterval = 200 * time.Millisecond
+
+// newUpCommand creates the built-in 'up' command, which starts services in the background
+func (r *RootCommand) newUpCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "up [service...]",
+		Short: "Start services in the background",
+		Long: `Start the given services, or every command with 'service: true', in the
+background. Services they depend on are started first; other dependencies run as
+usual before the service starts. Output goes to .yxa/logs/<service>.log.`,
+		ValidArgsFunction: r.completeCommandNames(0, isService),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.servicesUp(cmd.OutOrStdout(), args)
+		},
+	}
+}
+
+// newDownCommand creates the built-in 'down' command, which stops running services
+func (r *RootCommand) newDownCommand() *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "down [service...]",
+		Short: "Stop services started with yxa up",
+		Long: `Stop the given services, or every service started with 'yxa up', in reverse
+dependency order. A service that does not exit within --timeout after SIGTERM is killed.`,
+		ValidArgsFunction: r.completeCommandNames(0, isService),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.servicesDown(cmd.OutOrStdout(), args, timeout)
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultStopTimeout, "Time to wait for a service to exit before killing it")
+
+	return cmd
+}
+
+// newStatusCommand creates the built-in 'status' command, which lists the services
+// and the commands running in the background
+func (r *RootCommand) newStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the status of the services and of the commands started with yxa start",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := r.servicesStatus(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			return r.backgroundStatus(cmd.OutOrStdout())
+		},
+	}
+}
+
+// newLogsCommand creates the built-in 'logs' command, which prints the output of a service
+func (r *RootCommand) newLogsCommand() *cobra.Command {
+	var follow, stderr bool
+
+	cmd := &cobra.Command{
+		Use:   "logs <service|command>",
+		Short: "Show the output of a service, of a command started with yxa start or of a command with a log_file",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: r.completeCommandNames(1, func(c config.Command) bool {
+			return c.Service || c.LogFile != nil || c.StderrFile != nil
+		}),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.showLogs(cmd.Context(), cmd.OutOrStdout(), args[0], follow, stderr)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new output until interrupted")
+	cmd.Flags().BoolVar(&stderr, "stderr", false, "Show the stderr_file of the command instead of its log_file")
+
+	return cmd
+}
+
+// serviceManager returns the manager for the services of the loaded config
+func (r *RootCommand) serviceManager() *services.Manager {
+	base := r.Config.ConfigDir()
+	if base == "" {
+		base = "."
+	}
+	return services.NewManager(filepath.Join(base, stateDirName))
+}
+
+// serviceNames returns the names of all commands marked as services, sorted
+func (r *RootCommand) serviceNames() []string {
+	var names []string
+	for _, c := range flattenCommands(r.Config) {
+		if c.Command.Service {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// lookupService returns a service command, or an error if name is not a service
+func (r *RootCommand) lookupService(name string) (config.Command, error) {
+	cmd, err := r.Handler.lookupCommand(name)
+	if err != nil {
+		return config.Command{}, err
+	}
+	if !cmd.Service {
+		return config.Command{}, fmt.Errorf("command '%s' is not a service, set 'service: true' to run it with yxa up", name)
+	}
+	if err := r.Handler.validateService(name, cmd); err != nil {
+		return config.Command{}, err
+	}
+	return cmd, nil
+}
+
+// validateService checks that a service command is a single long-running process
+func (h *CommandHandler) validateService(cmdName string, cmd config.Command) error {
+	if !cmd.Service {
+		return nil
+	}
+	if cmd.Run == "" {
+		return errors.NewCommandConfigError(cmdName, "a service requires 'run'", nil)
+	}
+	if len(cmd.Matrix) > 0 || cmd.Foreach != "" {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use 'matrix' or 'foreach'", nil)
+	}
+	return nil
+}
+
+// serviceStartOrder returns the given services together with the services they
+// depend on, ordered so that every service comes after its dependencies
+func (r *RootCommand) serviceStartOrder(names []string) ([]string, error) {
+	var order []string
+	done := make(map[string]bool)
+	active := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if active[name] {
+			return fmt.Errorf("circular dependency detected at service '%s'", name)
+		}
+		cmd, err := r.lookupService(name)
+		if err != nil {
+			return err
+		}
+
+		active[name] = true
+		for _, dep := range cmd.Depends.Commands() {
+			if depCmd, err := r.Handler.lookupCommand(dep); err == nil && depCmd.Service {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		active[name] = false
+
+		done[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// servicesUp starts the given services, or all services if none are given
+func (r *RootCommand) servicesUp(out io.Writer, names []string) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	if len(names) == 0 {
+		names = r.serviceNames()
+		if len(names) == 0 {
+			return fmt.Errorf("no services defined, set 'service: true' on a command")
+		}
+	}
+
+	order, err := r.serviceStartOrder(names)
+	if err != nil {
+		return err
+	}
+
+	r.configureHandler()
+	manager := r.serviceManager()
+	for _, name := range order {
+		cmd, _ := r.lookupService(name)
+
+		status, err := manager.Status(name)
+		if err != nil {
+			return err
+		}
+		if status.Running {
+			fmt.Fprintf(out, "Service '%s' is already running (pid %d)\n", name, status.PID)
+			continue
+		}
+
+		// Dependencies that are not services run to completion first
+		vars := r.Handler.paramDefaults(name, cmd)
+		for _, dep := range cmd.Depends {
+			if depCmd, err := r.Handler.lookupCommand(dep.Command); err == nil && depCmd.Service {
+				continue
+			}
+			if !r.Handler.dependencyConditionMet(name, dep, vars) {
+				continue
+			}
+			if err := r.Handler.ExecuteCommand(dep.Command, vars); err != nil {
+				return fmt.Errorf("failed to execute dependency '%s' for service '%s': %w", dep.Command, name, err)
+			}
+		}
+
+		if r.DryRun {
+			fmt.Fprintf(out, "[dry-run] Would start service '%s': %s\n", name, r.Handler.replaceVariablesInString(name, cmd.Run, vars))
+			continue
+		}
+		state, err := manager.Start(name, r.Handler.replaceVariablesInString(name, cmd.Run, vars))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Started service '%s' (pid %d), logs in %s\n", name, state.PID, state.Log)
+	}
+	return nil
+}
+
+// servicesDown stops the given services, or all started services if none are given
+func (r *RootCommand) servicesDown(out io.Writer, names []string, timeout time.Duration) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	manager := r.serviceManager()
+
+	if len(names) == 0 {
+		for _, name := range r.serviceNames() {
+			if status, err := manager.Status(name); err == nil && status.Known {
+				names = append(names, name)
+			}
+		}
+	}
+
+	// Stop dependents before the services they depend on
+	order, err := r.serviceStartOrder(names)
+	if err != nil {
+		return err
+	}
+	requested := make(map[string]bool)
+	for _, name := range names {
+		requested[name] = true
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		if !requested[name] {
+			continue
+		}
+		status, err := manager.Status(name)
+		if err != nil {
+			return err
+		}
+		if !status.Known {
+			fmt.Fprintf(out, "Service '%s' is not running\n", name)
+			continue
+		}
+		if r.DryRun {
+			fmt.Fprintf(out, "[dry-run] Would stop service '%s' (pid %d)\n", name, status.PID)
+			continue
+		}
+		if err := manager.Stop(name, timeout); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Stopped service '%s'\n", name)
+	}
+	return nil
+}
+
+// servicesStatus writes a table with the status of every service
+func (r *RootCommand) servicesStatus(out io.Writer) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	names := r.serviceNames()
+	if len(names) == 0 {
+		_, err := fmt.Fprintln(out, "No services defined")
+		return err
+	}
+
+	manager := r.serviceManager()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "SERVICE\tSTATUS\tPID\tSTARTED"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		status, err := manager.Status(name)
+		if err != nil {
+			return err
+		}
+
+		state, pid, started := "stopped", "-", "-"
+		if status.Known {
+			state = "exited"
+			if status.Running {
+				state = "running"
+			}
+			pid = fmt.Sprint(status.PID)
+			started = status.StartedAt.Local().Format(time.DateTime)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, state, pid, started); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return w.Flush()
+}
+
+// serviceLogs writes the log of a service to out. With follow, it keeps writing
+// new output until ctx is cancelled.
+func (r *RootCommand) serviceLogs(ctx context.Context, out io.Writer, name string, follow bool) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	if _, err := r.lookupService(name); err != nil {
+		return err
+	}
+
+	f, err := os.Open(r.serviceManager().LogPath(name))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no logs for service '%s', start it with yxa up", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open logs of service '%s': %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return followFile(ctx, out, f, follow)
+}
+
+// followFile copies f to out. With follow set, it keeps copying what is written to
+// f until ctx is done.
+func followFile(ctx context.Context, out io.Writer, f *os.File, follow bool) error {
+	if _, err := io.Copy(out, f); err != nil {
+		return err
+	}
+	if !follow {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := io.Copy(out, f); err != nil {
+				return err
+			}
+		}
+	}
+}
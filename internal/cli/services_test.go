@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCommands(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"PORT": "8080"},
+		Commands: map[string]config.Command{
+			"migrate": {Run: "echo migrating > migrated.txt"},
+			"db":      {Run: "echo db started; exec sleep 30", Service: true},
+			"api":     {Run: "echo api on $PORT; exec sleep 30", Service: true, Depends: config.NewDependencyList("db", "migrate")},
+			"build":   {Run: "echo building"},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	exec := executor.NewDefaultExecutor()
+	exec.SetStdout(out)
+	exec.SetStderr(out)
+	root := NewRootCommand(nil, exec)
+	root.Config = cfg
+	root.Handler = NewCommandHandler(cfg, exec)
+	t.Cleanup(func() { _ = root.servicesDown(&bytes.Buffer{}, nil, time.Second) })
+
+	t.Run("up starts dependencies first", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, root.servicesUp(out, []string{"api"}))
+
+		db := bytes.Index(out.Bytes(), []byte("Started service 'db'"))
+		api := bytes.Index(out.Bytes(), []byte("Started service 'api'"))
+		assert.True(t, db >= 0 && api > db, "db must start before api:\n%s", out.String())
+		assert.FileExists(t, "migrated.txt")
+		assert.FileExists(t, filepath.Join(".yxa", "services", "api.json"))
+	})
+
+	t.Run("up skips running services", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, root.servicesUp(out, nil))
+		assert.Contains(t, out.String(), "Service 'api' is already running")
+		assert.Contains(t, out.String(), "Service 'db' is already running")
+	})
+
+	t.Run("status", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, root.servicesStatus(out))
+		assert.Regexp(t, `api\s+running\s+\d+`, out.String())
+		assert.Regexp(t, `db\s+running\s+\d+`, out.String())
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		var logs bytes.Buffer
+		require.Eventually(t, func() bool {
+			logs.Reset()
+			return root.serviceLogs(context.Background(), &logs, "api", false) == nil && logs.Len() > 0
+		}, 2*time.Second, 20*time.Millisecond)
+		assert.Equal(t, "api on 8080\n", logs.String())
+	})
+
+	t.Run("not a service", func(t *testing.T) {
+		err := root.servicesUp(out, []string{"build"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'build' is not a service")
+	})
+
+	t.Run("down stops services", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, root.servicesDown(out, nil, time.Second))
+		assert.Equal(t, "Stopped service 'api'\nStopped service 'db'\n", out.String())
+
+		_, err := os.Stat(filepath.Join(".yxa", "services", "api.json"))
+		assert.True(t, os.IsNotExist(err))
+
+		out.Reset()
+		require.NoError(t, root.servicesStatus(out))
+		assert.Regexp(t, `api\s+stopped\s+-`, out.String())
+	})
+}
+
+func TestValidateService(t *testing.T) {
+	h := NewCommandHandler(&config.ProjectConfig{}, executor.NewDefaultExecutor())
+
+	assert.NoError(t, h.validateService("web", config.Command{Run: "serve", Service: true}))
+	assert.NoError(t, h.validateService("build", config.Command{Tasks: config.NewTaskList("make")}))
+	assert.EqualError(t, h.validateService("web", config.Command{Tasks: config.NewTaskList("serve"), Service: true}), "config error in command 'web': a service requires 'run'")
+	assert.EqualError(t, h.validateService("web", config.Command{Run: "serve $ITEM", Foreach: "*", Service: true}), "config error in command 'web': a service cannot use 'matrix' or 'foreach'")
+}
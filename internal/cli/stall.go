@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// commandStall parses the stall_timeout and stall_action of a command
+func (h *CommandHandler) commandStall(cmdName string, cmd config.Command) (executor.Stall, error) {
+	var stall executor.Stall
+	switch cmd.StallAction {
+	case "", config.StallActionKill:
+		stall.Kill = true
+	case config.StallActionWarn:
+	default:
+		return stall, errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid stall_action '%s': expected '%s' or '%s'",
+			cmd.StallAction, config.StallActionKill, config.StallActionWarn), nil)
+	}
+	if cmd.StallTimeout == "" {
+		if cmd.StallAction != "" {
+			return stall, errors.NewCommandConfigError(cmdName, "stall_action requires stall_timeout", nil)
+		}
+		return stall, nil
+	}
+	timeout, err := h.parseTimeout(cmdName, cmd.StallTimeout)
+	if err != nil {
+		return stall, err
+	}
+	if timeout <= 0 {
+		return stall, errors.NewCommandConfigError(cmdName, fmt.Sprintf("invalid stall_timeout '%s': must be positive", cmd.StallTimeout), nil)
+	}
+	stall.Timeout = timeout
+	return stall, nil
+}
+
+// validateStall checks the stall_timeout and stall_action of a command
+func (h *CommandHandler) validateStall(cmdName string, cmd config.Command) error {
+	stall, err := h.commandStall(cmdName, cmd)
+	if err != nil || stall.Timeout == 0 {
+		return err
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use stall_timeout", nil)
+	}
+	return nil
+}
+
+// describeStall returns the stall_timeout of a command for explain output, or ""
+// if it has none
+func describeStall(cmd config.Command) string {
+	if cmd.StallTimeout == "" {
+		return ""
+	}
+	action := cmd.StallAction
+	if action == "" {
+		action = config.StallActionKill
+	}
+	return fmt.Sprintf("%s without output (%s)", cmd.StallTimeout, action)
+}
+
+// withStall runs fn with the executor set to watch the output of the shell
+// commands of cmd, warning about or stopping them when they produce no output for
+// the stall_timeout of cmd. Unlike the timeout it applies to the hooks as well.
+func (h *CommandHandler) withStall(cmdName string, cmd config.Command, fn func() error) error {
+	stall, err := h.commandStall(cmdName, cmd)
+	if err != nil {
+		return err
+	}
+	if stall.Timeout == 0 || h.DryRun {
+		return fn()
+	}
+
+	stallExec, ok := h.Executor.(executor.StallExecutor)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: stall_timeout of '%s' is not enforced by this executor\n", cmdName)
+		return fn()
+	}
+	previous := stallExec.GetStall()
+	stallExec.SetStall(stall)
+	defer stallExec.SetStall(previous)
+
+	return fn()
+}
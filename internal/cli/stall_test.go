@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stallRecordingExecutor records the stall handling every command runs with
+type stallRecordingExecutor struct {
+	testExecutor
+	stall executor.Stall
+	seen  []executor.Stall
+}
+
+func (e *stallRecordingExecutor) Execute(command string, timeout time.Duration) error {
+	e.seen = append(e.seen, e.stall)
+	return nil
+}
+
+func (e *stallRecordingExecutor) GetStall() executor.Stall      { return e.stall }
+func (e *stallRecordingExecutor) SetStall(stall executor.Stall) { e.stall = stall }
+
+func TestCommandStall(t *testing.T) {
+	h := NewCommandHandler(&config.ProjectConfig{}, &testExecutor{})
+
+	stall, err := h.commandStall("test", config.Command{StallTimeout: "2m"})
+	require.NoError(t, err)
+	assert.Equal(t, executor.Stall{Timeout: 2 * time.Minute, Kill: true}, stall)
+
+	stall, err = h.commandStall("test", config.Command{StallTimeout: "30s", StallAction: config.StallActionWarn})
+	require.NoError(t, err)
+	assert.Equal(t, executor.Stall{Timeout: 30 * time.Second}, stall)
+
+	tests := map[string]struct {
+		cmd  config.Command
+		want string
+	}{
+		"invalid timeout": {
+			cmd:  config.Command{Run: "make", StallTimeout: "soon"},
+			want: "invalid timeout 'soon'",
+		},
+		"negative timeout": {
+			cmd:  config.Command{Run: "make", StallTimeout: "-1s"},
+			want: "invalid stall_timeout '-1s': must be positive",
+		},
+		"invalid action": {
+			cmd:  config.Command{Run: "make", StallTimeout: "1m", StallAction: "ignore"},
+			want: "invalid stall_action 'ignore': expected 'kill' or 'warn'",
+		},
+		"action without timeout": {
+			cmd:  config.Command{Run: "make", StallAction: config.StallActionWarn},
+			want: "stall_action requires stall_timeout",
+		},
+		"service": {
+			cmd:  config.Command{Run: "serve", Service: true, StallTimeout: "1m"},
+			want: "config error in command 'test': a service cannot use stall_timeout",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := h.validateStall("test", tt.cmd)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestCommandHandler_Stall(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"prepare": {Run: "prepare"},
+			"test": {
+				Run:          "go test ./...",
+				Pre:          "echo pre",
+				Depends:      config.NewDependencyList("prepare"),
+				StallTimeout: "2m",
+			},
+		},
+	}
+	exec := &stallRecordingExecutor{testExecutor: testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}}
+	h := NewCommandHandler(cfg, exec)
+	h.setProgress(&bytes.Buffer{})
+
+	require.NoError(t, h.ExecuteCommand("test", nil))
+	watched := executor.Stall{Timeout: 2 * time.Minute, Kill: true}
+	assert.Equal(t, []executor.Stall{{}, watched, watched}, exec.seen, "the dependency is not watched, the hook and command are")
+	assert.Equal(t, executor.Stall{}, exec.stall, "the stall handling is restored")
+}
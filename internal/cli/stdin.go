@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/executor"
+)
+
+// validateStdin checks that a command with stdin runs a single script
+func (h *CommandHandler) validateStdin(cmdName string, cmd config.Command) error {
+	if cmd.Stdin == "" {
+		return nil
+	}
+	if cmd.Run == "" || len(cmd.Matrix) > 0 || cmd.Foreach != "" {
+		return errors.NewCommandConfigError(cmdName, "can only use stdin with 'run', not with 'tasks', 'steps', 'matrix' or 'foreach'", nil)
+	}
+	if cmd.Service {
+		return errors.NewCommandConfigError(cmdName, "a service cannot use stdin", nil)
+	}
+	return nil
+}
+
+// commandStdin returns the input of a command with variables resolved. A single
+// line naming an existing file, relative to the directory of the config file,
+// stands for the contents of that file, which are passed as they are. Anything
+// else is the input itself.
+func (h *CommandHandler) commandStdin(cmdName string, cmd config.Command, cmdVars map[string]string) (string, error) {
+	value := h.replaceVariablesInString(cmdName, cmd.Stdin, cmdVars)
+	if strings.Contains(value, "\n") {
+		return value, nil
+	}
+
+	path := value
+	if !filepath.IsAbs(path) {
+		base := "."
+		if h.Config != nil && h.Config.ConfigDir() != "" {
+			base = h.Config.ConfigDir()
+		}
+		path = filepath.Join(base, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return value, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- the path comes from the config
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin of command '%s': %w", cmdName, err)
+	}
+	return string(data), nil
+}
+
+// withStdin runs fn with the executor set to feed the stdin of cmd to the commands
+// it runs
+func (h *CommandHandler) withStdin(cmdName string, cmd config.Command, cmdVars map[string]string, fn func() error) error {
+	if cmd.Stdin == "" || h.DryRun {
+		return fn()
+	}
+	input, err := h.commandStdin(cmdName, cmd, cmdVars)
+	if err != nil {
+		return err
+	}
+	stdinExec, ok := h.Executor.(executor.StdinExecutor)
+	if !ok {
+		return fmt.Errorf("command '%s' cannot use stdin with this executor", cmdName)
+	}
+
+	previous := stdinExec.GetStdin()
+	stdinExec.SetStdin(strings.NewReader(input))
+	defer stdinExec.SetStdin(previous)
+
+	return fn()
+}
+
+// describeStdin returns the stdin of a command for explain output: the file or
+// single line, or the number of lines of multi-line input
+func describeStdin(stdin string) string {
+	lines := strings.Count(strings.TrimRight(stdin, "\n"), "\n") + 1
+	if lines > 1 {
+		return fmt.Sprintf("%d lines of inline input", lines)
+	}
+	return stdin
+}
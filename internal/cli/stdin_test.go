@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_Stdin(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "seed.sql"), []byte("insert into users values ('$NAME');\n"), 0o644))
+	path := filepath.Join(dir, "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: app
+variables:
+  NAME: alice
+  FILE: seed.sql
+commands:
+  seed:
+    run: cat
+    stdin: $FILE
+  greet:
+    run: cat
+    stdin: |
+      hello $NAME
+      bye
+  echo:
+    run: cat
+    stdin: not-a-file.txt
+  register:
+    run: head -n 1
+    stdin: "one"
+    register: {var: FIRST}
+  tasks:
+    tasks: [cat]
+    stdin: input
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	run := func(t *testing.T, name string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&out)
+		exec.SetStderr(&out)
+		handler := NewCommandHandler(cfg, exec)
+		err := handler.ExecuteCommand(name, nil)
+		return out.String(), err
+	}
+
+	t.Run("file", func(t *testing.T) {
+		out, err := run(t, "seed")
+		require.NoError(t, err)
+		assert.Contains(t, out, "insert into users values ('$NAME');\n", "file contents are passed as they are")
+	})
+
+	t.Run("inline", func(t *testing.T) {
+		out, err := run(t, "greet")
+		require.NoError(t, err)
+		assert.Contains(t, out, "hello alice\nbye\n")
+	})
+
+	t.Run("single line that is no file", func(t *testing.T) {
+		out, err := run(t, "echo")
+		require.NoError(t, err)
+		assert.Contains(t, out, "not-a-file.txt")
+	})
+
+	t.Run("register", func(t *testing.T) {
+		out, err := run(t, "register")
+		require.NoError(t, err)
+		assert.Contains(t, out, "one")
+	})
+
+	t.Run("only with run", func(t *testing.T) {
+		_, err := run(t, "tasks")
+		assert.EqualError(t, err, "config error in command 'tasks': can only use stdin with 'run', not with 'tasks', 'steps', 'matrix' or 'foreach'")
+	})
+
+	t.Run("executor without stdin", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, &testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}})
+		err := handler.ExecuteCommand("greet", nil)
+		assert.EqualError(t, err, "command 'greet' cannot use stdin with this executor")
+	})
+}
+
+func TestDescribeStdin(t *testing.T) {
+	assert.Equal(t, "seed.sql", describeStdin("seed.sql"))
+	assert.Equal(t, "2 lines of inline input", describeStdin("a\nb\n"))
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"sync"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// currentUsername returns the name of the user yxa runs as, empty if it is unknown
+var currentUsername = sync.OnceValue(func() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+})
+
+// refreshSudo checks that sudo runs commands without asking for a password,
+// extending the lifetime of the cached credentials
+var refreshSudo = func() error {
+	return exec.Command("sudo", "-n", "-v").Run()
+}
+
+// promptSudo lets sudo ask for the password on the terminal and cache the
+// credentials, so the commands that follow run without asking
+var promptSudo = func() error {
+	cmd := exec.Command("sudo", "-v")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	return cmd.Run()
+}
+
+// sudoUser returns the user the shell commands of a command run as with sudo: its
+// user, or root with sudo. It is empty if they run as the user of yxa.
+func sudoUser(cmd config.Command) string {
+	target := cmd.User
+	if target == "" && cmd.Sudo {
+		target = "root"
+	}
+	if target == currentUsername() {
+		return ""
+	}
+	return target
+}
+
+// validateUser checks the user of a command
+func validateUser(cmdName string, cmd config.Command) error {
+	if strings.ContainsAny(cmd.User, " \t\n") || strings.HasPrefix(cmd.User, "-") {
+		return fmt.Errorf("invalid user '%s' for command '%s': expected a user name", cmd.User, cmdName)
+	}
+	return nil
+}
+
+// authorizeSudo makes sure that sudo runs the shell commands of a command without
+// asking for a password. Once the credentials are cached, sudo asks again only
+// after they expire; the prompt is on the terminal, before the command runs.
+func (h *CommandHandler) authorizeSudo(cmdName string) error {
+	err := refreshSudo()
+	if err == nil {
+		return nil
+	}
+	if stderrors.Is(err, exec.ErrNotFound) {
+		return errors.NewCommandError(cmdName, "sudo is not on PATH", nil)
+	}
+	if h.NonInteractive || !stdinIsTerminal() {
+		return errors.NewCommandError(cmdName, "sudo needs a password, which cannot be asked in non-interactive mode; run 'sudo -v' first", nil)
+	}
+	h.printf("Command '%s' runs with sudo\n", cmdName)
+	if err := promptSudo(); err != nil {
+		return errors.NewCommandError(cmdName, "sudo authentication failed", err)
+	}
+	return nil
+}
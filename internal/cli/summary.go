@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// writeStepSummary appends a report of the finished run to the job summary of
+// GitHub Actions, the markdown file named by GITHUB_STEP_SUMMARY. Failing to
+// write it is reported as a warning, it does not fail the command.
+func (r *RootCommand) writeStepSummary(cmdName string, err error) {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" || r.DryRun {
+		return
+	}
+	failure := ""
+	if err != nil {
+		failure = r.Handler.maskSecrets(errorMessage(cmdName, err))
+	}
+	var b bytes.Buffer
+	writeRunSummary(&b, cmdName, r.Handler.RunContext(), time.Now(), failure)
+
+	f, openErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if openErr == nil {
+		_, openErr = f.Write(b.Bytes())
+		if closeErr := f.Close(); openErr == nil {
+			openErr = closeErr
+		}
+	}
+	if openErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the job summary: %v\n", openErr)
+	}
+}
+
+// writeRunSummary writes a markdown report of a run of cmdName: its outcome, a
+// table of the commands it executed and failure, the message of the error it
+// failed with, if any
+func writeRunSummary(out io.Writer, cmdName string, run *RunContext, now time.Time, failure string) {
+	outcome := "succeeded"
+	if failure != "" {
+		outcome = "failed"
+	}
+	fmt.Fprintf(out, "### yxa %s %s in %ss\n\n", cmdName, outcome, formatSeconds(now.Sub(run.StartedAt)))
+
+	stats := run.statsSnapshot()
+	fmt.Fprintln(out, "| Command | Result | Duration | Executions | Skipped | Cache hits |")
+	fmt.Fprintln(out, "| --- | --- | ---: | ---: | ---: | ---: |")
+	for _, name := range sortedKeys(stats) {
+		s := stats[name]
+		fmt.Fprintf(out, "| `%s` | %s | %ss | %d | %d | %d |\n",
+			name, summaryResult(s), formatSeconds(s.Duration), s.Executions, s.Skipped, s.CacheHits)
+	}
+
+	if failure != "" {
+		fmt.Fprintf(out, "\n```text\n%s\n```\n", failure)
+	}
+	fmt.Fprintln(out)
+}
+
+// summaryResult returns the result of a command in the job summary
+func summaryResult(s commandStats) string {
+	switch {
+	case s.Failures > 0:
+		return "failed"
+	case s.Executions > 0 && s.Skipped == s.Executions:
+		return "skipped"
+	case s.Executions == 0:
+		return "reused"
+	}
+	return "ok"
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepSummary(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "true"},
+			"lint":     {Run: "true", Depends: config.NewDependencyList("generate")},
+			"test":     {Run: "false", Depends: config.NewDependencyList("generate")},
+			"ci":       {Depends: config.NewDependencyList("lint", "test"), DependsMode: config.DependsModeAll},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "summary.md")
+		require.NoError(t, os.WriteFile(path, []byte("# Earlier step\n\n"), 0o644))
+		t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&bytes.Buffer{})
+		exec.SetStderr(&bytes.Buffer{})
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(&bytes.Buffer{})
+		root.RootCmd.SetErr(&bytes.Buffer{})
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		exitFunc = func(int) {}
+		require.NoError(t, root.Execute())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("failed run", func(t *testing.T) {
+		summary := run(t, "ci")
+		assert.Regexp(t, "^# Earlier step\n\n### yxa ci failed in \\d+\\.\\d{3}s\n\n", summary, "the summary is appended")
+		assert.Contains(t, summary, "| Command | Result | Duration | Executions | Skipped | Cache hits |\n")
+		assert.Regexp(t, "\n\\| `ci` \\| failed \\| \\d+\\.\\d{3}s \\| 1 \\| 0 \\| 0 \\|\n", summary)
+		assert.Regexp(t, "\n\\| `generate` \\| ok \\| \\d+\\.\\d{3}s \\| 1 \\| 0 \\| 1 \\|\n", summary)
+		assert.Regexp(t, "\n\\| `lint` \\| ok \\| ", summary)
+		assert.Regexp(t, "\n\\| `test` \\| failed \\| ", summary)
+		assert.Contains(t, summary, "\n```text\none or more dependencies failed: 'test': ")
+	})
+
+	t.Run("successful run", func(t *testing.T) {
+		summary := run(t, "lint")
+		assert.Regexp(t, "### yxa lint succeeded in ", summary)
+		assert.NotContains(t, summary, "```")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		assert.Equal(t, "# Earlier step\n\n", run(t, "--dry-run", "ci"))
+	})
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
+// planTask describes a task of a command without running it
+type planTask struct {
+	Run          string // Run string with variables resolved
+	Ref          string // Command referenced by the task, if any
+	Condition    string // Condition with variables resolved, empty if there is none
+	ConditionMet bool   // Result of the condition (true if there is none or it is not evaluated)
+	NotEvaluated bool   // Condition runs a command, so it is only evaluated when the task runs
+}
+
+// validateTasks checks that every task of a command has either a shell command or
+// a reference to an existing command
+func (h *CommandHandler) validateTasks(cmdName string, cmd config.Command) error {
+	for i, task := range cmd.Tasks {
+		switch {
+		case task.Run == "" && task.Task == "":
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d has no 'run' or 'task' defined", i+1), nil)
+		case task.Run != "" && task.Task != "":
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d cannot combine 'run' and 'task'", i+1), nil)
+		case task.Task == "" && len(task.Params) > 0:
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d has 'params', which requires 'task'", i+1), nil)
+		case task.Task == "":
+			continue
+		}
+
+		if _, err := h.lookupCommand(task.Task); err != nil {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d references unknown command '%s'", i+1, task.Task), nil)
+		}
+		if cmd.Parallel {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d references a command, which requires sequential tasks", i+1), nil)
+		}
+	}
+	return nil
+}
+
+// taskVars returns the variables a task that references a command passes to it:
+// the variables of the calling command and the params of the task
+func (h *CommandHandler) taskVars(cmdName string, task config.Task, cmdVars map[string]string) map[string]string {
+	vars := make(map[string]string, len(cmdVars)+len(task.Params))
+	for k, v := range cmdVars {
+		vars[k] = v
+	}
+	for k, v := range task.Params {
+		vars[k] = h.replaceVariablesInString(cmdName, v, cmdVars)
+	}
+	return vars
+}
+
+// taskJobs resolves the tasks of a command into jobs, skipping the tasks whose
+// condition is not met
+func (h *CommandHandler) taskJobs(cmdName string, cmd config.Command, cmdVars map[string]string) []taskJob {
+	var jobs []taskJob
+	for i, task := range cmd.Tasks {
+		id := fmt.Sprintf("#%d", i+1)
+		// Tasks are resolved in the environment of the command already
+		if task.Condition != "" && !config.EvaluateConditionWithResolver(task.Condition, h.resolver(cmdName, cmdVars), h.conditionOutput(cmdName)) {
+			h.printf("Skipping task %s for '%s' (condition not met: %s)\n", id, cmdName, task.Condition)
+			continue
+		}
+		if task.Task != "" {
+			jobs = append(jobs, taskJob{ID: id, Task: i + 1, Ref: task.Task, Vars: h.taskVars(cmdName, task, cmdVars)})
+			continue
+		}
+		// Invalid timeouts fail the validation of the command before it runs
+		timeout, _ := h.parseTimeout(cmdName, task.Timeout)
+		jobs = append(jobs, taskJob{
+			ID:      id,
+			Task:    i + 1,
+			Command: h.replaceVariablesInString(cmdName, task.Run, cmdVars),
+			Timeout: timeout,
+		})
+	}
+	return jobs
+}
+
+// validateTaskTimeouts checks the timeouts of the tasks of a command. Tasks that
+// reference a command run with the timeout of that command.
+func (h *CommandHandler) validateTaskTimeouts(cmdName string, cmd config.Command) error {
+	for i, task := range cmd.Tasks {
+		if task.Timeout == "" {
+			continue
+		}
+		if task.Task != "" {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d references a command, which cannot set a timeout", i+1), nil)
+		}
+		if _, err := time.ParseDuration(task.Timeout); err != nil {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d has an invalid timeout '%s'", i+1, task.Timeout), err)
+		}
+	}
+	return nil
+}
+
+// planTasks resolves the tasks of a command and evaluates their conditions
+func (h *CommandHandler) planTasks(cmdName string, cmd config.Command, cmdVars map[string]string) []planTask {
+	var tasks []planTask
+	for _, task := range cmd.Tasks {
+		planned := planTask{
+			Run:          h.replaceVariablesInString(cmdName, task.Run, cmdVars),
+			Ref:          task.Task,
+			ConditionMet: true,
+		}
+		if task.Condition != "" {
+			planned.Condition = h.replaceVariablesInString(cmdName, task.Condition, cmdVars)
+			met, evaluated := h.plannedCondition(cmdName, task.Condition, cmdVars)
+			planned.ConditionMet, planned.NotEvaluated = met, !evaluated
+		}
+		tasks = append(tasks, planned)
+	}
+	return tasks
+}
+
+// String describes what the task runs
+func (t planTask) String() string {
+	if t.Ref != "" {
+		return fmt.Sprintf("command '%s'", t.Ref)
+	}
+	return t.Run
+}
+
+// describeDryRunTask returns the dry-run line of a task run in the given mode
+func describeDryRunTask(mode string, task planTask) string {
+	if !task.ConditionMet {
+		return fmt.Sprintf("[dry-run] Would skip (%s, condition not met: %s): %s", mode, task.Condition, task)
+	}
+	if task.NotEvaluated {
+		return fmt.Sprintf("[dry-run] Would execute (%s, if %s, not evaluated): %s", mode, task.Condition, task)
+	}
+	return fmt.Sprintf("[dry-run] Would execute (%s): %s", mode, task)
+}
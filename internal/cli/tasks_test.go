@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandHandler_TaskConditions(t *testing.T) {
+	tasks := config.TaskList{
+		{Run: "echo always"},
+		{Run: "echo race", Condition: "$RACE == true"},
+		{Run: "echo linux", Condition: "$TARGET == linux"},
+	}
+	cfg := &config.ProjectConfig{
+		Variables: map[string]string{"TARGET": "linux", "RACE": "false"},
+		Commands: map[string]config.Command{
+			"test":    {Tasks: tasks},
+			"test-ci": {Tasks: tasks, Parallel: true},
+			"broken":  {Tasks: config.TaskList{{Condition: "$RACE == true"}}},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec), out
+	}
+
+	t.Run("sequential", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("test", nil))
+		assert.Equal(t, "always\nlinux\n", out.String())
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("test-ci", nil))
+		assert.Contains(t, out.String(), "[#1] always")
+		assert.Contains(t, out.String(), "[#3] linux")
+		assert.NotContains(t, out.String(), "#2")
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		handler, out := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("test", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would execute (sequential): echo always\n")
+		assert.Contains(t, out.String(), "[dry-run] Would skip (sequential, condition not met: false == true): echo race\n")
+	})
+
+	t.Run("task without run", func(t *testing.T) {
+		handler, _ := newHandler()
+		err := handler.ExecuteCommand("broken", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config error in command 'broken': task #1 has no 'run' or 'task' defined")
+	})
+}
+
+func TestCommandHandler_TaskReferences(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"generate": {Run: "echo generate"},
+			"build": {
+				Run:     "echo build $target",
+				Pre:     "echo pre-build",
+				Depends: config.NewDependencyList("generate"),
+				Params:  []config.Param{{Name: "target", Type: "string", Default: "host", Flag: true}},
+			},
+			"release": {
+				Tasks: config.TaskList{
+					{Task: "build"},
+					{Task: "build", Params: map[string]string{"target": "$OS"}},
+					{Run: "echo package"},
+					{Task: "build", Params: map[string]string{"target": "windows"}, Condition: "$OS == windows"},
+				},
+				Params: []config.Param{{Name: "OS", Type: "string", Default: "linux", Flag: true}},
+			},
+			"parallel":  {Tasks: config.TaskList{{Task: "build"}}, Parallel: true},
+			"unknown":   {Tasks: config.TaskList{{Task: "missing"}}},
+			"ambiguous": {Tasks: config.TaskList{{Run: "echo", Task: "build"}}},
+		},
+	}
+
+	newHandler := func() (*CommandHandler, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec), out
+	}
+
+	t.Run("referenced commands run with hooks and params", func(t *testing.T) {
+		handler, out := newHandler()
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Equal(t, "[generate] generate\npre-build\nbuild host\npre-build\nbuild linux\npackage\n", out.String())
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		handler, out := newHandler()
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("release", nil))
+		assert.Contains(t, out.String(), "[dry-run] Would execute (sequential): command 'build'\n")
+		assert.Contains(t, out.String(), "[dry-run] Would skip (sequential, condition not met: linux == windows): command 'build'\n")
+	})
+
+	for name, want := range map[string]string{
+		"parallel":  "config error in command 'parallel': task #1 references a command, which requires sequential tasks",
+		"unknown":   "config error in command 'unknown': task #1 references unknown command 'missing'",
+		"ambiguous": "config error in command 'ambiguous': task #1 cannot combine 'run' and 'task'",
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler, _ := newHandler()
+			err := handler.ExecuteCommand(name, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		})
+	}
+}
+
+func TestCommandHandler_TaskTimeouts(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"sequential": {
+				Tasks:   config.TaskList{{Run: "echo fast"}, {Run: "sleep 5", Timeout: "100ms"}},
+				Timeout: "1m",
+			},
+			"parallel": {
+				Tasks:    config.TaskList{{Run: "echo fast"}, {Run: "sleep 5", Timeout: "100ms"}},
+				Parallel: true,
+			},
+			"longer": {
+				Tasks:   config.TaskList{{Run: "sleep 0.3", Timeout: "5s"}},
+				Timeout: "100ms",
+			},
+			"invalid":   {Tasks: config.TaskList{{Run: "echo", Timeout: "soon"}}},
+			"reference": {Tasks: config.TaskList{{Task: "longer", Timeout: "1s"}}},
+		},
+	}
+	newHandler := func() *CommandHandler {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		exec.SetStderr(out)
+		return NewCommandHandler(cfg, exec)
+	}
+
+	for _, name := range []string{"sequential", "parallel"} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			err := newHandler().ExecuteCommand(name, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "#2")
+			assert.Less(t, time.Since(start), 3*time.Second, "the task is stopped after its own timeout")
+		})
+	}
+
+	t.Run("task timeout replaces that of the command", func(t *testing.T) {
+		require.NoError(t, newHandler().ExecuteCommand("longer", nil))
+	})
+
+	for name, want := range map[string]string{
+		"invalid":   "config error in command 'invalid': task #1 has an invalid timeout 'soon'",
+		"reference": "config error in command 'reference': task #1 references a command, which cannot set a timeout",
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := newHandler().ExecuteCommand(name, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		})
+	}
+}
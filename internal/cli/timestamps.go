@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Formats of the --timestamps flag
+const (
+	TimestampsRelative = "relative" // Time since yxa started, e.g. [+1.250s]
+	TimestampsAbsolute = "absolute" // Wall clock time, e.g. [15:04:05.000]
+)
+
+// timestampWriter prefixes every line written to it with a timestamp
+type timestampWriter struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	mode    string
+	start   time.Time
+	now     func() time.Time
+	midLine bool // The last write did not end with a newline
+}
+
+// newTimestampWriter creates a timestampWriter in the given --timestamps mode,
+// relative timestamps counting from start
+func newTimestampWriter(w io.Writer, mode string, start time.Time) *timestampWriter {
+	return &timestampWriter{writer: w, mode: mode, start: start, now: time.Now}
+}
+
+// Write implements io.Writer
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		if !w.midLine {
+			if _, err := io.WriteString(w.writer, w.prefix()); err != nil {
+				return written, err
+			}
+		}
+
+		line := p[written:]
+		end := len(line)
+		for i, c := range line {
+			if c == '\n' {
+				end = i + 1
+				break
+			}
+		}
+
+		n, err := w.writer.Write(line[:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		w.midLine = line[end-1] != '\n'
+	}
+	return written, nil
+}
+
+// prefix returns the timestamp of a new line
+func (w *timestampWriter) prefix() string {
+	now := w.now()
+	if w.mode == TimestampsAbsolute {
+		return "[" + now.Format("15:04:05.000") + "] "
+	}
+	return fmt.Sprintf("[+%.3fs] ", now.Sub(w.start).Seconds())
+}
+
+// setupTimestamps validates the --timestamps flag and prefixes the output of the
+// commands and the progress messages with timestamps
+func (r *RootCommand) setupTimestamps() error {
+	switch r.Timestamps {
+	case "":
+		return nil
+	case TimestampsRelative, TimestampsAbsolute:
+	default:
+		return fmt.Errorf("invalid --timestamps '%s': expected '%s' or '%s'", r.Timestamps, TimestampsRelative, TimestampsAbsolute)
+	}
+
+	r.Handler.SetTimestamps(r.Timestamps)
+	if r.timestamped {
+		return nil
+	}
+	r.timestamped = true
+
+	start := time.Now()
+	cmdExec := r.Handler.Executor
+	stdout := newTimestampWriter(cmdExec.GetStdout(), r.Timestamps, start)
+	cmdExec.SetStdout(stdout)
+	cmdExec.SetStderr(newTimestampWriter(cmdExec.GetStderr(), r.Timestamps, start))
+	r.Handler.setProgress(stdout)
+	return nil
+}
+
+// printDuration completes the banner of a command with its duration when
+// --timestamps is set
+func (h *CommandHandler) printDuration(cmdName string, start time.Time, err error) {
+	if h.Timestamps == "" {
+		return
+	}
+	status := "done"
+	if err != nil {
+		status = "failed"
+	}
+	h.printf("Executing command '%s'... %s in %s\n", cmdName, status, time.Since(start).Round(time.Millisecond))
+}
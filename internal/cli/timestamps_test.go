@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampWriter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	clock := start
+
+	t.Run("relative", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newTimestampWriter(&buf, TimestampsRelative, start)
+		w.now = func() time.Time { return clock }
+
+		clock = start.Add(1250 * time.Millisecond)
+		_, _ = w.Write([]byte("one\ntw"))
+		clock = start.Add(2 * time.Second)
+		_, _ = w.Write([]byte("o\nthree\n"))
+		assert.Equal(t, "[+1.250s] one\n[+1.250s] two\n[+2.000s] three\n", buf.String())
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newTimestampWriter(&buf, TimestampsAbsolute, start)
+		w.now = func() time.Time { return clock }
+
+		clock = start.Add(1500 * time.Millisecond)
+		_, _ = w.Write([]byte("line\n"))
+		assert.Equal(t, "[12:30:01.500] line\n", buf.String())
+	})
+
+	t.Run("composes with the parallel prefixes", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := newTimestampWriter(&buf, TimestampsRelative, start)
+		w.now = func() time.Time { return start }
+
+		syncWritePrefixed(w, "[#1] ", "first\nsecond\n")
+		assert.Equal(t, "[+0.000s] [#1] first\n[+0.000s] [#1] second\n", buf.String())
+	})
+}
+
+func TestTimestampsFlag(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"build": {Run: "echo building", Pre: "echo pre"},
+			"check": {Tasks: config.NewTaskList("echo one", "echo two"), Parallel: true},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		stdout := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(stdout)
+		exec.SetStderr(stdout)
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(stdout)
+		root.RootCmd.SetErr(stdout)
+		root.RootCmd.SetArgs(args)
+		err := root.Execute()
+		return stdout.String(), err
+	}
+
+	t.Run("relative by default", func(t *testing.T) {
+		out, err := run(t, "build", "--timestamps")
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`(?m)^\[\+\d+\.\d{3}s\] Executing pre-hook for 'build'\.\.\.$`), out)
+		assert.Regexp(t, regexp.MustCompile(`(?m)^\[\+\d+\.\d{3}s\] building$`), out)
+		assert.Regexp(t, regexp.MustCompile(`(?m)^\[\+\d+\.\d{3}s\] Executing command 'build'\.\.\. done in .+$`), out)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		out, err := run(t, "check", "--timestamps=absolute")
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`(?m)^\[\d{2}:\d{2}:\d{2}\.\d{3}\] \[#1\] one$`), out)
+		assert.Regexp(t, regexp.MustCompile(`(?m)^\[\d{2}:\d{2}:\d{2}\.\d{3}\] \[#2\] two$`), out)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := run(t, "build", "--timestamps=monotonic")
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("invalid --timestamps 'monotonic': expected '%s' or '%s'", TimestampsRelative, TimestampsAbsolute), err.Error())
+	})
+}
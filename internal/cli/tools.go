@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/floppa/yxa-cli/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+// toolsDirName is the directory below the state directory in which the tools of
+// the config are installed
+const toolsDirName = "tools"
+
+// stateDir returns the directory next to the config file in which yxa keeps its
+// state, below the current directory if no config file was loaded
+func (h *CommandHandler) stateDir() string {
+	base := "."
+	if h.Config != nil && h.Config.ConfigDir() != "" {
+		base = h.Config.ConfigDir()
+	}
+	return filepath.Join(base, stateDirName)
+}
+
+// toolInstaller returns the installer of the tools of the config
+func (h *CommandHandler) toolInstaller() *tools.Installer {
+	return tools.NewInstaller(filepath.Join(h.stateDir(), toolsDirName), filepath.Join(h.stateDir(), downloadsDirName))
+}
+
+// toolNames returns the names of the tools of the config, sorted
+func (h *CommandHandler) toolNames() []string {
+	if h.Config == nil {
+		return nil
+	}
+	names := make([]string, 0, len(h.Config.Tools))
+	for name := range h.Config.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// installTools installs the missing tools of the config and puts them in front of
+// PATH, so that commands and their requirements find the pinned versions. Tools
+// are set up once per handler, and dry runs install nothing.
+func (h *CommandHandler) installTools() error {
+	if h.toolsReady || h.DryRun || h.Config == nil || len(h.Config.Tools) == 0 {
+		return nil
+	}
+
+	installer := h.toolInstaller()
+	var dirs []string
+	for _, name := range h.toolNames() {
+		tool := h.Config.Tools[name]
+		if !installer.Installed(name, tool) {
+			h.printf("Installing tool %s %s...\n", name, tool.Version)
+			if err := installer.Install(h.RunContext().Context, name, tool); err != nil {
+				return err
+			}
+		}
+		dirs = append(dirs, installer.BinDir(name, tool))
+	}
+	if err := prependPath(dirs); err != nil {
+		return err
+	}
+	h.toolsReady = true
+	return nil
+}
+
+// prependPath puts dirs in front of the PATH of yxa and thereby of the commands it
+// runs, skipping those that are on it already
+func prependPath(dirs []string) error {
+	path := os.Getenv("PATH")
+	onPath := make(map[string]bool)
+	for _, dir := range filepath.SplitList(path) {
+		onPath[dir] = true
+	}
+
+	var prefix []string
+	for _, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if !onPath[dir] {
+			prefix = append(prefix, dir)
+			onPath[dir] = true
+		}
+	}
+	if len(prefix) == 0 {
+		return nil
+	}
+	if path != "" {
+		prefix = append(prefix, path)
+	}
+	return os.Setenv("PATH", strings.Join(prefix, string(os.PathListSeparator)))
+}
+
+// newToolsCommand creates the built-in 'tools' command, which installs the tools
+// pinned in the config
+func (r *RootCommand) newToolsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tools",
+		Short: "Install the tools pinned in the config",
+		Long: `Install the tools pinned in the 'tools' section of the config that are missing and
+list where they are. Commands install missing tools before they run, this
+command does so up front, e.g. to cache .yxa/tools in CI.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.listTools(cmd.OutOrStdout())
+		},
+	}
+}
+
+// listTools installs the missing tools, unless in dry-run mode, and writes a table
+// of them to out
+func (r *RootCommand) listTools(out io.Writer) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	names := r.Handler.toolNames()
+	if len(names) == 0 {
+		_, err := fmt.Fprintln(out, "No tools pinned")
+		return err
+	}
+	if err := r.Handler.installTools(); err != nil {
+		return err
+	}
+
+	installer := r.Handler.toolInstaller()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "TOOL\tVERSION\tSTATUS\tPATH"); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	for _, name := range names {
+		tool := r.Config.Tools[name]
+		status := "missing"
+		if installer.Installed(name, tool) {
+			status = "installed"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, tool.Version, status, installer.BinDir(name, tool)); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
+		}
+	}
+	return w.Flush()
+}
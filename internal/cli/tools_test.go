@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTools(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("PATH", os.Getenv("PATH"))
+
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write([]byte("#!/bin/sh\necho pinned $0\n"))
+	}))
+	defer server.Close()
+
+	cfg := &config.ProjectConfig{
+		Tools: map[string]config.Tool{
+			"pinned": {Version: "1.0.0", URL: server.URL + "/pinned-$version"},
+		},
+		Commands: map[string]config.Command{
+			"lint": {Run: "pinned"},
+		},
+	}
+	binDir := filepath.Join(dir, ".yxa", "tools", "pinned", "1.0.0-"+runtime.GOOS+"-"+runtime.GOARCH)
+
+	t.Run("dry-run installs nothing", func(t *testing.T) {
+		handler := NewCommandHandler(cfg, &recordingExecutor{testExecutor: testExecutor{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}})
+		handler.setProgress(&bytes.Buffer{})
+		handler.SetDryRun(true)
+		require.NoError(t, handler.ExecuteCommand("lint", nil))
+		assert.Zero(t, downloads)
+	})
+
+	t.Run("commands install missing tools and find them on PATH", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(out)
+		handler := NewCommandHandler(cfg, exec)
+		progress := &bytes.Buffer{}
+		handler.setProgress(progress)
+
+		require.NoError(t, handler.ExecuteCommand("lint", nil))
+		assert.Contains(t, progress.String(), "Installing tool pinned 1.0.0...")
+		assert.Equal(t, "pinned "+filepath.Join(binDir, "pinned")+"\n", out.String())
+		assert.True(t, strings.HasPrefix(os.Getenv("PATH"), binDir+string(os.PathListSeparator)))
+		assert.Equal(t, 1, downloads)
+
+		// Installed tools are neither downloaded nor added to PATH again
+		require.NoError(t, NewCommandHandler(cfg, exec).installTools())
+		assert.Equal(t, 1, downloads)
+		assert.Equal(t, 1, strings.Count(os.Getenv("PATH"), binDir))
+	})
+
+	t.Run("tools command lists the tools", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		root := NewRootCommand(cfg, executor.NewDefaultExecutor())
+		root.registerCommands()
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetArgs([]string{"tools"})
+		require.NoError(t, root.RootCmd.Execute())
+		assert.Contains(t, out.String(), "TOOL")
+		assert.Regexp(t, `pinned\s+1\.0\.0\s+installed\s+`+regexp.QuoteMeta(filepath.Join(".yxa", "tools", "pinned")), out.String())
+	})
+}
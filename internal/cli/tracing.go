@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/tracing"
+)
+
+// traceExportTimeout is how long exporting the spans of a run may take
+const traceExportTimeout = 10 * time.Second
+
+// SetTracer sets the tracer that records the spans of runs, nil disables tracing
+func (h *CommandHandler) SetTracer(tracer *tracing.Tracer) {
+	h.Tracer = tracer
+}
+
+// startSpan starts a span of the current run as a child of the span of parentCmd,
+// or as a root span if parentCmd has none. Nothing is traced in dry-run mode.
+func (h *CommandHandler) startSpan(name, parentCmd string, attrs ...tracing.Attribute) *tracing.Span {
+	if h.Tracer == nil || h.DryRun {
+		return nil
+	}
+	run := h.RunContext()
+	attrs = append([]tracing.Attribute{tracing.String("yxa.run_id", run.ID)}, attrs...)
+	return h.Tracer.Start(name, run.span(parentCmd), attrs...)
+}
+
+// endSpan ends a span with the exit code of err
+func endSpan(span *tracing.Span, err error) {
+	span.End(err, tracing.Int("process.exit.code", int64(processExitCode(err))))
+}
+
+// processExitCode returns the exit code of the process that caused err: 0 without
+// an error and 1 for errors that do not come from a process
+func processExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if stderrors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// setupTracing creates the tracer of this invocation if YXA_OTEL_ENDPOINT is set
+func (r *RootCommand) setupTracing() error {
+	var resource []tracing.Attribute
+	if r.Config != nil && r.Config.Name != "" {
+		resource = append(resource, tracing.String("yxa.project", r.Config.Name))
+	}
+	tracer, err := tracing.FromEnv(os.Getenv, resource...)
+	if err != nil {
+		return err
+	}
+	r.tracer = tracer
+	return nil
+}
+
+// exportTrace sends the spans of the finished run to the collector. A collector
+// that cannot be reached is reported as a warning, it does not fail the command.
+func (r *RootCommand) exportTrace() {
+	if r.tracer == nil {
+		return
+	}
+	// The run may have been cancelled, the export gets its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), traceExportTimeout)
+	defer cancel()
+	if err := r.tracer.Export(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// taskSpanName returns the name of the span of a task of a command, e.g. "ci: task 2"
+func taskSpanName(cmdName string, task int) string {
+	return fmt.Sprintf("%s: task %d", cmdName, task)
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/floppa/yxa-cli/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportedSpan is the part of an exported OTLP span the tests check
+type exportedSpan struct {
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+			IntValue    string `json:"intValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+// attr returns the value of an attribute of the span
+func (s exportedSpan) attr(key string) string {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.StringValue + a.Value.IntValue
+		}
+	}
+	return ""
+}
+
+func TestTracing(t *testing.T) {
+	var spans []exportedSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer server.Close()
+	t.Setenv(tracing.EndpointVariable, server.URL)
+
+	cfg := &config.ProjectConfig{
+		Name: "app",
+		Commands: map[string]config.Command{
+			"generate": {Run: "true"},
+			"build":    {Depends: config.NewDependencyList("generate"), Pre: "true", Tasks: config.TaskList{{Run: "true"}, {Run: "exit 3"}}},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		spans = nil
+		exec := executor.NewDefaultExecutor()
+		exec.SetStdout(&bytes.Buffer{})
+		exec.SetStderr(&bytes.Buffer{})
+		root := NewRootCommand(nil, exec)
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, exec)
+		root.registerCommands()
+		root.RootCmd.SetOut(&bytes.Buffer{})
+		root.RootCmd.SetErr(&bytes.Buffer{})
+		root.RootCmd.SetArgs(args)
+
+		origExit := exitFunc
+		defer func() { exitFunc = origExit }()
+		exitFunc = func(int) {}
+		require.NoError(t, root.Execute())
+	}
+
+	t.Run("spans", func(t *testing.T) {
+		run(t, "build")
+		byName := make(map[string]exportedSpan)
+		for _, s := range spans {
+			byName[s.Name] = s
+		}
+		require.Len(t, byName, 5, "spans: %v", spans)
+
+		build := byName["build"]
+		assert.Empty(t, build.ParentSpanID)
+		assert.Equal(t, "build", build.attr("yxa.command"))
+		assert.Equal(t, "3", build.attr("process.exit.code"))
+		assert.Equal(t, 2, build.Status.Code)
+		assert.NotEmpty(t, build.attr("yxa.duration_ms"))
+		assert.NotEmpty(t, build.attr("yxa.run_id"))
+
+		generate := byName["generate"]
+		assert.Equal(t, build.SpanID, generate.ParentSpanID)
+		assert.Equal(t, "0", generate.attr("process.exit.code"))
+		assert.Equal(t, 0, generate.Status.Code)
+
+		hook := byName["build: pre-hook"]
+		assert.Equal(t, build.SpanID, hook.ParentSpanID)
+		assert.Equal(t, "pre", hook.attr("yxa.hook"))
+
+		task := byName["build: task 2"]
+		assert.Equal(t, build.SpanID, task.ParentSpanID)
+		assert.Equal(t, "2", task.attr("yxa.task"))
+		assert.Equal(t, "3", task.attr("process.exit.code"))
+		assert.Equal(t, "0", byName["build: task 1"].attr("process.exit.code"))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		run(t, "--dry-run", "build")
+		assert.Empty(t, spans)
+	})
+}
+
+func TestProcessExitCode(t *testing.T) {
+	assert.Equal(t, 0, processExitCode(nil))
+	assert.Equal(t, 1, processExitCode(errors.New("failed")))
+
+	err := exec.Command("sh", "-c", "exit 4").Run()
+	assert.Equal(t, 4, processExitCode(err))
+}
@@ -2,13 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/floppa/yxa-cli/internal/config"
 	"github.com/floppa/yxa-cli/internal/errors"
 )
 
-// validateCommandDependencies validates that there are no circular dependencies
-// in the command configuration
+// validateCommandDependencies validates that the dependencies, needed commands and
+// commands referenced by tasks exist, including subcommands referenced as
+// parent:sub, and that there are no circular dependencies among them
 func validateCommandDependencies(cfg *config.ProjectConfig) error {
 	// Check if config is nil
 	if cfg == nil {
@@ -21,8 +23,10 @@ func validateCommandDependencies(cfg *config.ProjectConfig) error {
 	// Create a map to track commands in the current path
 	inPath := make(map[string]bool)
 
-	// Check each command for circular dependencies
-	for cmdName := range cfg.Commands {
+	// Check each command and subcommand for circular dependencies
+	for _, c := range flattenCommands(cfg) {
+		cmdName := c.Name
+
 		// Skip commands that have already been validated
 		if visited[cmdName] {
 			continue
@@ -58,22 +62,38 @@ func validateDependencyTree(
 	}
 
 	// Get the command configuration
-	cmd, ok := cfg.Commands[cmdName]
+	cmd, ok := configCommand(cfg, cmdName)
 	if !ok {
-		return fmt.Errorf("command '%s' not found", cmdName)
+		if len(path) > 0 {
+			return errors.NewDependencyConfigError(path[len(path)-1], cmdName, notFoundMessage(cfg, cmdName), nil)
+		}
+		return errors.NewCommandConfigError(cmdName, notFoundMessage(cfg, cmdName), nil)
 	}
 
 	// Mark this command as in the current path
 	inPath[cmdName] = true
 	path = append(path, cmdName)
 
-	// Recursively validate dependencies
-	for _, depName := range cmd.Depends {
+	// Recursively validate dependencies and the commands it needs
+	for _, depName := range prerequisites(cmd) {
 		if err := validateDependencyTree(cfg, depName, visited, inPath, path); err != nil {
 			return err
 		}
 	}
 
+	// Commands referenced by tasks always run, so they may close a cycle as well
+	for i, task := range cmd.Tasks {
+		if task.Task == "" {
+			continue
+		}
+		if _, ok := configCommand(cfg, task.Task); !ok {
+			return errors.NewCommandConfigError(cmdName, fmt.Sprintf("task #%d references '%s': %s", i+1, task.Task, notFoundMessage(cfg, task.Task)), nil)
+		}
+		if err := validateDependencyTree(cfg, task.Task, visited, inPath, path); err != nil {
+			return err
+		}
+	}
+
 	// Mark this command as validated
 	visited[cmdName] = true
 
@@ -82,3 +102,28 @@ func validateDependencyTree(
 
 	return nil
 }
+
+// configCommand returns the command of the given name, which may be a subcommand at
+// any depth as parent:sub:subsub
+func configCommand(cfg *config.ProjectConfig, cmdName string) (config.Command, bool) {
+	parts := strings.Split(cmdName, ":")
+	cmd, ok := cfg.Commands[parts[0]]
+	for _, name := range parts[1:] {
+		if !ok {
+			break
+		}
+		cmd, ok = cmd.Commands[name]
+	}
+	return cmd, ok
+}
+
+// notFoundMessage describes why a command does not exist: the command itself, or
+// the last subcommand of a parent:sub reference whose parent exists
+func notFoundMessage(cfg *config.ProjectConfig, cmdName string) string {
+	if i := strings.LastIndex(cmdName, ":"); i > 0 {
+		if _, ok := configCommand(cfg, cmdName[:i]); ok {
+			return fmt.Sprintf("subcommand '%s' of '%s' not found", cmdName[i+1:], cmdName[:i])
+		}
+	}
+	return "command not found"
+}
@@ -14,11 +14,11 @@ func TestCircularDependencyDetection(t *testing.T) {
 		Commands: map[string]config.Command{
 			"cmd1": {
 				Run:     "echo 'cmd1'",
-				Depends: []string{"cmd2"},
+				Depends: config.NewDependencyList("cmd2"),
 			},
 			"cmd2": {
 				Run:     "echo 'cmd2'",
-				Depends: []string{"cmd1"},
+				Depends: config.NewDependencyList("cmd1"),
 			},
 		},
 	}
@@ -45,7 +45,7 @@ func TestCircularDependencyDetection(t *testing.T) {
 			},
 			"cmd2": {
 				Run:     "echo 'cmd2'",
-				Depends: []string{"cmd1"},
+				Depends: config.NewDependencyList("cmd1"),
 			},
 		},
 	}
@@ -66,7 +66,7 @@ func TestMissingDependency(t *testing.T) {
 		Commands: map[string]config.Command{
 			"cmd1": {
 				Run:     "echo 'cmd1'",
-				Depends: []string{"missing"},
+				Depends: config.NewDependencyList("missing"),
 			},
 		},
 	}
@@ -99,7 +99,7 @@ func TestParallelCommands(t *testing.T) {
 			"parallel": {
 				Description: "Parallel command",
 				Parallel:    true,
-				Tasks:       []string{"echo 'cmd1'", "echo 'cmd2'"},
+				Tasks:       config.NewTaskList("echo 'cmd1'", "echo 'cmd2'"),
 			},
 		},
 	}
@@ -145,3 +145,88 @@ func TestParallelCommands(t *testing.T) {
 		}
 	*/
 }
+
+func TestNestedSubcommandDependencies(t *testing.T) {
+	db := func(upDepends ...string) map[string]config.Command {
+		return map[string]config.Command{
+			"db": {
+				Commands: map[string]config.Command{
+					"migrate": {
+						Commands: map[string]config.Command{
+							"up": {Run: "echo up", Depends: config.NewDependencyList(upDepends...)},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	cfg := &config.ProjectConfig{Commands: db("build")}
+	cfg.Commands["build"] = config.Command{Run: "echo build"}
+	cfg.Commands["release"] = config.Command{Run: "echo release", Depends: config.NewDependencyList("db:migrate:up")}
+	if err := validateCommandDependencies(cfg); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+
+	cfg = &config.ProjectConfig{Commands: db("db:migrate:missing")}
+	if err := validateCommandDependencies(cfg); err == nil || !strings.Contains(err.Error(), "db:migrate:missing") {
+		t.Errorf("Expected missing dependency error, got: %v", err)
+	}
+
+	cfg = &config.ProjectConfig{Commands: db("release")}
+	cfg.Commands["release"] = config.Command{Run: "echo release", Depends: config.NewDependencyList("db:migrate:up")}
+	if err := validateCommandDependencies(cfg); err == nil || !strings.Contains(err.Error(), "circular dependency") {
+		t.Errorf("Expected circular dependency error, got: %v", err)
+	}
+}
+
+func TestTaskReferenceValidation(t *testing.T) {
+	group := config.Command{
+		Commands: map[string]config.Command{
+			"app": {Run: "echo app"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		tasks   config.TaskList
+		wantErr string
+	}{
+		{"existing subcommand", config.TaskList{{Task: "deploy:app"}, {Run: "echo done"}}, ""},
+		{"missing subcommand", config.TaskList{{Task: "deploy:web"}}, "task #1 references 'deploy:web': subcommand 'web' of 'deploy' not found"},
+		{"missing command", config.TaskList{{Run: "echo"}, {Task: "missing:app"}}, "task #2 references 'missing:app': command not found"},
+		{"cycle", config.TaskList{{Task: "release"}}, "circular dependency detected: release -> release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{
+				Commands: map[string]config.Command{
+					"deploy":  group,
+					"release": {Tasks: tt.tasks},
+				},
+			}
+			err := validateCommandDependencies(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Dependencies on missing subcommands name the missing part
+	cfg := &config.ProjectConfig{
+		Commands: map[string]config.Command{
+			"deploy":  group,
+			"release": {Run: "echo release", Depends: config.NewDependencyList("deploy:web")},
+		},
+	}
+	want := "subcommand 'web' of 'deploy' not found"
+	if err := validateCommandDependencies(cfg); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got: %v", want, err)
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// yxaReleaseURL is where the release binaries of yxa are downloaded from
+const yxaReleaseURL = "https://github.com/floppa/yxa-cli/releases"
+
+// ghaOptions are the settings of a generated GitHub Actions workflow
+type ghaOptions struct {
+	RunsOn  string // Runner label of the job
+	Version string // Release of yxa to install, e.g. v1.2.0, or latest
+	Branch  string // Branch whose pushes run the workflow
+}
+
+// newExportGHACommand creates 'yxa export gha', which writes a GitHub Actions
+// workflow that runs the given commands with yxa
+func (r *RootCommand) newExportGHACommand(output *string) *cobra.Command {
+	opts := ghaOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "gha <command...>",
+		Short: "Export a GitHub Actions workflow that runs yxa commands",
+		Long: `Write a GitHub Actions workflow with a job that installs yxa and runs the given
+commands in order, so that CI runs the same tasks as your machine. The workflow
+runs on pushes to --branch and on pull requests.
+
+The yxa binary is cached by version, and the .yxa directory next to yxa.yml by
+the contents of yxa.yml. Use parent:sub for subcommands.
+
+  yxa export gha lint test build -o .github/workflows/yxa.yml`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: r.completeCommandNames(0, nil),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeExport(cmd, *output, func(out io.Writer) error {
+				return r.exportGHA(out, args, opts)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RunsOn, "runs-on", "ubuntu-latest", "Runner the job runs on")
+	cmd.Flags().StringVar(&opts.Version, "yxa-version", "latest", "Release of yxa to install, e.g. v1.2.0")
+	cmd.Flags().StringVar(&opts.Branch, "branch", "main", "Branch whose pushes run the workflow")
+
+	return cmd
+}
+
+// exportGHA writes a workflow that runs the given commands to out
+func (r *RootCommand) exportGHA(out io.Writer, cmdNames []string, opts ghaOptions) error {
+	if r.Config == nil {
+		return fmt.Errorf("no configuration loaded")
+	}
+	for _, name := range cmdNames {
+		if _, err := r.Handler.lookupCommand(name); err != nil {
+			return err
+		}
+	}
+
+	// The workflow runs from the root of the repository, which is assumed to be
+	// the current directory
+	dir := "."
+	if cwd, err := os.Getwd(); err == nil && r.Config.ConfigDir() != "" {
+		if rel, err := filepath.Rel(cwd, r.Config.ConfigDir()); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+
+	download := yxaReleaseURL + "/latest/download"
+	if opts.Version != "latest" {
+		download = yxaReleaseURL + "/download/" + opts.Version
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Generated by yxa export gha from yxa.yml
+name: yxa
+
+on:
+  push:
+    branches: [%s]
+  pull_request:
+
+jobs:
+  yxa:
+    runs-on: %s
+`, opts.Branch, opts.RunsOn)
+	if dir != "." {
+		fmt.Fprintf(&b, "    defaults:\n      run:\n        working-directory: %s\n", dir)
+	}
+	fmt.Fprintf(&b, `    steps:
+      - uses: actions/checkout@v4
+
+      - name: Cache yxa
+        id: cache-yxa
+        uses: actions/cache@v4
+        with:
+          path: ~/.local/bin/yxa
+          key: yxa-${{ runner.os }}-${{ runner.arch }}-%s
+
+      - name: Install yxa
+        if: steps.cache-yxa.outputs.cache-hit != 'true'
+        run: |
+          os=$(uname -s | tr '[:upper:]' '[:lower:]')
+          case "$(uname -m)" in
+            x86_64) arch=amd64 ;;
+            *) arch=arm64 ;;
+          esac
+          mkdir -p ~/.local/bin
+          curl -fsSL "%s/yxa-$os-$arch" -o ~/.local/bin/yxa
+          chmod +x ~/.local/bin/yxa
+
+      - name: Add yxa to PATH
+        run: echo "$HOME/.local/bin" >> "$GITHUB_PATH"
+
+      - name: Cache .yxa
+        uses: actions/cache@v4
+        with:
+          path: %s
+          key: yxa-state-${{ runner.os }}-${{ hashFiles('%s') }}
+          restore-keys: yxa-state-${{ runner.os }}-
+`, opts.Version, download, filepath.ToSlash(filepath.Join(dir, stateDirName)), filepath.ToSlash(filepath.Join(dir, "yxa.yml")))
+
+	for _, name := range cmdNames {
+		fmt.Fprintf(&b, "\n      - name: %s\n        run: yxa %s\n", name, strings.ReplaceAll(name, ":", " "))
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportGHACommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.Mkdir("app", 0o755))
+	path := filepath.Join(dir, "app", "yxa.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+commands:
+  test:
+    run: go test ./...
+  db:
+    commands:
+      migrate:
+        run: migrate up
+`), 0o644))
+	cfg, err := config.LoadConfigFrom(path)
+	require.NoError(t, err)
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		out := &bytes.Buffer{}
+		root := NewRootCommand(nil, executor.NewDefaultExecutor())
+		root.Config = cfg
+		root.Handler = NewCommandHandler(cfg, root.Executor)
+		root.RootCmd.SetOut(out)
+		root.RootCmd.SetErr(out)
+		root.RootCmd.SetArgs(append([]string{"export", "gha"}, args...))
+		err := root.RootCmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run(t, "test", "db:migrate", "--yxa-version", "v1.2.0", "--branch", "develop")
+	require.NoError(t, err)
+	assert.Equal(t, `# Generated by yxa export gha from yxa.yml
+name: yxa
+
+on:
+  push:
+    branches: [develop]
+  pull_request:
+
+jobs:
+  yxa:
+    runs-on: ubuntu-latest
+    defaults:
+      run:
+        working-directory: app
+    steps:
+      - uses: actions/checkout@v4
+
+      - name: Cache yxa
+        id: cache-yxa
+        uses: actions/cache@v4
+        with:
+          path: ~/.local/bin/yxa
+          key: yxa-${{ runner.os }}-${{ runner.arch }}-v1.2.0
+
+      - name: Install yxa
+        if: steps.cache-yxa.outputs.cache-hit != 'true'
+        run: |
+          os=$(uname -s | tr '[:upper:]' '[:lower:]')
+          case "$(uname -m)" in
+            x86_64) arch=amd64 ;;
+            *) arch=arm64 ;;
+          esac
+          mkdir -p ~/.local/bin
+          curl -fsSL "https://github.com/floppa/yxa-cli/releases/download/v1.2.0/yxa-$os-$arch" -o ~/.local/bin/yxa
+          chmod +x ~/.local/bin/yxa
+
+      - name: Add yxa to PATH
+        run: echo "$HOME/.local/bin" >> "$GITHUB_PATH"
+
+      - name: Cache .yxa
+        uses: actions/cache@v4
+        with:
+          path: app/.yxa
+          key: yxa-state-${{ runner.os }}-${{ hashFiles('app/yxa.yml') }}
+          restore-keys: yxa-state-${{ runner.os }}-
+
+      - name: test
+        run: yxa test
+
+      - name: db:migrate
+        run: yxa db migrate
+`, out)
+
+	t.Run("latest release and output file", func(t *testing.T) {
+		target := filepath.Join(dir, "yxa.yml.gha")
+		out, err := run(t, "test", "-o", target)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+		data, err := os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "https://github.com/floppa/yxa-cli/releases/latest/download/yxa-$os-$arch")
+		assert.Contains(t, string(data), "branches: [main]")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		_, err := run(t, "deploy")
+		assert.ErrorContains(t, err, "deploy")
+	})
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Patterns of the built-in predicates of conditions
+var (
+	cmdExistsPattern = regexp.MustCompile(`^\s*cmd-exists\s+(\S+)\s*$`)
+	envSetPattern    = regexp.MustCompile(`^\s*env\s+(\w+)\s*$`)
+	newerThanPattern = regexp.MustCompile(`^\s*file\s+(.+?)\s+newer-than\s+(.+?)\s*$`)
+	outputOfPattern  = regexp.MustCompile(`^\s*output-of\s+("(?:[^"\\]|\\.)*"|'[^']*')\s*(==|!=|contains)\s*(.*?)\s*$`)
+)
+
+// OutputFunc runs the command of an output-of condition and returns its stdout
+type OutputFunc func(cmdStr string) (string, error)
+
+// ConditionRunsCommand reports whether evaluating a condition runs a command, which
+// is the case for output-of conditions
+func ConditionRunsCommand(condition string) bool {
+	return outputOfPattern.MatchString(condition)
+}
+
+// evaluatePredicate evaluates the built-in predicates cmd-exists, env, file
+// newer-than and output-of, and reports whether the condition is one of them. The
+// commands of output-of conditions run with output; without it they are not met.
+func evaluatePredicate(condition string, output OutputFunc) (result, ok bool) {
+	if matches := outputOfPattern.FindStringSubmatch(condition); len(matches) == 4 {
+		if output == nil {
+			return false, true
+		}
+		stdout, err := output(conditionOperand(matches[1]))
+		if err != nil {
+			// A failing command has no meaningful output to compare
+			return false, true
+		}
+		return compareOperands(strings.TrimSpace(stdout), matches[2], conditionOperand(matches[3])), true
+	}
+
+	if matches := cmdExistsPattern.FindStringSubmatch(condition); len(matches) == 2 {
+		_, err := exec.LookPath(conditionOperand(matches[1]))
+		return err == nil, true
+	}
+
+	if matches := envSetPattern.FindStringSubmatch(condition); len(matches) == 2 {
+		_, set := os.LookupEnv(matches[1])
+		return set, true
+	}
+
+	if matches := newerThanPattern.FindStringSubmatch(condition); len(matches) == 3 {
+		return fileNewerThan(conditionOperand(matches[1]), conditionOperand(matches[2])), true
+	}
+
+	return false, false
+}
+
+// conditionBool evaluates a condition that is a boolean value, such as a resolved
+// bool parameter, optionally negated with a leading !
+func conditionBool(condition string) (result, ok bool) {
+	condition = strings.TrimSpace(condition)
+	negate := strings.HasPrefix(condition, "!")
+	if negate {
+		condition = strings.TrimSpace(condition[1:])
+	}
+	value, err := strconv.ParseBool(condition)
+	if err != nil {
+		return false, false
+	}
+	return value != negate, true
+}
+
+// compareOperands compares two operands of a condition with ==, != or contains
+func compareOperands(left, operator, right string) bool {
+	switch operator {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	default:
+		return strings.Contains(left, right)
+	}
+}
+
+// conditionOperand trims an operand of a condition and removes the quotes around
+// it, so that empty values can be compared as ""
+func conditionOperand(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
+
+// fileNewerThan reports whether file a was modified after file b, like a make
+// target that is out of date. A missing b makes any existing a newer.
+func fileNewerThan(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return infoA.ModTime().After(infoB.ModTime())
+}
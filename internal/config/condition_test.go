@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvaluateCondition_Predicates(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "older.txt")
+	newer := filepath.Join(dir, "newer.txt")
+	for _, path := range []string{older, newer} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+	t.Setenv("YXA_TEST_SET", "")
+
+	cfg := &ProjectConfig{
+		Variables: map[string]string{"DIR": dir, "EMPTY": "", "VERBOSE": "true", "QUIET": "false"},
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+	}{
+		{name: "command exists", condition: "cmd-exists sh", want: true},
+		{name: "command missing", condition: "cmd-exists yxa-no-such-command", want: false},
+		{name: "env set, also when empty", condition: "env YXA_TEST_SET", want: true},
+		{name: "env not set", condition: "env YXA_TEST_UNSET", want: false},
+		{name: "file newer", condition: "file $DIR/newer.txt newer-than $DIR/older.txt", want: true},
+		{name: "file older", condition: "file $DIR/older.txt newer-than $DIR/newer.txt", want: false},
+		{name: "file newer than missing file", condition: "file $DIR/newer.txt newer-than $DIR/missing.txt", want: true},
+		{name: "missing file newer", condition: "file $DIR/missing.txt newer-than $DIR/older.txt", want: false},
+		{name: "output-of runs no command", condition: `output-of "true" == ""`, want: false},
+		{name: "quoted empty variable", condition: `"$EMPTY" == ""`, want: true},
+		{name: "bool true", condition: "$VERBOSE", want: true},
+		{name: "bool false", condition: "$QUIET", want: false},
+		{name: "negated bool", condition: "!$QUIET", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.EvaluateCondition(tt.condition); got != tt.want {
+				t.Errorf("EvaluateCondition(%q) = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateCondition_OutputOf(t *testing.T) {
+	outputs := map[string]string{
+		"true":             "",
+		"echo dirty":       "dirty\n",
+		"echo a == b":      "a == b\n",
+		"echo a":           "a\n",
+		"echo hello world": "  hello world\n",
+	}
+	var ran []string
+	output := func(cmdStr string) (string, error) {
+		ran = append(ran, cmdStr)
+		stdout, ok := outputs[cmdStr]
+		if !ok {
+			return "", errors.New("exit status 1")
+		}
+		return stdout, nil
+	}
+	cfg := &ProjectConfig{Variables: map[string]string{"WORD": "world"}}
+
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+	}{
+		{name: "output equals empty", condition: `output-of "true" == ""`, want: true},
+		{name: "output not empty", condition: `output-of "echo dirty" == ""`, want: false},
+		{name: "output with operators in the command", condition: `output-of "echo a == b" == "a == b"`, want: true},
+		{name: "output inequality", condition: `output-of 'echo a' != b`, want: true},
+		{name: "output contains a variable", condition: `output-of "echo hello world" contains $WORD`, want: true},
+		{name: "failing command", condition: `output-of "exit 1" == ""`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !ConditionRunsCommand(cfg.ReplaceVariables(tt.condition)) {
+				t.Errorf("ConditionRunsCommand(%q) = false, want true", tt.condition)
+			}
+			if got := EvaluateConditionWithResolver(tt.condition, cfg.NewResolver(nil, nil), output); got != tt.want {
+				t.Errorf("EvaluateConditionWithResolver(%q) = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+
+	want := []string{"true", "echo dirty", "echo a == b", "echo a", "echo hello world", "exit 1"}
+	if len(ran) != len(want) {
+		t.Fatalf("ran %q, want %q", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Errorf("command #%d = %q, want %q", i+1, ran[i], want[i])
+		}
+	}
+	if ConditionRunsCommand("cmd-exists sh") {
+		t.Error("ConditionRunsCommand(cmd-exists sh) = true, want false")
+	}
+}
@@ -1,12 +1,16 @@
 package config
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/secrets"
 	"github.com/floppa/yxa-cli/internal/variables"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
@@ -14,28 +18,173 @@ import (
 
 // ProjectConfig represents the structure of the yxa.yml file
 type ProjectConfig struct {
-	Name       string             `yaml:"name"`
-	Variables  map[string]string  `yaml:"variables,omitempty"`
-	Commands   map[string]Command `yaml:"commands"`
+	Name       string             `yaml:"name"`                 // Name of the project
+	Variables  map[string]string  `yaml:"variables,omitempty"`  // Variables of every command
+	Commands   map[string]Command `yaml:"commands"`             // Commands by name
+	Params     []Param            `yaml:"params,omitempty"`     // Parameters that commands share with use_params
 	WorkingDir string             `yaml:"workingdir,omitempty"` // Directory-level workingdir
+	Profiles   map[string]Profile `yaml:"profiles,omitempty"`   // Named overrides selected with --profile or YXA_PROFILE
+	Notify     *Notify            `yaml:"notify,omitempty"`     // Notifications of every command that does not set its own
+	Tools      map[string]Tool    `yaml:"tools,omitempty"`      // Tools pinned to a version, installed into .yxa/tools
+	Root       bool               `yaml:"root,omitempty"`       // Do not inherit the yxa.yml files of parent directories
+	// Timeout of every command that does not set its own, e.g. "30m"
+	DefaultTimeout string `yaml:"default_timeout,omitempty"`
+	// File with variables encrypted with sops or age, relative to the config file
+	EncryptedVariables string `yaml:"encrypted_variables,omitempty"`
 	// Internal field to store environment variables (not from YAML)
 	envVars map[string]string
+	// Internal fields to store the decrypted variables and why they could not be decrypted
+	secretVars    map[string]string
+	secretVarsErr error
+	// Internal field to store why the encrypted global config was not loaded
+	globalConfigErr error
+	// Internal field to store the names of the variables marked as sensitive
+	sensitive map[string]bool
+	// Internal fields to store the absolute path and directory of the loaded config file
+	configFile string
+	configDir  string
+	// Internal field to store the name of the applied profile
+	profile string
+	// Internal fields to store the merged override file and the variables it set
+	overrideFile string
+	overrideVars map[string]bool
+	// Internal fields to store the config file of every command and variable, the
+	// files whose definitions they shadow and the conflicts with the global config
+	origins   map[string]string
+	shadowed  map[string][]string
+	conflicts []MergeConflict
 }
 
 // Command represents a command defined in the project.yml file
 type Command struct {
-	Run         string                  `yaml:"run"`                   // Main command to execute
-	Tasks       []string                `yaml:"tasks,omitempty"`       // Multiple tasks for parallel or sequential execution
-	Commands    map[string]Command      `yaml:"commands,omitempty"`    // Named subcommands for hierarchical command structures
-	Depends     []string                `yaml:"depends,omitempty"`     // Dependencies to execute first
-	Description string                  `yaml:"description,omitempty"` // Command description
-	Condition   string                  `yaml:"condition,omitempty"`   // Condition to evaluate before running
-	Pre         string                  `yaml:"pre,omitempty"`         // Command to run before the main command
-	Post        string                  `yaml:"post,omitempty"`        // Command to run after the main command
-	Timeout     string                  `yaml:"timeout,omitempty"`     // Timeout for command execution (e.g. "30s", "5m")
-	Parallel    bool                    `yaml:"parallel,omitempty"`    // Whether to run tasks in parallel
-	Params      []Param                 `yaml:"params,omitempty"`      // Command parameters (flags and positional)
-	WorkingDir  string                  `yaml:"workingdir,omitempty"`  // Command-level workingdir
+	Run              string             `yaml:"run"`                         // Main command to execute
+	Tasks            TaskList           `yaml:"tasks,omitempty"`             // Multiple tasks for parallel or sequential execution, each with an optional condition
+	Steps            []Step             `yaml:"steps,omitempty"`             // Built-in steps executed without a shell
+	Commands         map[string]Command `yaml:"commands,omitempty"`          // Named subcommands for hierarchical command structures
+	Depends          DependencyList     `yaml:"depends,omitempty"`           // Dependencies to execute first, each with an optional condition
+	DependsMode      string             `yaml:"depends_mode,omitempty"`      // How dependencies run: "fail-fast" (default) or "all"
+	Needs            map[string]string  `yaml:"needs,omitempty"`             // Commands whose stdout is passed to this command, by variable name
+	After            []string           `yaml:"after,omitempty"`             // Commands to run after if they run in the same invocation, without depending on them
+	Description      string             `yaml:"description,omitempty"`       // Command description
+	Help             string             `yaml:"help,omitempty"`              // Long help text shown by yxa help, the description if not set
+	Examples         []string           `yaml:"examples,omitempty"`          // Example invocations shown by yxa help
+	Deprecated       string             `yaml:"deprecated,omitempty"`        // Why the command is deprecated and what to use instead, warned about when it is invoked
+	HiddenDeprecated bool               `yaml:"hidden_deprecated,omitempty"` // Whether the deprecated command is hidden from help and completion
+	Condition        string             `yaml:"condition,omitempty"`         // Condition to evaluate before running
+	Pre              string             `yaml:"pre,omitempty"`               // Command to run before the main command
+	Post             string             `yaml:"post,omitempty"`              // Command to run after the main command
+	OnCancel         string             `yaml:"on_cancel,omitempty"`         // Command to run when the command is interrupted
+	Timeout          string             `yaml:"timeout,omitempty"`           // Timeout for command execution (e.g. "30s", "5m")
+	PreTimeout       string             `yaml:"pre_timeout,omitempty"`       // Timeout of the pre-hook, none if not set
+	PostTimeout      string             `yaml:"post_timeout,omitempty"`      // Timeout of the post-hook, none if not set
+	StallTimeout     string             `yaml:"stall_timeout,omitempty"`     // How long the command may produce no output, e.g. "2m"
+	StallAction      string             `yaml:"stall_action,omitempty"`      // What happens to a stalled command: "kill" (default) or "warn"
+	Register         RegisterList       `yaml:"register,omitempty"`          // Variables extracted from the output of run
+	Matrix           Matrix             `yaml:"matrix,omitempty"`            // Variables to run the command for every combination of, in parallel
+	Foreach          string             `yaml:"foreach,omitempty"`           // Glob pattern to run the command for every matched path of, as $ITEM
+	Artifacts        []string           `yaml:"artifacts,omitempty"`         // Files and directories the command creates, removed by yxa clean
+	Sources          []string           `yaml:"sources,omitempty"`           // Files the command reads, it is skipped while its generates are newer
+	Generates        []string           `yaml:"generates,omitempty"`         // Files the command creates from its sources
+	Stdin            string             `yaml:"stdin,omitempty"`             // Input of run: a file, relative to the config, or inline content
+	Service          bool               `yaml:"service,omitempty"`           // Long-running command that yxa up starts in the background
+	Runner           *Runner            `yaml:"runner,omitempty"`            // Executor backend to run the shell commands with, the host if not set
+	Container        *Container         `yaml:"container,omitempty"`         // Docker container to run the shell commands in
+	LogFile          *LogFile           `yaml:"log_file,omitempty"`          // File the output of the command is also written to
+	StderrFile       *LogFile           `yaml:"stderr_file,omitempty"`       // File stderr is written to instead of log_file
+	Output           string             `yaml:"output,omitempty"`            // How output is shown: "stream" (default) or "captured"
+	Variables        map[string]string  `yaml:"variables,omitempty"`         // Variables that shadow the project variables for this command
+	InheritEnv       *bool              `yaml:"inherit_env,omitempty"`       // Whether the command sees the environment of yxa, true if not set
+	ExportEnv        *bool              `yaml:"export_env,omitempty"`        // Whether the variables of the command are exported to its processes, false if not set
+	Requires         []Requirement      `yaml:"requires,omitempty"`          // Tools that must be on PATH before the command runs, e.g. go>=1.21
+	Nice             int                `yaml:"nice,omitempty"`              // Adjustment of the scheduling priority, e.g. 10 to yield to other processes
+	CPULimit         string             `yaml:"cpu_limit,omitempty"`         // Number of CPUs the command may use, e.g. 1.5
+	MemoryLimit      string             `yaml:"memory_limit,omitempty"`      // Maximum memory of the command, e.g. 512MB
+	Umask            string             `yaml:"umask,omitempty"`             // File mode creation mask of the shell commands in octal, e.g. 0022
+	Sudo             bool               `yaml:"sudo,omitempty"`              // Whether the shell commands run as root with sudo
+	User             string             `yaml:"user,omitempty"`              // User the shell commands run as with sudo, root with sudo if not set
+	Parallel         bool               `yaml:"parallel,omitempty"`          // Whether to run tasks in parallel
+	Pipe             bool               `yaml:"pipe,omitempty"`              // Whether the stdout of each sequential task is streamed into the stdin of the next
+	MaxParallel      int                `yaml:"max_parallel,omitempty"`      // Maximum number of tasks running at the same time, 0 for no limit
+	OrderedOutput    bool               `yaml:"ordered_output,omitempty"`    // Whether parallel output is printed per task in declaration order once all finished
+	ContinueOnError  bool               `yaml:"continue_on_error,omitempty"` // Whether sequential tasks keep running after a failure
+	Params           []Param            `yaml:"params,omitempty"`            // Command parameters (flags and positional)
+	UseParams        []string           `yaml:"use_params,omitempty"`        // Names of the top-level params the command accepts, before its own
+	WorkingDir       string             `yaml:"workingdir,omitempty"`        // Command-level workingdir
+	Notify           *Notify            `yaml:"notify,omitempty"`            // Notifications sent when the command finishes
+}
+
+// Modes for running the dependencies of a command
+const (
+	DependsModeFailFast = "fail-fast" // Stop at the first failing dependency
+	DependsModeAll      = "all"       // Run every dependency and report all failures
+)
+
+// Actions taken when a command produces no output for its stall_timeout
+const (
+	StallActionKill = "kill" // Stop the command, failing it
+	StallActionWarn = "warn" // Print a warning and let the command run on
+)
+
+// Modes for showing the output of a command
+const (
+	OutputStream   = "stream"   // Print the output while the command runs
+	OutputCaptured = "captured" // Print a status line, and the output only if the command fails
+)
+
+// InheritsEnv reports whether the command runs with the environment of yxa, or in
+// a clean environment with only the variables yxa knows
+func (c Command) InheritsEnv() bool {
+	return c.InheritEnv == nil || *c.InheritEnv
+}
+
+// ExportsEnv reports whether the variables yxa knows for the command are exported
+// to the environment of its processes, besides being substituted in run. Commands
+// that do not inherit the environment of yxa always export them.
+func (c Command) ExportsEnv() bool {
+	return !c.InheritsEnv() || (c.ExportEnv != nil && *c.ExportEnv)
+}
+
+// NeededVars returns the names of the variables of needs in the order the commands
+// they need run: sorted by name
+func (c Command) NeededVars() []string {
+	names := make([]string, 0, len(c.Needs))
+	for name := range c.Needs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Inherit returns the command as a subcommand of parent, with the inherit_env,
+// export_env, workingdir, timeout and notify of parent where it does not set them
+// and the variables of both, its own taking precedence
+func (c Command) Inherit(parent Command) Command {
+	if c.InheritEnv == nil {
+		c.InheritEnv = parent.InheritEnv
+	}
+	if c.ExportEnv == nil {
+		c.ExportEnv = parent.ExportEnv
+	}
+	if c.WorkingDir == "" {
+		c.WorkingDir = parent.WorkingDir
+	}
+	if c.Timeout == "" {
+		c.Timeout = parent.Timeout
+	}
+	if c.Notify == nil {
+		c.Notify = parent.Notify
+	}
+	if len(parent.Variables) > 0 {
+		vars := make(map[string]string, len(parent.Variables)+len(c.Variables))
+		for k, v := range parent.Variables {
+			vars[k] = v
+		}
+		for k, v := range c.Variables {
+			vars[k] = v
+		}
+		c.Variables = vars
+	}
+	return c
 }
 
 // LoadConfig loads the project configuration from the yxa.yml file (legacy, cwd)
@@ -44,6 +193,7 @@ func LoadConfig() (*ProjectConfig, error) {
 }
 
 // MergeConfigs merges global and project configs. Project config values take precedence.
+// Profiles are merged as well, see WithProfile for how they are applied.
 func MergeConfigs(global, project *ProjectConfig) *ProjectConfig {
 	if global == nil && project == nil {
 		return &ProjectConfig{}
@@ -58,6 +208,21 @@ func MergeConfigs(global, project *ProjectConfig) *ProjectConfig {
 	if project.Name != "" {
 		merged.Name = project.Name
 	}
+	if project.configDir != "" {
+		merged.configFile = project.configFile
+		merged.configDir = project.configDir
+	}
+	if project.Notify != nil {
+		merged.Notify = project.Notify
+	}
+	if project.DefaultTimeout != "" {
+		merged.DefaultTimeout = project.DefaultTimeout
+	}
+	if project.EncryptedVariables != "" {
+		merged.EncryptedVariables = project.EncryptedVariables
+		merged.secretVars = project.secretVars
+		merged.secretVarsErr = project.secretVarsErr
+	}
 
 	// Merge variables
 	merged.Variables = map[string]string{}
@@ -67,6 +232,13 @@ func MergeConfigs(global, project *ProjectConfig) *ProjectConfig {
 	for k, v := range project.Variables {
 		merged.Variables[k] = v
 	}
+	merged.sensitive = map[string]bool{}
+	for k := range global.sensitive {
+		merged.sensitive[k] = true
+	}
+	for k := range project.sensitive {
+		merged.sensitive[k] = true
+	}
 	// Merge commands
 	merged.Commands = map[string]Command{}
 	for k, v := range global.Commands {
@@ -75,63 +247,215 @@ func MergeConfigs(global, project *ProjectConfig) *ProjectConfig {
 	for k, v := range project.Commands {
 		merged.Commands[k] = v
 	}
+	// Merge tools, the project pins its own versions
+	if len(global.Tools) > 0 || len(project.Tools) > 0 {
+		merged.Tools = map[string]Tool{}
+		for k, v := range global.Tools {
+			merged.Tools[k] = v
+		}
+		for k, v := range project.Tools {
+			merged.Tools[k] = v
+		}
+	}
+	merged.Params = mergeParams(global.Params, project.Params)
+	// Merge profiles, they are applied on top of the merged config
+	merged.Profiles = mergeProfiles(global.Profiles, project.Profiles)
+	merged.origins, merged.shadowed = mergeOrigins(global, project)
 	return &merged
 }
 
-// LoadConfigFrom loads the project configuration from the specified file path, merging with global config if present.
+// OverrideFileName is the name of the file next to a config that is merged over it,
+// for local changes that are not committed
+const OverrideFileName = "yxa.override.yml"
+
+// LoadOptions controls how LoadConfigWith loads a config
+type LoadOptions struct {
+	NoOverride bool // Do not merge the yxa.override.yml next to the config
+}
+
+// LoadConfigFrom loads the project configuration from the specified file path, merging
+// with the yxa.yml files of its parent directories and the global config if present,
+// and with the yxa.override.yml next to it.
 func LoadConfigFrom(configPath string) (*ProjectConfig, error) {
+	return LoadConfigWith(configPath, LoadOptions{})
+}
+
+// LoadConfigWith loads the project configuration like LoadConfigFrom with the given
+// options
+func LoadConfigWith(configPath string, opts LoadOptions) (*ProjectConfig, error) {
+	config, err := loadWithParents(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to load and merge global config if present
+	globalConfigPath, err := getGlobalConfigPath(configPath)
+	if err == nil {
+		globalConfig, err := LoadConfigWith(globalConfigPath, LoadOptions{NoOverride: true})
+		switch {
+		case stderrors.Is(err, secrets.ErrNoConfigKey):
+			// Without the key the project config still works, only the global
+			// commands and variables are missing
+			config.globalConfigErr = err
+		case err != nil:
+			return nil, fmt.Errorf("failed to load global config: %w", err)
+		default:
+			conflicts := commandConflicts(globalConfig, config)
+			config = MergeConfigs(globalConfig, config)
+			config.conflicts = conflicts
+		}
+	}
+
+	if !opts.NoOverride {
+		if config, err = mergeOverride(config, filepath.Join(filepath.Dir(configPath), OverrideFileName)); err != nil {
+			return nil, err
+		}
+	}
+	return config.withSharedParams()
+}
+
+// mergeOverride merges the override file at path over config, if it exists, and
+// remembers which variables it set
+func mergeOverride(config *ProjectConfig, path string) (*ProjectConfig, error) {
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return config, nil
+	}
+	override, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := MergeConfigs(config, override)
+	// The override is merged last but does not move the config
+	merged.configFile, merged.configDir = config.configFile, config.configDir
+	merged.conflicts = config.conflicts
+	merged.overrideFile = override.configFile
+	merged.overrideVars = make(map[string]bool, len(override.Variables))
+	for name := range override.Variables {
+		merged.overrideVars[name] = true
+	}
+	return merged, nil
+}
+
+// OverrideFile returns the absolute path of the override file merged over the
+// config, or an empty string if none was
+func (c *ProjectConfig) OverrideFile() string {
+	return c.overrideFile
+}
+
+// FromOverride reports whether a variable was set by the override file
+func (c *ProjectConfig) FromOverride(name string) bool {
+	return c.overrideVars[name]
+}
+
+// loadWithParents loads the config file at configPath merged on top of the nearest
+// yxa.yml in a parent directory, which is itself merged with its parents, up to a
+// config that sets root: true or the root of the file system
+func loadWithParents(configPath string) (*ProjectConfig, error) {
+	config, err := loadFile(configPath)
+	if err != nil || config.Root || config.configDir == "" {
+		return config, err
+	}
+
+	parentPath := findParentConfig(filepath.Dir(config.configDir))
+	if parentPath == "" {
+		return config, nil
+	}
+	parent, err := loadWithParents(parentPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load parent config: %w", err)
+	}
+	return MergeConfigs(parent, config), nil
+}
+
+// findParentConfig returns the path of the yxa.yml in dir or the nearest of its
+// parents, or an empty string if there is none
+func findParentConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, "yxa.yml")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadFile loads a single config file with the .env file of the current directory
+// and its encrypted variables, without merging other configs
+func loadFile(configPath string) (*ProjectConfig, error) {
 	// Check if the file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("config file not found: %s", configPath)
+		return nil, errors.NewConfigFileError(configPath, "not found", nil)
 	}
 
-	// Read the file
-	// #nosec G304 -- This is intentional as reading the config file is the core functionality
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// Read the file, decrypting an encrypted config
+	var data []byte
+	var err error
+	if secrets.IsEncryptedConfig(configPath) {
+		if data, err = secrets.DecryptConfig(configPath); err != nil {
+			return nil, errors.NewConfigFileError(configPath, "failed to decrypt", err)
+		}
+	} else {
+		// #nosec G304 -- This is intentional as reading the config file is the core functionality
+		if data, err = os.ReadFile(configPath); err != nil {
+			return nil, errors.NewConfigFileError(configPath, "failed to read", err)
+		}
 	}
 
 	// Parse the YAML data
 	var config ProjectConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, errors.NewConfigFileError(configPath, "failed to parse", err)
 	}
 
 	// Initialize the environment variables map
 	config.envVars = make(map[string]string)
 
+	// Remember where the config was loaded from for the built-in variables
+	if absPath, err := filepath.Abs(configPath); err == nil {
+		config.configFile = absPath
+		config.configDir = filepath.Dir(absPath)
+	}
+	config.recordOrigins()
+
 	// Load environment variables from .env file if it exists (always relative to cwd)
 	envPath := filepath.Join(".", ".env")
 	if _, err := os.Stat(envPath); err == nil {
 		envVars, err := godotenv.Read(envPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read .env file: %w", err)
+			return nil, errors.NewConfigFileError(envPath, "failed to read", err)
 		}
 		for key, value := range envVars {
 			config.envVars[key] = value
 		}
 	}
 
-	// Process the commands to replace variables
-	for name, cmd := range config.Commands {
-		cmd.Run = config.ReplaceVariables(cmd.Run)
-		config.Commands[name] = cmd
-	}
-
-	// Try to load and merge global config if present
-	globalConfigPath, err := getGlobalConfigPath(configPath)
-	if err == nil {
-		globalConfig, err := LoadConfigFrom(globalConfigPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load global config: %w", err)
-		}
-		config = *MergeConfigs(globalConfig, &config)
+	// Decrypt the encrypted variables if the key is available. A missing key is no
+	// error, so the commands that do not need them still run.
+	if path := config.EncryptedVariablesPath(); path != "" {
+		config.secretVars, config.secretVarsErr = secrets.Decrypt(path)
 	}
 
 	return &config, nil
 }
 
+// EncryptedGlobalConfigName is the name of the encrypted global config in the home
+// directory. Like the global configs before it, ~/.yxa.yml merges it below itself.
+const EncryptedGlobalConfigName = ".yxa.yml" + secrets.EncryptedConfigExt
+
+// EncryptedGlobalConfigPath returns the path of the encrypted global config
+func EncryptedGlobalConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, EncryptedGlobalConfigName), nil
+}
+
 // getGlobalConfigPath returns the path to the global config, or error if not found or not applicable.
 func getGlobalConfigPath(currentPath string) (string, error) {
 	home, err := os.UserHomeDir()
@@ -142,11 +466,16 @@ func getGlobalConfigPath(currentPath string) (string, error) {
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
 		globalCandidates = append(globalCandidates, filepath.Join(xdg, "yxa", "config.yml"))
 	}
-	globalCandidates = append(globalCandidates, filepath.Join(home, ".yxa.yml"))
-	for _, p := range globalCandidates {
+	globalCandidates = append(globalCandidates, filepath.Join(home, ".yxa.yml"), filepath.Join(home, EncryptedGlobalConfigName))
+	// A global config only merges the global configs after it, so that they do not
+	// merge each other in turn
+	for i, p := range globalCandidates {
 		if p == currentPath {
-			continue
+			globalCandidates = globalCandidates[i+1:]
+			break
 		}
+	}
+	for _, p := range globalCandidates {
 		if _, err := os.Stat(p); err == nil {
 			return p, nil
 		}
@@ -154,12 +483,66 @@ func getGlobalConfigPath(currentPath string) (string, error) {
 	return "", fmt.Errorf("no global config found")
 }
 
+// ConfigFile returns the absolute path of the loaded config file, or an empty
+// string if the config was not loaded from disk
+func (c *ProjectConfig) ConfigFile() string {
+	return c.configFile
+}
+
+// ConfigDir returns the absolute directory of the loaded config file, or an
+// empty string if the config was not loaded from disk
+func (c *ProjectConfig) ConfigDir() string {
+	return c.configDir
+}
+
+// EncryptedVariablesPath returns the absolute path of the encrypted variables file,
+// or an empty string if the config has none
+func (c *ProjectConfig) EncryptedVariablesPath() string {
+	if c.EncryptedVariables == "" || filepath.IsAbs(c.EncryptedVariables) {
+		return c.EncryptedVariables
+	}
+	return filepath.Join(c.configDir, c.EncryptedVariables)
+}
+
+// SecretVars returns the decrypted encrypted variables
+func (c *ProjectConfig) SecretVars() map[string]string {
+	return c.secretVars
+}
+
+// SecretVarsError returns why the encrypted variables could not be decrypted, nil
+// if they were or the config has none
+func (c *ProjectConfig) SecretVarsError() error {
+	return c.secretVarsErr
+}
+
+// GlobalConfigError returns why the encrypted global config was not loaded, nil if
+// it was or there is none
+func (c *ProjectConfig) GlobalConfigError() error {
+	return c.globalConfigErr
+}
+
+// BuiltinVars returns the built-in variables that are derived from the config itself
+func (c *ProjectConfig) BuiltinVars() map[string]string {
+	vars := map[string]string{
+		variables.BuiltinProjectName: c.Name,
+	}
+	if c.configDir != "" {
+		vars[variables.BuiltinConfigDir] = c.configDir
+	}
+	if c.profile != "" {
+		vars[variables.BuiltinProfile] = c.profile
+	}
+	return vars
+}
+
 // ReplaceVariables replaces variables in the given string with their values
 func (c *ProjectConfig) ReplaceVariables(input string) string {
 	// Create a variable resolver with the project's variables
 	resolver := variables.NewResolver().
 		WithConfigVars(c.Variables).
-		WithEnvFileVars(c.envVars)
+		WithSecretVars(c.secretVars).
+		WithEnvFileVars(c.envVars).
+		WithBuiltinVars(c.BuiltinVars())
 
 	// Resolve variables in the input string
 	return resolver.Resolve(input)
@@ -168,14 +551,26 @@ func (c *ProjectConfig) ReplaceVariables(input string) string {
 // ReplaceVariablesWithParams replaces variables in the given string with their values,
 // including parameter variables
 func (c *ProjectConfig) ReplaceVariablesWithParams(input string, paramVars map[string]string) string {
-	// Create a variable resolver with all variable sources
-	resolver := variables.NewResolver().
+	return c.ReplaceVariablesWithContext(input, paramVars, nil)
+}
+
+// ReplaceVariablesWithContext replaces variables in the given string with their values,
+// including parameter variables and runtime built-in variables (e.g. YXA_COMMAND)
+func (c *ProjectConfig) ReplaceVariablesWithContext(input string, paramVars, builtinVars map[string]string) string {
+	// Resolve variables in the input string using all variable sources
+	return c.NewResolver(paramVars, builtinVars).Resolve(input)
+}
+
+// NewResolver creates a variable resolver with all variable sources of the config,
+// the given parameter variables and the given runtime built-in variables
+func (c *ProjectConfig) NewResolver(paramVars, builtinVars map[string]string) *variables.Resolver {
+	return variables.NewResolver().
 		WithParamVars(paramVars).
 		WithConfigVars(c.Variables).
-		WithEnvFileVars(c.envVars)
-
-	// Resolve variables in the input string
-	return resolver.Resolve(input)
+		WithSecretVars(c.secretVars).
+		WithEnvFileVars(c.envVars).
+		WithBuiltinVars(c.BuiltinVars()).
+		WithBuiltinVars(builtinVars)
 }
 
 // EvaluateCondition evaluates a condition string and returns whether it's true
@@ -185,39 +580,68 @@ func (c *ProjectConfig) EvaluateCondition(condition string) bool {
 
 // EvaluateConditionWithParams evaluates a condition string with parameter variables
 func (c *ProjectConfig) EvaluateConditionWithParams(condition string, paramVars map[string]string) bool {
+	return c.EvaluateConditionWithContext(condition, paramVars, nil)
+}
+
+// EvaluateConditionWithContext evaluates a condition string with parameter variables
+// and runtime built-in variables. It runs no commands, so output-of conditions are
+// not met.
+func (c *ProjectConfig) EvaluateConditionWithContext(condition string, paramVars, builtinVars map[string]string) bool {
 	if condition == "" {
 		// Empty condition is always true
 		return true
 	}
 
-	// Replace variables in the condition using all variable sources
-	resolver := variables.NewResolver().
-		WithParamVars(paramVars).
-		WithConfigVars(c.Variables).
-		WithEnvFileVars(c.envVars)
-	condition = resolver.Resolve(condition)
+	return EvaluateConditionWithResolver(condition, c.NewResolver(paramVars, builtinVars), nil)
+}
+
+// EvaluateConditionWithResolver resolves the variables in a condition string with the
+// given resolver and evaluates the result, running the commands of output-of
+// conditions with output
+func EvaluateConditionWithResolver(condition string, resolver *variables.Resolver, output OutputFunc) bool {
+	if condition == "" {
+		// Empty condition is always true
+		return true
+	}
 
 	// Evaluate the resolved condition
-	return evaluateConditionString(condition)
+	return evaluateConditionString(resolver.Resolve(condition), output)
+}
+
+// EvaluateResolvedCondition evaluates a condition whose variables have already been
+// resolved, running the commands of output-of conditions with output
+func EvaluateResolvedCondition(condition string, output OutputFunc) bool {
+	return evaluateConditionString(condition, output)
 }
 
-func evaluateConditionString(condition string) bool {
+func evaluateConditionString(condition string, output OutputFunc) bool {
+	// Built-in predicates (e.g., "cmd-exists docker"), which may contain operators
+	// in their quoted commands
+	if result, ok := evaluatePredicate(condition, output); ok {
+		return result
+	}
+
 	// Simple equality check (e.g., "$GOOS == darwin")
 	equalityPattern := regexp.MustCompile(`^\s*(.+?)\s*==\s*(.+?)\s*$`)
 	if matches := equalityPattern.FindStringSubmatch(condition); len(matches) == 3 {
-		return strings.TrimSpace(matches[1]) == strings.TrimSpace(matches[2])
+		return conditionOperand(matches[1]) == conditionOperand(matches[2])
 	}
 
 	// Simple inequality check (e.g., "$GOOS != darwin")
 	inequalityPattern := regexp.MustCompile(`^\s*(.+?)\s*!=\s*(.+?)\s*$`)
 	if matches := inequalityPattern.FindStringSubmatch(condition); len(matches) == 3 {
-		return strings.TrimSpace(matches[1]) != strings.TrimSpace(matches[2])
+		return conditionOperand(matches[1]) != conditionOperand(matches[2])
 	}
 
 	// Contains check (e.g., "$PATH contains /usr/local")
 	containsPattern := regexp.MustCompile(`^\s*(.+?)\s+contains\s+(.+?)\s*$`)
 	if matches := containsPattern.FindStringSubmatch(condition); len(matches) == 3 {
-		return strings.Contains(strings.TrimSpace(matches[1]), strings.TrimSpace(matches[2]))
+		return strings.Contains(conditionOperand(matches[1]), conditionOperand(matches[2]))
+	}
+
+	// Boolean value (e.g., "$VERBOSE" of a bool parameter), negated with a leading !
+	if value, ok := conditionBool(condition); ok {
+		return value
 	}
 
 	// Exists check (e.g., "exists /path/to/file")
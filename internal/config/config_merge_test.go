@@ -1,14 +1,20 @@
 package config
 
 import (
+	"encoding/base64"
+	stderrors "errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/floppa/yxa-cli/internal/secrets"
 )
 
 func TestMergeConfigs(t *testing.T) {
 	global := &ProjectConfig{
-		Name: "global",
+		Name:           "global",
+		DefaultTimeout: "10m",
 		Variables: map[string]string{
 			"A": "globalA",
 			"B": "globalB",
@@ -17,6 +23,10 @@ func TestMergeConfigs(t *testing.T) {
 			"gcmd":   {Run: "echo global"},
 			"shared": {Run: "echo global-shared"},
 		},
+		Tools: map[string]Tool{
+			"protoc": {Version: "25.1"},
+			"node":   {Version: "18.0.0"},
+		},
 	}
 	project := &ProjectConfig{
 		Name: "project",
@@ -28,8 +38,14 @@ func TestMergeConfigs(t *testing.T) {
 			"pcmd":   {Run: "echo project"},
 			"shared": {Run: "echo project-shared"},
 		},
+		Tools: map[string]Tool{
+			"node": {Version: "20.11.0"},
+		},
 	}
 	merged := MergeConfigs(global, project)
+	if merged.DefaultTimeout != "10m" {
+		t.Errorf("DefaultTimeout: got %v, want the global 10m", merged.DefaultTimeout)
+	}
 	if merged.Name != "project" {
 		t.Errorf("Name: got %v, want project", merged.Name)
 	}
@@ -39,6 +55,9 @@ func TestMergeConfigs(t *testing.T) {
 	if merged.Commands["gcmd"].Run != "echo global" || merged.Commands["pcmd"].Run != "echo project" || merged.Commands["shared"].Run != "echo project-shared" {
 		t.Errorf("Commands not merged as expected: %+v", merged.Commands)
 	}
+	if merged.Tools["protoc"].Version != "25.1" || merged.Tools["node"].Version != "20.11.0" {
+		t.Errorf("Tools not merged as expected: %+v", merged.Tools)
+	}
 }
 
 // assertVariable checks that a variable has the expected value.
@@ -111,3 +130,301 @@ commands:
 	assertCommand(t, cfg.Commands["shared"], "echo project-shared", "shared")
 
 }
+
+// TestLoadConfigFrom_InheritsParentDirectories verifies that the yxa.yml files of
+// parent directories are merged below the loaded config, up to one with root: true.
+func TestLoadConfigFrom_InheritsParentDirectories(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	files := map[string]string{
+		"repo/yxa.yml": `
+name: repo
+root: true
+variables:
+  REGISTRY: registry.example.com
+  ENV: dev
+commands:
+  lint:
+    run: golangci-lint run
+  shared:
+    run: echo repo
+`,
+		"repo/services/yxa.yml": `
+variables:
+  ENV: staging
+commands:
+  shared:
+    run: echo services
+`,
+		"repo/services/api/yxa.yml": `
+name: api
+commands:
+  build:
+    run: go build
+`,
+		"repo/tools/yxa.yml": `
+name: tools
+root: true
+commands:
+  gen:
+    run: go generate
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	// A config above the root one is never inherited
+	if err := os.WriteFile(filepath.Join(dir, "yxa.yml"), []byte("commands:\n  outer:\n    run: echo outer\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfigFrom(filepath.Join(dir, "repo", "services", "api", "yxa.yml"))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom error: %v", err)
+	}
+	if cfg.Name != "api" {
+		t.Errorf("Name: got %v, want api", cfg.Name)
+	}
+	if cfg.ConfigDir() != filepath.Join(dir, "repo", "services", "api") {
+		t.Errorf("ConfigDir: got %v, want the directory of the leaf config", cfg.ConfigDir())
+	}
+	assertVariable(t, cfg.Variables["REGISTRY"], "registry.example.com", "REGISTRY")
+	assertVariable(t, cfg.Variables["ENV"], "staging", "ENV")
+	assertCommand(t, cfg.Commands["lint"], "golangci-lint run", "lint")
+	assertCommand(t, cfg.Commands["shared"], "echo services", "shared")
+	assertCommand(t, cfg.Commands["build"], "go build", "build")
+	if _, ok := cfg.Commands["outer"]; ok {
+		t.Errorf("Commands above the root config were inherited: %+v", cfg.Commands)
+	}
+
+	cfg, err = LoadConfigFrom(filepath.Join(dir, "repo", "tools", "yxa.yml"))
+	if err != nil {
+		t.Fatalf("LoadConfigFrom error: %v", err)
+	}
+	if len(cfg.Commands) != 1 || len(cfg.Variables) != 0 {
+		t.Errorf("Config with root: true inherited from its parents: %+v", cfg)
+	}
+}
+
+// TestLoadConfigWith_Override verifies that yxa.override.yml is merged over the
+// config unless disabled.
+func TestLoadConfigWith_Override(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	projectConfig := `
+name: project
+root: true
+variables:
+  DB_HOST: db.internal
+  PORT: "8080"
+commands:
+  serve:
+    run: ./serve --port $PORT
+  test:
+    run: go test ./...
+`
+	overrideConfig := `
+variables:
+  DB_HOST: localhost
+commands:
+  serve:
+    run: air
+`
+	projectPath := filepath.Join(dir, "yxa.yml")
+	if err := os.WriteFile(projectPath, []byte(projectConfig), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, OverrideFileName), []byte(overrideConfig), 0644); err != nil {
+		t.Fatalf("Failed to write override config: %v", err)
+	}
+
+	cfg, err := LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom error: %v", err)
+	}
+	assertVariable(t, cfg.Variables["DB_HOST"], "localhost", "DB_HOST")
+	assertVariable(t, cfg.Variables["PORT"], "8080", "PORT")
+	assertCommand(t, cfg.Commands["serve"], "air", "serve")
+	assertCommand(t, cfg.Commands["test"], "go test ./...", "test")
+	if cfg.Name != "project" {
+		t.Errorf("Name: got %v, want project", cfg.Name)
+	}
+	if cfg.ConfigFile() != projectPath {
+		t.Errorf("ConfigFile: got %v, want %v", cfg.ConfigFile(), projectPath)
+	}
+	if cfg.OverrideFile() != filepath.Join(dir, OverrideFileName) {
+		t.Errorf("OverrideFile: got %v, want the override file", cfg.OverrideFile())
+	}
+	if !cfg.FromOverride("DB_HOST") || cfg.FromOverride("PORT") {
+		t.Errorf("FromOverride: got DB_HOST=%v PORT=%v, want true and false", cfg.FromOverride("DB_HOST"), cfg.FromOverride("PORT"))
+	}
+
+	cfg, err = LoadConfigWith(projectPath, LoadOptions{NoOverride: true})
+	if err != nil {
+		t.Fatalf("LoadConfigWith error: %v", err)
+	}
+	assertVariable(t, cfg.Variables["DB_HOST"], "db.internal", "DB_HOST")
+	assertCommand(t, cfg.Commands["serve"], "./serve --port $PORT", "serve")
+	if cfg.OverrideFile() != "" || cfg.FromOverride("DB_HOST") {
+		t.Errorf("Override merged although disabled: %v", cfg.OverrideFile())
+	}
+}
+
+// TestLoadConfigFrom_Origins verifies that the config file of every command and
+// variable is known and that conflicting global commands are reported.
+func TestLoadConfigFrom_Origins(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	globalConfig := `
+variables:
+  REGISTRY: ghcr.io
+commands:
+  deploy:
+    run: ./deploy.sh
+  lint:
+    run: golangci-lint run
+  fmt:
+    run: gofmt -w .
+`
+	projectConfig := `
+root: true
+variables:
+  REGISTRY: registry.internal
+commands:
+  deploy:
+    run: kubectl apply -f k8s
+  lint:
+    description: Lint the code
+    run: golangci-lint run
+`
+	globalPath := filepath.Join(dir, ".yxa.yml")
+	projectPath := filepath.Join(dir, "project", "yxa.yml")
+	if err := os.WriteFile(globalPath, []byte(globalConfig), 0644); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(projectPath), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(projectPath, []byte(projectConfig), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "project", OverrideFileName), []byte("variables:\n  REGISTRY: localhost:5000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write override config: %v", err)
+	}
+
+	cfg, err := LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom error: %v", err)
+	}
+
+	overridePath := filepath.Join(dir, "project", OverrideFileName)
+	if got := cfg.CommandOrigin("deploy"); got != projectPath {
+		t.Errorf("CommandOrigin(deploy): got %v, want %v", got, projectPath)
+	}
+	if got := cfg.CommandOrigin("fmt"); got != globalPath {
+		t.Errorf("CommandOrigin(fmt): got %v, want %v", got, globalPath)
+	}
+	if got := cfg.ShadowedCommand("deploy"); !reflect.DeepEqual(got, []string{globalPath}) {
+		t.Errorf("ShadowedCommand(deploy): got %v, want the global config", got)
+	}
+	if got := cfg.VariableOrigin("REGISTRY"); got != overridePath {
+		t.Errorf("VariableOrigin(REGISTRY): got %v, want %v", got, overridePath)
+	}
+	if got := cfg.ShadowedVariable("REGISTRY"); !reflect.DeepEqual(got, []string{projectPath, globalPath}) {
+		t.Errorf("ShadowedVariable(REGISTRY): got %v, want the project and global config", got)
+	}
+
+	// lint only adds a description and does not conflict
+	want := []MergeConflict{{Command: "deploy", Origin: projectPath, Shadowed: globalPath}}
+	if got := cfg.MergeConflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeConflicts: got %+v, want %+v", got, want)
+	}
+}
+
+// TestLoadConfigFrom_MergesEncryptedGlobal verifies that ~/.yxa.yml.enc is merged
+// like a plain global config, below ~/.yxa.yml, and left out if there is no key.
+func TestLoadConfigFrom_MergesEncryptedGlobal(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(secrets.ConfigKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+
+	globalPath, err := EncryptedGlobalConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if globalPath != filepath.Join(dir, ".yxa.yml.enc") {
+		t.Fatalf("unexpected encrypted global config path %s", globalPath)
+	}
+	if err := secrets.EncryptConfig(globalPath, []byte("variables:\n  TOKEN: abc\ncommands:\n  login:\n    run: ./login.sh $TOKEN\n")); err != nil {
+		t.Fatal(err)
+	}
+	projectPath := filepath.Join(dir, "project", "yxa.yml")
+	if err := os.MkdirAll(filepath.Dir(projectPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projectPath, []byte("name: project\nroot: true\ncommands:\n  build:\n    run: go build\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	assertCommand(t, cfg.Commands["login"], "./login.sh $TOKEN", "login")
+	assertCommand(t, cfg.Commands["build"], "go build", "build")
+	if cfg.Variables["TOKEN"] != "abc" {
+		t.Errorf("expected TOKEN from the encrypted global config, got %q", cfg.Variables["TOKEN"])
+	}
+	if cfg.GlobalConfigError() != nil {
+		t.Errorf("unexpected global config error: %v", cfg.GlobalConfigError())
+	}
+
+	// Without the key the project config still loads
+	t.Setenv(secrets.ConfigKeyEnv, "")
+	t.Setenv("PATH", t.TempDir())
+	cfg, err = LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("failed to load config without the key: %v", err)
+	}
+	if _, ok := cfg.Commands["login"]; ok {
+		t.Error("expected no commands of the encrypted global config without the key")
+	}
+	assertCommand(t, cfg.Commands["build"], "go build", "build")
+	if !stderrors.Is(cfg.GlobalConfigError(), secrets.ErrNoConfigKey) {
+		t.Errorf("expected a missing key error, got %v", cfg.GlobalConfigError())
+	}
+
+	// A plain global config is merged over the encrypted one
+	if err := os.WriteFile(filepath.Join(dir, ".yxa.yml"), []byte("commands:\n  plain:\n    run: echo plain\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("failed to load config without the key: %v", err)
+	}
+	assertCommand(t, cfg.Commands["plain"], "echo plain", "plain")
+	if !stderrors.Is(cfg.GlobalConfigError(), secrets.ErrNoConfigKey) {
+		t.Errorf("expected a missing key error, got %v", cfg.GlobalConfigError())
+	}
+
+	t.Setenv(secrets.ConfigKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	cfg, err = LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	assertCommand(t, cfg.Commands["plain"], "echo plain", "plain")
+	assertCommand(t, cfg.Commands["login"], "./login.sh $TOKEN", "login")
+}
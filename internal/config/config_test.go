@@ -1,9 +1,13 @@
 package config
 
 import (
+	stderrors "errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/floppa/yxa-cli/internal/errors"
 )
 
 func writeConfigsAndCheckWorkingDir(t *testing.T, globalConfigYAML, projectConfigYAML, commandName, expectedWorkingDir string) {
@@ -470,6 +474,40 @@ func TestLoadConfig(t *testing.T) {
 	assertConfigEnvVars(t, cfg)
 }
 
+func TestLoadConfig_BuiltinVars(t *testing.T) {
+	tmpDir, cleanupTmp := createTempDir(t)
+	defer cleanupTmp()
+
+	_, cleanupChdir := changeToDir(t, tmpDir)
+	defer cleanupChdir()
+
+	writeConfigFile(t, "yxa.yml", `
+name: builtin-project
+commands:
+  where:
+    run: echo $YXA_PROJECT_NAME in $YXA_CONFIG_DIR for $YXA_COMMAND
+`)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	wantDir, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	if cfg.ConfigDir() != wantDir {
+		t.Errorf("cfg.ConfigDir() = %v, want %v", cfg.ConfigDir(), wantDir)
+	}
+
+	want := "echo builtin-project in " + wantDir + " for where"
+	got := cfg.ReplaceVariablesWithContext(cfg.Commands["where"].Run, nil, map[string]string{"YXA_COMMAND": "where"})
+	if got != want {
+		t.Errorf("ReplaceVariablesWithContext() = %v, want %v", got, want)
+	}
+}
+
 func createTempDir(t *testing.T) (string, func()) {
 	tmpDir, err := os.MkdirTemp("", "config-test")
 	if err != nil {
@@ -602,3 +640,61 @@ func TestLoadConfig_FileNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigFrom_InvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yxa.yml")
+	writeConfigFile(t, path, "commands: [unclosed")
+
+	_, err := LoadConfigFrom(path)
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(err, &cfgErr) {
+		t.Fatalf("LoadConfigFrom() error = %v, want a ConfigError", err)
+	}
+	if cfgErr.Section != "file" || cfgErr.Name != path || cfgErr.Message != "failed to parse" {
+		t.Errorf("LoadConfigFrom() error = %v, want a parse error of %s", err, path)
+	}
+}
+
+func TestCommandInherit(t *testing.T) {
+	no := false
+	grandparent := Command{
+		Timeout:    "1m",
+		WorkingDir: "/srv",
+		InheritEnv: &no,
+		Variables:  map[string]string{"TIER": "platform", "OWNER": "infra"},
+		Notify:     &Notify{On: NotifyFailure, Desktop: true},
+	}
+	parent := Command{WorkingDir: "/srv/services", Variables: map[string]string{"TIER": "services"}}.Inherit(grandparent)
+	child := Command{Timeout: "5s", Variables: map[string]string{"NAME": "api"}}.Inherit(parent)
+
+	if child.Timeout != "5s" {
+		t.Errorf("Timeout: got %q, want the own 5s", child.Timeout)
+	}
+	if child.WorkingDir != "/srv/services" {
+		t.Errorf("WorkingDir: got %q, want /srv/services of the parent", child.WorkingDir)
+	}
+	if child.InheritsEnv() {
+		t.Error("inherit_env: false of the grandparent should be inherited")
+	}
+	if child.Notify == nil || child.Notify.On != NotifyFailure {
+		t.Errorf("Notify: got %+v, want the notify of the grandparent", child.Notify)
+	}
+	want := map[string]string{"TIER": "services", "OWNER": "infra", "NAME": "api"}
+	if len(child.Variables) != len(want) {
+		t.Errorf("Variables: got %v, want %v", child.Variables, want)
+	}
+	for k, v := range want {
+		if child.Variables[k] != v {
+			t.Errorf("Variables[%s]: got %q, want %q", k, child.Variables[k], v)
+		}
+	}
+	if len(parent.Variables) != 2 || grandparent.Variables["TIER"] != "platform" {
+		t.Errorf("parents should not be modified, got %v and %v", parent.Variables, grandparent.Variables)
+	}
+
+	// Settings of the command take precedence
+	yes := true
+	if cmd := (Command{InheritEnv: &yes}).Inherit(grandparent); !cmd.InheritsEnv() {
+		t.Error("own inherit_env: true should take precedence")
+	}
+}
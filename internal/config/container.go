@@ -0,0 +1,11 @@
+package config
+
+// Container runs the shell commands of a command inside a Docker container
+// instead of on the host
+type Container struct {
+	Image   string            `yaml:"image"`             // Image to run, e.g. golang:1.24
+	Volumes []string          `yaml:"volumes,omitempty"` // Bind mounts as host:container[:options]
+	Env     map[string]string `yaml:"env,omitempty"`     // Environment variables set in the container
+	Workdir string            `yaml:"workdir,omitempty"` // Working directory inside the container
+	Pull    string            `yaml:"pull,omitempty"`    // When to pull the image: missing (default), always or never
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dependency is one entry of the dependencies of a command. In yxa.yml it can be
+// written as the name of a command or as a mapping with a condition.
+type Dependency struct {
+	Command   string `yaml:"command"`             // Command to execute first
+	Condition string `yaml:"condition,omitempty"` // Condition to evaluate before executing the dependency
+}
+
+// UnmarshalYAML accepts both a command name and a dependency mapping
+func (d *Dependency) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*d = Dependency{Command: value.Value}
+		return nil
+	}
+
+	type plain Dependency
+	if err := value.Decode((*plain)(d)); err != nil {
+		return err
+	}
+	if d.Command == "" {
+		return fmt.Errorf("line %d: dependency without 'command'", value.Line)
+	}
+	return nil
+}
+
+// DependencyList is the list of dependencies of a command
+type DependencyList []Dependency
+
+// NewDependencyList creates a dependency list without conditions from command names
+func NewDependencyList(commands ...string) DependencyList {
+	deps := make(DependencyList, len(commands))
+	for i, command := range commands {
+		deps[i] = Dependency{Command: command}
+	}
+	return deps
+}
+
+// Commands returns the names of the commands of the dependencies
+func (l DependencyList) Commands() []string {
+	commands := make([]string, len(l))
+	for i, dep := range l {
+		commands[i] = dep.Command
+	}
+	return commands
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDependencyList_UnmarshalYAML(t *testing.T) {
+	var cmd Command
+	data := "depends:\n  - build\n  - command: docker-build\n    condition: $SKIP_DOCKER != true\n"
+	if err := yaml.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := DependencyList{
+		{Command: "build"},
+		{Command: "docker-build", Condition: "$SKIP_DOCKER != true"},
+	}
+	if !reflect.DeepEqual(cmd.Depends, want) {
+		t.Errorf("Depends = %+v, want %+v", cmd.Depends, want)
+	}
+	if commands := cmd.Depends.Commands(); !reflect.DeepEqual(commands, []string{"build", "docker-build"}) {
+		t.Errorf("Commands() = %v", commands)
+	}
+
+	if err := yaml.Unmarshal([]byte("depends:\n  - condition: $CI\n"), &cmd); err == nil {
+		t.Error("Expected an error for a dependency without command, got nil")
+	}
+	if err := yaml.Unmarshal([]byte("depends:\n  - [build]\n"), &cmd); err == nil {
+		t.Error("Expected an error for a dependency that is a list, got nil")
+	}
+}
@@ -0,0 +1,94 @@
+//go:build ignore
+
+// gen_schema_docs.go writes schema_docs.go, the descriptions of the JSON Schema of
+// yxa.yml, from the comments of the fields of the config types. Run it with
+// go generate after changing a field.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const output = "schema_docs.go"
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["config"]
+	if !ok {
+		log.Fatal("package config not found, run go generate in internal/config")
+	}
+
+	docs := make(map[string]string)
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok || !spec.Name.IsExported() {
+				return false
+			}
+			for _, field := range st.Fields.List {
+				if field.Tag == nil || len(field.Names) == 0 || !field.Names[0].IsExported() {
+					continue
+				}
+				tag, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					log.Fatal(err)
+				}
+				name := strings.Split(reflect.StructTag(tag).Get("yaml"), ",")[0]
+				if name == "" || name == "-" {
+					continue
+				}
+				comment := field.Doc
+				if comment == nil {
+					comment = field.Comment
+				}
+				docs[spec.Name.Name+"."+name] = strings.Join(strings.Fields(comment.Text()), " ")
+			}
+			return false
+		})
+	}
+
+	keys := make([]string, 0, len(docs))
+	for key := range docs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_schema_docs.go; DO NOT EDIT.\n\npackage config\n\n")
+	b.WriteString("// fieldDocs are the comments of the fields of the config types by type and YAML\n")
+	b.WriteString("// name, the descriptions of the JSON Schema of yxa.yml\n")
+	b.WriteString("var fieldDocs = map[string]string{\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\t%q: %q,\n", key, docs[key])
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
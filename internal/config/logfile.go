@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Modes of a LogFile
+const (
+	LogModeTruncate = "truncate" // Start a new log every time the command runs
+	LogModeAppend   = "append"   // Add the output of every run to the log
+)
+
+// DefaultLogKeep is the number of rotated log files kept when keep is not set
+const DefaultLogKeep = 3
+
+// LogFile is a file yxa writes the output of a command to, in addition to the
+// terminal. In yxa.yml it is written as a path, or as a mapping with options:
+//
+//	log_file: logs/build.log
+//	log_file: {path: logs/build.log, mode: append, max_size: 10MB, keep: 5}
+type LogFile struct {
+	Path    string `yaml:"path"`               // Path of the log, relative to the config file
+	Mode    string `yaml:"mode,omitempty"`     // truncate (default) or append
+	MaxSize string `yaml:"max_size,omitempty"` // Rotate the log before a run once it is this large, e.g. 10MB
+	Keep    int    `yaml:"keep,omitempty"`     // Number of rotated logs to keep, DefaultLogKeep if not set
+}
+
+// UnmarshalYAML accepts both a path and a mapping with a path and options
+func (l *LogFile) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		l.Path = value.Value
+		return nil
+	}
+
+	type plain LogFile
+	if err := value.Decode((*plain)(l)); err != nil {
+		return err
+	}
+	if l.Path == "" {
+		return fmt.Errorf("line %d: log file requires a path", value.Line)
+	}
+	return nil
+}
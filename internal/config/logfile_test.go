@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLogFile_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    *LogFile
+		wantErr string
+	}{
+		{
+			name: "path",
+			yaml: "log_file: logs/build.log",
+			want: &LogFile{Path: "logs/build.log"},
+		},
+		{
+			name: "mapping",
+			yaml: "log_file: {path: logs/build.log, mode: append, max_size: 10MB, keep: 5}",
+			want: &LogFile{Path: "logs/build.log", Mode: LogModeAppend, MaxSize: "10MB", Keep: 5},
+		},
+		{
+			name:    "mapping without path",
+			yaml:    "log_file: {mode: append}",
+			wantErr: "log file requires a path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cmd Command
+			err := yaml.Unmarshal([]byte(tt.yaml), &cmd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cmd.LogFile, tt.want) {
+				t.Errorf("LogFile = %+v, want %+v", cmd.LogFile, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MatrixAxis is a variable of a matrix together with the values it takes
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// Matrix expands a command into one instance per combination of its axes. In
+// yxa.yml it is written as a map from variable name to a list of values; the
+// order of the axes is kept.
+type Matrix []MatrixAxis
+
+// MatrixValue is the value of one matrix variable in a combination
+type MatrixValue struct {
+	Name  string
+	Value string
+}
+
+// MatrixCombination is one instance of a matrix, with a value for every axis in axis order
+type MatrixCombination []MatrixValue
+
+// UnmarshalYAML decodes a mapping of variable names to lists of scalar values
+func (m *Matrix) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: matrix must be a map of variable names to lists of values", value.Line)
+	}
+
+	var matrix Matrix
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, values := value.Content[i], value.Content[i+1]
+		axis := MatrixAxis{Name: key.Value}
+
+		switch values.Kind {
+		case yaml.SequenceNode:
+			for _, v := range values.Content {
+				if v.Kind != yaml.ScalarNode {
+					return fmt.Errorf("line %d: values of matrix variable '%s' must be scalars", v.Line, axis.Name)
+				}
+				axis.Values = append(axis.Values, v.Value)
+			}
+		case yaml.ScalarNode:
+			axis.Values = []string{values.Value}
+		default:
+			return fmt.Errorf("line %d: matrix variable '%s' must be a list of values", values.Line, axis.Name)
+		}
+		matrix = append(matrix, axis)
+	}
+	*m = matrix
+	return nil
+}
+
+// Combinations returns every combination of the matrix values. The last axis
+// changes fastest, like nested loops over the axes in order.
+func (m Matrix) Combinations() []MatrixCombination {
+	if len(m) == 0 {
+		return nil
+	}
+
+	combinations := []MatrixCombination{{}}
+	for _, axis := range m {
+		var next []MatrixCombination
+		for _, combination := range combinations {
+			for _, value := range axis.Values {
+				extended := append(append(MatrixCombination{}, combination...), MatrixValue{Name: axis.Name, Value: value})
+				next = append(next, extended)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// Vars returns the combination as variables
+func (c MatrixCombination) Vars() map[string]string {
+	vars := make(map[string]string, len(c))
+	for _, v := range c {
+		vars[v.Name] = v.Value
+	}
+	return vars
+}
+
+// String returns the combination as NAME=value pairs, e.g. "GOOS=linux GOARCH=amd64"
+func (c MatrixCombination) String() string {
+	parts := make([]string, len(c))
+	for i, v := range c {
+		parts[i] = v.Name + "=" + v.Value
+	}
+	return strings.Join(parts, " ")
+}
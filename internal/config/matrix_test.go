@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMatrix_UnmarshalYAML(t *testing.T) {
+	var cmd Command
+	data := "matrix:\n  GOOS: [linux, darwin]\n  GOARCH: [amd64, arm64]\n  GO: 1.22\n"
+	if err := yaml.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := Matrix{
+		{Name: "GOOS", Values: []string{"linux", "darwin"}},
+		{Name: "GOARCH", Values: []string{"amd64", "arm64"}},
+		{Name: "GO", Values: []string{"1.22"}},
+	}
+	if !reflect.DeepEqual(cmd.Matrix, want) {
+		t.Errorf("Matrix = %+v, want %+v", cmd.Matrix, want)
+	}
+
+	for _, invalid := range []string{"matrix: [linux]", "matrix:\n  GOOS: {linux: true}", "matrix:\n  GOOS: [[linux]]"} {
+		if err := yaml.Unmarshal([]byte(invalid), &cmd); err == nil {
+			t.Errorf("Expected an error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestMatrix_Combinations(t *testing.T) {
+	matrix := Matrix{
+		{Name: "GOOS", Values: []string{"linux", "darwin"}},
+		{Name: "GOARCH", Values: []string{"amd64", "arm64"}},
+	}
+
+	var got []string
+	for _, c := range matrix.Combinations() {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"GOOS=linux GOARCH=amd64",
+		"GOOS=linux GOARCH=arm64",
+		"GOOS=darwin GOARCH=amd64",
+		"GOOS=darwin GOARCH=arm64",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Combinations() = %v, want %v", got, want)
+	}
+
+	vars := matrix.Combinations()[3].Vars()
+	if !reflect.DeepEqual(vars, map[string]string{"GOOS": "darwin", "GOARCH": "arm64"}) {
+		t.Errorf("Vars() = %v", vars)
+	}
+
+	if c := (Matrix{}).Combinations(); c != nil {
+		t.Errorf("Combinations() of an empty matrix = %v, want nil", c)
+	}
+	if c := (Matrix{{Name: "A"}}).Combinations(); len(c) != 0 {
+		t.Errorf("Combinations() with an empty axis = %v, want none", c)
+	}
+}
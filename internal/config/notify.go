@@ -0,0 +1,18 @@
+package config
+
+// When a notification is sent
+const (
+	NotifyAlways  = "always"  // After every run
+	NotifyFailure = "failure" // Only when the command failed
+	NotifySuccess = "success" // Only when the command succeeded
+)
+
+// Notify configures the notifications sent when a command finishes. It can be set
+// for a command or at the top level of yxa.yml for every command.
+type Notify struct {
+	On          string `yaml:"on,omitempty"`           // When to notify: always (default), failure or success
+	Desktop     bool   `yaml:"desktop,omitempty"`      // Show a desktop notification
+	Slack       string `yaml:"slack,omitempty"`        // Incoming webhook URL of a Slack channel
+	Webhook     string `yaml:"webhook,omitempty"`      // URL the result is posted to as JSON
+	MinDuration string `yaml:"min_duration,omitempty"` // Only notify when the command ran at least this long, e.g. 1m
+}
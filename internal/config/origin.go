@@ -0,0 +1,138 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeConflict is a command of a project config that shadows a command of the
+// global config with the same name but a different definition
+type MergeConflict struct {
+	Command  string // Name of the command
+	Origin   string // Config file of the command that is used
+	Shadowed string // Config file of the command that is shadowed
+}
+
+// originKey returns the key of a command or variable in the origins of a config
+func originKey(kind, name string) string {
+	return kind + "." + name
+}
+
+// recordOrigins sets the origin of every command and variable of a config loaded
+// from a single file to that file
+func (c *ProjectConfig) recordOrigins() {
+	c.origins = make(map[string]string, len(c.Commands)+len(c.Variables))
+	for name := range c.Commands {
+		c.origins[originKey("commands", name)] = c.configFile
+	}
+	for name := range c.Variables {
+		c.origins[originKey("variables", name)] = c.configFile
+	}
+}
+
+// mergeOrigins returns the origins of the merge of two configs and the files each
+// key of top shadows in base, nearest first
+func mergeOrigins(base, top *ProjectConfig) (map[string]string, map[string][]string) {
+	origins := make(map[string]string, len(base.origins)+len(top.origins))
+	shadowed := make(map[string][]string)
+	for key, origin := range base.origins {
+		origins[key] = origin
+		if files := base.shadowed[key]; len(files) > 0 {
+			shadowed[key] = files
+		}
+	}
+	for key, origin := range top.origins {
+		files := append([]string(nil), top.shadowed[key]...)
+		if previous, ok := base.origins[key]; ok && previous != origin {
+			files = append(files, previous)
+			files = append(files, base.shadowed[key]...)
+		}
+		origins[key] = origin
+		if len(files) > 0 {
+			shadowed[key] = files
+		}
+	}
+	return origins, shadowed
+}
+
+// commandConflicts returns the commands of project that shadow a command of global
+// with a different definition, sorted by name. Commands that only differ in their
+// documentation do not conflict.
+func commandConflicts(global, project *ProjectConfig) []MergeConflict {
+	var conflicts []MergeConflict
+	for name, cmd := range project.Commands {
+		globalCmd, ok := global.Commands[name]
+		if !ok || reflect.DeepEqual(withoutDocs(cmd), withoutDocs(globalCmd)) {
+			continue
+		}
+		conflicts = append(conflicts, MergeConflict{
+			Command:  name,
+			Origin:   project.origins[originKey("commands", name)],
+			Shadowed: global.origins[originKey("commands", name)],
+		})
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Command < conflicts[j].Command })
+	return conflicts
+}
+
+// withoutDocs returns a command without the fields that only document it
+func withoutDocs(cmd Command) Command {
+	cmd.Description = ""
+	cmd.Help = ""
+	cmd.Examples = nil
+	return cmd
+}
+
+// CommandOrigin returns the config file that defines a top-level command, empty if
+// it is unknown
+func (c *ProjectConfig) CommandOrigin(name string) string {
+	return c.origins[originKey("commands", name)]
+}
+
+// VariableOrigin returns the config file that defines a variable, empty if it is
+// unknown
+func (c *ProjectConfig) VariableOrigin(name string) string {
+	return c.origins[originKey("variables", name)]
+}
+
+// ShadowedCommand returns the config files whose definitions of a top-level command
+// are shadowed by the one used, nearest first
+func (c *ProjectConfig) ShadowedCommand(name string) []string {
+	return c.shadowed[originKey("commands", name)]
+}
+
+// ShadowedVariable returns the config files whose values of a variable are shadowed
+// by the one used, nearest first
+func (c *ProjectConfig) ShadowedVariable(name string) []string {
+	return c.shadowed[originKey("variables", name)]
+}
+
+// MergeConflicts returns the commands of the project config that shadow a global
+// command with a different definition
+func (c *ProjectConfig) MergeConflicts() []MergeConflict {
+	return c.conflicts
+}
+
+// CommandProfile returns the name of the applied profile if it changes a top-level
+// command or one of its subcommands, and an empty string otherwise
+func (c *ProjectConfig) CommandProfile(name string) string {
+	if c.profile == "" {
+		return ""
+	}
+	for cmdName := range c.Profiles[c.profile].Commands {
+		if strings.SplitN(cmdName, ":", 2)[0] == name {
+			return c.profile
+		}
+	}
+	return ""
+}
+
+// VariableProfile returns the name of the applied profile if it sets a variable,
+// and an empty string otherwise
+func (c *ProjectConfig) VariableProfile(name string) string {
+	if _, ok := c.Profiles[c.profile].Variables[name]; ok && c.profile != "" {
+		return c.profile
+	}
+	return ""
+}
@@ -1,14 +1,25 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/floppa/yxa-cli/internal/errors"
+)
+
 // Param represents a command parameter, which can be either a flag or a positional parameter
 type Param struct {
-	Name        string `yaml:"name"`
-	Type        string `yaml:"type"`
-	Default     string `yaml:"default,omitempty"`
-	Description string `yaml:"description"`
-	Required    bool   `yaml:"required,omitempty"`
-	Flag        bool   `yaml:"flag,omitempty"`     // Is this a flag parameter?
-	Position    int    `yaml:"position,omitempty"` // Position for positional params (-1 means not positional)
+	Name          string   `yaml:"name"`                     // Name of the variable and the flag, name|n for a shorthand
+	Type          string   `yaml:"type"`                     // string, int, float or bool
+	Default       string   `yaml:"default,omitempty"`        // Value if the parameter is not given
+	Description   string   `yaml:"description"`              // Help text of the flag
+	Required      bool     `yaml:"required,omitempty"`       // Whether the parameter must be given
+	Flag          bool     `yaml:"flag,omitempty"`           // Is this a flag parameter?
+	Position      int      `yaml:"position,omitempty"`       // Position for positional params (-1 means not positional)
+	Choices       []string `yaml:"choices,omitempty"`        // Allowed values, also offered by shell completion
+	Sensitive     bool     `yaml:"sensitive,omitempty"`      // Mask the value in the output of yxa
+	ConflictsWith []string `yaml:"conflicts_with,omitempty"` // Parameters that cannot be given together with this one
+	Requires      []string `yaml:"requires,omitempty"`       // Parameters that must be given together with this one
+	CompletionCmd string   `yaml:"completion_cmd,omitempty"` // Command whose output lines shell completion offers, if there are no choices
 }
 
 // ProcessParamDefinition extracts name and shorthand from the parameter definition
@@ -38,3 +49,88 @@ func indexOf(s, sep string) int {
 	}
 	return -1
 }
+
+// paramName returns the name of a parameter without its shorthand
+func paramName(p Param) string {
+	name, _ := ProcessParamDefinition(p.Name)
+	return name
+}
+
+// mergeParams merges the shared parameters of the global and the project config. A
+// project parameter replaces the global one of the same name in its place, the
+// others are appended.
+func mergeParams(global, project []Param) []Param {
+	if len(global) == 0 && len(project) == 0 {
+		return nil
+	}
+	merged := append([]Param(nil), global...)
+	for _, p := range project {
+		merged = replaceParam(merged, p)
+	}
+	return merged
+}
+
+// replaceParam returns params with the parameter of the same name as p replaced by
+// it, or with p appended if there is none
+func replaceParam(params []Param, p Param) []Param {
+	for i := range params {
+		if paramName(params[i]) == paramName(p) {
+			params[i] = p
+			return params
+		}
+	}
+	return append(params, p)
+}
+
+// withSharedParams returns the config with the shared parameters that commands name
+// in use_params added to their params, before their own. A parameter the command
+// declares itself replaces the shared one of the same name. The use_params of the
+// commands are cleared, so the config is only expanded once.
+func (c *ProjectConfig) withSharedParams() (*ProjectConfig, error) {
+	commands, err := expandSharedParams(c.Commands, c.Params, "")
+	if err != nil {
+		return nil, err
+	}
+	cfg := *c
+	cfg.Commands = commands
+	return &cfg, nil
+}
+
+// expandSharedParams returns a copy of commands and their subcommands with the
+// shared parameters of use_params expanded, prefix being the name of their parent
+func expandSharedParams(commands map[string]Command, shared []Param, prefix string) (map[string]Command, error) {
+	if commands == nil {
+		return nil, nil
+	}
+	byName := make(map[string]Param, len(shared))
+	for _, p := range shared {
+		byName[paramName(p)] = p
+	}
+
+	expanded := make(map[string]Command, len(commands))
+	for name, cmd := range commands {
+		fullName := prefix + name
+		if len(cmd.UseParams) > 0 {
+			params := make([]Param, 0, len(cmd.UseParams)+len(cmd.Params))
+			for _, use := range cmd.UseParams {
+				p, ok := byName[use]
+				if !ok {
+					return nil, errors.NewCommandConfigError(fullName, fmt.Sprintf("use_params names unknown parameter '%s'", use), nil)
+				}
+				params = append(params, p)
+			}
+			for _, p := range cmd.Params {
+				params = replaceParam(params, p)
+			}
+			cmd.Params = params
+			cmd.UseParams = nil
+		}
+		subs, err := expandSharedParams(cmd.Commands, shared, fullName+":")
+		if err != nil {
+			return nil, err
+		}
+		cmd.Commands = subs
+		expanded[name] = cmd
+	}
+	return expanded, nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// paramNames returns the names of params
+func paramNames(params []Param) []string {
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestLoadConfigFrom_SharedParams(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	globalConfig := `
+params:
+  - {name: region, type: string, flag: true, default: eu-north-1}
+  - {name: env, type: string, flag: true, default: dev}
+commands:
+  whoami:
+    run: aws sts get-caller-identity --region $region
+    use_params: [region]
+`
+	projectConfig := `
+root: true
+params:
+  - {name: env|e, type: string, flag: true, default: dev, choices: [dev, prod]}
+  - {name: verbose, type: bool, flag: true}
+commands:
+  deploy:
+    run: ./deploy.sh $env $region $tag
+    use_params: [env, region]
+    params:
+      - {name: tag, type: string, flag: true}
+  release:
+    use_params: [env]
+    params:
+      - {name: env, type: string, flag: true, default: prod}
+    commands:
+      notes:
+        run: ./notes.sh $verbose
+        use_params: [verbose]
+`
+	globalPath := filepath.Join(dir, ".yxa.yml")
+	projectPath := filepath.Join(dir, "project", "yxa.yml")
+	if err := os.WriteFile(globalPath, []byte(globalConfig), 0644); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(projectPath), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.WriteFile(projectPath, []byte(projectConfig), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	cfg, err := LoadConfigFrom(projectPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom failed: %v", err)
+	}
+
+	if got, want := paramNames(cfg.Params), []string{"region", "env|e", "verbose"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shared params: got %v, want %v", got, want)
+	}
+	deploy := cfg.Commands["deploy"]
+	if got, want := paramNames(deploy.Params), []string{"env|e", "region", "tag"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deploy params: got %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(deploy.Params[0].Choices, []string{"dev", "prod"}) {
+		t.Errorf("deploy env: got choices %v, want those of the project", deploy.Params[0].Choices)
+	}
+	if deploy.UseParams != nil {
+		t.Errorf("deploy use_params: got %v, want them expanded", deploy.UseParams)
+	}
+	if got := cfg.Commands["whoami"].Params; len(got) != 1 || got[0].Default != "eu-north-1" {
+		t.Errorf("whoami params: got %+v, want the global region", got)
+	}
+	release := cfg.Commands["release"]
+	if len(release.Params) != 1 || release.Params[0].Default != "prod" {
+		t.Errorf("release params: got %+v, want its own env to replace the shared one", release.Params)
+	}
+	if got := paramNames(release.Commands["notes"].Params); !reflect.DeepEqual(got, []string{"verbose"}) {
+		t.Errorf("release:notes params: got %v, want [verbose]", got)
+	}
+}
+
+func TestLoadConfigFrom_UnknownSharedParam(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	path := filepath.Join(dir, "yxa.yml")
+	config := `
+root: true
+params:
+  - {name: env, type: string, flag: true}
+commands:
+  deploy:
+    commands:
+      app:
+        run: ./deploy.sh $env
+        use_params: [env, region]
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfigFrom(path)
+	if err == nil {
+		t.Fatal("LoadConfigFrom succeeded, want an error for the unknown parameter")
+	}
+	if !strings.Contains(err.Error(), "deploy:app") || !strings.Contains(err.Error(), "use_params names unknown parameter 'region'") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/floppa/yxa-cli/internal/variables"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnvVariable is the environment variable that selects a profile when
+// --profile is not set
+const ProfileEnvVariable = variables.BuiltinProfile
+
+// Profile is a named set of overrides, e.g. for dev or ci, selected with --profile
+// or YXA_PROFILE
+type Profile struct {
+	Variables  map[string]string          `yaml:"variables,omitempty"`  // Variables that replace or add to the config variables
+	Env        map[string]string          `yaml:"env,omitempty"`        // Values that replace or add to those of the .env file
+	WorkingDir string                     `yaml:"workingdir,omitempty"` // Replaces the directory-level workingdir
+	Commands   map[string]CommandOverride `yaml:"commands,omitempty"`   // Fields to override by command name, parent:sub for subcommands
+}
+
+// CommandOverride holds the fields a profile sets for a command. Only the fields
+// present in the profile replace those of the command, so they can also be set
+// to false or empty.
+type CommandOverride struct {
+	Command Command  // Values of the fields
+	Fields  []string // YAML names of the fields that are set
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (o *CommandOverride) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: profile command overrides must be a mapping", node.Line)
+	}
+
+	fields := commandFields()
+	o.Fields = nil
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		switch _, ok := fields[key.Value]; {
+		case key.Value == "commands" || key.Value == "params" || key.Value == "use_params":
+			return fmt.Errorf("line %d: profiles cannot override '%s' of a command", key.Line, key.Value)
+		case !ok:
+			return fmt.Errorf("line %d: unknown command field '%s'", key.Line, key.Value)
+		}
+		o.Fields = append(o.Fields, key.Value)
+	}
+	return node.Decode(&o.Command)
+}
+
+// apply returns cmd with the fields of the override replaced
+func (o CommandOverride) apply(cmd Command) Command {
+	fields := commandFields()
+	src := reflect.ValueOf(o.Command)
+	dst := reflect.ValueOf(&cmd).Elem()
+	for _, name := range o.Fields {
+		i := fields[name]
+		dst.Field(i).Set(src.Field(i))
+	}
+	return cmd
+}
+
+// merge returns an override that sets the fields of both overrides, those of next
+// taking precedence
+func (o CommandOverride) merge(next CommandOverride) CommandOverride {
+	merged := CommandOverride{Command: next.apply(o.Command)}
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), o.Fields...), next.Fields...) {
+		if !seen[name] {
+			seen[name] = true
+			merged.Fields = append(merged.Fields, name)
+		}
+	}
+	return merged
+}
+
+// commandFields returns the index of every field of Command by its YAML name
+func commandFields() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Command{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// mergeProfiles merges the profiles of the global and the project config. Profiles
+// with the same name are combined, the project taking precedence.
+func mergeProfiles(global, project map[string]Profile) map[string]Profile {
+	if len(global) == 0 && len(project) == 0 {
+		return nil
+	}
+	merged := make(map[string]Profile)
+	for name, p := range global {
+		merged[name] = p
+	}
+	for name, p := range project {
+		base, ok := merged[name]
+		if !ok {
+			merged[name] = p
+			continue
+		}
+		combined := Profile{
+			Variables:  overlay(base.Variables, p.Variables),
+			Env:        overlay(base.Env, p.Env),
+			WorkingDir: base.WorkingDir,
+			Commands:   make(map[string]CommandOverride),
+		}
+		if p.WorkingDir != "" {
+			combined.WorkingDir = p.WorkingDir
+		}
+		for cmdName, o := range base.Commands {
+			combined.Commands[cmdName] = o
+		}
+		for cmdName, o := range p.Commands {
+			if prev, ok := combined.Commands[cmdName]; ok {
+				o = prev.merge(o)
+			}
+			combined.Commands[cmdName] = o
+		}
+		merged[name] = combined
+	}
+	return merged
+}
+
+// overlay returns a copy of base with the values of top added or replaced
+func overlay(base, top map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(top))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range top {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ProfileNames returns the names of the profiles of the config, sorted
+func (c *ProjectConfig) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the name of the applied profile, or an empty string if none is
+func (c *ProjectConfig) Profile() string {
+	return c.profile
+}
+
+// WithProfile returns a copy of the config with the profile of the given name
+// applied. The profile takes precedence over both the global and the project
+// config; the config itself is not modified.
+func (c *ProjectConfig) WithProfile(name string) (*ProjectConfig, error) {
+	if name == "" || name == c.profile {
+		return c, nil
+	}
+	if c.profile != "" {
+		return nil, errors.NewConfigError("profile", name, fmt.Sprintf("profile '%s' is already applied", c.profile), nil)
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		available := "none"
+		if names := c.ProfileNames(); len(names) > 0 {
+			available = strings.Join(names, ", ")
+		}
+		return nil, errors.NewConfigError("profile", name, "not defined, available profiles: "+available, nil)
+	}
+
+	cfg := *c
+	cfg.profile = name
+	cfg.Variables = overlay(c.Variables, p.Variables)
+	cfg.envVars = overlay(c.envVars, p.Env)
+	if p.WorkingDir != "" {
+		cfg.WorkingDir = p.WorkingDir
+	}
+
+	cfg.Commands = make(map[string]Command, len(c.Commands))
+	for cmdName, cmd := range c.Commands {
+		cfg.Commands[cmdName] = cmd
+	}
+	cmdNames := make([]string, 0, len(p.Commands))
+	for cmdName := range p.Commands {
+		cmdNames = append(cmdNames, cmdName)
+	}
+	sort.Strings(cmdNames)
+	for _, cmdName := range cmdNames {
+		if err := cfg.overrideCommand(cmdName, p.Commands[cmdName]); err != nil {
+			return nil, errors.NewConfigError("profile", name, err.Error(), nil)
+		}
+	}
+	return &cfg, nil
+}
+
+// overrideCommand applies an override to the command of the given name, which may
+// be a subcommand as parent:sub or parent:sub:subsub. The command maps are copied
+// before they change.
+func (c *ProjectConfig) overrideCommand(cmdName string, o CommandOverride) error {
+	commands, err := overrideIn(c.Commands, strings.Split(cmdName, ":"), o)
+	if err != nil {
+		return fmt.Errorf("command '%s' is not defined", cmdName)
+	}
+	c.Commands = commands
+	return nil
+}
+
+// overrideIn returns a copy of commands with the override applied to the command
+// at path, a command name followed by the names of its subcommands
+func overrideIn(commands map[string]Command, path []string, o CommandOverride) (map[string]Command, error) {
+	cmd, ok := commands[path[0]]
+	if !ok {
+		return nil, fmt.Errorf("command '%s' is not defined", path[0])
+	}
+	if len(path) == 1 {
+		cmd = o.apply(cmd)
+	} else {
+		subs, err := overrideIn(cmd.Commands, path[1:], o)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Commands = subs
+	}
+
+	copied := make(map[string]Command, len(commands))
+	for k, v := range commands {
+		copied[k] = v
+	}
+	copied[path[0]] = cmd
+	return copied, nil
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const profileYAML = `
+name: app
+variables:
+  LEVEL: debug
+  REGION: eu
+commands:
+  test:
+    run: go test ./...
+    parallel: true
+    timeout: 10m
+  tools:
+    commands:
+      gen:
+        run: go generate ./...
+profiles:
+  ci:
+    variables:
+      LEVEL: info
+    env:
+      TOKEN: ci-token
+    workingdir: /build
+    commands:
+      test:
+        run: go test -race ./...
+        parallel: false
+      tools:gen:
+        run: go generate -x ./...
+  dev: {}
+`
+
+func loadProfileConfig(t *testing.T, data string) *ProjectConfig {
+	t.Helper()
+	var cfg ProjectConfig
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return &cfg
+}
+
+func TestWithProfile(t *testing.T) {
+	base := loadProfileConfig(t, profileYAML)
+
+	cfg, err := base.WithProfile("ci")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+
+	if cfg.Profile() != "ci" || cfg.BuiltinVars()["YXA_PROFILE"] != "ci" {
+		t.Errorf("profile not recorded: %q", cfg.Profile())
+	}
+	assertVariable(t, cfg.Variables["LEVEL"], "info", "LEVEL")
+	assertVariable(t, cfg.Variables["REGION"], "eu", "REGION")
+	assertVariable(t, cfg.ReplaceVariables("$TOKEN"), "ci-token", "TOKEN")
+	if cfg.WorkingDir != "/build" {
+		t.Errorf("WorkingDir: got %q, want /build", cfg.WorkingDir)
+	}
+
+	test := cfg.Commands["test"]
+	assertCommand(t, test, "go test -race ./...", "test")
+	if test.Parallel {
+		t.Error("parallel should be overridden to false")
+	}
+	if test.Timeout != "10m" {
+		t.Errorf("fields not set by the profile should be kept, got timeout %q", test.Timeout)
+	}
+	assertCommand(t, cfg.Commands["tools"].Commands["gen"], "go generate -x ./...", "tools:gen")
+
+	// The base config is not modified
+	assertVariable(t, base.Variables["LEVEL"], "debug", "LEVEL")
+	assertCommand(t, base.Commands["test"], "go test ./...", "test")
+	assertCommand(t, base.Commands["tools"].Commands["gen"], "go generate ./...", "tools:gen")
+	if base.Profile() != "" {
+		t.Errorf("base profile: got %q", base.Profile())
+	}
+
+	// Applying the same profile again is a no-op, another one is an error
+	if again, err := cfg.WithProfile("ci"); err != nil || again != cfg {
+		t.Errorf("re-applying ci: %v", err)
+	}
+	if _, err := cfg.WithProfile("dev"); err == nil || !strings.Contains(err.Error(), "profile 'ci' is already applied") {
+		t.Errorf("expected already applied error, got %v", err)
+	}
+}
+
+func TestWithProfile_NestedSubcommand(t *testing.T) {
+	base := loadProfileConfig(t, `
+commands:
+  platform:
+    commands:
+      services:
+        commands:
+          api:
+            run: ./deploy api
+            timeout: 1m
+profiles:
+  ci:
+    commands:
+      platform:services:api:
+        run: ./deploy --ci api
+`)
+	cfg, err := base.WithProfile("ci")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	api := cfg.Commands["platform"].Commands["services"].Commands["api"]
+	assertCommand(t, api, "./deploy --ci api", "platform:services:api")
+	if api.Timeout != "1m" {
+		t.Errorf("fields not set by the profile should be kept, got timeout %q", api.Timeout)
+	}
+	assertCommand(t, base.Commands["platform"].Commands["services"].Commands["api"], "./deploy api", "platform:services:api")
+
+	missing := loadProfileConfig(t, "commands:\n  platform:\n    commands:\n      services: {}\nprofiles:\n  ci:\n    commands:\n      platform:services:api:\n        run: true\n")
+	if _, err := missing.WithProfile("ci"); err == nil || !strings.Contains(err.Error(), "command 'platform:services:api' is not defined") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithProfile_Errors(t *testing.T) {
+	base := loadProfileConfig(t, profileYAML)
+	if _, err := base.WithProfile("prod"); err == nil || err.Error() != "config error in profile 'prod': not defined, available profiles: ci, dev" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	unknown := loadProfileConfig(t, "commands:\n  test:\n    run: go test\nprofiles:\n  ci:\n    commands:\n      lint:\n        run: golangci-lint run\n")
+	if _, err := unknown.WithProfile("ci"); err == nil || err.Error() != "config error in profile 'ci': command 'lint' is not defined" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := map[string]string{
+		"params":        "profiles:\n  ci:\n    commands:\n      test:\n        params: []\n",
+		"unknown field": "profiles:\n  ci:\n    commands:\n      test:\n        script: go test\n",
+		"not a mapping": "profiles:\n  ci:\n    commands:\n      test: go test\n",
+	}
+	wantErrs := map[string]string{
+		"params":        "profiles cannot override 'params' of a command",
+		"unknown field": "unknown command field 'script'",
+		"not a mapping": "profile command overrides must be a mapping",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cfg ProjectConfig
+			err := yaml.Unmarshal([]byte(data), &cfg)
+			if err == nil || !strings.Contains(err.Error(), wantErrs[name]) {
+				t.Errorf("expected error containing %q, got %v", wantErrs[name], err)
+			}
+		})
+	}
+}
+
+func TestMergeConfigs_Profiles(t *testing.T) {
+	global := loadProfileConfig(t, `
+variables:
+  LEVEL: warn
+commands:
+  test:
+    run: go test ./...
+profiles:
+  ci:
+    variables:
+      LEVEL: global-ci
+      COLOR: "false"
+    commands:
+      test:
+        timeout: 30m
+        run: go test -short ./...
+  local:
+    variables:
+      LEVEL: local
+`)
+	project := loadProfileConfig(t, `
+variables:
+  LEVEL: project
+profiles:
+  ci:
+    variables:
+      LEVEL: project-ci
+    commands:
+      test:
+        run: go test -race ./...
+`)
+
+	merged := MergeConfigs(global, project)
+	if names := merged.ProfileNames(); strings.Join(names, ",") != "ci,local" {
+		t.Fatalf("profiles: got %v", names)
+	}
+
+	// Precedence: global < project < global profile < project profile
+	cfg, err := merged.WithProfile("ci")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	assertVariable(t, cfg.Variables["LEVEL"], "project-ci", "LEVEL")
+	assertVariable(t, cfg.Variables["COLOR"], "false", "COLOR")
+	assertCommand(t, cfg.Commands["test"], "go test -race ./...", "test")
+	if cfg.Commands["test"].Timeout != "30m" {
+		t.Errorf("timeout of the global profile should be kept, got %q", cfg.Commands["test"].Timeout)
+	}
+
+	cfg, err = merged.WithProfile("local")
+	if err != nil {
+		t.Fatalf("WithProfile failed: %v", err)
+	}
+	assertVariable(t, cfg.Variables["LEVEL"], "local", "LEVEL")
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output streams that can be registered into variables
+const (
+	RegisterFromStdout = "stdout"
+)
+
+// Register extracts a value from the output of a command into a variable that
+// later commands and the post-hook can reference
+type Register struct {
+	Var      string `yaml:"var"`                // Name of the variable to set
+	From     string `yaml:"from,omitempty"`     // Output to parse, defaults to stdout
+	JSONPath string `yaml:"jsonPath,omitempty"` // Path of the value in JSON or YAML output, the whole output if not set
+}
+
+// UnmarshalYAML decodes a registration, accepting json_path as an alias of
+// jsonPath. Unknown keys are rejected: a misspelled path would otherwise silently
+// register the whole output.
+func (r *Register) UnmarshalYAML(value *yaml.Node) error {
+	var fields struct {
+		Var       string `yaml:"var"`
+		From      string `yaml:"from"`
+		JSONPath  string `yaml:"jsonPath"`
+		SnakePath string `yaml:"json_path"`
+	}
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key := value.Content[i]
+			switch {
+			case key.Tag == "!!merge", strings.HasPrefix(key.Value, "x-"):
+			case key.Value == "var", key.Value == "from", key.Value == "jsonPath", key.Value == "json_path":
+			default:
+				return fmt.Errorf("line %d: unknown register field '%s', expected 'var', 'from' or 'jsonPath'", key.Line, key.Value)
+			}
+		}
+	}
+	if err := value.Decode(&fields); err != nil {
+		return err
+	}
+	if fields.JSONPath != "" && fields.SnakePath != "" {
+		return fmt.Errorf("line %d: register sets both 'jsonPath' and its alias 'json_path'", value.Line)
+	}
+
+	*r = Register{Var: fields.Var, From: fields.From, JSONPath: fields.JSONPath}
+	if r.JSONPath == "" {
+		r.JSONPath = fields.SnakePath
+	}
+	return nil
+}
+
+// RegisterList is a list of registrations. In yxa.yml it can be written as a
+// single registration or as a list.
+type RegisterList []Register
+
+// UnmarshalYAML accepts both a single registration and a list of registrations
+func (l *RegisterList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		var single Register
+		if err := value.Decode(&single); err != nil {
+			return err
+		}
+		*l = RegisterList{single}
+		return nil
+	}
+
+	var list []Register
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
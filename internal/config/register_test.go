@@ -0,0 +1,67 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRegisterList_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    RegisterList
+		wantErr bool
+	}{
+		{
+			name: "single registration",
+			yaml: `register: {var: POD_NAME, from: stdout, jsonPath: "$.items[0].metadata.name"}`,
+			want: RegisterList{{Var: "POD_NAME", From: "stdout", JSONPath: "$.items[0].metadata.name"}},
+		},
+		{
+			name: "list of registrations",
+			yaml: "register:\n  - var: A\n  - var: B\n    jsonPath: $.b\n",
+			want: RegisterList{{Var: "A"}, {Var: "B", JSONPath: "$.b"}},
+		},
+		{
+			name: "json_path alias",
+			yaml: "register: {var: A, json_path: $.a}",
+			want: RegisterList{{Var: "A", JSONPath: "$.a"}},
+		},
+		{
+			name:    "jsonPath and its alias",
+			yaml:    "register: {var: A, jsonPath: $.a, json_path: $.b}",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			yaml:    "register: {var: A, jsonpath: $.a}",
+			wantErr: true,
+		},
+		{
+			name:    "scalar",
+			yaml:    "register: POD_NAME",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cmd Command
+			err := yaml.Unmarshal([]byte(tt.yaml), &cmd)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cmd.Register, tt.want) {
+				t.Errorf("Register = %+v, want %+v", cmd.Register, tt.want)
+			}
+		})
+	}
+}
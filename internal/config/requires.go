@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version operators of a Requirement
+const (
+	VersionAtLeast = ">="
+	VersionAbove   = ">"
+	VersionAtMost  = "<="
+	VersionBelow   = "<"
+	VersionExactly = "="
+)
+
+// requirementPattern matches a tool name with an optional version constraint,
+// e.g. docker, go>=1.21 or node = 20
+var requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][\w.+-]*?)\s*(?:(>=|<=|==|=|>|<)\s*(\d+(?:\.\d+)*))?$`)
+
+// Requirement is a tool a command needs on PATH, with an optional version
+// constraint. In yxa.yml it is written as a string such as docker>=24.
+type Requirement struct {
+	Tool    string // Name of the executable
+	Op      string // Version operator, empty if any version is accepted
+	Version string // Version the operator compares with, e.g. 1.21
+}
+
+// ParseRequirement parses a requirement such as node, go>=1.21 or make<4
+func ParseRequirement(s string) (Requirement, error) {
+	m := requirementPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Requirement{}, fmt.Errorf("invalid requirement '%s', expected a tool name with an optional version such as go>=1.21", s)
+	}
+	op := m[2]
+	if op == "==" {
+		op = VersionExactly
+	}
+	return Requirement{Tool: m[1], Op: op, Version: m[3]}, nil
+}
+
+// String returns the requirement as written in yxa.yml
+func (r Requirement) String() string {
+	return r.Tool + r.Op + r.Version
+}
+
+// UnmarshalYAML parses the requirement from a string
+func (r *Requirement) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: requirements must be strings such as go>=1.21", value.Line)
+	}
+	req, err := ParseRequirement(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*r = req
+	return nil
+}
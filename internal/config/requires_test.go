@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseRequirement(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Requirement
+	}{
+		{input: "node", want: Requirement{Tool: "node"}},
+		{input: "go>=1.21", want: Requirement{Tool: "go", Op: VersionAtLeast, Version: "1.21"}},
+		{input: "docker >= 24", want: Requirement{Tool: "docker", Op: VersionAtLeast, Version: "24"}},
+		{input: "make<4", want: Requirement{Tool: "make", Op: VersionBelow, Version: "4"}},
+		{input: "kubectl==1.28.2", want: Requirement{Tool: "kubectl", Op: VersionExactly, Version: "1.28.2"}},
+		{input: "clang-format", want: Requirement{Tool: "clang-format"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRequirement(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRequirement failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRequirement(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "go>=", "go>=latest", ">=1.2", "go ~1.2"} {
+		if _, err := ParseRequirement(input); err == nil {
+			t.Errorf("ParseRequirement(%q) should fail", input)
+		}
+	}
+}
+
+func TestCommand_Requires(t *testing.T) {
+	var cfg ProjectConfig
+	err := yaml.Unmarshal([]byte("commands:\n  build:\n    run: go build\n    requires: [docker>=24, node, go>=1.21]\n"), &cfg)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	var got []string
+	for _, req := range cfg.Commands["build"].Requires {
+		got = append(got, req.String())
+	}
+	if strings.Join(got, ",") != "docker>=24,node,go>=1.21" {
+		t.Errorf("requires: got %v", got)
+	}
+
+	err = yaml.Unmarshal([]byte("commands:\n  build:\n    requires: [go~1]\n"), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "line 3: invalid requirement 'go~1'") {
+		t.Errorf("expected invalid requirement error, got %v", err)
+	}
+}
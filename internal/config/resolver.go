@@ -52,6 +52,13 @@ func ResolveConfigPath(flagPath string) (string, error) {
 		} else {
 			fmt.Printf("Home config file not found: %v\n", err)
 		}
+
+		encPath := filepath.Join(home, EncryptedGlobalConfigName)
+		fmt.Printf("Checking for encrypted home config at: %s\n", encPath)
+		if _, err := os.Stat(encPath); err == nil {
+			fmt.Printf("Found encrypted home config file at: %s\n", encPath)
+			return encPath, nil
+		}
 	}
 
 	fmt.Println("No yxa config file found")
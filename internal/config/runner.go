@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Runner selects the executor backend a command runs with. In yxa.yml it is
+// written as a name, or as a mapping with the name and the options of the runner:
+//
+//	runner: local
+//	runner: {name: docker, image: alpine:3}
+type Runner struct {
+	Name    string
+	options yaml.Node
+}
+
+// NewRunner creates a runner with options encoded from v
+func NewRunner(name string, v any) (*Runner, error) {
+	r := &Runner{Name: name}
+	if v != nil {
+		if err := r.options.Encode(v); err != nil {
+			return nil, fmt.Errorf("failed to encode options of runner '%s': %w", name, err)
+		}
+	}
+	return r, nil
+}
+
+// UnmarshalYAML accepts both a runner name and a mapping with a name and options
+func (r *Runner) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		r.Name = value.Value
+		return nil
+	}
+
+	var named struct {
+		Name string `yaml:"name"`
+	}
+	if err := value.Decode(&named); err != nil {
+		return err
+	}
+	if named.Name == "" {
+		return fmt.Errorf("line %d: runner requires a name", value.Line)
+	}
+	r.Name = named.Name
+	r.options = *value
+	return nil
+}
+
+// Decode fills v from the options of the runner. The name of the runner is part
+// of the options, so v can ignore it or decode it as well.
+func (r *Runner) Decode(v any) error {
+	if r.options.Kind == 0 {
+		return nil
+	}
+	return r.options.Decode(v)
+}
+
+// Expand returns a copy of the runner with mapping applied to every option value,
+// for example to resolve variables. Option keys are left as they are.
+func (r *Runner) Expand(mapping func(string) string) *Runner {
+	expanded := &Runner{Name: r.Name}
+	if r.options.Kind != 0 {
+		expanded.options = expandNode(r.options, mapping)
+	}
+	return expanded
+}
+
+// expandNode returns a deep copy of node with mapping applied to its scalar values
+func expandNode(node yaml.Node, mapping func(string) string) yaml.Node {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		node.Value = mapping(node.Value)
+	case yaml.MappingNode:
+		content := make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied := *child
+			if i%2 == 1 {
+				copied = expandNode(*child, mapping)
+			}
+			content[i] = &copied
+		}
+		node.Content = content
+	case yaml.SequenceNode, yaml.DocumentNode:
+		content := make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied := expandNode(*child, mapping)
+			content[i] = &copied
+		}
+		node.Content = content
+	}
+	return node
+}
+
+// RunnerConfig returns the runner a command runs with: its runner, a docker
+// runner for its container block, or nil to run on the host
+func (c Command) RunnerConfig() *Runner {
+	if c.Runner != nil {
+		return c.Runner
+	}
+	if c.Container != nil {
+		// A container block is the options of the docker runner
+		runner, err := NewRunner("docker", c.Container)
+		if err == nil {
+			return runner
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+type sshOptions struct {
+	Host string   `yaml:"host"`
+	Args []string `yaml:"args"`
+}
+
+func TestRunner_UnmarshalYAML(t *testing.T) {
+	var cmd Command
+	if err := yaml.Unmarshal([]byte("runner: local"), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Runner == nil || cmd.Runner.Name != "local" {
+		t.Fatalf("Runner = %+v, want local", cmd.Runner)
+	}
+	var none sshOptions
+	if err := cmd.Runner.Decode(&none); err != nil || none.Host != "" {
+		t.Errorf("Decode() of a runner without options = %+v, %v", none, err)
+	}
+
+	data := "runner:\n  name: ssh\n  host: $HOST\n  args: [-p, $PORT]\n"
+	if err := yaml.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expanded := cmd.Runner.Expand(func(s string) string {
+		return strings.NewReplacer("$HOST", "build.example.com", "$PORT", "2222").Replace(s)
+	})
+
+	var got sshOptions
+	if err := expanded.Decode(&got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := sshOptions{Host: "build.example.com", Args: []string{"-p", "2222"}}
+	if expanded.Name != "ssh" || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %s %+v, want ssh %+v", expanded.Name, got, want)
+	}
+
+	// Expand must not change the original options
+	if err := cmd.Runner.Decode(&got); err != nil || got.Host != "$HOST" {
+		t.Errorf("original options changed to %+v", got)
+	}
+
+	if err := yaml.Unmarshal([]byte("runner: {host: example.com}"), &cmd); err == nil {
+		t.Error("Expected an error for a runner without a name, got nil")
+	}
+}
+
+func TestCommand_RunnerConfig(t *testing.T) {
+	if runner := (Command{Run: "make"}).RunnerConfig(); runner != nil {
+		t.Errorf("RunnerConfig() = %+v, want nil", runner)
+	}
+
+	cmd := Command{Container: &Container{Image: "alpine:3", Workdir: "/src"}}
+	runner := cmd.RunnerConfig()
+	if runner == nil || runner.Name != "docker" {
+		t.Fatalf("RunnerConfig() = %+v, want docker", runner)
+	}
+	var got Container
+	if err := runner.Decode(&got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, *cmd.Container) {
+		t.Errorf("options = %+v, want %+v", got, *cmd.Container)
+	}
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate go run gen_schema_docs.go
+
+// SchemaDraft is the JSON Schema version of Schema
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// fieldEnums are the values of the fields of the config types that only take
+// some values, by type and YAML name
+var fieldEnums = map[string][]string{
+	"ArchiveStep.format":   {"zip", "tar.gz"},
+	"Command.depends_mode": {DependsModeFailFast, DependsModeAll},
+	"Command.output":       {OutputStream, OutputCaptured},
+	"Command.stall_action": {StallActionKill, StallActionWarn},
+	"Container.pull":       {"missing", "always", "never"},
+	"LogFile.mode":         {LogModeTruncate, LogModeAppend},
+	"Notify.on":            {NotifyAlways, NotifyFailure, NotifySuccess},
+	"Param.type":           {"string", "int", "float", "bool"},
+}
+
+// fieldAliases are the other YAML names the fields of the config types accept, by
+// type and YAML name
+var fieldAliases = map[string]string{
+	"Register.jsonPath": "json_path",
+}
+
+// extensionPattern matches the keys of extension fields, which are allowed in every
+// mapping of yxa.yml, e.g. to hold YAML anchors as x-defaults
+const extensionPattern = "^x-"
+
+// scalarSchema accepts the values YAML decodes into a string: strings, numbers
+// and booleans
+var scalarSchema = map[string]any{"type": []string{"string", "number", "boolean"}}
+
+// Schema returns the JSON Schema of yxa.yml. It is derived from the config types,
+// with the descriptions of schema_docs.go, so it follows them as they change;
+// fields that accept several forms in YAML, such as a log file given as a path or
+// a mapping, accept all of them.
+func Schema() map[string]any {
+	s := &schemaBuilder{definitions: make(map[string]any)}
+	root := s.structSchema(reflect.TypeOf(ProjectConfig{}))
+	root["$schema"] = SchemaDraft
+	root["title"] = "yxa.yml"
+	root["description"] = "Configuration of the commands of a project for yxa"
+	root["definitions"] = s.definitions
+	return root
+}
+
+// schemaBuilder builds a schema, collecting the definitions of the struct types it
+// refers to
+type schemaBuilder struct {
+	definitions map[string]any
+}
+
+// typeSchema returns the schema of a value of type t
+func (s *schemaBuilder) typeSchema(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeOf(Task{}), reflect.TypeOf(Dependency{}):
+		return oneOf(map[string]any{"type": "string"}, s.ref(t))
+	case reflect.TypeOf(RegisterList{}):
+		register := s.ref(reflect.TypeOf(Register{}))
+		return oneOf(register, map[string]any{"type": "array", "items": register})
+	case reflect.TypeOf(LogFile{}):
+		return oneOf(map[string]any{"type": "string"}, s.ref(t))
+	case reflect.TypeOf(Runner{}):
+		return oneOf(map[string]any{"type": "string"}, map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}},
+			"required":   []string{"name"},
+		})
+	case reflect.TypeOf(Matrix{}):
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": oneOf(map[string]any{"type": "array", "items": scalarSchema}, scalarSchema),
+		}
+	case reflect.TypeOf(Requirement{}):
+		return map[string]any{"type": "string"}
+	case reflect.TypeOf(CommandOverride{}):
+		return s.commandOverrideSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": s.typeSchema(t.Elem())}
+	case reflect.Map:
+		values := scalarSchema
+		if t.Elem().Kind() != reflect.String {
+			values = s.typeSchema(t.Elem())
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}
+	case reflect.Struct:
+		return s.ref(t)
+	}
+	return map[string]any{}
+}
+
+// ref returns a reference to the definition of a struct type, adding it first
+func (s *schemaBuilder) ref(t reflect.Type) map[string]any {
+	if _, ok := s.definitions[t.Name()]; !ok {
+		// Added before its fields, since a command contains commands
+		s.definitions[t.Name()] = nil
+		s.definitions[t.Name()] = s.structSchema(t)
+	}
+	return map[string]any{"$ref": "#/definitions/" + t.Name()}
+}
+
+// structSchema returns the schema of the YAML fields of a struct type. Variables
+// can be given as values or as mappings that mark them as sensitive.
+func (s *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		var property map[string]any
+		if name == "variables" {
+			property = s.variablesSchema()
+		} else {
+			property = s.typeSchema(field.Type)
+		}
+		key := t.Name() + "." + name
+		if doc := fieldDocs[key]; doc != "" {
+			property["description"] = doc
+		}
+		if enum, ok := fieldEnums[key]; ok {
+			property["enum"] = enum
+		}
+		properties[name] = property
+		if alias, ok := fieldAliases[key]; ok {
+			aliasProperty := s.typeSchema(field.Type)
+			aliasProperty["description"] = "Alias of " + name
+			properties[alias] = aliasProperty
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"patternProperties":    map[string]any{extensionPattern: map[string]any{}},
+		"additionalProperties": false,
+	}
+}
+
+// variablesSchema returns the schema of a variables mapping
+func (s *schemaBuilder) variablesSchema() map[string]any {
+	if _, ok := s.definitions["Variables"]; !ok {
+		s.definitions["Variables"] = map[string]any{
+			"type": "object",
+			"additionalProperties": oneOf(scalarSchema, map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"value":     scalarSchema,
+					"sensitive": map[string]any{"type": "boolean", "description": "Mask the value in the output of yxa"},
+				},
+				"additionalProperties": false,
+			}),
+		}
+	}
+	return map[string]any{"$ref": "#/definitions/Variables"}
+}
+
+// commandOverrideSchema returns the schema of the command overrides of a profile,
+// the fields of a command except its subcommands and parameters
+func (s *schemaBuilder) commandOverrideSchema() map[string]any {
+	if _, ok := s.definitions["CommandOverride"]; !ok {
+		override := s.structSchema(reflect.TypeOf(Command{}))
+		properties := override["properties"].(map[string]any)
+		delete(properties, "commands")
+		delete(properties, "params")
+		delete(properties, "use_params")
+		s.definitions["CommandOverride"] = override
+	}
+	return map[string]any{"$ref": "#/definitions/CommandOverride"}
+}
+
+// oneOf returns a schema that accepts exactly one of the given schemas
+func oneOf(schemas ...map[string]any) map[string]any {
+	return map[string]any{"oneOf": schemas}
+}
+
+// UnknownField is a key of yxa.yml that is not part of the config format. yxa
+// ignores such keys, which usually are typos of a field.
+type UnknownField struct {
+	Path   []string // Keys of the mappings the key is in, e.g. commands, build
+	Key    string
+	Line   int
+	Column int
+}
+
+// UnknownFields returns the keys of a parsed yxa.yml that Schema does not allow
+func UnknownFields(doc *yaml.Node) []UnknownField {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	schema := Schema()
+	definitions := schema["definitions"].(map[string]any)
+
+	var unknown []UnknownField
+	var walk func(path []string, node *yaml.Node, s map[string]any)
+	walk = func(path []string, node *yaml.Node, s map[string]any) {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if ref, ok := s["$ref"].(string); ok {
+			s = definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any)
+		}
+		if branches, ok := s["oneOf"].([]map[string]any); ok {
+			for _, branch := range branches {
+				if schemaAccepts(branch, definitions, node.Kind) {
+					walk(path, node, branch)
+					return
+				}
+			}
+			return
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			properties, _ := s["properties"].(map[string]any)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				keyPath := append(append([]string(nil), path...), key.Value)
+				if key.Tag == "!!merge" {
+					// The keys merged in with <<: *anchor belong to this mapping
+					walk(path, value, s)
+				} else if property, ok := properties[key.Value]; ok {
+					walk(keyPath, value, property.(map[string]any))
+				} else if additional, ok := s["additionalProperties"].(map[string]any); ok {
+					walk(keyPath, value, additional)
+				} else if s["additionalProperties"] == false && !strings.HasPrefix(key.Value, "x-") {
+					unknown = append(unknown, UnknownField{Path: path, Key: key.Value, Line: key.Line, Column: key.Column})
+				}
+			}
+		case yaml.SequenceNode:
+			if items, ok := s["items"].(map[string]any); ok {
+				for _, item := range node.Content {
+					walk(path, item, items)
+				}
+			}
+		}
+	}
+	walk(nil, doc, schema)
+	return unknown
+}
+
+// schemaAccepts reports whether a schema accepts YAML nodes of the given kind
+func schemaAccepts(s map[string]any, definitions map[string]any, kind yaml.Kind) bool {
+	if ref, ok := s["$ref"].(string); ok {
+		s = definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any)
+	}
+	switch s["type"] {
+	case "object":
+		return kind == yaml.MappingNode
+	case "array":
+		return kind == yaml.SequenceNode
+	}
+	return kind == yaml.ScalarNode
+}
@@ -0,0 +1,155 @@
+// Code generated by gen_schema_docs.go; DO NOT EDIT.
+
+package config
+
+// fieldDocs are the comments of the fields of the config types by type and YAML
+// name, the descriptions of the JSON Schema of yxa.yml
+var fieldDocs = map[string]string{
+	"ArchiveStep.dest":                  "",
+	"ArchiveStep.format":                "zip or tar.gz, derived from Dest if not set",
+	"ArchiveStep.src":                   "",
+	"AssertStep.condition":              "",
+	"AssertStep.message":                "Error message if the condition is not met",
+	"Command.after":                     "Commands to run after if they run in the same invocation, without depending on them",
+	"Command.artifacts":                 "Files and directories the command creates, removed by yxa clean",
+	"Command.commands":                  "Named subcommands for hierarchical command structures",
+	"Command.condition":                 "Condition to evaluate before running",
+	"Command.container":                 "Docker container to run the shell commands in",
+	"Command.continue_on_error":         "Whether sequential tasks keep running after a failure",
+	"Command.cpu_limit":                 "Number of CPUs the command may use, e.g. 1.5",
+	"Command.depends":                   "Dependencies to execute first, each with an optional condition",
+	"Command.depends_mode":              "How dependencies run: \"fail-fast\" (default) or \"all\"",
+	"Command.deprecated":                "Why the command is deprecated and what to use instead, warned about when it is invoked",
+	"Command.description":               "Command description",
+	"Command.examples":                  "Example invocations shown by yxa help",
+	"Command.export_env":                "Whether the variables of the command are exported to its processes, false if not set",
+	"Command.foreach":                   "Glob pattern to run the command for every matched path of, as $ITEM",
+	"Command.generates":                 "Files the command creates from its sources",
+	"Command.help":                      "Long help text shown by yxa help, the description if not set",
+	"Command.hidden_deprecated":         "Whether the deprecated command is hidden from help and completion",
+	"Command.inherit_env":               "Whether the command sees the environment of yxa, true if not set",
+	"Command.log_file":                  "File the output of the command is also written to",
+	"Command.matrix":                    "Variables to run the command for every combination of, in parallel",
+	"Command.max_parallel":              "Maximum number of tasks running at the same time, 0 for no limit",
+	"Command.memory_limit":              "Maximum memory of the command, e.g. 512MB",
+	"Command.needs":                     "Commands whose stdout is passed to this command, by variable name",
+	"Command.nice":                      "Adjustment of the scheduling priority, e.g. 10 to yield to other processes",
+	"Command.notify":                    "Notifications sent when the command finishes",
+	"Command.on_cancel":                 "Command to run when the command is interrupted",
+	"Command.ordered_output":            "Whether parallel output is printed per task in declaration order once all finished",
+	"Command.output":                    "How output is shown: \"stream\" (default) or \"captured\"",
+	"Command.parallel":                  "Whether to run tasks in parallel",
+	"Command.params":                    "Command parameters (flags and positional)",
+	"Command.pipe":                      "Whether the stdout of each sequential task is streamed into the stdin of the next",
+	"Command.post":                      "Command to run after the main command",
+	"Command.post_timeout":              "Timeout of the post-hook, none if not set",
+	"Command.pre":                       "Command to run before the main command",
+	"Command.pre_timeout":               "Timeout of the pre-hook, none if not set",
+	"Command.register":                  "Variables extracted from the output of run",
+	"Command.requires":                  "Tools that must be on PATH before the command runs, e.g. go>=1.21",
+	"Command.run":                       "Main command to execute",
+	"Command.runner":                    "Executor backend to run the shell commands with, the host if not set",
+	"Command.service":                   "Long-running command that yxa up starts in the background",
+	"Command.sources":                   "Files the command reads, it is skipped while its generates are newer",
+	"Command.stall_action":              "What happens to a stalled command: \"kill\" (default) or \"warn\"",
+	"Command.stall_timeout":             "How long the command may produce no output, e.g. \"2m\"",
+	"Command.stderr_file":               "File stderr is written to instead of log_file",
+	"Command.stdin":                     "Input of run: a file, relative to the config, or inline content",
+	"Command.steps":                     "Built-in steps executed without a shell",
+	"Command.sudo":                      "Whether the shell commands run as root with sudo",
+	"Command.tasks":                     "Multiple tasks for parallel or sequential execution, each with an optional condition",
+	"Command.timeout":                   "Timeout for command execution (e.g. \"30s\", \"5m\")",
+	"Command.umask":                     "File mode creation mask of the shell commands in octal, e.g. 0022",
+	"Command.use_params":                "Names of the top-level params the command accepts, before its own",
+	"Command.user":                      "User the shell commands run as with sudo, root with sudo if not set",
+	"Command.variables":                 "Variables that shadow the project variables for this command",
+	"Command.workingdir":                "Command-level workingdir",
+	"Container.env":                     "Environment variables set in the container",
+	"Container.image":                   "Image to run, e.g. golang:1.24",
+	"Container.pull":                    "When to pull the image: missing (default), always or never",
+	"Container.volumes":                 "Bind mounts as host:container[:options]",
+	"Container.workdir":                 "Working directory inside the container",
+	"CopyStep.from":                     "",
+	"CopyStep.to":                       "",
+	"Dependency.command":                "Command to execute first",
+	"Dependency.condition":              "Condition to evaluate before executing the dependency",
+	"DownloadStep.dest":                 "File to write, or a directory to keep the name of the URL",
+	"DownloadStep.sha256":               "Expected hex encoded SHA-256 checksum",
+	"DownloadStep.url":                  "",
+	"HTTPStep.body":                     "Request body",
+	"HTTPStep.headers":                  "Request headers",
+	"HTTPStep.method":                   "Defaults to GET",
+	"HTTPStep.output":                   "File to write the response body to",
+	"HTTPStep.status":                   "Expected status code, any 2xx if not set",
+	"HTTPStep.url":                      "",
+	"LogFile.keep":                      "Number of rotated logs to keep, DefaultLogKeep if not set",
+	"LogFile.max_size":                  "Rotate the log before a run once it is this large, e.g. 10MB",
+	"LogFile.mode":                      "truncate (default) or append",
+	"LogFile.path":                      "Path of the log, relative to the config file",
+	"MkdirStep.path":                    "",
+	"MoveStep.from":                     "",
+	"MoveStep.to":                       "",
+	"Notify.desktop":                    "Show a desktop notification",
+	"Notify.min_duration":               "Only notify when the command ran at least this long, e.g. 1m",
+	"Notify.on":                         "When to notify: always (default), failure or success",
+	"Notify.slack":                      "Incoming webhook URL of a Slack channel",
+	"Notify.webhook":                    "URL the result is posted to as JSON",
+	"Param.choices":                     "Allowed values, also offered by shell completion",
+	"Param.completion_cmd":              "Command whose output lines shell completion offers, if there are no choices",
+	"Param.conflicts_with":              "Parameters that cannot be given together with this one",
+	"Param.default":                     "Value if the parameter is not given",
+	"Param.description":                 "Help text of the flag",
+	"Param.flag":                        "Is this a flag parameter?",
+	"Param.name":                        "Name of the variable and the flag, name|n for a shorthand",
+	"Param.position":                    "Position for positional params (-1 means not positional)",
+	"Param.required":                    "Whether the parameter must be given",
+	"Param.requires":                    "Parameters that must be given together with this one",
+	"Param.sensitive":                   "Mask the value in the output of yxa",
+	"Param.type":                        "string, int, float or bool",
+	"Profile.commands":                  "Fields to override by command name, parent:sub for subcommands",
+	"Profile.env":                       "Values that replace or add to those of the .env file",
+	"Profile.variables":                 "Variables that replace or add to the config variables",
+	"Profile.workingdir":                "Replaces the directory-level workingdir",
+	"ProjectConfig.commands":            "Commands by name",
+	"ProjectConfig.default_timeout":     "Timeout of every command that does not set its own, e.g. \"30m\"",
+	"ProjectConfig.encrypted_variables": "File with variables encrypted with sops or age, relative to the config file",
+	"ProjectConfig.name":                "Name of the project",
+	"ProjectConfig.notify":              "Notifications of every command that does not set its own",
+	"ProjectConfig.params":              "Parameters that commands share with use_params",
+	"ProjectConfig.profiles":            "Named overrides selected with --profile or YXA_PROFILE",
+	"ProjectConfig.root":                "Do not inherit the yxa.yml files of parent directories",
+	"ProjectConfig.tools":               "Tools pinned to a version, installed into .yxa/tools",
+	"ProjectConfig.variables":           "Variables of every command",
+	"ProjectConfig.workingdir":          "Directory-level workingdir",
+	"Register.from":                     "Output to parse, defaults to stdout",
+	"Register.jsonPath":                 "Path of the value in JSON or YAML output, the whole output if not set",
+	"Register.var":                      "Name of the variable to set",
+	"RmStep.path":                       "",
+	"Step.archive":                      "Create a zip or tar.gz archive",
+	"Step.assert":                       "Fail the command unless a condition holds",
+	"Step.copy":                         "Copy a file or directory",
+	"Step.download":                     "Download a file and verify its checksum",
+	"Step.http":                         "Send an HTTP request",
+	"Step.mkdir":                        "Create a directory and its parents",
+	"Step.move":                         "Move a file or directory",
+	"Step.name":                         "Optional name used in logs and errors",
+	"Step.rm":                           "Remove a file or directory",
+	"Step.template":                     "Render a Go template",
+	"Step.wait_for":                     "Wait for a URL, TCP address or file",
+	"Task.condition":                    "Condition to evaluate before running the task",
+	"Task.params":                       "Parameter values passed to the referenced command",
+	"Task.run":                          "Shell command to execute",
+	"Task.task":                         "Command to execute instead of a shell command",
+	"Task.timeout":                      "Timeout of the shell command, instead of the timeout of the command",
+	"TemplateStep.dest":                 "",
+	"TemplateStep.src":                  "",
+	"Tool.bin":                          "Path of the executable in the archive, the name of the tool if not set",
+	"Tool.sha256":                       "Checksums of the download by platform, e.g. linux-amd64",
+	"Tool.url":                          "Download URL, a .zip, .tar.gz or .tgz archive or the executable itself",
+	"Tool.version":                      "Version of the tool",
+	"WaitForStep.file":                  "Path that must exist",
+	"WaitForStep.interval":              "Defaults to 1s",
+	"WaitForStep.tcp":                   "host:port",
+	"WaitForStep.timeout":               "Defaults to 30s",
+	"WaitForStep.url":                   "",
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSchema_FieldDocs(t *testing.T) {
+	// Every YAML field of the config types needs an entry in schema_docs.go
+	seen := make(map[reflect.Type]bool)
+	var check func(reflect.Type)
+	check = func(typ reflect.Type) {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			check(typ.Elem())
+			return
+		case reflect.Struct:
+		default:
+			return
+		}
+		if seen[typ] {
+			return
+		}
+		seen[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			if _, ok := fieldDocs[typ.Name()+"."+name]; !ok {
+				t.Errorf("field %s.%s is missing in schema_docs.go, run go generate ./internal/config", typ.Name(), name)
+			}
+			check(field.Type)
+		}
+	}
+	check(reflect.TypeOf(ProjectConfig{}))
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	if got := schema["$schema"]; got != SchemaDraft {
+		t.Errorf("$schema = %v, want %v", got, SchemaDraft)
+	}
+
+	command := schema["definitions"].(map[string]any)["Command"].(map[string]any)["properties"].(map[string]any)
+	output := command["output"].(map[string]any)
+	if got, want := output["enum"], []string{OutputStream, OutputCaptured}; !reflect.DeepEqual(got, want) {
+		t.Errorf("enum of output = %v, want %v", got, want)
+	}
+	if got, want := command["sources"].(map[string]any)["description"], fieldDocs["Command.sources"]; got != want || got == "" {
+		t.Errorf("description of sources = %q, want %q", got, want)
+	}
+	override := schema["definitions"].(map[string]any)["CommandOverride"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := override["params"]; ok {
+		t.Error("profiles cannot override params, but the schema allows it")
+	}
+}
+
+func TestSchema_Validates(t *testing.T) {
+	valid := `
+name: shop
+encrypted_variables: secrets.enc.yml
+variables:
+  PORT: 8080
+  TOKEN: {value: abc, sensitive: true}
+notify: {on: failure, desktop: true}
+commands:
+  build:
+    run: go build
+    log_file: logs/build.log
+    register: [{var: VERSION, jsonPath: version}, {var: NAME, json_path: name}]
+    requires: [go>=1.21]
+    matrix:
+      os: [linux, darwin]
+      arch: amd64
+    runner: {name: docker, image: alpine:3}
+  deploy:
+    depends: [build, {command: build, condition: $CI != true}]
+    tasks:
+      - echo one
+      - {task: build, condition: $CI == true}
+    params:
+      - {name: env|e, type: string, flag: true, default: dev, choices: [dev, prod], sensitive: false}
+    commands:
+      app:
+        steps:
+          - wait_for: {url: http://localhost:8080, timeout: 10s}
+        stderr_file: {path: logs/err.log, mode: append}
+profiles:
+  ci:
+    env: {CI: true}
+    commands:
+      build: {output: captured, timeout: 5m}
+`
+	if errs := validateSchema(t, valid); len(errs) > 0 {
+		t.Errorf("valid config has errors: %v", errs)
+	}
+
+	invalid := `
+commands:
+  build:
+    rn: go build
+    output: quiet
+profiles:
+  ci:
+    commands:
+      build: {params: []}
+`
+	errs := validateSchema(t, invalid)
+	want := []string{
+		"commands.build: unknown field rn",
+		"commands.build.output: quiet is not one of [stream captured]",
+		"profiles.ci.commands.build: unknown field params",
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	var doc yaml.Node
+	data := `
+x-defaults: &defaults
+  timeout: 5m
+  retries: 3
+commands:
+  build:
+    <<: *defaults
+    run: go build
+    log_file: {path: build.log, rotate: true}
+    commands:
+      linux: {rn: go build}
+`
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("invalid YAML: %v", err)
+	}
+
+	var got []string
+	for _, f := range UnknownFields(&doc) {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", f.Line, f.Column, strings.Join(f.Path, "."), f.Key))
+	}
+	want := []string{
+		"4:3 commands.build retries",
+		"9:33 commands.build.log_file rotate",
+		"11:15 commands.build.commands.linux rn",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownFields() = %v, want %v", got, want)
+	}
+}
+
+// validateSchema checks a YAML document against the parts of Schema that matter
+// for the structure of a config: types, properties, enums, references and oneOf
+func validateSchema(t *testing.T, data string) []string {
+	t.Helper()
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("invalid YAML: %v", err)
+	}
+	schema := Schema()
+	definitions := schema["definitions"].(map[string]any)
+
+	var errs []string
+	var validate func(path string, node *yaml.Node, s map[string]any) bool
+	validate = func(path string, node *yaml.Node, s map[string]any) bool {
+		if ref, ok := s["$ref"].(string); ok {
+			return validate(path, node, definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any))
+		}
+		if branches, ok := s["oneOf"].([]map[string]any); ok {
+			for _, branch := range branches {
+				if schemaKind(branch, definitions) == node.Kind {
+					return validate(path, node, branch)
+				}
+			}
+			errs = append(errs, fmt.Sprintf("%s: matches no form", path))
+			return false
+		}
+		if kind := schemaKind(s, definitions); kind != 0 && kind != node.Kind {
+			errs = append(errs, fmt.Sprintf("%s: wrong kind of value", path))
+			return false
+		}
+		if enum, ok := s["enum"].([]string); ok && !contains(enum, node.Value) {
+			errs = append(errs, fmt.Sprintf("%s: %s is not one of %v", path, node.Value, enum))
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			properties, _ := s["properties"].(map[string]any)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i].Value, node.Content[i+1]
+				if property, ok := properties[key]; ok {
+					validate(strings.TrimPrefix(path+"."+key, "."), value, property.(map[string]any))
+				} else if additional, ok := s["additionalProperties"].(map[string]any); ok {
+					validate(strings.TrimPrefix(path+"."+key, "."), value, additional)
+				} else if s["additionalProperties"] == false && !strings.HasPrefix(key, "x-") {
+					errs = append(errs, fmt.Sprintf("%s: unknown field %s", path, key))
+				}
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				validate(fmt.Sprintf("%s[%d]", path, i), item, s["items"].(map[string]any))
+			}
+		}
+		return true
+	}
+	validate("", doc.Content[0], schema)
+	return errs
+}
+
+// schemaKind returns the YAML node kind a schema accepts
+func schemaKind(s map[string]any, definitions map[string]any) yaml.Kind {
+	if ref, ok := s["$ref"].(string); ok {
+		return schemaKind(definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any), definitions)
+	}
+	switch s["type"] {
+	case "object":
+		return yaml.MappingNode
+	case "array":
+		return yaml.SequenceNode
+	case nil:
+		return 0
+	}
+	return yaml.ScalarNode
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sensitiveVariable is the mapping form of a variable, whose value is masked in
+// the output of yxa if it is sensitive
+type sensitiveVariable struct {
+	Value     string `yaml:"value"`
+	Sensitive bool   `yaml:"sensitive"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Variables of the config, its
+// commands and profiles can be given as a value or as a mapping with a value and
+// sensitive; the mappings are replaced with their values before decoding, and the
+// names of the sensitive ones are kept.
+func (c *ProjectConfig) UnmarshalYAML(node *yaml.Node) error {
+	sensitive := make(map[string]bool)
+	if err := collectSensitiveVariables(node, sensitive); err != nil {
+		return err
+	}
+
+	type plain ProjectConfig
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.sensitive = sensitive
+	return nil
+}
+
+// collectSensitiveVariables walks the variables of a config, command or profile
+// node and of the commands and profiles below it
+func collectSensitiveVariables(node *yaml.Node, sensitive map[string]bool) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch key {
+		case "variables":
+			if err := flattenVariables(value, sensitive); err != nil {
+				return err
+			}
+		case "commands", "profiles":
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 1; j < len(value.Content); j += 2 {
+				if err := collectSensitiveVariables(value.Content[j], sensitive); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// flattenVariables replaces the mapping form of the variables in a variables node
+// with their values, adding the names of the sensitive ones to sensitive
+func flattenVariables(node *yaml.Node, sensitive map[string]bool) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j < len(value.Content); j += 2 {
+			if field := value.Content[j].Value; field != "value" && field != "sensitive" {
+				return fmt.Errorf("line %d: variable '%s' must be a value or a mapping with 'value' and 'sensitive', not '%s'", value.Line, name, field)
+			}
+		}
+		var v sensitiveVariable
+		if err := value.Decode(&v); err != nil {
+			return err
+		}
+		if v.Sensitive {
+			sensitive[name] = true
+		}
+		*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Value, Line: value.Line, Column: value.Column}
+	}
+	return nil
+}
+
+// SensitiveVariables returns the names of the variables marked as sensitive,
+// sorted
+func (c *ProjectConfig) SensitiveVariables() []string {
+	names := make([]string, 0, len(c.sensitive))
+	for name := range c.sensitive {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
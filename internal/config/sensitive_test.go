@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestProjectConfig_SensitiveVariables(t *testing.T) {
+	data := `
+variables:
+  REGION: eu
+  TOKEN: {value: abc123, sensitive: true}
+commands:
+  deploy:
+    run: echo deploy
+    variables:
+      KEY: {value: k3y, sensitive: true}
+      PLAIN: {value: shown}
+profiles:
+  prod:
+    variables:
+      PROD_PASS: {value: p4ss, sensitive: true}
+`
+	var cfg ProjectConfig
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got, want := cfg.SensitiveVariables(), []string{"KEY", "PROD_PASS", "TOKEN"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SensitiveVariables() = %v, want %v", got, want)
+	}
+	if got, want := cfg.Variables, map[string]string{"REGION": "eu", "TOKEN": "abc123"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables = %v, want %v", got, want)
+	}
+	if got, want := cfg.Commands["deploy"].Variables, map[string]string{"KEY": "k3y", "PLAIN": "shown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deploy variables = %v, want %v", got, want)
+	}
+	if got := cfg.Profiles["prod"].Variables["PROD_PASS"]; got != "p4ss" {
+		t.Errorf("prod variable PROD_PASS = %q, want %q", got, "p4ss")
+	}
+}
+
+func TestProjectConfig_SensitiveVariablesUnknownField(t *testing.T) {
+	var cfg ProjectConfig
+	err := yaml.Unmarshal([]byte("variables:\n  TOKEN: {value: abc, secret: true}\n"), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "variable 'TOKEN' must be a value or a mapping") {
+		t.Errorf("Unmarshal() error = %v, want an error about variable 'TOKEN'", err)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+// Step represents a built-in step of a script command. Exactly one of the step
+// types must be set.
+type Step struct {
+	Name     string        `yaml:"name,omitempty"`     // Optional name used in logs and errors
+	HTTP     *HTTPStep     `yaml:"http,omitempty"`     // Send an HTTP request
+	Copy     *CopyStep     `yaml:"copy,omitempty"`     // Copy a file or directory
+	Move     *MoveStep     `yaml:"move,omitempty"`     // Move a file or directory
+	Mkdir    *MkdirStep    `yaml:"mkdir,omitempty"`    // Create a directory and its parents
+	Rm       *RmStep       `yaml:"rm,omitempty"`       // Remove a file or directory
+	Download *DownloadStep `yaml:"download,omitempty"` // Download a file and verify its checksum
+	WaitFor  *WaitForStep  `yaml:"wait_for,omitempty"` // Wait for a URL, TCP address or file
+	Template *TemplateStep `yaml:"template,omitempty"` // Render a Go template
+	Archive  *ArchiveStep  `yaml:"archive,omitempty"`  // Create a zip or tar.gz archive
+	Assert   *AssertStep   `yaml:"assert,omitempty"`   // Fail the command unless a condition holds
+}
+
+// HTTPStep sends an HTTP request
+type HTTPStep struct {
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method,omitempty"`  // Defaults to GET
+	Headers map[string]string `yaml:"headers,omitempty"` // Request headers
+	Body    string            `yaml:"body,omitempty"`    // Request body
+	Status  int               `yaml:"status,omitempty"`  // Expected status code, any 2xx if not set
+	Output  string            `yaml:"output,omitempty"`  // File to write the response body to
+}
+
+// CopyStep copies a file or a directory tree
+type CopyStep struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// MoveStep moves a file or a directory tree
+type MoveStep struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// MkdirStep creates a directory and its parents
+type MkdirStep struct {
+	Path string `yaml:"path"`
+}
+
+// RmStep removes a file or a directory tree. A missing path is not an error.
+type RmStep struct {
+	Path string `yaml:"path"`
+}
+
+// DownloadStep downloads a file. With SHA256 the file is verified, cached in
+// .yxa/downloads and resumed if a download was interrupted.
+type DownloadStep struct {
+	URL    string `yaml:"url"`
+	Dest   string `yaml:"dest"`             // File to write, or a directory to keep the name of the URL
+	SHA256 string `yaml:"sha256,omitempty"` // Expected hex encoded SHA-256 checksum
+}
+
+// WaitForStep waits until a URL responds, a TCP address accepts connections or a
+// file exists. Exactly one of URL, TCP and File must be set.
+type WaitForStep struct {
+	URL      string `yaml:"url,omitempty"`
+	TCP      string `yaml:"tcp,omitempty"`      // host:port
+	File     string `yaml:"file,omitempty"`     // Path that must exist
+	Timeout  string `yaml:"timeout,omitempty"`  // Defaults to 30s
+	Interval string `yaml:"interval,omitempty"` // Defaults to 1s
+}
+
+// TemplateStep renders a Go text/template with the command's variables
+type TemplateStep struct {
+	Src  string `yaml:"src"`
+	Dest string `yaml:"dest"`
+}
+
+// ArchiveStep packs a file or directory into an archive
+type ArchiveStep struct {
+	Src    string `yaml:"src"`
+	Dest   string `yaml:"dest"`
+	Format string `yaml:"format,omitempty"` // zip or tar.gz, derived from Dest if not set
+}
+
+// AssertStep fails the command with Message unless Condition is met. The condition
+// uses the same syntax as a command's condition.
+type AssertStep struct {
+	Condition string `yaml:"condition"`
+	Message   string `yaml:"message,omitempty"` // Error message if the condition is not met
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Task is one entry of the tasks of a command. In yxa.yml it can be written as
+// a plain shell command or as a mapping with a run string or a reference to
+// another command, and a condition.
+type Task struct {
+	Run       string            `yaml:"run,omitempty"`       // Shell command to execute
+	Task      string            `yaml:"task,omitempty"`      // Command to execute instead of a shell command
+	Params    map[string]string `yaml:"params,omitempty"`    // Parameter values passed to the referenced command
+	Condition string            `yaml:"condition,omitempty"` // Condition to evaluate before running the task
+	Timeout   string            `yaml:"timeout,omitempty"`   // Timeout of the shell command, instead of the timeout of the command
+}
+
+// UnmarshalYAML accepts both a shell command and a task mapping
+func (t *Task) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*t = Task{Run: value.Value}
+		return nil
+	}
+
+	type plain Task
+	return value.Decode((*plain)(t))
+}
+
+// TaskList is the list of tasks of a command
+type TaskList []Task
+
+// NewTaskList creates a task list without conditions from shell commands
+func NewTaskList(runs ...string) TaskList {
+	tasks := make(TaskList, len(runs))
+	for i, run := range runs {
+		tasks[i] = Task{Run: run}
+	}
+	return tasks
+}
+
+// Runs returns the shell commands of the tasks, empty for tasks that reference
+// a command
+func (l TaskList) Runs() []string {
+	runs := make([]string, len(l))
+	for i, task := range l {
+		runs[i] = task.Run
+	}
+	return runs
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTaskList_UnmarshalYAML(t *testing.T) {
+	var cmd Command
+	data := "tasks:\n  - go test ./...\n  - run: go test -race ./...\n    condition: $OS != windows\n"
+	if err := yaml.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := TaskList{
+		{Run: "go test ./..."},
+		{Run: "go test -race ./...", Condition: "$OS != windows"},
+	}
+	if !reflect.DeepEqual(cmd.Tasks, want) {
+		t.Errorf("Tasks = %+v, want %+v", cmd.Tasks, want)
+	}
+	if runs := cmd.Tasks.Runs(); !reflect.DeepEqual(runs, []string{"go test ./...", "go test -race ./..."}) {
+		t.Errorf("Runs() = %v", runs)
+	}
+
+	data = "tasks:\n  - task: build\n    params: {target: linux}\n"
+	if err := yaml.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = TaskList{{Task: "build", Params: map[string]string{"target": "linux"}}}
+	if !reflect.DeepEqual(cmd.Tasks, want) {
+		t.Errorf("Tasks = %+v, want %+v", cmd.Tasks, want)
+	}
+
+	if err := yaml.Unmarshal([]byte("tasks:\n  - [go, test]\n"), &cmd); err == nil {
+		t.Error("Expected an error for a task that is a list, got nil")
+	}
+}
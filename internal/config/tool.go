@@ -0,0 +1,11 @@
+package config
+
+// Tool pins the version of a tool that yxa downloads into .yxa/tools and puts on
+// the PATH of commands. The URL and Bin may reference $version, $os and $arch,
+// which are the Go names of the platform (e.g. linux and amd64).
+type Tool struct {
+	Version string            `yaml:"version"`          // Version of the tool
+	URL     string            `yaml:"url"`              // Download URL, a .zip, .tar.gz or .tgz archive or the executable itself
+	SHA256  map[string]string `yaml:"sha256,omitempty"` // Checksums of the download by platform, e.g. linux-amd64
+	Bin     string            `yaml:"bin,omitempty"`    // Path of the executable in the archive, the name of the tool if not set
+}
@@ -3,21 +3,39 @@ package errors
 import (
 	"fmt"
 	"strings"
+	"time"
+)
+
+// Stages of a command execution in which a CommandError can occur
+const (
+	StageLookup     = "lookup"     // Finding the command in the config
+	StageValidate   = "validate"   // Checking the definition of the command
+	StageParameters = "parameters" // Processing the parameters of the command
+	StageRequires   = "requires"   // Checking the tools the command requires
+	StageDependency = "dependency" // Executing the dependencies of the command
+	StageRun        = "run"        // Executing the command itself
 )
 
 // CommandError represents an error that occurred during command execution
 type CommandError struct {
 	CommandName string
+	Stage       string // Stage in which the error occurred, empty if unknown
 	Message     string
 	Err         error
 }
 
 // Error implements the error interface
 func (e *CommandError) Error() string {
+	return fmt.Sprintf("command '%s': %s", e.CommandName, e.Detail())
+}
+
+// Detail returns the error message without the command name, for callers that
+// already mention the command
+func (e *CommandError) Detail() string {
 	if e.Err != nil {
-		return fmt.Sprintf("command '%s': %s: %v", e.CommandName, e.Message, e.Err)
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
 	}
-	return fmt.Sprintf("command '%s': %s", e.CommandName, e.Message)
+	return e.Message
 }
 
 // Unwrap returns the underlying error
@@ -38,6 +56,7 @@ func NewCommandError(cmdName, message string, err error) *CommandError {
 func NewCommandNotFoundError(cmdName string) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageLookup,
 		Message:     "command not found",
 	}
 }
@@ -46,6 +65,7 @@ func NewCommandNotFoundError(cmdName string) *CommandError {
 func NewDependencyError(cmdName, depName string, err error) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageDependency,
 		Message:     fmt.Sprintf("failed to execute dependency '%s'", depName),
 		Err:         err,
 	}
@@ -58,6 +78,7 @@ func NewCircularDependencyError(path []string, cmdName string) *CommandError {
 
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageDependency,
 		Message:     fmt.Sprintf("circular dependency detected: %s", pathStr),
 	}
 }
@@ -66,6 +87,7 @@ func NewCircularDependencyError(path []string, cmdName string) *CommandError {
 func NewExecutionError(cmdName string, err error) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageRun,
 		Message:     "execution failed",
 		Err:         err,
 	}
@@ -75,6 +97,7 @@ func NewExecutionError(cmdName string, err error) *CommandError {
 func NewHookError(cmdName, hookType string, err error) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       hookType + "-hook",
 		Message:     fmt.Sprintf("%s-hook execution failed", hookType),
 		Err:         err,
 	}
@@ -84,15 +107,95 @@ func NewHookError(cmdName, hookType string, err error) *CommandError {
 func NewTimeoutError(cmdName, timeoutStr string, err error) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageValidate,
 		Message:     fmt.Sprintf("invalid timeout '%s'", timeoutStr),
 		Err:         err,
 	}
 }
 
+// NewRequirementsError creates a new error for a command whose required tools are
+// missing or too old. Every problem describes one requirement.
+func NewRequirementsError(cmdName string, problems []string) *CommandError {
+	return &CommandError{
+		CommandName: cmdName,
+		Stage:       StageRequires,
+		Message: fmt.Sprintf("missing required tools: %s; install them, or run 'yxa doctor' to check all prerequisites",
+			strings.Join(problems, ", ")),
+	}
+}
+
 // NewParameterError creates a new error for parameter-related issues
 func NewParameterError(cmdName, paramName, message string) *CommandError {
 	return &CommandError{
 		CommandName: cmdName,
+		Stage:       StageParameters,
 		Message:     fmt.Sprintf("parameter '%s': %s", paramName, message),
 	}
 }
+
+// ExecutionTimeoutError represents a command that ran longer than its timeout
+type ExecutionTimeoutError struct {
+	Timeout time.Duration // The timeout that was exceeded
+	Message string        // What happened to the command afterwards, if anything
+	Err     error         // The underlying error, if any
+}
+
+// Error implements the error interface
+func (e *ExecutionTimeoutError) Error() string {
+	msg := fmt.Sprintf("command timed out after %s", e.Timeout)
+	if e.Message != "" {
+		msg += " and " + e.Message
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(": %v", e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ExecutionTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// NewExecutionTimeoutError creates a new error for when a command exceeds its timeout
+func NewExecutionTimeoutError(timeout time.Duration, message string, err error) *ExecutionTimeoutError {
+	return &ExecutionTimeoutError{
+		Timeout: timeout,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// ExecutionStallError represents a command that was stopped because it produced no
+// output for longer than its stall timeout
+type ExecutionStallError struct {
+	Timeout time.Duration // The stall timeout that was exceeded
+	Message string        // What happened to the command afterwards, if anything
+	Err     error         // The underlying error, if any
+}
+
+// Error implements the error interface
+func (e *ExecutionStallError) Error() string {
+	msg := fmt.Sprintf("command produced no output for %s", e.Timeout)
+	if e.Message != "" {
+		msg += " and " + e.Message
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(": %v", e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *ExecutionStallError) Unwrap() error {
+	return e.Err
+}
+
+// NewExecutionStallError creates a new error for when a command exceeds its stall timeout
+func NewExecutionStallError(timeout time.Duration, message string, err error) *ExecutionStallError {
+	return &ExecutionStallError{
+		Timeout: timeout,
+		Message: message,
+		Err:     err,
+	}
+}
@@ -15,10 +15,16 @@ type ConfigError struct {
 
 // Error implements the error interface
 func (e *ConfigError) Error() string {
+	return fmt.Sprintf("config error in %s '%s': %s", e.Section, e.Name, e.Detail())
+}
+
+// Detail returns the error message without the section and name, for callers
+// that already mention them
+func (e *ConfigError) Detail() string {
 	if e.Err != nil {
-		return fmt.Sprintf("config error in %s '%s': %s: %v", e.Section, e.Name, e.Message, e.Err)
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
 	}
-	return fmt.Sprintf("config error in %s '%s': %s", e.Section, e.Name, e.Message)
+	return e.Message
 }
 
 // Unwrap returns the underlying error
@@ -36,6 +42,16 @@ func NewConfigError(section, name, message string, err error) *ConfigError {
 	}
 }
 
+// NewConfigFileError creates a new error for a config file that cannot be loaded
+func NewConfigFileError(path, message string, err error) *ConfigError {
+	return &ConfigError{
+		Section: "file",
+		Name:    path,
+		Message: message,
+		Err:     err,
+	}
+}
+
 // NewCommandConfigError creates a new error for command configuration issues
 func NewCommandConfigError(cmdName, message string, err error) *ConfigError {
 	return &ConfigError{
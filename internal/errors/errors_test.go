@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +21,8 @@ func TestCommandError(t *testing.T) {
 	expectedErrMsg := "command 'test-cmd': test message: underlying error"
 	assert.Equal(t, expectedErrMsg, cmdErr.Error(), "Error message should match")
 
+	assert.Equal(t, "test message: underlying error", cmdErr.Detail(), "Detail should omit the command name")
+
 	// Test Unwrap() method
 	assert.Equal(t, underlyingErr, errors.Unwrap(cmdErr), "Unwrap should return underlying error")
 
@@ -29,6 +32,20 @@ func TestCommandError(t *testing.T) {
 	assert.Equal(t, expectedErrMsg, cmdErr.Error(), "Error message without underlying error should match")
 }
 
+func TestExecutionTimeoutError(t *testing.T) {
+	underlyingErr := errors.New("signal: killed")
+	timeoutErr := NewExecutionTimeoutError(5*time.Second, "was terminated", underlyingErr)
+
+	assert.Equal(t, "command timed out after 5s and was terminated: signal: killed", timeoutErr.Error())
+	assert.Equal(t, underlyingErr, errors.Unwrap(timeoutErr), "Unwrap should return underlying error")
+
+	var target *ExecutionTimeoutError
+	assert.True(t, errors.As(NewExecutionError("build", timeoutErr), &target), "errors.As should find the timeout")
+	assert.Equal(t, 5*time.Second, target.Timeout)
+
+	assert.Equal(t, "command timed out after 1ms", NewExecutionTimeoutError(time.Millisecond, "", nil).Error())
+}
+
 func TestConfigError(t *testing.T) {
 	// Test with underlying error
 	underlyingErr := errors.New("underlying error")
@@ -43,6 +60,8 @@ func TestConfigError(t *testing.T) {
 	expectedErrMsg := "config error in section 'name': test message: underlying error"
 	assert.Equal(t, expectedErrMsg, cfgErr.Error(), "Error message should match")
 
+	assert.Equal(t, "test message: underlying error", cfgErr.Detail(), "Detail should omit the section and name")
+
 	// Test Unwrap() method
 	assert.Equal(t, underlyingErr, errors.Unwrap(cfgErr), "Unwrap should return underlying error")
 
@@ -82,6 +101,7 @@ func TestCommandErrorConstructors(t *testing.T) {
 	// Test NewCommandNotFoundError
 	cmdNotFoundErr := NewCommandNotFoundError("test-cmd")
 	assert.Equal(t, "test-cmd", cmdNotFoundErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageLookup, cmdNotFoundErr.Stage, "Stage should match")
 	assert.Equal(t, "command not found", cmdNotFoundErr.Message, "Message should match")
 	assert.Nil(t, cmdNotFoundErr.Err, "Err should be nil")
 	assert.Contains(t, cmdNotFoundErr.Error(), "command 'test-cmd': command not found")
@@ -89,6 +109,7 @@ func TestCommandErrorConstructors(t *testing.T) {
 	// Test NewDependencyError
 	depErr := NewDependencyError("test-cmd", "dep-cmd", underlyingErr)
 	assert.Equal(t, "test-cmd", depErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageDependency, depErr.Stage, "Stage should match")
 	assert.Equal(t, "failed to execute dependency 'dep-cmd'", depErr.Message, "Message should match")
 	assert.Equal(t, underlyingErr, depErr.Err, "Err should match")
 	assert.Contains(t, depErr.Error(), "command 'test-cmd': failed to execute dependency 'dep-cmd'")
@@ -97,6 +118,7 @@ func TestCommandErrorConstructors(t *testing.T) {
 	path := []string{"cmd1", "cmd2"}
 	circDepErr := NewCircularDependencyError(path, "cmd3")
 	assert.Equal(t, "cmd3", circDepErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageDependency, circDepErr.Stage, "Stage should match")
 	assert.Contains(t, circDepErr.Message, "circular dependency detected")
 	assert.Contains(t, circDepErr.Message, "cmd1 -> cmd2 -> cmd3")
 	assert.Nil(t, circDepErr.Err, "Err should be nil")
@@ -104,32 +126,47 @@ func TestCommandErrorConstructors(t *testing.T) {
 	// Test NewExecutionError
 	execErr := NewExecutionError("test-cmd", underlyingErr)
 	assert.Equal(t, "test-cmd", execErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageRun, execErr.Stage, "Stage should match")
 	assert.Equal(t, "execution failed", execErr.Message, "Message should match")
 	assert.Equal(t, underlyingErr, execErr.Err, "Err should match")
 
 	// Test NewHookError
 	hookErr := NewHookError("test-cmd", "pre", underlyingErr)
 	assert.Equal(t, "test-cmd", hookErr.CommandName, "CommandName should match")
+	assert.Equal(t, "pre-hook", hookErr.Stage, "Stage should match")
 	assert.Equal(t, "pre-hook execution failed", hookErr.Message, "Message should match")
 	assert.Equal(t, underlyingErr, hookErr.Err, "Err should match")
 
 	// Test NewTimeoutError
 	timeoutErr := NewTimeoutError("test-cmd", "10s", underlyingErr)
 	assert.Equal(t, "test-cmd", timeoutErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageValidate, timeoutErr.Stage, "Stage should match")
 	assert.Equal(t, "invalid timeout '10s'", timeoutErr.Message, "Message should match")
 	assert.Equal(t, underlyingErr, timeoutErr.Err, "Err should match")
 
 	// Test NewParameterError
 	paramErr := NewParameterError("test-cmd", "param1", "invalid value")
 	assert.Equal(t, "test-cmd", paramErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageParameters, paramErr.Stage, "Stage should match")
 	assert.Equal(t, "parameter 'param1': invalid value", paramErr.Message, "Message should match")
 	assert.Nil(t, paramErr.Err, "Err should be nil")
+
+	// Test NewRequirementsError
+	reqErr := NewRequirementsError("test-cmd", []string{"node (not found in PATH)", "go>=1.21 (found 1.20.3)"})
+	assert.Equal(t, "test-cmd", reqErr.CommandName, "CommandName should match")
+	assert.Equal(t, StageRequires, reqErr.Stage, "Stage should match")
+	assert.Equal(t, "missing required tools: node (not found in PATH), go>=1.21 (found 1.20.3); install them, or run 'yxa doctor' to check all prerequisites", reqErr.Message, "Message should match")
 }
 
 // TestConfigErrorConstructors tests all the specialized config error constructors
 func TestConfigErrorConstructors(t *testing.T) {
 	underlyingErr := errors.New("underlying error")
 
+	// Test NewConfigFileError
+	fileErr := NewConfigFileError("yxa.yml", "failed to parse", underlyingErr)
+	assert.Equal(t, "file", fileErr.Section, "Section should match")
+	assert.Equal(t, "config error in file 'yxa.yml': failed to parse: underlying error", fileErr.Error())
+
 	// Test NewCommandConfigError
 	cmdCfgErr := NewCommandConfigError("test-cmd", "invalid config", underlyingErr)
 	assert.Equal(t, "command", cmdCfgErr.Section, "Section should match")
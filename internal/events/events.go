@@ -0,0 +1,81 @@
+// Package events emits structured events about a yxa run, so that wrappers and
+// IDEs can follow the execution without parsing the human readable output.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Types of the emitted events
+const (
+	CommandStart = "command_start" // A command starts executing
+	CommandEnd   = "command_end"   // A command finished, see Status
+	TaskOutput   = "task_output"   // Output of a sequential or parallel task
+	HookStart    = "hook_start"    // A pre, post or on_cancel hook starts executing
+	Error        = "error"         // A command failed
+)
+
+// Statuses of a command_end event
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // The command was up to date or its condition was not met and did not run
+)
+
+// FormatJSON is the only supported event format: one JSON object per line
+const FormatJSON = "json"
+
+// Event is a single structured event. Fields that do not apply to an event type are omitted.
+type Event struct {
+	Type       string    `json:"event"`
+	Time       time.Time `json:"time"`
+	RunID      string    `json:"run_id,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Hook       string    `json:"hook,omitempty"`        // pre, post or on_cancel
+	Task       int       `json:"task,omitempty"`        // 1-based task number
+	Output     string    `json:"output,omitempty"`      // Captured task output
+	Status     string    `json:"status,omitempty"`      // Result of command_end
+	DurationMS int64     `json:"duration_ms,omitempty"` // Duration of command_end
+	Error      string    `json:"error,omitempty"`
+}
+
+// Emitter writes events as newline-delimited JSON. A nil Emitter discards all
+// events, so callers do not need to check whether events are enabled.
+type Emitter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// NewEmitter creates an emitter that writes events in the given format to w
+func NewEmitter(format string, w io.Writer) (*Emitter, error) {
+	if format != FormatJSON {
+		return nil, fmt.Errorf("unsupported event format '%s', expected '%s'", format, FormatJSON)
+	}
+	return &Emitter{enc: json.NewEncoder(w), now: time.Now}, nil
+}
+
+// Enabled reports whether events are written
+func (e *Emitter) Enabled() bool {
+	return e != nil
+}
+
+// Emit writes an event, setting its time if it is not set. Events are written
+// atomically, so it is safe to emit from parallel tasks.
+func (e *Emitter) Emit(event Event) {
+	if e == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = e.now().UTC()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Events are best effort, a closed event stream must not fail the run
+	_ = e.enc.Encode(event)
+}
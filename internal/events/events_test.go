@@ -0,0 +1,66 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEmitter(t *testing.T) {
+	_, err := NewEmitter("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, "unsupported event format 'xml', expected 'json'")
+
+	e, err := NewEmitter(FormatJSON, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, e.Enabled())
+}
+
+func TestEmitter_Emit(t *testing.T) {
+	out := &bytes.Buffer{}
+	e, err := NewEmitter(FormatJSON, out)
+	require.NoError(t, err)
+	e.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	e.Emit(Event{Type: CommandStart, RunID: "abc", Command: "build"})
+	e.Emit(Event{Type: CommandEnd, Command: "build", Status: StatusOK, DurationMS: 42})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"event":"command_start","time":"2024-05-01T12:00:00Z","run_id":"abc","command":"build"}`, lines[0])
+	assert.JSONEq(t, `{"event":"command_end","time":"2024-05-01T12:00:00Z","command":"build","status":"ok","duration_ms":42}`, lines[1])
+}
+
+func TestEmitter_Concurrent(t *testing.T) {
+	out := &bytes.Buffer{}
+	e, err := NewEmitter(FormatJSON, out)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(task int) {
+			defer wg.Done()
+			e.Emit(Event{Type: TaskOutput, Task: task, Output: strings.Repeat("x", 1000)})
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 20)
+	for _, line := range lines {
+		var event Event
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+	}
+}
+
+func TestEmitter_Nil(t *testing.T) {
+	var e *Emitter
+	assert.False(t, e.Enabled())
+	assert.NotPanics(t, func() { e.Emit(Event{Type: Error}) })
+}
@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultCaptureLimit is the number of bytes a CaptureBuffer keeps in memory before
+// it spills to a temporary file
+const DefaultCaptureLimit = 1 << 20
+
+// CaptureBuffer collects the output of a command so it can be replayed later. Up to
+// limit bytes are kept in memory, beyond that the output is moved to a temporary
+// file. It is safe for concurrent use, e.g. as both stdout and stderr.
+type CaptureBuffer struct {
+	mu    sync.Mutex
+	limit int
+	mem   bytes.Buffer
+	file  *os.File
+	size  int64
+}
+
+// NewCaptureBuffer creates a CaptureBuffer that keeps up to limit bytes in memory
+func NewCaptureBuffer(limit int) *CaptureBuffer {
+	return &CaptureBuffer{limit: limit}
+}
+
+// Write implements io.Writer
+func (b *CaptureBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil && b.mem.Len()+len(p) > b.limit {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves the output kept in memory to a temporary file
+func (b *CaptureBuffer) spill() error {
+	f, err := os.CreateTemp("", "yxa-output-*")
+	if err != nil {
+		return err
+	}
+	if _, err := b.mem.WriteTo(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	b.file = f
+	return nil
+}
+
+// Len returns the number of bytes written
+func (b *CaptureBuffer) Len() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Spilled reports whether the output was moved to a temporary file
+func (b *CaptureBuffer) Spilled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file != nil
+}
+
+// WriteTo writes the captured output to w. The output is kept, so it can be
+// written more than once.
+func (b *CaptureBuffer) WriteTo(w io.Writer) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes()).WriteTo(w)
+	}
+	return io.Copy(w, io.NewSectionReader(b.file, 0, b.size))
+}
+
+// Close removes the temporary file, if any. The captured output is dropped.
+func (b *CaptureBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mem.Reset()
+	b.size = 0
+	if b.file == nil {
+		return nil
+	}
+	f := b.file
+	b.file = nil
+	closeErr := f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureBuffer_InMemory(t *testing.T) {
+	buf := NewCaptureBuffer(64)
+	defer func() { _ = buf.Close() }()
+
+	_, _ = buf.Write([]byte("hello\n"))
+	_, _ = buf.Write([]byte("world\n"))
+	assert.False(t, buf.Spilled())
+	assert.Equal(t, int64(12), buf.Len())
+
+	// Replaying keeps the output
+	for i := 0; i < 2; i++ {
+		var out bytes.Buffer
+		_, err := buf.WriteTo(&out)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\nworld\n", out.String())
+	}
+}
+
+func TestCaptureBuffer_SpillsToDisk(t *testing.T) {
+	buf := NewCaptureBuffer(8)
+
+	_, _ = buf.Write([]byte("first\n"))
+	assert.False(t, buf.Spilled())
+	_, _ = buf.Write([]byte(strings.Repeat("x", 20) + "\n"))
+	assert.True(t, buf.Spilled())
+	_, _ = buf.Write([]byte("last\n"))
+	name := buf.file.Name()
+
+	var out bytes.Buffer
+	_, err := buf.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n"+strings.Repeat("x", 20)+"\nlast\n", out.String())
+	assert.Equal(t, int64(out.Len()), buf.Len())
+
+	require.NoError(t, buf.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err), "temporary file should be removed")
+}
@@ -0,0 +1,300 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yxaerrors "github.com/floppa/yxa-cli/internal/errors"
+)
+
+// Image pull policies of a ContainerSpec
+const (
+	PullMissing = "missing"
+	PullAlways  = "always"
+	PullNever   = "never"
+)
+
+// containerStopTimeout is how long 'docker stop' waits for a container to exit
+// after SIGTERM before it kills it
+const containerStopTimeout = 5 * time.Second
+
+// ContainerSpec describes the container commands run in. It is also the options
+// of the docker runner.
+type ContainerSpec struct {
+	Image   string            `yaml:"image"`   // Image to run
+	Volumes []string          `yaml:"volumes"` // Bind mounts as host:container[:options]
+	Env     map[string]string `yaml:"env"`     // Environment variables set in the container
+	Workdir string            `yaml:"workdir"` // Working directory inside the container
+	Pull    string            `yaml:"pull"`    // Image pull policy, PullMissing if empty
+}
+
+// ContainerExecutor is an implementation of CommandExecutor that runs every
+// command in a new Docker container with 'sh -c'
+type ContainerExecutor struct {
+	Spec   ContainerSpec
+	Docker string    // Docker CLI to use, "docker" if empty
+	Stdin  io.Reader // Input of the commands, nil for the stdin of yxa
+	Stdout io.Writer
+	Stderr io.Writer
+	mutex  sync.Mutex // Protects concurrent access to Stdin/Stdout/Stderr
+}
+
+// NewContainerExecutor creates a new ContainerExecutor with standard output/error
+func NewContainerExecutor(spec ContainerSpec) *ContainerExecutor {
+	return &ContainerExecutor{
+		Spec:   spec,
+		Docker: "docker",
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// GetStdin returns the input of the commands, nil if they read the stdin of yxa
+func (e *ContainerExecutor) GetStdin() io.Reader {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Stdin
+}
+
+// SetStdin sets the input of the commands, nil to let them read the stdin of yxa
+func (e *ContainerExecutor) SetStdin(r io.Reader) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Stdin = r
+}
+
+// GetStdout returns the stdout writer
+func (e *ContainerExecutor) GetStdout() io.Writer {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Stdout
+}
+
+// GetStderr returns the stderr writer
+func (e *ContainerExecutor) GetStderr() io.Writer {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Stderr
+}
+
+// SetStdout sets the stdout writer
+func (e *ContainerExecutor) SetStdout(w io.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Stdout = w
+}
+
+// SetStderr sets the stderr writer
+func (e *ContainerExecutor) SetStderr(w io.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Stderr = w
+}
+
+// docker returns the Docker CLI to run
+func (e *ContainerExecutor) docker() string {
+	if e.Docker == "" {
+		return "docker"
+	}
+	return e.Docker
+}
+
+// EnsureImage makes the image of the container available according to its pull
+// policy, pulling it if needed. Pull progress is written to stderr.
+func (e *ContainerExecutor) EnsureImage(ctx context.Context) error {
+	switch e.Spec.Pull {
+	case PullNever:
+		return nil
+	case "", PullMissing:
+		inspect := exec.CommandContext(ctx, e.docker(), "image", "inspect", e.Spec.Image) // #nosec G204
+		if inspect.Run() == nil {
+			return nil
+		}
+	case PullAlways:
+	default:
+		return fmt.Errorf("invalid pull policy '%s', must be %s, %s or %s", e.Spec.Pull, PullMissing, PullAlways, PullNever)
+	}
+
+	pull := exec.CommandContext(ctx, e.docker(), "pull", e.Spec.Image) // #nosec G204
+	pull.Stdout = e.GetStderr()
+	pull.Stderr = e.GetStderr()
+	if err := pull.Run(); err != nil {
+		return fmt.Errorf("failed to pull image '%s': %w", e.Spec.Image, err)
+	}
+	return nil
+}
+
+// Prepare makes the image of the container available before commands run in it
+func (e *ContainerExecutor) Prepare(ctx context.Context) error {
+	return e.EnsureImage(ctx)
+}
+
+// runArgs returns the arguments of 'docker run' for a command
+func (e *ContainerExecutor) runArgs(name, cmdStr string, tty bool) []string {
+	args := []string{"run", "--rm", "-i", "--name", name}
+	if tty {
+		args = append(args, "-t")
+	}
+	for _, volume := range e.Spec.Volumes {
+		args = append(args, "-v", absVolume(volume))
+	}
+
+	// Sort the variables so the command line is stable
+	keys := make([]string, 0, len(e.Spec.Env))
+	for key := range e.Spec.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+e.Spec.Env[key])
+	}
+
+	if e.Spec.Workdir != "" {
+		args = append(args, "-w", e.Spec.Workdir)
+	}
+	return append(args, e.Spec.Image, "sh", "-c", cmdStr)
+}
+
+// absVolume makes a relative host path of a bind mount absolute, which older
+// Docker versions require. Named volumes are left as they are.
+func absVolume(volume string) string {
+	host, rest, found := strings.Cut(volume, ":")
+	if !found || !strings.HasPrefix(host, ".") {
+		return volume
+	}
+	abs, err := filepath.Abs(host)
+	if err != nil {
+		return volume
+	}
+	return abs + ":" + rest
+}
+
+// containerName returns a unique name for a container, used to stop it
+func containerName() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return "yxa-" + hex.EncodeToString(b)
+}
+
+// isTerminal reports whether v, a reader or writer, is a terminal
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Execute runs a shell command in a container with optional timeout
+func (e *ContainerExecutor) Execute(cmdStr string, timeout time.Duration) error {
+	return e.ExecuteContext(context.Background(), cmdStr, timeout)
+}
+
+// ExecuteContext runs a shell command in a container with optional timeout,
+// stopping the container when ctx is cancelled. When yxa runs in a terminal, the
+// container gets a TTY.
+func (e *ContainerExecutor) ExecuteContext(ctx context.Context, cmdStr string, timeout time.Duration) error {
+	stdout := e.GetStdout()
+	stderr := e.GetStderr()
+	tty := isTerminal(stdinOrDefault(e.GetStdin())) && isTerminal(stdout)
+
+	return e.run(ctx, cmdStr, timeout, tty, stdout, stderr)
+}
+
+// ExecuteWithOutput runs a shell command in a container and returns its output
+func (e *ContainerExecutor) ExecuteWithOutput(cmdStr string, timeout time.Duration) (string, error) {
+	return e.ExecuteWithOutputContext(context.Background(), cmdStr, timeout)
+}
+
+// ExecuteWithOutputContext runs a shell command in a container and returns its
+// output, stopping the container when ctx is cancelled
+func (e *ContainerExecutor) ExecuteWithOutputContext(ctx context.Context, cmdStr string, timeout time.Duration) (string, error) {
+	var stdoutBuffer bytes.Buffer
+	var stderrBuffer bytes.Buffer
+	stdout := io.MultiWriter(&stdoutBuffer, e.GetStdout())
+	stderr := io.MultiWriter(&stderrBuffer, e.GetStderr())
+
+	// No TTY, it would merge stderr into the captured stdout
+	err := e.run(ctx, cmdStr, timeout, false, stdout, stderr)
+	return stdoutBuffer.String(), err
+}
+
+// run starts a container for the command and waits for it to exit. On timeout or
+// cancellation the container is stopped with 'docker stop', which gives it
+// containerStopTimeout to exit before it is killed.
+func (e *ContainerExecutor) run(ctx context.Context, cmdStr string, timeout time.Duration, tty bool, stdout, stderr io.Writer) error {
+	name := containerName()
+	cmdExec := exec.Command(e.docker(), e.runArgs(name, cmdStr, tty)...) // #nosec G204
+	cmdExec.Stdout = stdout
+	cmdExec.Stderr = stderr
+	cmdExec.Stdin = stdinOrDefault(e.GetStdin())
+
+	if err := cmdExec.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmdExec.Wait()
+	}()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-timeoutC:
+		fmt.Fprintf(os.Stderr, "Command is taking too long, stopping container %s after %s\n", name, timeout)
+		if err := e.stopContainer(name, cmdExec, done); err != nil {
+			return yxaerrors.NewExecutionTimeoutError(timeout, "failed to stop container", err)
+		}
+		return yxaerrors.NewExecutionTimeoutError(timeout, "the container was stopped", nil)
+	case <-ctx.Done():
+		if err := e.stopContainer(name, cmdExec, done); err != nil {
+			return fmt.Errorf("command cancelled and failed to stop container: %v: %w", err, ctx.Err())
+		}
+		return fmt.Errorf("command cancelled: %w", ctx.Err())
+	}
+}
+
+// stopContainer stops a running container and waits for its 'docker run' client
+// to exit, killing the client if it does not exit after the container stopped
+func (e *ContainerExecutor) stopContainer(name string, cmdExec *exec.Cmd, done <-chan error) error {
+	seconds := fmt.Sprint(int(containerStopTimeout.Seconds()))
+	stopErr := exec.Command(e.docker(), "stop", "--time", seconds, name).Run() // #nosec G204
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(gracePeriod):
+		if err := cmdExec.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		<-done
+		if stopErr != nil {
+			return stopErr
+		}
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	yxaerrors "github.com/floppa/yxa-cli/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker is a stand-in for the Docker CLI. It logs its arguments, knows only
+// the image "local:1", and runs the command of 'docker run' on the host.
+const fakeDocker = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$@" >> "$dir/calls.log"
+case "$1" in
+image) [ "$3" = "local:1" ] ;;
+pull) echo "pulling $2" ;;
+stop) kill $(cat "$dir/$4.pid") ;;
+run)
+	while [ "$1" != "--name" ]; do shift; done
+	echo $$ > "$dir/$2.pid"
+	for cmd; do :; done
+	exec sh -c "$cmd"
+	;;
+esac
+`
+
+// newFakeDockerExecutor returns a ContainerExecutor that uses fakeDocker, and the
+// path of the log of its calls
+func newFakeDockerExecutor(t *testing.T, spec ContainerSpec) (*ContainerExecutor, string) {
+	dir := t.TempDir()
+	docker := filepath.Join(dir, "docker")
+	require.NoError(t, os.WriteFile(docker, []byte(fakeDocker), 0700)) // #nosec G306
+
+	e := NewContainerExecutor(spec)
+	e.Docker = docker
+	e.SetStdout(&bytes.Buffer{})
+	e.SetStderr(&bytes.Buffer{})
+	return e, filepath.Join(dir, "calls.log")
+}
+
+func readCalls(t *testing.T, path string) string {
+	data, err := os.ReadFile(path) // #nosec G304
+	if os.IsNotExist(err) {
+		return ""
+	}
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestContainerExecutor_RunArgs(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	e := NewContainerExecutor(ContainerSpec{
+		Image:   "golang:1.24",
+		Volumes: []string{".:/src", "cache:/go/pkg/mod", "/tmp:/tmp:ro"},
+		Env:     map[string]string{"GOOS": "linux", "CGO_ENABLED": "0"},
+		Workdir: "/src",
+	})
+
+	got := e.runArgs("yxa-1", "go build ./...", true)
+	want := []string{
+		"run", "--rm", "-i", "--name", "yxa-1", "-t",
+		"-v", wd + ":/src", "-v", "cache:/go/pkg/mod", "-v", "/tmp:/tmp:ro",
+		"-e", "CGO_ENABLED=0", "-e", "GOOS=linux",
+		"-w", "/src",
+		"golang:1.24", "sh", "-c", "go build ./...",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestContainerExecutor_Execute(t *testing.T) {
+	e, calls := newFakeDockerExecutor(t, ContainerSpec{Image: "local:1"})
+
+	output, err := e.ExecuteWithOutput("echo hello", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+	assert.Equal(t, "hello\n", e.GetStdout().(*bytes.Buffer).String())
+	assert.Contains(t, readCalls(t, calls), "local:1 sh -c echo hello")
+
+	err = e.Execute("exit 3", 0)
+	assert.Error(t, err)
+}
+
+func TestContainerExecutor_EnsureImage(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		pull  string
+		calls []string
+		err   string
+	}{
+		{name: "present", image: "local:1", calls: []string{"image inspect local:1"}},
+		{name: "missing", image: "remote:1", calls: []string{"image inspect remote:1", "pull remote:1"}},
+		{name: "always", image: "local:1", pull: PullAlways, calls: []string{"pull local:1"}},
+		{name: "never", image: "remote:1", pull: PullNever},
+		{name: "invalid", image: "local:1", pull: "sometimes", err: "invalid pull policy 'sometimes'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, calls := newFakeDockerExecutor(t, ContainerSpec{Image: tt.image, Pull: tt.pull})
+
+			err := e.EnsureImage(context.Background())
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(readCalls(t, calls)), "\n") {
+				if line != "" {
+					got = append(got, line)
+				}
+			}
+			assert.Equal(t, tt.calls, got)
+		})
+	}
+}
+
+func TestContainerExecutor_TimeoutStopsContainer(t *testing.T) {
+	e, calls := newFakeDockerExecutor(t, ContainerSpec{Image: "local:1"})
+
+	start := time.Now()
+	err := e.Execute("exec sleep 5", 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 100ms and the container was stopped")
+	var timeoutErr *yxaerrors.ExecutionTimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Regexp(t, `stop --time 5 yxa-[0-9a-f]+`, readCalls(t, calls))
+}
+
+func TestContainerExecutor_Cancel(t *testing.T) {
+	e, calls := newFakeDockerExecutor(t, ContainerSpec{Image: "local:1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err := e.ExecuteContext(ctx, "exec sleep 5", 0)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, readCalls(t, calls), "stop --time 5")
+}
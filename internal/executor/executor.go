@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"sync"
 	"time"
+
+	"github.com/floppa/yxa-cli/internal/errors"
 )
 
 // CommandExecutor defines an interface for executing shell commands
@@ -38,7 +40,11 @@ type CommandExecutor interface {
 type DefaultExecutor struct {
 	Stdout io.Writer
 	Stderr io.Writer
-	mutex  sync.Mutex // Protects concurrent access to Stdout/Stderr
+	Stdin  io.Reader  // Input of the commands, nil for the stdin of yxa
+	Env    []string   // Environment of the commands as KEY=VALUE, nil for the one of yxa
+	Limits Limits     // Resource limits of the commands
+	Stall  Stall      // How commands that stop producing output are handled
+	mutex  sync.Mutex // Protects concurrent access to Stdin/Stdout/Stderr/Env/Limits/Stall
 }
 
 // NewDefaultExecutor creates a new DefaultExecutor with standard output/error
@@ -77,19 +83,104 @@ func (e *DefaultExecutor) SetStderr(w io.Writer) {
 	e.Stderr = w
 }
 
-// executeWithContext is a helper function that executes a command with timeout handling
-// It's used internally by both Execute and ExecuteWithOutput to avoid code duplication
-func executeWithContext(cmd *exec.Cmd, timeout time.Duration) error {
-	// If no timeout is specified, just run the command
-	if timeout == 0 {
-		return cmd.Run()
+// GetStdin returns the input of the commands, nil if they read the stdin of yxa
+func (e *DefaultExecutor) GetStdin() io.Reader {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Stdin
+}
+
+// SetStdin sets the input of the commands, nil to let them read the stdin of yxa
+func (e *DefaultExecutor) SetStdin(r io.Reader) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Stdin = r
+}
+
+// GetEnv returns the environment commands run with, nil if they inherit the one of yxa
+func (e *DefaultExecutor) GetEnv() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Env
+}
+
+// SetEnv sets the environment commands run with, nil to inherit the one of yxa
+func (e *DefaultExecutor) SetEnv(env []string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Env = env
+}
+
+// GetLimits returns the resource limits commands run with
+func (e *DefaultExecutor) GetLimits() Limits {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Limits
+}
+
+// SetLimits sets the resource limits commands run with
+func (e *DefaultExecutor) SetLimits(limits Limits) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Limits = limits
+}
+
+// EnvExecutor is implemented by executors that can run commands with a given
+// environment instead of the one of yxa
+type EnvExecutor interface {
+	// GetEnv returns the environment commands run with, nil if they inherit it
+	GetEnv() []string
+
+	// SetEnv sets the environment commands run with, nil to inherit it
+	SetEnv(env []string)
+}
+
+// StdinExecutor is implemented by executors that can feed commands other input
+// than the stdin of yxa
+type StdinExecutor interface {
+	// GetStdin returns the input of the commands, nil if they read the stdin of yxa
+	GetStdin() io.Reader
+
+	// SetStdin sets the input of the commands, nil to let them read the stdin of yxa
+	SetStdin(r io.Reader)
+}
+
+// stdinOrDefault returns the input of a command: r, or the stdin of yxa if r is nil
+func stdinOrDefault(r io.Reader) io.Reader {
+	if r == nil {
+		return os.Stdin
 	}
+	return r
+}
+
+// ContextExecutor is implemented by executors that stop a running command when a
+// context is cancelled, for example because the user pressed Ctrl-C
+type ContextExecutor interface {
+	// ExecuteContext runs a shell command with optional timeout until ctx is cancelled
+	ExecuteContext(ctx context.Context, cmdStr string, timeout time.Duration) error
 
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	// ExecuteWithOutputContext runs a shell command and returns its output, until ctx is cancelled
+	ExecuteWithOutputContext(ctx context.Context, cmdStr string, timeout time.Duration) (string, error)
+}
 
-	// Start the command
+// gracePeriod is how long a command gets to exit after being interrupted before it is killed
+const gracePeriod = 500 * time.Millisecond
+
+// executeWithContext is a helper function that executes a command with timeout and
+// cancellation handling. It's used internally by both Execute and ExecuteWithOutput
+// to avoid code duplication. stall watches the output of the command for stalls.
+func executeWithContext(ctx context.Context, cmd *exec.Cmd, timeout time.Duration, stall Stall) error {
+	// Record the output of the command to notice when it stops producing any
+	activity := newActivity()
+	cmd.Stdout, cmd.Stderr = stall.watch(activity, cmd.Stdout, cmd.Stderr)
+
+	// Start the command in its own process group, so a timeout or cancellation
+	// also stops the processes it spawns. A command attached to the terminal stays
+	// in the foreground process group of yxa instead, because a background process
+	// that reads from the terminal is stopped. Ctrl-C reaches it from the terminal.
+	if !attachedToTerminal(cmd) {
+		setProcessGroup(cmd)
+	}
 	err := cmd.Start()
 	if err != nil {
 		return err
@@ -101,60 +192,140 @@ func executeWithContext(cmd *exec.Cmd, timeout time.Duration) error {
 		done <- cmd.Wait()
 	}()
 
-	// Wait for either command completion or timeout
-	select {
-	case err := <-done:
-		return err
-	case <-ctx.Done():
-		// Command timed out, try to gracefully terminate it first
-		fmt.Fprintf(os.Stderr, "Command is taking too long, attempting to terminate after %s\n", timeout)
-
-		// First try to send SIGTERM for a graceful shutdown
-		if err := cmd.Process.Signal(os.Interrupt); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to send interrupt signal: %v\n", err)
-		}
+	// A nil channel never fires, so without a timeout only completion and
+	// cancellation are waited for
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	var stallC <-chan time.Time
+	if stall.Timeout > 0 {
+		ticker := time.NewTicker(stallCheckInterval(stall.Timeout))
+		defer ticker.Stop()
+		stallC = ticker.C
+	}
 
-		// Give it a short grace period to terminate
-		graceTimer := time.NewTimer(500 * time.Millisecond)
+	// Wait for command completion, timeout, stall or cancellation
+	warned := false
+	for {
 		select {
 		case err := <-done:
-			graceTimer.Stop()
-			return fmt.Errorf("command timed out after %s and was terminated: %v", timeout, err)
-		case <-graceTimer.C:
-			// Grace period expired, force kill the process
-			fmt.Fprintf(os.Stderr, "Grace period expired, force killing the process\n")
-			if err := cmd.Process.Kill(); err != nil {
-				return fmt.Errorf("command timed out after %s and failed to kill process: %v", timeout, err)
+			return err
+		case <-stallC:
+			if activity.idle() < stall.Timeout {
+				warned = false
+				continue
 			}
+			if !stall.Kill {
+				// Warn once per stall, again only after the command produced output
+				if !warned {
+					fmt.Fprintf(os.Stderr, "Warning: command produced no output for %s\n", stall.Timeout)
+					warned = true
+				}
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Command produced no output for %s, attempting to terminate\n", stall.Timeout)
+
+			exited, err, killErr := stopProcess(cmd, done)
+			if exited {
+				return errors.NewExecutionStallError(stall.Timeout, "was terminated", err)
+			}
+			if killErr != nil {
+				return errors.NewExecutionStallError(stall.Timeout, "failed to kill process", killErr)
+			}
+			return errors.NewExecutionStallError(stall.Timeout, "", nil)
+		case <-timeoutC:
+			// Command timed out, try to gracefully terminate it first
+			fmt.Fprintf(os.Stderr, "Command is taking too long, attempting to terminate after %s\n", timeout)
+
+			exited, err, killErr := stopProcess(cmd, done)
+			if exited {
+				return errors.NewExecutionTimeoutError(timeout, "was terminated", err)
+			}
+			if killErr != nil {
+				return errors.NewExecutionTimeoutError(timeout, "failed to kill process", killErr)
+			}
+			return errors.NewExecutionTimeoutError(timeout, "", nil)
+		case <-ctx.Done():
+			// The run was cancelled, pass the interrupt on to the command
+			if _, _, killErr := stopProcess(cmd, done); killErr != nil {
+				return fmt.Errorf("command cancelled and failed to kill process: %v: %w", killErr, ctx.Err())
+			}
+			return fmt.Errorf("command cancelled: %w", ctx.Err())
 		}
+	}
+}
+
+// attachedToTerminal reports whether a command reads from and writes to the
+// terminal directly, like an interactive program does. Output that yxa captures or
+// prefixes goes through a pipe, which keeps the command in its own process group.
+func attachedToTerminal(cmd *exec.Cmd) bool {
+	return isTerminal(cmd.Stdin) && isTerminal(cmd.Stdout)
+}
 
-		return fmt.Errorf("command timed out after %s", timeout)
+// stopProcess interrupts the process group of a running command and kills it if the
+// command does not exit within the grace period. It reports whether the command
+// exited by itself, with its error. Processes the command left running in its
+// group are ended either way.
+func stopProcess(cmd *exec.Cmd, done <-chan error) (exited bool, err error, killErr error) {
+	// First try to send an interrupt for a graceful shutdown
+	if err := interruptProcessGroup(cmd); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send interrupt signal: %v\n", err)
+	}
+
+	// Give it a short grace period to terminate
+	graceTimer := time.NewTimer(gracePeriod)
+	defer graceTimer.Stop()
+	select {
+	case err := <-done:
+		// The shell exited, but its background processes may have ignored the interrupt
+		return true, err, endProcessGroup(cmd)
+	case <-graceTimer.C:
+		// Grace period expired, force kill the process
+		fmt.Fprintf(os.Stderr, "Grace period expired, force killing the process\n")
+		if err := killProcessGroup(cmd); err != nil {
+			return false, nil, err
+		}
+		<-done
+		return false, nil, nil
 	}
 }
 
 // Execute runs a shell command with optional timeout
 func (e *DefaultExecutor) Execute(cmdStr string, timeout time.Duration) error {
+	return e.ExecuteContext(context.Background(), cmdStr, timeout)
+}
+
+// ExecuteContext runs a shell command with optional timeout, stopping it when ctx is cancelled
+func (e *DefaultExecutor) ExecuteContext(ctx context.Context, cmdStr string, timeout time.Duration) error {
 	// Lock to safely access stdout/stderr
 	e.mutex.Lock()
 
 	// Create a command
-	cmdExec := exec.Command("sh", "-c", cmdStr) // #nosec G204
+	cmdExec := shellCommand(e.Limits, cmdStr)
 	cmdExec.Stdout = e.Stdout
 	cmdExec.Stderr = e.Stderr
-	cmdExec.Stdin = os.Stdin
+	cmdExec.Stdin = stdinOrDefault(e.Stdin)
+	cmdExec.Env = e.Env
+	stall := e.Stall
 
 	// Unlock after setting up the command
 	e.mutex.Unlock()
 
-	// Execute the command with timeout handling
-	return executeWithContext(cmdExec, timeout)
+	// Execute the command with timeout and cancellation handling
+	return executeWithContext(ctx, cmdExec, timeout, stall)
 }
 
 // ExecuteWithOutput runs a shell command and returns its output
 func (e *DefaultExecutor) ExecuteWithOutput(cmdStr string, timeout time.Duration) (string, error) {
-	// For thread safety, we need to use a different approach than Execute
-	// We'll create a separate command and buffer for this operation
+	return e.ExecuteWithOutputContext(context.Background(), cmdStr, timeout)
+}
 
+// ExecuteWithOutputContext runs a shell command and returns its output, stopping it
+// when ctx is cancelled
+func (e *DefaultExecutor) ExecuteWithOutputContext(ctx context.Context, cmdStr string, timeout time.Duration) (string, error) {
 	// Create buffers to capture output
 	var stdoutBuffer bytes.Buffer
 	var stderrBuffer bytes.Buffer
@@ -163,45 +334,23 @@ func (e *DefaultExecutor) ExecuteWithOutput(cmdStr string, timeout time.Duration
 	e.mutex.Lock()
 	stdout := e.Stdout
 	stderr := e.Stderr
+	stdin := e.Stdin
+	env := e.Env
+	limits := e.Limits
+	stall := e.Stall
 	e.mutex.Unlock()
 
-	// For no timeout case, use a simpler approach to avoid race conditions
-	if timeout == 0 {
-		// Create and configure the command
-		cmdExec := exec.Command("sh", "-c", cmdStr) // #nosec G204
-
-		// Set up a multi-writer to capture output and also write to the original writers
-		cmdExec.Stdout = io.MultiWriter(&stdoutBuffer, stdout)
-		cmdExec.Stderr = io.MultiWriter(&stderrBuffer, stderr)
-		cmdExec.Stdin = os.Stdin
-
-		// Run the command and wait for it to complete
-		err := cmdExec.Run()
-
-		// Return only the stdout content
-		return stdoutBuffer.String(), err
-	}
-
-	// For timeout case, we need to handle it carefully
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	// Create and configure the command with context
-	cmdExec := exec.CommandContext(ctx, "sh", "-c", cmdStr) // #nosec G204
+	// Create and configure the command
+	cmdExec := shellCommand(limits, cmdStr)
+	cmdExec.Env = env
 
 	// Set up a multi-writer to capture output and also write to the original writers
 	cmdExec.Stdout = io.MultiWriter(&stdoutBuffer, stdout)
 	cmdExec.Stderr = io.MultiWriter(&stderrBuffer, stderr)
-	cmdExec.Stdin = os.Stdin
+	cmdExec.Stdin = stdinOrDefault(stdin)
 
 	// Run the command and wait for it to complete
-	err := cmdExec.Run()
-
-	// Check if the context was canceled (timeout)
-	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("command timed out after %s", timeout)
-	}
+	err := executeWithContext(ctx, cmdExec, timeout, stall)
 
 	// Return only the stdout content
 	return stdoutBuffer.String(), err
@@ -2,6 +2,8 @@ package executor
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"strings"
@@ -107,6 +109,52 @@ func TestDefaultExecutor_Execute_Timeout(t *testing.T) {
 	}
 }
 
+func TestDefaultExecutor_ExecuteContext_Cancel(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	e := &DefaultExecutor{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := e.ExecuteContext(ctx, "sleep 5", 0)
+	if err == nil {
+		t.Fatalf("Expected cancellation error, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Cancelled command took %s to stop", elapsed)
+	}
+}
+
+func TestDefaultExecutor_Execute_TimeoutStopsChildren(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	e := &DefaultExecutor{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	// The shell waits for sleep, which holds the output pipe open until it is stopped
+	start := time.Now()
+	err := e.Execute("sleep 5; echo done", 100*time.Millisecond)
+	if err == nil {
+		t.Fatalf("Expected timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Timed out command took %s to stop, children were not terminated", elapsed)
+	}
+	if strings.Contains(stdout.String(), "done") {
+		t.Errorf("Command continued after the timeout, stdout: %q", stdout.String())
+	}
+}
+
 func TestDefaultExecutor_Execute_InvalidCommand(t *testing.T) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -312,3 +360,53 @@ func TestExecuteWithTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultExecutor_Env(t *testing.T) {
+	t.Setenv("YXA_TEST_HOST_VAR", "host")
+	executor := NewDefaultExecutor()
+	executor.SetStderr(io.Discard)
+	var _ EnvExecutor = executor
+
+	// Without an environment the one of the process is inherited
+	output, err := executor.ExecuteWithOutput("echo \"[$YXA_TEST_HOST_VAR]\"", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "[host]\n", output)
+
+	executor.SetEnv([]string{"GREETING=hello"})
+	assert.Equal(t, []string{"GREETING=hello"}, executor.GetEnv())
+	output, err = executor.ExecuteWithOutput("echo \"[$YXA_TEST_HOST_VAR] $GREETING\"", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "[] hello\n", output)
+}
+
+func TestDefaultExecutor_Stdin(t *testing.T) {
+	executor := NewDefaultExecutor()
+	executor.SetStderr(io.Discard)
+	var _ StdinExecutor = executor
+	assert.Nil(t, executor.GetStdin())
+
+	executor.SetStdin(strings.NewReader("select 1;\n"))
+	output, err := executor.ExecuteWithOutput("cat", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "select 1;\n", output)
+
+	var stdout bytes.Buffer
+	executor.SetStdout(&stdout)
+	executor.SetStdin(strings.NewReader("a\nb\n"))
+	assert.NoError(t, executor.Execute("wc -l", 0))
+	assert.Equal(t, "2", strings.TrimSpace(stdout.String()))
+}
+
+func TestAttachedToTerminal(t *testing.T) {
+	cmd := shellCommand(Limits{}, "true")
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stdout = &bytes.Buffer{}
+	assert.False(t, attachedToTerminal(cmd), "pipes are no terminal")
+
+	// Commands that are not attached to the terminal get a process group, which
+	// timeouts stop as a whole
+	assert.NoError(t, executeWithContext(context.Background(), cmd, 0, Stall{}))
+	if cmd.SysProcAttr != nil {
+		assert.True(t, cmd.SysProcAttr.Setpgid)
+	}
+}
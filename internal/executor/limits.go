@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"os/exec"
+)
+
+// Limits are the resource limits commands run with. The zero value runs commands
+// without limits.
+type Limits struct {
+	Nice   int     // Adjustment of the scheduling priority, 0 to keep that of yxa
+	CPUs   float64 // Number of CPUs the command may use, e.g. 1.5, 0 for no limit
+	Memory int64   // Maximum memory in bytes, 0 for no limit
+	Umask  string  // File mode creation mask in octal, e.g. 0022, empty to keep that of yxa
+	User   string  // User to run the commands as with sudo, empty for the user of yxa
+}
+
+// IsZero reports whether no limit is set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// LimitExecutor is implemented by executors that can run commands with resource
+// limits
+type LimitExecutor interface {
+	// GetLimits returns the limits commands run with
+	GetLimits() Limits
+
+	// SetLimits sets the limits commands run with
+	SetLimits(limits Limits)
+}
+
+// Unenforced returns the limits of l that cannot be enforced on this platform,
+// by their yxa.yml name, with the reason. Commands still run without them.
+func Unenforced(l Limits) map[string]string {
+	return unenforcedLimits(l)
+}
+
+// shellCommand creates the command that runs cmdStr with sh, within the limits
+func shellCommand(l Limits, cmdStr string) *exec.Cmd {
+	argv := limitedArgv(l, []string{"sh", "-c", cmdStr})
+	return exec.Command(argv[0], argv[1:]...) // #nosec G204
+}
@@ -0,0 +1,99 @@
+//go:build !windows
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitedArgv(t *testing.T) {
+	origCgroups, origUlimit, origNice := cgroupsAvailable, ulimitMemoryAvailable, niceAvailable
+	defer func() { cgroupsAvailable, ulimitMemoryAvailable, niceAvailable = origCgroups, origUlimit, origNice }()
+	ulimitMemoryAvailable = func() bool { return true }
+	niceAvailable = func() bool { return true }
+	argv := []string{"sh", "-c", "make"}
+
+	assert.Equal(t, argv, limitedArgv(Limits{}, argv))
+
+	cgroupsAvailable = func() bool { return true }
+	assert.Equal(t, []string{"systemd-run", "--user", "--scope", "--quiet", "-p", "MemoryMax=536870912", "-p", "CPUQuota=150%",
+		"nice", "-n", "10", "sh", "-c", "make"},
+		limitedArgv(Limits{Nice: 10, CPUs: 1.5, Memory: 512 << 20}, argv))
+	assert.Empty(t, Unenforced(Limits{Nice: 10, CPUs: 1.5, Memory: 512 << 20}))
+
+	cgroupsAvailable = func() bool { return false }
+	assert.Equal(t, []string{"sh", "-c", `ulimit -v "$0" && exec "$@"`, "524288", "sh", "-c", "make"},
+		limitedArgv(Limits{CPUs: 2, Memory: 512 << 20}, argv))
+	assert.Equal(t, map[string]string{"cpu_limit": "needs cgroups through systemd-run --user"}, Unenforced(Limits{CPUs: 2, Memory: 512 << 20}))
+
+	// Without ulimit -v, as on macOS, the command runs without the memory limit
+	ulimitMemoryAvailable = func() bool { return false }
+	assert.Equal(t, argv, limitedArgv(Limits{Memory: 512 << 20}, argv))
+	assert.Contains(t, Unenforced(Limits{Memory: 512 << 20}), "memory_limit")
+	cgroupsAvailable = func() bool { return true }
+	assert.Empty(t, Unenforced(Limits{Memory: 512 << 20}), "cgroups do not need ulimit -v")
+	cgroupsAvailable = func() bool { return false }
+	ulimitMemoryAvailable = func() bool { return true }
+
+	assert.Equal(t, []string{"nice", "-n", "5", "sh", "-c", `umask "$0" && exec "$@"`, "0077", "sh", "-c", "make"},
+		limitedArgv(Limits{Nice: 5, Umask: "0077"}, argv))
+	assert.Empty(t, Unenforced(Limits{Umask: "0077"}))
+
+	assert.Equal(t, []string{"sudo", "-n", "-u", "root", "--", "nice", "-n", "-5", "sh", "-c", "make"},
+		limitedArgv(Limits{Nice: -5, User: "root"}, argv))
+	assert.Empty(t, Unenforced(Limits{User: "deploy"}))
+
+	niceAvailable = func() bool { return false }
+	assert.Equal(t, argv, limitedArgv(Limits{Nice: 5}, argv))
+	assert.Contains(t, Unenforced(Limits{Nice: 5}), "nice")
+}
+
+func TestDefaultExecutor_Limits(t *testing.T) {
+	if _, err := exec.LookPath("nice"); err != nil {
+		t.Skip("nice is not available")
+	}
+	if !ulimitMemoryAvailable() {
+		t.Skip("ulimit -v is not supported")
+	}
+	origCgroups := cgroupsAvailable
+	defer func() { cgroupsAvailable = origCgroups }()
+	cgroupsAvailable = func() bool { return false }
+
+	executor := NewDefaultExecutor()
+	executor.SetStderr(&bytes.Buffer{})
+	var _ LimitExecutor = executor
+
+	base, err := executor.ExecuteWithOutput("nice", 0)
+	require.NoError(t, err)
+	baseNice, err := strconv.Atoi(strings.TrimSpace(base))
+	require.NoError(t, err)
+
+	executor.SetLimits(Limits{Nice: 5, Memory: 1 << 30})
+	assert.Equal(t, Limits{Nice: 5, Memory: 1 << 30}, executor.GetLimits())
+	output, err := executor.ExecuteWithOutput("nice; ulimit -v", 0)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(min(baseNice+5, 19))+"\n1048576\n", output)
+}
+
+func TestDefaultExecutor_Umask(t *testing.T) {
+	dir := t.TempDir()
+	executor := NewDefaultExecutor()
+	executor.SetStderr(&bytes.Buffer{})
+	executor.SetLimits(Limits{Umask: "0077"})
+
+	output, err := executor.ExecuteWithOutput("umask; touch "+filepath.Join(dir, "artifact"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "0077\n", output)
+	info, err := os.Stat(filepath.Join(dir, "artifact"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,88 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// cgroupsAvailable reports whether commands can be run in a transient systemd
+// scope with cgroup limits, which needs systemd-run and a user session
+var cgroupsAvailable = sync.OnceValue(func() bool {
+	if runtime.GOOS != "linux" || os.Getenv("XDG_RUNTIME_DIR") == "" {
+		return false
+	}
+	_, err := exec.LookPath("systemd-run")
+	return err == nil
+})
+
+// ulimitMemoryAvailable reports whether ulimit -v can limit the memory of commands.
+// macOS does not support limiting virtual memory, so ulimit -v with a value fails.
+var ulimitMemoryAvailable = sync.OnceValue(func() bool {
+	return runtime.GOOS != "darwin"
+})
+
+// niceAvailable reports whether the nice command is on PATH
+var niceAvailable = sync.OnceValue(func() bool {
+	_, err := exec.LookPath("nice")
+	return err == nil
+})
+
+// unenforcedLimits returns the limits that cannot be enforced: the CPU limit needs
+// cgroups, memory falls back to ulimit -v where it is supported and nice needs the
+// nice command
+func unenforcedLimits(l Limits) map[string]string {
+	unenforced := make(map[string]string)
+	if l.CPUs > 0 && !cgroupsAvailable() {
+		unenforced["cpu_limit"] = "needs cgroups through systemd-run --user"
+	}
+	if l.Memory > 0 && !cgroupsAvailable() && !ulimitMemoryAvailable() {
+		unenforced["memory_limit"] = "needs cgroups through systemd-run --user, ulimit -v is not supported on " + runtime.GOOS
+	}
+	if l.Nice != 0 && !niceAvailable() {
+		unenforced["nice"] = "the nice command is not on PATH"
+	}
+	return unenforced
+}
+
+// limitedArgv wraps the argv of a command so that it runs within the limits: in a
+// systemd scope with MemoryMax and CPUQuota where cgroups are available, with
+// ulimit -v for the memory otherwise where it is supported, with nice for the
+// priority, with umask for the file mode creation mask and with sudo as another
+// user. sudo never asks for a password, the credentials must have been cached
+// before.
+func limitedArgv(l Limits, argv []string) []string {
+	if l.Umask != "" {
+		argv = append([]string{"sh", "-c", `umask "$0" && exec "$@"`, l.Umask}, argv...)
+	}
+	if l.Nice != 0 && niceAvailable() {
+		argv = append([]string{"nice", "-n", strconv.Itoa(l.Nice)}, argv...)
+	}
+	if l.User != "" {
+		argv = append([]string{"sudo", "-n", "-u", l.User, "--"}, argv...)
+	}
+	if l.CPUs <= 0 && l.Memory <= 0 {
+		return argv
+	}
+
+	if cgroupsAvailable() {
+		scope := []string{"systemd-run", "--user", "--scope", "--quiet"}
+		if l.Memory > 0 {
+			scope = append(scope, "-p", "MemoryMax="+strconv.FormatInt(l.Memory, 10))
+		}
+		if l.CPUs > 0 {
+			scope = append(scope, "-p", "CPUQuota="+strconv.Itoa(int(l.CPUs*100))+"%")
+		}
+		return append(scope, argv...)
+	}
+	if l.Memory > 0 && ulimitMemoryAvailable() {
+		// ulimit -v takes KiB; the limit is passed as $0 so that argv needs no quoting
+		kib := strconv.FormatInt((l.Memory+1023)/1024, 10)
+		return append([]string{"sh", "-c", `ulimit -v "$0" && exec "$@"`, kib}, argv...)
+	}
+	return argv
+}
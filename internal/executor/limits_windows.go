@@ -0,0 +1,31 @@
+//go:build windows
+
+package executor
+
+// unenforcedLimits returns every limit that is set: Windows would need job
+// objects, which yxa does not create yet
+func unenforcedLimits(l Limits) map[string]string {
+	unenforced := make(map[string]string)
+	reason := "not supported on Windows"
+	if l.Nice != 0 {
+		unenforced["nice"] = reason
+	}
+	if l.CPUs > 0 {
+		unenforced["cpu_limit"] = reason
+	}
+	if l.Memory > 0 {
+		unenforced["memory_limit"] = reason
+	}
+	if l.Umask != "" {
+		unenforced["umask"] = reason
+	}
+	if l.User != "" {
+		unenforced["user"] = reason
+	}
+	return unenforced
+}
+
+// limitedArgv returns argv as is, the limits are not enforced on Windows
+func limitedArgv(l Limits, argv []string) []string {
+	return argv
+}
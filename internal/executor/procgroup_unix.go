@@ -0,0 +1,64 @@
+//go:build !windows
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup starts the command in a process group of its own, so that the
+// processes spawned by the shell can be signalled together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interruptProcessGroup sends SIGINT to the process group of a started command,
+// or to the command itself if it was not started in a group of its own
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGINT)
+}
+
+// killProcessGroup sends SIGKILL to the process group of a started command, or to
+// the command itself if it was not started in a group of its own
+func killProcessGroup(cmd *exec.Cmd) error {
+	return signalProcessGroup(cmd, syscall.SIGKILL)
+}
+
+// endProcessGroup ends the processes left in the process group of a command whose
+// shell exited. Background processes of a non-interactive shell ignore SIGINT, so
+// they get SIGTERM and, if they are still running after the grace period, SIGKILL.
+// A group without processes left is no error.
+func endProcessGroup(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return nil
+	}
+	pgid := -cmd.Process.Pid
+	if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+		return ignoreNoProcess(err)
+	}
+	for deadline := time.Now().Add(gracePeriod); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if err := syscall.Kill(pgid, 0); errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+	}
+	return ignoreNoProcess(syscall.Kill(pgid, syscall.SIGKILL))
+}
+
+// ignoreNoProcess returns err unless it reports that no process was left to signal
+func ignoreNoProcess(err error) error {
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
+
+// signalProcessGroup sends sig to the process group of a started command
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) error {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
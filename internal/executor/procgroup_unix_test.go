@@ -0,0 +1,74 @@
+//go:build !windows
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDefaultExecutor_StopEndsBackgroundProcesses(t *testing.T) {
+	// Background processes of sh ignore SIGINT and the shell exits without them.
+	// The output goes to a file, so waiting for the shell does not wait for them to
+	// close a pipe.
+	output, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatalf("Failed to create output file: %v", err)
+	}
+	defer output.Close()
+
+	tests := []struct {
+		name string
+		run  func(e *DefaultExecutor, cmdStr string) error
+	}{
+		{
+			name: "timeout",
+			run: func(e *DefaultExecutor, cmdStr string) error {
+				return e.Execute(cmdStr, 200*time.Millisecond)
+			},
+		},
+		{
+			name: "cancellation",
+			run: func(e *DefaultExecutor, cmdStr string) error {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(200*time.Millisecond, cancel)
+				return e.ExecuteContext(ctx, cmdStr, 0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pidFile := filepath.Join(t.TempDir(), "pid")
+			e := &DefaultExecutor{Stdout: output, Stderr: output}
+			if err := tt.run(e, "sleep 47 & echo $! > "+pidFile+"; sleep 48"); err == nil {
+				t.Fatal("Expected the command to be stopped, got nil")
+			}
+
+			data, err := os.ReadFile(pidFile)
+			if err != nil {
+				t.Fatalf("Failed to read the pid of the background process: %v", err)
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("Invalid pid %q: %v", data, err)
+			}
+			// The process may take a moment to be reaped after it was killed
+			deadline := time.Now().Add(2 * time.Second)
+			for !errors.Is(syscall.Kill(pid, 0), syscall.ESRCH) {
+				if time.Now().After(deadline) {
+					_ = syscall.Kill(pid, syscall.SIGKILL)
+					t.Fatalf("Background process %d is still running after the command was stopped", pid)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows, which has no process groups to signal
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcessGroup interrupts the started command itself
+func interruptProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+// killProcessGroup kills the started command itself
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// endProcessGroup is a no-op on Windows, the command itself has exited
+func endProcessGroup(cmd *exec.Cmd) error {
+	return nil
+}
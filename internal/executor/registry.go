@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in runners
+const (
+	LocalRunner  = "local"  // Runs commands with sh on the host
+	DockerRunner = "docker" // Runs commands in a Docker container, see ContainerSpec
+)
+
+// Options holds the options of a runner as configured for a command. Decode
+// fills v, usually a pointer to a struct with yaml tags, from the options.
+type Options interface {
+	Decode(v any) error
+}
+
+// Factory creates an executor for a command from the options of its runner.
+// Output writers are set by the caller after the executor is created.
+//
+// Executors created by a factory may implement ContextExecutor to support
+// cancellation and Preparer to do setup work once before a command runs.
+type Factory func(opts Options) (CommandExecutor, error)
+
+// Preparer is implemented by executors that need to do setup work before they
+// run commands, for example pulling an image
+type Preparer interface {
+	Prepare(ctx context.Context) error
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]Factory)
+)
+
+// Register makes a runner available under a name. Builds of yxa that add their
+// own runners call it from an init function. Register panics if the name is
+// empty, the factory is nil or the name is already registered.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if name == "" || factory == nil {
+		panic("executor: Register needs a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("executor: runner '%s' is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Runners returns the names of all registered runners, sorted
+func Runners() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRegistered reports whether a runner with the given name is registered
+func IsRegistered(name string) bool {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	_, ok := registry[name]
+	return ok
+}
+
+// New creates an executor with the runner registered under name. opts may be
+// nil if the runner has no options.
+func New(name string, opts Options) (CommandExecutor, error) {
+	registryMutex.RLock()
+	factory, ok := registry[name]
+	registryMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown runner '%s', available runners: %v", name, Runners())
+	}
+	if opts == nil {
+		opts = noOptions{}
+	}
+	return factory(opts)
+}
+
+// noOptions are the options of a runner that is configured by name only
+type noOptions struct{}
+
+// Decode leaves v unchanged
+func (noOptions) Decode(v any) error {
+	return nil
+}
+
+func init() {
+	Register(LocalRunner, func(Options) (CommandExecutor, error) {
+		return NewDefaultExecutor(), nil
+	})
+	Register(DockerRunner, func(opts Options) (CommandExecutor, error) {
+		var spec ContainerSpec
+		if err := opts.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("invalid options for runner '%s': %w", DockerRunner, err)
+		}
+		if spec.Image == "" {
+			return nil, fmt.Errorf("runner '%s' requires 'image'", DockerRunner)
+		}
+		return NewContainerExecutor(spec), nil
+	})
+}
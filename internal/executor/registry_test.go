@@ -0,0 +1,41 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapOptions are runner options for tests
+type mapOptions map[string]string
+
+// Decode sets the image of a ContainerSpec
+func (o mapOptions) Decode(v any) error {
+	if spec, ok := v.(*ContainerSpec); ok {
+		spec.Image = o["image"]
+	}
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	assert.Subset(t, Runners(), []string{LocalRunner, DockerRunner})
+
+	exec, err := New(LocalRunner, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &DefaultExecutor{}, exec)
+
+	exec, err = New(DockerRunner, mapOptions{"image": "alpine:3"})
+	require.NoError(t, err)
+	require.IsType(t, &ContainerExecutor{}, exec)
+	assert.Equal(t, "alpine:3", exec.(*ContainerExecutor).Spec.Image)
+
+	_, err = New(DockerRunner, nil)
+	assert.EqualError(t, err, "runner 'docker' requires 'image'")
+
+	_, err = New("ssh", nil)
+	assert.EqualError(t, err, "unknown runner 'ssh', available runners: [docker local]")
+
+	assert.Panics(t, func() { Register(LocalRunner, func(Options) (CommandExecutor, error) { return nil, nil }) })
+	assert.Panics(t, func() { Register("", nil) })
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Stall configures how commands that stop producing output are handled. The zero
+// value does not watch the output of commands.
+type Stall struct {
+	Timeout time.Duration // How long a command may produce no output, 0 to not watch it
+	Kill    bool          // Whether a stalled command is stopped, else it is only warned about
+}
+
+// StallExecutor is implemented by executors that can watch the output of commands
+// for stalls
+type StallExecutor interface {
+	// GetStall returns how stalled commands are handled
+	GetStall() Stall
+
+	// SetStall sets how stalled commands are handled
+	SetStall(stall Stall)
+}
+
+// GetStall returns how stalled commands are handled
+func (e *DefaultExecutor) GetStall() Stall {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.Stall
+}
+
+// SetStall sets how stalled commands are handled
+func (e *DefaultExecutor) SetStall(stall Stall) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.Stall = stall
+}
+
+// activityWriter passes writes on to a writer and records when the last one happened
+type activityWriter struct {
+	writer   io.Writer
+	activity *activity
+}
+
+// Write implements io.Writer
+func (w *activityWriter) Write(p []byte) (int, error) {
+	w.activity.touch()
+	return w.writer.Write(p)
+}
+
+// activity is the time of the last output of a command, shared by the writers of
+// its stdout and stderr
+type activity struct {
+	mu   sync.Mutex
+	last time.Time
+	now  func() time.Time
+}
+
+// newActivity creates an activity whose last output is now
+func newActivity() *activity {
+	a := &activity{now: time.Now}
+	a.last = a.now()
+	return a
+}
+
+// touch records output at the current time
+func (a *activity) touch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = a.now()
+}
+
+// idle returns how long ago the last output was
+func (a *activity) idle() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.now().Sub(a.last)
+}
+
+// watch returns the writers of a command that record its output in a, or the
+// given writers if stall does not watch the command
+func (s Stall) watch(a *activity, stdout, stderr io.Writer) (io.Writer, io.Writer) {
+	if s.Timeout <= 0 {
+		return stdout, stderr
+	}
+	return &activityWriter{writer: stdout, activity: a}, &activityWriter{writer: stderr, activity: a}
+}
+
+// stallCheckInterval returns how often the output of a command is checked for a
+// stall, a fraction of the timeout so a stall is noticed soon after it happens
+func stallCheckInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	if interval > time.Second {
+		interval = time.Second
+	}
+	return interval
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package executor
+
+import (
+	"bytes"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultExecutor_StallKill(t *testing.T) {
+	var stdout bytes.Buffer
+	e := &DefaultExecutor{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	e.SetStall(Stall{Timeout: 200 * time.Millisecond, Kill: true})
+	assert.Equal(t, Stall{Timeout: 200 * time.Millisecond, Kill: true}, e.GetStall())
+
+	start := time.Now()
+	err := e.Execute("echo started; sleep 5", 0)
+	require.Error(t, err)
+	var stallErr *errors.ExecutionStallError
+	require.True(t, stderrors.As(err, &stallErr), "got %v", err)
+	assert.Equal(t, 200*time.Millisecond, stallErr.Timeout)
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Equal(t, "started\n", stdout.String())
+}
+
+func TestDefaultExecutor_StallOutputKeepsCommandAlive(t *testing.T) {
+	var stdout bytes.Buffer
+	e := &DefaultExecutor{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	e.SetStall(Stall{Timeout: 300 * time.Millisecond, Kill: true})
+
+	// Longer than the stall timeout in total, but never silent for that long
+	require.NoError(t, e.Execute("for i in 1 2 3 4 5; do echo $i; sleep 0.1; done", 0))
+	assert.Equal(t, "1\n2\n3\n4\n5\n", stdout.String())
+}
+
+func TestDefaultExecutor_StallWarn(t *testing.T) {
+	e := &DefaultExecutor{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	e.SetStall(Stall{Timeout: 50 * time.Millisecond})
+
+	// A warning does not stop the command
+	require.NoError(t, e.Execute("sleep 0.3", 0))
+}
+
+func TestActivity(t *testing.T) {
+	now := time.Unix(0, 0)
+	a := &activity{now: func() time.Time { return now }}
+	a.touch()
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Minute, a.idle())
+
+	var out bytes.Buffer
+	stdout, stderr := Stall{Timeout: time.Second}.watch(a, &out, &out)
+	_, _ = stderr.Write([]byte("x"))
+	assert.Equal(t, time.Duration(0), a.idle())
+	_, _ = stdout.Write([]byte("y"))
+	assert.Equal(t, "xy", out.String())
+
+	unwatched, _ := Stall{}.watch(a, &out, &out)
+	assert.Same(t, &out, unwatched)
+}
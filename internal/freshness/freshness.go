@@ -0,0 +1,167 @@
+// Package freshness tells whether the files a command generates are newer than
+// the files it reads, so that the command can be skipped while they are.
+package freshness
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files returns the files matched by glob patterns relative to base, sorted. A
+// ** segment matches any number of directories, and a matched directory stands
+// for all files below it.
+func Files(base string, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := match(base, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if err := addFiles(m, seen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// UpToDate reports whether every file that generates matches is newer than every
+// file that sources matches. It is false if sources match no files or a pattern
+// of generates matches none, because then there is nothing to compare.
+func UpToDate(base string, sources, generates []string) (bool, error) {
+	if len(sources) == 0 || len(generates) == 0 {
+		return false, nil
+	}
+
+	var oldest time.Time
+	for _, pattern := range generates {
+		files, err := Files(base, []string{pattern})
+		if err != nil {
+			return false, err
+		}
+		if len(files) == 0 {
+			return false, nil
+		}
+		for _, f := range files {
+			info, err := os.Stat(f)
+			if err != nil {
+				return false, err
+			}
+			if oldest.IsZero() || info.ModTime().Before(oldest) {
+				oldest = info.ModTime()
+			}
+		}
+	}
+
+	files, err := Files(base, sources)
+	if err != nil || len(files) == 0 {
+		return false, err
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return false, err
+		}
+		if !oldest.After(info.ModTime()) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// match returns the paths a single pattern matches
+func match(base, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(base, pattern)
+	}
+	pattern = filepath.Clean(pattern)
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		return matches, nil
+	}
+
+	// Walk the directory before the first segment with wildcards
+	segments := strings.Split(pattern, string(filepath.Separator))
+	i := 0
+	for i < len(segments) && !strings.ContainsAny(segments[i], "*?[\\") {
+		i++
+	}
+	root := strings.Join(segments[:i], string(filepath.Separator))
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+	rest := segments[i:]
+	for _, s := range rest {
+		if _, err := filepath.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if matchSegments(rest, strings.Split(rel, string(filepath.Separator))) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// matchSegments reports whether the segments of a path match the segments of a
+// pattern, where ** matches any number of segments
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	ok, _ := filepath.Match(pattern[0], path[0])
+	return ok && matchSegments(pattern[1:], path[1:])
+}
+
+// addFiles adds path to files, or the files below it if it is a directory
+func addFiles(path string, files map[string]bool) error {
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files[p] = true
+		}
+		return nil
+	})
+}
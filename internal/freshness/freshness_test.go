@@ -0,0 +1,86 @@
+package freshness
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func touch(t *testing.T, dir, file string, mtime time.Time) {
+	t.Helper()
+	file = filepath.Join(dir, file)
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+	require.NoError(t, os.Chtimes(file, mtime, mtime))
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for _, file := range []string{"main.go", "go.mod", "cmd/app/main.go", "cmd/app/main_test.go", "web/index.html", "web/css/site.css"} {
+		touch(t, dir, file, now)
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"glob", []string{"*.go"}, []string{"main.go"}},
+		{"double star", []string{"**/*.go"}, []string{"cmd/app/main.go", "cmd/app/main_test.go", "main.go"}},
+		{"double star below directory", []string{"cmd/**/main.go"}, []string{"cmd/app/main.go"}},
+		{"directory", []string{"web"}, []string{"web/css/site.css", "web/index.html"}},
+		{"duplicates", []string{"go.mod", "go.*"}, []string{"go.mod"}},
+		{"no match", []string{"*.rs", "missing/**"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Files(dir, tt.patterns)
+			require.NoError(t, err)
+			want := make([]string, len(tt.want))
+			for i, f := range tt.want {
+				want[i] = filepath.Join(dir, f)
+			}
+			assert.Equal(t, want, files)
+		})
+	}
+
+	_, err := Files(dir, []string{"[", "**/["})
+	assert.Error(t, err)
+}
+
+func TestUpToDate(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		files     map[string]time.Time
+		sources   []string
+		generates []string
+		want      bool
+	}{
+		{"newer generates", map[string]time.Time{"src/a.go": old, "bin/a": now}, []string{"src/**/*.go"}, []string{"bin/a"}, true},
+		{"newer source", map[string]time.Time{"src/a.go": now, "bin/a": old}, []string{"src/*.go"}, []string{"bin/a"}, false},
+		{"same time", map[string]time.Time{"src/a.go": now, "bin/a": now}, []string{"src/*.go"}, []string{"bin/a"}, false},
+		{"one old generated file", map[string]time.Time{"src/a.go": old.Add(time.Minute), "bin/a": now, "bin/b": old}, []string{"src/*.go"}, []string{"bin/*"}, false},
+		{"missing generates", map[string]time.Time{"src/a.go": old, "bin/a": now}, []string{"src/*.go"}, []string{"bin/a", "bin/b"}, false},
+		{"no sources", map[string]time.Time{"bin/a": now}, []string{"src/*.go"}, []string{"bin/a"}, false},
+		{"nothing configured", map[string]time.Time{"bin/a": now}, nil, []string{"bin/a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, mtime := range tt.files {
+				touch(t, dir, file, mtime)
+			}
+			got, err := UpToDate(dir, tt.sources, tt.generates)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package history keeps a small record of the commands run with yxa in a JSON
+// lines file, so later invocations can list them and run them again.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the history file in the state directory
+const FileName = "history.jsonl"
+
+// DefaultLimit is the number of entries a Store keeps when Limit is not set
+const DefaultLimit = 100
+
+// Outcomes of a recorded run
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailure   = "failure"
+	OutcomeCancelled = "cancelled"
+	OutcomeSkipped   = "skipped" // The command did not run, it was up to date or its condition was not met
+)
+
+// Entry is one invocation of a command
+type Entry struct {
+	Command    string    `json:"command"`          // Name of the command, parent:sub for subcommands
+	Args       []string  `json:"args"`             // Arguments of the invocation, without the yxa binary
+	Flags      []string  `json:"flags,omitempty"`  // Global flags among Args, e.g. --set=KEY=VALUE
+	Time       time.Time `json:"time"`             // When the run started
+	Outcome    string    `json:"outcome"`          // OutcomeSuccess, OutcomeFailure, OutcomeCancelled or OutcomeSkipped
+	DurationMS int64     `json:"duration_ms"`      // How long the run took
+	Failed     []string  `json:"failed,omitempty"` // Commands that caused the failure, in order
+}
+
+// Store reads and writes the history file at Path
+type Store struct {
+	Path  string
+	Limit int // Number of entries to keep, DefaultLimit if 0
+}
+
+// NewStore creates a store for the history file in dir
+func NewStore(dir string) *Store {
+	return &Store{Path: filepath.Join(dir, FileName)}
+}
+
+// Load returns the entries of the history, oldest first. A missing file is an
+// empty history; lines that cannot be parsed are skipped.
+func (s *Store) Load() ([]Entry, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Append adds an entry to the history, dropping the oldest entries beyond the
+// limit. The file is replaced atomically, so concurrent readers never see a
+// partial history.
+func (s *Store) Append(e Entry) error {
+	entries, err := s.Load()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, e)
+	limit := s.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(b.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Last returns the most recent entry, and whether the history has one. With
+// failedOnly, only entries of failed runs are considered.
+func (s *Store) Last(failedOnly bool) (Entry, bool, error) {
+	entries, err := s.Load()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !failedOnly || entries[i].Outcome == OutcomeFailure {
+			return entries[i], true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
@@ -0,0 +1,70 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".yxa")
+	s := NewStore(dir)
+
+	entries, err := s.Load()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	_, ok, err := s.Last(false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Append(Entry{Command: "build", Args: []string{"build"}, Time: start, Outcome: OutcomeSuccess, DurationMS: 1200}))
+	require.NoError(t, s.Append(Entry{Command: "ci", Args: []string{"ci", "--keep-going"}, Flags: []string{"--keep-going=true"}, Time: start.Add(time.Minute), Outcome: OutcomeFailure, Failed: []string{"lint", "test"}}))
+	require.NoError(t, s.Append(Entry{Command: "fmt", Args: []string{"fmt"}, Time: start.Add(2 * time.Minute), Outcome: OutcomeSuccess}))
+
+	entries, err = s.Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "build", entries[0].Command)
+	assert.Equal(t, int64(1200), entries[0].DurationMS)
+	assert.True(t, start.Equal(entries[0].Time))
+
+	last, ok, err := s.Last(false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "fmt", last.Command)
+
+	failed, ok, err := s.Last(true)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"lint", "test"}, failed.Failed)
+	assert.Equal(t, []string{"--keep-going=true"}, failed.Flags)
+}
+
+func TestStore_Limit(t *testing.T) {
+	s := NewStore(t.TempDir())
+	s.Limit = 2
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Append(Entry{Command: name, Outcome: OutcomeSuccess}))
+	}
+
+	entries, err := s.Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Command)
+	assert.Equal(t, "c", entries[1].Command)
+}
+
+func TestStore_SkipsInvalidLines(t *testing.T) {
+	s := NewStore(t.TempDir())
+	require.NoError(t, os.WriteFile(s.Path, []byte("{\"command\":\"a\"}\nnot json\n{\"command\":\"b\"}\n"), 0o644))
+
+	entries, err := s.Load()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[1].Command)
+}
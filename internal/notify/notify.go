@@ -0,0 +1,136 @@
+// Package notify tells the user that a command finished: with a desktop
+// notification, a Slack message or a JSON request to a webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of a Result
+const (
+	StatusSuccess   = "success"
+	StatusFailure   = "failure"
+	StatusCancelled = "cancelled"
+)
+
+// Result is the outcome of a command that notifications report
+type Result struct {
+	Command    string        `json:"command"`
+	Project    string        `json:"project,omitempty"`
+	Status     string        `json:"status"` // StatusSuccess, StatusFailure or StatusCancelled
+	Duration   time.Duration `json:"-"`
+	DurationMS int64         `json:"duration_ms"`
+	Failed     []string      `json:"failed,omitempty"` // Commands that caused the failure
+	Error      string        `json:"error,omitempty"`
+}
+
+// Title returns the title of the notification, e.g. "yxa build"
+func (r Result) Title() string {
+	return "yxa " + r.Command
+}
+
+// Message returns a one line summary, e.g. "build failed after 2m3s"
+func (r Result) Message() string {
+	duration := r.Duration.Round(time.Second)
+	if r.Duration < time.Second {
+		duration = r.Duration.Round(time.Millisecond)
+	}
+	switch r.Status {
+	case StatusSuccess:
+		return fmt.Sprintf("%s succeeded in %s", r.Command, duration)
+	case StatusCancelled:
+		return fmt.Sprintf("%s was cancelled after %s", r.Command, duration)
+	}
+	msg := fmt.Sprintf("%s failed after %s", r.Command, duration)
+	if len(r.Failed) > 0 {
+		msg += " (" + strings.Join(r.Failed, ", ") + ")"
+	}
+	return msg
+}
+
+// Desktop shows the result as a desktop notification, with notify-send on Linux
+// and osascript on macOS
+func Desktop(ctx context.Context, r Result) error {
+	name, args, err := desktopCommand(runtime.GOOS, r.Title(), r.Message())
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("desktop notifications need %s: %w", name, err)
+	}
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput() // #nosec G204
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// desktopCommand returns the command that shows a notification on the platform
+func desktopCommand(goos, title, message string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "notify-send", []string{"--app-name=yxa", title, message}, nil
+	}
+	return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// Slack posts the result to the incoming webhook of a Slack channel
+func Slack(ctx context.Context, url string, r Result) error {
+	icon := ":white_check_mark:"
+	if r.Status != StatusSuccess {
+		icon = ":x:"
+	}
+	return post(ctx, url, map[string]string{"text": icon + " " + r.Message()})
+}
+
+// Webhook posts the result as JSON to url
+func Webhook(ctx context.Context, url string, r Result) error {
+	r.DurationMS = r.Duration.Milliseconds()
+	return post(ctx, url, r)
+}
+
+// post sends payload as JSON to url and fails on a response other than 2xx
+func post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Leave out the URL, webhook URLs contain their credentials
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultMessage(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{Result{Command: "build", Status: StatusSuccess, Duration: 2*time.Minute + 3400*time.Millisecond}, "build succeeded in 2m3s"},
+		{Result{Command: "test", Status: StatusFailure, Duration: 250 * time.Millisecond}, "test failed after 250ms"},
+		{Result{Command: "ci", Status: StatusFailure, Duration: time.Second, Failed: []string{"lint", "test"}}, "ci failed after 1s (lint, test)"},
+		{Result{Command: "serve", Status: StatusCancelled, Duration: time.Hour}, "serve was cancelled after 1h0m0s"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.result.Message())
+	}
+	assert.Equal(t, "yxa build", Result{Command: "build"}.Title())
+}
+
+func TestDesktopCommand(t *testing.T) {
+	name, args, err := desktopCommand("linux", "yxa build", "build succeeded in 1s")
+	require.NoError(t, err)
+	assert.Equal(t, "notify-send", name)
+	assert.Equal(t, []string{"--app-name=yxa", "yxa build", "build succeeded in 1s"}, args)
+
+	name, args, err = desktopCommand("darwin", "yxa build", `say "hi"`)
+	require.NoError(t, err)
+	assert.Equal(t, "osascript", name)
+	assert.Equal(t, []string{"-e", `display notification "say \"hi\"" with title "yxa build"`}, args)
+
+	_, _, err = desktopCommand("windows", "yxa build", "")
+	assert.EqualError(t, err, "desktop notifications are not supported on windows")
+}
+
+func TestPost(t *testing.T) {
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("no_service"))
+	}))
+	defer server.Close()
+
+	result := Result{Command: "build", Project: "app", Status: StatusFailure, Duration: 1500 * time.Millisecond, Failed: []string{"build"}, Error: "exit status 1"}
+
+	require.NoError(t, Webhook(context.Background(), server.URL, result))
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, map[string]any{
+		"command":     "build",
+		"project":     "app",
+		"status":      "failure",
+		"duration_ms": float64(1500),
+		"failed":      []any{"build"},
+		"error":       "exit status 1",
+	}, got)
+
+	require.NoError(t, Slack(context.Background(), server.URL, result))
+	assert.JSONEq(t, `{"text": ":x: build failed after 2s (build)"}`, string(body))
+
+	status = http.StatusNotFound
+	assert.EqualError(t, Slack(context.Background(), server.URL, result), "unexpected status 404: no_service")
+
+	// The URL holds the credentials of the webhook and is not part of the error
+	err := Webhook(context.Background(), "http://127.0.0.1:1/services/T000/SECRET", result)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+}
@@ -0,0 +1,364 @@
+// Package scaffold creates new projects from templates: a directory with a
+// yxa.yml snippet, files to copy and a template.yml that declares the variables
+// the template asks for.
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the file of a template that declares its variables. It is not
+// copied into the project.
+const ManifestFile = "template.yml"
+
+// ConfigFile is the yxa.yml snippet of a template, it is merged into the yxa.yml
+// of the project
+const ConfigFile = "yxa.yml"
+
+// TemplateSuffix marks files that are rendered with the variables, the suffix is
+// removed from the created file. Other files are copied as they are.
+const TemplateSuffix = ".tmpl"
+
+// NameVariable holds the name the project was created with
+const NameVariable = "NAME"
+
+// Variable is a value a template asks for before it is rendered
+type Variable struct {
+	Name    string `yaml:"name"`
+	Prompt  string `yaml:"prompt,omitempty"`  // Question asked for the value, the name if not set
+	Default string `yaml:"default,omitempty"` // Value used when the answer is empty
+}
+
+// Manifest is the template.yml of a template
+type Manifest struct {
+	Description string     `yaml:"description,omitempty"`
+	Variables   []Variable `yaml:"variables,omitempty"`
+}
+
+// Template is a template directory on disk
+type Template struct {
+	Dir      string
+	Manifest Manifest
+}
+
+// Load reads the template in dir. The template.yml is optional.
+func Load(dir string) (*Template, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s' not found: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template '%s' is not a directory", dir)
+	}
+
+	t := &Template{Dir: dir}
+	// #nosec G304 -- Reading the manifest of a user specified template is intended
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &t.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of template '%s': %w", ManifestFile, dir, err)
+	}
+	for i, v := range t.Manifest.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("variable %d in %s of template '%s' has no name", i+1, ManifestFile, dir)
+		}
+	}
+	return t, nil
+}
+
+// IsGitSource reports whether a template source is a git repository rather than
+// a local directory
+func IsGitSource(source string) bool {
+	repo, _, _ := strings.Cut(source, "#")
+	return strings.HasPrefix(repo, "git@") || strings.Contains(repo, "://") || strings.HasSuffix(repo, ".git")
+}
+
+// Clone clones the template repository of source into dir. A source ending in
+// #ref checks out that branch or tag instead of the default branch.
+func Clone(ctx context.Context, source, dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is needed for template '%s': %w", source, err)
+	}
+	repo, ref, _ := strings.Cut(source, "#")
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", repo, dir)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...) // #nosec G204
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to clone template '%s': %w: %s", source, err, strings.TrimSpace(stderr.String()))
+	}
+	return os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+// Lookup returns the directory of a local template: name itself if it exists,
+// otherwise the template of that name in one of the template directories
+func Lookup(name string, dirs []string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	if !strings.ContainsAny(name, `/\`) {
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("template '%s' not found", name)
+}
+
+// Dirs returns the directories named templates are looked up in, next to the
+// global config: $XDG_CONFIG_HOME/yxa/templates and ~/.yxa/templates
+func Dirs() []string {
+	var dirs []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, "yxa", "templates"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".yxa", "templates"))
+	}
+	return dirs
+}
+
+// file is a file of a template and where it is created
+type file struct {
+	src    string
+	dest   string
+	render bool
+	mode   fs.FileMode
+}
+
+// Render creates the files of the template in dest with vars as template data and
+// merges its yxa.yml snippet into dest/yxa.yml. Nothing is written if a file of
+// the template already exists in dest or if a command or variable of the snippet
+// is already defined there. It returns the paths it created or changed, relative
+// to dest.
+func (t *Template) Render(dest string, vars map[string]string) ([]string, error) {
+	files, err := t.files(dest, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var snippet []byte
+	// #nosec G304 -- Reading the snippet of a user specified template is intended
+	if data, err := os.ReadFile(filepath.Join(t.Dir, ConfigFile)); err == nil {
+		if snippet, err = render(ConfigFile, data, vars); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Check everything before the first file is written
+	for _, f := range files {
+		if _, err := os.Stat(f.dest); err == nil {
+			return nil, fmt.Errorf("'%s' already exists", f.dest)
+		}
+	}
+	configPath := filepath.Join(dest, ConfigFile)
+	var config []byte
+	if snippet != nil {
+		// #nosec G304 -- Merging into the yxa.yml of the project is intended
+		existing, err := os.ReadFile(configPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if config, err = MergeConfig(existing, snippet); err != nil {
+			return nil, fmt.Errorf("failed to merge %s into '%s': %w", ConfigFile, configPath, err)
+		}
+	}
+
+	var created []string
+	for _, f := range files {
+		if err := f.write(vars); err != nil {
+			return created, err
+		}
+		rel, _ := filepath.Rel(dest, f.dest)
+		created = append(created, rel)
+	}
+	if config != nil {
+		if err := os.MkdirAll(dest, 0750); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(configPath, config, 0600); err != nil {
+			return created, err
+		}
+		created = append(created, ConfigFile)
+	}
+	sort.Strings(created)
+	return created, nil
+}
+
+// files returns the files of the template other than its manifest and yxa.yml.
+// Their paths can reference variables too, e.g. cmd/{{ .NAME }}/main.go.
+func (t *Template) files(dest string, vars map[string]string) ([]file, error) {
+	var files []file
+	err := filepath.WalkDir(t.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(t.Dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel == ManifestFile || rel == ConfigFile {
+			return nil
+		}
+
+		name, err := render(rel, []byte(filepath.ToSlash(rel)), vars)
+		if err != nil {
+			return err
+		}
+		f := file{src: path, dest: filepath.Join(dest, filepath.FromSlash(string(name)))}
+		if strings.HasSuffix(f.dest, TemplateSuffix) {
+			f.dest = strings.TrimSuffix(f.dest, TemplateSuffix)
+			f.render = true
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f.mode = info.Mode().Perm()
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// write creates the file, rendering it first if it is a template
+func (f file) write(vars map[string]string) error {
+	// #nosec G304 -- Reading the files of a user specified template is intended
+	data, err := os.ReadFile(f.src)
+	if err != nil {
+		return err
+	}
+	if f.render {
+		if data, err = render(f.src, data, vars); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(f.dest), 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(f.dest, data, f.mode)
+}
+
+// render executes text as a Go text/template with vars as data. Referencing a
+// variable that has no value is an error rather than "<no value>".
+func render(name string, text []byte, vars map[string]string) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", name, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return nil, fmt.Errorf("failed to render template '%s': %w", name, err)
+	}
+	return out.Bytes(), nil
+}
+
+// RenderString renders text, e.g. the default of a variable, with vars as data
+func RenderString(name, text string, vars map[string]string) (string, error) {
+	out, err := render(name, []byte(text), vars)
+	return string(out), err
+}
+
+// mergedSections are the maps of yxa.yml whose entries a snippet adds to
+var mergedSections = map[string]bool{"variables": true, "commands": true, "profiles": true}
+
+// MergeConfig adds the commands, variables and profiles of the snippet to the
+// existing yxa.yml, keeping its comments and order. Other settings of the snippet
+// are only used when the existing file does not set them. Defining a command,
+// variable or profile that already exists is an error.
+func MergeConfig(existing, snippet []byte) ([]byte, error) {
+	var base, add yaml.Node
+	if err := yaml.Unmarshal(existing, &base); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(snippet, &add); err != nil {
+		return nil, fmt.Errorf("invalid snippet: %w", err)
+	}
+	if len(base.Content) == 0 {
+		return snippet, nil
+	}
+	if len(add.Content) == 0 {
+		return existing, nil
+	}
+	baseMap, addMap := base.Content[0], add.Content[0]
+	if baseMap.Kind != yaml.MappingNode || addMap.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a map at the top level")
+	}
+
+	for i := 0; i < len(addMap.Content); i += 2 {
+		key, value := addMap.Content[i], addMap.Content[i+1]
+		current := mappingValue(baseMap, key.Value)
+		switch {
+		case current == nil:
+			baseMap.Content = append(baseMap.Content, key, value)
+		case mergedSections[key.Value]:
+			if current.Kind == yaml.ScalarNode && current.Tag == "!!null" {
+				*current = *value
+				continue
+			}
+			if current.Kind != yaml.MappingNode || value.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("'%s' must be a map", key.Value)
+			}
+			for j := 0; j < len(value.Content); j += 2 {
+				name := value.Content[j].Value
+				if mappingValue(current, name) != nil {
+					return nil, fmt.Errorf("%s '%s' is already defined", strings.TrimSuffix(key.Value, "s"), name)
+				}
+				current.Content = append(current.Content, value.Content[j], value.Content[j+1])
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&base); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil if it is not set
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates the files in dir, the keys are slash separated paths
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := Load(dir)
+	require.NoError(t, err)
+	assert.Empty(t, tmpl.Manifest.Variables)
+
+	writeFiles(t, dir, map[string]string{ManifestFile: `
+description: Go service
+variables:
+  - name: MODULE
+    prompt: Go module path
+    default: example.com/{{ .NAME }}
+  - name: OWNER
+`})
+	tmpl, err = Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Go service", tmpl.Manifest.Description)
+	assert.Equal(t, []Variable{
+		{Name: "MODULE", Prompt: "Go module path", Default: "example.com/{{ .NAME }}"},
+		{Name: "OWNER"},
+	}, tmpl.Manifest.Variables)
+
+	writeFiles(t, dir, map[string]string{ManifestFile: "variables:\n  - prompt: Owner\n"})
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "has no name")
+
+	_, err = Load(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestRender(t *testing.T) {
+	tmplDir := t.TempDir()
+	writeFiles(t, tmplDir, map[string]string{
+		ManifestFile:                   "variables:\n  - name: MODULE\n",
+		ConfigFile:                     "commands:\n  build:\n    run: go build ./cmd/{{ .NAME }}\n",
+		"go.mod.tmpl":                  "module {{ .MODULE }}\n",
+		"cmd/{{ .NAME }}/main.go.tmpl": "package main // {{ .NAME }}\n",
+		"README.md":                    "Uses {{ .MODULE }} literally\n",
+	})
+	tmpl, err := Load(tmplDir)
+	require.NoError(t, err)
+
+	dest := filepath.Join(t.TempDir(), "svc")
+	vars := map[string]string{NameVariable: "svc", "MODULE": "example.com/svc"}
+	created, err := tmpl.Render(dest, vars)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", filepath.Join("cmd", "svc", "main.go"), "go.mod", ConfigFile}, created)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "module example.com/svc\n", read("go.mod"))
+	assert.Equal(t, "package main // svc\n", read("cmd/svc/main.go"))
+	assert.Equal(t, "Uses {{ .MODULE }} literally\n", read("README.md"))
+	assert.Equal(t, "commands:\n  build:\n    run: go build ./cmd/svc\n", read(ConfigFile))
+	assert.NoFileExists(t, filepath.Join(dest, ManifestFile))
+
+	t.Run("existing files are not overwritten", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dest, "go.mod"), []byte("module mine\n"), 0o644))
+		require.NoError(t, os.Remove(filepath.Join(dest, "README.md")))
+		_, err := tmpl.Render(dest, vars)
+		assert.ErrorContains(t, err, "already exists")
+		assert.NoFileExists(t, filepath.Join(dest, "README.md"))
+		assert.Equal(t, "module mine\n", read("go.mod"))
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		_, err := tmpl.Render(t.TempDir(), map[string]string{NameVariable: "svc"})
+		assert.ErrorContains(t, err, "MODULE")
+	})
+}
+
+func TestMergeConfig(t *testing.T) {
+	existing := `name: app
+# shared settings
+variables:
+  GO: go1.24
+commands:
+  test:
+    run: go test ./...
+`
+
+	t.Run("adds commands and variables", func(t *testing.T) {
+		merged, err := MergeConfig([]byte(existing), []byte(`
+name: template
+workingdir: svc
+variables:
+  IMAGE: app
+commands:
+  build:
+    run: go build
+`))
+		require.NoError(t, err)
+		assert.Equal(t, `name: app
+# shared settings
+variables:
+  GO: go1.24
+  IMAGE: app
+commands:
+  test:
+    run: go test ./...
+  build:
+    run: go build
+workingdir: svc
+`, string(merged))
+	})
+
+	t.Run("no existing config", func(t *testing.T) {
+		snippet := "commands:\n  build:\n    run: go build\n"
+		merged, err := MergeConfig(nil, []byte(snippet))
+		require.NoError(t, err)
+		assert.Equal(t, snippet, string(merged))
+	})
+
+	t.Run("existing command", func(t *testing.T) {
+		_, err := MergeConfig([]byte(existing), []byte("commands:\n  test:\n    run: make test\n"))
+		assert.EqualError(t, err, "command 'test' is already defined")
+	})
+
+	t.Run("empty section", func(t *testing.T) {
+		merged, err := MergeConfig([]byte("name: app\ncommands:\n"), []byte("commands:\n  build:\n    run: go build\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "name: app\ncommands:\n  build:\n    run: go build\n", string(merged))
+	})
+}
+
+func TestLookup(t *testing.T) {
+	templates := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(templates, "go-service"), 0o755))
+
+	path, err := Lookup("go-service", []string{filepath.Join(t.TempDir(), "missing"), templates})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(templates, "go-service"), path)
+
+	path, err = Lookup(templates, nil)
+	require.NoError(t, err)
+	assert.Equal(t, templates, path)
+
+	_, err = Lookup("other", []string{templates})
+	assert.EqualError(t, err, "template 'other' not found")
+}
+
+func TestIsGitSource(t *testing.T) {
+	for source, want := range map[string]bool{
+		"https://github.com/acme/templates.git": true,
+		"git@github.com:acme/templates.git":     true,
+		"file:///srv/templates#v1":              true,
+		"../templates/service.git":              true,
+		"go-service":                            false,
+		"./templates/go-service":                false,
+	} {
+		assert.Equal(t, want, IsGitSource(source), source)
+	}
+}
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{ConfigFile: "commands: {}\n"})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "template")
+	git("tag", "v1")
+
+	dir := filepath.Join(t.TempDir(), "template")
+	require.NoError(t, Clone(t.Context(), "file://"+filepath.ToSlash(repo)+"#v1", dir))
+	assert.FileExists(t, filepath.Join(dir, ConfigFile))
+	assert.NoDirExists(t, filepath.Join(dir, ".git"))
+
+	err := Clone(t.Context(), "file://"+filepath.ToSlash(repo)+"#missing", filepath.Join(t.TempDir(), "template"))
+	assert.ErrorContains(t, err, "failed to clone template")
+}
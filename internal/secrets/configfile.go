@@ -0,0 +1,169 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// EncryptedConfigExt ends the name of an encrypted config, e.g. ~/.yxa.yml.enc
+const EncryptedConfigExt = ".enc"
+
+// ConfigKeyEnv is the environment variable that holds the key of encrypted
+// configs, base64 encoded, instead of the keychain, e.g. in CI
+const ConfigKeyEnv = "YXA_CONFIG_KEY"
+
+// configMagic starts an encrypted config, followed by the nonce and the sealed
+// config. It is authenticated along with the config.
+const configMagic = "YXA-ENCRYPTED-CONFIG-1\n"
+
+// configKeySize is the size of the AES-256 key of encrypted configs
+const configKeySize = 32
+
+// ErrNoConfigKey is returned when the key of encrypted configs is neither in
+// $YXA_CONFIG_KEY nor in the keychain
+var ErrNoConfigKey = errors.New("no key for encrypted configs")
+
+// IsEncryptedConfig reports whether the config at path is encrypted, by its name
+func IsEncryptedConfig(path string) bool {
+	return strings.HasSuffix(path, EncryptedConfigExt)
+}
+
+// DecryptConfig decrypts the encrypted config at path with the key of encrypted
+// configs
+func DecryptConfig(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- the path of the global config
+	if err != nil {
+		return nil, err
+	}
+	key, err := ConfigKey()
+	if err != nil {
+		return nil, err
+	}
+	return openConfig(key, data)
+}
+
+// EncryptConfig encrypts data with the key of encrypted configs and replaces the
+// file at path with it, readable only by the user
+func EncryptConfig(path string, data []byte) error {
+	key, err := ConfigKey()
+	if err != nil {
+		return err
+	}
+	return writeConfig(path, key, data)
+}
+
+// EditConfig opens the decrypted config at path in an editor and encrypts it
+// again when the editor exits, only if it changed and validate accepts it. A
+// missing config is created, along with a key in the keychain if there is none.
+func EditConfig(path string, validate func([]byte) error, stdin io.Reader, stdout, stderr io.Writer) error {
+	var plain []byte
+	if _, err := os.Stat(path); err == nil {
+		if plain, err = DecryptConfig(path); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	edited, changed, err := editPlain(path, plain, stdin, stdout, stderr)
+	if err != nil || !changed {
+		return err
+	}
+	if err := validate(edited); err != nil {
+		return fmt.Errorf("%w, %s is unchanged", err, path)
+	}
+	key, err := configKeyOrNew()
+	if err != nil {
+		return err
+	}
+	return writeConfig(path, key, edited)
+}
+
+// ConfigKey returns the key of encrypted configs, from $YXA_CONFIG_KEY or else
+// from the keychain
+func ConfigKey() ([]byte, error) {
+	encoded := os.Getenv(ConfigKeyEnv)
+	if encoded == "" {
+		var err error
+		if encoded, err = keychainLookup(); err != nil {
+			return nil, fmt.Errorf("%w, set %s or store one with 'yxa secret edit --global': %v", ErrNoConfigKey, ConfigKeyEnv, err)
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != configKeySize {
+		return nil, fmt.Errorf("invalid key for encrypted configs, expected %d base64 encoded bytes", configKeySize)
+	}
+	return key, nil
+}
+
+// configKeyOrNew returns the key of encrypted configs, creating a random one and
+// storing it in the keychain if there is none
+func configKeyOrNew() ([]byte, error) {
+	if key, err := ConfigKey(); !errors.Is(err, ErrNoConfigKey) {
+		return key, err
+	}
+	key := make([]byte, configKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keychainStore(base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store the key of encrypted configs in the keychain: %w", err)
+	}
+	return key, nil
+}
+
+// writeConfig encrypts data with key and replaces the file at path with it
+func writeConfig(path string, key, data []byte) error {
+	sealed, err := sealConfig(key, data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, sealed, 0o600)
+}
+
+// sealConfig encrypts a config with AES-256-GCM
+func sealConfig(key, plain []byte) ([]byte, error) {
+	aead, err := configCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append([]byte(configMagic), nonce...)
+	return aead.Seal(sealed, nonce, plain, []byte(configMagic)), nil
+}
+
+// openConfig decrypts a config encrypted by sealConfig
+func openConfig(key, data []byte) ([]byte, error) {
+	aead, err := configCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(string(data), configMagic) || len(data) < len(configMagic)+aead.NonceSize() {
+		return nil, errors.New("not an encrypted yxa config")
+	}
+	data = data[len(configMagic):]
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(configMagic))
+	if err != nil {
+		return nil, errors.New("failed to decrypt, the key does not match the config or it was modified")
+	}
+	return plain, nil
+}
+
+// configCipher returns the AES-256-GCM cipher of encrypted configs
+func configCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeychain puts scripts that stand in for secret-tool and an editor on PATH.
+// The fake secret-tool keeps the stored secret in the file keychain in the
+// returned directory.
+func fakeKeychain(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("the fake keychain stands in for secret-tool")
+	}
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"secret-tool": `store="$(dirname "$0")/keychain"
+case "$1" in
+  store) cat > "$store" ;;
+  lookup) [ -f "$store" ] && cat "$store" ;;
+esac`,
+		"editor": `echo "variables: {TOKEN: abc}" >> "$1"`,
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("VISUAL", filepath.Join(dir, "editor"))
+	t.Setenv(ConfigKeyEnv, "")
+	return dir
+}
+
+func TestEncryptConfig(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", configKeySize)))
+	t.Setenv(ConfigKeyEnv, key)
+	path := filepath.Join(t.TempDir(), ".yxa.yml.enc")
+	assert.True(t, IsEncryptedConfig(path))
+	assert.False(t, IsEncryptedConfig(filepath.Join(filepath.Dir(path), ".yxa.yml")))
+
+	require.NoError(t, EncryptConfig(path, []byte("variables:\n  TOKEN: abc\n")))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), configMagic))
+	assert.NotContains(t, string(data), "TOKEN")
+
+	plain, err := DecryptConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "variables:\n  TOKEN: abc\n", string(plain))
+
+	t.Run("wrong key", func(t *testing.T) {
+		t.Setenv(ConfigKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", configKeySize))))
+		_, err := DecryptConfig(path)
+		assert.ErrorContains(t, err, "the key does not match the config")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		t.Setenv(ConfigKeyEnv, "c2hvcnQ=")
+		_, err := DecryptConfig(path)
+		assert.ErrorContains(t, err, "invalid key for encrypted configs")
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		t.Setenv(ConfigKeyEnv, "")
+		t.Setenv("PATH", t.TempDir())
+		_, err := DecryptConfig(path)
+		assert.True(t, errors.Is(err, ErrNoConfigKey), "got %v", err)
+	})
+}
+
+func TestEditConfig(t *testing.T) {
+	tools := fakeKeychain(t)
+	path := filepath.Join(t.TempDir(), ".yxa.yml.enc")
+	accept := func([]byte) error { return nil }
+
+	// A missing config is created with a new key in the keychain
+	require.NoError(t, EditConfig(path, accept, nil, os.Stdout, os.Stderr))
+	stored, err := os.ReadFile(filepath.Join(tools, "keychain"))
+	require.NoError(t, err)
+	key, err := base64.StdEncoding.DecodeString(string(stored))
+	require.NoError(t, err)
+	assert.Len(t, key, configKeySize)
+
+	plain, err := DecryptConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "variables: {TOKEN: abc}\n", string(plain))
+
+	// Editing again keeps the key
+	require.NoError(t, EditConfig(path, accept, nil, os.Stdout, os.Stderr))
+	again, err := os.ReadFile(filepath.Join(tools, "keychain"))
+	require.NoError(t, err)
+	assert.Equal(t, stored, again)
+	plain, err = DecryptConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "variables: {TOKEN: abc}\nvariables: {TOKEN: abc}\n", string(plain))
+
+	// A config that is not accepted is not written
+	err = EditConfig(path, func([]byte) error { return errors.New("duplicate key") }, nil, os.Stdout, os.Stderr)
+	assert.EqualError(t, err, "duplicate key, "+path+" is unchanged")
+	unchanged, err := DecryptConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, plain, unchanged)
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Service and account the key of encrypted configs is stored under in the keychain
+const (
+	keychainService = "yxa"
+	keychainAccount = "config-key"
+	keychainLabel   = "yxa encrypted config key"
+)
+
+// keychainLookup returns the secret stored for yxa in the keychain of the OS: the
+// login keychain on macOS and the Secret Service, such as GNOME Keyring or
+// KWallet, on Linux
+func keychainLookup() (string, error) {
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = run(nil, "security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "windows":
+		return "", errNoKeychain()
+	default:
+		out, err = run(nil, "secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	}
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", errors.New("the keychain holds no key")
+	}
+	return secret, nil
+}
+
+// keychainStore stores secret for yxa in the keychain of the OS, replacing the
+// one stored before. The secret is passed on stdin, so that it does not show up
+// in the process list.
+func keychainStore(secret string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %s\n", keychainService, keychainAccount, keychainLabel, secret)
+		_, err = run(strings.NewReader(cmd), "security", "-i")
+	case "windows":
+		return errNoKeychain()
+	default:
+		_, err = run(strings.NewReader(secret), "secret-tool", "store", "--label="+keychainLabel, "service", keychainService, "account", keychainAccount)
+	}
+	return err
+}
+
+// errNoKeychain reports that yxa cannot use the keychain of the OS
+func errNoKeychain() error {
+	return fmt.Errorf("the keychain of %s is not supported, set %s instead", runtime.GOOS, ConfigKeyEnv)
+}
@@ -0,0 +1,248 @@
+// Package secrets decrypts the encrypted variables file of a config with sops or
+// age, and edits it with re-encryption on save. Both tools are run as external
+// commands, so yxa works without them as long as no file needs to be decrypted.
+// It also encrypts whole configs, such as the global config, with a key kept in
+// the keychain of the OS.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of encrypted variables files
+const (
+	FormatSops = "sops" // YAML file encrypted with sops, with any of its key types
+	FormatAge  = "age"  // YAML file encrypted as a whole with age, ending in .age
+)
+
+// ageArmorHeader starts age files in the ASCII armored format
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// Format returns the format of an encrypted variables file, age for files ending in
+// .age and sops for any other file
+func Format(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".age") {
+		return FormatAge
+	}
+	return FormatSops
+}
+
+// Decrypt decrypts the file at path and returns the variables it defines, a map
+// of names to values. It fails if the tool of its format is not installed or the
+// key to decrypt it is not available.
+func Decrypt(path string) (map[string]string, error) {
+	var data []byte
+	var err error
+	switch Format(path) {
+	case FormatAge:
+		data, err = decryptAge(path)
+	default:
+		data, err = run(nil, "sops", "--decrypt", "--output-type", "yaml", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+// Edit opens the decrypted file at path in an editor and encrypts it again when
+// the editor exits. sops runs the editor itself; age files are decrypted to a
+// private temporary file that is removed afterwards and re-encrypted for the
+// recipient of the age identity, only if they changed. A missing age file is
+// created.
+func Edit(path string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if Format(path) == FormatSops {
+		if _, err := exec.LookPath("sops"); err != nil {
+			return fmt.Errorf("editing %s needs sops: %w", path, err)
+		}
+		cmd := exec.Command("sops", "edit", path) // #nosec G204 -- the path comes from the config
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("sops failed to edit %s: %w", path, err)
+		}
+		return nil
+	}
+	return editAge(path, stdin, stdout, stderr)
+}
+
+// editAge implements Edit for age files
+func editAge(path string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var plain []byte
+	armored := false
+	if existing, err := os.ReadFile(path); err == nil { // #nosec G304 -- the path comes from the config
+		armored = bytes.HasPrefix(existing, []byte(ageArmorHeader))
+		if plain, err = decryptAge(path); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	edited, changed, err := editPlain(path, plain, stdin, stdout, stderr)
+	if err != nil || !changed {
+		return err
+	}
+	if _, err := parse(edited); err != nil {
+		return fmt.Errorf("%w, %s is unchanged", err, path)
+	}
+	return encryptAge(path, edited, armored)
+}
+
+// editPlain opens plain, the decrypted content of the file at path, in the editor
+// of the user from a private temporary file that is removed afterwards. It
+// returns the edited content and whether it changed.
+func editPlain(path string, plain []byte, stdin io.Reader, stdout, stderr io.Writer) ([]byte, bool, error) {
+	dir, err := os.MkdirTemp("", "yxa-secret-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	tmp := filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if err := os.WriteFile(tmp, plain, 0o600); err != nil {
+		return nil, false, err
+	}
+
+	editor := strings.Fields(editorCommand())
+	cmd := exec.Command(editor[0], append(editor[1:], tmp)...) // #nosec G204 -- the editor is chosen by the user
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return nil, false, fmt.Errorf("editor %s failed, %s is unchanged: %w", editor[0], path, err)
+	}
+
+	edited, err := os.ReadFile(tmp) // #nosec G304 -- the temporary file created above
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(edited, plain) {
+		fmt.Fprintf(stdout, "No changes, %s is unchanged\n", path)
+		return nil, false, nil
+	}
+	return edited, true, nil
+}
+
+// editorCommand returns the editor of the user, $VISUAL or $EDITOR, vi if neither
+// is set
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// decryptAge decrypts an age file with the identity of the user
+func decryptAge(path string) ([]byte, error) {
+	identity, err := ageIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return run(nil, "age", "--decrypt", "--identity", identity, path)
+}
+
+// encryptAge encrypts data for the recipients of the identity of the user and
+// replaces the file at path with it
+func encryptAge(path string, data []byte, armored bool) error {
+	identity, err := ageIdentity()
+	if err != nil {
+		return err
+	}
+	recipients, err := run(nil, "age-keygen", "-y", identity)
+	if err != nil {
+		return err
+	}
+	args := []string{"--encrypt"}
+	if armored {
+		args = append(args, "--armor")
+	}
+	for _, recipient := range strings.Fields(string(recipients)) {
+		args = append(args, "--recipient", recipient)
+	}
+	encrypted, err := run(bytes.NewReader(data), "age", args...)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return writeFileAtomic(path, encrypted, mode)
+}
+
+// writeFileAtomic replaces the file at path with data, so that it is never left
+// partially written
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ageIdentity returns the path of the age identity of the user: $YXA_AGE_KEY_FILE,
+// $SOPS_AGE_KEY_FILE or the keys.txt that sops uses by default
+func ageIdentity() (string, error) {
+	var candidates []string
+	for _, name := range []string{"YXA_AGE_KEY_FILE", "SOPS_AGE_KEY_FILE"} {
+		if path := os.Getenv(name); path != "" {
+			candidates = append(candidates, path)
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "sops", "age", "keys.txt"))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no age identity found, set YXA_AGE_KEY_FILE to the file with your key")
+}
+
+// run runs a tool with the given input and returns its output
+func run(stdin io.Reader, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...) // #nosec G204 -- fixed tools with paths from the config
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parse reads the variables of a decrypted file, a YAML map of names to scalar
+// values
+func parse(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	if err := yaml.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("encrypted variables must be a YAML map of names to values: %w", err)
+	}
+	return vars, nil
+}
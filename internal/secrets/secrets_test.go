@@ -0,0 +1,168 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTools puts scripts that stand in for sops, age and age-keygen on PATH. The
+// fake age "encrypts" by prefixing the plaintext with a header line, and both
+// tools log their arguments to args.log in the returned directory.
+func fakeTools(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"sops": `echo "sops $*" >> "$(dirname "$0")/args.log"
+[ "$1" = "--decrypt" ] || exit 0
+sed 's/ENC\[\(.*\)\]/\1/' "$4"`,
+		"age": `echo "age $*" >> "$(dirname "$0")/args.log"
+if [ "$1" = "--decrypt" ]; then
+  [ -f "$3" ] || { echo "no identity" >&2; exit 1; }
+  tail -n +2 "$4"
+else
+  echo "FAKE-AGE"
+  cat
+fi`,
+		"age-keygen": `echo age1recipient`,
+		"editor":     `echo "ADDED: new" >> "$1"`,
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, FormatSops, Format("secrets.enc.yaml"))
+	assert.Equal(t, FormatAge, Format("secrets.yml.age"))
+	assert.Equal(t, FormatAge, Format("SECRETS.AGE"))
+}
+
+func TestDecrypt(t *testing.T) {
+	tools := fakeTools(t)
+	dir := t.TempDir()
+	identity := filepath.Join(dir, "keys.txt")
+	require.NoError(t, os.WriteFile(identity, []byte("AGE-SECRET-KEY-1"), 0o600))
+	t.Setenv("YXA_AGE_KEY_FILE", identity)
+
+	t.Run("sops", func(t *testing.T) {
+		path := filepath.Join(dir, "secrets.enc.yml")
+		require.NoError(t, os.WriteFile(path, []byte("DB_PASSWORD: ENC[hunter2]\nPORT: ENC[5432]\n"), 0o600))
+		vars, err := Decrypt(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter2", "PORT": "5432"}, vars)
+	})
+
+	t.Run("age", func(t *testing.T) {
+		path := filepath.Join(dir, "secrets.yml.age")
+		require.NoError(t, os.WriteFile(path, []byte("FAKE-AGE\nAPI_KEY: abc\n"), 0o600))
+		vars, err := Decrypt(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"API_KEY": "abc"}, vars)
+
+		log, err := os.ReadFile(filepath.Join(tools, "args.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(log), "age --decrypt --identity "+identity+" "+path)
+	})
+
+	t.Run("no age identity", func(t *testing.T) {
+		t.Setenv("YXA_AGE_KEY_FILE", filepath.Join(dir, "missing.txt"))
+		t.Setenv("SOPS_AGE_KEY_FILE", "")
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		_, err := Decrypt(filepath.Join(dir, "secrets.yml.age"))
+		assert.ErrorContains(t, err, "no age identity found")
+	})
+
+	t.Run("not a map", func(t *testing.T) {
+		path := filepath.Join(dir, "list.enc.yml")
+		require.NoError(t, os.WriteFile(path, []byte("- a\n- b\n"), 0o600))
+		_, err := Decrypt(path)
+		assert.ErrorContains(t, err, "must be a YAML map")
+	})
+
+	t.Run("tool not installed", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := Decrypt(filepath.Join(dir, "secrets.enc.yml"))
+		assert.ErrorContains(t, err, "sops is not installed")
+	})
+}
+
+func TestEdit(t *testing.T) {
+	tools := fakeTools(t)
+	dir := t.TempDir()
+	identity := filepath.Join(dir, "keys.txt")
+	require.NoError(t, os.WriteFile(identity, []byte("AGE-SECRET-KEY-1"), 0o600))
+	t.Setenv("YXA_AGE_KEY_FILE", identity)
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", filepath.Join(tools, "editor"))
+
+	t.Run("age", func(t *testing.T) {
+		path := filepath.Join(dir, "secrets.yml.age")
+		require.NoError(t, os.WriteFile(path, []byte("FAKE-AGE\nAPI_KEY: abc\n"), 0o640))
+
+		var out strings.Builder
+		require.NoError(t, Edit(path, strings.NewReader(""), &out, &out))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-AGE\nAPI_KEY: abc\nADDED: new\n", string(data))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "the mode of the file is kept")
+
+		log, err := os.ReadFile(filepath.Join(tools, "args.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(log), "age --encrypt --recipient age1recipient")
+	})
+
+	t.Run("new age file", func(t *testing.T) {
+		path := filepath.Join(dir, "new.yml.age")
+		require.NoError(t, Edit(path, strings.NewReader(""), &strings.Builder{}, &strings.Builder{}))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "FAKE-AGE\nADDED: new\n", string(data))
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		t.Setenv("EDITOR", "true")
+		path := filepath.Join(dir, "secrets.yml.age")
+		before, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var out strings.Builder
+		require.NoError(t, Edit(path, strings.NewReader(""), &out, &out))
+		assert.Contains(t, out.String(), "No changes")
+		after, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		editor := filepath.Join(dir, "bad-editor")
+		require.NoError(t, os.WriteFile(editor, []byte("#!/bin/sh\necho '- not a map' > \"$1\"\n"), 0o755))
+		t.Setenv("EDITOR", editor)
+		path := filepath.Join(dir, "secrets.yml.age")
+		before, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		err = Edit(path, strings.NewReader(""), &strings.Builder{}, &strings.Builder{})
+		assert.ErrorContains(t, err, "is unchanged")
+		after, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("sops", func(t *testing.T) {
+		path := filepath.Join(dir, "secrets.enc.yml")
+		require.NoError(t, Edit(path, strings.NewReader(""), &strings.Builder{}, &strings.Builder{}))
+		log, err := os.ReadFile(filepath.Join(tools, "args.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(log), "sops edit "+path)
+	})
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package services
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the service in a session of its own, so it keeps running after
+// yxa exits and does not receive the signals of yxa's terminal
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether a process with the given pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminate sends SIGTERM to the process group of a service
+func terminate(pid int) error {
+	return ignoreGone(syscall.Kill(-pid, syscall.SIGTERM))
+}
+
+// kill sends SIGKILL to the process group of a service
+func kill(pid int) error {
+	return ignoreGone(syscall.Kill(-pid, syscall.SIGKILL))
+}
+
+// ignoreGone ignores the error of signalling a process that already exited
+func ignoreGone(err error) error {
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
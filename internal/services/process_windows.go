@@ -0,0 +1,42 @@
+//go:build windows
+
+package services
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the service in a process group of its own, so it does not receive
+// the Ctrl-C events of yxa's console
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processAlive reports whether a process with the given pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
+
+// terminate stops a service. Windows cannot ask a process to exit, so it is killed.
+func terminate(pid int) error {
+	return kill(pid)
+}
+
+// kill stops a service immediately
+func kill(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return p.Kill()
+}
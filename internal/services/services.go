@@ -0,0 +1,205 @@
+// Package services starts long-running commands in the background and keeps track
+// of them with state files, so later invocations of yxa can inspect and stop them.
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// State is the persisted state of a started service
+type State struct {
+	Name      string    `json:"name"`
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	Log       string    `json:"log"`
+}
+
+// Status is the state of a service together with whether its process is alive
+type Status struct {
+	State
+	Running bool // The process of the service is alive
+	Known   bool // A state file exists, i.e. the service was started and not stopped
+}
+
+// Manager starts and stops services. It keeps state files in Dir/services and
+// the output of every service in Dir/logs.
+type Manager struct {
+	Dir string
+}
+
+// NewManager creates a manager that keeps its files below dir
+func NewManager(dir string) *Manager {
+	return &Manager{Dir: dir}
+}
+
+// statePath returns the path of the state file of a service
+func (m *Manager) statePath(name string) string {
+	return filepath.Join(m.Dir, "services", fileName(name)+".json")
+}
+
+// LogPath returns the path of the log file of a service
+func (m *Manager) LogPath(name string) string {
+	return filepath.Join(m.Dir, "logs", fileName(name)+".log")
+}
+
+// fileName turns a service name into a file name; subcommands use parent:sub
+func fileName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c == ':' || c == '/' || c == '\\' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// Status returns the status of a service
+func (m *Manager) Status(name string) (Status, error) {
+	data, err := os.ReadFile(m.statePath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return Status{State: State{Name: name}}, nil
+	}
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read state of service '%s': %w", name, err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return Status{}, fmt.Errorf("invalid state file for service '%s': %w", name, err)
+	}
+	return Status{State: state, Running: processAlive(state.PID), Known: true}, nil
+}
+
+// List returns the status of every service that has a state file, sorted by name
+func (m *Manager) List() ([]Status, error) {
+	entries, err := os.ReadDir(filepath.Join(m.Dir, "services"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	var statuses []Status
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.Dir, "services", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state of service '%s': %w", strings.TrimSuffix(entry.Name(), ".json"), err)
+		}
+		var state State
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("invalid state file %s: %w", entry.Name(), err)
+		}
+		statuses = append(statuses, Status{State: state, Running: processAlive(state.PID), Known: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+// Start runs cmdStr in the background as the named service. Its output is appended
+// to the log file of the service. Starting a running service is an error.
+func (m *Manager) Start(name, cmdStr string) (State, error) {
+	return m.start(name, cmdStr, exec.Command("sh", "-c", cmdStr)) // #nosec G204
+}
+
+// StartArgs runs the program argv[0] with the arguments argv[1:] in the background
+// as the named service, like Start does with a shell command. The state describes
+// it with description, which can leave out secrets among the arguments.
+func (m *Manager) StartArgs(name string, argv []string, description string) (State, error) {
+	return m.start(name, description, exec.Command(argv[0], argv[1:]...)) // #nosec G204
+}
+
+// start runs cmd in the background as the named service, described by cmdStr
+func (m *Manager) start(name, cmdStr string, cmd *exec.Cmd) (State, error) {
+	status, err := m.Status(name)
+	if err != nil {
+		return State{}, err
+	}
+	if status.Running {
+		return State{}, fmt.Errorf("service '%s' is already running with pid %d", name, status.PID)
+	}
+
+	for _, dir := range []string{filepath.Dir(m.statePath(name)), filepath.Dir(m.LogPath(name))} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return State{}, fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	logFile, err := os.OpenFile(m.LogPath(name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to open log of service '%s': %w", name, err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return State{}, fmt.Errorf("failed to start service '%s': %w", name, err)
+	}
+
+	state := State{
+		Name:      name,
+		PID:       cmd.Process.Pid,
+		Command:   cmdStr,
+		StartedAt: time.Now().UTC(),
+		Log:       m.LogPath(name),
+	}
+
+	// Reap the process when it exits while yxa is still running; after yxa exits
+	// the service is adopted by init
+	go func() { _ = cmd.Wait() }()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return State{}, err
+	}
+	if err := os.WriteFile(m.statePath(name), data, 0600); err != nil {
+		_ = kill(state.PID)
+		return State{}, fmt.Errorf("failed to write state of service '%s': %w", name, err)
+	}
+	return state, nil
+}
+
+// Stop terminates a service and waits up to timeout for it to exit before killing
+// it. Stopping a service that is not running only removes its state file.
+func (m *Manager) Stop(name string, timeout time.Duration) error {
+	status, err := m.Status(name)
+	if err != nil {
+		return err
+	}
+
+	if status.Running {
+		if err := terminate(status.PID); err != nil {
+			return fmt.Errorf("failed to stop service '%s': %w", name, err)
+		}
+		deadline := time.Now().Add(timeout)
+		for processAlive(status.PID) && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if processAlive(status.PID) {
+			if err := kill(status.PID); err != nil {
+				return fmt.Errorf("failed to kill service '%s': %w", name, err)
+			}
+		}
+	}
+
+	if err := os.Remove(m.statePath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state of service '%s': %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFor polls cond until it holds or the timeout expires
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestManager_StartStop(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	status, err := m.Status("api")
+	require.NoError(t, err)
+	assert.False(t, status.Known)
+	assert.False(t, status.Running)
+
+	state, err := m.Start("api", "echo started; exec sleep 30")
+	require.NoError(t, err)
+	assert.Equal(t, "api", state.Name)
+	assert.Positive(t, state.PID)
+
+	status, err = m.Status("api")
+	require.NoError(t, err)
+	assert.True(t, status.Known)
+	assert.True(t, status.Running)
+	assert.Equal(t, state.PID, status.PID)
+
+	_, err = m.Start("api", "sleep 30")
+	assert.ErrorContains(t, err, "service 'api' is already running")
+
+	waitFor(t, func() bool {
+		data, _ := os.ReadFile(m.LogPath("api"))
+		return strings.Contains(string(data), "started")
+	})
+
+	require.NoError(t, m.Stop("api", time.Second))
+	waitFor(t, func() bool { return !processAlive(state.PID) })
+
+	status, err = m.Status("api")
+	require.NoError(t, err)
+	assert.False(t, status.Known)
+}
+
+func TestManager_StopKillsStubbornService(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	state, err := m.Start("db:primary", "trap '' TERM; while true; do sleep 0.1; done")
+	require.NoError(t, err)
+	assert.Contains(t, m.LogPath("db:primary"), "db_primary.log")
+
+	start := time.Now()
+	require.NoError(t, m.Stop("db:primary", 200*time.Millisecond))
+	waitFor(t, func() bool { return !processAlive(state.PID) })
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestManager_ExitedService(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	state, err := m.Start("job", "exit 0")
+	require.NoError(t, err)
+	waitFor(t, func() bool { return !processAlive(state.PID) })
+
+	status, err := m.Status("job")
+	require.NoError(t, err)
+	assert.True(t, status.Known)
+	assert.False(t, status.Running)
+
+	// An exited service can be started again and stopping it cleans up
+	_, err = m.Start("job", "sleep 30")
+	require.NoError(t, err)
+	require.NoError(t, m.Stop("job", time.Second))
+}
+
+func TestManager_List(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	statuses, err := m.List()
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+
+	web, err := m.StartArgs("web", []string{"sleep", "30"}, "sleep 30")
+	require.NoError(t, err)
+	assert.Equal(t, "sleep 30", web.Command)
+	_, err = m.Start("api", "exit 0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = m.Stop("web", time.Second)
+		_ = m.Stop("api", time.Second)
+	})
+
+	statuses, err = m.List()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "api", statuses[0].Name)
+	assert.Equal(t, "web", statuses[1].Name)
+	assert.True(t, statuses[1].Running)
+	assert.Equal(t, web.PID, statuses[1].PID)
+}
@@ -0,0 +1,184 @@
+package steps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// Archive formats supported by the archive step
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+// archiveStep packs a file or a directory tree into a zip or tar.gz archive.
+// Entries are stored relative to the source, so archiving dist/ yields the
+// contents of dist at the root of the archive.
+type archiveStep struct {
+	src    string
+	dest   string
+	format string
+}
+
+func newArchiveStep(cfg config.ArchiveStep, resolve func(string) string) (*archiveStep, error) {
+	s := &archiveStep{src: resolve(cfg.Src), dest: resolve(cfg.Dest), format: resolve(cfg.Format)}
+	if err := requireFields([2]string{"src", s.src}, [2]string{"dest", s.dest}); err != nil {
+		return nil, err
+	}
+
+	if s.format == "" {
+		switch {
+		case strings.HasSuffix(s.dest, ".zip"):
+			s.format = FormatZip
+		case strings.HasSuffix(s.dest, ".tar.gz"), strings.HasSuffix(s.dest, ".tgz"):
+			s.format = FormatTarGz
+		default:
+			return nil, fmt.Errorf("cannot derive the format from '%s', set 'format' to %s or %s", s.dest, FormatZip, FormatTarGz)
+		}
+	}
+	if s.format != FormatZip && s.format != FormatTarGz {
+		return nil, fmt.Errorf("unsupported format '%s', expected %s or %s", s.format, FormatZip, FormatTarGz)
+	}
+	return s, nil
+}
+
+func (s *archiveStep) Kind() string { return "archive" }
+
+func (s *archiveStep) Describe() string {
+	return fmt.Sprintf("archive %s to %s (%s)", s.src, s.dest, s.format)
+}
+
+func (s *archiveStep) Run(ctx *Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.dest), 0750); err != nil {
+		return err
+	}
+	// #nosec G304 -- Writing a user specified file is the purpose of the archive step
+	out, err := os.Create(s.dest)
+	if err != nil {
+		return err
+	}
+
+	if s.format == FormatZip {
+		err = s.writeZip(ctx, out)
+	} else {
+		err = s.writeTarGz(ctx, out)
+	}
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archiveEntry is called for every file and directory below the source
+type archiveEntry func(path, name string, info fs.FileInfo) error
+
+// walk calls fn for the source and everything below it with slash separated names
+// relative to the source
+func (s *archiveStep) walk(ctx *Context, fn archiveEntry) error {
+	info, err := os.Stat(s.src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fn(s.src, filepath.Base(s.src), info)
+	}
+
+	return filepath.WalkDir(s.src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Context.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(rel), info)
+	})
+}
+
+func (s *archiveStep) writeZip(ctx *Context, out io.Writer) error {
+	zw := zip.NewWriter(out)
+	err := s.walk(ctx, func(path, name string, info fs.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		w, err := zw.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return copyFileTo(w, path)
+	})
+	if err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *archiveStep) writeTarGz(ctx *Context, out io.Writer) error {
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	err := s.walk(ctx, func(path, name string, info fs.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		return copyFileTo(tw, path)
+	})
+	if err != nil {
+		_ = tw.Close()
+		_ = gw.Close()
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		_ = gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// copyFileTo writes the contents of the file at path to w
+func copyFileTo(w io.Writer, path string) error {
+	// #nosec G304 -- Archiving user specified files is the purpose of the archive step
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,42 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// assertStep fails unless a condition is met
+type assertStep struct {
+	condition string // Condition as written, for error messages
+	resolved  string // Condition with variables resolved
+	message   string
+}
+
+func newAssertStep(cfg config.AssertStep, resolve func(string) string) (*assertStep, error) {
+	s := &assertStep{
+		condition: cfg.Condition,
+		resolved:  resolve(cfg.Condition),
+		message:   resolve(cfg.Message),
+	}
+	if err := requireFields([2]string{"condition", s.condition}); err != nil {
+		return nil, err
+	}
+	if s.message == "" {
+		s.message = "assertion failed"
+	}
+	return s, nil
+}
+
+func (s *assertStep) Kind() string { return "assert" }
+
+func (s *assertStep) Describe() string {
+	return fmt.Sprintf("assert %s", s.resolved)
+}
+
+func (s *assertStep) Run(ctx *Context) error {
+	if config.EvaluateResolvedCondition(s.resolved, ctx.Output) {
+		return nil
+	}
+	return fmt.Errorf("%s (condition: %s => %s)", s.message, s.condition, s.resolved)
+}
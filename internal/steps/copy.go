@@ -0,0 +1,102 @@
+package steps
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// copyStep copies a file or a directory tree
+type copyStep struct {
+	from string
+	to   string
+}
+
+func newCopyStep(cfg config.CopyStep, resolve func(string) string) (*copyStep, error) {
+	s := &copyStep{from: resolve(cfg.From), to: resolve(cfg.To)}
+	if err := requireFields([2]string{"from", s.from}, [2]string{"to", s.to}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *copyStep) Kind() string { return "copy" }
+
+func (s *copyStep) Describe() string {
+	return fmt.Sprintf("copy %s to %s", s.from, s.to)
+}
+
+func (s *copyStep) Run(ctx *Context) error {
+	return copyPath(ctx, s.from, s.to)
+}
+
+// copyPath copies the file or directory tree from to to
+func copyPath(ctx *Context, from, to string) error {
+	info, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		// Copying a file into an existing directory keeps the file name
+		if toInfo, err := os.Stat(to); err == nil && toInfo.IsDir() {
+			to = filepath.Join(to, filepath.Base(from))
+		}
+		return copyFile(from, to, info.Mode())
+	}
+
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Context.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+// copyFile copies a single file, creating the parent directories of dst
+func copyFile(src, dst string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	// #nosec G304 -- Copying user specified files is the purpose of the copy step
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	// #nosec G304 -- Copying user specified files is the purpose of the copy step
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
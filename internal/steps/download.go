@@ -0,0 +1,170 @@
+package steps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// downloadStep downloads a file, verifies its checksum and caches it by checksum
+type downloadStep struct {
+	url    string
+	dest   string
+	sha256 string
+}
+
+func newDownloadStep(cfg config.DownloadStep, resolve func(string) string) (*downloadStep, error) {
+	s := &downloadStep{url: resolve(cfg.URL), dest: resolve(cfg.Dest), sha256: strings.ToLower(resolve(cfg.SHA256))}
+	if err := requireFields([2]string{"url", s.url}, [2]string{"dest", s.dest}); err != nil {
+		return nil, err
+	}
+	if s.sha256 != "" {
+		if sum, err := hex.DecodeString(s.sha256); err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid sha256 '%s', expected %d hex digits", cfg.SHA256, 2*sha256.Size)
+		}
+	}
+	return s, nil
+}
+
+func (s *downloadStep) Kind() string { return "download" }
+
+func (s *downloadStep) Describe() string {
+	desc := fmt.Sprintf("download %s to %s", s.url, s.dest)
+	if s.sha256 != "" {
+		desc += fmt.Sprintf(" (sha256 %s)", s.sha256[:12])
+	}
+	return desc
+}
+
+// Run downloads the file. With a checksum, a file that was downloaded before is
+// taken from the cache, and an interrupted download continues where it stopped.
+func (s *downloadStep) Run(ctx *Context) error {
+	dest := s.dest
+	// Downloading into an existing directory keeps the file name of the URL
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		name, err := urlFileName(s.url)
+		if err != nil {
+			return err
+		}
+		dest = filepath.Join(dest, name)
+	}
+
+	if s.sha256 != "" && ctx.CacheDir != "" {
+		cached := filepath.Join(ctx.CacheDir, s.sha256)
+		if sum, err := fileSHA256(cached); err == nil && sum == s.sha256 {
+			return copyFile(cached, dest, 0644)
+		}
+		if err := s.fetch(ctx, cached); err != nil {
+			return err
+		}
+		return copyFile(cached, dest, 0644)
+	}
+	return s.fetch(ctx, dest)
+}
+
+// fetch downloads the file to target through a partial file next to it, which a
+// later run resumes if the download is interrupted, and verifies the checksum
+func (s *downloadStep) fetch(ctx *Context, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+	partial := target + ".part"
+
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		// The server ignored the range, start over
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("download failed: unexpected status %d", resp.StatusCode)
+	}
+
+	// #nosec G304 -- Writing to a user specified file is the purpose of dest
+	f, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", partial, err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("download interrupted, run again to resume: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if s.sha256 != "" {
+		sum, err := fileSHA256(partial)
+		if err != nil {
+			return err
+		}
+		if sum != s.sha256 {
+			// A corrupt partial file must not be resumed
+			_ = os.Remove(partial)
+			return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", s.url, s.sha256, sum)
+		}
+	}
+	return os.Rename(partial, target)
+}
+
+// urlFileName returns the last element of the path of a URL
+func urlFileName(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url '%s': %w", rawURL, err)
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("cannot derive a file name from '%s', set dest to a file", rawURL)
+	}
+	return name, nil
+}
+
+// fileSHA256 returns the hex encoded SHA-256 checksum of a file
+func fileSHA256(path string) (string, error) {
+	// #nosec G304 -- Reading user specified files is the purpose of the step
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
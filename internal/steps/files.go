@@ -0,0 +1,101 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// moveStep moves a file or a directory tree
+type moveStep struct {
+	from string
+	to   string
+}
+
+func newMoveStep(cfg config.MoveStep, resolve func(string) string) (*moveStep, error) {
+	s := &moveStep{from: resolve(cfg.From), to: resolve(cfg.To)}
+	if err := requireFields([2]string{"from", s.from}, [2]string{"to", s.to}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *moveStep) Kind() string { return "move" }
+
+func (s *moveStep) Describe() string {
+	return fmt.Sprintf("move %s to %s", s.from, s.to)
+}
+
+func (s *moveStep) Run(ctx *Context) error {
+	if _, err := os.Stat(s.from); err != nil {
+		return err
+	}
+
+	to := s.to
+	// Moving into an existing directory keeps the name, like mv
+	if toInfo, err := os.Stat(to); err == nil && toInfo.IsDir() {
+		to = filepath.Join(to, filepath.Base(s.from))
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0750); err != nil {
+		return err
+	}
+	if err := os.Rename(s.from, to); err == nil {
+		return nil
+	}
+
+	// Renaming fails across file systems and, on Windows, onto existing files, so
+	// fall back to copying and removing the source
+	if err := copyPath(ctx, s.from, to); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.from)
+}
+
+// mkdirStep creates a directory and its parents
+type mkdirStep struct {
+	path string
+}
+
+func newMkdirStep(cfg config.MkdirStep, resolve func(string) string) (*mkdirStep, error) {
+	s := &mkdirStep{path: resolve(cfg.Path)}
+	if err := requireFields([2]string{"path", s.path}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *mkdirStep) Kind() string { return "mkdir" }
+
+func (s *mkdirStep) Describe() string {
+	return fmt.Sprintf("create directory %s", s.path)
+}
+
+func (s *mkdirStep) Run(_ *Context) error {
+	return os.MkdirAll(s.path, 0750)
+}
+
+// rmStep removes a file or a directory tree
+type rmStep struct {
+	path string
+}
+
+func newRmStep(cfg config.RmStep, resolve func(string) string) (*rmStep, error) {
+	s := &rmStep{path: resolve(cfg.Path)}
+	if err := requireFields([2]string{"path", s.path}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *rmStep) Kind() string { return "rm" }
+
+func (s *rmStep) Describe() string {
+	return fmt.Sprintf("remove %s", s.path)
+}
+
+// Run removes the path. A path that does not exist is not an error, like rm -rf.
+func (s *rmStep) Run(_ *Context) error {
+	return os.RemoveAll(s.path)
+}
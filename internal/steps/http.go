@@ -0,0 +1,101 @@
+package steps
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// httpStep sends an HTTP request and checks the response status
+type httpStep struct {
+	url     string
+	method  string
+	headers map[string]string
+	body    string
+	status  int
+	output  string
+}
+
+func newHTTPStep(cfg config.HTTPStep, resolve func(string) string) (*httpStep, error) {
+	s := &httpStep{
+		url:     resolve(cfg.URL),
+		method:  strings.ToUpper(resolve(cfg.Method)),
+		headers: make(map[string]string, len(cfg.Headers)),
+		body:    resolve(cfg.Body),
+		status:  cfg.Status,
+		output:  resolve(cfg.Output),
+	}
+	if err := requireFields([2]string{"url", s.url}); err != nil {
+		return nil, err
+	}
+	if s.method == "" {
+		s.method = http.MethodGet
+	}
+	for name, value := range cfg.Headers {
+		s.headers[name] = resolve(value)
+	}
+	return s, nil
+}
+
+func (s *httpStep) Kind() string { return "http" }
+
+func (s *httpStep) Describe() string {
+	desc := fmt.Sprintf("%s %s", s.method, s.url)
+	if s.status != 0 {
+		desc += fmt.Sprintf(" (expect %d)", s.status)
+	}
+	if s.output != "" {
+		desc += " > " + s.output
+	}
+	return desc
+}
+
+func (s *httpStep) Run(ctx *Context) error {
+	var body io.Reader
+	if s.body != "" {
+		body = strings.NewReader(s.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context, s.method, s.url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if s.status != 0 && resp.StatusCode != s.status {
+		return fmt.Errorf("unexpected status %d, expected %d", resp.StatusCode, s.status)
+	}
+	if s.status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if s.output == "" {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	// #nosec G304 -- Writing to a user specified file is the purpose of output
+	f, err := os.Create(s.output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return f.Close()
+}
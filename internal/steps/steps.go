@@ -0,0 +1,111 @@
+// Package steps implements the built-in steps of script commands. Steps run inside
+// yxa itself instead of a shell, so they behave the same on every platform.
+package steps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// Context carries everything a step needs while running
+type Context struct {
+	Context  context.Context   // Cancelled when the command times out
+	Vars     map[string]string // Variables available to templates
+	Check    bool              // Whether template steps only check that their output is up to date
+	CacheDir string            // Directory in which download steps cache files by checksum, none if empty
+	Output   config.OutputFunc // Runs the commands of output-of conditions, which are not met without it
+}
+
+// Step is a single built-in step
+type Step interface {
+	// Kind returns the step type as written in yxa.yml (e.g. "copy")
+	Kind() string
+	// Describe returns a short human readable summary used for logs and dry-run
+	Describe() string
+	// Run executes the step
+	Run(ctx *Context) error
+}
+
+// New creates a step from its configuration. Variables in the string fields of the
+// step are resolved with resolve.
+func New(cfg config.Step, resolve func(string) string) (Step, error) {
+	var kinds []string
+	var step Step
+	var err error
+
+	if cfg.HTTP != nil {
+		kinds = append(kinds, "http")
+		step, err = newHTTPStep(*cfg.HTTP, resolve)
+	}
+	if cfg.Copy != nil {
+		kinds = append(kinds, "copy")
+		step, err = newCopyStep(*cfg.Copy, resolve)
+	}
+	if cfg.Move != nil {
+		kinds = append(kinds, "move")
+		step, err = newMoveStep(*cfg.Move, resolve)
+	}
+	if cfg.Mkdir != nil {
+		kinds = append(kinds, "mkdir")
+		step, err = newMkdirStep(*cfg.Mkdir, resolve)
+	}
+	if cfg.Rm != nil {
+		kinds = append(kinds, "rm")
+		step, err = newRmStep(*cfg.Rm, resolve)
+	}
+	if cfg.Download != nil {
+		kinds = append(kinds, "download")
+		step, err = newDownloadStep(*cfg.Download, resolve)
+	}
+	if cfg.WaitFor != nil {
+		kinds = append(kinds, "wait_for")
+		step, err = newWaitForStep(*cfg.WaitFor, resolve)
+	}
+	if cfg.Template != nil {
+		kinds = append(kinds, "template")
+		step, err = newTemplateStep(*cfg.Template, resolve)
+	}
+	if cfg.Archive != nil {
+		kinds = append(kinds, "archive")
+		step, err = newArchiveStep(*cfg.Archive, resolve)
+	}
+	if cfg.Assert != nil {
+		kinds = append(kinds, "assert")
+		step, err = newAssertStep(*cfg.Assert, resolve)
+	}
+
+	switch {
+	case len(kinds) == 0:
+		return nil, fmt.Errorf("step has no type, expected one of http, copy, move, mkdir, rm, download, wait_for, template, archive or assert")
+	case len(kinds) > 1:
+		return nil, fmt.Errorf("step has more than one type: %s", strings.Join(kinds, ", "))
+	case err != nil:
+		return nil, fmt.Errorf("invalid %s step: %w", kinds[0], err)
+	}
+	return step, nil
+}
+
+// Label returns the name used for a step in logs and errors: its configured name,
+// or its type if it has none
+func Label(cfg config.Step, step Step) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	if step != nil {
+		return step.Kind()
+	}
+	return "step"
+}
+
+// requireFields returns an error naming the first empty field
+func requireFields(fields ...[2]string) error {
+	for _, field := range fields {
+		if field[1] == "" {
+			return fmt.Errorf("'%s' is required", field[0])
+		}
+	}
+	return nil
+}
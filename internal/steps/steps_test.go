@@ -0,0 +1,535 @@
+package steps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// identity resolves nothing
+func identity(s string) string { return s }
+
+func newContext(vars map[string]string) *Context {
+	return &Context{Context: context.Background(), Vars: vars}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Step
+		wantKind string
+		wantDesc string
+		wantErr  string
+	}{
+		{
+			name:     "http defaults to GET",
+			cfg:      config.Step{HTTP: &config.HTTPStep{URL: "http://$HOST/health", Status: 204}},
+			wantKind: "http",
+			wantDesc: "GET http://localhost/health (expect 204)",
+		},
+		{
+			name:     "copy",
+			cfg:      config.Step{Copy: &config.CopyStep{From: "a", To: "b"}},
+			wantKind: "copy",
+			wantDesc: "copy a to b",
+		},
+		{
+			name:     "move",
+			cfg:      config.Step{Move: &config.MoveStep{From: "a", To: "$HOST"}},
+			wantKind: "move",
+			wantDesc: "move a to localhost",
+		},
+		{
+			name:     "mkdir",
+			cfg:      config.Step{Mkdir: &config.MkdirStep{Path: "dist/$HOST"}},
+			wantKind: "mkdir",
+			wantDesc: "create directory dist/localhost",
+		},
+		{
+			name:     "rm",
+			cfg:      config.Step{Rm: &config.RmStep{Path: "dist"}},
+			wantKind: "rm",
+			wantDesc: "remove dist",
+		},
+		{
+			name:    "rm without path",
+			cfg:     config.Step{Rm: &config.RmStep{}},
+			wantErr: "invalid rm step: 'path' is required",
+		},
+		{
+			name:     "download",
+			cfg:      config.Step{Download: &config.DownloadStep{URL: "http://$HOST/tool.tgz", Dest: "bin", SHA256: strings.Repeat("AB", 32)}},
+			wantKind: "download",
+			wantDesc: "download http://localhost/tool.tgz to bin (sha256 abababababab)",
+		},
+		{
+			name:    "download with invalid checksum",
+			cfg:     config.Step{Download: &config.DownloadStep{URL: "http://x/a", Dest: "a", SHA256: "abc"}},
+			wantErr: "invalid download step: invalid sha256 'abc', expected 64 hex digits",
+		},
+		{
+			name:     "wait_for with defaults",
+			cfg:      config.Step{WaitFor: &config.WaitForStep{TCP: "$HOST:5432"}},
+			wantKind: "wait_for",
+			wantDesc: "wait for tcp localhost:5432 (timeout 30s)",
+		},
+		{
+			name:     "archive derives format",
+			cfg:      config.Step{Archive: &config.ArchiveStep{Src: "dist", Dest: "out/app.tgz"}},
+			wantKind: "archive",
+			wantDesc: "archive dist to out/app.tgz (tar.gz)",
+		},
+		{
+			name:     "assert",
+			cfg:      config.Step{Assert: &config.AssertStep{Condition: "$HOST == localhost"}},
+			wantKind: "assert",
+			wantDesc: "assert localhost == localhost",
+		},
+		{
+			name:    "assert without condition",
+			cfg:     config.Step{Assert: &config.AssertStep{Message: "nope"}},
+			wantErr: "invalid assert step: 'condition' is required",
+		},
+		{
+			name:    "no type",
+			cfg:     config.Step{Name: "empty"},
+			wantErr: "step has no type",
+		},
+		{
+			name:    "more than one type",
+			cfg:     config.Step{Copy: &config.CopyStep{From: "a", To: "b"}, Template: &config.TemplateStep{Src: "a", Dest: "b"}},
+			wantErr: "more than one type: copy, template",
+		},
+		{
+			name:    "missing field",
+			cfg:     config.Step{Template: &config.TemplateStep{Src: "a"}},
+			wantErr: "invalid template step: 'dest' is required",
+		},
+		{
+			name:    "wait_for with two targets",
+			cfg:     config.Step{WaitFor: &config.WaitForStep{URL: "http://x", File: "y"}},
+			wantErr: "exactly one of 'url', 'tcp' or 'file'",
+		},
+		{
+			name:    "wait_for with invalid timeout",
+			cfg:     config.Step{WaitFor: &config.WaitForStep{File: "y", Timeout: "soon"}},
+			wantErr: "invalid timeout 'soon'",
+		},
+		{
+			name:    "archive with unknown extension",
+			cfg:     config.Step{Archive: &config.ArchiveStep{Src: "dist", Dest: "app.rar"}},
+			wantErr: "cannot derive the format",
+		},
+	}
+
+	resolve := func(s string) string { return strings.ReplaceAll(s, "$HOST", "localhost") }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := New(tt.cfg, resolve)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKind, step.Kind())
+			assert.Equal(t, tt.wantDesc, step.Describe())
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	step, err := New(config.Step{Copy: &config.CopyStep{From: "a", To: "b"}}, identity)
+	require.NoError(t, err)
+
+	assert.Equal(t, "copy", Label(config.Step{}, step))
+	assert.Equal(t, "stage assets", Label(config.Step{Name: "stage assets"}, step))
+	assert.Equal(t, "step", Label(config.Step{}, nil))
+}
+
+func TestHTTPStep(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, r.Method+" "+r.Header.Get("X-Token")+" "+string(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "response.txt")
+
+	step, err := New(config.Step{HTTP: &config.HTTPStep{
+		URL:     server.URL + "/deploy",
+		Method:  "post",
+		Headers: map[string]string{"X-Token": "abc"},
+		Body:    "payload",
+		Output:  output,
+	}}, identity)
+	require.NoError(t, err)
+	require.NoError(t, step.Run(newContext(nil)))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "POST abc payload", string(data))
+
+	step, err = New(config.Step{HTTP: &config.HTTPStep{URL: server.URL + "/missing"}}, identity)
+	require.NoError(t, err)
+	err = step.Run(newContext(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 404")
+
+	step, err = New(config.Step{HTTP: &config.HTTPStep{URL: server.URL + "/missing", Status: 404}}, identity)
+	require.NoError(t, err)
+	assert.NoError(t, step.Run(newContext(nil)))
+}
+
+func TestCopyStep(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "src", "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "src", "sub", "b.txt"), "b")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "into"), 0750))
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want map[string]string
+	}{
+		{
+			name: "directory tree",
+			from: filepath.Join(dir, "src"),
+			to:   filepath.Join(dir, "tree"),
+			want: map[string]string{"tree/a.txt": "a", "tree/sub/b.txt": "b"},
+		},
+		{
+			name: "file to new path",
+			from: filepath.Join(dir, "src", "a.txt"),
+			to:   filepath.Join(dir, "new", "renamed.txt"),
+			want: map[string]string{"new/renamed.txt": "a"},
+		},
+		{
+			name: "file into existing directory",
+			from: filepath.Join(dir, "src", "sub", "b.txt"),
+			to:   filepath.Join(dir, "into"),
+			want: map[string]string{"into/b.txt": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := New(config.Step{Copy: &config.CopyStep{From: tt.from, To: tt.to}}, identity)
+			require.NoError(t, err)
+			require.NoError(t, step.Run(newContext(nil)))
+
+			for rel, content := range tt.want {
+				data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+				require.NoError(t, err)
+				assert.Equal(t, content, string(data))
+			}
+		})
+	}
+
+	step, err := New(config.Step{Copy: &config.CopyStep{From: filepath.Join(dir, "missing"), To: filepath.Join(dir, "x")}}, identity)
+	require.NoError(t, err)
+	assert.Error(t, step.Run(newContext(nil)))
+}
+
+func TestFileSteps(t *testing.T) {
+	dir := t.TempDir()
+	run := func(cfg config.Step) error {
+		step, err := New(cfg, identity)
+		require.NoError(t, err)
+		return step.Run(newContext(nil))
+	}
+
+	require.NoError(t, run(config.Step{Mkdir: &config.MkdirStep{Path: filepath.Join(dir, "out", "nested")}}))
+	assert.DirExists(t, filepath.Join(dir, "out", "nested"))
+	require.NoError(t, run(config.Step{Mkdir: &config.MkdirStep{Path: filepath.Join(dir, "out", "nested")}}))
+
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	require.NoError(t, run(config.Step{Move: &config.MoveStep{From: filepath.Join(dir, "a.txt"), To: filepath.Join(dir, "out")}}))
+	assert.NoFileExists(t, filepath.Join(dir, "a.txt"))
+	data, err := os.ReadFile(filepath.Join(dir, "out", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	require.NoError(t, run(config.Step{Move: &config.MoveStep{From: filepath.Join(dir, "out"), To: filepath.Join(dir, "moved", "dist")}}))
+	assert.NoDirExists(t, filepath.Join(dir, "out"))
+	assert.FileExists(t, filepath.Join(dir, "moved", "dist", "a.txt"))
+	assert.DirExists(t, filepath.Join(dir, "moved", "dist", "nested"))
+	assert.Error(t, run(config.Step{Move: &config.MoveStep{From: filepath.Join(dir, "missing"), To: filepath.Join(dir, "x")}}))
+
+	require.NoError(t, run(config.Step{Rm: &config.RmStep{Path: filepath.Join(dir, "moved")}}))
+	assert.NoDirExists(t, filepath.Join(dir, "moved"))
+	assert.NoError(t, run(config.Step{Rm: &config.RmStep{Path: filepath.Join(dir, "moved")}}))
+}
+
+func TestDownloadStep(t *testing.T) {
+	content := "tool binary"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	var requests, ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "tool", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ctx := newContext(nil)
+	ctx.CacheDir = filepath.Join(dir, "cache")
+	run := func(cfg config.DownloadStep) error {
+		step, err := New(config.Step{Download: &cfg}, identity)
+		require.NoError(t, err)
+		return step.Run(ctx)
+	}
+	readFile := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("verifies and caches", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, run(config.DownloadStep{URL: server.URL + "/tool", Dest: filepath.Join(dir, "bin", "tool"), SHA256: checksum}))
+		assert.Equal(t, content, readFile(filepath.Join(dir, "bin", "tool")))
+		assert.FileExists(t, filepath.Join(ctx.CacheDir, checksum))
+
+		require.NoError(t, run(config.DownloadStep{URL: server.URL + "/tool", Dest: filepath.Join(dir, "bin", "again"), SHA256: checksum}))
+		assert.Equal(t, content, readFile(filepath.Join(dir, "bin", "again")))
+		assert.Len(t, requests, 1)
+	})
+
+	t.Run("resumes a partial download", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(ctx.CacheDir))
+		writeFile(t, filepath.Join(ctx.CacheDir, checksum+".part"), content[:4])
+		ranges = nil
+
+		require.NoError(t, run(config.DownloadStep{URL: server.URL + "/tool", Dest: filepath.Join(dir, "resumed"), SHA256: checksum}))
+		assert.Equal(t, []string{"bytes=4-"}, ranges)
+		assert.Equal(t, content, readFile(filepath.Join(dir, "resumed")))
+		assert.NoFileExists(t, filepath.Join(ctx.CacheDir, checksum+".part"))
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		wrong := strings.Repeat("0", 64)
+		err := run(config.DownloadStep{URL: server.URL + "/tool", Dest: filepath.Join(dir, "bad"), SHA256: wrong})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch for "+server.URL+"/tool: expected sha256 "+wrong+", got "+checksum)
+		assert.NoFileExists(t, filepath.Join(dir, "bad"))
+		assert.NoFileExists(t, filepath.Join(ctx.CacheDir, wrong+".part"))
+	})
+
+	t.Run("without checksum into a directory", func(t *testing.T) {
+		requests = nil
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools"), 0750))
+		require.NoError(t, run(config.DownloadStep{URL: server.URL + "/files/tool.tgz", Dest: filepath.Join(dir, "tools")}))
+		assert.Equal(t, content, readFile(filepath.Join(dir, "tools", "tool.tgz")))
+		assert.Equal(t, []string{"/files/tool.tgz"}, requests)
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		missing := httptest.NewServer(http.NotFoundHandler())
+		defer missing.Close()
+		err := run(config.DownloadStep{URL: missing.URL + "/tool", Dest: filepath.Join(dir, "missing")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected status 404")
+	})
+}
+
+func TestWaitForStep(t *testing.T) {
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.WriteFile(ready, nil, 0600)
+	}()
+
+	step, err := New(config.Step{WaitFor: &config.WaitForStep{File: ready, Timeout: "5s", Interval: "10ms"}}, identity)
+	require.NoError(t, err)
+	assert.NoError(t, step.Run(newContext(nil)))
+
+	step, err = New(config.Step{WaitFor: &config.WaitForStep{File: filepath.Join(dir, "never"), Timeout: "50ms", Interval: "10ms"}}, identity)
+	require.NoError(t, err)
+	err = step.Run(newContext(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not ready after 50ms")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	step, err = New(config.Step{WaitFor: &config.WaitForStep{URL: server.URL, Timeout: "5s"}}, identity)
+	require.NoError(t, err)
+	assert.NoError(t, step.Run(newContext(nil)))
+
+	step, err = New(config.Step{WaitFor: &config.WaitForStep{TCP: strings.TrimPrefix(server.URL, "http://"), Timeout: "5s"}}, identity)
+	require.NoError(t, err)
+	assert.NoError(t, step.Run(newContext(nil)))
+}
+
+func TestTemplateStep(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.tmpl")
+	writeFile(t, src, "version={{ .VERSION }}\n")
+
+	dest := filepath.Join(dir, "out", "config.ini")
+	step, err := New(config.Step{Template: &config.TemplateStep{Src: src, Dest: dest}}, identity)
+	require.NoError(t, err)
+	require.NoError(t, step.Run(newContext(map[string]string{"VERSION": "1.2.3"})))
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "version=1.2.3\n", string(data))
+
+	err = step.Run(newContext(map[string]string{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to render template")
+
+	// Check mode compares the output without writing it
+	check := newContext(map[string]string{"VERSION": "1.2.3"})
+	check.Check = true
+	require.NoError(t, step.Run(check))
+	check.Vars["VERSION"] = "2.0.0"
+	err = step.Run(check)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.ini is out of date, run without --check to render it")
+	data, err = os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "version=1.2.3\n", string(data))
+
+	// Output that does not change is not written again
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(dest, old, old))
+	require.NoError(t, step.Run(newContext(map[string]string{"VERSION": "1.2.3"})))
+	info, err := os.Stat(dest)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(old))
+
+	require.NoError(t, os.Remove(dest))
+	check.Vars["VERSION"] = "1.2.3"
+	assert.Error(t, step.Run(check), "a missing output is out of date")
+}
+
+func TestArchiveStep(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "dist")
+	writeFile(t, filepath.Join(src, "app"), "binary")
+	writeFile(t, filepath.Join(src, "docs", "README"), "readme")
+
+	t.Run("zip", func(t *testing.T) {
+		dest := filepath.Join(dir, "app.zip")
+		step, err := New(config.Step{Archive: &config.ArchiveStep{Src: src, Dest: dest}}, identity)
+		require.NoError(t, err)
+		require.NoError(t, step.Run(newContext(nil)))
+
+		zr, err := zip.OpenReader(dest)
+		require.NoError(t, err)
+		defer func() {
+			_ = zr.Close()
+		}()
+
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{"app", "docs/", "docs/README"}, names)
+	})
+
+	t.Run("tar.gz", func(t *testing.T) {
+		dest := filepath.Join(dir, "app.tar.gz")
+		step, err := New(config.Step{Archive: &config.ArchiveStep{Src: src, Dest: dest}}, identity)
+		require.NoError(t, err)
+		require.NoError(t, step.Run(newContext(nil)))
+
+		f, err := os.Open(dest)
+		require.NoError(t, err)
+		defer func() {
+			_ = f.Close()
+		}()
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+
+		contents := make(map[string]string)
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			contents[header.Name] = string(data)
+		}
+		assert.Equal(t, map[string]string{"app": "binary", "docs/": "", "docs/README": "readme"}, contents)
+	})
+}
+
+func TestAssertStep(t *testing.T) {
+	resolve := func(s string) string { return strings.ReplaceAll(s, "$STATUS", "degraded") }
+
+	tests := []struct {
+		name    string
+		cfg     config.AssertStep
+		wantErr string
+	}{
+		{
+			name: "condition met",
+			cfg:  config.AssertStep{Condition: "$STATUS != healthy"},
+		},
+		{
+			name:    "condition not met with message",
+			cfg:     config.AssertStep{Condition: "$STATUS == healthy", Message: "service $STATUS"},
+			wantErr: "service degraded (condition: $STATUS == healthy => degraded == healthy)",
+		},
+		{
+			name:    "condition not met without message",
+			cfg:     config.AssertStep{Condition: "$STATUS contains ok"},
+			wantErr: "assertion failed (condition: $STATUS contains ok => degraded contains ok)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, err := New(config.Step{Assert: &tt.cfg}, resolve)
+			require.NoError(t, err)
+
+			err = step.Run(newContext(nil))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package steps
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+// templateStep renders a Go text/template with the command's variables as data,
+// so {{ .VERSION }} is replaced by the value of VERSION. The output file is only
+// written if its content changes, and in check mode not at all.
+type templateStep struct {
+	src  string
+	dest string
+}
+
+func newTemplateStep(cfg config.TemplateStep, resolve func(string) string) (*templateStep, error) {
+	s := &templateStep{src: resolve(cfg.Src), dest: resolve(cfg.Dest)}
+	if err := requireFields([2]string{"src", s.src}, [2]string{"dest", s.dest}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *templateStep) Kind() string { return "template" }
+
+func (s *templateStep) Describe() string {
+	return fmt.Sprintf("render %s to %s", s.src, s.dest)
+}
+
+func (s *templateStep) Run(ctx *Context) error {
+	// #nosec G304 -- Reading a user specified template is the purpose of the template step
+	data, err := os.ReadFile(s.src)
+	if err != nil {
+		return err
+	}
+
+	// Referencing an unknown variable is an error rather than "<no value>"
+	tmpl, err := template.New(filepath.Base(s.src)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, ctx.Vars); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	// #nosec G304 -- Reading the output of the template step to compare it
+	current, err := os.ReadFile(s.dest)
+	upToDate := err == nil && bytes.Equal(current, rendered.Bytes())
+	if ctx.Check {
+		if !upToDate {
+			return fmt.Errorf("%s is out of date, run without --check to render it", s.dest)
+		}
+		return nil
+	}
+	if upToDate {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.dest), 0750); err != nil {
+		return err
+	}
+	// #nosec G306 -- Rendered files are configuration that is not secret by itself
+	return os.WriteFile(s.dest, rendered.Bytes(), 0644)
+}
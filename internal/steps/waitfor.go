@@ -0,0 +1,115 @@
+package steps
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/floppa/yxa-cli/internal/config"
+)
+
+const (
+	defaultWaitTimeout  = 30 * time.Second
+	defaultWaitInterval = time.Second
+)
+
+// waitForStep polls until a URL responds, a TCP address accepts connections or a
+// file exists
+type waitForStep struct {
+	kind     string // "url", "tcp" or "file"
+	target   string
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func newWaitForStep(cfg config.WaitForStep, resolve func(string) string) (*waitForStep, error) {
+	s := &waitForStep{timeout: defaultWaitTimeout, interval: defaultWaitInterval}
+
+	targets := 0
+	for _, t := range []struct{ kind, value string }{
+		{"url", cfg.URL},
+		{"tcp", cfg.TCP},
+		{"file", cfg.File},
+	} {
+		if t.value != "" {
+			targets++
+			s.kind, s.target = t.kind, resolve(t.value)
+		}
+	}
+	if targets != 1 {
+		return nil, fmt.Errorf("exactly one of 'url', 'tcp' or 'file' is required")
+	}
+
+	var err error
+	if cfg.Timeout != "" {
+		if s.timeout, err = time.ParseDuration(resolve(cfg.Timeout)); err != nil {
+			return nil, fmt.Errorf("invalid timeout '%s': %w", cfg.Timeout, err)
+		}
+	}
+	if cfg.Interval != "" {
+		if s.interval, err = time.ParseDuration(resolve(cfg.Interval)); err != nil {
+			return nil, fmt.Errorf("invalid interval '%s': %w", cfg.Interval, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *waitForStep) Kind() string { return "wait_for" }
+
+func (s *waitForStep) Describe() string {
+	return fmt.Sprintf("wait for %s %s (timeout %s)", s.kind, s.target, s.timeout)
+}
+
+func (s *waitForStep) Run(ctx *Context) error {
+	waitCtx, cancel := context.WithTimeout(ctx.Context, s.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.check(waitCtx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("%s %s not ready after %s: %w", s.kind, s.target, s.timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// check returns nil when the target is ready
+func (s *waitForStep) check(ctx context.Context) error {
+	switch s.kind {
+	case "url":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	case "tcp":
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", s.target)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	default:
+		_, err := os.Stat(s.target)
+		return err
+	}
+}
@@ -0,0 +1,168 @@
+// Package tools installs the tools that a project pins to a version, so that every
+// machine runs its commands with the same toolchain.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/floppa/yxa-cli/internal/steps"
+	"github.com/floppa/yxa-cli/internal/variables"
+)
+
+// Installer installs tools into a directory, one directory per tool, version and
+// platform
+type Installer struct {
+	Dir      string // Directory the tools are installed into, e.g. .yxa/tools
+	CacheDir string // Directory downloads are cached in by checksum, none if empty
+	OS       string // Platform to install for, runtime.GOOS if empty
+	Arch     string // Architecture to install for, runtime.GOARCH if empty
+}
+
+// NewInstaller creates an installer for the current platform
+func NewInstaller(dir, cacheDir string) *Installer {
+	return &Installer{Dir: dir, CacheDir: cacheDir, OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// Platform returns the platform the installer installs for, e.g. linux-amd64, as
+// used for the checksums of a tool
+func (i *Installer) Platform() string {
+	return i.OS + "-" + i.Arch
+}
+
+// BinDir returns the directory with the executable of a tool, which is put on PATH
+func (i *Installer) BinDir(name string, tool config.Tool) string {
+	return filepath.Dir(i.binPath(name, tool))
+}
+
+// Installed reports whether the pinned version of a tool is installed
+func (i *Installer) Installed(name string, tool config.Tool) bool {
+	_, err := os.Stat(i.binPath(name, tool))
+	return err == nil
+}
+
+// Install downloads and unpacks the pinned version of a tool unless it is
+// installed already
+func (i *Installer) Install(ctx context.Context, name string, tool config.Tool) error {
+	if err := i.validate(name, tool); err != nil {
+		return err
+	}
+	if i.Installed(name, tool) {
+		return nil
+	}
+
+	checksum := tool.SHA256[i.Platform()]
+	if len(tool.SHA256) > 0 && checksum == "" {
+		return fmt.Errorf("tool '%s' has no sha256 for %s", name, i.Platform())
+	}
+
+	// Unpack next to the final directory and move it in place when complete, so an
+	// interrupted install is never taken for an installed tool
+	target := i.installDir(name, tool)
+	staging := target + ".tmp"
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+
+	url := i.resolve(tool, tool.URL)
+	download := filepath.Join(staging, ".download")
+	step, err := steps.New(config.Step{Download: &config.DownloadStep{URL: url, Dest: download, SHA256: checksum}}, func(s string) string { return s })
+	if err != nil {
+		return fmt.Errorf("invalid tool '%s': %w", name, err)
+	}
+	if err := step.Run(&steps.Context{Context: ctx, CacheDir: i.CacheDir}); err != nil {
+		return fmt.Errorf("failed to download tool '%s': %w", name, err)
+	}
+
+	bin := filepath.Join(staging, filepath.FromSlash(i.resolve(tool, i.bin(name, tool))))
+	switch format := archiveFormat(url); format {
+	case "":
+		// The download is the executable itself
+		if err := os.MkdirAll(filepath.Dir(bin), 0750); err != nil {
+			return err
+		}
+		if err := os.Rename(download, bin); err != nil {
+			return err
+		}
+	default:
+		if err := extract(download, staging, format); err != nil {
+			return fmt.Errorf("failed to unpack tool '%s': %w", name, err)
+		}
+		if err := os.Remove(download); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(bin); err != nil {
+		return fmt.Errorf("tool '%s' has no executable %s in its download, set bin to its path", name, i.resolve(tool, i.bin(name, tool)))
+	}
+	// #nosec G302 -- The installed tool must be executable
+	if err := os.Chmod(bin, 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	return os.Rename(staging, target)
+}
+
+// validate checks the required fields of a tool
+func (i *Installer) validate(name string, tool config.Tool) error {
+	switch {
+	case tool.Version == "":
+		return fmt.Errorf("tool '%s' has no version", name)
+	case tool.URL == "":
+		return fmt.Errorf("tool '%s' has no url", name)
+	}
+	return nil
+}
+
+// installDir returns the directory a version of a tool is installed into
+func (i *Installer) installDir(name string, tool config.Tool) string {
+	return filepath.Join(i.Dir, name, tool.Version+"-"+i.Platform())
+}
+
+// binPath returns the path of the executable of an installed tool
+func (i *Installer) binPath(name string, tool config.Tool) string {
+	return filepath.Join(i.installDir(name, tool), filepath.FromSlash(i.resolve(tool, i.bin(name, tool))))
+}
+
+// bin returns the path of the executable in the download of a tool
+func (i *Installer) bin(name string, tool config.Tool) string {
+	if tool.Bin != "" {
+		return tool.Bin
+	}
+	if i.OS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// resolve replaces $version, $os and $arch in s
+func (i *Installer) resolve(tool config.Tool, s string) string {
+	return variables.NewResolver().
+		WithConfigVars(map[string]string{"version": tool.Version, "os": i.OS, "arch": i.Arch}).
+		WithSystemEnvVar(false).
+		Resolve(s)
+}
+
+// archiveFormat returns the archive format of a download by its URL, empty if it
+// is no archive
+func archiveFormat(url string) string {
+	lower := strings.ToLower(strings.SplitN(url, "?", 2)[0])
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/floppa/yxa-cli/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarGz returns a tar.gz archive with the given files
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInstaller_Install(t *testing.T) {
+	archive := tarGz(t, map[string]string{"lint-1.2.0-linux-amd64/lint": "#!/bin/sh\necho lint 1.2.0\n"})
+	binary := []byte("#!/bin/sh\necho gen\n")
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/lint/v1.2.0/lint-linux-amd64.tar.gz":
+			_, _ = w.Write(archive)
+		case "/gen-linux":
+			_, _ = w.Write(binary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	installer := &Installer{Dir: filepath.Join(dir, "tools"), CacheDir: filepath.Join(dir, "downloads"), OS: "linux", Arch: "amd64"}
+	ctx := context.Background()
+
+	t.Run("archive", func(t *testing.T) {
+		lint := config.Tool{
+			Version: "1.2.0",
+			URL:     server.URL + "/lint/v$version/lint-$os-$arch.tar.gz",
+			SHA256:  map[string]string{"linux-amd64": checksum(archive)},
+			Bin:     "lint-$version-$os-$arch/lint",
+		}
+		assert.False(t, installer.Installed("lint", lint))
+		require.NoError(t, installer.Install(ctx, "lint", lint))
+		assert.True(t, installer.Installed("lint", lint))
+
+		binDir := installer.BinDir("lint", lint)
+		assert.Equal(t, filepath.Join(dir, "tools", "lint", "1.2.0-linux-amd64", "lint-1.2.0-linux-amd64"), binDir)
+		info, err := os.Stat(filepath.Join(binDir, "lint"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+		assert.NoDirExists(t, filepath.Join(dir, "tools", "lint", "1.2.0-linux-amd64.tmp"))
+
+		// Installed tools are not downloaded again
+		requests = nil
+		require.NoError(t, installer.Install(ctx, "lint", lint))
+		assert.Empty(t, requests)
+	})
+
+	t.Run("executable", func(t *testing.T) {
+		gen := config.Tool{Version: "3", URL: server.URL + "/gen-$os"}
+		require.NoError(t, installer.Install(ctx, "gen", gen))
+		data, err := os.ReadFile(filepath.Join(installer.BinDir("gen", gen), "gen"))
+		require.NoError(t, err)
+		assert.Equal(t, binary, data)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			tool    config.Tool
+			wantErr string
+		}{
+			{name: "no version", tool: config.Tool{URL: server.URL + "/gen-linux"}, wantErr: "tool 'bad' has no version"},
+			{name: "no url", tool: config.Tool{Version: "1"}, wantErr: "tool 'bad' has no url"},
+			{
+				name:    "no checksum for the platform",
+				tool:    config.Tool{Version: "1", URL: server.URL + "/gen-linux", SHA256: map[string]string{"darwin-arm64": checksum(binary)}},
+				wantErr: "tool 'bad' has no sha256 for linux-amd64",
+			},
+			{
+				name:    "checksum mismatch",
+				tool:    config.Tool{Version: "2", URL: server.URL + "/gen-linux", SHA256: map[string]string{"linux-amd64": checksum([]byte("other"))}},
+				wantErr: "failed to download tool 'bad': checksum mismatch",
+			},
+			{
+				name:    "executable not in archive",
+				tool:    config.Tool{Version: "1.2.0", URL: server.URL + "/lint/v1.2.0/lint-linux-amd64.tar.gz"},
+				wantErr: "tool 'bad' has no executable bad in its download, set bin to its path",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := installer.Install(ctx, "bad", tt.tool)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.False(t, installer.Installed("bad", tt.tool))
+			})
+		}
+	})
+}
+
+func TestEntryPath(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := entryPath(dir, "bin/tool")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "bin", "tool"), path)
+
+	_, err = entryPath(dir, "../escape")
+	assert.ErrorContains(t, err, "archive entry '../escape' is outside of the archive")
+}
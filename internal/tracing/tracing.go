@@ -0,0 +1,300 @@
+// Package tracing records the commands, dependencies, hooks and tasks of a run as
+// OpenTelemetry spans and exports them to a collector with OTLP over HTTP, in the
+// JSON encoding.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointVariable is the environment variable with the URL of the OTLP collector.
+// Tracing is disabled when it is not set.
+const EndpointVariable = "YXA_OTEL_ENDPOINT"
+
+// HeadersVariable is the standard OpenTelemetry variable with headers sent to the
+// collector, e.g. for authentication: key1=value1,key2=value2
+const HeadersVariable = "OTEL_EXPORTER_OTLP_HEADERS"
+
+// tracesPath is the path of the OTLP traces endpoint of a collector
+const tracesPath = "/v1/traces"
+
+// Status codes of OTLP spans
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// spanKindInternal is the OTLP kind of spans that are neither client nor server
+const spanKindInternal = 1
+
+// Attribute is a key and a string or integer value of a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer collects the spans of a run until they are exported. A nil Tracer
+// records nothing, so callers do not need to check whether tracing is enabled.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	resource []Attribute
+	traceID  string
+	now      func() time.Time
+
+	mu    sync.Mutex
+	spans []*Span // Ended spans, in the order they ended
+}
+
+// Span is an operation of a run. A nil Span is a no-op.
+type Span struct {
+	tracer   *Tracer
+	id       string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	err      string
+}
+
+// New creates a tracer that exports to the collector at endpoint. The traces path
+// is added to an endpoint without a path. resource describes the source of the
+// spans, service.name is always set to yxa.
+func New(endpoint string, headers map[string]string, resource ...Attribute) (*Tracer, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s '%s': expected a URL such as http://localhost:4318", EndpointVariable, endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	return &Tracer{
+		endpoint: u.String(),
+		headers:  headers,
+		resource: append([]Attribute{String("service.name", "yxa")}, resource...),
+		traceID:  randomID(16),
+		now:      time.Now,
+	}, nil
+}
+
+// FromEnv creates a tracer from EndpointVariable and HeadersVariable, looked up with
+// getenv. It returns nil if no endpoint is set.
+func FromEnv(getenv func(string) string, resource ...Attribute) (*Tracer, error) {
+	endpoint := getenv(EndpointVariable)
+	if endpoint == "" {
+		return nil, nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(getenv(HeadersVariable), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		// Values are URL encoded, as in the OpenTelemetry specification
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return New(endpoint, headers, resource...)
+}
+
+// TraceID returns the ID of the trace the spans of the tracer belong to
+func (t *Tracer) TraceID() string {
+	if t == nil {
+		return ""
+	}
+	return t.traceID
+}
+
+// Start starts a span, as a child of parent if it is not nil
+func (t *Tracer) Start(name string, parent *Span, attrs ...Attribute) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{tracer: t, id: randomID(8), name: name, start: t.now(), attrs: attrs}
+	if parent != nil {
+		s.parentID = parent.id
+	}
+	return s
+}
+
+// End ends the span, failed if err is not nil. The duration is added as the
+// yxa.duration_ms attribute.
+func (s *Span) End(err error, attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.end = s.tracer.now()
+	s.attrs = append(s.attrs, attrs...)
+	s.attrs = append(s.attrs, Int("yxa.duration_ms", s.end.Sub(s.start).Milliseconds()))
+	if err != nil {
+		s.err = err.Error()
+	}
+
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Export sends the ended spans to the collector and forgets them
+func (t *Tracer) Export(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to export traces to %s: %w", t.endpoint, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export traces to %s: unexpected status %d: %s", t.endpoint, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// OTLP JSON encoding of an ExportTraceServiceRequest
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+	}
+)
+
+// request returns the export request of the spans
+func (t *Tracer) request(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		encoded[i] = otlpSpan{
+			TraceID:           t.traceID,
+			SpanID:            s.id,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attrs),
+			Status:            otlpStatus{Code: statusUnset},
+		}
+		if s.err != "" {
+			encoded[i].Status = otlpStatus{Code: statusError, Message: s.err}
+		}
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes(t.resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "yxa"}, Spans: encoded}},
+	}}}
+}
+
+// encodeAttributes converts attributes to OTLP, integers are encoded as strings
+func encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, a := range attrs {
+		var value otlpValue
+		switch v := a.Value.(type) {
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		encoded = append(encoded, otlpAttribute{Key: a.Key, Value: value})
+	}
+	return encoded
+}
+
+// randomID returns n random bytes in hex, the format of trace and span IDs
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	tracer, err := FromEnv(getenv)
+	require.NoError(t, err)
+	assert.Nil(t, tracer)
+
+	env[EndpointVariable] = "http://localhost:4318"
+	env[HeadersVariable] = "Authorization=Bearer%20token, x-team = build,invalid"
+	tracer, err = FromEnv(getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracer.endpoint)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token", "x-team": "build"}, tracer.headers)
+	assert.Len(t, tracer.TraceID(), 32)
+
+	env[EndpointVariable] = "https://collector.example.com/otlp/v1/traces"
+	tracer, err = FromEnv(getenv)
+	require.NoError(t, err)
+	assert.Equal(t, "https://collector.example.com/otlp/v1/traces", tracer.endpoint)
+
+	env[EndpointVariable] = "localhost:4318"
+	_, err = FromEnv(getenv)
+	assert.EqualError(t, err, "invalid YXA_OTEL_ENDPOINT 'localhost:4318': expected a URL such as http://localhost:4318")
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("build", nil)
+	assert.Nil(t, span)
+	span.End(nil)
+	assert.Empty(t, tracer.TraceID())
+	assert.NoError(t, tracer.Export(context.Background()))
+}
+
+func TestExport(t *testing.T) {
+	var req otlpRequest
+	var header http.Header
+	status := http.StatusOK
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		header = r.Header
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	tracer, err := New(server.URL, map[string]string{"x-api-key": "secret"}, String("yxa.project", "app"))
+	require.NoError(t, err)
+	clock := time.Unix(1700000000, 0)
+	tracer.now = func() time.Time {
+		clock = clock.Add(250 * time.Millisecond)
+		return clock
+	}
+
+	// Nothing is sent without spans
+	require.NoError(t, tracer.Export(context.Background()))
+	assert.Equal(t, 0, requests)
+
+	build := tracer.Start("build", nil, String("yxa.command", "build"))
+	task := tracer.Start("build: task 1", build, Int("yxa.task", 1))
+	task.End(errors.New("exit status 2"), Int("process.exit.code", 2))
+	build.End(nil)
+	require.NoError(t, tracer.Export(context.Background()))
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, "secret", header.Get("x-api-key"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	require.Len(t, req.ResourceSpans, 1)
+	resource := req.ResourceSpans[0]
+	assert.Equal(t, encodeAttributes([]Attribute{String("service.name", "yxa"), String("yxa.project", "app")}), resource.Resource.Attributes)
+	require.Len(t, resource.ScopeSpans, 1)
+	assert.Equal(t, "yxa", resource.ScopeSpans[0].Scope.Name)
+
+	spans := resource.ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	taskSpan, buildSpan := spans[0], spans[1]
+	assert.Equal(t, "build: task 1", taskSpan.Name)
+	assert.Equal(t, tracer.TraceID(), taskSpan.TraceID)
+	assert.Equal(t, buildSpan.SpanID, taskSpan.ParentSpanID)
+	assert.Len(t, taskSpan.SpanID, 16)
+	assert.Equal(t, otlpStatus{Code: statusError, Message: "exit status 2"}, taskSpan.Status)
+	assert.Equal(t, encodeAttributes([]Attribute{Int("yxa.task", 1), Int("process.exit.code", 2), Int("yxa.duration_ms", 250)}), taskSpan.Attributes)
+	assert.Equal(t, "1700000000500000000", taskSpan.StartTimeUnixNano)
+	assert.Equal(t, "1700000000750000000", taskSpan.EndTimeUnixNano)
+
+	assert.Equal(t, "build", buildSpan.Name)
+	assert.Empty(t, buildSpan.ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: statusUnset}, buildSpan.Status)
+	assert.Equal(t, encodeAttributes([]Attribute{String("yxa.command", "build"), Int("yxa.duration_ms", 750)}), buildSpan.Attributes)
+
+	// Exported spans are not sent again
+	require.NoError(t, tracer.Export(context.Background()))
+	assert.Equal(t, 1, requests)
+
+	status = http.StatusBadRequest
+	tracer.Start("test", nil).End(nil)
+	err = tracer.Export(context.Background())
+	assert.EqualError(t, err, "failed to export traces to "+server.URL+"/v1/traces: unexpected status 400: ")
+}
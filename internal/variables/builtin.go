@@ -0,0 +1,17 @@
+package variables
+
+// Names of the built-in variables that describe the execution context.
+// They can be referenced from run lines, tasks, hooks and conditions like any
+// other variable, but are overridden by params, config variables and .env values.
+const (
+	BuiltinConfigDir   = "YXA_CONFIG_DIR"   // Absolute directory of the loaded config file
+	BuiltinProjectName = "YXA_PROJECT_NAME" // Value of the top-level 'name' field
+	BuiltinProfile     = "YXA_PROFILE"      // Name of the applied profile, if any
+	BuiltinCommand     = "YXA_COMMAND"      // Name of the command being executed (parent:sub for subcommands)
+	BuiltinRunID       = "YXA_RUN_ID"       // Unique identifier for the current invocation
+	BuiltinTimestamp   = "YXA_TIMESTAMP"    // UTC start time of the current invocation
+)
+
+// TimestampFormat is the layout used for the YXA_TIMESTAMP variable.
+// It is a compact ISO 8601 form that is safe to use in file names.
+const TimestampFormat = "20060102T150405Z"
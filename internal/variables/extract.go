@@ -0,0 +1,152 @@
+package variables
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extract parses output as JSON or YAML and returns the value at path. The path
+// supports a subset of JSONPath: $ for the root, .name and ['name'] for map keys
+// and [index] for list items, where negative indexes count from the end.
+// Scalars are returned as written, maps and lists are returned as JSON.
+func Extract(output, path string) (string, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(output), &doc); err != nil {
+		return "", fmt.Errorf("output is not valid JSON or YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return "", fmt.Errorf("output is empty")
+	}
+
+	node := doc.Content[0]
+	for i, segment := range segments {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		next, err := selectChild(node, segment)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", formatPath(segments[:i+1]), err)
+		}
+		node = next
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// pathSegment is a map key or a list index
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath splits a path such as $.items[0]['name'] into segments
+func parsePath(path string) ([]pathSegment, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segments []pathSegment
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path '%s': empty key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ]", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path '%s': '%s' is not an index", path, inner)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid path '%s': expected . or [ at '%s'", path, rest)
+		}
+	}
+
+	return segments, nil
+}
+
+// selectChild returns the child of node selected by segment
+func selectChild(node *yaml.Node, segment pathSegment) (*yaml.Node, error) {
+	if segment.isIndex {
+		if node.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("not a list")
+		}
+		index := segment.index
+		if index < 0 {
+			index += len(node.Content)
+		}
+		if index < 0 || index >= len(node.Content) {
+			return nil, fmt.Errorf("index out of range (length %d)", len(node.Content))
+		}
+		return node.Content[index], nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("not a map")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == segment.key {
+			return node.Content[i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("key not found")
+}
+
+// formatPath formats segments back into a path for error messages
+func formatPath(segments []pathSegment) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range segments {
+		if segment.isIndex {
+			fmt.Fprintf(&b, "[%d]", segment.index)
+		} else {
+			b.WriteString("." + segment.key)
+		}
+	}
+	return b.String()
+}
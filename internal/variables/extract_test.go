@@ -0,0 +1,127 @@
+package variables
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	jsonOutput := `{
+  "items": [
+    {"metadata": {"name": "web-1", "labels": {"app.kubernetes.io/name": "web"}}, "replicas": 3, "version": 1.10},
+    {"metadata": {"name": "web-2"}, "ready": null}
+  ]
+}`
+	yamlOutput := "image:\n  tag: v1.2.3\n  ports: [80, 443]\n"
+
+	tests := []struct {
+		name    string
+		output  string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "nested json field",
+			output: jsonOutput,
+			path:   "$.items[0].metadata.name",
+			want:   "web-1",
+		},
+		{
+			name:   "negative index",
+			output: jsonOutput,
+			path:   "$.items[-1].metadata.name",
+			want:   "web-2",
+		},
+		{
+			name:   "quoted key",
+			output: jsonOutput,
+			path:   "$.items[0].metadata.labels['app.kubernetes.io/name']",
+			want:   "web",
+		},
+		{
+			name:   "number kept as written",
+			output: jsonOutput,
+			path:   "$.items[0].version",
+			want:   "1.10",
+		},
+		{
+			name:   "null is empty",
+			output: jsonOutput,
+			path:   "$.items[1].ready",
+			want:   "",
+		},
+		{
+			name:   "yaml output without $",
+			output: yamlOutput,
+			path:   ".image.tag",
+			want:   "v1.2.3",
+		},
+		{
+			name:   "list returned as json",
+			output: yamlOutput,
+			path:   "$.image.ports",
+			want:   "[80,443]",
+		},
+		{
+			name:   "root map returned as json",
+			output: `{"a": "b"}`,
+			path:   "$",
+			want:   `{"a":"b"}`,
+		},
+		{
+			name:    "missing key",
+			output:  jsonOutput,
+			path:    "$.items[0].spec.name",
+			wantErr: "$.items[0].spec: key not found",
+		},
+		{
+			name:    "index out of range",
+			output:  jsonOutput,
+			path:    "$.items[5]",
+			wantErr: "index out of range (length 2)",
+		},
+		{
+			name:    "index into map",
+			output:  jsonOutput,
+			path:    "$[0]",
+			wantErr: "not a list",
+		},
+		{
+			name:    "invalid path",
+			output:  jsonOutput,
+			path:    "$.items[first]",
+			wantErr: "'first' is not an index",
+		},
+		{
+			name:    "invalid output",
+			output:  "{not: [valid",
+			path:    "$.not",
+			wantErr: "not valid JSON or YAML",
+		},
+		{
+			name:    "empty output",
+			output:  "",
+			path:    "$.x",
+			wantErr: "output is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(tt.output, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Extract() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package variables
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// globPattern matches glob helpers: $(glob PATTERN...). Other $(...) are shell
+// command substitutions and are left to the shell.
+var globPattern = regexp.MustCompile(`\$\(glob\s+([^)]*)\)`)
+
+// expandGlobs replaces every $(glob PATTERN...) in input with the files matching
+// the patterns, quoted for the shell. Helpers with a malformed pattern are left
+// untouched so the mistake is visible.
+func (r *Resolver) expandGlobs(input string) string {
+	if !strings.Contains(input, "$(glob") {
+		return input
+	}
+	return globPattern.ReplaceAllStringFunc(input, func(match string) string {
+		var words []string
+		for _, pattern := range strings.Fields(globPattern.FindStringSubmatch(match)[1]) {
+			matches, err := Glob(pattern)
+			if err != nil {
+				return match
+			}
+			for _, m := range matches {
+				words = append(words, NormalizePath(m))
+			}
+		}
+		return strings.Join(words, " ")
+	})
+}
+
+// Glob returns the paths matching pattern in lexical order, or none if nothing
+// matches. Patterns use / as separator on every platform and support the syntax of
+// path.Match plus ** for any number of directories, e.g. src/**/*.proto.
+func Glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Walk from the longest leading part of the pattern without wildcards
+	var base []string
+	for len(segments) > 0 && !strings.ContainsAny(segments[0], "*?[\\") {
+		base = append(base, segments[0])
+		segments = segments[1:]
+	}
+	root := strings.Join(base, "/")
+	if len(base) == 1 && base[0] == "" {
+		root = "/"
+	}
+	if len(segments) == 0 {
+		// Without wildcards the pattern matches only the path itself
+		if _, err := os.Lstat(filepath.FromSlash(root)); err != nil {
+			return nil, nil
+		}
+		return []string{filepath.FromSlash(root)}, nil
+	}
+
+	walkRoot := filepath.FromSlash(root)
+	if walkRoot == "" {
+		walkRoot = "."
+	}
+	var matches []string
+	err := filepath.WalkDir(walkRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories do not match anything, like in a shell
+			if p == walkRoot || errors.Is(err, fs.ErrPermission) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if p == walkRoot {
+			return nil
+		}
+		rel, err := filepath.Rel(walkRoot, p)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchSegments reports whether the segments of a path match those of a pattern,
+// where ** matches any number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	// The pattern was validated by Glob, so Match cannot fail
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
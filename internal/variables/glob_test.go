@@ -0,0 +1,107 @@
+package variables
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// globTree creates files below a temporary directory and changes into it
+func globTree(t *testing.T, files ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+func TestGlob(t *testing.T) {
+	globTree(t, "proto/a.proto", "proto/v1/b.proto", "proto/v1/deep/c.proto", "proto/readme.md", "main.go")
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single directory", pattern: "proto/*.proto", want: []string{"proto/a.proto"}},
+		{name: "any depth", pattern: "proto/**/*.proto", want: []string{"proto/a.proto", "proto/v1/b.proto", "proto/v1/deep/c.proto"}},
+		{name: "leading double star", pattern: "**/c.proto", want: []string{"proto/v1/deep/c.proto"}},
+		{name: "character class", pattern: "proto/[r]*", want: []string{"proto/readme.md"}},
+		{name: "no wildcard", pattern: "main.go", want: []string{"main.go"}},
+		{name: "no match", pattern: "src/**/*.go", want: nil},
+		{name: "malformed", pattern: "proto/[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Glob(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Glob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var want []string
+			for _, path := range tt.want {
+				want = append(want, filepath.FromSlash(path))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Glob() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveGlob(t *testing.T) {
+	globTree(t, "src/a.proto", "src/My Types/b.proto", "docs/intro.md")
+
+	r := NewResolver().
+		WithConfigVars(map[string]string{"SRC": "src"}).
+		WithSystemEnvVar(false)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "quotes matches for posix shell",
+			input: "protoc $(glob $SRC/**/*.proto)",
+			want:  "protoc 'src/My Types/b.proto' src/a.proto",
+		},
+		{
+			name:  "several patterns",
+			input: "wc $(glob docs/*.md src/*.proto)",
+			want:  "wc docs/intro.md src/a.proto",
+		},
+		{
+			name:  "no match expands to nothing",
+			input: "lint $(glob **/*.go)",
+			want:  "lint ",
+		},
+		{
+			name:  "malformed pattern is left untouched",
+			input: "ls $(glob src/[)",
+			want:  "ls $(glob src/[)",
+		},
+		{
+			name:  "shell command substitution is left to the shell",
+			input: "echo $(uname -s)",
+			want:  "echo $(uname -s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Resolve(tt.input); got != tt.want {
+				t.Errorf("Resolver.Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package variables
+
+import (
+	"strings"
+)
+
+// modifiers maps the name used in ${VAR:modifier} to the function that transforms the value
+var modifiers = map[string]func(value string) string{
+	"path": NormalizePath,
+}
+
+// NormalizePath converts the path separators in value to the ones of sh, which
+// runs the commands, and quotes the result if it contains characters the shell
+// would otherwise interpret (such as spaces).
+func NormalizePath(value string) string {
+	return quoteForPosix(strings.ReplaceAll(value, `\`, "/"))
+}
+
+// posixSpecialChars are the characters that need quoting in a POSIX shell word
+const posixSpecialChars = " \t\n'\"`$&|;<>()*?[]#~!{}\\"
+
+// quoteForPosix wraps value in single quotes if it contains special characters
+func quoteForPosix(value string) string {
+	if value == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(value, posixSpecialChars) {
+		return value
+	}
+	// Single quotes cannot be escaped inside single quotes, so close, escape and reopen
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
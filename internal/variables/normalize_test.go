@@ -0,0 +1,88 @@
+package variables
+
+import (
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "posix plain path",
+			value: "build/bin",
+			want:  "build/bin",
+		},
+		{
+			name:  "posix converts backslashes",
+			value: `C:\work\build`,
+			want:  "C:/work/build",
+		},
+		{
+			name:  "posix quotes spaces",
+			value: "/home/me/My Projects",
+			want:  "'/home/me/My Projects'",
+		},
+		{
+			name:  "posix escapes single quotes",
+			value: "/tmp/it's here",
+			want:  `'/tmp/it'\''s here'`,
+		},
+		{
+			name:  "posix empty value",
+			value: "",
+			want:  "''",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizePath(tt.value); got != tt.want {
+				t.Errorf("NormalizePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveModifiers(t *testing.T) {
+	r := NewResolver().
+		WithConfigVars(map[string]string{"OUT_DIR": `dist\My App`}).
+		WithSystemEnvVar(false)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "path modifier for posix shell",
+			input: "cp app ${OUT_DIR:path}",
+			want:  "cp app 'dist/My App'",
+		},
+		{
+			name:  "unknown modifier is left untouched",
+			input: "echo ${OUT_DIR:upper}",
+			want:  "echo ${OUT_DIR:upper}",
+		},
+		{
+			name:  "missing variable with modifier is left untouched",
+			input: "echo ${MISSING:path}",
+			want:  "echo ${MISSING:path}",
+		},
+		{
+			name:  "shell default expansion is not treated as a modifier",
+			input: "echo ${OUT_DIR:-fallback}",
+			want:  "echo ${OUT_DIR:-fallback}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Resolve(tt.input); got != tt.want {
+				t.Errorf("Resolver.Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,24 +3,66 @@ package variables
 import (
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 )
 
+// Names of the variable sources, as reported by Lookup
+const (
+	SourceOverride = "override"
+	SourceRegister = "register"
+	SourceParam    = "param"
+	SourceCommand  = "command"
+	SourceConfig   = "config"
+	SourceSecret   = "encrypted"
+	SourceEnvFile  = ".env"
+	SourceBuiltin  = "builtin"
+	SourceSystem   = "system"
+)
+
+// ResolvedVariable is a variable with its effective value and the source it came from
+type ResolvedVariable struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// Substitution describes how a single variable reference was resolved, see
+// Resolver.Trace
+type Substitution struct {
+	Reference string // Reference as written, e.g. $OUT or ${OUT:path}
+	Name      string // Name of the variable
+	Source    string // Source the value was taken from, empty if the variable is not set
+	Value     string // Value the reference was replaced with, after applying the modifier
+	Replaced  bool   // Whether the reference was replaced, unset variables and unknown modifiers are left as they are
+}
+
 // Resolver handles variable resolution from multiple sources
 type Resolver struct {
 	// Sources of variables in order of priority (highest first)
-	ConfigVars   map[string]string // Variables from config file
-	EnvFileVars  map[string]string // Variables from .env file
-	ParamVars    map[string]string // Variables from command parameters
-	SystemEnvVar bool              // Whether to check system environment variables
+	OverrideVars map[string]string  // Variables set for the invocation (e.g. --set KEY=VALUE)
+	RegisterVars map[string]string  // Variables extracted from the output of earlier commands
+	CommandVars  map[string]string  // Variables of the command being run
+	ConfigVars   map[string]string  // Variables from config file
+	SecretVars   map[string]string  // Variables decrypted from the encrypted variables file of the config
+	EnvFileVars  map[string]string  // Variables from .env file
+	ParamVars    map[string]string  // Variables from command parameters
+	BuiltinVars  map[string]string  // Built-in variables describing the execution context
+	SystemEnvVar bool               // Whether to check system environment variables
+	Trace        func(Substitution) // Called for every reference Resolve finds, nil to disable
 }
 
 // NewResolver creates a new variable resolver
 func NewResolver() *Resolver {
 	return &Resolver{
+		OverrideVars: make(map[string]string),
+		RegisterVars: make(map[string]string),
+		CommandVars:  make(map[string]string),
 		ConfigVars:   make(map[string]string),
+		SecretVars:   make(map[string]string),
 		EnvFileVars:  make(map[string]string),
 		ParamVars:    make(map[string]string),
+		BuiltinVars:  make(map[string]string),
 		SystemEnvVar: true,
 	}
 }
@@ -34,6 +76,26 @@ func (r *Resolver) WithConfigVars(vars map[string]string) *Resolver {
 	return r
 }
 
+// WithCommandVars adds the variables of a command to the resolver. They shadow the
+// config variables, but not parameters.
+func (r *Resolver) WithCommandVars(vars map[string]string) *Resolver {
+	// Range over map is safe even if map is nil
+	for k, v := range vars {
+		r.CommandVars[k] = v
+	}
+	return r
+}
+
+// WithSecretVars adds the decrypted encrypted variables to the resolver. They are
+// shadowed by the config variables.
+func (r *Resolver) WithSecretVars(vars map[string]string) *Resolver {
+	// Range over map is safe even if map is nil
+	for k, v := range vars {
+		r.SecretVars[k] = v
+	}
+	return r
+}
+
 // WithEnvFileVars adds .env file variables to the resolver
 func (r *Resolver) WithEnvFileVars(vars map[string]string) *Resolver {
 	// Range over map is safe even if map is nil
@@ -43,6 +105,24 @@ func (r *Resolver) WithEnvFileVars(vars map[string]string) *Resolver {
 	return r
 }
 
+// WithOverrideVars adds invocation-level override variables to the resolver
+func (r *Resolver) WithOverrideVars(vars map[string]string) *Resolver {
+	// Range over map is safe even if map is nil
+	for k, v := range vars {
+		r.OverrideVars[k] = v
+	}
+	return r
+}
+
+// WithRegisterVars adds variables registered from command output to the resolver
+func (r *Resolver) WithRegisterVars(vars map[string]string) *Resolver {
+	// Range over map is safe even if map is nil
+	for k, v := range vars {
+		r.RegisterVars[k] = v
+	}
+	return r
+}
+
 // WithParamVars adds parameter variables to the resolver
 func (r *Resolver) WithParamVars(vars map[string]string) *Resolver {
 	// Range over map is safe even if map is nil
@@ -52,57 +132,99 @@ func (r *Resolver) WithParamVars(vars map[string]string) *Resolver {
 	return r
 }
 
+// WithBuiltinVars adds built-in context variables to the resolver
+func (r *Resolver) WithBuiltinVars(vars map[string]string) *Resolver {
+	// Range over map is safe even if map is nil
+	for k, v := range vars {
+		r.BuiltinVars[k] = v
+	}
+	return r
+}
+
 // WithSystemEnvVar sets whether to check system environment variables
 func (r *Resolver) WithSystemEnvVar(check bool) *Resolver {
 	r.SystemEnvVar = check
 	return r
 }
 
+// WithTrace sets a function that is called for every variable reference that
+// Resolve finds, to debug where values come from
+func (r *Resolver) WithTrace(trace func(Substitution)) *Resolver {
+	r.Trace = trace
+	return r
+}
+
+// referencePattern matches variable references: $VAR, ${VAR} or ${VAR:modifier}
+var referencePattern = regexp.MustCompile(`\$(\w+|\{\w+(?::\w+)?\})`)
+
 // Resolve resolves variables in the given string
 func (r *Resolver) Resolve(input string) string {
 	if input == "" {
 		return input
 	}
 
-	// Define regex pattern for variables: $VAR or ${VAR}
-	pattern := regexp.MustCompile(`\$(\w+|\{\w+\})`)
-
 	// Replace all occurrences
-	result := pattern.ReplaceAllStringFunc(input, func(match string) string {
+	result := referencePattern.ReplaceAllStringFunc(input, func(match string) string {
 		// Extract variable name (remove $ and {} if present)
 		varName := match[1:] // Remove $
 		if strings.HasPrefix(varName, "{") && strings.HasSuffix(varName, "}") {
 			varName = varName[1 : len(varName)-1] // Remove { and }
 		}
 
-		// Try to get value from different sources in order of priority
-		// 1. Parameter variables (highest priority)
-		if value, ok := r.ParamVars[varName]; ok {
-			return value
-		}
-
-		// 2. Config variables
-		if value, ok := r.ConfigVars[varName]; ok {
-			return value
+		// Split off an optional modifier (e.g. ${VAR:path})
+		modifier := ""
+		if idx := strings.Index(varName, ":"); idx >= 0 {
+			varName, modifier = varName[:idx], varName[idx+1:]
 		}
 
-		// 3. Environment variables from .env file
-		if value, ok := r.EnvFileVars[varName]; ok {
-			return value
+		value, source, ok := r.Lookup(varName)
+		if !ok {
+			// If variable not found, return the original match
+			r.trace(Substitution{Reference: match, Name: varName})
+			return match
 		}
 
-		// 4. System environment variables (if enabled)
-		if r.SystemEnvVar {
-			if value, ok := os.LookupEnv(varName); ok {
-				return value
+		if modifier != "" {
+			// Unknown modifiers leave the reference untouched so the mistake is visible
+			apply, ok := modifiers[modifier]
+			if !ok {
+				r.trace(Substitution{Reference: match, Name: varName, Source: source})
+				return match
 			}
+			value = apply(value)
 		}
-
-		// If variable not found, return the original match
-		return match
+		r.trace(Substitution{Reference: match, Name: varName, Source: source, Value: value, Replaced: true})
+		return value
 	})
 
-	return result
+	// Globs are expanded last, so their patterns can use variables
+	return r.expandGlobs(result)
+}
+
+// trace reports a substitution to Trace, if it is set
+func (r *Resolver) trace(s Substitution) {
+	if r.Trace != nil {
+		r.Trace(s)
+	}
+}
+
+// References returns the names of the variables referenced in the given string, in
+// order of first appearance. Positional shell parameters such as $1 are ignored.
+func References(input string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range referencePattern.FindAllStringSubmatch(input, -1) {
+		name := strings.Trim(match[1], "{}")
+		if idx := strings.Index(name, ":"); idx >= 0 {
+			name = name[:idx]
+		}
+		if seen[name] || name[0] >= '0' && name[0] <= '9' {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
 }
 
 // ResolveAll resolves variables in all the given strings
@@ -116,28 +238,88 @@ func (r *Resolver) ResolveAll(inputs ...string) []string {
 
 // GetVariableValue gets the value of a variable from all sources
 func (r *Resolver) GetVariableValue(varName string) (string, bool) {
+	value, _, ok := r.Lookup(varName)
+	return value, ok
+}
+
+// Lookup gets the value of a variable together with the name of the source it was taken from
+func (r *Resolver) Lookup(varName string) (value, source string, found bool) {
 	// Check sources in order of priority
-	// 1. Parameter variables (highest priority)
+	// 1. Override variables (highest priority)
+	if value, ok := r.OverrideVars[varName]; ok {
+		return value, SourceOverride, true
+	}
+
+	// 2. Variables registered from command output
+	if value, ok := r.RegisterVars[varName]; ok {
+		return value, SourceRegister, true
+	}
+
+	// 3. Parameter variables
 	if value, ok := r.ParamVars[varName]; ok {
-		return value, true
+		return value, SourceParam, true
 	}
 
-	// 2. Config variables
+	// 4. Variables of the command
+	if value, ok := r.CommandVars[varName]; ok {
+		return value, SourceCommand, true
+	}
+
+	// 5. Config variables
 	if value, ok := r.ConfigVars[varName]; ok {
-		return value, true
+		return value, SourceConfig, true
+	}
+
+	// 6. Decrypted encrypted variables
+	if value, ok := r.SecretVars[varName]; ok {
+		return value, SourceSecret, true
 	}
 
-	// 3. Environment variables from .env file
+	// 7. Environment variables from .env file
 	if value, ok := r.EnvFileVars[varName]; ok {
-		return value, true
+		return value, SourceEnvFile, true
+	}
+
+	// 8. Built-in context variables
+	if value, ok := r.BuiltinVars[varName]; ok {
+		return value, SourceBuiltin, true
 	}
 
-	// 4. System environment variables (if enabled)
+	// 9. System environment variables (if enabled)
 	if r.SystemEnvVar {
 		if value, ok := os.LookupEnv(varName); ok {
-			return value, true
+			return value, SourceSystem, true
 		}
 	}
 
-	return "", false
+	return "", "", false
+}
+
+// Variables returns every variable known to the resolver, sorted by name, with the
+// effective value and its source. System environment variables are only included
+// when includeSystem is true.
+func (r *Resolver) Variables(includeSystem bool) []ResolvedVariable {
+	names := make(map[string]bool)
+	for _, vars := range []map[string]string{r.OverrideVars, r.RegisterVars, r.ParamVars, r.CommandVars, r.ConfigVars, r.SecretVars, r.EnvFileVars, r.BuiltinVars} {
+		for name := range vars {
+			names[name] = true
+		}
+	}
+	if includeSystem && r.SystemEnvVar {
+		for _, entry := range os.Environ() {
+			if idx := strings.Index(entry, "="); idx > 0 {
+				names[entry[:idx]] = true
+			}
+		}
+	}
+
+	result := make([]ResolvedVariable, 0, len(names))
+	for name := range names {
+		value, source, _ := r.Lookup(name)
+		result = append(result, ResolvedVariable{Name: name, Value: value, Source: source})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
 }
@@ -2,6 +2,7 @@ package variables
 
 import (
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -22,6 +23,8 @@ func TestResolver_Resolve(t *testing.T) {
 		configVars  map[string]string
 		envFileVars map[string]string
 		paramVars   map[string]string
+		builtinVars map[string]string
+		overrides   map[string]string
 		systemEnv   bool
 		want        string
 	}{
@@ -84,6 +87,19 @@ func TestResolver_Resolve(t *testing.T) {
 			input: "hello $NOT_FOUND",
 			want:  "hello $NOT_FOUND",
 		},
+		{
+			name:        "builtin variable",
+			input:       "running $YXA_COMMAND",
+			builtinVars: map[string]string{BuiltinCommand: "build"},
+			want:        "running build",
+		},
+		{
+			name:        "config variable overrides builtin",
+			input:       "$YXA_COMMAND",
+			configVars:  map[string]string{BuiltinCommand: "custom"},
+			builtinVars: map[string]string{BuiltinCommand: "build"},
+			want:        "custom",
+		},
 		{
 			name:        "priority order",
 			input:       "$PRIORITY",
@@ -93,6 +109,13 @@ func TestResolver_Resolve(t *testing.T) {
 			systemEnv:   true,
 			want:        "param", // Param vars have highest priority
 		},
+		{
+			name:      "override beats param",
+			input:     "$PRIORITY",
+			paramVars: map[string]string{"PRIORITY": "param"},
+			overrides: map[string]string{"PRIORITY": "override"},
+			want:      "override",
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,6 +130,12 @@ func TestResolver_Resolve(t *testing.T) {
 			if tt.paramVars != nil {
 				r.WithParamVars(tt.paramVars)
 			}
+			if tt.builtinVars != nil {
+				r.WithBuiltinVars(tt.builtinVars)
+			}
+			if tt.overrides != nil {
+				r.WithOverrideVars(tt.overrides)
+			}
 			r.WithSystemEnvVar(tt.systemEnv)
 
 			if got := r.Resolve(tt.input); got != tt.want {
@@ -134,6 +163,24 @@ func TestResolver_ResolveAll(t *testing.T) {
 	}
 }
 
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"echo hello", nil},
+		{"go build -o $OUT/${name}", []string{"OUT", "name"}},
+		{"cd ${DIR:path} && ls $DIR $OUT", []string{"DIR", "OUT"}},
+		{"awk '{print $1}' $FILE", []string{"FILE"}},
+	}
+
+	for _, tt := range tests {
+		if got := References(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("References(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestResolver_GetVariableValue(t *testing.T) {
 	// Set up environment variable for testing
 	if err := os.Setenv("TEST_ENV_VAR", "env_value"); err != nil {
@@ -200,3 +247,77 @@ func TestResolver_GetVariableValue(t *testing.T) {
 		})
 	}
 }
+
+func TestResolver_Variables(t *testing.T) {
+	r := NewResolver().
+		WithConfigVars(map[string]string{"SHARED": "config", "CONFIG_ONLY": "c"}).
+		WithEnvFileVars(map[string]string{"ENV_ONLY": "e"}).
+		WithParamVars(map[string]string{"SHARED": "param"}).
+		WithBuiltinVars(map[string]string{BuiltinCommand: "build"})
+
+	got := r.Variables(false)
+	want := []ResolvedVariable{
+		{Name: "CONFIG_ONLY", Value: "c", Source: SourceConfig},
+		{Name: "ENV_ONLY", Value: "e", Source: SourceEnvFile},
+		{Name: "SHARED", Value: "param", Source: SourceParam},
+		{Name: BuiltinCommand, Value: "build", Source: SourceBuiltin},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Resolver.Variables() returned %d items, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Resolver.Variables()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResolver_CommandVars(t *testing.T) {
+	r := NewResolver().
+		WithConfigVars(map[string]string{"TARGET": "config", "REGION": "eu"}).
+		WithCommandVars(map[string]string{"TARGET": "command", "LEVEL": "debug"}).
+		WithParamVars(map[string]string{"LEVEL": "param"})
+
+	tests := []struct {
+		varName string
+		want    string
+		source  string
+	}{
+		{varName: "TARGET", want: "command", source: SourceCommand},
+		{varName: "REGION", want: "eu", source: SourceConfig},
+		{varName: "LEVEL", want: "param", source: SourceParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.varName, func(t *testing.T) {
+			value, source, found := r.Lookup(tt.varName)
+			if !found || value != tt.want || source != tt.source {
+				t.Errorf("Resolver.Lookup(%q) = %q, %q, %v, want %q, %q", tt.varName, value, source, found, tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestResolver_Trace(t *testing.T) {
+	var got []Substitution
+	r := NewResolver().
+		WithSystemEnvVar(false).
+		WithConfigVars(map[string]string{"OUT": "my dist", "ROOT": "$HOME/app"}).
+		WithParamVars(map[string]string{"TARGET": "linux"}).
+		WithTrace(func(s Substitution) { got = append(got, s) })
+
+	result := r.Resolve("$TARGET ${OUT:path} ${OUT:nope} $ROOT $MISSING")
+	if want := "linux 'my dist' ${OUT:nope} $HOME/app $MISSING"; result != want {
+		t.Errorf("Resolver.Resolve() = %q, want %q", result, want)
+	}
+
+	want := []Substitution{
+		{Reference: "$TARGET", Name: "TARGET", Source: SourceParam, Value: "linux", Replaced: true},
+		{Reference: "${OUT:path}", Name: "OUT", Source: SourceConfig, Value: "'my dist'", Replaced: true},
+		{Reference: "${OUT:nope}", Name: "OUT", Source: SourceConfig},
+		{Reference: "$ROOT", Name: "ROOT", Source: SourceConfig, Value: "$HOME/app", Replaced: true},
+		{Reference: "$MISSING", Name: "MISSING"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolver.Trace got %+v, want %+v", got, want)
+	}
+}
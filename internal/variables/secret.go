@@ -0,0 +1,31 @@
+package variables
+
+import (
+	"strings"
+)
+
+// MaskedValue is shown in place of the value of a secret variable
+const MaskedValue = "***"
+
+// secretNameParts are the name fragments that mark a variable as a secret
+var secretNameParts = []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "API_KEY", "PRIVATE_KEY", "CREDENTIAL", "AUTH"}
+
+// IsSecretName reports whether a variable name looks like it holds a secret
+func IsSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, part := range secretNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskValue returns the value to display for a variable, masking it if the name
+// looks like a secret
+func MaskValue(name, value string) string {
+	if value != "" && IsSecretName(name) {
+		return MaskedValue
+	}
+	return value
+}
@@ -0,0 +1,27 @@
+package variables
+
+import (
+	"testing"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "API_TOKEN", value: "abc", want: MaskedValue},
+		{name: "db_password", value: "abc", want: MaskedValue},
+		{name: "AWS_SECRET_ACCESS_KEY", value: "abc", want: MaskedValue},
+		{name: "BUILD_DIR", value: "./build", want: "./build"},
+		{name: "API_TOKEN", value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskValue(tt.name, tt.value); got != tt.want {
+				t.Errorf("MaskValue(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}